@@ -19,6 +19,7 @@
 package cncdb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -100,6 +101,102 @@ func (c *CNCMySQLHandler) UnsetLiveAttrs(transact *sql.Tx, corpus string) error
 	return err
 }
 
+// DeactivateCorpus marks a corpus record as inactive. The record itself
+// is kept as it may be referenced by other tables.
+func (c *CNCMySQLHandler) DeactivateCorpus(transact *sql.Tx, corpus string) error {
+	_, err := transact.Exec(
+		fmt.Sprintf("UPDATE %s SET active = 0 WHERE name = ?", c.corporaTableName),
+		corpus,
+	)
+	return err
+}
+
+// CorpusRemovalPlan implements corpus.CorpusDataRemover
+func (c *CNCMySQLHandler) CorpusRemovalPlan(
+	corpusID string, corpusInfo *corpus.DBInfo, opts corpus.RemovalOptions,
+) []corpus.RemovedItem {
+	if !opts.Deactivate || corpusInfo == nil || corpusInfo.Active == 0 {
+		return []corpus.RemovedItem{}
+	}
+	return []corpus.RemovedItem{
+		{Kind: "corpusRecord", Target: corpusID, Details: "deactivate"},
+	}
+}
+
+// RemoveCorpusData implements corpus.CorpusDataRemover. It only
+// deactivates the corpus record (if requested).
+func (c *CNCMySQLHandler) RemoveCorpusData(
+	corpusID string, corpusInfo *corpus.DBInfo, opts corpus.RemovalOptions,
+) []corpus.RemovedItem {
+	ans := c.CorpusRemovalPlan(corpusID, corpusInfo, opts)
+	if len(ans) == 0 {
+		return ans
+	}
+	tx, err := c.StartTx()
+	if err != nil {
+		ans[0].Error = err.Error()
+		return ans
+	}
+	if err := c.DeactivateCorpus(tx, corpusID); err != nil {
+		tx.Rollback()
+		ans[0].Error = err.Error()
+		return ans
+	}
+	if err := tx.Commit(); err != nil {
+		ans[0].Error = err.Error()
+		return ans
+	}
+	ans[0].Removed = true
+	return ans
+}
+
+// RenameCorpus changes name of a corpus record
+func (c *CNCMySQLHandler) RenameCorpus(transact *sql.Tx, corpus, newName string) error {
+	_, err := transact.Exec(
+		fmt.Sprintf("UPDATE %s SET name = ? WHERE name = ?", c.corporaTableName),
+		newName, corpus,
+	)
+	return err
+}
+
+// CorpusRenamePlan implements corpus.CorpusRenamer
+func (c *CNCMySQLHandler) CorpusRenamePlan(
+	corpusID, newID string, corpusInfo *corpus.DBInfo,
+) []corpus.RenameStep {
+	if corpusInfo == nil {
+		return []corpus.RenameStep{}
+	}
+	return []corpus.RenameStep{
+		{Kind: "corpusRecord", Source: corpusID, Target: newID},
+	}
+}
+
+// RenameCorpusData implements corpus.CorpusRenamer
+func (c *CNCMySQLHandler) RenameCorpusData(
+	corpusID, newID string, corpusInfo *corpus.DBInfo,
+) []corpus.RenameStep {
+	ans := c.CorpusRenamePlan(corpusID, newID, corpusInfo)
+	if len(ans) == 0 {
+		return ans
+	}
+	tx, err := c.StartTx()
+	if err != nil {
+		ans[0].Error = err.Error()
+		return ans
+	}
+	if err := c.RenameCorpus(tx, corpusID, newID); err != nil {
+		tx.Rollback()
+		ans[0].Error = err.Error()
+		return ans
+	}
+	if err := tx.Commit(); err != nil {
+		ans[0].Error = err.Error()
+		return ans
+	}
+	ans[0].Done = true
+	return ans
+}
+
 func (c *CNCMySQLHandler) UpdateDescription(transact *sql.Tx, corpus, descCs, descEn string) error {
 	var err error
 	if descCs != "" {
@@ -123,8 +220,15 @@ func (c *CNCMySQLHandler) UpdateDescription(transact *sql.Tx, corpus, descCs, de
 }
 
 func (c *CNCMySQLHandler) LoadInfo(corpusID string) (*corpus.DBInfo, error) {
+	return c.LoadInfoContext(context.Background(), corpusID)
+}
+
+// LoadInfoContext is the same as LoadInfo but the query
+// is cancelled once the context is done
+func (c *CNCMySQLHandler) LoadInfoContext(ctx context.Context, corpusID string) (*corpus.DBInfo, error) {
 	var bibLabelStruct, bibLabelAttr, bibIDStruct, bibIDAttr sql.NullString
-	row := c.conn.QueryRow(
+	row := c.conn.QueryRowContext(
+		ctx,
 		fmt.Sprintf(
 			"SELECT c.name, c.active, c.bib_label_struct, c.bib_label_attr, "+
 				" c.bib_id_struct, c.bib_id_attr, c.bib_group_duplicates, c.locale, "+
@@ -171,6 +275,25 @@ func (c *CNCMySQLHandler) LoadInfo(corpusID string) (*corpus.DBInfo, error) {
 
 }
 
+// GetDefaultViewOpts loads KonText default view options for a corpus.
+// In case nothing is set, an empty DefaultViewOpts value is returned.
+func (c *CNCMySQLHandler) GetDefaultViewOpts(corpusID string) (DefaultViewOpts, error) {
+	var ans DefaultViewOpts
+	var data sql.NullString
+	row := c.conn.QueryRow(
+		fmt.Sprintf("SELECT default_view_opts FROM %s WHERE name = ?", c.corporaTableName),
+		corpusID,
+	)
+	if err := row.Scan(&data); err != nil {
+		return ans, err
+	}
+	if !data.Valid || data.String == "" {
+		return ans, nil
+	}
+	err := json.Unmarshal([]byte(data.String), &ans)
+	return ans, err
+}
+
 func (c *CNCMySQLHandler) GetSimpleQueryDefaultAttrs(corpusID string) ([]string, error) {
 	rows, err := c.conn.Query(
 		"SELECT pos_attr FROM kontext_simple_query_default_attrs WHERE corpus_name = ?",
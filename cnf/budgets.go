@@ -0,0 +1,109 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package cnf
+
+import (
+	"fmt"
+	"masm/v3/general/budget"
+	"time"
+)
+
+const (
+	// BudgetStageCNCDB is a stage of loading corpus information
+	// from the CNC database
+	BudgetStageCNCDB = "cncDb"
+
+	// BudgetStageLADB is a stage of querying the liveattrs database
+	BudgetStageLADB = "laDb"
+
+	// BudgetStagePost is a stage of post-processing of obtained data
+	BudgetStagePost = "post"
+
+	// dfltBudgetWriteTimeoutRatio specifies a default total budget
+	// as a ratio of the write timeout of interactive endpoints (there
+	// must be some time left for writing the response)
+	dfltBudgetWriteTimeoutRatio = 0.8
+)
+
+var dfltBudgetShares = map[string]float64{
+	BudgetStageCNCDB: 0.1,
+	BudgetStageLADB:  0.7,
+	BudgetStagePost:  0.2,
+}
+
+// RequestBudgetConf configures a time budget of an endpoint
+type RequestBudgetConf struct {
+	TotalSecs float64 `json:"totalSecs"`
+
+	// Shares are relative shares of the individual stages
+	// (cncDb, laDb, post). Missing stages get default shares.
+	Shares map[string]float64 `json:"shares"`
+}
+
+// RequestBudgetsConf configures time budgets of endpoints
+// supporting partial results (see budget.Budget)
+type RequestBudgetsConf struct {
+	// DefaultTotalSecs is used for endpoints without
+	// their own configuration
+	DefaultTotalSecs float64 `json:"defaultTotalSecs"`
+
+	// Endpoints maps route paths (e.g. `/liveAttributes/:corpusId/query`)
+	// to their budgets
+	Endpoints map[string]RequestBudgetConf `json:"endpoints"`
+}
+
+// NewBudget creates a budget of a request to a provided endpoint
+func (rbc RequestBudgetsConf) NewBudget(endpoint string) *budget.Budget {
+	conf := rbc.Endpoints[endpoint]
+	total := conf.TotalSecs
+	if total == 0 {
+		total = rbc.DefaultTotalSecs
+	}
+	stages := make([]budget.Stage, 0, len(dfltBudgetShares))
+	for _, stage := range []string{BudgetStageCNCDB, BudgetStageLADB, BudgetStagePost} {
+		share, ok := conf.Shares[stage]
+		if !ok {
+			share = dfltBudgetShares[stage]
+		}
+		stages = append(stages, budget.Stage{Name: stage, Share: share})
+	}
+	return budget.New(time.Duration(total*float64(time.Second)), stages...)
+}
+
+// Validate tests whether budgets are non-negative and whether
+// they use only known stages
+func (rbc RequestBudgetsConf) Validate() error {
+	if rbc.DefaultTotalSecs < 0 {
+		return fmt.Errorf("defaultTotalSecs must not be negative")
+	}
+	for endpoint, conf := range rbc.Endpoints {
+		if conf.TotalSecs < 0 {
+			return fmt.Errorf("totalSecs of %s must not be negative", endpoint)
+		}
+		for stage, share := range conf.Shares {
+			if _, ok := dfltBudgetShares[stage]; !ok {
+				return fmt.Errorf("unknown budget stage %s of %s", stage, endpoint)
+			}
+			if share < 0 {
+				return fmt.Errorf("share of %s in %s must not be negative", stage, endpoint)
+			}
+		}
+	}
+	return nil
+}
@@ -21,9 +21,12 @@ package cnf
 import (
 	"encoding/json"
 	"masm/v3/corpus"
+	"masm/v3/db/health"
+	"masm/v3/general/quota"
 	"masm/v3/jobs"
 	"masm/v3/kontext"
 	"masm/v3/liveattrs"
+	"masm/v3/replication"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -38,6 +41,18 @@ const (
 	dfltLanguage               = "en"
 	dfltMaxNumConcurrentJobs   = 4
 	dfltVertMaxNumErrors       = 100
+	dfltMultiQueryMaxWorkers   = 4
+	dfltMultiQueryMaxCorpora   = 50
+	dfltAttrListSize           = 30
+	dfltMaxAttrListSize        = 1000
+	dfltDBHealthCheckInterval  = 30
+	dfltDBHealthTimeout        = 5
+	dfltDBHealthMaxBackoff     = 300
+	dfltRegistryWatchInterval  = 10
+)
+
+var (
+	dfltAtomInferencePreferredStructs = []string{"doc", "text"}
 )
 
 // Conf is a global configuration of the app
@@ -55,7 +70,27 @@ type Conf struct {
 	NgramDB                *liveattrs.NgramDBConf `json:"ngramDb"`
 	LogLevel               logging.LogLevel       `json:"logLevel"`
 	Language               string                 `json:"language"`
-	srcPath                string
+	Features               FeaturesConf           `json:"features"`
+	RequestBudgets         RequestBudgetsConf     `json:"requestBudgets"`
+	ShutdownReportPath     string                 `json:"shutdownReportPath"`
+
+	// EndpointTimeouts specify write timeouts of endpoint classes
+	// (interactive, export, admin) overriding ServerWriteTimeoutSecs
+	EndpointTimeouts EndpointTimeoutsConf `json:"endpointTimeouts"`
+
+	// Quotas specify daily limits of expensive operations per
+	// client token and corpus. If nil, there are no limits.
+	Quotas *quota.Conf `json:"quotas"`
+
+	// Standby enables the standby mode (see replication.StandbyConf).
+	// If nil, the instance is a primary one.
+	Standby *replication.StandbyConf `json:"standby"`
+
+	// DBHealth configures periodic checks of the databases
+	// and reconnecting after their failure
+	DBHealth health.Conf `json:"dbHealth"`
+
+	srcPath string
 }
 
 func (conf *Conf) IsDebugMode() bool {
@@ -109,6 +144,20 @@ func ApplyDefaults(conf *Conf) {
 			dfltServerWriteTimeoutSecs,
 		)
 	}
+	if err := conf.EndpointTimeouts.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid endpointTimeouts")
+	}
+	conf.EndpointTimeouts.applyDefaults(conf.ServerWriteTimeoutSecs)
+	if conf.RequestBudgets.DefaultTotalSecs == 0 {
+		conf.RequestBudgets.DefaultTotalSecs = float64(conf.EndpointTimeouts.InteractiveSecs) * dfltBudgetWriteTimeoutRatio
+		log.Warn().Msgf(
+			"requestBudgets.defaultTotalSecs not specified, using default: %.1f",
+			conf.RequestBudgets.DefaultTotalSecs,
+		)
+	}
+	if err := conf.RequestBudgets.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid requestBudgets")
+	}
 	if conf.LiveAttrs.VertMaxNumErrors == 0 {
 		conf.LiveAttrs.VertMaxNumErrors = dfltVertMaxNumErrors
 		log.Warn().Msgf(
@@ -116,6 +165,110 @@ func ApplyDefaults(conf *Conf) {
 			dfltVertMaxNumErrors,
 		)
 	}
+	if conf.LiveAttrs.MultiQueryMaxWorkers <= 0 {
+		conf.LiveAttrs.MultiQueryMaxWorkers = dfltMultiQueryMaxWorkers
+		log.Warn().Msgf(
+			"liveAttrs.multiQueryMaxWorkers not specified or invalid, using default: %d",
+			dfltMultiQueryMaxWorkers,
+		)
+	}
+	if conf.LiveAttrs.MultiQueryMaxCorpora <= 0 {
+		conf.LiveAttrs.MultiQueryMaxCorpora = dfltMultiQueryMaxCorpora
+		log.Warn().Msgf(
+			"liveAttrs.multiQueryMaxCorpora not specified or invalid, using default: %d",
+			dfltMultiQueryMaxCorpora,
+		)
+	}
+	if conf.LiveAttrs.AtomInference.Strategy == "" {
+		conf.LiveAttrs.AtomInference.Strategy = liveattrs.AtomInferenceSingle
+		log.Warn().Msgf(
+			"liveAttrs.atomInference.strategy not specified, using default: %s",
+			liveattrs.AtomInferenceSingle,
+		)
+	}
+	if err := conf.LiveAttrs.AtomInference.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid liveAttrs.atomInference")
+	}
+	if conf.LiveAttrs.TextTypesDbDownload != nil {
+		if err := conf.LiveAttrs.TextTypesDbDownload.Validate(); err != nil {
+			log.Fatal().Err(err).Msg("invalid liveAttrs.textTypesDbDownload")
+		}
+	}
+	if len(conf.LiveAttrs.AtomInference.PreferredStructs) == 0 {
+		conf.LiveAttrs.AtomInference.PreferredStructs = dfltAtomInferencePreferredStructs
+		log.Warn().Msgf(
+			"liveAttrs.atomInference.preferredStructs not specified, using default: %v",
+			dfltAtomInferencePreferredStructs,
+		)
+	}
+	if conf.LiveAttrs.AttrListSize.Default == 0 {
+		conf.LiveAttrs.AttrListSize.Default = dfltAttrListSize
+		log.Warn().Msgf(
+			"liveAttrs.attrListSize.default not specified, using default: %d",
+			dfltAttrListSize,
+		)
+	}
+	if conf.LiveAttrs.AttrListSize.Max == 0 {
+		conf.LiveAttrs.AttrListSize.Max = dfltMaxAttrListSize
+		log.Warn().Msgf(
+			"liveAttrs.attrListSize.max not specified, using default: %d",
+			dfltMaxAttrListSize,
+		)
+	}
+	if err := conf.LiveAttrs.AttrListSize.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid liveAttrs.attrListSize")
+	}
+	if err := conf.Features.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid features")
+	}
+	if len(conf.Features.Disabled) > 0 {
+		log.Info().Strs("features", conf.Features.Disabled).Msg("some features are disabled")
+	}
+	if conf.Quotas != nil {
+		if err := conf.Quotas.Validate(); err != nil {
+			log.Fatal().Err(err).Msg("invalid quotas")
+		}
+	}
+	if conf.Standby != nil {
+		if err := conf.Standby.Validate(); err != nil {
+			log.Fatal().Err(err).Msg("invalid standby")
+		}
+		log.Info().Str("primary", conf.Standby.PrimaryURL).Msg("running in the standby mode")
+	}
+	if conf.CorporaSetup.RegistryWatchIntervalSecs == 0 {
+		conf.CorporaSetup.RegistryWatchIntervalSecs = dfltRegistryWatchInterval
+		log.Warn().Msgf(
+			"corporaSetup.registryWatchIntervalSecs not specified, using default: %d",
+			dfltRegistryWatchInterval,
+		)
+	}
+	if err := conf.CorporaSetup.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid corporaSetup")
+	}
+	if conf.DBHealth.CheckIntervalSecs == 0 {
+		conf.DBHealth.CheckIntervalSecs = dfltDBHealthCheckInterval
+		log.Warn().Msgf(
+			"dbHealth.checkIntervalSecs not specified, using default: %d",
+			dfltDBHealthCheckInterval,
+		)
+	}
+	if conf.DBHealth.TimeoutSecs == 0 {
+		conf.DBHealth.TimeoutSecs = dfltDBHealthTimeout
+		log.Warn().Msgf(
+			"dbHealth.timeoutSecs not specified, using default: %d",
+			dfltDBHealthTimeout,
+		)
+	}
+	if conf.DBHealth.MaxBackoffSecs == 0 {
+		conf.DBHealth.MaxBackoffSecs = dfltDBHealthMaxBackoff
+		log.Warn().Msgf(
+			"dbHealth.maxBackoffSecs not specified, using default: %d",
+			dfltDBHealthMaxBackoff,
+		)
+	}
+	if err := conf.DBHealth.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid dbHealth")
+	}
 	if conf.Language == "" {
 		conf.Language = dfltLanguage
 		log.Warn().Msgf("language not specified, using default: %s", conf.Language)
@@ -0,0 +1,66 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package cnf
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+)
+
+const (
+	// FeatureFreqs covers frequency distributions and collocations
+	// of concordances (including the concordance cache)
+	FeatureFreqs = "freqs"
+
+	// FeatureNgrams covers generating of n-grams and query suggestions
+	FeatureNgrams = "ngrams"
+
+	// FeatureRegistryDefaults covers default values of registry settings
+	FeatureRegistryDefaults = "registryDefaults"
+
+	// FeatureDebug covers debugging actions (available only in the debug mode)
+	FeatureDebug = "debug"
+)
+
+var allFeatures = []string{FeatureFreqs, FeatureNgrams, FeatureRegistryDefaults, FeatureDebug}
+
+// FeaturesConf allows for disabling whole modules of the service.
+// Routes of a disabled module are not registered (i.e. they return
+// 404) and background workers of the module are not started.
+// All the modules are enabled by default.
+type FeaturesConf struct {
+	Disabled []string `json:"disabled"`
+}
+
+// IsEnabled tests whether a feature is enabled. An empty feature
+// (e.g. of a route not belonging to any optional module) is always
+// enabled.
+func (fc FeaturesConf) IsEnabled(feature string) bool {
+	return feature == "" || !collections.SliceContains(fc.Disabled, feature)
+}
+
+// Validate tests whether all the disabled features are known
+func (fc FeaturesConf) Validate() error {
+	for _, feature := range fc.Disabled {
+		if !collections.SliceContains(allFeatures, feature) {
+			return fmt.Errorf("unknown feature %s (supported: %v)", feature, allFeatures)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package cnf
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// TimeoutClassInteractive covers common requests of user
+	// interfaces (the default class)
+	TimeoutClassInteractive = "interactive"
+
+	// TimeoutClassExport covers long responses (e.g. document lists,
+	// data exports and event streams)
+	TimeoutClassExport = "export"
+
+	// TimeoutClassAdmin covers administration requests
+	// (routes with the admin role unless specified otherwise)
+	TimeoutClassAdmin = "admin"
+)
+
+// EndpointTimeoutsConf configures write timeouts of endpoint classes.
+// Unspecified timeouts are replaced by ServerWriteTimeoutSecs.
+type EndpointTimeoutsConf struct {
+	InteractiveSecs int `json:"interactiveSecs"`
+	ExportSecs      int `json:"exportSecs"`
+	AdminSecs       int `json:"adminSecs"`
+}
+
+// Validate tests whether timeouts are non-negative
+func (etc EndpointTimeoutsConf) Validate() error {
+	if etc.InteractiveSecs < 0 || etc.ExportSecs < 0 || etc.AdminSecs < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	return nil
+}
+
+// applyDefaults replaces unspecified timeouts by a default one
+func (etc *EndpointTimeoutsConf) applyDefaults(dfltSecs int) {
+	for _, v := range []*int{&etc.InteractiveSecs, &etc.ExportSecs, &etc.AdminSecs} {
+		if *v == 0 {
+			*v = dfltSecs
+		}
+	}
+}
+
+// Timeouts returns write timeouts of individual endpoint classes
+func (etc EndpointTimeoutsConf) Timeouts() map[string]time.Duration {
+	return map[string]time.Duration{
+		TimeoutClassInteractive: time.Duration(etc.InteractiveSecs) * time.Second,
+		TimeoutClassExport:      time.Duration(etc.ExportSecs) * time.Second,
+		TimeoutClassAdmin:       time.Duration(etc.AdminSecs) * time.Second,
+	}
+}
+
+// MaxSecs returns the longest of the timeouts
+func (etc EndpointTimeoutsConf) MaxSecs() int {
+	return max(etc.InteractiveSecs, etc.ExportSecs, etc.AdminSecs)
+}
@@ -30,7 +30,9 @@ import (
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/google/uuid"
 
+	"masm/v3/general/confirm"
 	"masm/v3/jobs"
+	"masm/v3/mango"
 )
 
 const (
@@ -48,12 +50,44 @@ type Actions struct {
 	jobsConf     *jobs.Conf
 	jobActions   *jobs.Actions
 	infoProvider CorpusInfoProvider
+
+	// onboardingCheckers provide additional items for OnboardingStatus
+	onboardingCheckers []OnboardingChecker
+
+	// dataRemovers remove data of other modules along with
+	// removed corpora (see RemoveCorpus)
+	dataRemovers []CorpusDataRemover
+
+	// confirmations stores tokens confirming corpus removals
+	confirmations *confirm.Registry
+
+	// archiveFilesProviders provide data of other modules
+	// for corpus archives (see ExportCorpus)
+	archiveFilesProviders []ArchiveFilesProvider
+
+	// notes stores operational notes attached to corpora
+	notes *NotesStore
+
+	// renamers rename data of other modules along with
+	// renamed corpora (see RenameCorpus)
+	renamers []CorpusRenamer
+
+	// infoCache keeps information about corpora
+	// provided by GetCorpusInfo
+	infoCache *InfoCache
 }
 
 func (a *Actions) OnExit() {}
 
-// GetCorpusInfo provides some basic information about stored data
-func (a *Actions) GetCorpusInfo(ctx *gin.Context) {
+// OpenCorporaStats provides information about currently open
+// Manatee corpora and open/close rates
+func (a *Actions) OpenCorporaStats(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, mango.GetPoolStats())
+}
+
+// writeCorpusInfo writes (possibly cached) information about a corpus.
+// With `refresh` set to true, the cached information is replaced.
+func (a *Actions) writeCorpusInfo(ctx *gin.Context, refresh bool) {
 	var err error
 	corpusID := ctx.Param("corpusId")
 	baseErrTpl := "failed to get corpus info for %s: %w"
@@ -71,7 +105,10 @@ func (a *Actions) GetCorpusInfo(ctx *gin.Context) {
 		log.Error().Err(err)
 		return
 	}
-	ans, err := GetCorpusInfo(corpusID, a.conf, dbInfo.HasLimitedVariant)
+	if refresh {
+		a.infoCache.Invalidate(corpusID)
+	}
+	ans, err := a.infoCache.Get(corpusID, dbInfo.HasLimitedVariant)
 	if err == CorpusNotFound {
 		uniresp.WriteJSONErrorResponse(
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
@@ -84,9 +121,33 @@ func (a *Actions) GetCorpusInfo(ctx *gin.Context) {
 		log.Error().Err(err)
 		return
 	}
+	// notes are just an auxiliary information so we do not fail here
+	ans.Notes, err = a.notes.List(corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to load corpus notes")
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
+// GetCorpusInfo provides some basic information about stored data.
+// The information is cached until registry files of the corpus change
+// (see InfoCache).
+func (a *Actions) GetCorpusInfo(ctx *gin.Context) {
+	a.writeCorpusInfo(ctx, false)
+}
+
+// RefreshCorpusInfo reloads cached information about a corpus
+// (e.g. after its data have been recompiled) and provides it
+func (a *Actions) RefreshCorpusInfo(ctx *gin.Context) {
+	a.writeCorpusInfo(ctx, true)
+}
+
+// WatchRegistry starts watching registry directories for changes
+// invalidating cached corpus information (see InfoCache.WatchRegistry)
+func (a *Actions) WatchRegistry(exitEvent <-chan os.Signal) {
+	go a.infoCache.WatchRegistry(exitEvent)
+}
+
 func (a *Actions) RestartJob(jinfo *JobInfo) error {
 	err := a.jobActions.TestAllowsJobRestart(jinfo)
 	if err != nil {
@@ -99,6 +160,7 @@ func (a *Actions) RestartJob(jinfo *JobInfo) error {
 	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
 		defer close(updateJobChan)
 		resp, err := synchronizeCorpusData(&a.conf.CorpusDataPath, jinfo.CorpusID)
+		a.infoCache.Invalidate(jinfo.CorpusID)
 		if err != nil {
 			updateJobChan <- jinfo.WithError(err)
 
@@ -153,10 +215,12 @@ func (a *Actions) SynchronizeCorpusData(ctx *gin.Context) {
 		if err != nil {
 			jobRec.Error = err
 		}
+		a.infoCache.Invalidate(corpusID)
 		jobRec.Result = &resp
 		updateJobChan <- jobRec.AsFinished()
 	}
 	a.jobActions.EnqueueJob(&fn, jobRec)
+	a.jobActions.AttachRequest(ctx, jobKey)
 
 	uniresp.WriteJSONResponse(ctx.Writer, jobRec.FullInfo())
 }
@@ -167,11 +231,17 @@ func NewActions(
 	jobsConf *jobs.Conf,
 	jobActions *jobs.Actions,
 	infoProvider CorpusInfoProvider,
+	notes *NotesStore,
+	onboardingCheckers ...OnboardingChecker,
 ) *Actions {
 	return &Actions{
-		conf:         conf,
-		jobsConf:     jobsConf,
-		jobActions:   jobActions,
-		infoProvider: infoProvider,
+		conf:               conf,
+		jobsConf:           jobsConf,
+		jobActions:         jobActions,
+		infoProvider:       infoProvider,
+		notes:              notes,
+		onboardingCheckers: onboardingCheckers,
+		confirmations:      confirm.NewRegistry(removalConfirmationTTL),
+		infoCache:          NewInfoCache(conf),
 	}
 }
@@ -19,6 +19,7 @@
 package corpus
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"github.com/czcorpus/cnc-gokit/fs"
@@ -63,6 +64,33 @@ type CorporaSetup struct {
 	WordSketchDefDirPath string            `json:"wordSketchDefDirPath"`
 	SyncAllowedCorpora   []string          `json:"syncAllowedCorpora"`
 	ManateeDynlibPath    string            `json:"manateeDynlibPath"`
+
+	// ExportDirPath is a directory where corpus archives
+	// are written (see Actions.ExportCorpus)
+	ExportDirPath string `json:"exportDirPath"`
+
+	// MaxOpenCorpora limits the number of simultaneously open
+	// Manatee corpora (zero means unlimited)
+	MaxOpenCorpora int `json:"maxOpenCorpora"`
+
+	// MaxIdleCorpora is the number of recently used corpora
+	// kept open for reuse (zero means corpora are closed
+	// right after use)
+	MaxIdleCorpora int `json:"maxIdleCorpora"`
+
+	// RegistryWatchIntervalSecs specifies how often registry directories
+	// are scanned for changes in case they cannot be watched
+	// (see InfoCache.WatchRegistry)
+	RegistryWatchIntervalSecs int `json:"registryWatchIntervalSecs"`
+}
+
+// Validate tests values which cannot be fixed by applying defaults
+func (cs *CorporaSetup) Validate() error {
+	if cs.RegistryWatchIntervalSecs <= 0 {
+		return fmt.Errorf(
+			"registryWatchIntervalSecs must be positive (got %d)", cs.RegistryWatchIntervalSecs)
+	}
+	return nil
 }
 
 func (cs *CorporaSetup) GetFirstValidRegistry(corpusID, subDir string) string {
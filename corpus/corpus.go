@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"masm/v3/mango"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/fs"
@@ -77,6 +78,42 @@ type Info struct {
 	IndexedData    IndexedData  `json:"indexedData"`
 	IndexedStructs []string     `json:"indexedStructs"`
 	RegistryConf   RegistryConf `json:"registry"`
+
+	// RegistryErrors contains errors related to individual
+	// registry items (registry key => error) which could not
+	// be read or parsed. Information based on such items is
+	// left empty.
+	RegistryErrors map[string]string `json:"registryErrors,omitempty"`
+
+	// Notes contains operational notes attached to the corpus
+	// (see NotesStore)
+	Notes []Note `json:"notes,omitempty"`
+}
+
+func (info *Info) addRegistryError(key string, err error) {
+	if info.RegistryErrors == nil {
+		info.RegistryErrors = make(map[string]string)
+	}
+	info.RegistryErrors[key] = err.Error()
+}
+
+// RegistryError returns an error summarizing all the registry
+// items which could not be read or parsed. In case there are
+// no such items, nil is returned.
+func (info *Info) RegistryError() error {
+	if len(info.RegistryErrors) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(info.RegistryErrors))
+	for k := range info.RegistryErrors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		msgs[i] = fmt.Sprintf("%s: %s", k, info.RegistryErrors[k])
+	}
+	return InfoError{fmt.Errorf("invalid registry items - %s", strings.Join(msgs, "; "))}
 }
 
 // InfoError is a general corpus data information error.
@@ -119,7 +156,7 @@ func FindVerticalFile(basePath, corpusID string) (FileMappedValue, error) {
 	if basePath == "" {
 		panic("FindVerticalFile error - basePath cannot be empty")
 	}
-	suffixes := []string{".tar.gz", ".tar.bz2", ".tgz", ".tbz2", ".7z", ".gz", ".zip", ".tar", ".rar", ""}
+	suffixes := []string{".tar.gz", ".tar.bz2", ".tgz", ".tbz2", ".7z", ".gz", ".xz", ".zip", ".tar", ".rar", ""}
 	var verticalPath string
 	if IsIntercorpFilename(corpusID) {
 		verticalPath = filepath.Join(basePath, GenCorpusGroupName(corpusID), corpusID)
@@ -205,10 +242,32 @@ func openCorpus(regPath string) (*mango.GoCorpus, error) {
 	return corp, err
 }
 
+// parseSubcorpAttrs parses registry's SUBCORPATTRS value
+// (e.g. "doc.title,doc.author|text.type") into a map
+// structure => attributes
+func parseSubcorpAttrs(value string) (map[string][]string, error) {
+	ans := make(map[string][]string)
+	if value == "" {
+		return ans, nil
+	}
+	for _, attr1 := range strings.Split(value, "|") {
+		for _, attr2 := range strings.Split(attr1, ",") {
+			split := strings.Split(attr2, ".")
+			if len(split) != 2 || split[0] == "" || split[1] == "" {
+				return ans, fmt.Errorf("invalid attribute %s", attr2)
+			}
+			ans[split[0]] = append(ans[split[0]], split[1])
+		}
+	}
+	return ans, nil
+}
+
 // GetCorpusInfo provides miscellaneous corpus installation information mostly
 // related to different data files.
 // It should return an error only in case Manatee or filesystem produces some
-// error (i.e. not in case something is just not found).
+// error (i.e. not in case something is just not found). Errors related to individual
+// registry items do not stop the function - they are collected in Info.RegistryErrors
+// (see also Info.RegistryError).
 func GetCorpusInfo(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info, error) {
 	ans := &Info{ID: corpusID}
 	ans.IndexedData = IndexedData{}
@@ -226,12 +285,17 @@ func GetCorpusInfo(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info
 	if err != nil {
 		return nil, InfoError{err}
 	}
-	defer mango.CloseCorpus(corp1)
 	corp1Info, err := getCorpusInfo(corp1)
 	if err != nil {
+		mango.CloseCorpus(corp1)
 		return nil, InfoError{fmt.Errorf("Failed to get info about %s: %w", corpReg1, err)}
 	}
 	ans.IndexedData.Primary = corp1Info
+	ans.loadRegistryConf(corp1)
+	// The primary corpus must be released before the limited variant
+	// is opened. Otherwise, concurrent calls could wait for each other
+	// forever in case the number of open corpora is limited.
+	mango.CloseCorpus(corp1)
 
 	if tryLimited {
 		corpReg2 := setup.GetFirstValidRegistry(corpusID, CorpusVariantLimited.SubDir())
@@ -247,51 +311,59 @@ func GetCorpusInfo(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info
 		ans.IndexedData.Limited = corp2Info
 	}
 
-	// -------
+	return ans, nil
+}
+
+// loadRegistryConf reads selected registry items of an open corpus.
+func (info *Info) loadRegistryConf(corp *mango.GoCorpus) {
+	// Errors related to individual registry items are not fatal
+	// so it is still possible to review a (partially) broken corpus.
 
 	// get encoding
-	ans.RegistryConf.Encoding, err = mango.GetCorpusConf(corp1, "ENCODING")
+	var err error
+	info.RegistryConf.Encoding, err = mango.GetCorpusConf(corp, "ENCODING")
 	if err != nil {
-		return nil, InfoError{err}
+		info.addRegistryError("ENCODING", err)
 	}
 
 	// parse SUBCORPATTRS
-	subcorpAttrsString, err := mango.GetCorpusConf(corp1, "SUBCORPATTRS")
+	subcorpAttrsString, err := mango.GetCorpusConf(corp, "SUBCORPATTRS")
 	if err != nil {
-		return nil, InfoError{err}
-	}
-	if subcorpAttrsString != "" {
-		for _, attr1 := range strings.Split(subcorpAttrsString, "|") {
-			for _, attr2 := range strings.Split(attr1, ",") {
-				split := strings.Split(attr2, ".")
-				ans.RegistryConf.SubcorpAttrs[split[0]] = append(ans.RegistryConf.SubcorpAttrs[split[0]], split[1])
-			}
+		info.addRegistryError("SUBCORPATTRS", err)
+
+	} else {
+		subcorpAttrs, err := parseSubcorpAttrs(subcorpAttrsString)
+		if err != nil {
+			info.addRegistryError("SUBCORPATTRS", err)
+
+		} else {
+			info.RegistryConf.SubcorpAttrs = subcorpAttrs
 		}
 	}
 
-	unparsedStructs, err := mango.GetCorpusConf(corp1, "STRUCTLIST")
+	unparsedStructs, err := mango.GetCorpusConf(corp, "STRUCTLIST")
 	if err != nil {
-		return nil, InfoError{err}
-	}
-	if unparsedStructs != "" {
+		info.addRegistryError("STRUCTLIST", err)
+
+	} else if unparsedStructs != "" {
 		structs := strings.Split(unparsedStructs, ",")
-		ans.IndexedStructs = make([]string, len(structs))
+		info.IndexedStructs = make([]string, len(structs))
 		for i, st := range structs {
-			ans.IndexedStructs[i] = st
+			info.IndexedStructs[i] = st
 		}
 	}
 
 	// try registry's VERTICAL
-	regVertical, err := mango.GetCorpusConf(corp1, "VERTICAL")
+	regVertical, err := mango.GetCorpusConf(corp, "VERTICAL")
 	if err != nil {
-		return nil, InfoError{err}
-	}
-	ans.RegistryConf.Vertical, err = bindValueToPath(regVertical, regVertical)
-	if err != nil {
-		return nil, InfoError{err}
-	}
+		info.addRegistryError("VERTICAL", err)
 
-	return ans, nil
+	} else {
+		info.RegistryConf.Vertical, err = bindValueToPath(regVertical, regVertical)
+		if err != nil {
+			info.addRegistryError("VERTICAL", err)
+		}
+	}
 }
 
 func OpenCorpus(corpusID string, setup *CorporaSetup) (*mango.GoCorpus, error) {
@@ -322,6 +394,7 @@ func GetCorpusAttrs(corpusID string, setup *CorporaSetup) ([]string, error) {
 	if err != nil {
 		return []string{}, err
 	}
+	defer mango.CloseCorpus(corp)
 
 	unparsedStructs, err := mango.GetCorpusConf(corp, "ATTRLIST")
 	if err != nil {
@@ -332,3 +405,56 @@ func GetCorpusAttrs(corpusID string, setup *CorporaSetup) ([]string, error) {
 	}
 	return nil, nil
 }
+
+// GetStructAttrs returns structural attributes of a corpus
+// as defined in its registry (structure name => attribute names)
+func GetStructAttrs(corpusID string, setup *CorporaSetup) (map[string][]string, error) {
+	corp, err := OpenCorpus(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	defer mango.CloseCorpus(corp)
+	unparsedAttrs, err := mango.GetCorpusConf(corp, "STRUCTATTRLIST")
+	if err != nil {
+		return nil, InfoError{err}
+	}
+	ans := make(map[string][]string)
+	if unparsedAttrs == "" {
+		return ans, nil
+	}
+	for _, item := range strings.Split(unparsedAttrs, ",") {
+		split := strings.SplitN(item, ".", 2)
+		if len(split) != 2 {
+			continue
+		}
+		ans[split[0]] = append(ans[split[0]], split[1])
+	}
+	return ans, nil
+}
+
+// GetStructCoverage returns ratio of corpus positions covered
+// by each of provided structures (1.0 means that each position
+// is within a structure)
+func GetStructCoverage(corpusID string, setup *CorporaSetup, structs []string) (map[string]float64, error) {
+	corp, err := OpenCorpus(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	defer mango.CloseCorpus(corp)
+	size, err := mango.GetCorpusSize(corp)
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	if size == 0 {
+		return nil, CorpusError{fmt.Errorf("corpus %s is empty", corpusID)}
+	}
+	ans := make(map[string]float64)
+	for _, st := range structs {
+		covered, err := mango.GetStructCoverage(corp, st)
+		if err != nil {
+			return nil, CorpusError{err}
+		}
+		ans[st] = float64(covered) / float64(size)
+	}
+	return ans, nil
+}
@@ -0,0 +1,373 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"masm/v3/jobs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ExportJobType = "corpus-export"
+)
+
+// ArchiveFile is a file to be added to a corpus archive
+type ArchiveFile struct {
+
+	// Path is a path of the file in the filesystem
+	Path string
+
+	// ArchivePath is a path of the file within the archive
+	// (relative to the archive root directory)
+	ArchivePath string
+}
+
+// ArchiveFilesProvider provides files of other modules (e.g. liveattrs)
+// to be added to corpus archives
+type ArchiveFilesProvider interface {
+
+	// CorpusArchiveFiles returns files to be archived. Files created
+	// on the fly should be written to tmpDir which is removed once
+	// the archive is written.
+	CorpusArchiveFiles(corpusID string, tmpDir string) ([]ArchiveFile, error)
+}
+
+// ExportResult describes a created corpus archive
+type ExportResult struct {
+	ArchivePath string `json:"archivePath"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	NumFiles    int    `json:"numFiles"`
+	DataSize    int64  `json:"dataSize"`
+}
+
+// archiveWriter writes files into a tar archive and keeps
+// their checksums for the manifest
+type archiveWriter struct {
+	tw       *tar.Writer
+	rootDir  string
+	manifest []manifestItem
+	dataSize int64
+}
+
+func (aw *archiveWriter) addFile(srcPath, archPath string) error {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = path.Join(aw.rootDir, archPath)
+	if err := aw.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	h := sha256.New()
+	written, err := io.Copy(io.MultiWriter(aw.tw, h), f)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", srcPath, err)
+	}
+	aw.manifest = append(aw.manifest, manifestItem{path: archPath, sum: h.Sum(nil)})
+	aw.dataSize += written
+	return nil
+}
+
+// addDir adds all the regular files (including the ones symlinks
+// point to) found in srcDir (recursively)
+func (aw *archiveWriter) addDir(srcDir, archDir string) error {
+	return filepath.WalkDir(srcDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			log.Warn().Str("path", p).Msg("skipping non-regular file in corpus archive")
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+		return aw.addFile(p, path.Join(archDir, filepath.ToSlash(rel)))
+	})
+}
+
+// writeManifest adds a manifest with checksums of all the
+// archived files so the extracted data can be verified
+// via `sha256sum -c MANIFEST.sha256`
+func (aw *archiveWriter) writeManifest() error {
+	manifest := formatManifest(aw.manifest)
+	hdr := &tar.Header{
+		Name:    path.Join(aw.rootDir, manifestFileName),
+		Mode:    0644,
+		Size:    int64(len(manifest)),
+		ModTime: time.Now(),
+	}
+	if err := aw.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.WriteString(aw.tw, manifest)
+	return err
+}
+
+// exportFiles lists the registry files and the data directory
+// of a corpus along with files of other modules
+func (a *Actions) exportFiles(corpusID, tmpDir string) (regFiles []ArchiveFile, dataDir string, other []ArchiveFile, err error) {
+	regPath := a.conf.GetFirstValidRegistry(corpusID, CorpusVariantPrimary.SubDir())
+	if regPath == "" {
+		err = fmt.Errorf("registry file not found")
+		return
+	}
+	regFiles = append(regFiles, ArchiveFile{Path: regPath, ArchivePath: path.Join("registry", corpusID)})
+	if regPath := a.conf.GetFirstValidRegistry(corpusID, CorpusVariantLimited.SubDir()); regPath != "" {
+		regFiles = append(
+			regFiles,
+			ArchiveFile{Path: regPath, ArchivePath: path.Join("registry", CorpusVariantLimited.SubDir(), corpusID)},
+		)
+	}
+	dataDir = filepath.Join(a.conf.CorpusDataPath.CNC, corpusID)
+	if isDir, _ := fs.IsDir(dataDir); !isDir {
+		err = fmt.Errorf("data directory %s not found", dataDir)
+		return
+	}
+	for _, provider := range a.archiveFilesProviders {
+		var files []ArchiveFile
+		files, err = provider.CorpusArchiveFiles(corpusID, tmpDir)
+		if err != nil {
+			return
+		}
+		other = append(other, files...)
+	}
+	return
+}
+
+// exportCorpus writes a tar.gz archive containing registry files
+// (both variants), the indexed data of a corpus and files of other
+// modules (e.g. liveattrs) along with a checksum manifest. To allow
+// verification of a transferred archive, a checksum of the archive
+// itself is written to a `.sha256` file next to the archive.
+func (a *Actions) exportCorpus(corpusID string) (*ExportResult, error) {
+	if a.conf.ExportDirPath == "" {
+		return nil, fmt.Errorf("corpus export directory not configured")
+	}
+	tmpDir, err := os.MkdirTemp(a.conf.ExportDirPath, ".export-"+corpusID+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	regFiles, dataDir, otherFiles, err := a.exportFiles(corpusID, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	archivePath := filepath.Join(
+		a.conf.ExportDirPath,
+		fmt.Sprintf("%s-%s.tar.gz", corpusID, time.Now().Format("20060102-150405")),
+	)
+	tmpPath := archivePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath) // in case of success, the file is already renamed
+	archHash := sha256.New()
+	gzw := gzip.NewWriter(io.MultiWriter(f, archHash))
+	aw := &archiveWriter{tw: tar.NewWriter(gzw), rootDir: corpusID}
+
+	err = func() error {
+		for _, af := range append(regFiles, otherFiles...) {
+			if err := aw.addFile(af.Path, af.ArchivePath); err != nil {
+				return err
+			}
+		}
+		if err := aw.addDir(dataDir, "data"); err != nil {
+			return err
+		}
+		if err := aw.writeManifest(); err != nil {
+			return err
+		}
+		if err := aw.tw.Close(); err != nil {
+			return err
+		}
+		return gzw.Close()
+	}()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		return nil, err
+	}
+	ans := &ExportResult{
+		ArchivePath: archivePath,
+		Size:        info.Size(),
+		SHA256:      fmt.Sprintf("%x", archHash.Sum(nil)),
+		NumFiles:    len(aw.manifest),
+		DataSize:    aw.dataSize,
+	}
+	err = os.WriteFile(
+		archivePath+".sha256",
+		[]byte(fmt.Sprintf("%s  %s\n", ans.SHA256, filepath.Base(archivePath))),
+		0644,
+	)
+	if err != nil {
+		return ans, fmt.Errorf("failed to write archive checksum: %w", err)
+	}
+	return ans, nil
+}
+
+func (a *Actions) exportCorpusFromJobStatus(status *ExportJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		result, err := a.exportCorpus(status.CorpusID)
+		if err != nil {
+			finalStatus.Error = err
+
+		} else {
+			log.Info().
+				Str("corpusId", status.CorpusID).
+				Str("path", result.ArchivePath).
+				Int64("size", result.Size).
+				Msg("exported corpus")
+		}
+		finalStatus.Result = result
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+// ExportCorpus starts a job packaging registry files, indexed data
+// and liveattrs data (as an SQLite database) of a corpus into a single
+// tar.gz archive stored in the configured export directory so the corpus
+// can be handed to partner institutions.
+func (a *Actions) ExportCorpus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to export corpus %s: %w"
+	if !isValidCorpusID(corpusID) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid corpus ID")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if a.conf.ExportDirPath == "" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("corpus export not configured")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if a.conf.GetFirstValidRegistry(corpusID, CorpusVariantPrimary.SubDir()) == "" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("corpus not found")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, ExportJobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	// other jobs (e.g. data synchronization, liveattrs) may change the exported data
+	if running := a.jobActions.UnfinishedJobsOfCorpus(corpusID); len(running) > 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, corpusID, fmt.Errorf("job %s of the corpus is running", running[0].GetID())),
+			http.StatusConflict,
+		)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := ExportJobInfo{
+		ID:       jobID.String(),
+		Type:     ExportJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+	}
+	a.exportCorpusFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// RestartExportJob starts an interrupted export job again
+// (the archive is always created from scratch)
+func (a *Actions) RestartExportJob(jinfo *ExportJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.exportCorpusFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted corpus export job %s", jinfo.ID)
+	return nil
+}
+
+// AddArchiveFilesProviders registers modules providing their
+// data for corpus archives
+func (a *Actions) AddArchiveFilesProviders(providers ...ArchiveFilesProvider) {
+	a.archiveFilesProviders = append(a.archiveFilesProviders, providers...)
+}
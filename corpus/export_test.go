@@ -0,0 +1,123 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testingArchiveFilesProvider struct{}
+
+func (p testingArchiveFilesProvider) CorpusArchiveFiles(corpusID string, tmpDir string) ([]ArchiveFile, error) {
+	path := filepath.Join(tmpDir, corpusID+".db")
+	if err := os.WriteFile(path, []byte("sqlite"), 0644); err != nil {
+		return nil, err
+	}
+	return []ArchiveFile{{Path: path, ArchivePath: "liveattrs/" + corpusID + ".db"}}, nil
+}
+
+func readTestingArchive(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	gzr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	tr := tar.NewReader(gzr)
+	ans := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		ans[hdr.Name] = string(data)
+	}
+	return ans
+}
+
+func TestExportCorpus(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{
+		RegistryDirPaths: []string{filepath.Join(root, "registry")},
+		CorpusDataPath:   CorporaDataPaths{CNC: filepath.Join(root, "cnc")},
+		ExportDirPath:    filepath.Join(root, "export"),
+	}
+	for _, dir := range []string{
+		filepath.Join(root, "registry", "omezeni"),
+		filepath.Join(root, "cnc", "syn2020", "sub"),
+		conf.ExportDirPath,
+	} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "registry", "syn2020"), []byte("PATH a"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "registry", "omezeni", "syn2020"), []byte("PATH b"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "cnc", "syn2020", "word.lex"), []byte("lex"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "cnc", "syn2020", "sub", "word.text"), []byte("text"), 0644))
+
+	a := &Actions{conf: conf}
+	a.AddArchiveFilesProviders(testingArchiveFilesProvider{})
+	result, err := a.exportCorpus("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.NumFiles)
+	assert.Equal(t, int64(25), result.DataSize)
+
+	content := readTestingArchive(t, result.ArchivePath)
+	assert.Equal(t, "PATH a", content["syn2020/registry/syn2020"])
+	assert.Equal(t, "PATH b", content["syn2020/registry/omezeni/syn2020"])
+	assert.Equal(t, "lex", content["syn2020/data/word.lex"])
+	assert.Equal(t, "text", content["syn2020/data/sub/word.text"])
+	assert.Equal(t, "sqlite", content["syn2020/liveattrs/syn2020.db"])
+	manifest := strings.Split(strings.TrimSpace(content["syn2020/MANIFEST.sha256"]), "\n")
+	assert.Len(t, manifest, 5)
+	assert.Equal(t, fmt.Sprintf("%x  data/sub/word.text", sha256.Sum256([]byte("text"))), manifest[0])
+
+	archSum, err := os.ReadFile(result.ArchivePath + ".sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s  %s\n", result.SHA256, filepath.Base(result.ArchivePath)), string(archSum))
+
+	// temporary files must not be left in the export directory
+	entries, err := os.ReadDir(conf.ExportDirPath)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestExportCorpusMissingData(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{
+		RegistryDirPaths: []string{root},
+		CorpusDataPath:   CorporaDataPaths{CNC: filepath.Join(root, "cnc")},
+		ExportDirPath:    root,
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "syn2020"), []byte("PATH a"), 0644))
+	a := &Actions{conf: conf}
+	_, err := a.exportCorpus("syn2020")
+	assert.Error(t, err)
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"fmt"
+	"masm/v3/mango"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+type infoCacheKey struct {
+	corpusID   string
+	tryLimited bool
+}
+
+// InfoCache keeps corpus information (see GetCorpusInfo) so corpora
+// do not have to be opened via Manatee on each request. Entries are
+// invalidated by changes of registry files (see InfoCache.WatchRegistry)
+// or explicitly (e.g. by corpus data synchronization).
+type InfoCache struct {
+	setup *CorporaSetup
+	data  *collections.ConcurrentMap[infoCacheKey, *Info]
+	load  func(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info, error)
+}
+
+// Get returns a (shallow) copy of cached corpus information.
+// In case the information is not cached, it is loaded and stored.
+// Errors are not cached.
+func (c *InfoCache) Get(corpusID string, tryLimited bool) (*Info, error) {
+	key := infoCacheKey{corpusID: corpusID, tryLimited: tryLimited}
+	info, ok := c.data.GetWithTest(key)
+	if !ok {
+		var err error
+		info, err = c.load(corpusID, c.setup, tryLimited)
+		if err != nil {
+			return nil, err
+		}
+		c.data.Set(key, info)
+	}
+	ans := *info
+	return &ans, nil
+}
+
+// Invalidate removes cached information about a corpus. Open Manatee
+// handles of the corpus are evicted from the corpus pool too so the
+// information is reloaded from actual data.
+func (c *InfoCache) Invalidate(corpusID string) {
+	c.data.Delete(infoCacheKey{corpusID: corpusID, tryLimited: false})
+	c.data.Delete(infoCacheKey{corpusID: corpusID, tryLimited: true})
+	for _, regPath := range registryPaths(corpusID, c.setup) {
+		mango.EvictCorpus(regPath)
+	}
+}
+
+// registryPaths returns all the registry paths a corpus can be opened
+// from (i.e. all the registry directories and corpus variants).
+func registryPaths(corpusID string, setup *CorporaSetup) []string {
+	ans := make([]string, 0, 2*len(setup.RegistryDirPaths))
+	for _, dir := range setup.RegistryDirPaths {
+		ans = append(
+			ans,
+			filepath.Join(dir, CorpusVariantPrimary.SubDir(), corpusID),
+			filepath.Join(dir, CorpusVariantLimited.SubDir(), corpusID),
+		)
+	}
+	return ans
+}
+
+type registryFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// scanRegistryDirs returns states of registry files found in `dirs`
+// and their direct subdirectories (e.g. registry files of limited
+// variants of corpora). Files are identified by their paths.
+func scanRegistryDirs(dirs []string) map[string]registryFileState {
+	ans := make(map[string]registryFileState)
+	var scan func(dir string, depth int)
+	scan = func(dir string, depth int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			log.Warn().Err(err).Str("path", dir).Msg("failed to scan registry directory")
+			return
+		}
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				if depth == 0 {
+					scan(p, depth+1)
+				}
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// the file has been probably removed in the meantime
+				continue
+			}
+			ans[p] = registryFileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	for _, dir := range dirs {
+		scan(dir, 0)
+	}
+	return ans
+}
+
+// changedCorpora returns IDs of corpora with registry files
+// added, removed or modified between two scans
+func changedCorpora(prev, curr map[string]registryFileState) []string {
+	changed := make(map[string]bool)
+	for p, state := range curr {
+		if prevState, ok := prev[p]; !ok || prevState != state {
+			changed[filepath.Base(p)] = true
+		}
+	}
+	for p := range prev {
+		if _, ok := curr[p]; !ok {
+			changed[filepath.Base(p)] = true
+		}
+	}
+	ans := make([]string, 0, len(changed))
+	for corpusID := range changed {
+		ans = append(ans, corpusID)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// newRegistryWatcher creates a file system watcher of registry
+// directories and their direct subdirectories (e.g. registry files
+// of limited variants of corpora)
+func newRegistryWatcher(dirs []string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if err := watcher.Add(filepath.Join(dir, entry.Name())); err != nil {
+				watcher.Close()
+				return nil, fmt.Errorf("failed to watch %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return watcher, nil
+}
+
+// handleRegistryEvent invalidates a corpus affected by a file system
+// event. Subdirectories created directly in a registry directory are
+// watched too and corpora of files already present in them are invalidated.
+func (c *InfoCache) handleRegistryEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	isRegistryDir := func(dir string) bool {
+		return slices.ContainsFunc(c.setup.RegistryDirPaths, func(regDir string) bool {
+			return filepath.Clean(regDir) == dir
+		})
+	}
+	if event.Has(fsnotify.Create) && isRegistryDir(filepath.Dir(event.Name)) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := watcher.Add(event.Name); err != nil {
+				log.Warn().Err(err).Str("path", event.Name).Msg("failed to watch registry directory")
+			}
+			for p := range scanRegistryDirs([]string{event.Name}) {
+				c.invalidateChanged(filepath.Base(p))
+			}
+			return
+		}
+	}
+	c.invalidateChanged(filepath.Base(event.Name))
+}
+
+func (c *InfoCache) invalidateChanged(corpusID string) {
+	log.Debug().Str("corpusId", corpusID).Msg("registry changed, invalidating corpus info")
+	c.Invalidate(corpusID)
+}
+
+// invalidateAll removes all the cached information (e.g. in case
+// some registry changes may have been missed)
+func (c *InfoCache) invalidateAll() {
+	for _, key := range c.data.Keys() {
+		c.Invalidate(key.corpusID)
+	}
+}
+
+// WatchRegistry watches the configured registry directories for changes
+// and invalidates cached information about corpora with changed registry
+// files. In case file system notifications cannot be used (e.g. due to
+// a missing directory or the limit of watches), the directories are
+// scanned every RegistryWatchIntervalSecs instead. The method blocks
+// until the exitEvent so it should be run as a goroutine.
+func (c *InfoCache) WatchRegistry(exitEvent <-chan os.Signal) {
+	watcher, err := newRegistryWatcher(c.setup.RegistryDirPaths)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to watch registry directories, falling back to polling")
+		c.pollRegistry(exitEvent)
+		return
+	}
+	defer watcher.Close()
+	for {
+		select {
+		case <-exitEvent:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				c.pollRegistry(exitEvent)
+				return
+			}
+			c.handleRegistryEvent(watcher, event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				c.pollRegistry(exitEvent)
+				return
+			}
+			log.Error().Err(err).Msg("registry watcher error, invalidating all corpora info")
+			c.invalidateAll()
+		}
+	}
+}
+
+// pollRegistry periodically scans the configured registry directories
+// and invalidates cached information about corpora with changed
+// registry files. The method blocks until the exitEvent.
+func (c *InfoCache) pollRegistry(exitEvent <-chan os.Signal) {
+	ticker := time.NewTicker(time.Duration(c.setup.RegistryWatchIntervalSecs) * time.Second)
+	defer ticker.Stop()
+	state := scanRegistryDirs(c.setup.RegistryDirPaths)
+	for {
+		select {
+		case <-exitEvent:
+			return
+		case <-ticker.C:
+			newState := scanRegistryDirs(c.setup.RegistryDirPaths)
+			for _, corpusID := range changedCorpora(state, newState) {
+				c.invalidateChanged(corpusID)
+			}
+			state = newState
+		}
+	}
+}
+
+func NewInfoCache(setup *CorporaSetup) *InfoCache {
+	return &InfoCache{
+		setup: setup,
+		data:  collections.NewConcurrentMap[infoCacheKey, *Info](),
+		load:  GetCorpusInfo,
+	}
+}
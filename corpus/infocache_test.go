@@ -0,0 +1,159 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfoCache(t *testing.T) {
+	numLoads := 0
+	cache := &InfoCache{
+		setup: &CorporaSetup{RegistryDirPaths: []string{"/var/local/corpora/registry"}},
+		data:  collections.NewConcurrentMap[infoCacheKey, *Info](),
+		load: func(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info, error) {
+			numLoads++
+			if corpusID == "missing" {
+				return nil, CorpusNotFound
+			}
+			return &Info{ID: corpusID}, nil
+		},
+	}
+	info, err := cache.Get("syn2020", false)
+	assert.NoError(t, err)
+	info.Notes = []Note{{Text: "foo"}}
+	info, err = cache.Get("syn2020", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2020", info.ID)
+	assert.Nil(t, info.Notes)
+	assert.Equal(t, 1, numLoads)
+
+	_, err = cache.Get("syn2020", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, numLoads)
+
+	cache.Invalidate("syn2020")
+	_, err = cache.Get("syn2020", false)
+	assert.NoError(t, err)
+	_, err = cache.Get("syn2020", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, numLoads)
+
+	_, err = cache.Get("missing", false)
+	assert.ErrorIs(t, err, CorpusNotFound)
+	_, err = cache.Get("missing", false)
+	assert.ErrorIs(t, err, CorpusNotFound)
+	assert.Equal(t, 6, numLoads)
+}
+
+func TestRegistryPaths(t *testing.T) {
+	setup := &CorporaSetup{RegistryDirPaths: []string{"/reg1", "/reg2"}}
+	assert.Equal(
+		t,
+		[]string{"/reg1/syn2020", "/reg1/omezeni/syn2020", "/reg2/syn2020", "/reg2/omezeni/syn2020"},
+		registryPaths("syn2020", setup),
+	)
+}
+
+func TestScanRegistryDirs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "omezeni", "nested"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "syn2020"), []byte("NAME syn2020"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "omezeni", "syn2020"), []byte("NAME syn2020"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "omezeni", "nested", "foo"), []byte(""), 0644))
+
+	state := scanRegistryDirs([]string{dir, filepath.Join(dir, "missing")})
+	assert.Len(t, state, 2)
+	assert.Contains(t, state, filepath.Join(dir, "syn2020"))
+	assert.Contains(t, state, filepath.Join(dir, "omezeni", "syn2020"))
+	assert.Equal(t, int64(12), state[filepath.Join(dir, "syn2020")].size)
+}
+
+func TestChangedCorpora(t *testing.T) {
+	t0 := time.Now()
+	prev := map[string]registryFileState{
+		"/reg/syn2020":         {modTime: t0, size: 10},
+		"/reg/omezeni/syn2020": {modTime: t0, size: 10},
+		"/reg/intercorp_cs":    {modTime: t0, size: 10},
+		"/reg/removed":         {modTime: t0, size: 10},
+	}
+	curr := map[string]registryFileState{
+		"/reg/syn2020":         {modTime: t0, size: 10},
+		"/reg/omezeni/syn2020": {modTime: t0.Add(time.Second), size: 10},
+		"/reg/intercorp_cs":    {modTime: t0, size: 11},
+		"/reg/added":           {modTime: t0, size: 10},
+	}
+	assert.Equal(
+		t,
+		[]string{"added", "intercorp_cs", "removed", "syn2020"},
+		changedCorpora(prev, curr),
+	)
+	assert.Equal(t, []string{}, changedCorpora(prev, prev))
+}
+
+func TestCorporaSetupValidateWatchInterval(t *testing.T) {
+	assert.NoError(t, (&CorporaSetup{RegistryWatchIntervalSecs: 10}).Validate())
+	assert.Error(t, (&CorporaSetup{RegistryWatchIntervalSecs: 0}).Validate())
+	assert.Error(t, (&CorporaSetup{RegistryWatchIntervalSecs: -5}).Validate())
+}
+
+func TestWatchRegistry(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "syn2020"), []byte("NAME syn2020"), 0644))
+	cache := &InfoCache{
+		setup: &CorporaSetup{RegistryDirPaths: []string{dir}, RegistryWatchIntervalSecs: 3600},
+		data:  collections.NewConcurrentMap[infoCacheKey, *Info](),
+		load: func(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info, error) {
+			return &Info{ID: corpusID}, nil
+		},
+	}
+	for _, corpusID := range []string{"syn2020", "susanne"} {
+		_, err := cache.Get(corpusID, false)
+		assert.NoError(t, err)
+	}
+	exitEvent := make(chan os.Signal)
+	defer close(exitEvent)
+	go cache.WatchRegistry(exitEvent)
+	isCached := func(corpusID string) bool {
+		return cache.data.HasKey(infoCacheKey{corpusID: corpusID})
+	}
+	// the watcher is set up asynchronously so we repeat the change
+	assert.Eventually(t, func() bool {
+		os.WriteFile(filepath.Join(dir, "syn2020"), []byte("NAME syn2020 changed"), 0644)
+		return !isCached("syn2020")
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.True(t, isCached("susanne"))
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "omezeni"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "omezeni", "susanne"), []byte("NAME susanne"), 0644))
+	assert.Eventually(t, func() bool {
+		return !isCached("susanne")
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestNewRegistryWatcherMissingDir(t *testing.T) {
+	_, err := newRegistryWatcher([]string{filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,383 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"masm/v3/jobs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ManifestJobType             = "corpus-manifest"
+	ManifestVerificationJobType = "corpus-manifest-verification"
+
+	// manifestFileName is a name of a file with checksums
+	// of corpus data files (in the `sha256sum` format)
+	manifestFileName = "MANIFEST.sha256"
+
+	dataVariantCNC     = "cnc"
+	dataVariantKontext = "kontext"
+)
+
+type manifestItem struct {
+	path string
+	sum  []byte
+}
+
+// ManifestArgs specifies a copy of corpus data a manifest
+// is created for (or verified against)
+type ManifestArgs struct {
+
+	// Variant is either `cnc` or `kontext` (see CorporaDataPaths)
+	Variant string `json:"variant"`
+}
+
+// ManifestResult describes a created or verified manifest of corpus data.
+// The Mismatched, Missing and Unlisted files are filled in only
+// by a verification.
+type ManifestResult struct {
+	DataDir      string `json:"dataDir"`
+	ManifestPath string `json:"manifestPath"`
+	NumFiles     int    `json:"numFiles"`
+	DataSize     int64  `json:"dataSize"`
+
+	// Mismatched lists files with a checksum different from the manifest
+	Mismatched []string `json:"mismatched,omitempty"`
+
+	// Missing lists files from the manifest not found in the data
+	Missing []string `json:"missing,omitempty"`
+
+	// Unlisted lists files not present in the manifest
+	Unlisted []string `json:"unlisted,omitempty"`
+}
+
+func (res *ManifestResult) OK() bool {
+	return len(res.Mismatched) == 0 && len(res.Missing) == 0 && len(res.Unlisted) == 0
+}
+
+// formatManifest writes manifest items (sorted by their paths)
+// in the `sha256sum` format
+func formatManifest(items []manifestItem) string {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].path < items[j].path
+	})
+	var buff strings.Builder
+	for _, item := range items {
+		buff.WriteString(fmt.Sprintf("%x  %s\n", item.sum, item.path))
+	}
+	return buff.String()
+}
+
+// parseManifest reads manifest items written by formatManifest
+func parseManifest(r io.Reader) ([]manifestItem, error) {
+	ans := make([]manifestItem, 0, 50)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		sum, filePath, ok := strings.Cut(line, "  ")
+		if !ok || filePath == "" {
+			return nil, fmt.Errorf("invalid manifest line %d", lineNum)
+		}
+		rawSum, err := hex.DecodeString(sum)
+		if err != nil || len(rawSum) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum on manifest line %d", lineNum)
+		}
+		ans = append(ans, manifestItem{path: filePath, sum: rawSum})
+	}
+	return ans, scanner.Err()
+}
+
+func hashFile(filePath string) ([]byte, int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return h.Sum(nil), size, nil
+}
+
+// hashDataDir calculates checksums of all the regular files (including
+// the ones symlinks point to) found in dataDir (recursively). The manifest
+// file itself is skipped.
+func hashDataDir(dataDir string) ([]manifestItem, int64, error) {
+	ans := make([]manifestItem, 0, 50)
+	var dataSize int64
+	err := filepath.WalkDir(dataDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifestFileName {
+			return nil
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			log.Warn().Str("path", p).Msg("skipping non-regular file in corpus data manifest")
+			return nil
+		}
+		sum, size, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		ans = append(ans, manifestItem{path: path.Clean(rel), sum: sum})
+		dataSize += size
+		return nil
+	})
+	return ans, dataSize, err
+}
+
+// createManifest writes checksums of all the files of dataDir
+// to the manifest file stored in the directory
+func createManifest(dataDir string) (*ManifestResult, error) {
+	items, dataSize, err := hashDataDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksums: %w", err)
+	}
+	ans := &ManifestResult{
+		DataDir:      dataDir,
+		ManifestPath: filepath.Join(dataDir, manifestFileName),
+		NumFiles:     len(items),
+		DataSize:     dataSize,
+	}
+	if err := os.WriteFile(ans.ManifestPath, []byte(formatManifest(items)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return ans, nil
+}
+
+// verifyManifest compares files of dataDir with the manifest
+// stored in the directory
+func verifyManifest(dataDir string) (*ManifestResult, error) {
+	ans := &ManifestResult{
+		DataDir:      dataDir,
+		ManifestPath: filepath.Join(dataDir, manifestFileName),
+		Mismatched:   []string{},
+		Missing:      []string{},
+		Unlisted:     []string{},
+	}
+	f, err := os.Open(ans.ManifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	expected, err := parseManifest(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	items, dataSize, err := hashDataDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksums: %w", err)
+	}
+	ans.NumFiles = len(items)
+	ans.DataSize = dataSize
+	actual := make(map[string][]byte)
+	for _, item := range items {
+		actual[item.path] = item.sum
+	}
+	listed := make(map[string]bool)
+	for _, item := range expected {
+		listed[item.path] = true
+		sum, ok := actual[item.path]
+		if !ok {
+			ans.Missing = append(ans.Missing, item.path)
+
+		} else if !bytes.Equal(sum, item.sum) {
+			ans.Mismatched = append(ans.Mismatched, item.path)
+		}
+	}
+	for _, item := range items {
+		if !listed[item.path] {
+			ans.Unlisted = append(ans.Unlisted, item.path)
+		}
+	}
+	sort.Strings(ans.Missing)
+	sort.Strings(ans.Mismatched)
+	sort.Strings(ans.Unlisted)
+	return ans, nil
+}
+
+// manifestDataDir returns a data directory of a corpus
+// within a data variant (cnc, kontext)
+func (a *Actions) manifestDataDir(corpusID, variant string) (string, error) {
+	var root string
+	switch variant {
+	case dataVariantCNC:
+		root = a.conf.CorpusDataPath.CNC
+	case dataVariantKontext:
+		root = a.conf.CorpusDataPath.Kontext
+	default:
+		return "", fmt.Errorf("invalid data variant %s", variant)
+	}
+	if root == "" {
+		return "", fmt.Errorf("data path of variant %s not configured", variant)
+	}
+	return filepath.Join(root, corpusID), nil
+}
+
+func (a *Actions) manifestFromJobStatus(status *ManifestJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		dataDir, err := a.manifestDataDir(status.CorpusID, status.Args.Variant)
+		if err == nil {
+			if status.Type == ManifestVerificationJobType {
+				finalStatus.Result, err = verifyManifest(dataDir)
+
+			} else {
+				finalStatus.Result, err = createManifest(dataDir)
+			}
+		}
+		if err != nil {
+			finalStatus.Error = err
+
+		} else {
+			log.Info().
+				Str("corpusId", status.CorpusID).
+				Str("type", status.Type).
+				Str("dataDir", dataDir).
+				Int("numFiles", finalStatus.Result.NumFiles).
+				Bool("ok", finalStatus.Result.OK()).
+				Msg("processed corpus data manifest")
+		}
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+func (a *Actions) startManifestJob(ctx *gin.Context, jobType, baseErrTpl string) {
+	corpusID := ctx.Param("corpusId")
+	args := ManifestArgs{Variant: ctx.DefaultQuery("variant", dataVariantCNC)}
+	if !isValidCorpusID(corpusID) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid corpus ID")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	dataDir, err := a.manifestDataDir(corpusID, args.Variant)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if isDir, _ := fs.IsDir(dataDir); !isDir {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("data directory not found")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, jobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	// other jobs (e.g. data synchronization) may change the data
+	if running := a.jobActions.UnfinishedJobsOfCorpus(corpusID); len(running) > 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, corpusID, fmt.Errorf("job %s of the corpus is running", running[0].GetID())),
+			http.StatusConflict,
+		)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := ManifestJobInfo{
+		ID:       jobID.String(),
+		Type:     jobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     args,
+	}
+	a.manifestFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// CreateManifest starts a job writing SHA-256 checksums of all the files
+// of a corpus data directory to a manifest file stored in the directory.
+// As the manifest is a part of the data, it is transferred along with them
+// (e.g. by SynchronizeCorpusData) so other copies can be verified.
+func (a *Actions) CreateManifest(ctx *gin.Context) {
+	a.startManifestJob(ctx, ManifestJobType, "failed to create data manifest of %s: %w")
+}
+
+// VerifyManifest starts a job comparing files of a corpus data directory
+// with its manifest (see CreateManifest) to detect damaged files or
+// incomplete transfers.
+func (a *Actions) VerifyManifest(ctx *gin.Context) {
+	a.startManifestJob(ctx, ManifestVerificationJobType, "failed to verify data manifest of %s: %w")
+}
+
+// RestartManifestJob starts an interrupted manifest job again
+func (a *Actions) RestartManifestJob(jinfo *ManifestJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.manifestFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted corpus manifest job %s", jinfo.ID)
+	return nil
+}
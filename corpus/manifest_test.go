@@ -0,0 +1,94 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingDataDir(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "word.lex"), []byte("foo"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "lemma.lex"), []byte("bar"), 0644))
+	return dir
+}
+
+func TestParseManifest(t *testing.T) {
+	items, err := parseManifest(strings.NewReader(formatManifest([]manifestItem{
+		{path: "b", sum: make([]byte, 32)},
+		{path: "a b", sum: make([]byte, 32)},
+	})))
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "a b", items[0].path)
+	assert.Equal(t, make([]byte, 32), items[0].sum)
+
+	_, err = parseManifest(strings.NewReader("abcd  foo\n"))
+	assert.Error(t, err)
+	_, err = parseManifest(strings.NewReader("foo\n"))
+	assert.Error(t, err)
+}
+
+func TestCreateManifest(t *testing.T) {
+	dir := createTestingDataDir(t)
+	res, err := createManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.NumFiles)
+	assert.Equal(t, int64(6), res.DataSize)
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9  sub/lemma.lex\n"+
+			"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae  word.lex\n",
+		string(data),
+	)
+	// the manifest itself is never listed
+	res, err = createManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.NumFiles)
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := createTestingDataDir(t)
+	_, err := verifyManifest(dir)
+	assert.Error(t, err)
+
+	_, err = createManifest(dir)
+	assert.NoError(t, err)
+	res, err := verifyManifest(dir)
+	assert.NoError(t, err)
+	assert.True(t, res.OK())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "word.lex"), []byte("fox"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "sub", "lemma.lex")))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tag.lex"), []byte("baz"), 0644))
+	res, err = verifyManifest(dir)
+	assert.NoError(t, err)
+	assert.False(t, res.OK())
+	assert.Equal(t, []string{"word.lex"}, res.Mismatched)
+	assert.Equal(t, []string{"sub/lemma.lex"}, res.Missing)
+	assert.Equal(t, []string{"tag.lex"}, res.Unlisted)
+}
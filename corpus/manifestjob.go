@@ -0,0 +1,128 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"masm/v3/jobs"
+	"time"
+)
+
+// ManifestJobInfo collects information about a job creating
+// or verifying a corpus data manifest (see Actions.CreateManifest)
+type ManifestJobInfo struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	CorpusID    string          `json:"corpusId"`
+	Start       jobs.JSONTime   `json:"start"`
+	Update      jobs.JSONTime   `json:"update"`
+	Finished    bool            `json:"finished"`
+	Error       error           `json:"error,omitempty"`
+	NumRestarts int             `json:"numRestarts"`
+	Args        ManifestArgs    `json:"args"`
+	Result      *ManifestResult `json:"result"`
+}
+
+func (j ManifestJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j ManifestJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j ManifestJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j ManifestJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j ManifestJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j ManifestJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j ManifestJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j ManifestJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil && (j.Result == nil || j.Result.OK()),
+	}
+}
+
+func (j ManifestJobInfo) FullInfo() any {
+	return struct {
+		ID          string          `json:"id"`
+		Type        string          `json:"type"`
+		CorpusID    string          `json:"corpusId"`
+		Start       jobs.JSONTime   `json:"start"`
+		Update      jobs.JSONTime   `json:"update"`
+		Finished    bool            `json:"finished"`
+		Error       string          `json:"error,omitempty"`
+		OK          bool            `json:"ok"`
+		NumRestarts int             `json:"numRestarts"`
+		Args        ManifestArgs    `json:"args"`
+		Result      *ManifestResult `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil && (j.Result == nil || j.Result.OK()),
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
+
+func (j ManifestJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j ManifestJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return ManifestJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxNoteLength is a max. length (in characters) of a note text
+	maxNoteLength = 10000
+)
+
+var (
+	ErrorNoSuchNote = errors.New("no such note")
+)
+
+// Note is a free-form operational note attached to a corpus
+// (e.g. known problems of specific data versions) so such
+// information is available to all the administrators
+type Note struct {
+	ID       int64     `json:"id"`
+	CorpusID string    `json:"corpusId"`
+	Text     string    `json:"text"`
+	Author   string    `json:"author,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// NoteArgs contains user-provided data of a note
+type NoteArgs struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+}
+
+// Validate tests whether a note can be stored. Surrounding white
+// characters are removed from the text.
+func (args *NoteArgs) Validate() error {
+	args.Text = strings.TrimSpace(args.Text)
+	args.Author = strings.TrimSpace(args.Author)
+	if args.Text == "" {
+		return fmt.Errorf("empty note text")
+	}
+	if n := utf8.RuneCountInString(args.Text); n > maxNoteLength {
+		return fmt.Errorf("note text too long (%d characters, max. %d)", n, maxNoteLength)
+	}
+	if utf8.RuneCountInString(args.Author) > 127 {
+		return fmt.Errorf("author name too long")
+	}
+	return nil
+}
+
+// NotesStore stores corpus notes in the `corpus_notes` table
+// of the MASM database (see scripts/install.sql)
+type NotesStore struct {
+	db *sql.DB
+}
+
+// List returns all the notes of a corpus (oldest first)
+func (store *NotesStore) List(corpusID string) ([]Note, error) {
+	rows, err := store.db.Query(
+		"SELECT id, corpus_id, text, author, created, updated FROM corpus_notes "+
+			"WHERE corpus_id = ? ORDER BY created, id",
+		corpusID,
+	)
+	if err != nil {
+		return []Note{}, err
+	}
+	defer rows.Close()
+	ans := make([]Note, 0, 10)
+	for rows.Next() {
+		var item Note
+		var author sql.NullString
+		if err := rows.Scan(
+			&item.ID, &item.CorpusID, &item.Text, &author, &item.Created, &item.Updated); err != nil {
+			return []Note{}, err
+		}
+		item.Author = author.String
+		ans = append(ans, item)
+	}
+	return ans, rows.Err()
+}
+
+// Add stores a new note and returns it
+func (store *NotesStore) Add(corpusID string, args NoteArgs) (Note, error) {
+	now := time.Now().Truncate(time.Second)
+	ans := Note{
+		CorpusID: corpusID,
+		Text:     args.Text,
+		Author:   args.Author,
+		Created:  now,
+		Updated:  now,
+	}
+	tx, err := store.db.Begin()
+	if err != nil {
+		return ans, err
+	}
+	res, err := tx.Exec(
+		"INSERT INTO corpus_notes (corpus_id, text, author, created, updated) VALUES (?, ?, ?, ?, ?)",
+		corpusID, args.Text, sql.NullString{String: args.Author, Valid: args.Author != ""}, now, now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	ans.ID, err = res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	return ans, tx.Commit()
+}
+
+// get returns a note of a corpus. In case there is no such note,
+// ErrorNoSuchNote is returned.
+func (store *NotesStore) get(tx *sql.Tx, corpusID string, noteID int64) (Note, error) {
+	var ans Note
+	var author sql.NullString
+	row := tx.QueryRow(
+		"SELECT id, corpus_id, text, author, created, updated FROM corpus_notes "+
+			"WHERE corpus_id = ? AND id = ?",
+		corpusID, noteID,
+	)
+	err := row.Scan(&ans.ID, &ans.CorpusID, &ans.Text, &author, &ans.Created, &ans.Updated)
+	if err == sql.ErrNoRows {
+		return ans, ErrorNoSuchNote
+
+	} else if err != nil {
+		return ans, err
+	}
+	ans.Author = author.String
+	return ans, nil
+}
+
+// Update replaces text and author of an existing note
+// and returns the updated note
+func (store *NotesStore) Update(corpusID string, noteID int64, args NoteArgs) (Note, error) {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return Note{}, err
+	}
+	ans, err := store.get(tx, corpusID, noteID)
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	ans.Text = args.Text
+	ans.Author = args.Author
+	ans.Updated = time.Now().Truncate(time.Second)
+	_, err = tx.Exec(
+		"UPDATE corpus_notes SET text = ?, author = ?, updated = ? WHERE corpus_id = ? AND id = ?",
+		ans.Text, sql.NullString{String: ans.Author, Valid: ans.Author != ""}, ans.Updated,
+		corpusID, noteID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	return ans, tx.Commit()
+}
+
+// Delete removes a note and returns it
+func (store *NotesStore) Delete(corpusID string, noteID int64) (Note, error) {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return Note{}, err
+	}
+	ans, err := store.get(tx, corpusID, noteID)
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	_, err = tx.Exec("DELETE FROM corpus_notes WHERE corpus_id = ? AND id = ?", corpusID, noteID)
+	if err != nil {
+		tx.Rollback()
+		return ans, err
+	}
+	return ans, tx.Commit()
+}
+
+func NewNotesStore(db *sql.DB) *NotesStore {
+	return &NotesStore{db: db}
+}
+
+// CorpusNotes lists all the notes attached to a corpus
+func (a *Actions) CorpusNotes(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get notes of %s: %w"
+	notes, err := a.notes.List(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, notes)
+}
+
+// AddCorpusNote attaches a new note (see NoteArgs) to a corpus
+func (a *Actions) AddCorpusNote(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to add note to %s: %w"
+	var args NoteArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := args.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if _, err := a.infoProvider.LoadInfo(corpusID); err == sql.ErrNoRows {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, CorpusNotFound), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	note, err := a.notes.Add(corpusID, args)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, note)
+}
+
+// noteActionStatus returns a proper HTTP status for a note operation error
+func noteActionStatus(err error) int {
+	if err == ErrorNoSuchNote {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// UpdateCorpusNote replaces text (and author) of an existing note
+func (a *Actions) UpdateCorpusNote(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to update note of %s: %w"
+	noteID, err := strconv.ParseInt(ctx.Param("noteId"), 10, 64)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	var args NoteArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := args.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	note, err := a.notes.Update(corpusID, noteID, args)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), noteActionStatus(err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, note)
+}
+
+// DeleteCorpusNote removes a note of a corpus
+func (a *Actions) DeleteCorpusNote(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to delete note of %s: %w"
+	noteID, err := strconv.ParseInt(ctx.Param("noteId"), 10, 64)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	note, err := a.notes.Delete(corpusID, noteID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), noteActionStatus(err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, note)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteArgsValidate(t *testing.T) {
+	args := NoteArgs{Text: "  vertical v2 has broken doc.id until 2023-05\n", Author: " admin "}
+	assert.NoError(t, args.Validate())
+	assert.Equal(t, "vertical v2 has broken doc.id until 2023-05", args.Text)
+	assert.Equal(t, "admin", args.Author)
+}
+
+func TestNoteArgsValidateEmpty(t *testing.T) {
+	args := NoteArgs{Text: " \n\t"}
+	assert.Error(t, args.Validate())
+}
+
+func TestNoteArgsValidateTooLong(t *testing.T) {
+	args := NoteArgs{Text: strings.Repeat("ž", maxNoteLength)}
+	assert.NoError(t, args.Validate())
+	args = NoteArgs{Text: strings.Repeat("ž", maxNoteLength+1)}
+	assert.Error(t, args.Validate())
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// OnboardingItem is a single step of a corpus installation
+// along with its status
+type OnboardingItem struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Details string `json:"details,omitempty"`
+}
+
+// OnboardingChecker provides statuses of onboarding steps which
+// are handled by other modules (e.g. liveattrs). The corpusInfo
+// argument is nil in case the corpus database record is not available.
+type OnboardingChecker interface {
+	OnboardingItems(corpusID string, corpusInfo *DBInfo) []OnboardingItem
+}
+
+type onboardingStatusResp struct {
+	CorpusID string           `json:"corpusId"`
+	Complete bool             `json:"complete"`
+	Items    []OnboardingItem `json:"items"`
+}
+
+func (a *Actions) checkRegistry(corpusID string) OnboardingItem {
+	ans := OnboardingItem{Name: "registryPresent"}
+	regPath := a.conf.GetFirstValidRegistry(corpusID, "")
+	if regPath == "" {
+		ans.Details = "registry file not found in any of configured registry directories"
+		return ans
+	}
+	isFile, err := fs.IsFile(regPath)
+	if err != nil {
+		ans.Details = err.Error()
+		return ans
+	}
+	ans.OK = isFile
+	ans.Details = regPath
+	return ans
+}
+
+func (a *Actions) checkIndexedData(corpusID string) OnboardingItem {
+	ans := OnboardingItem{Name: "dataIndexed"}
+	corpusInfo, err := GetCorpusInfo(corpusID, a.conf, false)
+	if err != nil {
+		ans.Details = err.Error()
+		return ans
+	}
+	if corpusInfo.IndexedData.Primary == nil || !corpusInfo.IndexedData.Primary.Path.FileExists {
+		ans.Details = "indexed data not found"
+		return ans
+	}
+	if corpusInfo.IndexedData.Primary.ManateeError != nil {
+		ans.Details = *corpusInfo.IndexedData.Primary.ManateeError
+		return ans
+	}
+	ans.OK = true
+	ans.Details = corpusInfo.IndexedData.Primary.Path.Value
+	return ans
+}
+
+func (a *Actions) checkCNCDBRow(corpusInfo *DBInfo, loadErr error) OnboardingItem {
+	ans := OnboardingItem{Name: "cncdbRowActive"}
+	if errors.Is(loadErr, sql.ErrNoRows) {
+		ans.Details = "corpus not found in the database"
+		return ans
+
+	} else if loadErr != nil {
+		ans.Details = loadErr.Error()
+		return ans
+	}
+	ans.OK = corpusInfo.Active == 1
+	if !ans.OK {
+		ans.Details = "corpus is not active"
+	}
+	return ans
+}
+
+// OnboardingStatus provides a checklist of steps required to make
+// a corpus fully available along with their pass/fail status.
+func (a *Actions) OnboardingStatus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	corpusInfo, err := a.infoProvider.LoadInfo(corpusID)
+	ans := onboardingStatusResp{
+		CorpusID: corpusID,
+		Items: []OnboardingItem{
+			a.checkRegistry(corpusID),
+			a.checkIndexedData(corpusID),
+			a.checkCNCDBRow(corpusInfo, err),
+		},
+	}
+	if err != nil {
+		corpusInfo = nil
+	}
+	for _, checker := range a.onboardingCheckers {
+		ans.Items = append(ans.Items, checker.OnboardingItems(corpusID, corpusInfo)...)
+	}
+	ans.Complete = true
+	for _, item := range ans.Items {
+		if !item.OK {
+			ans.Complete = false
+			break
+		}
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -82,6 +82,20 @@ func (a *Actions) FreqDistrib(ctx *gin.Context) {
 		)
 		return
 	}
+	// a newly created concordance is saved asynchronously so in such
+	// case we can close the corpus only once the concordance is saved
+	var concSaved <-chan CacheEntry
+	defer func() {
+		if concSaved != nil {
+			go func() {
+				<-concSaved
+				mango.CloseCorpus(corp)
+			}()
+
+		} else {
+			mango.CloseCorpus(corp)
+		}
+	}()
 
 	var conc *mango.GoConc
 	if a.concCache.Contains(ctx.Param("corpusId"), q) {
@@ -109,7 +123,7 @@ func (a *Actions) FreqDistrib(ctx *gin.Context) {
 
 	} else {
 		conc, err = mango.CreateConcordance(corp, q)
-		a.concCache.Promise(
+		concSaved = a.concCache.Promise(
 			ctx.Param("corpusId"),
 			q,
 			func(targetPath string) error {
@@ -171,6 +185,7 @@ func (a *Actions) Collocations(ctx *gin.Context) {
 		)
 		return
 	}
+	defer mango.CloseCorpus(corp)
 
 	conc, err := mango.CreateConcordance(corp, q)
 	if err != nil {
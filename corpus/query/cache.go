@@ -177,6 +177,11 @@ func (cache *Cache) Get(corpusID, query string) (CacheEntry, error) {
 	return backoff.RetryWithData(operation, bkoff)
 }
 
+// NumEntries returns the number of cached concordances
+func (cache *Cache) NumEntries() int {
+	return cache.data.Len()
+}
+
 func NewCache(rootPath string, location *time.Location) *Cache {
 	return &Cache{
 		rootPath:          rootPath,
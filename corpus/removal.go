@@ -0,0 +1,301 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"fmt"
+	"masm/v3/general/confirm"
+	"masm/v3/jobs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RemovalJobType = "corpus-removal"
+
+	removeCorpusAction              = "removeCorpus"
+	removeAndDeactivateCorpusAction = "removeAndDeactivateCorpus"
+
+	// removalConfirmationTTL specifies how long a corpus
+	// removal confirmation token is valid
+	removalConfirmationTTL = 5 * time.Minute
+)
+
+const (
+	RemovedItemIndexedData = "indexedData"
+	RemovedItemRegistry    = "registry"
+)
+
+// RemovalOptions specifies optional steps of a corpus removal
+type RemovalOptions struct {
+
+	// Deactivate specifies whether the corpus database record
+	// should be deactivated (the record itself is always kept)
+	Deactivate bool `json:"deactivate"`
+}
+
+// RemovedItem describes a single item (a directory, a file,
+// a database table etc.) removed along with a corpus. In a removal
+// plan, Removed is always false.
+type RemovedItem struct {
+	Kind    string `json:"kind"`
+	Target  string `json:"target"`
+	Details string `json:"details,omitempty"`
+	Removed bool   `json:"removed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RemovalResult lists all the items of a removed corpus
+type RemovalResult struct {
+	Items []RemovedItem `json:"items"`
+}
+
+// NumErrors returns number of items which could not be removed
+func (r *RemovalResult) NumErrors() int {
+	var ans int
+	for _, item := range r.Items {
+		if item.Error != "" {
+			ans++
+		}
+	}
+	return ans
+}
+
+// CorpusDataRemover removes corpus data handled by other modules
+// (e.g. liveattrs). The corpusInfo argument is nil in case the corpus
+// database record is not available.
+type CorpusDataRemover interface {
+
+	// CorpusRemovalPlan lists items to be removed without
+	// removing anything
+	CorpusRemovalPlan(corpusID string, corpusInfo *DBInfo, opts RemovalOptions) []RemovedItem
+
+	// RemoveCorpusData removes the items and reports the outcome
+	// for each of them
+	RemoveCorpusData(corpusID string, corpusInfo *DBInfo, opts RemovalOptions) []RemovedItem
+}
+
+// isValidCorpusID tests whether a corpus ID cannot be used
+// to access paths outside configured directories
+func isValidCorpusID(corpusID string) bool {
+	return corpusID != "" && corpusID != "." && corpusID != ".." &&
+		filepath.Base(corpusID) == corpusID
+}
+
+// corpusPaths lists existing indexed data directories and registry
+// files (of both the primary and the limited variant) of a corpus
+func (a *Actions) corpusPaths(corpusID string) []RemovedItem {
+	ans := make([]RemovedItem, 0, 10)
+	dataDirs := []string{a.conf.CorpusDataPath.CNC, a.conf.CorpusDataPath.Kontext}
+	if alt := a.conf.AltAccessMapping[CorpusVariantLimited.SubDir()]; alt != "" {
+		dataDirs = append(
+			dataDirs,
+			filepath.Join(a.conf.CorpusDataPath.CNC, alt),
+			filepath.Join(a.conf.CorpusDataPath.Kontext, alt),
+		)
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dataDirs {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Clean(filepath.Join(dir, corpusID))
+		if isDir, _ := fs.IsDir(path); isDir && !seen[path] {
+			ans = append(ans, RemovedItem{Kind: RemovedItemIndexedData, Target: path})
+			seen[path] = true
+		}
+	}
+	for _, dir := range a.conf.RegistryDirPaths {
+		for _, variant := range []CorpusVariant{CorpusVariantPrimary, CorpusVariantLimited} {
+			path := filepath.Join(dir, variant.SubDir(), corpusID)
+			if isFile, _ := fs.IsFile(path); isFile && !seen[path] {
+				ans = append(ans, RemovedItem{Kind: RemovedItemRegistry, Target: path, Details: string(variant)})
+				seen[path] = true
+			}
+		}
+	}
+	return ans
+}
+
+// loadCorpusInfo returns nil in case the corpus database
+// record is not available
+func (a *Actions) loadCorpusInfo(corpusID string) *DBInfo {
+	corpusInfo, err := a.infoProvider.LoadInfo(corpusID)
+	if err != nil {
+		return nil
+	}
+	return corpusInfo
+}
+
+func (a *Actions) createRemovalPlan(corpusID string, opts RemovalOptions) RemovalResult {
+	corpusInfo := a.loadCorpusInfo(corpusID)
+	ans := RemovalResult{Items: a.corpusPaths(corpusID)}
+	for _, remover := range a.dataRemovers {
+		ans.Items = append(ans.Items, remover.CorpusRemovalPlan(corpusID, corpusInfo, opts)...)
+	}
+	return ans
+}
+
+// removeCorpus removes all the corpus data. Other modules go first
+// as e.g. liveattrs may need the corpus database record.
+func (a *Actions) removeCorpus(corpusID string, opts RemovalOptions) *RemovalResult {
+	defer a.infoCache.Invalidate(corpusID)
+	corpusInfo := a.loadCorpusInfo(corpusID)
+	ans := &RemovalResult{Items: make([]RemovedItem, 0, 20)}
+	for _, remover := range a.dataRemovers {
+		ans.Items = append(ans.Items, remover.RemoveCorpusData(corpusID, corpusInfo, opts)...)
+	}
+	for _, item := range a.corpusPaths(corpusID) {
+		var err error
+		if item.Kind == RemovedItemIndexedData {
+			err = os.RemoveAll(item.Target)
+
+		} else {
+			err = os.Remove(item.Target)
+		}
+		if err != nil {
+			item.Error = err.Error()
+
+		} else {
+			item.Removed = true
+		}
+		ans.Items = append(ans.Items, item)
+	}
+	return ans
+}
+
+func (a *Actions) removeCorpusFromJobStatus(status *RemovalJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		finalStatus.Result = a.removeCorpus(status.CorpusID, status.Args)
+		if n := finalStatus.Result.NumErrors(); n > 0 {
+			finalStatus.Error = fmt.Errorf("failed to remove %d item(s)", n)
+		}
+		log.Info().
+			Str("corpusId", status.CorpusID).
+			Int("numItems", len(finalStatus.Result.Items)).
+			Int("numErrors", finalStatus.Result.NumErrors()).
+			Msg("removed corpus")
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+// RemoveCorpus removes a whole corpus - its indexed data, registry files
+// (both the primary and the limited variant) and data of other modules
+// (e.g. liveattrs tables and configs). With `deactivate=1`, the corpus
+// database record is deactivated too. This is a two-step operation - without
+// the `confirm` URL argument, nothing is removed and a list of items to be
+// removed is returned along with a confirmation token. Once the token is
+// passed via the `confirm` argument, a removal job is started.
+func (a *Actions) RemoveCorpus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to remove corpus %s: %w"
+	if !isValidCorpusID(corpusID) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid corpus ID")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	opts := RemovalOptions{Deactivate: ctx.Request.URL.Query().Get("deactivate") == "1"}
+	token := ctx.Request.URL.Query().Get("confirm")
+	if token == "" {
+		plan := a.createRemovalPlan(corpusID, opts)
+		if len(plan.Items) == 0 {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("nothing to remove")),
+				http.StatusNotFound,
+			)
+			return
+		}
+		ans := a.confirmations.Issue(confirm.Operation{
+			Action:  removalAction(opts),
+			Target:  corpusID,
+			Summary: plan,
+		})
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, ans)
+		return
+	}
+	if err := a.confirmations.Consume(token, removalAction(opts), corpusID); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusPreconditionFailed)
+		return
+	}
+	if running := a.jobActions.UnfinishedJobsOfCorpus(corpusID); len(running) > 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, corpusID, fmt.Errorf("job %s of the corpus is running", running[0].GetID())),
+			http.StatusConflict,
+		)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := RemovalJobInfo{
+		ID:       jobID.String(),
+		Type:     RemovalJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     opts,
+	}
+	a.removeCorpusFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// removalAction binds a confirmation token also to the removal
+// options so a token issued for a removal keeping the database
+// record active cannot be used to deactivate it
+func removalAction(opts RemovalOptions) string {
+	if opts.Deactivate {
+		return removeAndDeactivateCorpusAction
+	}
+	return removeCorpusAction
+}
+
+// RestartRemovalJob refuses to restart an interrupted removal job
+// as the situation may have changed since the removal was confirmed
+func (a *Actions) RestartRemovalJob(jinfo *RemovalJobInfo) error {
+	return fmt.Errorf("corpus removal jobs cannot be restarted")
+}
+
+// AddDataRemovers registers modules removing their data
+// along with removed corpora
+func (a *Actions) AddDataRemovers(removers ...CorpusDataRemover) {
+	a.dataRemovers = append(a.dataRemovers, removers...)
+}
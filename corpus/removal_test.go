@@ -0,0 +1,71 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidCorpusID(t *testing.T) {
+	assert.True(t, isValidCorpusID("syn2020"))
+	assert.False(t, isValidCorpusID(""))
+	assert.False(t, isValidCorpusID(".."))
+	assert.False(t, isValidCorpusID("../syn2020"))
+	assert.False(t, isValidCorpusID("omezeni/syn2020"))
+}
+
+func TestCorpusPaths(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{
+		RegistryDirPaths: []string{filepath.Join(root, "registry")},
+		CorpusDataPath: CorporaDataPaths{
+			CNC:     filepath.Join(root, "cnc"),
+			Kontext: filepath.Join(root, "kontext"),
+		},
+	}
+	for _, dir := range []string{
+		filepath.Join(root, "registry", "omezeni"),
+		filepath.Join(root, "cnc", "syn2020"),
+		filepath.Join(root, "cnc", "syn2015"),
+	} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	for _, file := range []string{
+		filepath.Join(root, "registry", "syn2020"),
+		filepath.Join(root, "registry", "omezeni", "syn2020"),
+		filepath.Join(root, "registry", "syn2015"),
+	} {
+		assert.NoError(t, os.WriteFile(file, []byte("PATH"), 0644))
+	}
+	a := &Actions{conf: conf}
+	items := a.corpusPaths("syn2020")
+	assert.Equal(
+		t,
+		[]RemovedItem{
+			{Kind: RemovedItemIndexedData, Target: filepath.Join(root, "cnc", "syn2020")},
+			{Kind: RemovedItemRegistry, Target: filepath.Join(root, "registry", "syn2020"), Details: "primary"},
+			{Kind: RemovedItemRegistry, Target: filepath.Join(root, "registry", "omezeni", "syn2020"), Details: "omezeni"},
+		},
+		items,
+	)
+}
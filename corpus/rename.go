@@ -0,0 +1,428 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/jobs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RenameJobType = "corpus-rename"
+)
+
+// RenameArgs specifies a corpus rename
+type RenameArgs struct {
+	NewID string `json:"newId"`
+}
+
+// RenameStep describes a single change (e.g. a renamed directory
+// or a database table) performed by a corpus rename. In a rename plan,
+// Done is always false.
+type RenameStep struct {
+	Kind    string `json:"kind"`
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Details string `json:"details,omitempty"`
+	Done    bool   `json:"done"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RenameResult lists all the steps of a corpus rename
+type RenameResult struct {
+	Steps []RenameStep `json:"steps"`
+
+	// Rollback lists steps performed to undo a failed rename
+	Rollback []RenameStep `json:"rollback,omitempty"`
+}
+
+// NumErrors returns number of steps which failed
+func (r *RenameResult) NumErrors() int {
+	var ans int
+	for _, step := range r.Steps {
+		if step.Error != "" {
+			ans++
+		}
+	}
+	return ans
+}
+
+// CorpusRenamer renames corpus data handled by other modules
+// (e.g. liveattrs). The corpusInfo argument is nil in case the corpus
+// database record is not available.
+type CorpusRenamer interface {
+
+	// CorpusRenamePlan lists changes to be performed without
+	// changing anything
+	CorpusRenamePlan(corpusID, newID string, corpusInfo *DBInfo) []RenameStep
+
+	// RenameCorpusData performs the changes and reports the outcome
+	// for each of them
+	RenameCorpusData(corpusID, newID string, corpusInfo *DBInfo) []RenameStep
+}
+
+// rewriteRegistryPath replaces the last element of the PATH value
+// in a registry file content in case it matches the corpus ID
+// (e.g. `PATH "/corpora/data/syn2020/"`). Returns true in case
+// the value has been changed.
+func rewriteRegistryPath(reg []byte, corpusID, newID string) ([]byte, bool) {
+	lines := strings.Split(string(reg), "\n")
+	var changed bool
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		value, ok := strings.CutPrefix(trimmed, "PATH")
+		if !ok || value == "" || (value[0] != ' ' && value[0] != '\t') {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		quoted := len(value) > 1 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")
+		if quoted {
+			value = value[1 : len(value)-1]
+		}
+		if filepath.Base(filepath.Clean(value)) != corpusID {
+			continue
+		}
+		newValue := filepath.Join(filepath.Dir(filepath.Clean(value)), newID)
+		if strings.HasSuffix(value, "/") {
+			newValue += "/"
+		}
+		if quoted {
+			newValue = "\"" + newValue + "\""
+		}
+		lines[i] = line[:len(line)-len(trimmed)] + "PATH " + newValue
+		changed = true
+	}
+	return []byte(strings.Join(lines, "\n")), changed
+}
+
+// alignedCorpora returns corpora listed in the ALIGNED value
+// of a registry file content (e.g. `ALIGNED "intercorp_v13_cs,intercorp_v13_en"`)
+func alignedCorpora(reg []byte) []string {
+	ans := make([]string, 0, 5)
+	for _, line := range strings.Split(string(reg), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "ALIGNED")
+		if !ok || value == "" || (value[0] != ' ' && value[0] != '\t') {
+			continue
+		}
+		for _, item := range strings.Split(strings.Trim(strings.TrimSpace(value), "\""), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				ans = append(ans, item)
+			}
+		}
+	}
+	return ans
+}
+
+// alignedReferences lists registry files of other corpora referring
+// to the corpus via their ALIGNED value
+func (a *Actions) alignedReferences(corpusID string) []string {
+	ans := make([]string, 0, 5)
+	for path := range scanRegistryDirs(a.conf.RegistryDirPaths) {
+		if filepath.Base(path) == corpusID {
+			continue
+		}
+		reg, err := os.ReadFile(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("failed to read registry file")
+			continue
+		}
+		if slices.Contains(alignedCorpora(reg), corpusID) {
+			ans = append(ans, path)
+		}
+	}
+	slices.Sort(ans)
+	return ans
+}
+
+// renamePaths lists renames of indexed data directories and registry
+// files (of both the primary and the limited variant) of a corpus
+func (a *Actions) renamePaths(corpusID, newID string) []RenameStep {
+	items := a.corpusPaths(corpusID)
+	ans := make([]RenameStep, len(items))
+	for i, item := range items {
+		ans[i] = RenameStep{
+			Kind:    item.Kind,
+			Source:  item.Target,
+			Target:  filepath.Join(filepath.Dir(item.Target), newID),
+			Details: item.Details,
+		}
+	}
+	return ans
+}
+
+// renameFile renames a data directory or a registry file. In case
+// of a registry file, the PATH value is updated too.
+func renameFile(step RenameStep, corpusID, newID string) error {
+	if _, err := os.Stat(step.Target); err == nil {
+		return fmt.Errorf("%s already exists", step.Target)
+	}
+	if step.Kind != RemovedItemRegistry {
+		return os.Rename(step.Source, step.Target)
+	}
+	reg, err := os.ReadFile(step.Source)
+	if err != nil {
+		return err
+	}
+	newReg, _ := rewriteRegistryPath(reg, corpusID, newID)
+	info, err := os.Stat(step.Source)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(step.Target, newReg, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Remove(step.Source)
+}
+
+func (a *Actions) createRenamePlan(corpusID, newID string, corpusInfo *DBInfo) RenameResult {
+	ans := RenameResult{Steps: a.renamePaths(corpusID, newID)}
+	for _, renamer := range a.renamers {
+		ans.Steps = append(ans.Steps, renamer.CorpusRenamePlan(corpusID, newID, corpusInfo)...)
+	}
+	return ans
+}
+
+// renamedInfo returns a copy of corpus info matching the renamed corpus
+func renamedInfo(info *DBInfo, corpusID, newID string) *DBInfo {
+	if info == nil {
+		return nil
+	}
+	ans := *info
+	ans.Name = newID
+	if ans.ParallelCorpus == corpusID {
+		ans.ParallelCorpus = newID
+	}
+	return &ans
+}
+
+// renameCorpus renames the corpus files first and then data of other
+// modules. The rename stops at the first failed step as further steps
+// would make the state even less consistent and the completed steps
+// are undone (see undoRename).
+func (a *Actions) renameCorpus(corpusID, newID string) *RenameResult {
+	defer a.infoCache.Invalidate(corpusID)
+	corpusInfo := a.loadCorpusInfo(corpusID)
+	ans := &RenameResult{Steps: make([]RenameStep, 0, 20)}
+	moved := make([]RenameStep, 0, 10)
+	for _, step := range a.renamePaths(corpusID, newID) {
+		if err := renameFile(step, corpusID, newID); err != nil {
+			step.Error = err.Error()
+			ans.Steps = append(ans.Steps, step)
+			ans.Rollback = a.undoRename(corpusID, newID, corpusInfo, moved, []CorpusRenamer{})
+			return ans
+		}
+		step.Done = true
+		ans.Steps = append(ans.Steps, step)
+		moved = append(moved, step)
+	}
+	renamed := make([]CorpusRenamer, 0, len(a.renamers))
+	for _, renamer := range a.renamers {
+		steps := renamer.RenameCorpusData(corpusID, newID, corpusInfo)
+		ans.Steps = append(ans.Steps, steps...)
+		if slices.ContainsFunc(steps, func(step RenameStep) bool { return step.Done }) {
+			renamed = append(renamed, renamer)
+		}
+		if ans.NumErrors() > 0 {
+			ans.Rollback = a.undoRename(corpusID, newID, corpusInfo, moved, renamed)
+			return ans
+		}
+	}
+	return ans
+}
+
+// undoRename reverts completed steps of a failed rename in reverse
+// order. Renamers which have performed at least one step rename their
+// data back (their rename plans are based on existing data so only
+// the completed steps are reverted) and then the moved files are
+// renamed back.
+func (a *Actions) undoRename(
+	corpusID, newID string,
+	corpusInfo *DBInfo,
+	moved []RenameStep,
+	renamed []CorpusRenamer,
+) []RenameStep {
+	ans := make([]RenameStep, 0, 20)
+	newInfo := renamedInfo(corpusInfo, corpusID, newID)
+	for i := len(renamed) - 1; i >= 0; i-- {
+		ans = append(ans, renamed[i].RenameCorpusData(newID, corpusID, newInfo)...)
+	}
+	for i := len(moved) - 1; i >= 0; i-- {
+		step := RenameStep{
+			Kind:    moved[i].Kind,
+			Source:  moved[i].Target,
+			Target:  moved[i].Source,
+			Details: moved[i].Details,
+		}
+		if err := renameFile(step, newID, corpusID); err != nil {
+			step.Error = err.Error()
+
+		} else {
+			step.Done = true
+		}
+		ans = append(ans, step)
+	}
+	if len(ans) > 0 {
+		log.Warn().
+			Str("corpusId", corpusID).
+			Str("newId", newID).
+			Int("numSteps", len(ans)).
+			Msg("failed corpus rename has been rolled back")
+	}
+	return ans
+}
+
+func (a *Actions) renameCorpusFromJobStatus(status *RenameJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		finalStatus.Result = a.renameCorpus(status.CorpusID, status.Args.NewID)
+		if n := finalStatus.Result.NumErrors(); n > 0 {
+			finalStatus.Error = fmt.Errorf("failed to rename corpus, see the result for performed steps")
+		}
+		log.Info().
+			Str("corpusId", status.CorpusID).
+			Str("newId", status.Args.NewID).
+			Int("numSteps", len(finalStatus.Result.Steps)).
+			Int("numErrors", finalStatus.Result.NumErrors()).
+			Msg("renamed corpus")
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+// testNewCorpusID tests whether a corpus can be renamed to newID
+func (a *Actions) testNewCorpusID(corpusID, newID string) (int, error) {
+	if !isValidCorpusID(newID) || newID == corpusID {
+		return http.StatusBadRequest, fmt.Errorf("invalid new corpus ID")
+	}
+	if len(a.corpusPaths(newID)) > 0 {
+		return http.StatusConflict, fmt.Errorf("data or registry of %s already exist", newID)
+	}
+	_, err := a.infoProvider.LoadInfo(newID)
+	if err == nil {
+		return http.StatusConflict, fmt.Errorf("database record of %s already exists", newID)
+
+	} else if err != sql.ErrNoRows {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// RenameCorpus renames a corpus - its indexed data directories, registry
+// files (both the primary and the limited variant; including the PATH value),
+// liveattrs data and configuration and the corpus database record.
+// Corpora referenced via ALIGNED in registries of other corpora are
+// not renamed.
+// The new ID is passed via the `newId` URL argument. With `dryRun=1`,
+// nothing is changed and the planned changes are returned.
+// Otherwise, a rename job is started.
+func (a *Actions) RenameCorpus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	args := RenameArgs{NewID: ctx.Request.URL.Query().Get("newId")}
+	baseErrTpl := "failed to rename corpus %s: %w"
+	if !isValidCorpusID(corpusID) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid corpus ID")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if a.conf.GetFirstValidRegistry(corpusID, CorpusVariantPrimary.SubDir()) == "" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, CorpusNotFound),
+			http.StatusNotFound,
+		)
+		return
+	}
+	if refs := a.alignedReferences(corpusID); len(refs) > 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl,
+				corpusID,
+				fmt.Errorf("the corpus is referenced as aligned in %s", strings.Join(refs, ", ")),
+			),
+			http.StatusConflict,
+		)
+		return
+	}
+	if status, err := a.testNewCorpusID(corpusID, args.NewID); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), status)
+		return
+	}
+	for _, id := range []string{corpusID, args.NewID} {
+		if running := a.jobActions.UnfinishedJobsOfCorpus(id); len(running) > 0 {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(
+					baseErrTpl, corpusID, fmt.Errorf("job %s of %s is running", running[0].GetID(), id)),
+				http.StatusConflict,
+			)
+			return
+		}
+	}
+	if ctx.Request.URL.Query().Get("dryRun") == "1" {
+		uniresp.WriteJSONResponse(
+			ctx.Writer, a.createRenamePlan(corpusID, args.NewID, a.loadCorpusInfo(corpusID)))
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := RenameJobInfo{
+		ID:       jobID.String(),
+		Type:     RenameJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     args,
+	}
+	a.renameCorpusFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// RestartRenameJob refuses to restart an interrupted rename job
+// as the corpus may be partially renamed
+func (a *Actions) RestartRenameJob(jinfo *RenameJobInfo) error {
+	return fmt.Errorf("corpus rename jobs cannot be restarted")
+}
+
+// AddRenamers registers modules renaming their data
+// along with renamed corpora
+func (a *Actions) AddRenamers(renamers ...CorpusRenamer) {
+	a.renamers = append(a.renamers, renamers...)
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testingInfoProvider struct{}
+
+func (p testingInfoProvider) LoadInfo(corpusID string) (*DBInfo, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestRewriteRegistryPath(t *testing.T) {
+	reg := "NAME \"SYN2020\"\nPATH \"/corpora/data/syn2020/\"\nVERTICAL \"/corpora/vert/syn2020\"\n"
+	ans, changed := rewriteRegistryPath([]byte(reg), "syn2020", "syn2020v2")
+	assert.True(t, changed)
+	assert.Equal(
+		t,
+		"NAME \"SYN2020\"\nPATH \"/corpora/data/syn2020v2/\"\nVERTICAL \"/corpora/vert/syn2020\"\n",
+		string(ans),
+	)
+}
+
+func TestRewriteRegistryPathUnquoted(t *testing.T) {
+	ans, changed := rewriteRegistryPath([]byte("PATH /corpora/data/syn2020\n"), "syn2020", "syn2020v2")
+	assert.True(t, changed)
+	assert.Equal(t, "PATH /corpora/data/syn2020v2\n", string(ans))
+}
+
+func TestRewriteRegistryPathOtherDir(t *testing.T) {
+	reg := "PATH \"/corpora/data/syn2020_data/\"\nPATHS \"/corpora/data/syn2020\"\n"
+	ans, changed := rewriteRegistryPath([]byte(reg), "syn2020", "syn2020v2")
+	assert.False(t, changed)
+	assert.Equal(t, reg, string(ans))
+}
+
+func TestRenameCorpusFiles(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{
+		RegistryDirPaths: []string{filepath.Join(root, "registry")},
+		CorpusDataPath:   CorporaDataPaths{CNC: filepath.Join(root, "cnc")},
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "registry", "omezeni"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "cnc", "syn2020"), 0755))
+	dataPath := filepath.Join(root, "cnc", "syn2020") + "/"
+	for _, file := range []string{
+		filepath.Join(root, "registry", "syn2020"),
+		filepath.Join(root, "registry", "omezeni", "syn2020"),
+	} {
+		assert.NoError(t, os.WriteFile(file, []byte("PATH \""+dataPath+"\"\n"), 0644))
+	}
+	a := &Actions{conf: conf, infoProvider: testingInfoProvider{}, infoCache: NewInfoCache(conf)}
+	status, err := a.testNewCorpusID("syn2020", "syn2020v2")
+	assert.NoError(t, err, status)
+
+	result := a.renameCorpus("syn2020", "syn2020v2")
+	assert.Equal(t, 0, result.NumErrors())
+	assert.Len(t, result.Steps, 3)
+	assert.Empty(t, a.corpusPaths("syn2020"))
+	assert.Len(t, a.corpusPaths("syn2020v2"), 3)
+	reg, err := os.ReadFile(filepath.Join(root, "registry", "omezeni", "syn2020v2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "PATH \""+filepath.Join(root, "cnc", "syn2020v2")+"/\"\n", string(reg))
+
+	_, err = a.testNewCorpusID("syn2020v2", "syn2020v2")
+	assert.Error(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "registry", "syn2015"), []byte{}, 0644))
+	status, err = a.testNewCorpusID("syn2020v2", "syn2015")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusConflict, status)
+}
+
+type failingRenamer struct {
+	calls [][2]string
+}
+
+func (r *failingRenamer) CorpusRenamePlan(corpusID, newID string, corpusInfo *DBInfo) []RenameStep {
+	return []RenameStep{{Kind: "testData", Source: corpusID, Target: newID}}
+}
+
+func (r *failingRenamer) RenameCorpusData(corpusID, newID string, corpusInfo *DBInfo) []RenameStep {
+	r.calls = append(r.calls, [2]string{corpusID, newID})
+	ans := r.CorpusRenamePlan(corpusID, newID, corpusInfo)
+	if len(r.calls) == 1 {
+		ans[0].Error = "test failure"
+	}
+	return ans
+}
+
+func TestRenameCorpusRollback(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{
+		RegistryDirPaths: []string{filepath.Join(root, "registry")},
+		CorpusDataPath:   CorporaDataPaths{CNC: filepath.Join(root, "cnc")},
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "registry"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "cnc", "syn2020"), 0755))
+	dataPath := filepath.Join(root, "cnc", "syn2020") + "/"
+	regPath := filepath.Join(root, "registry", "syn2020")
+	assert.NoError(t, os.WriteFile(regPath, []byte("PATH \""+dataPath+"\"\n"), 0644))
+	renamer := &failingRenamer{}
+	a := &Actions{conf: conf, infoProvider: testingInfoProvider{}, infoCache: NewInfoCache(conf)}
+	a.AddRenamers(renamer)
+
+	result := a.renameCorpus("syn2020", "syn2020v2")
+	assert.Equal(t, 1, result.NumErrors())
+	assert.Len(t, result.Steps, 3)
+	// the renamer has not completed any step so it is not asked to undo it
+	assert.Equal(t, [][2]string{{"syn2020", "syn2020v2"}}, renamer.calls)
+	assert.Len(t, result.Rollback, 2)
+	assert.Equal(t, RemovedItemRegistry, result.Rollback[0].Kind)
+	assert.Equal(t, RemovedItemIndexedData, result.Rollback[1].Kind)
+	for _, step := range result.Rollback {
+		assert.True(t, step.Done)
+		assert.Empty(t, step.Error)
+	}
+	assert.Empty(t, a.corpusPaths("syn2020v2"))
+	assert.Len(t, a.corpusPaths("syn2020"), 2)
+	reg, err := os.ReadFile(regPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "PATH \""+dataPath+"\"\n", string(reg))
+}
+
+func TestAlignedCorpora(t *testing.T) {
+	reg := "NAME \"InterCorp\"\nALIGNED \"intercorp_v13_en, intercorp_v13_de\"\nALIGNED_EXTRA \"x\"\n"
+	assert.Equal(t, []string{"intercorp_v13_en", "intercorp_v13_de"}, alignedCorpora([]byte(reg)))
+	assert.Empty(t, alignedCorpora([]byte("PATH /corpora/data/syn2020\n")))
+}
+
+func TestAlignedReferences(t *testing.T) {
+	root := t.TempDir()
+	conf := &CorporaSetup{RegistryDirPaths: []string{root}}
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(root, "intercorp_v13_cs"), []byte("ALIGNED \"intercorp_v13_en\"\n"), 0644))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(root, "intercorp_v13_en"), []byte("ALIGNED \"intercorp_v13_cs\"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "syn2020"), []byte("PATH /corpora/data/syn2020\n"), 0644))
+	a := &Actions{conf: conf}
+	assert.Equal(t, []string{filepath.Join(root, "intercorp_v13_cs")}, a.alignedReferences("intercorp_v13_en"))
+	assert.Empty(t, a.alignedReferences("syn2020"))
+}
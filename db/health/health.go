@@ -0,0 +1,227 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// sqlDefaultMaxIdleConns is the default value
+	// used by the database/sql package
+	sqlDefaultMaxIdleConns = 2
+
+	initialBackoffInterval = 1 * time.Second
+)
+
+// Conf configures periodic health checks of databases
+type Conf struct {
+
+	// CheckIntervalSecs specifies how often databases are checked
+	CheckIntervalSecs int `json:"checkIntervalSecs"`
+
+	// TimeoutSecs limits a single check
+	TimeoutSecs int `json:"timeoutSecs"`
+
+	// MaxBackoffSecs is a max. interval between checks
+	// of an unavailable database
+	MaxBackoffSecs int `json:"maxBackoffSecs"`
+}
+
+func (conf Conf) Validate() error {
+	if conf.CheckIntervalSecs <= 0 {
+		return fmt.Errorf("checkIntervalSecs must be a positive number")
+	}
+	if conf.TimeoutSecs <= 0 {
+		return fmt.Errorf("timeoutSecs must be a positive number")
+	}
+	if conf.MaxBackoffSecs <= 0 {
+		return fmt.Errorf("maxBackoffSecs must be a positive number")
+	}
+	return nil
+}
+
+// Status describes the current state of a database
+type Status struct {
+	Name                string    `json:"name"`
+	OK                  bool      `json:"ok"`
+	LastCheck           time.Time `json:"lastCheck"`
+	LastOK              time.Time `json:"lastOk"`
+	LastError           string    `json:"lastError,omitempty"`
+	LatencyMs           float64   `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	NumReconnects       int       `json:"numReconnects"`
+}
+
+// Probe periodically checks a database connection pool. Once a check
+// fails (e.g. after a MySQL failover), all the idle connections are
+// dropped so requests do not get stale connections and the database
+// is checked again (with an exponential backoff) until it is available.
+type Probe struct {
+	name   string
+	db     *sql.DB
+	conf   Conf
+	mu     sync.RWMutex
+	status Status
+}
+
+func (p *Probe) record(err error, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.LastCheck = time.Now()
+	p.status.LatencyMs = float64(latency.Microseconds()) / 1000
+	if err != nil {
+		p.status.OK = false
+		p.status.LastError = err.Error()
+		p.status.ConsecutiveFailures++
+		return
+	}
+	p.status.OK = true
+	p.status.LastOK = p.status.LastCheck
+	p.status.LastError = ""
+	p.status.ConsecutiveFailures = 0
+}
+
+func (p *Probe) check() error {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Duration(p.conf.TimeoutSecs)*time.Second)
+	defer cancel()
+	t0 := time.Now()
+	err := p.db.PingContext(ctx)
+	p.record(err, time.Since(t0))
+	return err
+}
+
+// reconnect drops all the idle connections so next queries
+// open fresh ones
+func (p *Probe) reconnect() {
+	p.db.SetMaxIdleConns(0)
+	p.db.SetMaxIdleConns(sqlDefaultMaxIdleConns)
+	p.mu.Lock()
+	p.status.NumReconnects++
+	p.mu.Unlock()
+}
+
+// recover reconnects and checks the database until it is available
+// (or until the exitEvent).
+func (p *Probe) recover(exitEvent <-chan os.Signal) {
+	bkoff := backoff.NewExponentialBackOff()
+	bkoff.InitialInterval = initialBackoffInterval
+	bkoff.MaxInterval = time.Duration(p.conf.MaxBackoffSecs) * time.Second
+	bkoff.MaxElapsedTime = 0
+	for {
+		p.reconnect()
+		err := p.check()
+		if err == nil {
+			log.Info().Str("database", p.name).Msg("database available again")
+			return
+		}
+		wait := bkoff.NextBackOff()
+		log.Error().
+			Err(err).
+			Str("database", p.name).
+			Float64("retryInSecs", wait.Seconds()).
+			Msg("database unavailable")
+		select {
+		case <-exitEvent:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Run starts periodic checks. The method blocks until
+// the exitEvent so it should be run as a goroutine.
+func (p *Probe) Run(exitEvent <-chan os.Signal) {
+	ticker := time.NewTicker(time.Duration(p.conf.CheckIntervalSecs) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-exitEvent:
+			return
+		case <-ticker.C:
+			if err := p.check(); err != nil {
+				log.Error().Err(err).Str("database", p.name).Msg("database health check failed")
+				p.recover(exitEvent)
+			}
+		}
+	}
+}
+
+// Status returns the current state of the database
+func (p *Probe) Status() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+func NewProbe(name string, db *sql.DB, conf Conf) *Probe {
+	return &Probe{
+		name:   name,
+		db:     db,
+		conf:   conf,
+		status: Status{Name: name},
+	}
+}
+
+// Monitor runs probes of all the databases used by the service
+type Monitor struct {
+	probes []*Probe
+}
+
+// Start checks all the databases once and then starts
+// periodic checks in the background
+func (m *Monitor) Start(exitEvent <-chan os.Signal) {
+	for _, p := range m.probes {
+		if err := p.check(); err != nil {
+			log.Error().Err(err).Str("database", p.name).Msg("database health check failed")
+		}
+		go p.Run(exitEvent)
+	}
+}
+
+// StatusAction provides the current state of all the databases.
+// In case any of the databases is not available, status 503
+// is returned so the endpoint can be used by load balancers.
+func (m *Monitor) StatusAction(ctx *gin.Context) {
+	ans := make([]Status, len(m.probes))
+	status := http.StatusOK
+	for i, p := range m.probes {
+		ans[i] = p.Status()
+		if !ans[i].OK {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, status, ans)
+}
+
+func NewMonitor(probes ...*Probe) *Monitor {
+	return &Monitor{probes: probes}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func testingConf() Conf {
+	return Conf{CheckIntervalSecs: 1, TimeoutSecs: 1, MaxBackoffSecs: 1}
+}
+
+func openTestingDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	return db
+}
+
+func TestConfValidate(t *testing.T) {
+	assert.NoError(t, testingConf().Validate())
+	conf := testingConf()
+	conf.TimeoutSecs = 0
+	assert.Error(t, conf.Validate())
+}
+
+func TestProbeCheck(t *testing.T) {
+	db := openTestingDB(t)
+	p := NewProbe("test", db, testingConf())
+	assert.NoError(t, p.check())
+	st := p.Status()
+	assert.True(t, st.OK)
+	assert.Equal(t, st.LastCheck, st.LastOK)
+
+	db.Close()
+	assert.Error(t, p.check())
+	assert.Error(t, p.check())
+	st = p.Status()
+	assert.False(t, st.OK)
+	assert.NotEmpty(t, st.LastError)
+	assert.Equal(t, 2, st.ConsecutiveFailures)
+	assert.True(t, st.LastOK.Before(st.LastCheck))
+}
+
+func TestProbeRecover(t *testing.T) {
+	p := NewProbe("test", openTestingDB(t), testingConf())
+	p.record(assert.AnError, 0)
+	p.recover(make(chan os.Signal))
+	st := p.Status()
+	assert.True(t, st.OK)
+	assert.Equal(t, 0, st.ConsecutiveFailures)
+	assert.Equal(t, 1, st.NumReconnects)
+}
+
+func TestProbeRecoverExit(t *testing.T) {
+	db := openTestingDB(t)
+	db.Close()
+	p := NewProbe("test", db, testingConf())
+	exitEvent := make(chan os.Signal)
+	close(exitEvent)
+	p.recover(exitEvent)
+	assert.False(t, p.Status().OK)
+}
+
+func TestMonitorStatusAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	okDB := openTestingDB(t)
+	failedDB := openTestingDB(t)
+	failedDB.Close()
+	okProbe := NewProbe("ok", okDB, testingConf())
+	failedProbe := NewProbe("failed", failedDB, testingConf())
+	okProbe.check()
+	failedProbe.check()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	NewMonitor(okProbe).StatusAction(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	NewMonitor(okProbe, failedProbe).StatusAction(ctx)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"failed"`)
+}
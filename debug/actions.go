@@ -67,6 +67,7 @@ func (a *Actions) CreateDummyJob(ctx *gin.Context) {
 	}
 	a.jobActions.EnqueueJob(&fn, jobInfo)
 	a.finishSignals[jobID.String()] = finishSignal
+	a.jobActions.AttachRequest(ctx, jobInfo.ID)
 	uniresp.WriteJSONResponse(ctx.Writer, jobInfo)
 }
 
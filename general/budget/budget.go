@@ -0,0 +1,98 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package budget splits time available for processing of a request
+// among its stages (e.g. database lookups and post-processing) so
+// a slow stage cannot consume the whole time and the request can
+// still be answered (possibly with partial results) before the
+// server closes the connection.
+package budget
+
+import (
+	"context"
+	"time"
+)
+
+// Stage is a part of request processing with a relative
+// share of the time budget
+type Stage struct {
+	Name  string
+	Share float64
+}
+
+// Budget is a time budget of a single request. It is not
+// intended for a concurrent use. A nil budget means no limits.
+type Budget struct {
+	deadline time.Time
+	stages   []Stage
+	exceeded []string
+}
+
+// Start returns a context of a stage with a deadline derived from
+// the stage's share of the remaining time (relative to shares of the
+// stage and all the following stages). This means that the time
+// not used by previous stages is available to the following ones.
+// Stages are expected to be started in the order they have been
+// defined. For an unknown stage, the whole remaining time is used.
+func (b *Budget) Start(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	if b == nil {
+		return context.WithCancel(ctx)
+	}
+	idx := -1
+	var sumShares float64
+	for i, s := range b.stages {
+		if s.Name == stage {
+			idx = i
+		}
+		if idx >= 0 {
+			sumShares += s.Share
+		}
+	}
+	stageTime := time.Until(b.deadline)
+	if idx >= 0 {
+		if sumShares > 0 {
+			stageTime = time.Duration(float64(stageTime) * b.stages[idx].Share / sumShares)
+		}
+		b.stages = b.stages[idx+1:]
+	}
+	return context.WithTimeout(ctx, stageTime)
+}
+
+// MarkExceeded records a stage which has not been finished in time
+func (b *Budget) MarkExceeded(stage string) {
+	if b != nil {
+		b.exceeded = append(b.exceeded, stage)
+	}
+}
+
+// Exceeded returns stages which have not been finished in time
+func (b *Budget) Exceeded() []string {
+	if b == nil {
+		return []string{}
+	}
+	return b.exceeded
+}
+
+// New creates a budget of `total` time split among `stages`
+func New(total time.Duration, stages ...Stage) *Budget {
+	return &Budget{
+		deadline: time.Now().Add(total),
+		stages:   stages,
+		exceeded: make([]string, 0, len(stages)),
+	}
+}
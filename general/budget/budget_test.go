@@ -0,0 +1,80 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stageTime(t *testing.T, ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	return time.Until(deadline)
+}
+
+func TestStartSplitsRemainingTime(t *testing.T) {
+	b := New(10*time.Second, Stage{"a", 1}, Stage{"b", 2}, Stage{"c", 1})
+	ctx, cancel := b.Start(context.Background(), "a")
+	defer cancel()
+	assert.InDelta(t, 2500*time.Millisecond, stageTime(t, ctx), float64(100*time.Millisecond))
+
+	// stage `a` finished immediately so `b` gets 2/3 of the remaining time
+	ctx, cancel = b.Start(context.Background(), "b")
+	defer cancel()
+	assert.InDelta(t, 6667*time.Millisecond, stageTime(t, ctx), float64(100*time.Millisecond))
+
+	ctx, cancel = b.Start(context.Background(), "c")
+	defer cancel()
+	assert.InDelta(t, 10*time.Second, stageTime(t, ctx), float64(100*time.Millisecond))
+}
+
+func TestStartUnknownStage(t *testing.T) {
+	b := New(time.Second, Stage{"a", 1}, Stage{"b", 1})
+	ctx, cancel := b.Start(context.Background(), "x")
+	defer cancel()
+	assert.InDelta(t, time.Second, stageTime(t, ctx), float64(100*time.Millisecond))
+}
+
+func TestStartExpired(t *testing.T) {
+	b := New(time.Millisecond, Stage{"a", 1})
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := b.Start(context.Background(), "a")
+	defer cancel()
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	ctx, cancel := b.Start(context.Background(), "a")
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	b.MarkExceeded("a")
+	assert.Empty(t, b.Exceeded())
+}
+
+func TestMarkExceeded(t *testing.T) {
+	b := New(time.Second, Stage{"a", 1}, Stage{"b", 1})
+	b.MarkExceeded("b")
+	assert.Equal(t, []string{"b"}, b.Exceeded())
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package clientgen generates typed clients (Go, Python) of the MASM
+// API. Types of request bodies and responses are obtained via reflection
+// from values declared along with routes so the clients follow changes
+// of the API without any additional work.
+package clientgen
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Language is a target language of a generated client
+type Language string
+
+const (
+	LanguageGo     Language = "go"
+	LanguagePython Language = "python"
+)
+
+// Endpoint describes an HTTP endpoint a client method is generated for.
+// Request and Response are (typically zero) values of types the endpoint
+// reads and writes. In case a type is not known, the respective client
+// method works with raw JSON.
+type Endpoint struct {
+	Method      string
+	Path        string
+	Description string
+	Request     any
+	Response    any
+}
+
+type kind int
+
+const (
+	kindAny kind = iota
+	kindString
+	kindInt
+	kindFloat
+	kindBool
+	kindList
+	kindMap
+	kindStruct
+)
+
+// typeDesc is a language independent description of a JSON value type
+type typeDesc struct {
+	kind       kind
+	nullable   bool
+	elem       *typeDesc
+	structType reflect.Type
+}
+
+type fieldDesc struct {
+	goName    string
+	jsonName  string
+	omitEmpty bool
+	typ       *typeDesc
+}
+
+type methodDesc struct {
+	name        string
+	httpMethod  string
+	path        string
+	params      []string
+	description string
+	hasBody     bool
+	request     *typeDesc
+	response    *typeDesc
+}
+
+// api is a language independent description of a client
+type api struct {
+	version    string
+	structs    map[reflect.Type][]fieldDesc
+	typeNames  map[reflect.Type]string
+	methods    []methodDesc
+	processing map[reflect.Type]bool
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) ||
+		t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(iface)
+}
+
+func (a *api) describe(t reflect.Type) *typeDesc {
+	if t == timeType {
+		return &typeDesc{kind: kindString}
+	}
+	if t.Kind() == reflect.Pointer {
+		ans := *a.describe(t.Elem())
+		ans.nullable = true
+		return &ans
+	}
+	// values with custom serialization cannot be described
+	if implements(t, jsonMarshalerType) {
+		return &typeDesc{kind: kindAny}
+	}
+	if implements(t, textMarshalerType) {
+		return &typeDesc{kind: kindString}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &typeDesc{kind: kindString}
+	case reflect.Bool:
+		return &typeDesc{kind: kindBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &typeDesc{kind: kindInt}
+	case reflect.Float32, reflect.Float64:
+		return &typeDesc{kind: kindFloat}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &typeDesc{kind: kindString} // base64 encoded
+		}
+		return &typeDesc{kind: kindList, elem: a.describe(t.Elem())}
+	case reflect.Map:
+		return &typeDesc{kind: kindMap, elem: a.describe(t.Elem())}
+	case reflect.Struct:
+		// anonymous and generic types are not worth a named type
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return &typeDesc{kind: kindAny}
+		}
+		a.addStruct(t)
+		return &typeDesc{kind: kindStruct, structType: t}
+	}
+	return &typeDesc{kind: kindAny}
+}
+
+func (a *api) addStruct(t reflect.Type) {
+	if _, ok := a.structs[t]; ok || a.processing[t] {
+		return
+	}
+	a.processing[t] = true // recursive types
+	fields := a.fieldsOf(t, make(map[string]bool))
+	delete(a.processing, t)
+	a.structs[t] = fields
+}
+
+// fieldsOf returns JSON fields of a struct including fields
+// of embedded structs (same as encoding/json does)
+func (a *api) fieldsOf(t reflect.Type, used map[string]bool) []fieldDesc {
+	ans := make([]fieldDesc, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !implements(ft, jsonMarshalerType) {
+				ans = append(ans, a.fieldsOf(ft, used)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		ans = append(ans, fieldDesc{
+			goName:    f.Name,
+			jsonName:  name,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+			typ:       a.describe(f.Type),
+		})
+	}
+	return ans
+}
+
+// reservedTypeNames are names used by the generated
+// code itself (in any of the languages)
+var reservedTypeNames = map[string]bool{
+	"Client": true, "Error": true, "MasmError": true, "Any": true,
+	"Dict": true, "List": true, "Optional": true, "TypedDict": true,
+}
+
+// assignTypeNames names structs by their Go names. In case more
+// types share a name (or the name is reserved), the name is prefixed
+// by the package name.
+func (a *api) assignTypeNames() {
+	counts := make(map[string]int)
+	for t := range a.structs {
+		counts[t.Name()]++
+	}
+	for t := range a.structs {
+		if counts[t.Name()] > 1 || reservedTypeNames[t.Name()] {
+			a.typeNames[t] = exportedName(path.Base(t.PkgPath())) + t.Name()
+
+		} else {
+			a.typeNames[t] = t.Name()
+		}
+	}
+}
+
+// sortedStructs returns described structs ordered by their names
+func (a *api) sortedStructs() []reflect.Type {
+	ans := make([]reflect.Type, 0, len(a.structs))
+	for t := range a.structs {
+		ans = append(ans, t)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return a.typeNames[ans[i]] < a.typeNames[ans[j]]
+	})
+	return ans
+}
+
+// exportedName converts a path segment (e.g. `_openStats`, `dynamic-functions`)
+// to an exported identifier part (`OpenStats`, `DynamicFunctions`)
+func exportedName(s string) string {
+	var ans strings.Builder
+	upper := true
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			upper = true
+			continue
+		}
+		if upper {
+			ans.WriteRune(unicode.ToUpper(c))
+			upper = false
+
+		} else {
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+// snakeCase converts a camel case identifier to the snake case
+// (e.g. GetLiveAttributesStats => get_live_attributes_stats)
+func snakeCase(s string) string {
+	var ans strings.Builder
+	runes := []rune(s)
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				ans.WriteRune('_')
+			}
+			ans.WriteRune(unicode.ToLower(c))
+
+		} else {
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+// pathParams returns names of Gin path parameters (`:name`, `*name`)
+func pathParams(routePath string) []string {
+	ans := make([]string, 0, 3)
+	for _, seg := range strings.Split(routePath, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			ans = append(ans, seg[1:])
+		}
+	}
+	return ans
+}
+
+// methodName derives a method name from an HTTP method and a path
+// (e.g. GET /liveAttributes/:corpusId/stats => GetLiveAttributesStats)
+func methodName(httpMethod, routePath string) string {
+	var ans strings.Builder
+	ans.WriteString(exportedName(strings.ToLower(httpMethod)))
+	numParts := 0
+	for _, seg := range strings.Split(routePath, "/") {
+		if seg == "" || strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			continue
+		}
+		ans.WriteString(exportedName(seg))
+		numParts++
+	}
+	if numParts == 0 {
+		ans.WriteString("Root")
+	}
+	return ans.String()
+}
+
+func hasBody(httpMethod string) bool {
+	return httpMethod == http.MethodPost || httpMethod == http.MethodPut ||
+		httpMethod == http.MethodPatch
+}
+
+func newAPI(version string, endpoints []Endpoint) *api {
+	ans := &api{
+		version:    version,
+		structs:    make(map[reflect.Type][]fieldDesc),
+		typeNames:  make(map[reflect.Type]string),
+		methods:    make([]methodDesc, 0, len(endpoints)),
+		processing: make(map[reflect.Type]bool),
+	}
+	usedNames := make(map[string]bool)
+	for _, ep := range endpoints {
+		m := methodDesc{
+			name:        methodName(ep.Method, ep.Path),
+			httpMethod:  ep.Method,
+			path:        ep.Path,
+			params:      pathParams(ep.Path),
+			description: ep.Description,
+			hasBody:     hasBody(ep.Method),
+		}
+		if usedNames[m.name] {
+			for _, p := range m.params {
+				m.name += "By" + exportedName(p)
+			}
+		}
+		base := m.name
+		for i := 2; usedNames[m.name]; i++ {
+			m.name = fmt.Sprintf("%s%d", base, i)
+		}
+		usedNames[m.name] = true
+		if ep.Request != nil && m.hasBody {
+			m.request = ans.describe(reflect.TypeOf(ep.Request))
+		}
+		if ep.Response != nil {
+			m.response = ans.describe(reflect.TypeOf(ep.Response))
+		}
+		ans.methods = append(ans.methods, m)
+	}
+	ans.assignTypeNames()
+	return ans
+}
+
+// Generate creates a source code of a client of provided endpoints
+func Generate(lang Language, version string, endpoints []Endpoint) ([]byte, error) {
+	switch lang {
+	case LanguageGo:
+		return generateGo(newAPI(version, endpoints))
+	case LanguagePython:
+		return generatePython(newAPI(version, endpoints)), nil
+	}
+	return nil, fmt.Errorf("unsupported client language: %s", lang)
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package clientgen
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAttrs map[string]any
+
+type testPayload struct {
+	Aligned  []string  `json:"aligned"`
+	Attrs    testAttrs `json:"attrs"`
+	MaxItems int       `json:"maxItems,omitempty"`
+	Internal string    `json:"-"`
+	hidden   string
+}
+
+type testBase struct {
+	Total int `json:"total"`
+}
+
+type testItem struct {
+	Value   string    `json:"value"`
+	Created time.Time `json:"created"`
+	Parent  *testItem `json:"parent"`
+}
+
+type testAns struct {
+	testBase
+	Items []testItem         `json:"items"`
+	Freqs map[string]float64 `json:"freqs"`
+	Raw   testCustom         `json:"raw"`
+	Opt   *testBase          `json:"opt,omitempty"`
+}
+
+type testCustom struct{}
+
+func (c testCustom) MarshalJSON() ([]byte, error) {
+	return []byte("{}"), nil
+}
+
+var testEndpoints = []Endpoint{
+	{Method: "GET", Path: "/", Description: "basic info"},
+	{Method: "GET", Path: "/jobs"},
+	{Method: "GET", Path: "/jobs/:jobId"},
+	{Method: "POST", Path: "/liveAttributes/:corpusId/query", Request: testPayload{}, Response: testAns{}},
+	{Method: "GET", Path: "/liveAttributes/:corpusId/stats", Response: map[string]int{}},
+	{Method: "PUT", Path: "/corpora/_openStats"},
+}
+
+func TestMethodNames(t *testing.T) {
+	a := newAPI("1.0", testEndpoints)
+	names := make([]string, len(a.methods))
+	for i, m := range a.methods {
+		names[i] = m.name
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"GetRoot", "GetJobs", "GetJobsByJobId", "PostLiveAttributesQuery",
+			"GetLiveAttributesStats", "PutCorporaOpenStats",
+		},
+		names,
+	)
+	assert.Equal(t, "get_live_attributes_stats", snakeCase("GetLiveAttributesStats"))
+	assert.Equal(t, "corpus_id", snakeCase("corpusId"))
+}
+
+func TestDescribeStructs(t *testing.T) {
+	a := newAPI("1.0", testEndpoints)
+	assert.Len(t, a.structs, 4)
+	fields := a.structs[reflect.TypeOf(testPayload{})]
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "maxItems", fields[2].jsonName)
+	assert.True(t, fields[2].omitEmpty)
+
+	fields = a.structs[reflect.TypeOf(testAns{})]
+	assert.Equal(t, "total", fields[0].jsonName) // embedded struct
+	assert.Equal(t, kindMap, fields[2].typ.kind)
+	assert.Equal(t, kindFloat, fields[2].typ.elem.kind)
+	assert.Equal(t, kindAny, fields[3].typ.kind)
+	assert.True(t, fields[4].typ.nullable)
+
+	fields = a.structs[reflect.TypeOf(testItem{})]
+	assert.Equal(t, kindString, fields[1].typ.kind)
+	assert.Equal(t, kindStruct, fields[2].typ.kind)
+}
+
+func TestGenerateGo(t *testing.T) {
+	src, err := Generate(LanguageGo, "1.0", testEndpoints)
+	assert.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "client.go", src, 0)
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		string(src),
+		"func (c *Client) PostLiveAttributesQuery(ctx context.Context, corpusId string, "+
+			"query url.Values, body testPayload) (testAns, error) {",
+	)
+	assert.Contains(
+		t,
+		string(src),
+		`err := c.call(ctx, "GET", "/jobs/"+url.PathEscape(jobId), query, nil, &ans)`,
+	)
+	assert.Contains(t, string(src), "Parent  *testItem `json:\"parent\"`")
+}
+
+func TestGeneratePython(t *testing.T) {
+	src, err := Generate(LanguagePython, "1.0", testEndpoints)
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		string(src),
+		"    def post_live_attributes_query(self, corpus_id: str, body: 'testPayload', "+
+			"query: Optional[Dict[str, Any]] = None) -> 'testAns':\n",
+	)
+	assert.Contains(
+		t,
+		string(src),
+		"return self._call('GET', '/liveAttributes/' + _quote(corpus_id) + '/stats', query, None)",
+	)
+	assert.Contains(t, string(src), "    'parent': Optional['testItem'],\n")
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	_, err := Generate(Language("rust"), "1.0", testEndpoints)
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package clientgen
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+const goClientBase = `
+// Package masmclient is a typed client of the CNC-MASM API
+package masmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls a MASM instance
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client of a MASM instance running at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is returned in case MASM responds with an error status
+type Error struct {
+	Status int
+	Body   string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("MASM responded with status %d: %s", err.Status, err.Body)
+}
+
+func (c *Client) call(
+	ctx context.Context, method, path string, query url.Values, body, ans any,
+) error {
+	reqURL := c.BaseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return &Error{Status: resp.StatusCode, Body: string(data)}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, ans)
+}
+`
+
+// goReservedParams are identifiers which cannot be used
+// as names of path parameters in generated methods
+var goReservedParams = map[string]bool{
+	"ctx": true, "query": true, "body": true, "ans": true, "err": true,
+	"c": true, "url": true, "type": true, "func": true, "range": true,
+}
+
+func goParamName(param string) string {
+	if goReservedParams[param] {
+		return param + "Arg"
+	}
+	return param
+}
+
+func (a *api) goType(t *typeDesc) string {
+	var ans string
+	switch t.kind {
+	case kindString:
+		ans = "string"
+	case kindInt:
+		ans = "int"
+	case kindFloat:
+		ans = "float64"
+	case kindBool:
+		ans = "bool"
+	case kindList:
+		return "[]" + a.goType(t.elem)
+	case kindMap:
+		return "map[string]" + a.goType(t.elem)
+	case kindStruct:
+		ans = a.typeNames[t.structType]
+	default:
+		return "json.RawMessage"
+	}
+	if t.nullable {
+		return "*" + ans
+	}
+	return ans
+}
+
+func (a *api) goPathExpr(routePath string) string {
+	parts := make([]string, 0, 5)
+	var lit strings.Builder
+	for _, seg := range strings.Split(routePath, "/")[1:] {
+		lit.WriteString("/")
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			parts = append(parts, strconv.Quote(lit.String()))
+			lit.Reset()
+			parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", goParamName(seg[1:])))
+
+		} else {
+			lit.WriteString(seg)
+		}
+	}
+	if lit.Len() > 0 {
+		parts = append(parts, strconv.Quote(lit.String()))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (a *api) writeGoMethod(b *strings.Builder, m methodDesc) {
+	fmt.Fprintf(b, "// %s calls %s %s", m.name, m.httpMethod, m.path)
+	if m.description != "" {
+		fmt.Fprintf(b, "\n// (%s)", m.description)
+	}
+	args := []string{"ctx context.Context"}
+	for _, p := range m.params {
+		args = append(args, goParamName(p)+" string")
+	}
+	args = append(args, "query url.Values")
+	bodyExpr := "nil"
+	if m.hasBody {
+		if m.request != nil {
+			args = append(args, "body "+a.goType(m.request))
+
+		} else {
+			args = append(args, "body any")
+		}
+		bodyExpr = "body"
+	}
+	respType := "json.RawMessage"
+	if m.response != nil {
+		respType = a.goType(m.response)
+	}
+	fmt.Fprintf(
+		b, "\nfunc (c *Client) %s(%s) (%s, error) {\n", m.name, strings.Join(args, ", "), respType)
+	fmt.Fprintf(b, "\tvar ans %s\n", respType)
+	fmt.Fprintf(
+		b, "\terr := c.call(ctx, %s, %s, query, %s, &ans)\n",
+		strconv.Quote(m.httpMethod), a.goPathExpr(m.path), bodyExpr)
+	b.WriteString("\treturn ans, err\n}\n\n")
+}
+
+func generateGo(a *api) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by CNC-MASM %s; DO NOT EDIT.\n", a.version)
+	b.WriteString(goClientBase)
+	b.WriteString("\n")
+	for _, t := range a.sortedStructs() {
+		fmt.Fprintf(&b, "type %s struct {\n", a.typeNames[t])
+		for _, f := range a.structs[t] {
+			tag := f.jsonName
+			if f.omitEmpty {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&b, "\t%s %s `json:%s`\n", f.goName, a.goType(f.typ), strconv.Quote(tag))
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, m := range a.methods {
+		a.writeGoMethod(&b, m)
+	}
+	return format.Source([]byte(b.String()))
+}
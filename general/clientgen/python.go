@@ -0,0 +1,201 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package clientgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+const pythonClientBase = `"""
+Typed client of the CNC-MASM API
+"""
+
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Optional, TypedDict
+
+
+class MasmError(Exception):
+    """Raised in case MASM responds with an error status"""
+
+    def __init__(self, status: int, body: str):
+        super().__init__(f'MASM responded with status {status}: {body}')
+        self.status = status
+        self.body = body
+
+
+def _quote(value: str) -> str:
+    return urllib.parse.quote(value, safe='')
+
+`
+
+const pythonClientCall = `
+class Client:
+
+    def __init__(self, base_url: str, timeout: float = 60.0):
+        self._base_url = base_url.rstrip('/')
+        self._timeout = timeout
+
+    def _call(self, method: str, path: str, query: Optional[Dict[str, Any]] = None, body: Any = None) -> Any:
+        url = self._base_url + path
+        if query:
+            url += '?' + urllib.parse.urlencode(query, doseq=True)
+        headers = {}
+        data = None
+        if body is not None:
+            data = json.dumps(body).encode('utf-8')
+            headers['Content-Type'] = 'application/json'
+        req = urllib.request.Request(url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(req, timeout=self._timeout) as resp:
+                raw = resp.read()
+        except urllib.error.HTTPError as ex:
+            raise MasmError(ex.code, ex.read().decode('utf-8', errors='replace'))
+        return json.loads(raw) if raw else None
+`
+
+// pythonKeywords are reserved words which cannot
+// be used as names of method arguments
+var pythonKeywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true,
+	"break": true, "class": true, "continue": true, "def": true, "del": true,
+	"elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true,
+	"with": true, "yield": true, "self": true, "query": true, "body": true,
+}
+
+func pythonParamName(param string) string {
+	ans := snakeCase(param)
+	if pythonKeywords[ans] {
+		return ans + "_"
+	}
+	return ans
+}
+
+// pythonStr creates a single-quoted Python string literal
+func pythonStr(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+func (a *api) pythonType(t *typeDesc) string {
+	var ans string
+	switch t.kind {
+	case kindString:
+		ans = "str"
+	case kindInt:
+		ans = "int"
+	case kindFloat:
+		ans = "float"
+	case kindBool:
+		ans = "bool"
+	case kindList:
+		ans = fmt.Sprintf("List[%s]", a.pythonType(t.elem))
+	case kindMap:
+		ans = fmt.Sprintf("Dict[str, %s]", a.pythonType(t.elem))
+	case kindStruct:
+		// forward references allow for any order of definitions
+		ans = pythonStr(a.typeNames[t.structType])
+	default:
+		return "Any"
+	}
+	if t.nullable {
+		return fmt.Sprintf("Optional[%s]", ans)
+	}
+	return ans
+}
+
+func (a *api) pythonPathExpr(routePath string) string {
+	parts := make([]string, 0, 5)
+	var lit strings.Builder
+	for _, seg := range strings.Split(routePath, "/")[1:] {
+		lit.WriteString("/")
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			parts = append(parts, pythonStr(lit.String()))
+			lit.Reset()
+			parts = append(parts, fmt.Sprintf("_quote(%s)", pythonParamName(seg[1:])))
+
+		} else {
+			lit.WriteString(seg)
+		}
+	}
+	if lit.Len() > 0 {
+		parts = append(parts, pythonStr(lit.String()))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (a *api) writePythonMethod(b *strings.Builder, m methodDesc) {
+	args := []string{"self"}
+	for _, p := range m.params {
+		args = append(args, pythonParamName(p)+": str")
+	}
+	bodyExpr := "None"
+	if m.hasBody {
+		if m.request != nil {
+			args = append(args, "body: "+a.pythonType(m.request))
+
+		} else {
+			args = append(args, "body: Any = None")
+		}
+		bodyExpr = "body"
+	}
+	args = append(args, "query: Optional[Dict[str, Any]] = None")
+	respType := "Any"
+	if m.response != nil {
+		respType = a.pythonType(m.response)
+	}
+	fmt.Fprintf(
+		b, "\n    def %s(%s) -> %s:\n", snakeCase(m.name), strings.Join(args, ", "), respType)
+	doc := m.httpMethod + " " + m.path
+	if m.description != "" {
+		doc += " (" + m.description + ")"
+	}
+	doc = strings.ReplaceAll(strings.ReplaceAll(doc, `\`, `\\`), `"""`, `\"\"\"`)
+	fmt.Fprintf(b, "        \"\"\"%s\"\"\"\n", doc)
+	fmt.Fprintf(
+		b, "        return self._call(%s, %s, query, %s)\n",
+		pythonStr(m.httpMethod), a.pythonPathExpr(m.path), bodyExpr)
+}
+
+func generatePython(a *api) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code generated by CNC-MASM %s; DO NOT EDIT.\n\n", a.version)
+	b.WriteString(pythonClientBase)
+	for _, t := range a.sortedStructs() {
+		name := a.typeNames[t]
+		fmt.Fprintf(&b, "\n%s = TypedDict(%s, {\n", name, pythonStr(name))
+		for _, f := range a.structs[t] {
+			fmt.Fprintf(&b, "    %s: %s,\n", pythonStr(f.jsonName), a.pythonType(f.typ))
+		}
+		b.WriteString("}, total=False)\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(pythonClientCall)
+	for _, m := range a.methods {
+		a.writePythonMethod(&b, m)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package confirm provides one-time confirmation tokens for destructive
+// operations. The first request describes what is going to be removed
+// and issues a token, the second request (with the token) performs the
+// operation. This prevents from accidental removals caused e.g. by a typo
+// in a corpus ID.
+package confirm
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrorInvalidToken = errors.New("invalid or expired confirmation token")
+)
+
+// Operation describes a destructive operation
+type Operation struct {
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Summary any    `json:"summary"`
+}
+
+// Token is an issued confirmation token along with
+// the operation it confirms
+type Token struct {
+	Operation
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Registry issues and validates confirmation tokens
+type Registry struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens map[string]Token
+}
+
+func (r *Registry) removeExpired(now time.Time) {
+	for k, v := range r.tokens {
+		if now.After(v.Expires) {
+			delete(r.tokens, k)
+		}
+	}
+}
+
+// Issue creates a new confirmation token for an operation
+func (r *Registry) Issue(op Operation) Token {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.removeExpired(now)
+	ans := Token{
+		Operation: op,
+		Token:     uuid.New().String(),
+		Expires:   now.Add(r.ttl),
+	}
+	r.tokens[ans.Token] = ans
+	return ans
+}
+
+// Consume validates a token against an action and its target.
+// A valid token is removed so it cannot be used again. In case
+// the token is unknown, expired or it has been issued for
+// a different operation, ErrorInvalidToken is returned.
+func (r *Registry) Consume(token, action, target string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeExpired(time.Now())
+	tk, ok := r.tokens[token]
+	if !ok || tk.Action != action || tk.Target != target {
+		return ErrorInvalidToken
+	}
+	delete(r.tokens, token)
+	return nil
+}
+
+// NewRegistry creates a new Registry with tokens valid
+// for the specified time
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		ttl:    ttl,
+		tokens: make(map[string]Token),
+	}
+}
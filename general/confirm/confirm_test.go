@@ -0,0 +1,51 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package confirm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndConsume(t *testing.T) {
+	reg := NewRegistry(time.Minute)
+	tk := reg.Issue(Operation{Action: "delete", Target: "corp1", Summary: map[string]int{"numRows": 10}})
+	assert.NotEmpty(t, tk.Token)
+	assert.NoError(t, reg.Consume(tk.Token, "delete", "corp1"))
+	// tokens are one-time only
+	assert.ErrorIs(t, reg.Consume(tk.Token, "delete", "corp1"), ErrorInvalidToken)
+}
+
+func TestConsumeWrongTarget(t *testing.T) {
+	reg := NewRegistry(time.Minute)
+	tk := reg.Issue(Operation{Action: "delete", Target: "corp1"})
+	assert.ErrorIs(t, reg.Consume(tk.Token, "delete", "corp2"), ErrorInvalidToken)
+	assert.ErrorIs(t, reg.Consume(tk.Token, "truncate", "corp1"), ErrorInvalidToken)
+	assert.ErrorIs(t, reg.Consume("foo", "delete", "corp1"), ErrorInvalidToken)
+	// failed attempts do not invalidate the token
+	assert.NoError(t, reg.Consume(tk.Token, "delete", "corp1"))
+}
+
+func TestConsumeExpired(t *testing.T) {
+	reg := NewRegistry(-time.Second)
+	tk := reg.Issue(Operation{Action: "delete", Target: "corp1"})
+	assert.ErrorIs(t, reg.Consume(tk.Token, "delete", "corp1"), ErrorInvalidToken)
+}
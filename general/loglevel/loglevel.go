@@ -0,0 +1,201 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package loglevel allows for changing log levels at runtime (without
+// restarting the service). Besides the default level, modules using
+// their own logger (see Module) can have their own levels so e.g.
+// debug messages of a single module can be enabled.
+package loglevel
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Levels describes actual log levels
+type Levels struct {
+	Default string            `json:"default"`
+	Modules map[string]string `json:"modules"`
+}
+
+type registry struct {
+	mu      sync.RWMutex
+	base    zerolog.Logger
+	dflt    zerolog.Level
+	levels  map[string]zerolog.Level
+	modules map[string]*ModuleLogger
+}
+
+var reg = &registry{
+	base:    log.Logger,
+	dflt:    zerolog.GlobalLevel(),
+	levels:  make(map[string]zerolog.Level),
+	modules: make(map[string]*ModuleLogger),
+}
+
+// levelOf returns an effective level of a module
+// (an empty module means the default level)
+func (r *registry) levelOf(module string) zerolog.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if v, ok := r.levels[module]; ok {
+		return v
+	}
+	return r.dflt
+}
+
+// updateGlobalLevel sets zerolog's global level to the lowest
+// of the configured levels so no enabled event gets filtered
+// out before our hooks see it. Caller must hold the lock.
+func (r *registry) updateGlobalLevel() {
+	lowest := r.dflt
+	for _, v := range r.levels {
+		if v < lowest {
+			lowest = v
+		}
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// levelHook discards events below the effective level of a module
+type levelHook struct {
+	module string
+}
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < reg.levelOf(h.module) {
+		e.Discard()
+	}
+}
+
+// ModuleLogger is a logger of a module with its own log level.
+// It is safe to create it (see Module) before logging is set up.
+type ModuleLogger struct {
+	name   string
+	logger atomic.Pointer[zerolog.Logger]
+}
+
+func (ml *ModuleLogger) get() *zerolog.Logger {
+	if l := ml.logger.Load(); l != nil {
+		return l
+	}
+	reg.mu.RLock()
+	l := reg.base.With().Str("module", ml.name).Logger().Hook(levelHook{module: ml.name})
+	reg.mu.RUnlock()
+	ml.logger.Store(&l)
+	return &l
+}
+
+func (ml *ModuleLogger) Debug() *zerolog.Event {
+	return ml.get().Debug()
+}
+
+func (ml *ModuleLogger) Info() *zerolog.Event {
+	return ml.get().Info()
+}
+
+func (ml *ModuleLogger) Warn() *zerolog.Event {
+	return ml.get().Warn()
+}
+
+func (ml *ModuleLogger) Error() *zerolog.Event {
+	return ml.get().Error()
+}
+
+// Module returns a logger of a module. Loggers are created
+// once per module name.
+func Module(name string) *ModuleLogger {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if ml, ok := reg.modules[name]; ok {
+		return ml
+	}
+	ml := &ModuleLogger{name: name}
+	reg.modules[name] = ml
+	return ml
+}
+
+// Init takes the actual global logger and level as the default ones.
+// It must be called once the logging is set up and before any
+// concurrent logging starts.
+func Init() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.base = log.Logger
+	reg.dflt = zerolog.GlobalLevel()
+	for _, ml := range reg.modules {
+		ml.logger.Store(nil)
+	}
+	log.Logger = reg.base.Hook(levelHook{})
+	reg.updateGlobalLevel()
+}
+
+// SetLevel sets a log level of a module. An empty module means
+// the default level (used by code outside of modules and by modules
+// without their own level).
+func SetLevel(module string, level zerolog.Level) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if module == "" {
+		reg.dflt = level
+
+	} else if _, ok := reg.modules[module]; ok {
+		reg.levels[module] = level
+
+	} else {
+		return fmt.Errorf("unknown module %s", module)
+	}
+	reg.updateGlobalLevel()
+	return nil
+}
+
+// ResetLevel removes an own log level of a module
+// so the module uses the default level again.
+func ResetLevel(module string) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.modules[module]; !ok {
+		return fmt.Errorf("unknown module %s", module)
+	}
+	delete(reg.levels, module)
+	reg.updateGlobalLevel()
+	return nil
+}
+
+// GetLevels returns the default level and effective
+// levels of all the modules
+func GetLevels() Levels {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	ans := Levels{
+		Default: reg.dflt.String(),
+		Modules: make(map[string]string, len(reg.modules)),
+	}
+	for name := range reg.modules {
+		level, ok := reg.levels[name]
+		if !ok {
+			level = reg.dflt
+		}
+		ans.Modules[name] = level.String()
+	}
+	return ans
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package loglevel
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestLogger(t *testing.T) *bytes.Buffer {
+	origLogger := log.Logger
+	origLevel := zerolog.GlobalLevel()
+	t.Cleanup(func() {
+		log.Logger = origLogger
+		zerolog.SetGlobalLevel(origLevel)
+		reg.levels = make(map[string]zerolog.Level)
+	})
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	Init()
+	return &buf
+}
+
+func TestModuleLevel(t *testing.T) {
+	buf := setupTestLogger(t)
+	ml := Module("test1")
+	ml.Debug().Msg("hidden")
+	log.Debug().Msg("hidden")
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, SetLevel("test1", zerolog.DebugLevel))
+	ml.Debug().Msg("module debug")
+	log.Debug().Msg("hidden")
+	assert.Contains(t, buf.String(), "module debug")
+	assert.Contains(t, buf.String(), `"module":"test1"`)
+	assert.NotContains(t, buf.String(), "hidden")
+
+	buf.Reset()
+	assert.NoError(t, ResetLevel("test1"))
+	ml.Debug().Msg("hidden")
+	ml.Info().Msg("module info")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "module info")
+}
+
+func TestDefaultLevel(t *testing.T) {
+	buf := setupTestLogger(t)
+	ml := Module("test2")
+	assert.NoError(t, SetLevel("", zerolog.ErrorLevel))
+	ml.Warn().Msg("hidden")
+	log.Warn().Msg("hidden")
+	ml.Error().Msg("module error")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "module error")
+}
+
+func TestUnknownModule(t *testing.T) {
+	setupTestLogger(t)
+	assert.Error(t, SetLevel("nonexistent", zerolog.DebugLevel))
+	assert.Error(t, ResetLevel("nonexistent"))
+}
+
+func TestGetLevels(t *testing.T) {
+	setupTestLogger(t)
+	Module("test3")
+	assert.NoError(t, SetLevel("test3", zerolog.WarnLevel))
+	levels := GetLevels()
+	assert.Equal(t, "info", levels.Default)
+	assert.Equal(t, "warn", levels.Modules["test3"])
+}
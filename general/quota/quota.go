@@ -0,0 +1,363 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package quota limits a daily number of expensive operations
+// (e.g. liveattrs queries) per API token and corpus so a single
+// runaway client (typically a research script) cannot exhaust shared
+// infrastructure. MASM does not authenticate clients by itself,
+// so tokens are just values of a configured request header (set
+// by a proxy or by clients). Counters are kept in memory and they
+// are reset at midnight. Only operations on existing corpora are
+// counted and the number of counters is limited so arbitrary tokens
+// and corpus IDs cannot make the tracker grow without bounds.
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"masm/v3/general/collections"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	OpQuery            = "query"
+	OpAutocomplete     = "autocomplete"
+	OpDocumentList     = "documentList"
+	OpDocumentListPage = "documentListPage"
+	OpNgrams           = "ngrams"
+
+	// AnonymousToken is used for requests without a token
+	AnonymousToken = "anonymous"
+
+	dfltTokenHeader = "Authorization"
+
+	// dfltMaxCounters limits the number of counters (i.e. combinations
+	// of tokens, corpora and operations) kept in memory
+	dfltMaxCounters = 100000
+)
+
+var allOperations = []string{OpQuery, OpAutocomplete, OpDocumentList, OpDocumentListPage, OpNgrams}
+
+// ErrQuotaExceeded is returned by Tracker.Charge in case
+// a daily quota has been reached
+var ErrQuotaExceeded = errors.New("daily quota exceeded")
+
+// Conf configures daily quotas. Limits are resolved in the order
+// Tokens, Corpora, Limits (the first one specifying the operation
+// is used). A missing or zero limit means no limit.
+type Conf struct {
+
+	// TokenHeader is a request header containing a client token
+	// (default is `Authorization`, a `Bearer` prefix is removed)
+	TokenHeader string `json:"tokenHeader"`
+
+	// Limits maps operations to default daily limits
+	Limits map[string]int `json:"limits"`
+
+	// Corpora maps corpora to their own operation limits
+	Corpora map[string]map[string]int `json:"corpora"`
+
+	// Tokens maps tokens to their own operation limits
+	Tokens map[string]map[string]int `json:"tokens"`
+}
+
+func validateLimits(limits map[string]int, scope string) error {
+	for op, limit := range limits {
+		if !collections.SliceContains(allOperations, op) {
+			return fmt.Errorf("unknown operation %s in %s (supported: %v)", op, scope, allOperations)
+		}
+		if limit < 0 {
+			return fmt.Errorf("limit of %s in %s must not be negative", op, scope)
+		}
+	}
+	return nil
+}
+
+// Validate tests whether limits are non-negative and whether
+// they use only known operations
+func (conf *Conf) Validate() error {
+	if err := validateLimits(conf.Limits, "limits"); err != nil {
+		return err
+	}
+	for corpusID, limits := range conf.Corpora {
+		if err := validateLimits(limits, "corpora."+corpusID); err != nil {
+			return err
+		}
+	}
+	for token, limits := range conf.Tokens {
+		if err := validateLimits(limits, "token "+Fingerprint(token)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Limit returns a daily limit of an operation for a token and a corpus
+// (zero means no limit)
+func (conf *Conf) Limit(token, corpusID, op string) int {
+	if limit, ok := conf.Tokens[token][op]; ok {
+		return limit
+	}
+	if limit, ok := conf.Corpora[corpusID][op]; ok {
+		return limit
+	}
+	return conf.Limits[op]
+}
+
+// Fingerprint returns a shortened hash of a token so tokens
+// can be identified in logs and reports without being revealed
+func Fingerprint(token string) string {
+	if token == AnonymousToken {
+		return token
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Usage describes a daily usage of an operation by a token
+// on a corpus
+type Usage struct {
+	Token     string `json:"token"`
+	CorpusID  string `json:"corpusId"`
+	Operation string `json:"operation"`
+	Used      int    `json:"used"`
+
+	// Limit is zero for unlimited operations
+	Limit int `json:"limit"`
+}
+
+// Report is an overview of the actual daily usage
+type Report struct {
+	Enabled bool    `json:"enabled"`
+	Day     string  `json:"day"`
+	Usage   []Usage `json:"usage"`
+}
+
+type usageKey struct {
+	token    string
+	corpusID string
+	op       string
+}
+
+// Tracker counts usage of operations and enforces configured quotas.
+// A nil tracker means no quotas.
+type Tracker struct {
+	conf  *Conf
+	mu    sync.Mutex
+	day   string
+	usage map[usageKey]int
+	now   func() time.Time
+
+	// corpusExists tests whether operations on a corpus should
+	// be counted (nil means all corpora are counted)
+	corpusExists func(corpusID string) bool
+
+	// maxCounters limits the size of usage. Once reached, new
+	// tokens are counted as AnonymousToken.
+	maxCounters int
+}
+
+// resetIfNewDay clears counters in case the day has changed.
+// The tracker must be locked.
+func (t *Tracker) resetIfNewDay() {
+	day := t.now().Format("2006-01-02")
+	if day != t.day {
+		t.day = day
+		t.usage = make(map[usageKey]int)
+	}
+}
+
+// Take increments usage of an operation unless its daily limit
+// has been reached already. The returned usage is the actual one
+// (i.e. after the increment, if any). Operations on non-existing
+// corpora are not counted (the respective handlers reject them anyway).
+func (t *Tracker) Take(token, corpusID, op string) (Usage, bool) {
+	ans := Usage{Token: Fingerprint(token), CorpusID: corpusID, Operation: op}
+	if t == nil || t.corpusExists != nil && !t.corpusExists(corpusID) {
+		return ans, true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resetIfNewDay()
+	key := usageKey{token: token, corpusID: corpusID, op: op}
+	if _, ok := t.usage[key]; !ok && len(t.usage) >= t.maxCounters {
+		key.token = AnonymousToken
+		ans.Token = AnonymousToken
+	}
+	ans.Limit = t.conf.Limit(token, corpusID, op)
+	ans.Used = t.usage[key]
+	if ans.Limit > 0 && ans.Used >= ans.Limit {
+		return ans, false
+	}
+	ans.Used++
+	t.usage[key] = ans.Used
+	return ans, true
+}
+
+// Report returns the actual daily usage sorted by tokens,
+// corpora and operations
+func (t *Tracker) Report() Report {
+	if t == nil {
+		return Report{Usage: []Usage{}}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resetIfNewDay()
+	ans := Report{Enabled: true, Day: t.day, Usage: make([]Usage, 0, len(t.usage))}
+	for key, used := range t.usage {
+		ans.Usage = append(ans.Usage, Usage{
+			Token:     Fingerprint(key.token),
+			CorpusID:  key.corpusID,
+			Operation: key.op,
+			Used:      used,
+			Limit:     t.conf.Limit(key.token, key.corpusID, key.op),
+		})
+	}
+	sort.Slice(ans.Usage, func(i, j int) bool {
+		a, b := ans.Usage[i], ans.Usage[j]
+		if a.Token != b.Token {
+			return a.Token < b.Token
+		}
+		if a.CorpusID != b.CorpusID {
+			return a.CorpusID < b.CorpusID
+		}
+		return a.Operation < b.Operation
+	})
+	return ans
+}
+
+// requestToken extracts a client token from a request
+func (t *Tracker) requestToken(req *http.Request) string {
+	header := t.conf.TokenHeader
+	if header == "" {
+		header = dfltTokenHeader
+	}
+	token := strings.TrimSpace(req.Header.Get(header))
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return AnonymousToken
+	}
+	return token
+}
+
+func logExceeded(usage Usage) {
+	log.Warn().
+		Str("token", usage.Token).
+		Str("corpusId", usage.CorpusID).
+		Str("operation", usage.Operation).
+		Int("limit", usage.Limit).
+		Msg("daily quota exceeded")
+}
+
+// Guard wraps a handler of a corpus-specific operation (the corpus
+// is expected to be in the `corpusId` URL parameter) so requests
+// exceeding the daily quota are rejected with status 429.
+func (t *Tracker) Guard(op string, handler gin.HandlerFunc) gin.HandlerFunc {
+	if t == nil {
+		return handler
+	}
+	return func(ctx *gin.Context) {
+		token := t.requestToken(ctx.Request)
+		usage, ok := t.Take(token, ctx.Param("corpusId"), op)
+		if usage.Limit > 0 {
+			ctx.Header("X-Quota-Limit", strconv.Itoa(usage.Limit))
+			ctx.Header("X-Quota-Remaining", strconv.Itoa(usage.Limit-usage.Used))
+		}
+		if !ok {
+			logExceeded(usage)
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(
+					"daily quota of operation %s on corpus %s exceeded (%d)",
+					op, usage.CorpusID, usage.Limit),
+				http.StatusTooManyRequests,
+			)
+			return
+		}
+		handler(ctx)
+	}
+}
+
+type tokenCtxKey struct{}
+
+// WithRequestToken returns a context carrying a client token
+// of a request so operations performed within a single request
+// on multiple corpora (e.g. multi-corpus queries or GraphQL)
+// can be charged via Charge.
+func (t *Tracker) WithRequestToken(ctx context.Context, req *http.Request) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenCtxKey{}, t.requestToken(req))
+}
+
+// Charge increments usage of an operation on a corpus by a client
+// specified by a token stored in the context (see WithRequestToken).
+// In case the daily quota has been reached, ErrQuotaExceeded is returned.
+func (t *Tracker) Charge(ctx context.Context, corpusID, op string) error {
+	if t == nil {
+		return nil
+	}
+	token, ok := ctx.Value(tokenCtxKey{}).(string)
+	if !ok {
+		token = AnonymousToken
+	}
+	usage, ok := t.Take(token, corpusID, op)
+	if !ok {
+		logExceeded(usage)
+		return fmt.Errorf(
+			"%w: operation %s on corpus %s (%d)", ErrQuotaExceeded, op, corpusID, usage.Limit)
+	}
+	return nil
+}
+
+// ReportAction shows the actual daily usage of operations
+// (tokens are replaced by their fingerprints)
+func (t *Tracker) ReportAction(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, t.Report())
+}
+
+// NewTracker creates a tracker of configured quotas. Only operations
+// on corpora accepted by corpusExists are counted. In case the
+// configuration is nil, nil is returned (i.e. no quotas).
+func NewTracker(conf *Conf, corpusExists func(corpusID string) bool) *Tracker {
+	if conf == nil {
+		return nil
+	}
+	return &Tracker{
+		conf:         conf,
+		usage:        make(map[usageKey]int),
+		now:          time.Now,
+		corpusExists: corpusExists,
+		maxCounters:  dfltMaxCounters,
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package quota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestingTracker() *Tracker {
+	return NewTracker(&Conf{
+		Limits:  map[string]int{OpQuery: 2, OpDocumentList: 1},
+		Corpora: map[string]map[string]int{"syn2020": {OpQuery: 3}},
+		Tokens:  map[string]map[string]int{"vip": {OpQuery: 0}},
+	}, nil)
+}
+
+func TestLimitResolution(t *testing.T) {
+	conf := newTestingTracker().conf
+	assert.Equal(t, 2, conf.Limit("abc", "bnc", OpQuery))
+	assert.Equal(t, 3, conf.Limit("abc", "syn2020", OpQuery))
+	assert.Equal(t, 0, conf.Limit("vip", "syn2020", OpQuery))
+	assert.Equal(t, 0, conf.Limit("abc", "bnc", OpNgrams))
+}
+
+func TestTakeEnforcesLimit(t *testing.T) {
+	tracker := newTestingTracker()
+	_, ok := tracker.Take("abc", "bnc", OpQuery)
+	assert.True(t, ok)
+	usage, ok := tracker.Take("abc", "bnc", OpQuery)
+	assert.True(t, ok)
+	assert.Equal(t, 2, usage.Used)
+	usage, ok = tracker.Take("abc", "bnc", OpQuery)
+	assert.False(t, ok)
+	assert.Equal(t, 2, usage.Used)
+
+	// other tokens and corpora have their own counters
+	_, ok = tracker.Take("def", "bnc", OpQuery)
+	assert.True(t, ok)
+	_, ok = tracker.Take("abc", "syn2020", OpQuery)
+	assert.True(t, ok)
+	for i := 0; i < 5; i++ {
+		_, ok = tracker.Take("vip", "bnc", OpQuery)
+		assert.True(t, ok)
+	}
+}
+
+func TestCountersResetNextDay(t *testing.T) {
+	tracker := newTestingTracker()
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)
+	tracker.now = func() time.Time { return now }
+	_, ok := tracker.Take("abc", "bnc", OpDocumentList)
+	assert.True(t, ok)
+	_, ok = tracker.Take("abc", "bnc", OpDocumentList)
+	assert.False(t, ok)
+	now = now.Add(2 * time.Minute)
+	_, ok = tracker.Take("abc", "bnc", OpDocumentList)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-05-02", tracker.Report().Day)
+}
+
+func TestNonExistingCorpusNotCounted(t *testing.T) {
+	tracker := NewTracker(
+		&Conf{Limits: map[string]int{OpQuery: 1}},
+		func(corpusID string) bool { return corpusID == "bnc" },
+	)
+	for i := 0; i < 3; i++ {
+		_, ok := tracker.Take("abc", "foo", OpQuery)
+		assert.True(t, ok)
+	}
+	assert.Empty(t, tracker.Report().Usage)
+	_, ok := tracker.Take("abc", "bnc", OpQuery)
+	assert.True(t, ok)
+	_, ok = tracker.Take("abc", "bnc", OpQuery)
+	assert.False(t, ok)
+}
+
+func TestCountersLimited(t *testing.T) {
+	tracker := newTestingTracker()
+	tracker.maxCounters = 2
+	tracker.Take("abc", "bnc", OpQuery)
+	tracker.Take("def", "bnc", OpQuery)
+	// new tokens are counted as anonymous once the limit is reached
+	usage, ok := tracker.Take("ghi", "bnc", OpQuery)
+	assert.True(t, ok)
+	assert.Equal(t, AnonymousToken, usage.Token)
+	_, ok = tracker.Take("jkl", "bnc", OpQuery)
+	assert.True(t, ok)
+	_, ok = tracker.Take("mno", "bnc", OpQuery)
+	assert.False(t, ok)
+	assert.Len(t, tracker.Report().Usage, 3)
+	// existing counters are still used
+	usage, ok = tracker.Take("abc", "bnc", OpQuery)
+	assert.True(t, ok)
+	assert.Equal(t, Fingerprint("abc"), usage.Token)
+}
+
+func TestReportHidesTokens(t *testing.T) {
+	tracker := newTestingTracker()
+	tracker.Take("secret", "bnc", OpQuery)
+	tracker.Take(AnonymousToken, "bnc", OpQuery)
+	report := tracker.Report()
+	assert.True(t, report.Enabled)
+	assert.Len(t, report.Usage, 2)
+	for _, u := range report.Usage {
+		assert.NotEqual(t, "secret", u.Token)
+		assert.Equal(t, 2, u.Limit)
+	}
+	assert.Equal(t, Fingerprint("secret"), report.Usage[0].Token)
+	assert.Equal(t, AnonymousToken, report.Usage[1].Token)
+}
+
+func TestGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := newTestingTracker()
+	engine := gin.New()
+	engine.POST("/:corpusId/documentList", tracker.Guard(OpDocumentList, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	}))
+	send := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bnc/documentList", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := send("abc")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, http.StatusTooManyRequests, send("abc").Code)
+	assert.Equal(t, http.StatusOK, send("def").Code)
+}
+
+func TestCharge(t *testing.T) {
+	tracker := newTestingTracker()
+	req := httptest.NewRequest(http.MethodPost, "/liveAttributes/_multiQuery", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	ctx := tracker.WithRequestToken(context.Background(), req)
+	assert.NoError(t, tracker.Charge(ctx, "bnc", OpDocumentList))
+	assert.ErrorIs(t, tracker.Charge(ctx, "bnc", OpDocumentList), ErrQuotaExceeded)
+	assert.NoError(t, tracker.Charge(ctx, "syn2020", OpDocumentList))
+	usage, _ := tracker.Take("abc", "bnc", OpDocumentList)
+	assert.Equal(t, 1, usage.Used)
+
+	// a context without a token is charged as anonymous
+	assert.NoError(t, tracker.Charge(context.Background(), "bnc", OpDocumentList))
+	assert.Error(t, tracker.Charge(context.Background(), "bnc", OpDocumentList))
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	_, ok := tracker.Take("abc", "bnc", OpQuery)
+	assert.True(t, ok)
+	assert.False(t, tracker.Report().Enabled)
+	assert.NoError(t, tracker.Charge(context.Background(), "bnc", OpQuery))
+	assert.Nil(t, NewTracker(nil, nil))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, newTestingTracker().conf.Validate())
+	conf := &Conf{Corpora: map[string]map[string]int{"bnc": {"freqs": 1}}}
+	assert.Error(t, conf.Validate())
+	conf = &Conf{Limits: map[string]int{OpQuery: -1}}
+	assert.Error(t, conf.Validate())
+}
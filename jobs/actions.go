@@ -20,6 +20,7 @@ package jobs
 
 import (
 	"fmt"
+	"masm/v3/notifications"
 	"net/http"
 	"os"
 	"reflect"
@@ -27,9 +28,7 @@ import (
 	"sync"
 	"time"
 
-	cncmail "github.com/czcorpus/cnc-gokit/mail"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog/log"
 	"golang.org/x/text/message"
 
 	"github.com/czcorpus/cnc-gokit/fs"
@@ -67,7 +66,57 @@ type Actions struct {
 	// updated
 	tableUpdate chan TableUpdate
 
-	notificationRecipients map[string][]string
+	notificationRecipients map[string][]NotificationRecipient
+
+	// notificationTemplates renders notifications
+	// of finished jobs and digests
+	notificationTemplates *notificationTemplates
+
+	// userDigests contains outcomes of jobs waiting for a daily
+	// digest of recipients registered with the digest mode
+	userDigests     map[string][]DigestItem
+	userDigestsLock sync.Mutex
+
+	// jobRequests contains originating requests of jobs
+	// (see RecordingRequest, AttachRequest)
+	jobRequests     map[string]*JobRequest
+	jobRequestsLock sync.Mutex
+
+	// requestHandler is used for re-submitting job requests
+	// and for submitting scheduled requests
+	requestHandler http.Handler
+
+	schedules     map[string]*Schedule
+	schedulesLock sync.Mutex
+
+	// replicatedJobs contains IDs of detached jobs imported
+	// from a primary instance (see ImportJobHistory)
+	replicatedJobs map[string]bool
+
+	// jobLogs contains log entries captured during jobs'
+	// execution (see JobLogger)
+	jobLogs *jobLogs
+
+	// jobArchive stores jobs removed from jobList once their
+	// retention expires (nil if not configured)
+	jobArchive *JobArchive
+
+	// jobResources records system resources consumed
+	// by jobs (see ResourceUsage)
+	jobResources *jobResources
+
+	// jobEvents distributes changes of jobs
+	// to clients of JobEvents
+	jobEvents *jobEventBroker
+
+	// pauseGates contains gates of running jobs
+	// supporting pausing (see PauseGate)
+	pauseGates     map[string]*PauseGate
+	pauseGatesLock sync.Mutex
+
+	// jobRetries contains jobs which can be retried
+	// automatically (see RetryPolicy)
+	jobRetries *jobRetries
 }
 
 func (a *Actions) TestAllowsJobRestart(jinfo GeneralJobInfo) error {
@@ -91,7 +140,8 @@ func (a *Actions) EnqueueJob(fn *QueuedFunc, initialStatus GeneralJobInfo) {
 	a.jobQueueLock.Lock()
 	a.jobQueue.Enqueue(fn, initialStatus)
 	a.jobQueueLock.Unlock()
-	log.Info().Msgf("Enqueued job %s", initialStatus.GetID())
+	a.jobEvents.publish(JobEventCreated, initialStatus)
+	logger.Info().Msgf("Enqueued job %s", initialStatus.GetID())
 }
 
 func (a *Actions) EqueueJobAfter(fn *QueuedFunc, initialStatus GeneralJobInfo, parentJobID string) {
@@ -99,38 +149,49 @@ func (a *Actions) EqueueJobAfter(fn *QueuedFunc, initialStatus GeneralJobInfo, p
 	a.jobQueue.Enqueue(fn, initialStatus)
 	a.jobQueueLock.Unlock()
 	a.jobDeps.Add(initialStatus.GetID(), parentJobID)
-	log.Info().Msgf("Enqueued job %s with parent %s", initialStatus.GetID(), parentJobID)
+	a.jobEvents.publish(JobEventCreated, initialStatus)
+	logger.Info().Msgf("Enqueued job %s with parent %s", initialStatus.GetID(), parentJobID)
 }
 
-func (a *Actions) dequeueAndRunJob() {
-	fn, initState, err := a.jobQueue.Dequeue()
-	if err == nil {
-		log.Info().
-			Float32(
-				"utilization",
-				float32(a.numOfUnfinishedJobs())/float32(a.conf.MaxNumConcurrentJobs),
-			).
-			Str("jobId", initState.GetID()).
-			Str("jobType", initState.GetType()).
-			Str("corpus", initState.GetCorpus()).
-			Msgf("Dequeued a new job")
-		updateJobChan := a.addJobInfo(initState)
-		go func() {
-			(*fn)(updateJobChan)
-		}()
+func (a *Actions) dequeueAndRunJob(jobID string) GeneralJobInfo {
+	fn, initState, err := a.jobQueue.Remove(jobID)
+	if err != nil {
+		return nil
+	}
+	logger.Info().
+		Float32(
+			"utilization",
+			float32(a.numOfUnfinishedJobs())/float32(a.conf.MaxNumConcurrentJobs),
+		).
+		Str("jobId", initState.GetID()).
+		Str("jobType", initState.GetType()).
+		Str("corpus", initState.GetCorpus()).
+		Msgf("Dequeued a new job")
+	if policy := a.retryPolicy(initState.GetType()); policy != nil {
+		a.jobRetries.track(fn, initState, policy)
 	}
+	updateJobChan := a.addJobInfo(initState)
+	a.jobResources.start(initState.GetID())
+	go func() {
+		(*fn)(updateJobChan)
+	}()
+	return initState
 }
 
 // dequeueJobAsFailed can be used in case we know we cannot
 // run a job e.g. because of a failed dependency (= other job).
 // But we still need to respect basic workflow so we dequeue
 // the job, set the status and send it via a respective channel.
-func (a *Actions) dequeueJobAsFailed(err error) {
-	_, initState, _ := a.jobQueue.Dequeue()
+func (a *Actions) dequeueJobAsFailed(jobID string, err error) {
+	_, initState, rmErr := a.jobQueue.Remove(jobID)
+	if rmErr != nil {
+		return
+	}
 	finalState := initState.WithError(err)
 	updateJobChan := a.addJobInfo(finalState)
 	updateJobChan <- finalState.AsFinished()
-	log.Error().Err(err).Send()
+	close(updateJobChan)
+	logger.Error().Err(err).Send()
 }
 
 // addJobInfo add a new job to the job table and provides
@@ -138,7 +199,7 @@ func (a *Actions) dequeueJobAsFailed(err error) {
 func (a *Actions) addJobInfo(j GeneralJobInfo) chan GeneralJobInfo {
 	_, ok := a.detachedJobs[j.GetID()]
 	if ok {
-		log.Info().Msgf("Registering again detached job %s", j.GetID())
+		logger.Info().Msgf("Registering again detached job %s", j.GetID())
 		a.detachedJobsLock.Lock()
 		delete(a.detachedJobs, j.GetID())
 		a.detachedJobsLock.Unlock()
@@ -173,7 +234,7 @@ func (a *Actions) JobList(ctx *gin.Context) {
 		ans := make(JobInfoListCompact, 0, len(a.jobList))
 		for _, v := range a.jobList {
 			if !unOnly || !v.IsFinished() {
-				item := v.CompactVersion()
+				item := a.compactInfoWithResources(v)
 				ans = append(ans, &item)
 			}
 		}
@@ -185,7 +246,7 @@ func (a *Actions) JobList(ctx *gin.Context) {
 		sort.Sort(sort.Reverse(tmp))
 		ans := make([]any, len(tmp))
 		for i, item := range tmp {
-			ans[i] = item.FullInfo()
+			ans[i] = a.fullInfoWithResources(item)
 		}
 		uniresp.WriteJSONResponse(ctx.Writer, ans)
 	}
@@ -196,10 +257,10 @@ func (a *Actions) JobInfo(ctx *gin.Context) {
 	job := FindJob(a.jobList, ctx.Param("jobId"))
 	if job != nil {
 		if ctx.Request.URL.Query().Get("compact") == "1" {
-			uniresp.WriteJSONResponse(ctx.Writer, job.CompactVersion())
+			uniresp.WriteJSONResponse(ctx.Writer, a.compactInfoWithResources(job))
 
 		} else {
-			uniresp.WriteJSONResponse(ctx.Writer, job.FullInfo())
+			uniresp.WriteJSONResponse(ctx.Writer, a.fullInfoWithResources(job))
 		}
 
 	} else {
@@ -210,6 +271,17 @@ func (a *Actions) JobInfo(ctx *gin.Context) {
 func (a *Actions) Delete(ctx *gin.Context) {
 	job := FindJob(a.jobList, ctx.Param("jobId"))
 	if job != nil {
+		if a.cancelRetry(job.GetID()) {
+			// the job is not running so it just becomes finished
+			a.tableUpdate <- TableUpdate{
+				action: tableActionFinishJob,
+				itemID: job.GetID(),
+				data:   job,
+			}
+			uniresp.WriteJSONResponse(ctx.Writer, job)
+			return
+		}
+		a.ReleasePauseGate(job.GetID())
 		a.jobStop <- job.GetID()
 		uniresp.WriteJSONResponse(ctx.Writer, job)
 
@@ -230,15 +302,15 @@ func (a *Actions) ClearIfFinished(ctx *gin.Context) {
 
 func (a *Actions) OnExit() {
 	if a.conf.StatusDataPath != "" {
-		log.Info().Msgf("saving state to %s", a.conf.StatusDataPath)
+		logger.Info().Msgf("saving state to %s", a.conf.StatusDataPath)
 		jobList := a.createJobList(true)
 		err := jobList.Serialize(a.conf.StatusDataPath)
 		if err != nil {
-			log.Error().Err(err)
+			logger.Error().Err(err)
 		}
 
 	} else {
-		log.Warn().Msg("no status file specified, discarding job list")
+		logger.Warn().Msg("no status file specified, discarding job list")
 	}
 }
 
@@ -283,6 +355,20 @@ func (a *Actions) LastUnfinishedJobOfType(corpusID string, jobType string) (Gene
 	return tmp, tmp != nil && !reflect.ValueOf(tmp).IsNil()
 }
 
+// UnfinishedJobsOfCorpus returns all the unfinished jobs (of any type)
+// related to a corpus
+func (a *Actions) UnfinishedJobsOfCorpus(corpusID string) []GeneralJobInfo {
+	a.jobListLock.Lock()
+	defer a.jobListLock.Unlock()
+	ans := make([]GeneralJobInfo, 0, 5)
+	for _, v := range a.jobList {
+		if v.GetCorpus() == corpusID && !v.IsFinished() {
+			ans = append(ans, v)
+		}
+	}
+	return ans
+}
+
 func (a *Actions) GetJob(jobID string) (GeneralJobInfo, bool) {
 	v, ok := a.jobList[jobID]
 	return v, ok
@@ -292,26 +378,29 @@ func (a *Actions) AddNotification(ctx *gin.Context) {
 	jobID := ctx.Param("jobId")
 	job := FindJob(a.jobList, jobID)
 	if job != nil {
-		recipients, ok := a.notificationRecipients[jobID]
-		if !ok {
-			recipients = make([]string, 1)
-			recipients[0] = ctx.Param("address")
-		} else {
-			hasValue := false
-			for _, addr := range recipients {
-				if addr == ctx.Param("address") {
-					hasValue = true
-				}
-			}
-			if !hasValue {
-				recipients = append(recipients, ctx.Param("address"))
+		recipient, err := notificationRecipientFromArgs(ctx.Param("address"), ctx.Request.URL.Query())
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusBadRequest)
+			return
+		}
+		recipients := a.notificationRecipients[jobID]
+		hasValue := false
+		for i, rcpt := range recipients {
+			if rcpt.Address == recipient.Address {
+				recipients[i] = recipient
+				hasValue = true
 			}
 		}
+		if !hasValue {
+			recipients = append(recipients, recipient)
+		}
 		a.notificationRecipients[jobID] = recipients
 		resp := struct {
-			Registered bool `json:"registered"`
+			Registered bool                  `json:"registered"`
+			Recipient  NotificationRecipient `json:"recipient"`
 		}{
 			Registered: true,
+			Recipient:  recipient,
 		}
 		uniresp.WriteJSONResponse(ctx.Writer, resp)
 
@@ -326,12 +415,15 @@ func (a *Actions) GetNotifications(ctx *gin.Context) {
 	if job != nil {
 		recipients, ok := a.notificationRecipients[job.GetID()]
 		resp := struct {
-			Recipients []string `json:"recipients"`
+			Recipients []string                `json:"recipients"`
+			Details    []NotificationRecipient `json:"details"`
 		}{
 			Recipients: []string{},
+			Details:    []NotificationRecipient{},
 		}
 		if ok {
-			resp.Recipients = recipients
+			resp.Recipients = recipientAddresses(recipients)
+			resp.Details = recipients
 		}
 		uniresp.WriteJSONResponse(ctx.Writer, resp)
 
@@ -347,8 +439,8 @@ func (a *Actions) CheckNotification(ctx *gin.Context) {
 		registered := false
 		recipients, ok := a.notificationRecipients[jobID]
 		if ok {
-			for _, addr := range recipients {
-				if addr == ctx.Param("address") {
+			for _, rcpt := range recipients {
+				if rcpt.Address == ctx.Param("address") {
 					registered = true
 					break
 				}
@@ -378,8 +470,8 @@ func (a *Actions) RemoveNotification(ctx *gin.Context) {
 	if job != nil {
 		recipients, ok := a.notificationRecipients[jobID]
 		if ok {
-			for i, addr := range recipients {
-				if addr == ctx.Param("address") {
+			for i, rcpt := range recipients {
+				if rcpt.Address == ctx.Param("address") {
 					recipients = append(recipients[:i], recipients[i+1:]...)
 					break
 				}
@@ -406,10 +498,44 @@ func (a *Actions) Utilization(ctx *gin.Context) {
 		"currentRunningJobs":   numUnfinished,
 		"utilization":          float32(numUnfinished) / float32(a.conf.MaxNumConcurrentJobs),
 		"jobQueueLength":       a.jobQueue.Size(),
+		"runningJobsByType":    a.runningJobsByType(),
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
+// notify sends a message to all the recipients. Recipients matching
+// a configured notification channel are notified via the channel,
+// all the other ones are treated as e-mail addresses and receive
+// a single e-mail.
+func (a *Actions) notify(recipients []string, msg notifications.Message) {
+	emails := make([]string, 0, len(recipients))
+	notifiers := make([]notifications.Notifier, 0, len(recipients))
+	for _, rcpt := range recipients {
+		chConf, ok := a.conf.NotificationChannels[rcpt]
+		if !ok {
+			emails = append(emails, rcpt)
+			continue
+		}
+		notifier, err := notifications.NewNotifier(rcpt, chConf, a.conf.EmailNotification)
+		if err != nil {
+			logger.Error().Err(err).Str("channel", rcpt).Msg("Failed to create notifier")
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if len(emails) > 0 {
+		notifiers = append(notifiers, notifications.NewEmailNotifier(a.conf.EmailNotification, emails...))
+	}
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(msg); err != nil {
+			logger.Error().Err(err).
+				Str("subject", msg.Subject).
+				Strs("body", msg.Paragraphs).
+				Msg("Failed to send job notification")
+		}
+	}
+}
+
 // NewActions is the default factory
 func NewActions(
 	conf *Conf,
@@ -423,25 +549,84 @@ func NewActions(
 		detachedJobs:           make(map[string]GeneralJobInfo),
 		tableUpdate:            make(chan TableUpdate),
 		jobStop:                jobStop,
-		notificationRecipients: make(map[string][]string),
+		notificationRecipients: make(map[string][]NotificationRecipient),
+		userDigests:            make(map[string][]DigestItem),
 		msgPrinter:             message.NewPrinter(message.MatchLanguage(lang)),
 		jobQueue:               &JobQueue{},
 		jobDeps:                make(JobsDeps),
+		jobRequests:            make(map[string]*JobRequest),
+		schedules:              make(map[string]*Schedule),
+		replicatedJobs:         make(map[string]bool),
+		jobLogs:                newJobLogs(conf.JobLogSize),
+		jobResources:           newJobResources(),
+		pauseGates:             make(map[string]*PauseGate),
+		jobRetries:             newJobRetries(),
+		jobEvents:              newJobEventBroker(),
+	}
+	var err error
+	ans.jobArchive, err = NewJobArchive(conf.ArchiveDirPath)
+	if err != nil {
+		logger.Error().Err(err).Msg("job archive disabled, old jobs will be removed")
+	}
+	for i, hook := range conf.Webhooks {
+		if err := hook.Validate(); err != nil {
+			logger.Error().Err(err).Int("webhook", i).Msg("invalid job webhook")
+		}
+	}
+	for i, alert := range conf.FailureAlerts {
+		if err := alert.Validate(); err != nil {
+			logger.Error().Err(err).Int("alert", i).Msg("invalid job failure alert")
+		}
+	}
+	for jobType, typeConf := range conf.JobTypes {
+		if typeConf.MaxConcurrency < 0 {
+			logger.Error().Str("jobType", jobType).Msg("invalid maxConcurrency of job type, ignoring")
+		}
+		if typeConf.RetentionHours < 0 {
+			logger.Error().Str("jobType", jobType).Msg("invalid retentionHours of job type, ignoring")
+		}
+		if typeConf.Retry != nil {
+			if err := typeConf.Retry.Validate(); err != nil {
+				logger.Error().Err(err).Str("jobType", jobType).Msg("invalid retry policy of job type, retries disabled")
+				typeConf.Retry = nil
+				conf.JobTypes[jobType] = typeConf
+			}
+		}
+	}
+	for name, chConf := range conf.NotificationChannels {
+		if err := chConf.Validate(); err != nil {
+			logger.Error().Err(err).Str("channel", name).Msg("invalid notification channel")
+		}
+	}
+	ans.notificationTemplates, err = newNotificationTemplates(
+		conf.NotificationTemplatesDirPath, lang, ans.msgPrinter, notificationSignature(conf, lang))
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load notification templates, using the built-in ones")
+		ans.notificationTemplates, _ = newNotificationTemplates(
+			"", lang, ans.msgPrinter, notificationSignature(conf, lang))
 	}
 	isFile, err := fs.IsFile(conf.StatusDataPath)
 	if err != nil {
-		log.Error().Err(err)
+		logger.Error().Err(err)
 	}
 	if isFile {
-		log.Info().Msgf("found status data in %s - loading...", conf.StatusDataPath)
+		logger.Info().Msgf("found status data in %s - loading...", conf.StatusDataPath)
 		jobs, err := LoadJobList(conf.StatusDataPath)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to load status data")
+			logger.Error().Err(err).Msg("failed to load status data")
 		}
 		for _, job := range jobs {
-			if job != nil {
+			if job == nil {
+				continue
+			}
+			if job.IsFinished() {
+				// e.g. a job which could not be restored (see RestoredJobInfo)
+				ans.jobList[job.GetID()] = job
+				logger.Info().Msgf("added finished job %s", job.GetID())
+
+			} else {
 				ans.detachedJobs[job.GetID()] = job
-				log.Info().Msgf("added detached job %s", job.GetID())
+				logger.Info().Msgf("added detached job %s", job.GetID())
 			}
 		}
 	}
@@ -463,55 +648,21 @@ func NewActions(
 		}
 	}()
 
+	go ans.runDigestScheduler(exitEvent)
+
+	if err := ans.loadSchedules(); err != nil {
+		logger.Error().Err(err).Msg("failed to load schedules")
+	}
+	go ans.runScheduler(exitEvent)
+
 	ticker2 := time.NewTicker(1 * time.Second)
 	go func() {
 		for {
 			select {
 			case <-ticker2.C:
 				ans.jobQueueLock.Lock()
-				numUnfinished := ans.numOfUnfinishedJobs()
-				// Now calling again the numOfUnfinishedJobs() may return
-				// different value but it can be only a value smaller than
-				// numUnfinished as the change can be only caused by another
-				// job being finished (adding of jobs for execution happens
-				// only here and is not concurrent).
-				if ans.conf.MaxNumConcurrentJobs > numUnfinished {
-					// first, let's check whether the current job depends
-					// on other job(s) (= aka 'parents') and delay it in case
-					// parents are not ready yet
-					nextJobID, err := ans.jobQueue.PeekID()
-					if err != nil {
-						// empty queue
-					} else if _, ok := ans.jobDeps[nextJobID]; ok { // job with dependencies
-
-						mustWait, err := ans.jobDeps.MustWait(nextJobID)
-						if err != nil {
-							err := fmt.Errorf("failed to obtain waiting status for job %s: %w", nextJobID, err)
-							ans.dequeueJobAsFailed(err)
-
-						} else if mustWait {
-							ans.jobQueue.DelayNext()
-
-						} else {
-							hasFailedParent, err := ans.jobDeps.HasFailedParent(nextJobID)
-							if err != nil {
-								err := fmt.Errorf("failed to check parents of job %s: %w", nextJobID, err)
-								ans.dequeueJobAsFailed(err)
-
-							} else if hasFailedParent {
-								err := fmt.Errorf("failed to run job %s due to failed parent(s): %w", nextJobID, err)
-								ans.dequeueJobAsFailed(err)
-
-							} else {
-								ans.dequeueAndRunJob()
-							}
-						}
-
-					} else { // job without deps
-						ans.dequeueAndRunJob()
-					}
-					ans.jobQueueLock.Unlock()
-				}
+				ans.scheduleJobs()
+				ans.jobQueueLock.Unlock()
 			case <-exitEvent:
 				ticker.Stop()
 				return
@@ -519,6 +670,19 @@ func NewActions(
 		}
 	}()
 
+	ticker3 := time.NewTicker(dfltResourceSamplingInterval)
+	go func() {
+		for {
+			select {
+			case <-ticker3.C:
+				ans.jobResources.update()
+			case <-exitEvent:
+				ticker3.Stop()
+				return
+			}
+		}
+	}()
+
 	go func() {
 		for upd := range ans.tableUpdate {
 			switch upd.action {
@@ -533,55 +697,44 @@ func NewActions(
 				} else {
 					ans.jobList[upd.itemID] = upd.data
 				}
+				updated := ans.jobList[upd.itemID]
 				ans.jobListLock.Unlock()
+				ans.jobEvents.publish(JobEventUpdated, updated)
 			case tableActionFinishJob:
+				if retrying, ok := ans.scheduleRetry(upd.data); ok {
+					ans.jobListLock.Lock()
+					ans.jobList[upd.itemID] = retrying
+					ans.jobListLock.Unlock()
+					ans.jobResources.finish(upd.itemID)
+					ans.ReleasePauseGate(upd.itemID)
+					ans.jobEvents.publish(JobEventRetrying, retrying)
+					break
+				}
 				ans.jobListLock.Lock()
-				ans.jobList[upd.itemID] = ans.jobList[upd.itemID].AsFinished()
+				finished := ans.jobList[upd.itemID].AsFinished()
+				ans.jobList[upd.itemID] = finished
 				ans.jobListLock.Unlock()
+				ans.jobResources.finish(upd.itemID)
+				ans.ReleasePauseGate(upd.itemID)
+				if finished.GetError() != nil {
+					ans.jobEvents.publish(JobEventFailed, finished)
+
+				} else {
+					ans.jobEvents.publish(JobEventFinished, finished)
+				}
+				if len(conf.Webhooks) > 0 {
+					go ans.callWebhooks(finished)
+				}
+				if upd.data.GetError() != nil && len(conf.FailureAlerts) > 0 {
+					go ans.sendFailureAlerts(finished, upd.data.GetError())
+				}
 				ans.jobDeps.SetParentFinished(upd.itemID, upd.data.GetError() != nil)
-				recipients, ok := ans.notificationRecipients[upd.itemID]
-				if ok {
-					jdesc := extractJobDescription(ans.msgPrinter, upd.data)
-					subject := ans.msgPrinter.Sprintf("Job of type \"%s\" finished", jdesc)
-					var sign string
-					if conf.EmailNotification.HasSignature() {
-						var err error
-						sign, err = conf.EmailNotification.LocalizedSignature(lang)
-						if err != nil {
-							log.Error().Err(err).Send()
-						}
-
-					} else {
-						sign = conf.EmailNotification.DefaultSignature(lang)
-					}
-
-					notificationConf := conf.EmailNotification.WithRecipients(recipients...)
-					err := cncmail.SendNotification(
-						&notificationConf,
-						time.Now().Location(),
-						cncmail.Notification{
-							Subject: subject,
-							Paragraphs: []string{
-								subject,
-								ans.msgPrinter.Sprintf("Job ID: %s", upd.itemID),
-								localizedStatus(ans.msgPrinter, upd.data),
-								"",
-								"",
-								sign,
-							},
-						},
-					)
-					if err != nil {
-						log.Error().Err(err).
-							Str("mailSubject", subject).
-							Strs("mailBody", []string{subject, jdesc}).
-							Msg("Failed to send finished job notification")
-					}
+				if recipients, ok := ans.notificationRecipients[upd.itemID]; ok {
+					ans.notifyJobFinished(recipients, finished, upd.data.GetError())
 				}
 			case tableActionClearOldJobs:
-				ans.jobListLock.Lock()
-				clearOldJobs(ans.jobList)
-				ans.jobListLock.Unlock()
+				ans.clearOldJobs()
+				ans.clearOldJobRequests()
 			}
 
 		}
@@ -0,0 +1,95 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+)
+
+var defaultAlertJobTypes = []string{"liveattrs", "sync-cnk"}
+
+// FailureAlertConf configures immediate notifications of failed
+// jobs (e.g. to an ops Slack or Mattermost channel)
+type FailureAlertConf struct {
+
+	// Recipients are e-mail addresses or names of configured
+	// notification channels
+	Recipients []string `json:"recipients"`
+
+	// Corpora limits the alert to jobs of specific corpora.
+	// If empty, jobs of all corpora are reported.
+	Corpora []string `json:"corpora"`
+
+	// JobTypes limits the alert to specific job types. If empty,
+	// liveattrs and corpus data synchronization jobs are reported.
+	JobTypes []string `json:"jobTypes"`
+}
+
+// Validate tests whether the alert is properly configured
+func (conf FailureAlertConf) Validate() error {
+	if len(conf.Recipients) == 0 {
+		return fmt.Errorf("missing failure alert recipients")
+	}
+	return nil
+}
+
+// Matches tests whether the alert should be sent for a failed job
+func (conf FailureAlertConf) Matches(job GeneralJobInfo) bool {
+	jobTypes := conf.JobTypes
+	if len(jobTypes) == 0 {
+		jobTypes = defaultAlertJobTypes
+	}
+	if !collections.SliceContains(jobTypes, job.GetType()) {
+		return false
+	}
+	return len(conf.Corpora) == 0 || collections.SliceContains(conf.Corpora, job.GetCorpus())
+}
+
+// alertRecipients returns recipients of all the alerts matching
+// a failed job (each recipient just once)
+func (a *Actions) alertRecipients(job GeneralJobInfo) []string {
+	ans := make([]string, 0, 5)
+	for _, alert := range a.conf.FailureAlerts {
+		if !alert.Matches(job) {
+			continue
+		}
+		for _, rcpt := range alert.Recipients {
+			if !collections.SliceContains(ans, rcpt) {
+				ans = append(ans, rcpt)
+			}
+		}
+	}
+	return ans
+}
+
+// sendFailureAlerts notifies recipients of all the configured
+// alerts matching a job failed with `jobErr`
+func (a *Actions) sendFailureAlerts(job GeneralJobInfo, jobErr error) {
+	recipients := a.alertRecipients(job)
+	if len(recipients) == 0 {
+		return
+	}
+	msg, err := a.notificationTemplates.jobMessage(job, jobErr)
+	if err != nil {
+		logger.Error().Err(err).Str("jobId", job.GetID()).Msg("Failed to create job failure alert")
+		return
+	}
+	a.notify(recipients, msg)
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureAlertMatches(t *testing.T) {
+	alert := FailureAlertConf{Recipients: []string{"ops-slack"}}
+	assert.True(t, alert.Matches(DummyJobInfo{Type: "liveattrs", CorpusID: "syn2020"}))
+	assert.True(t, alert.Matches(DummyJobInfo{Type: "sync-cnk", CorpusID: "syn2020"}))
+	assert.False(t, alert.Matches(DummyJobInfo{Type: "dummy-job", CorpusID: "syn2020"}))
+
+	alert = FailureAlertConf{Recipients: []string{"ops-slack"}, Corpora: []string{"susanne"}, JobTypes: []string{"dummy-job"}}
+	assert.True(t, alert.Matches(DummyJobInfo{Type: "dummy-job", CorpusID: "susanne"}))
+	assert.False(t, alert.Matches(DummyJobInfo{Type: "dummy-job", CorpusID: "syn2020"}))
+	assert.False(t, alert.Matches(DummyJobInfo{Type: "liveattrs", CorpusID: "susanne"}))
+
+	assert.Error(t, FailureAlertConf{}.Validate())
+}
+
+func TestAlertRecipients(t *testing.T) {
+	a := &Actions{
+		conf: &Conf{
+			FailureAlerts: []FailureAlertConf{
+				{Recipients: []string{"ops-slack"}},
+				{Recipients: []string{"ops-slack", "joe@example.com"}, Corpora: []string{"susanne"}},
+			},
+		},
+	}
+	assert.Equal(t, []string{"ops-slack"}, a.alertRecipients(DummyJobInfo{Type: "liveattrs", CorpusID: "syn2020"}))
+	assert.Equal(
+		t,
+		[]string{"ops-slack", "joe@example.com"},
+		a.alertRecipients(DummyJobInfo{Type: "liveattrs", CorpusID: "susanne"}),
+	)
+	assert.Empty(t, a.alertRecipients(DummyJobInfo{Type: "dummy-job", CorpusID: "susanne"}))
+}
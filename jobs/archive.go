@@ -0,0 +1,410 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// dfltJobRetention is used for job types without
+	// a configured retention (see Conf.Retention)
+	dfltJobRetention = 168 * time.Hour
+
+	dfltArchivePageSize = 50
+
+	archiveFilePrefix  = "jobs-"
+	archiveFileSuffix  = ".jsonl.gz"
+	archiveMonthLayout = "2006-01"
+	archiveDateLayout  = "2006-01-02"
+)
+
+// Retention returns how long jobs of a specified type are kept
+// in the job list (counted from the start of a job)
+func (conf *Conf) Retention(jobType string) time.Duration {
+	if typeConf, ok := conf.JobTypes[jobType]; ok && typeConf.RetentionHours > 0 {
+		return time.Duration(typeConf.RetentionHours) * time.Hour
+	}
+	if conf.RetentionHours > 0 {
+		return time.Duration(conf.RetentionHours) * time.Hour
+	}
+	return dfltJobRetention
+}
+
+// MaxRetention returns the longest retention of all the job types
+func (conf *Conf) MaxRetention() time.Duration {
+	ans := conf.Retention("")
+	for jobType := range conf.JobTypes {
+		if r := conf.Retention(jobType); r > ans {
+			ans = r
+		}
+	}
+	return ans
+}
+
+// ArchivedJob is a record of a job removed from the job list
+// once its retention expired
+type ArchivedJob struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	CorpusID string   `json:"corpusId"`
+	Start    JSONTime `json:"start"`
+	Archived JSONTime `json:"archived"`
+	Finished bool     `json:"finished"`
+	OK       bool     `json:"ok"`
+
+	// Info contains the full information about the job
+	// (see GeneralJobInfo.FullInfo)
+	Info json.RawMessage `json:"info"`
+}
+
+func newArchivedJob(job GeneralJobInfo, archived time.Time) (*ArchivedJob, error) {
+	info, err := json.Marshal(job.FullInfo())
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive job %s: %w", job.GetID(), err)
+	}
+	return &ArchivedJob{
+		ID:       job.GetID(),
+		Type:     job.GetType(),
+		CorpusID: job.GetCorpus(),
+		Start:    job.GetStartDT(),
+		Archived: JSONTime(archived),
+		Finished: job.IsFinished(),
+		OK:       job.GetError() == nil,
+		Info:     info,
+	}, nil
+}
+
+// ArchiveFilter specifies archived jobs to be searched for.
+// Empty (zero) values match any job.
+type ArchiveFilter struct {
+	Type     string
+	CorpusID string
+
+	// From and To specify an interval (both ends included)
+	// the start of jobs must be within
+	From time.Time
+	To   time.Time
+}
+
+func (f ArchiveFilter) Matches(job *ArchivedJob) bool {
+	if f.Type != "" && job.Type != f.Type {
+		return false
+	}
+	if f.CorpusID != "" && job.CorpusID != f.CorpusID {
+		return false
+	}
+	start := time.Time(job.Start)
+	if !f.From.IsZero() && start.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && start.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// matchesMonth tests whether an archive file of a month
+// (jobs are archived by the month of their start) may
+// contain matching jobs
+func (f ArchiveFilter) matchesMonth(month time.Time) bool {
+	if !f.From.IsZero() && month.AddDate(0, 1, 0).Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && month.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// JobArchive stores expired jobs to gzip-compressed JSON lines
+// files (one file per month of job start). New records are
+// appended as new gzip members so the files are never rewritten.
+type JobArchive struct {
+	dirPath string
+	mu      sync.Mutex
+}
+
+func (ja *JobArchive) filePath(month time.Time) string {
+	return filepath.Join(ja.dirPath, archiveFilePrefix+month.Format(archiveMonthLayout)+archiveFileSuffix)
+}
+
+func (ja *JobArchive) appendToFile(path string, records []*ArchivedJob) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			zw.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Add stores jobs to the archive. In case the archive is not
+// configured (nil), the jobs are just dropped.
+func (ja *JobArchive) Add(jobs []GeneralJobInfo) error {
+	if ja == nil || len(jobs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	byFile := make(map[string][]*ArchivedJob)
+	for _, job := range jobs {
+		rec, err := newArchivedJob(job, now)
+		if err != nil {
+			return err
+		}
+		path := ja.filePath(time.Time(job.GetStartDT()))
+		byFile[path] = append(byFile[path], rec)
+	}
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+	for path, records := range byFile {
+		if err := ja.appendToFile(path, records); err != nil {
+			return fmt.Errorf("failed to archive jobs to %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func readArchiveFile(path string, filter ArchiveFilter) ([]*ArchivedJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer zr.Close()
+	ans := make([]*ArchivedJob, 0, 100)
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var rec ArchivedJob
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if filter.Matches(&rec) {
+			ans = append(ans, &rec)
+		}
+	}
+	return ans, nil
+}
+
+// Search returns archived jobs matching the filter sorted
+// from the most recent ones
+func (ja *JobArchive) Search(filter ArchiveFilter) ([]*ArchivedJob, error) {
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+	entries, err := os.ReadDir(ja.dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search job archive: %w", err)
+	}
+	ans := make([]*ArchivedJob, 0, 100)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, archiveFilePrefix) || !strings.HasSuffix(name, archiveFileSuffix) {
+			continue
+		}
+		month, err := time.ParseInLocation(
+			archiveMonthLayout,
+			strings.TrimSuffix(strings.TrimPrefix(name, archiveFilePrefix), archiveFileSuffix),
+			time.Local,
+		)
+		if err != nil || !filter.matchesMonth(month) {
+			continue
+		}
+		items, err := readArchiveFile(filepath.Join(ja.dirPath, name), filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search job archive: %w", err)
+		}
+		ans = append(ans, items...)
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[j].Start.Before(ans[i].Start)
+	})
+	return ans, nil
+}
+
+// NewJobArchive creates an archive stored in `dirPath`. For an empty
+// path, nil is returned (i.e. expired jobs are not archived).
+func NewJobArchive(dirPath string) (*JobArchive, error) {
+	if dirPath == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job archive: %w", err)
+	}
+	return &JobArchive{dirPath: dirPath}, nil
+}
+
+// expiredJobs returns jobs with expired retention sorted by their start
+func expiredJobs(data map[string]GeneralJobInfo, now JSONTime, retention func(string) time.Duration) []GeneralJobInfo {
+	ans := make(JobInfoList, 0, 10)
+	for _, v := range data {
+		if now.Sub(v.GetStartDT()) > retention(v.GetType()) {
+			ans = append(ans, v)
+		}
+	}
+	sort.Sort(ans)
+	return ans
+}
+
+// clearOldJobs removes jobs with expired retention from the job list.
+// In case the job archive is configured, the jobs are archived first
+// and they are removed only if the archiving succeeded.
+func (a *Actions) clearOldJobs() {
+	a.jobListLock.Lock()
+	expired := expiredJobs(a.jobList, CurrentDatetime(), a.conf.Retention)
+	a.jobListLock.Unlock()
+	if len(expired) == 0 {
+		return
+	}
+	if err := a.jobArchive.Add(expired); err != nil {
+		logger.Error().Err(err).Msg("failed to archive old jobs, keeping them in the job list")
+		return
+	}
+	a.jobListLock.Lock()
+	for _, job := range expired {
+		delete(a.jobList, job.GetID())
+		a.jobRetries.remove(job.GetID())
+	}
+	a.jobListLock.Unlock()
+	if a.jobArchive != nil {
+		logger.Info().Msgf("archived %d old job(s)", len(expired))
+
+	} else {
+		logger.Info().Msgf("removed %d old job(s)", len(expired))
+	}
+}
+
+// ArchivedJobsPage is a page of archived jobs
+type ArchivedJobsPage struct {
+	Page     int            `json:"page"`
+	PageSize int            `json:"pageSize"`
+	Total    int            `json:"total"`
+	Jobs     []*ArchivedJob `json:"jobs"`
+}
+
+func parseArchiveDate(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	ans, err := time.ParseInLocation(archiveDateLayout, v, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s", v)
+	}
+	if endOfDay {
+		ans = ans.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return ans, nil
+}
+
+// ArchivedJobs searches for jobs removed from the job list
+// once their retention expired
+func (a *Actions) ArchivedJobs(ctx *gin.Context) {
+	baseErrTpl := "failed to search archived jobs: %w"
+	if a.jobArchive == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, fmt.Errorf("job archive not configured")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	filter := ArchiveFilter{
+		Type:     ctx.Query("type"),
+		CorpusID: ctx.Query("corpusId"),
+	}
+	var err error
+	filter.From, err = parseArchiveDate(ctx.Query("from"), false)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	filter.To, err = parseArchiveDate(ctx.Query("to"), true)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", strconv.Itoa(dfltArchivePageSize)))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	if page < 1 || pageSize < 1 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl,
+				fmt.Errorf("page or pageSize argument incorrect (got: %d and %d)", page, pageSize)),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	items, err := a.jobArchive.Search(filter)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusInternalServerError)
+		return
+	}
+	ans := ArchivedJobsPage{
+		Page:     page,
+		PageSize: pageSize,
+		Total:    len(items),
+		Jobs:     []*ArchivedJob{},
+	}
+	from := (page - 1) * pageSize
+	if from < len(items) {
+		ans.Jobs = items[from:min(from+pageSize, len(items))]
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfRetention(t *testing.T) {
+	conf := &Conf{
+		RetentionHours: 48,
+		JobTypes: map[string]JobTypeConf{
+			"liveattrs":        {RetentionHours: 720},
+			"ngram-generating": {MaxConcurrency: 1},
+		},
+	}
+	assert.Equal(t, 720*time.Hour, conf.Retention("liveattrs"))
+	assert.Equal(t, 48*time.Hour, conf.Retention("ngram-generating"))
+	assert.Equal(t, 48*time.Hour, conf.Retention("dummy"))
+	assert.Equal(t, 720*time.Hour, conf.MaxRetention())
+	assert.Equal(t, dfltJobRetention, (&Conf{}).Retention("liveattrs"))
+}
+
+func TestExpiredJobs(t *testing.T) {
+	now := time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)
+	data := map[string]GeneralJobInfo{
+		"a": DummyJobInfo{ID: "a", Type: "liveattrs", Start: JSONTime(now.Add(-100 * time.Hour))},
+		"b": DummyJobInfo{ID: "b", Type: "dummy", Start: JSONTime(now.Add(-100 * time.Hour))},
+		"c": DummyJobInfo{ID: "c", Type: "dummy", Start: JSONTime(now.Add(-200 * time.Hour))},
+		"d": DummyJobInfo{ID: "d", Type: "dummy", Start: JSONTime(now.Add(-10 * time.Hour))},
+	}
+	conf := &Conf{
+		RetentionHours: 50,
+		JobTypes:       map[string]JobTypeConf{"liveattrs": {RetentionHours: 150}},
+	}
+	ans := expiredJobs(data, JSONTime(now), conf.Retention)
+	ids := make([]string, len(ans))
+	for i, job := range ans {
+		ids[i] = job.GetID()
+	}
+	assert.Equal(t, []string{"c", "b"}, ids)
+}
+
+func TestJobArchiveAddSearch(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	archive, err := NewJobArchive(dir)
+	assert.NoError(t, err)
+	day := func(m time.Month, d int) JSONTime {
+		return JSONTime(time.Date(2024, m, d, 12, 0, 0, 0, time.Local))
+	}
+	// two separate additions to the same file must be readable as a whole
+	err = archive.Add([]GeneralJobInfo{
+		DummyJobInfo{ID: "a", Type: "liveattrs", CorpusID: "syn2020", Start: day(2, 27), Finished: true},
+		DummyJobInfo{ID: "b", Type: "dummy", CorpusID: "syn2020", Start: day(3, 1), Finished: true},
+	})
+	assert.NoError(t, err)
+	err = archive.Add([]GeneralJobInfo{
+		DummyJobInfo{ID: "c", Type: "liveattrs", CorpusID: "intercorp", Start: day(3, 5)},
+	})
+	assert.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	ans, err := archive.Search(ArchiveFilter{})
+	assert.NoError(t, err)
+	ids := make([]string, len(ans))
+	for i, job := range ans {
+		ids[i] = job.ID
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, ids)
+	assert.True(t, ans[1].Finished)
+	assert.Contains(t, string(ans[1].Info), `"corpusId":"syn2020"`)
+
+	ans, err = archive.Search(ArchiveFilter{Type: "liveattrs"})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+
+	from, _ := parseArchiveDate("2024-02-27", false)
+	to, _ := parseArchiveDate("2024-03-01", true)
+	ans, err = archive.Search(ArchiveFilter{CorpusID: "syn2020", From: from, To: to})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 2)
+
+	from, _ = parseArchiveDate("2024-03-02", false)
+	ans, err = archive.Search(ArchiveFilter{From: from})
+	assert.NoError(t, err)
+	assert.Len(t, ans, 1)
+	assert.Equal(t, "c", ans[0].ID)
+}
+
+func TestNilJobArchive(t *testing.T) {
+	archive, err := NewJobArchive("")
+	assert.NoError(t, err)
+	assert.Nil(t, archive)
+	assert.NoError(t, archive.Add([]GeneralJobInfo{DummyJobInfo{ID: "a"}}))
+}
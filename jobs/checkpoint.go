@@ -0,0 +1,62 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import "sort"
+
+// JobCheckpoint describes the last known state of an unfinished job
+// (e.g. for a shutdown report)
+type JobCheckpoint struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	CorpusID   string   `json:"corpusId"`
+	Start      JSONTime `json:"start"`
+	LastUpdate JSONTime `json:"lastUpdate"`
+
+	// State is the full job information including its progress
+	State any `json:"state"`
+}
+
+// Checkpoints returns states of all the unfinished jobs
+// sorted by their start
+func (a *Actions) Checkpoints() []JobCheckpoint {
+	a.jobListLock.Lock()
+	jobList := a.createJobList(true)
+	a.jobListLock.Unlock()
+	sort.Sort(jobList)
+	ans := make([]JobCheckpoint, len(jobList))
+	for i, job := range jobList {
+		ans[i] = JobCheckpoint{
+			ID:         job.GetID(),
+			Type:       job.GetType(),
+			CorpusID:   job.GetCorpus(),
+			Start:      job.GetStartDT(),
+			LastUpdate: job.CompactVersion().Update,
+			State:      job.FullInfo(),
+		}
+	}
+	return ans
+}
+
+// NumQueuedJobs returns the number of jobs waiting to be started
+func (a *Actions) NumQueuedJobs() int {
+	a.jobQueueLock.Lock()
+	defer a.jobQueueLock.Unlock()
+	return a.jobQueue.Size()
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoints(t *testing.T) {
+	now := time.Now()
+	actions := &Actions{
+		jobList: map[string]GeneralJobInfo{
+			"job1": DummyJobInfo{ID: "job1", Type: "dummy-job", CorpusID: "syn2020", Start: JSONTime(now)},
+			"job2": DummyJobInfo{ID: "job2", Type: "dummy-job", Start: JSONTime(now.Add(-time.Hour)), Finished: true},
+			"job3": DummyJobInfo{ID: "job3", Type: "dummy-job", CorpusID: "susanne", Start: JSONTime(now.Add(-time.Minute))},
+		},
+		jobQueue: &JobQueue{},
+	}
+	checkpoints := actions.Checkpoints()
+	if assert.Len(t, checkpoints, 2) {
+		assert.Equal(t, "job3", checkpoints[0].ID)
+		assert.Equal(t, "susanne", checkpoints[0].CorpusID)
+		assert.Equal(t, "job1", checkpoints[1].ID)
+		assert.Equal(t, "dummy-job", checkpoints[1].Type)
+		assert.NotNil(t, checkpoints[1].State)
+	}
+	assert.Equal(t, 0, actions.NumQueuedJobs())
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// cronMaxSearchYears limits searching for the next matching
+	// time (e.g. for specs like `0 0 31 2 *` which never match)
+	cronMaxSearchYears = 5
+)
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+type cronField struct {
+	values     map[int]bool
+	restricted bool
+}
+
+func (cf cronField) matches(v int) bool {
+	return cf.values[v]
+}
+
+// parseCronField parses a single field of a cron specification
+// (supported forms: `*`, `5`, `1-5`, `*/15`, `1-30/5` and their
+// comma-separated lists)
+func parseCronField(src string, minVal, maxVal int) (cronField, error) {
+	ans := cronField{values: make(map[int]bool), restricted: src != "*"}
+	for _, item := range strings.Split(src, ",") {
+		rng, stepSrc, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSrc)
+			if err != nil || step < 1 {
+				return ans, fmt.Errorf("invalid step in %s", item)
+			}
+		}
+		from, to := minVal, maxVal
+		if rng != "*" {
+			fromSrc, toSrc, isRange := strings.Cut(rng, "-")
+			var err error
+			from, err = strconv.Atoi(fromSrc)
+			if err != nil {
+				return ans, fmt.Errorf("invalid value in %s", item)
+			}
+			to = from
+			if isRange {
+				to, err = strconv.Atoi(toSrc)
+				if err != nil {
+					return ans, fmt.Errorf("invalid range in %s", item)
+				}
+
+			} else if hasStep {
+				to = maxVal
+			}
+		}
+		if from < minVal || to > maxVal || from > to {
+			return ans, fmt.Errorf("value out of range %d-%d in %s", minVal, maxVal, item)
+		}
+		for v := from; v <= to; v += step {
+			ans.values[v] = true
+		}
+	}
+	return ans, nil
+}
+
+// CronSpec is a parsed cron-like specification of recurring times
+// in the standard `minute hour day-of-month month day-of-week` form.
+// Shortcuts @hourly, @daily, @weekly and @monthly are supported too.
+type CronSpec struct {
+	minute cronField
+	hour   cronField
+	dom    cronField
+	month  cronField
+	dow    cronField
+}
+
+// ParseCronSpec parses a cron-like specification
+func ParseCronSpec(src string) (*CronSpec, error) {
+	src = strings.TrimSpace(src)
+	if v, ok := cronShortcuts[src]; ok {
+		src = v
+	}
+	fields := strings.Fields(src)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec `%s` - 5 fields expected", src)
+	}
+	var ans CronSpec
+	var err error
+	if ans.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+	if ans.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+	if ans.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if ans.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+	if ans.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %w", err)
+	}
+	if ans.dow.values[7] {
+		ans.dow.values[0] = true
+	}
+	return &ans, nil
+}
+
+// matchesDay tests the day of month and the day of week. As in cron,
+// in case both of them are restricted, any of them must match.
+func (cs *CronSpec) matchesDay(t time.Time) bool {
+	domOK := cs.dom.matches(t.Day())
+	dowOK := cs.dow.matches(int(t.Weekday()))
+	if cs.dom.restricted && cs.dow.restricted {
+		return domOK || dowOK
+	}
+	return domOK && dowOK
+}
+
+// Next returns the first matching time after `t`. In case
+// the spec cannot be matched, a zero time is returned.
+func (cs *CronSpec) Next(t time.Time) time.Time {
+	curr := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronMaxSearchYears, 0, 0)
+	for curr.Before(limit) {
+		if !cs.month.matches(int(curr.Month())) {
+			curr = time.Date(curr.Year(), curr.Month()+1, 1, 0, 0, 0, 0, curr.Location())
+			continue
+		}
+		if !cs.matchesDay(curr) {
+			curr = time.Date(curr.Year(), curr.Month(), curr.Day()+1, 0, 0, 0, 0, curr.Location())
+			continue
+		}
+		if !cs.hour.matches(curr.Hour()) {
+			curr = time.Date(curr.Year(), curr.Month(), curr.Day(), curr.Hour()+1, 0, 0, 0, curr.Location())
+			continue
+		}
+		if !cs.minute.matches(curr.Minute()) {
+			curr = curr.Add(time.Minute)
+			continue
+		}
+		return curr
+	}
+	return time.Time{}
+}
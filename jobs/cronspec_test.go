@@ -0,0 +1,69 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronSpecInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCronSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronSpecNextDaily(t *testing.T) {
+	spec, err := ParseCronSpec("30 2 * * *")
+	assert.NoError(t, err)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, time.UTC), spec.Next(now))
+	now = time.Date(2024, 3, 10, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC), spec.Next(now))
+}
+
+func TestCronSpecNextSteps(t *testing.T) {
+	spec, err := ParseCronSpec("*/15 8-10 * * 1-5")
+	assert.NoError(t, err)
+	// Saturday
+	now := time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), spec.Next(now))
+	now = time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 8, 15, 0, 0, time.UTC), spec.Next(now))
+}
+
+func TestCronSpecNextShortcutAndDays(t *testing.T) {
+	spec, err := ParseCronSpec("@monthly")
+	assert.NoError(t, err)
+	now := time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), spec.Next(now))
+
+	// day of month OR Sunday
+	spec, err = ParseCronSpec("0 0 20 * 7")
+	assert.NoError(t, err)
+	now = time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC), spec.Next(now))
+
+	spec, err = ParseCronSpec("0 0 31 2 *")
+	assert.NoError(t, err)
+	assert.True(t, spec.Next(now).IsZero())
+}
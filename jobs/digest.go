@@ -0,0 +1,236 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	defaultDigestTime      = "07:00"
+	digestPeriod           = 24 * time.Hour
+	digestErrorExcerptSize = 200
+)
+
+// DigestConf configures a daily digest of job outcomes
+type DigestConf struct {
+
+	// Recipients are e-mail addresses or names of configured
+	// notification channels
+	Recipients []string `json:"recipients"`
+
+	// Time is a local time (HH:MM) the digest is sent at.
+	// If empty, defaultDigestTime is used.
+	Time string `json:"time"`
+}
+
+// NextSendTime returns the first time after `now` the digest
+// should be sent at.
+func (dc DigestConf) NextSendTime(now time.Time) (time.Time, error) {
+	tm := dc.Time
+	if tm == "" {
+		tm = defaultDigestTime
+	}
+	t, err := time.Parse("15:04", tm)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid digest time %s: %w", tm, err)
+	}
+	ans := time.Date(
+		now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !ans.After(now) {
+		ans = ans.AddDate(0, 0, 1)
+	}
+	return ans, nil
+}
+
+// DigestItem is a summary of a single finished job
+type DigestItem struct {
+	ID       string
+	Type     string
+	CorpusID string
+	Duration time.Duration
+	Error    string
+}
+
+// JobDigest summarizes jobs finished within a time period
+type JobDigest struct {
+	Since     time.Time
+	Until     time.Time
+	Succeeded []DigestItem
+	Failed    []DigestItem
+}
+
+// IsEmpty returns true if no job finished within the digest period
+func (d JobDigest) IsEmpty() bool {
+	return len(d.Succeeded) == 0 && len(d.Failed) == 0
+}
+
+func errorExcerpt(err error, maxLen int) string {
+	msg := []rune(err.Error())
+	if len(msg) > maxLen {
+		return string(msg[:maxLen]) + "..."
+	}
+	return string(msg)
+}
+
+// newDigestItem creates a digest item out of a job
+// finished with the `jobErr`
+func newDigestItem(job GeneralJobInfo, jobErr error) DigestItem {
+	info := job.CompactVersion()
+	ans := DigestItem{
+		ID:       job.GetID(),
+		Type:     job.GetType(),
+		CorpusID: job.GetCorpus(),
+		Duration: info.Update.Sub(info.Start).Round(time.Second),
+	}
+	if jobErr != nil {
+		ans.Error = errorExcerpt(jobErr, digestErrorExcerptSize)
+	}
+	return ans
+}
+
+// newJobDigest creates a digest out of items of jobs finished
+// between `since` and `until`
+func newJobDigest(items []DigestItem, since, until time.Time) JobDigest {
+	ans := JobDigest{
+		Since:     since,
+		Until:     until,
+		Succeeded: make([]DigestItem, 0, len(items)),
+		Failed:    make([]DigestItem, 0, len(items)),
+	}
+	for _, item := range items {
+		if item.Error != "" {
+			ans.Failed = append(ans.Failed, item)
+
+		} else {
+			ans.Succeeded = append(ans.Succeeded, item)
+		}
+	}
+	sort.Slice(ans.Succeeded, func(i, j int) bool { return ans.Succeeded[i].ID < ans.Succeeded[j].ID })
+	sort.Slice(ans.Failed, func(i, j int) bool { return ans.Failed[i].ID < ans.Failed[j].ID })
+	return ans
+}
+
+// createDigest summarizes jobs finished within the `period`
+// ending at `until`
+func createDigest(jobs []GeneralJobInfo, until time.Time, period time.Duration) JobDigest {
+	since := until.Add(-period)
+	items := make([]DigestItem, 0, len(jobs))
+	for _, job := range jobs {
+		if !job.IsFinished() {
+			continue
+		}
+		finished := time.Time(job.CompactVersion().Update)
+		if finished.Before(since) || finished.After(until) {
+			continue
+		}
+		items = append(items, newDigestItem(job, job.GetError()))
+	}
+	return newJobDigest(items, since, until)
+}
+
+// addToUserDigest stores a job outcome to be sent within
+// a daily digest of the recipient
+func (a *Actions) addToUserDigest(recipient string, item DigestItem) {
+	a.userDigestsLock.Lock()
+	defer a.userDigestsLock.Unlock()
+	a.userDigests[recipient] = append(a.userDigests[recipient], item)
+}
+
+// sendDigestMessage renders a digest and sends it to the recipients
+func (a *Actions) sendDigestMessage(recipients []string, digest JobDigest) {
+	msg, err := a.notificationTemplates.digestMessage(digest)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to create jobs digest")
+		return
+	}
+	a.notify(recipients, msg)
+}
+
+func (a *Actions) sendDigest() {
+	now := time.Now()
+	if a.conf.DailyDigest != nil && len(a.conf.DailyDigest.Recipients) > 0 {
+		a.sendGeneralDigest(now)
+	}
+	a.sendUserDigests(now)
+}
+
+func (a *Actions) sendGeneralDigest(now time.Time) {
+	a.jobListLock.Lock()
+	jobs := make([]GeneralJobInfo, 0, len(a.jobList))
+	for _, job := range a.jobList {
+		jobs = append(jobs, job)
+	}
+	a.jobListLock.Unlock()
+	digest := createDigest(jobs, now, digestPeriod)
+	if digest.IsEmpty() {
+		logger.Info().Msg("no jobs finished in the last 24h, skipping daily jobs digest")
+		return
+	}
+	logger.Info().
+		Int("succeeded", len(digest.Succeeded)).
+		Int("failed", len(digest.Failed)).
+		Msg("sending daily jobs digest")
+	a.sendDigestMessage(a.conf.DailyDigest.Recipients, digest)
+}
+
+// sendUserDigests sends digests to recipients registered
+// with the digest mode (see NotificationRecipient)
+func (a *Actions) sendUserDigests(now time.Time) {
+	a.userDigestsLock.Lock()
+	userDigests := a.userDigests
+	a.userDigests = make(map[string][]DigestItem)
+	a.userDigestsLock.Unlock()
+	for recipient, items := range userDigests {
+		digest := newJobDigest(items, now.Add(-digestPeriod), now)
+		logger.Info().
+			Str("recipient", recipient).
+			Int("succeeded", len(digest.Succeeded)).
+			Int("failed", len(digest.Failed)).
+			Msg("sending daily jobs digest of a recipient")
+		a.sendDigestMessage([]string{recipient}, digest)
+	}
+}
+
+// runDigestScheduler sends daily digests of job outcomes
+// at the configured time until an exit event is received
+func (a *Actions) runDigestScheduler(exitEvent <-chan os.Signal) {
+	digestConf := DigestConf{}
+	if a.conf.DailyDigest != nil {
+		digestConf = *a.conf.DailyDigest
+	}
+	for {
+		next, err := digestConf.NextSendTime(time.Now())
+		if err != nil {
+			logger.Error().Err(err).Msg("daily jobs digest disabled")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			a.sendDigest()
+		case <-exitEvent:
+			timer.Stop()
+			return
+		}
+	}
+}
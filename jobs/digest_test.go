@@ -0,0 +1,87 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestDigestNextSendTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC)
+	next, err := DigestConf{}.NextSendTime(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), next)
+
+	next, err = DigestConf{Time: "06:30"}.NextSendTime(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC), next)
+
+	_, err = DigestConf{Time: "7am"}.NextSendTime(now)
+	assert.Error(t, err)
+}
+
+func TestCreateDigest(t *testing.T) {
+	until := time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)
+	hoursBefore := func(h float64) JSONTime {
+		return JSONTime(until.Add(-time.Duration(h * float64(time.Hour))))
+	}
+	jobs := []GeneralJobInfo{
+		DummyJobInfo{
+			ID: "b", CorpusID: "corp1", Finished: true,
+			Start: hoursBefore(3), Update: hoursBefore(2)},
+		DummyJobInfo{
+			ID: "a", CorpusID: "corp2", Finished: true, Error: errors.New(strings.Repeat("x", 300)),
+			Start: hoursBefore(5), Update: hoursBefore(4.5)},
+		DummyJobInfo{
+			ID: "c", CorpusID: "corp1", Finished: true,
+			Start: hoursBefore(30), Update: hoursBefore(25)},
+		DummyJobInfo{
+			ID: "d", CorpusID: "corp1", Finished: false,
+			Start: hoursBefore(1), Update: hoursBefore(0.5)},
+	}
+	digest := createDigest(jobs, until, 24*time.Hour)
+	assert.False(t, digest.IsEmpty())
+	assert.Len(t, digest.Succeeded, 1)
+	assert.Equal(t, "b", digest.Succeeded[0].ID)
+	assert.Equal(t, time.Hour, digest.Succeeded[0].Duration)
+	assert.Len(t, digest.Failed, 1)
+	assert.Equal(t, "a", digest.Failed[0].ID)
+	assert.Equal(t, 30*time.Minute, digest.Failed[0].Duration)
+	assert.Equal(t, strings.Repeat("x", 200)+"...", digest.Failed[0].Error)
+
+	tpls, err := newNotificationTemplates("", "en", message.NewPrinter(language.English), "Your CNC-MASM")
+	assert.NoError(t, err)
+	msg, err := tpls.digestMessage(digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "MASM jobs digest: 1 succeeded, 1 failed", msg.Subject)
+	assert.Contains(t, msg.Paragraphs, "b (, corp1), 1h0m0s")
+	assert.Contains(t, msg.HTML, "<strong>b</strong> (, corp1), 1h0m0s")
+}
+
+func TestCreateDigestEmpty(t *testing.T) {
+	digest := createDigest([]GeneralJobInfo{}, time.Now(), 24*time.Hour)
+	assert.True(t, digest.IsEmpty())
+}
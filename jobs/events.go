@@ -0,0 +1,144 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	JobEventCreated  = "created"
+	JobEventUpdated  = "updated"
+	JobEventFinished = "finished"
+	JobEventFailed   = "failed"
+	JobEventPaused   = "paused"
+	JobEventResumed  = "resumed"
+	JobEventRetrying = "retrying"
+
+	eventSubscriberBufferSize = 50
+)
+
+// JobEvent describes a change of any job
+// (see Actions.JobEvents)
+type JobEvent struct {
+	Event string         `json:"event"`
+	Time  JSONTime       `json:"time"`
+	Job   JobInfoCompact `json:"job"`
+	Error string         `json:"error,omitempty"`
+}
+
+func newJobEvent(event string, job GeneralJobInfo) JobEvent {
+	return JobEvent{
+		Event: event,
+		Time:  CurrentDatetime(),
+		Job:   job.CompactVersion(),
+		Error: ErrorToString(job.GetError()),
+	}
+}
+
+// jobEventFilter limits events sent to a subscriber
+// to a corpus and/or a job type (empty means any)
+type jobEventFilter struct {
+	corpusID string
+	jobType  string
+}
+
+func (f jobEventFilter) matches(evt JobEvent) bool {
+	return (f.corpusID == "" || f.corpusID == evt.Job.CorpusID) &&
+		(f.jobType == "" || f.jobType == evt.Job.Type)
+}
+
+// jobEventBroker distributes job events to subscribed clients.
+// Slow subscribers may miss some events as the publisher never
+// waits for them.
+type jobEventBroker struct {
+	mu   sync.Mutex
+	subs map[chan JobEvent]jobEventFilter
+}
+
+func (eb *jobEventBroker) subscribe(filter jobEventFilter) chan JobEvent {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	ch := make(chan JobEvent, eventSubscriberBufferSize)
+	eb.subs[ch] = filter
+	return ch
+}
+
+func (eb *jobEventBroker) unsubscribe(ch chan JobEvent) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	if _, ok := eb.subs[ch]; ok {
+		delete(eb.subs, ch)
+		close(ch)
+	}
+}
+
+func (eb *jobEventBroker) publish(event string, job GeneralJobInfo) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	if len(eb.subs) == 0 {
+		return
+	}
+	evt := newJobEvent(event, job)
+	for ch, filter := range eb.subs {
+		if !filter.matches(evt) {
+			continue
+		}
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+func newJobEventBroker() *jobEventBroker {
+	return &jobEventBroker{
+		subs: make(map[chan JobEvent]jobEventFilter),
+	}
+}
+
+// JobEvents streams events of all the jobs (created, updated,
+// finished, failed) using Server-Sent Events until the client
+// disconnects. The stream can be limited to a corpus (`corpusId`)
+// and/or a job type (`jobType`).
+func (a *Actions) JobEvents(ctx *gin.Context) {
+	events := a.jobEvents.subscribe(jobEventFilter{
+		corpusID: ctx.Request.URL.Query().Get("corpusId"),
+		jobType:  ctx.Request.URL.Query().Get("jobType"),
+	})
+	defer a.jobEvents.unsubscribe(events)
+
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Flush()
+	for {
+		select {
+		case evt := <-events:
+			ctx.SSEvent(evt.Event, evt)
+			ctx.Writer.Flush()
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobEventBrokerFilters(t *testing.T) {
+	eb := newJobEventBroker()
+	all := eb.subscribe(jobEventFilter{})
+	syn := eb.subscribe(jobEventFilter{corpusID: "syn2020"})
+	eb.publish(JobEventCreated, DummyJobInfo{ID: "job1", Type: "dummy-job", CorpusID: "syn2020"})
+	eb.publish(JobEventFailed, DummyJobInfo{ID: "job2", Type: "dummy-job", CorpusID: "susanne", Error: errors.New("failed")})
+
+	assert.Len(t, all, 2)
+	assert.Len(t, syn, 1)
+	evt := <-syn
+	assert.Equal(t, JobEventCreated, evt.Event)
+	assert.Equal(t, "job1", evt.Job.ID)
+	<-all
+	evt = <-all
+	assert.Equal(t, JobEventFailed, evt.Event)
+	assert.Equal(t, "failed", evt.Error)
+
+	eb.unsubscribe(syn)
+	_, ok := <-syn
+	assert.False(t, ok)
+}
+
+func TestJobEventBrokerSkipsSlowSubscribers(t *testing.T) {
+	eb := newJobEventBroker()
+	ch := eb.subscribe(jobEventFilter{})
+	for i := 0; i < eventSubscriberBufferSize+5; i++ {
+		eb.publish(JobEventUpdated, DummyJobInfo{ID: "job1"})
+	}
+	assert.Len(t, ch, eventSubscriberBufferSize)
+}
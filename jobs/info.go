@@ -37,9 +37,9 @@ func extractJobDescription(printer *message.Printer, info GeneralJobInfo) string
 	return desc
 }
 
-func localizedStatus(printer *message.Printer, info GeneralJobInfo) string {
-	if info.GetError() == nil {
+func localizedStatus(printer *message.Printer, jobErr error) string {
+	if jobErr == nil {
 		return printer.Sprintf("Job finished without errors")
 	}
-	return printer.Sprintf("Job finished with error: %s", info.GetError())
+	return printer.Sprintf("Job finished with error: %s", jobErr)
 }
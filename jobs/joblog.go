@@ -0,0 +1,252 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltJobLogSize     = 500
+	dfltJobLogPageSize = 100
+
+	// maxNumJobLogs is a max. number of jobs with captured logs.
+	// Logs of the oldest jobs are removed first.
+	maxNumJobLogs = 500
+)
+
+// JobLogEntry is a log record emitted during a job's execution
+type JobLogEntry struct {
+	Time    JSONTime `json:"time"`
+	Level   string   `json:"level"`
+	Message string   `json:"message"`
+
+	// Fields contains additional fields of the entry (e.g. "error")
+	Fields map[string]any `json:"fields,omitempty"`
+
+	level zerolog.Level
+}
+
+// JobLogPage is a page of job log entries (from the oldest ones)
+type JobLogPage struct {
+	JobID      string        `json:"jobId"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"pageSize"`
+	Total      int           `json:"total"`
+	NumDropped int           `json:"numDropped"`
+	Entries    []JobLogEntry `json:"entries"`
+}
+
+// jobLogBuffer is a ring buffer of log entries of a single job
+type jobLogBuffer struct {
+	entries []JobLogEntry
+	next    int
+	full    bool
+
+	// numDropped is a number of entries overwritten by newer ones
+	numDropped int
+}
+
+func (b *jobLogBuffer) add(entry JobLogEntry) {
+	if b.full {
+		b.numDropped++
+	}
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// list returns entries (from the oldest ones) with
+// the minimal level
+func (b *jobLogBuffer) list(minLevel zerolog.Level) []JobLogEntry {
+	ans := make([]JobLogEntry, 0, len(b.entries))
+	appendFrom := func(items []JobLogEntry) {
+		for _, item := range items {
+			if item.level >= minLevel {
+				ans = append(ans, item)
+			}
+		}
+	}
+	if b.full {
+		appendFrom(b.entries[b.next:])
+	}
+	appendFrom(b.entries[:b.next])
+	return ans
+}
+
+// jobLogs captures log entries of individual jobs
+type jobLogs struct {
+	mu         sync.Mutex
+	bufferSize int
+	buffers    map[string]*jobLogBuffer
+	order      []string
+}
+
+func (jl *jobLogs) add(jobID string, entry JobLogEntry) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	buff, ok := jl.buffers[jobID]
+	if !ok {
+		if len(jl.order) >= maxNumJobLogs {
+			delete(jl.buffers, jl.order[0])
+			jl.order = jl.order[1:]
+		}
+		buff = &jobLogBuffer{entries: make([]JobLogEntry, jl.bufferSize)}
+		jl.buffers[jobID] = buff
+		jl.order = append(jl.order, jobID)
+	}
+	buff.add(entry)
+}
+
+func (jl *jobLogs) get(jobID string, minLevel zerolog.Level) ([]JobLogEntry, int, bool) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	buff, ok := jl.buffers[jobID]
+	if !ok {
+		return []JobLogEntry{}, 0, false
+	}
+	return buff.list(minLevel), buff.numDropped, true
+}
+
+func newJobLogs(bufferSize int) *jobLogs {
+	if bufferSize <= 0 {
+		bufferSize = dfltJobLogSize
+	}
+	return &jobLogs{
+		bufferSize: bufferSize,
+		buffers:    make(map[string]*jobLogBuffer),
+	}
+}
+
+// jobLogWriter stores events of a job logger to the job's log
+// and passes them to the global logger
+type jobLogWriter struct {
+	jobID string
+	logs  *jobLogs
+}
+
+func (w jobLogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w jobLogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return 0, fmt.Errorf("failed to capture job log entry: %w", err)
+	}
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	entry := JobLogEntry{
+		Time:    CurrentDatetime(),
+		Level:   level.String(),
+		Message: msg,
+		level:   level,
+	}
+	if len(fields) > 1 {
+		entry.Fields = make(map[string]any, len(fields)-1)
+		for k, v := range fields {
+			if k != "jobId" {
+				entry.Fields[k] = v
+			}
+		}
+	}
+	w.logs.add(w.jobID, entry)
+	log.WithLevel(level).Fields(fields).Msg(msg)
+	return len(p), nil
+}
+
+// JobLogger returns a logger tagging events with a job ID. Besides
+// the server log, the events are also stored to the job's log
+// (see JobLog).
+func (a *Actions) JobLogger(jobID string) zerolog.Logger {
+	return zerolog.New(jobLogWriter{jobID: jobID, logs: a.jobLogs}).
+		With().
+		Str("jobId", jobID).
+		Logger()
+}
+
+// JobLog returns a page of log entries captured during
+// a job's execution
+func (a *Actions) JobLog(ctx *gin.Context) {
+	jobID := ctx.Param("jobId")
+	baseErrTpl := "failed to get log of job %s: %w"
+	job := FindJob(a.jobList, jobID)
+	if job != nil {
+		jobID = job.GetID()
+	}
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, jobID, err), http.StatusBadRequest)
+		return
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", strconv.Itoa(dfltJobLogPageSize)))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, jobID, err), http.StatusBadRequest)
+		return
+	}
+	if page < 1 || pageSize < 1 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl,
+				jobID,
+				fmt.Errorf("page or pageSize argument incorrect (got: %d and %d)", page, pageSize)),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	minLevel := zerolog.TraceLevel
+	if v := ctx.Query("level"); v != "" {
+		minLevel, err = zerolog.ParseLevel(v)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, jobID, err), http.StatusBadRequest)
+			return
+		}
+	}
+	entries, numDropped, ok := a.jobLogs.get(jobID, minLevel)
+	if !ok && job == nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
+		return
+	}
+	ans := JobLogPage{
+		JobID:      jobID,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      len(entries),
+		NumDropped: numDropped,
+		Entries:    []JobLogEntry{},
+	}
+	from := (page - 1) * pageSize
+	if from < len(entries) {
+		ans.Entries = entries[from:min(from+pageSize, len(entries))]
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
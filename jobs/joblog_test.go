@@ -0,0 +1,77 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobLogBuffer(t *testing.T) {
+	logs := newJobLogs(3)
+	for i, level := range []zerolog.Level{zerolog.InfoLevel, zerolog.WarnLevel, zerolog.DebugLevel, zerolog.ErrorLevel} {
+		logs.add("job1", JobLogEntry{Message: string(rune('a' + i)), Level: level.String(), level: level})
+	}
+	entries, numDropped, ok := logs.get("job1", zerolog.TraceLevel)
+	assert.True(t, ok)
+	assert.Equal(t, 1, numDropped)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "b", entries[0].Message)
+		assert.Equal(t, "c", entries[1].Message)
+		assert.Equal(t, "d", entries[2].Message)
+	}
+	entries, _, _ = logs.get("job1", zerolog.WarnLevel)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "b", entries[0].Message)
+		assert.Equal(t, "d", entries[1].Message)
+	}
+	_, _, ok = logs.get("job2", zerolog.TraceLevel)
+	assert.False(t, ok)
+}
+
+func TestJobLogsEviction(t *testing.T) {
+	logs := newJobLogs(0)
+	assert.Equal(t, dfltJobLogSize, logs.bufferSize)
+	for i := 0; i <= maxNumJobLogs; i++ {
+		logs.add(string(rune(i)), JobLogEntry{})
+	}
+	assert.Len(t, logs.buffers, maxNumJobLogs)
+	_, _, ok := logs.get(string(rune(0)), zerolog.TraceLevel)
+	assert.False(t, ok)
+}
+
+func TestJobLogger(t *testing.T) {
+	actions := &Actions{jobLogs: newJobLogs(10)}
+	jobLog := actions.JobLogger("job1")
+	jobLog.Info().Msg("started")
+	jobLog.Error().Err(errors.New("failed to read")).Int("line", 10).Msg("parsing error")
+	entries, _, ok := actions.jobLogs.get("job1", zerolog.TraceLevel)
+	assert.True(t, ok)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "info", entries[0].Level)
+		assert.Equal(t, "started", entries[0].Message)
+		assert.Nil(t, entries[0].Fields)
+		assert.Equal(t, "error", entries[1].Level)
+		assert.Equal(t, "parsing error", entries[1].Message)
+		assert.Equal(t, map[string]any{"error": "failed to read", "line": float64(10)}, entries[1].Fields)
+	}
+}
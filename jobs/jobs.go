@@ -19,20 +19,92 @@
 package jobs
 
 import (
-	"encoding/gob"
+	"masm/v3/general/loglevel"
 	"masm/v3/mail"
-	"os"
+	"masm/v3/notifications"
 	"strings"
-	"time"
-
-	"github.com/rs/zerolog/log"
 )
 
+// logger allows for setting a log level of the job
+// dispatcher at runtime (see loglevel.SetLevel)
+var logger = loglevel.Module("jobs")
+
 type Conf struct {
 	StatusDataPath       string                 `json:"statusDataPath"`
 	MaxNumConcurrentJobs int                    `json:"maxNumConcurrentJobs"`
 	MaxNumRestarts       int                    `json:"maxNumRestarts"`
 	EmailNotification    mail.EmailNotification `json:"emailNotification"`
+
+	// JobRequestsDirPath is a directory where originating requests
+	// of jobs are stored. If empty, the requests are kept only
+	// in memory.
+	JobRequestsDirPath string `json:"jobRequestsDirPath"`
+
+	// NotificationChannels maps recipient names to non-email
+	// notification channels (e.g. an SMS gateway webhook or MS Teams).
+	// Registered notification recipients not found here are
+	// considered to be e-mail addresses.
+	NotificationChannels map[string]notifications.ChannelConf `json:"notificationChannels"`
+
+	// DailyDigest enables a daily summary of finished jobs.
+	// If nil, no digest is sent.
+	DailyDigest *DigestConf `json:"dailyDigest"`
+
+	// NotificationTemplatesDirPath is a directory with custom templates
+	// of job notifications and digests (job.[lang].txt, job.[lang].html,
+	// digest.[lang].txt, digest.[lang].html; the language part is optional).
+	// Templates not found there are replaced by the built-in ones.
+	NotificationTemplatesDirPath string `json:"notificationTemplatesDirPath"`
+
+	// FailureAlerts specify recipients notified immediately about
+	// failed jobs (globally or for specific corpora)
+	FailureAlerts []FailureAlertConf `json:"failureAlerts"`
+
+	// Webhooks are called with a final status of finished jobs
+	Webhooks []WebhookConf `json:"webhooks"`
+
+	// SchedulesPath is a file where recurring schedules (see Schedule)
+	// are stored. If empty, schedules are kept only in memory.
+	SchedulesPath string `json:"schedulesPath"`
+
+	// JobTypes configures scheduling of individual job types
+	// (e.g. "liveattrs", "ngram-generating"). Types not listed
+	// here have no concurrency limit (except for MaxNumConcurrentJobs)
+	// and the default priority 0.
+	JobTypes map[string]JobTypeConf `json:"jobTypes"`
+
+	// JobLogSize is a max. number of log entries captured per job
+	// (see Actions.JobLogger). Older entries are dropped first.
+	JobLogSize int `json:"jobLogSize"`
+
+	// RetentionHours specifies how long jobs are kept in the job list
+	// (default 168). It can be overridden for individual job types
+	// (see JobTypeConf).
+	RetentionHours int `json:"retentionHours"`
+
+	// ArchiveDirPath is a directory where jobs with expired retention
+	// are archived (see JobArchive). If empty, the jobs are just removed.
+	ArchiveDirPath string `json:"archiveDirPath"`
+}
+
+// JobTypeConf configures scheduling of jobs of a single type
+type JobTypeConf struct {
+
+	// MaxConcurrency is a max. number of running jobs of the type.
+	// Zero means no specific limit.
+	MaxConcurrency int `json:"maxConcurrency"`
+
+	// Priority specifies which waiting jobs are started first
+	// (higher values first; jobs of the same priority are started
+	// in the order they were submitted)
+	Priority int `json:"priority"`
+
+	// RetentionHours overrides Conf.RetentionHours for the job type
+	RetentionHours int `json:"retentionHours"`
+
+	// Retry enables automatic retries of jobs failed with
+	// transient errors. If nil, failed jobs are not retried.
+	Retry *RetryPolicy `json:"retry"`
 }
 
 // GeneralJobInfo defines a general job information
@@ -82,31 +154,6 @@ type GeneralJobInfo interface {
 // JobInfoList is just a list of any jobs
 type JobInfoList []GeneralJobInfo
 
-// Serialize gob-encodes the list and stores
-// it to a specified path
-func (jil JobInfoList) Serialize(path string) error {
-	fw, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer fw.Close()
-	enc := gob.NewEncoder(fw)
-	return enc.Encode(jil)
-}
-
-// LoadJobList loads gob-encoded job list
-// from a specified path
-func LoadJobList(path string) (JobInfoList, error) {
-	fw, err := os.OpenFile(path, os.O_RDONLY, 0644)
-	if err != nil {
-		return nil, err
-	}
-	dec := gob.NewDecoder(fw)
-	ans := make(JobInfoList, 0, 50)
-	err = dec.Decode(&ans)
-	return ans, err
-}
-
 func (jil JobInfoList) Len() int {
 	return len(jil)
 }
@@ -119,20 +166,6 @@ func (jil JobInfoList) Swap(i, j int) {
 	jil[i], jil[j] = jil[j], jil[i]
 }
 
-func clearOldJobs(data map[string]GeneralJobInfo) {
-	curr := CurrentDatetime()
-	numRemoved := 0
-	for k, v := range data {
-		if curr.Sub(v.GetStartDT()) > time.Duration(168)*time.Hour {
-			delete(data, k)
-			numRemoved++
-		}
-	}
-	if numRemoved > 0 {
-		log.Info().Msgf("removed %d old job(s)", numRemoved)
-	}
-}
-
 // FindJob searches a job by providing either full id or its prefix.
 // In case a prefix is used and there is more than one job matching the
 // prefix, nil is returned
@@ -172,6 +205,19 @@ type JobInfoCompact struct {
 	Update   JSONTime `json:"update"`
 	Finished bool     `json:"finished"`
 	OK       bool     `json:"ok"`
+
+	// Resources contains resource usage of the job (if recorded)
+	Resources *ResourceUsage `json:"resources,omitempty"`
+
+	// Paused is true for a running job paused via Actions.PauseJob
+	Paused bool `json:"paused,omitempty"`
+
+	// Retrying is true for a failed job waiting for
+	// an automatic retry (see RetryPolicy)
+	Retrying bool `json:"retrying,omitempty"`
+
+	// Retry describes automatic retries of the job (if any)
+	Retry *RetryStatus `json:"retry,omitempty"`
 }
 
 // JobInfoListCompact represents a list of jobs for quick reviews
@@ -0,0 +1,117 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	NotifyOnFinish  = "finish"
+	NotifyOnFailure = "failure"
+)
+
+// NotificationRecipient is a recipient registered to be notified
+// about a finished job
+type NotificationRecipient struct {
+
+	// Address is an e-mail address or a name of a configured
+	// notification channel
+	Address string `json:"address"`
+
+	// Trigger is either NotifyOnFinish (any outcome) or
+	// NotifyOnFailure (failed jobs only)
+	Trigger string `json:"trigger"`
+
+	// Digest makes the job outcome to be included in a daily
+	// digest of the recipient instead of an immediate notification
+	Digest bool `json:"digest"`
+}
+
+// IsTriggeredBy tests whether the recipient should be notified
+// about a job finished with the `jobErr`
+func (r NotificationRecipient) IsTriggeredBy(jobErr error) bool {
+	return r.Trigger != NotifyOnFailure || jobErr != nil
+}
+
+// notificationRecipientFromArgs creates a recipient out of
+// URL arguments `trigger` and `digest`
+func notificationRecipientFromArgs(address string, args url.Values) (NotificationRecipient, error) {
+	ans := NotificationRecipient{
+		Address: address,
+		Trigger: args.Get("trigger"),
+		Digest:  args.Get("digest") == "1",
+	}
+	switch ans.Trigger {
+	case "":
+		ans.Trigger = NotifyOnFinish
+	case NotifyOnFinish, NotifyOnFailure:
+	default:
+		return ans, fmt.Errorf("invalid notification trigger: %s", ans.Trigger)
+	}
+	return ans, nil
+}
+
+// recipientAddresses returns addresses of recipients
+func recipientAddresses(recipients []NotificationRecipient) []string {
+	ans := make([]string, len(recipients))
+	for i, r := range recipients {
+		ans[i] = r.Address
+	}
+	return ans
+}
+
+// notificationSignature returns a localized signature of notifications
+func notificationSignature(conf *Conf, lang string) string {
+	if !conf.EmailNotification.HasSignature() {
+		return conf.EmailNotification.DefaultSignature(lang)
+	}
+	sign, err := conf.EmailNotification.LocalizedSignature(lang)
+	if err != nil {
+		logger.Error().Err(err).Send()
+	}
+	return sign
+}
+
+// notifyJobFinished notifies recipients registered for a job finished
+// with `jobErr`. Recipients in the digest mode get the job outcome
+// within their next daily digest instead.
+func (a *Actions) notifyJobFinished(recipients []NotificationRecipient, job GeneralJobInfo, jobErr error) {
+	immediate := make([]string, 0, len(recipients))
+	for _, rcpt := range recipients {
+		if !rcpt.IsTriggeredBy(jobErr) {
+			continue
+		}
+		if rcpt.Digest {
+			a.addToUserDigest(rcpt.Address, newDigestItem(job, jobErr))
+			continue
+		}
+		immediate = append(immediate, rcpt.Address)
+	}
+	if len(immediate) == 0 {
+		return
+	}
+	msg, err := a.notificationTemplates.jobMessage(job, jobErr)
+	if err != nil {
+		logger.Error().Err(err).Str("jobId", job.GetID()).Msg("Failed to create job notification")
+		return
+	}
+	a.notify(immediate, msg)
+}
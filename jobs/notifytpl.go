@@ -0,0 +1,253 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"masm/v3/notifications"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/text/message"
+)
+
+const (
+	tplJobNotification = "job"
+	tplDigest          = "digest"
+)
+
+const dfltJobTextTpl = `{{ .Title }}
+{{ .IDInfo }}
+{{ .Status }}
+
+
+{{ .Signature }}`
+
+const dfltJobHTMLTpl = `<h3>{{ .Title }}</h3>
+<p>{{ .IDInfo }}<br />
+{{ .CorpusInfo }}</p>
+{{ if .Error }}<p style="color: #c00">{{ .Status }}</p>{{ else }}<p>{{ .Status }}</p>{{ end }}
+<p>{{ .Signature }}</p>`
+
+const dfltDigestTextTpl = `{{ .PeriodInfo }}
+{{- if .Failed }}
+
+{{ .FailedTitle }}
+{{- range .Failed }}
+{{ .ID }} ({{ .Type }}, {{ .CorpusID }}), {{ .Duration }}: {{ .Error }}
+{{- end }}
+{{- end }}
+{{- if .Succeeded }}
+
+{{ .SucceededTitle }}
+{{- range .Succeeded }}
+{{ .ID }} ({{ .Type }}, {{ .CorpusID }}), {{ .Duration }}
+{{- end }}
+{{- end }}`
+
+const dfltDigestHTMLTpl = `<p>{{ .PeriodInfo }}</p>
+{{ if .Failed }}<h3>{{ .FailedTitle }}</h3>
+<ul>
+{{ range .Failed }}<li><strong>{{ .ID }}</strong> ({{ .Type }}, {{ .CorpusID }}), {{ .Duration }}:
+<span style="color: #c00">{{ .Error }}</span></li>
+{{ end }}</ul>
+{{ end }}{{ if .Succeeded }}<h3>{{ .SucceededTitle }}</h3>
+<ul>
+{{ range .Succeeded }}<li><strong>{{ .ID }}</strong> ({{ .Type }}, {{ .CorpusID }}), {{ .Duration }}</li>
+{{ end }}</ul>
+{{ end }}<p>{{ .Signature }}</p>`
+
+// jobNotificationData is passed to templates of a finished job
+// notification. Besides job properties, it contains already
+// localized texts used by the built-in templates.
+type jobNotificationData struct {
+	ID          string
+	Type        string
+	Description string
+	CorpusID    string
+	Start       time.Time
+	Finished    time.Time
+	Error       string
+	Signature   string
+
+	Title      string
+	IDInfo     string
+	CorpusInfo string
+	Status     string
+}
+
+// digestNotificationData is passed to templates of a jobs digest
+type digestNotificationData struct {
+	JobDigest
+	Signature string
+
+	Title          string
+	PeriodInfo     string
+	FailedTitle    string
+	SucceededTitle string
+}
+
+// notificationTemplates renders localized notifications of finished
+// jobs and job digests. Each notification has a text version (used
+// by non-email channels) and an HTML one (used by e-mails).
+type notificationTemplates struct {
+	printer   *message.Printer
+	signature string
+	text      map[string]*texttemplate.Template
+	html      map[string]*htmltemplate.Template
+}
+
+// findTemplateFile searches `dirPath` for a template file of the
+// `name` and the `ext` suffix in the order [name].[lang].[ext],
+// [name].[2-char lang].[ext], [name].[ext]. An empty string is
+// returned if no file is found.
+func findTemplateFile(dirPath, name, lang, ext string) string {
+	if dirPath == "" {
+		return ""
+	}
+	lang2 := strings.Split(lang, "-")[0]
+	candidates := []string{
+		fmt.Sprintf("%s.%s.%s", name, lang, ext),
+		fmt.Sprintf("%s.%s.%s", name, lang2, ext),
+		fmt.Sprintf("%s.%s", name, ext),
+	}
+	for _, c := range candidates {
+		path := filepath.Join(dirPath, c)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func readTemplateSrc(dirPath, name, lang, ext, dflt string) (string, error) {
+	path := findTemplateFile(dirPath, name, lang, ext)
+	if path == "" {
+		return dflt, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read notification template %s: %w", path, err)
+	}
+	return string(src), nil
+}
+
+// newNotificationTemplates loads notification templates for `lang`
+// from `dirPath` (see findTemplateFile). Templates not found there
+// are replaced by the built-in ones. Besides the data, templates can
+// use the `T` function to translate a message via the translations
+// catalog.
+func newNotificationTemplates(
+	dirPath string,
+	lang string,
+	printer *message.Printer,
+	signature string,
+) (*notificationTemplates, error) {
+	ans := &notificationTemplates{
+		printer:   printer,
+		signature: signature,
+		text:      make(map[string]*texttemplate.Template),
+		html:      make(map[string]*htmltemplate.Template),
+	}
+	funcs := map[string]any{
+		"T": func(key string, args ...any) string {
+			return printer.Sprintf(key, args...)
+		},
+	}
+	defaults := map[string][2]string{
+		tplJobNotification: {dfltJobTextTpl, dfltJobHTMLTpl},
+		tplDigest:          {dfltDigestTextTpl, dfltDigestHTMLTpl},
+	}
+	for name, dflt := range defaults {
+		src, err := readTemplateSrc(dirPath, name, lang, "txt", dflt[0])
+		if err != nil {
+			return nil, err
+		}
+		ans.text[name], err = texttemplate.New(name).Funcs(funcs).Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s text notification template: %w", name, err)
+		}
+		src, err = readTemplateSrc(dirPath, name, lang, "html", dflt[1])
+		if err != nil {
+			return nil, err
+		}
+		ans.html[name], err = htmltemplate.New(name).Funcs(funcs).Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s HTML notification template: %w", name, err)
+		}
+	}
+	return ans, nil
+}
+
+func (nt *notificationTemplates) render(name, subject string, data any) (notifications.Message, error) {
+	var text, html bytes.Buffer
+	if err := nt.text[name].Execute(&text, data); err != nil {
+		return notifications.Message{}, fmt.Errorf("failed to render %s notification: %w", name, err)
+	}
+	if err := nt.html[name].Execute(&html, data); err != nil {
+		return notifications.Message{}, fmt.Errorf("failed to render %s notification: %w", name, err)
+	}
+	return notifications.Message{
+		Subject:    subject,
+		Paragraphs: strings.Split(strings.TrimRight(text.String(), "\n"), "\n"),
+		HTML:       html.String(),
+	}, nil
+}
+
+// jobMessage creates a notification about a job finished with `jobErr`
+func (nt *notificationTemplates) jobMessage(job GeneralJobInfo, jobErr error) (notifications.Message, error) {
+	info := job.CompactVersion()
+	jdesc := extractJobDescription(nt.printer, job)
+	data := jobNotificationData{
+		ID:          job.GetID(),
+		Type:        job.GetType(),
+		Description: jdesc,
+		CorpusID:    job.GetCorpus(),
+		Start:       time.Time(info.Start),
+		Finished:    time.Time(info.Update),
+		Error:       ErrorToString(jobErr),
+		Signature:   nt.signature,
+		Title:       nt.printer.Sprintf("Job of type \"%s\" finished", jdesc),
+		IDInfo:      nt.printer.Sprintf("Job ID: %s", job.GetID()),
+		CorpusInfo:  nt.printer.Sprintf("Corpus: %s", job.GetCorpus()),
+		Status:      localizedStatus(nt.printer, jobErr),
+	}
+	return nt.render(tplJobNotification, data.Title, data)
+}
+
+// digestMessage creates a notification out of a jobs digest
+func (nt *notificationTemplates) digestMessage(digest JobDigest) (notifications.Message, error) {
+	data := digestNotificationData{
+		JobDigest: digest,
+		Signature: nt.signature,
+		Title: nt.printer.Sprintf(
+			"MASM jobs digest: %d succeeded, %d failed", len(digest.Succeeded), len(digest.Failed)),
+		PeriodInfo: nt.printer.Sprintf(
+			"Jobs finished between %s and %s",
+			digest.Since.Format(time.RFC3339), digest.Until.Format(time.RFC3339)),
+		FailedTitle:    nt.printer.Sprintf("Failed jobs:"),
+		SucceededTitle: nt.printer.Sprintf("Successful jobs:"),
+	}
+	return nt.render(tplDigest, data.Title, data)
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "masm/v3/translations"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestJobMessageBuiltinTemplates(t *testing.T) {
+	tpls, err := newNotificationTemplates("", "en", message.NewPrinter(language.English), "Your CNC-MASM")
+	assert.NoError(t, err)
+	job := DummyJobInfo{ID: "job1", Type: "dummy-job", CorpusID: "syn2020", Finished: true}
+	msg, err := tpls.jobMessage(job, errors.New("<failed>"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Job of type \"Testing and debugging empty job\" finished", msg.Subject)
+	assert.Equal(
+		t,
+		[]string{
+			"Job of type \"Testing and debugging empty job\" finished",
+			"Job ID: job1",
+			"Job finished with error: <failed>",
+			"",
+			"",
+			"Your CNC-MASM",
+		},
+		msg.Paragraphs,
+	)
+	assert.Contains(t, msg.HTML, "Corpus: syn2020")
+	assert.Contains(t, msg.HTML, "Job finished with error: &lt;failed&gt;")
+}
+
+func TestJobMessageLocalized(t *testing.T) {
+	tpls, err := newNotificationTemplates("", "cs", message.NewPrinter(language.Czech), "Váš CNC-MASM")
+	assert.NoError(t, err)
+	job := DummyJobInfo{ID: "job1", Type: "dummy-job", CorpusID: "syn2020", Finished: true}
+	msg, err := tpls.jobMessage(job, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Paragraphs, "ID úlohy: job1")
+	assert.Contains(t, msg.HTML, "Korpus: syn2020")
+}
+
+func TestCustomNotificationTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(dir, "job.cs.txt"), []byte("{{ T \"Job ID: %s\" .ID }} ({{ .CorpusID }})"), 0644))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(dir, "job.html"), []byte("<b>{{ .ID }}</b>"), 0644))
+	tpls, err := newNotificationTemplates(dir, "cs-CZ", message.NewPrinter(language.Czech), "")
+	assert.NoError(t, err)
+	msg, err := tpls.jobMessage(DummyJobInfo{ID: "job1", CorpusID: "syn2020"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ID úlohy: job1 (syn2020)"}, msg.Paragraphs)
+	assert.Equal(t, "<b>job1</b>", msg.HTML)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "digest.txt"), []byte("{{ .Foo"), 0644))
+	_, err = newNotificationTemplates(dir, "cs", message.NewPrinter(language.Czech), "")
+	assert.Error(t, err)
+}
+
+func TestNotificationRecipientFromArgs(t *testing.T) {
+	rcpt, err := notificationRecipientFromArgs("joe@example.com", url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, NotificationRecipient{Address: "joe@example.com", Trigger: NotifyOnFinish}, rcpt)
+	assert.True(t, rcpt.IsTriggeredBy(nil))
+
+	rcpt, err = notificationRecipientFromArgs(
+		"joe@example.com", url.Values{"trigger": {"failure"}, "digest": {"1"}})
+	assert.NoError(t, err)
+	assert.True(t, rcpt.Digest)
+	assert.False(t, rcpt.IsTriggeredBy(nil))
+	assert.True(t, rcpt.IsTriggeredBy(errors.New("failed")))
+
+	_, err = notificationRecipientFromArgs("joe@example.com", url.Values{"trigger": {"start"}})
+	assert.Error(t, err)
+}
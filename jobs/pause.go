@@ -0,0 +1,214 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// PauseGate allows for suspending a running job. A worker
+// supporting pausing obtains the gate via Actions.PauseGate and
+// calls Wait only between units of work which hold no external
+// resources - e.g. after a database transaction has been committed
+// and before the next one is started (a paused job must not keep
+// locks or connections open for an unlimited time).
+// Once the gate is released (see Actions.ReleasePauseGate), the job
+// is being stopped and the worker should not start another unit
+// of work. A nil gate never pauses.
+type PauseGate struct {
+	mu       sync.Mutex
+	paused   bool
+	released bool
+	resumed  chan struct{}
+}
+
+// Wait blocks while the gate is paused
+func (g *PauseGate) Wait() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	if !g.paused {
+		g.mu.Unlock()
+		return
+	}
+	resumed := g.resumed
+	g.mu.Unlock()
+	<-resumed
+}
+
+// IsPaused tests whether the gate is paused
+func (g *PauseGate) IsPaused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// IsReleased tests whether the gate has been released
+// (i.e. the job is being stopped or it has finished)
+func (g *PauseGate) IsReleased() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.released
+}
+
+// pause closes the gate. It returns false if the gate
+// has been paused or released already.
+func (g *PauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused || g.released {
+		return false
+	}
+	g.paused = true
+	g.resumed = make(chan struct{})
+	return true
+}
+
+// resume opens the gate and unblocks all the waiting workers.
+// It returns false if the gate has not been paused.
+func (g *PauseGate) resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumeLocked()
+}
+
+func (g *PauseGate) resumeLocked() bool {
+	if !g.paused {
+		return false
+	}
+	g.paused = false
+	close(g.resumed)
+	return true
+}
+
+// release opens the gate for good. It returns true
+// if the gate has been paused.
+func (g *PauseGate) release() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.released = true
+	return g.resumeLocked()
+}
+
+// PauseGate registers a running job as a pausable one and returns
+// its gate (the same gate is returned for repeated calls). The gate
+// is released once the job finishes (see ReleasePauseGate).
+func (a *Actions) PauseGate(jobID string) *PauseGate {
+	a.pauseGatesLock.Lock()
+	defer a.pauseGatesLock.Unlock()
+	gate, ok := a.pauseGates[jobID]
+	if !ok {
+		gate = &PauseGate{}
+		a.pauseGates[jobID] = gate
+	}
+	return gate
+}
+
+// ReleasePauseGate resumes a job (in case it is paused) and removes
+// its gate. The gate is released before a job is signalled to stop
+// as a paused job may not be able to react (see PauseGate.IsReleased).
+func (a *Actions) ReleasePauseGate(jobID string) {
+	a.pauseGatesLock.Lock()
+	gate, ok := a.pauseGates[jobID]
+	delete(a.pauseGates, jobID)
+	a.pauseGatesLock.Unlock()
+	if ok && gate.release() {
+		jobLog := a.JobLogger(jobID)
+		jobLog.Info().Msg("job resumed")
+	}
+}
+
+func (a *Actions) getPauseGate(jobID string) *PauseGate {
+	a.pauseGatesLock.Lock()
+	defer a.pauseGatesLock.Unlock()
+	return a.pauseGates[jobID]
+}
+
+// isJobPaused tests whether a job is paused
+func (a *Actions) isJobPaused(jobID string) bool {
+	return a.getPauseGate(jobID).IsPaused()
+}
+
+// findPausableJob finds a running job supporting pausing. In case there
+// is no such job, an error response is written and nil is returned.
+func (a *Actions) findPausableJob(ctx *gin.Context) (GeneralJobInfo, *PauseGate) {
+	a.jobListLock.Lock()
+	job := FindJob(a.jobList, ctx.Param("jobId"))
+	a.jobListLock.Unlock()
+	if job == nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
+		return nil, nil
+	}
+	if job.IsFinished() {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("job %s has already finished", job.GetID()), http.StatusConflict)
+		return nil, nil
+	}
+	gate := a.getPauseGate(job.GetID())
+	if gate == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("job %s (%s) does not support pausing", job.GetID(), job.GetType()),
+			http.StatusConflict,
+		)
+		return nil, nil
+	}
+	return job, gate
+}
+
+// PauseJob suspends processing of a running job (if supported by the job)
+// without losing its state. Pausing an already paused job has no effect.
+func (a *Actions) PauseJob(ctx *gin.Context) {
+	job, gate := a.findPausableJob(ctx)
+	if job == nil {
+		return
+	}
+	if gate.pause() {
+		jobLog := a.JobLogger(job.GetID())
+		jobLog.Info().Msg("job paused")
+		a.jobEvents.publish(JobEventPaused, job)
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, a.compactInfoWithResources(job))
+}
+
+// ResumeJob continues processing of a paused job. Resuming a job
+// which is not paused has no effect.
+func (a *Actions) ResumeJob(ctx *gin.Context) {
+	job, gate := a.findPausableJob(ctx)
+	if job == nil {
+		return
+	}
+	if gate.resume() {
+		jobLog := a.JobLogger(job.GetID())
+		jobLog.Info().Msg("job resumed")
+		a.jobEvents.publish(JobEventResumed, job)
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, a.compactInfoWithResources(job))
+}
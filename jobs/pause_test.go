@@ -0,0 +1,114 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestingPauseActions() *Actions {
+	return &Actions{
+		jobList:    map[string]GeneralJobInfo{"job1": DummyJobInfo{ID: "job1"}},
+		jobLogs:    newJobLogs(10),
+		jobEvents:  newJobEventBroker(),
+		pauseGates: make(map[string]*PauseGate),
+	}
+}
+
+func callPauseAction(a *Actions, handler gin.HandlerFunc, jobID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/pause", nil)
+	ctx.Params = gin.Params{{Key: "jobId", Value: jobID}}
+	handler(ctx)
+	return rec
+}
+
+func TestPauseGateWait(t *testing.T) {
+	gate := &PauseGate{}
+	gate.Wait() // not paused, must not block
+	assert.True(t, gate.pause())
+	assert.False(t, gate.pause())
+	done := make(chan bool)
+	go func() {
+		gate.Wait()
+		done <- true
+	}()
+	select {
+	case <-done:
+		assert.Fail(t, "paused gate has not blocked")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.True(t, gate.resume())
+	assert.True(t, <-done)
+	assert.False(t, gate.resume())
+
+	var nilGate *PauseGate
+	nilGate.Wait()
+	assert.False(t, nilGate.IsPaused())
+	assert.False(t, nilGate.IsReleased())
+}
+
+func TestPauseResumeJob(t *testing.T) {
+	a := newTestingPauseActions()
+	gate := a.PauseGate("job1")
+	assert.Same(t, gate, a.PauseGate("job1"))
+
+	rec := callPauseAction(a, a.PauseJob, "job1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var info JobInfoCompact
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.True(t, info.Paused)
+	assert.True(t, gate.IsPaused())
+
+	rec = callPauseAction(a, a.ResumeJob, "job1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var info2 JobInfoCompact
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info2))
+	assert.False(t, info2.Paused)
+	assert.False(t, gate.IsPaused())
+}
+
+func TestPauseUnsupportedJob(t *testing.T) {
+	a := newTestingPauseActions()
+	a.jobList["job2"] = DummyJobInfo{ID: "job2", Finished: true}
+	assert.Equal(t, http.StatusConflict, callPauseAction(a, a.PauseJob, "job1").Code)
+	assert.Equal(t, http.StatusConflict, callPauseAction(a, a.PauseJob, "job2").Code)
+	assert.Equal(t, http.StatusNotFound, callPauseAction(a, a.PauseJob, "job3").Code)
+}
+
+func TestReleasePauseGateResumes(t *testing.T) {
+	a := newTestingPauseActions()
+	gate := a.PauseGate("job1")
+	callPauseAction(a, a.PauseJob, "job1")
+	a.ReleasePauseGate("job1")
+	assert.False(t, gate.IsPaused())
+	assert.True(t, gate.IsReleased())
+	assert.False(t, gate.pause())
+	assert.False(t, a.isJobPaused("job1"))
+	assert.Equal(t, http.StatusConflict, callPauseAction(a, a.PauseJob, "job1").Code)
+}
@@ -0,0 +1,341 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// JobListFormatVersion is the current version of the status
+	// data file format. Version 1 is a plain gob-encoded JobInfoList
+	// (files written before the format was versioned).
+	JobListFormatVersion = 2
+
+	jobListFormatMagic = "MASM-JOB-LIST"
+)
+
+func init() {
+	gob.Register(&RestoredJobInfo{})
+}
+
+// jobListHeader precedes job records in the status data file
+type jobListHeader struct {
+	Magic   string
+	Version int
+}
+
+// JobRecord is a stored job. Besides the gob-encoded job itself,
+// it contains basic job properties and a JSON version of the job
+// (see GeneralJobInfo.FullInfo) so the job can be restored (at least
+// partially) even if its struct layout changes and the gob data
+// cannot be decoded anymore.
+type JobRecord struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	CorpusID string   `json:"corpusId"`
+	Start    JSONTime `json:"start"`
+	Update   JSONTime `json:"update"`
+	Finished bool     `json:"finished"`
+	Error    string   `json:"error,omitempty"`
+
+	// Data is a gob-encoded JobInfoList containing just the job
+	// (nil in case the job could not be encoded)
+	Data []byte `json:"data,omitempty"`
+
+	// Info is a JSON-encoded GeneralJobInfo.FullInfo()
+	Info json.RawMessage `json:"info,omitempty"`
+}
+
+// JobMigration converts a stored job which cannot be decoded
+// anymore to a job of the current version (see RegisterJobMigration)
+type JobMigration func(rec JobRecord, formatVersion int) (GeneralJobInfo, error)
+
+var (
+	jobMigrations     = make(map[string]JobMigration)
+	jobMigrationsLock sync.Mutex
+)
+
+// RegisterJobMigration registers a function restoring stored jobs
+// of a type whose gob data cannot be decoded (typically after a change
+// of the job struct layout). The function is expected to use
+// JobRecord.Info.
+func RegisterJobMigration(jobType string, fn JobMigration) {
+	jobMigrationsLock.Lock()
+	defer jobMigrationsLock.Unlock()
+	jobMigrations[jobType] = fn
+}
+
+func getJobMigration(jobType string) (JobMigration, bool) {
+	jobMigrationsLock.Lock()
+	defer jobMigrationsLock.Unlock()
+	fn, ok := jobMigrations[jobType]
+	return fn, ok
+}
+
+func newJobRecord(job GeneralJobInfo) JobRecord {
+	job = exportableJob(job)
+	info := job.CompactVersion()
+	ans := JobRecord{
+		ID:       job.GetID(),
+		Type:     job.GetType(),
+		CorpusID: job.GetCorpus(),
+		Start:    info.Start,
+		Update:   info.Update,
+		Finished: job.IsFinished(),
+		Error:    ErrorToString(job.GetError()),
+	}
+	var buff bytes.Buffer
+	if err := gob.NewEncoder(&buff).Encode(JobInfoList{job}); err != nil {
+		logger.Warn().Err(err).Str("jobId", ans.ID).Msg("failed to gob-encode job, storing JSON info only")
+
+	} else {
+		ans.Data = buff.Bytes()
+	}
+	var err error
+	ans.Info, err = json.Marshal(job.FullInfo())
+	if err != nil {
+		logger.Warn().Err(err).Str("jobId", ans.ID).Msg("failed to JSON-encode job info")
+	}
+	return ans
+}
+
+func decodeJobRecordData(rec JobRecord) (GeneralJobInfo, error) {
+	if len(rec.Data) == 0 {
+		return nil, fmt.Errorf("no gob data available")
+	}
+	var jobs JobInfoList
+	if err := gob.NewDecoder(bytes.NewReader(rec.Data)).Decode(&jobs); err != nil {
+		return nil, err
+	}
+	if len(jobs) != 1 || jobs[0] == nil {
+		return nil, fmt.Errorf("unexpected job record data")
+	}
+	return jobs[0], nil
+}
+
+// restoreJob decodes a stored job. In case the gob data cannot be decoded,
+// a registered migration (if any) is used. As a last resort, the job is
+// restored as a finished RestoredJobInfo so it is never lost.
+func restoreJob(rec JobRecord, formatVersion int) GeneralJobInfo {
+	job, err := decodeJobRecordData(rec)
+	if err == nil {
+		return job
+	}
+	logger.Warn().Err(err).Str("jobId", rec.ID).Str("jobType", rec.Type).Msg("failed to decode stored job")
+	if migrate, ok := getJobMigration(rec.Type); ok {
+		job, err2 := migrate(rec, formatVersion)
+		if err2 == nil {
+			logger.Info().Str("jobId", rec.ID).Str("jobType", rec.Type).Msg("stored job migrated")
+			return job
+		}
+		logger.Warn().Err(err2).Str("jobId", rec.ID).Msg("failed to migrate stored job")
+	}
+	return newRestoredJobInfo(rec, err)
+}
+
+// Serialize stores the list to a specified path
+// (see JobListFormatVersion). The file is replaced
+// atomically.
+func (jil JobInfoList) Serialize(path string) error {
+	tmpPath := path + ".tmp"
+	fw, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := jil.writeTo(fw); err != nil {
+		fw.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (jil JobInfoList) writeTo(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+	header := jobListHeader{Magic: jobListFormatMagic, Version: JobListFormatVersion}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	records := make([]JobRecord, 0, len(jil))
+	for _, job := range jil {
+		if job != nil {
+			records = append(records, newJobRecord(job))
+		}
+	}
+	return enc.Encode(records)
+}
+
+func readJobList(data []byte) (JobInfoList, error) {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	var header jobListHeader
+	if err := dec.Decode(&header); err != nil || header.Magic != jobListFormatMagic {
+		// version 1 (a plain JobInfoList)
+		ans := make(JobInfoList, 0, 50)
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&ans); err != nil {
+			return nil, fmt.Errorf("failed to decode job list of unknown format: %w", err)
+		}
+		return ans, nil
+	}
+	if header.Version > JobListFormatVersion {
+		return nil, fmt.Errorf(
+			"job list format version %d is newer than the supported %d",
+			header.Version, JobListFormatVersion)
+	}
+	var records []JobRecord
+	if err := dec.Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode job list records: %w", err)
+	}
+	ans := make(JobInfoList, 0, len(records))
+	for _, rec := range records {
+		ans = append(ans, restoreJob(rec, header.Version))
+	}
+	return ans, nil
+}
+
+// LoadJobList loads a job list stored by JobInfoList.Serialize
+// (any format version). Jobs which cannot be decoded anymore are
+// migrated (see RegisterJobMigration) or restored as RestoredJobInfo.
+// In case the file cannot be read at all, it is kept as a backup
+// (with a timestamp suffix) so it is not overwritten once the current
+// job list is stored.
+func LoadJobList(path string) (JobInfoList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ans, err := readJobList(data)
+	if err != nil {
+		backupPath := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102T150405"))
+		if err2 := os.Rename(path, backupPath); err2 != nil {
+			return nil, fmt.Errorf("%w (and failed to back up the file: %s)", err, err2)
+		}
+		return nil, fmt.Errorf("%w (the file has been backed up to %s)", err, backupPath)
+	}
+	return ans, nil
+}
+
+// RestoredJobInfo replaces a stored job which could not be decoded
+// or migrated. It keeps the basic job properties and the original
+// full information. It is always finished (i.e. never restarted).
+type RestoredJobInfo struct {
+	ID       string
+	Type     string
+	CorpusID string
+	Start    JSONTime
+	Update   JSONTime
+	Error    string
+	Info     json.RawMessage
+}
+
+func newRestoredJobInfo(rec JobRecord, decodeErr error) *RestoredJobInfo {
+	ans := &RestoredJobInfo{
+		ID:       rec.ID,
+		Type:     rec.Type,
+		CorpusID: rec.CorpusID,
+		Start:    rec.Start,
+		Update:   rec.Update,
+		Error:    rec.Error,
+		Info:     rec.Info,
+	}
+	if !rec.Finished {
+		ans.Update = CurrentDatetime()
+		ans.Error = fmt.Sprintf("unfinished job could not be restored: %s", decodeErr)
+	}
+	return ans
+}
+
+func (j *RestoredJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j *RestoredJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j *RestoredJobInfo) GetStartDT() JSONTime {
+	return j.Start
+}
+
+func (j *RestoredJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j *RestoredJobInfo) IsFinished() bool {
+	return true
+}
+
+func (j *RestoredJobInfo) AsFinished() GeneralJobInfo {
+	return j
+}
+
+func (j *RestoredJobInfo) GetNumRestarts() int {
+	return 0
+}
+
+func (j *RestoredJobInfo) GetError() error {
+	if j.Error == "" {
+		return nil
+	}
+	return &ReplicatedError{Message: j.Error}
+}
+
+func (j *RestoredJobInfo) WithError(err error) GeneralJobInfo {
+	ans := *j
+	ans.Update = CurrentDatetime()
+	ans.Error = ErrorToString(err)
+	return &ans
+}
+
+func (j *RestoredJobInfo) CompactVersion() JobInfoCompact {
+	return JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: true,
+		OK:       j.Error == "",
+	}
+}
+
+func (j *RestoredJobInfo) FullInfo() any {
+	return struct {
+		JobInfoCompact
+		Error    string          `json:"error,omitempty"`
+		Restored bool            `json:"restored"`
+		Info     json.RawMessage `json:"info,omitempty"`
+	}{
+		JobInfoCompact: j.CompactVersion(),
+		Error:          j.Error,
+		Restored:       true,
+		Info:           j.Info,
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializeLoadJobList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.gob")
+	jobs := JobInfoList{
+		DummyJobInfo{ID: "job1", CorpusID: "syn2020"},
+		DummyJobInfo{
+			ID: "job2", CorpusID: "syn2020", Finished: true,
+			Error: fmt.Errorf("failed: %w", os.ErrNotExist)},
+		unregisteredJobInfo{DummyJobInfo{ID: "job3", Type: "dummy-job", CorpusID: "susanne"}},
+	}
+	assert.NoError(t, jobs.Serialize(path))
+	loaded, err := LoadJobList(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 3)
+	assert.Equal(t, DummyJobInfo{ID: "job1", CorpusID: "syn2020"}, loaded[0])
+	assert.True(t, loaded[1].IsFinished())
+	assert.EqualError(t, loaded[1].GetError(), "failed: file does not exist")
+
+	// job3 cannot be gob-encoded, so it is restored from its basic properties
+	restored, ok := loaded[2].(*RestoredJobInfo)
+	assert.True(t, ok)
+	assert.Equal(t, "job3", restored.GetID())
+	assert.Equal(t, "susanne", restored.GetCorpus())
+	assert.True(t, restored.IsFinished())
+	assert.Error(t, restored.GetError())
+}
+
+func TestLoadLegacyJobList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.gob")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, gob.NewEncoder(f).Encode(JobInfoList{DummyJobInfo{ID: "job1"}}))
+	f.Close()
+	loaded, err := LoadJobList(path)
+	assert.NoError(t, err)
+	assert.Equal(t, JobInfoList{DummyJobInfo{ID: "job1"}}, loaded)
+}
+
+func TestRestoreJobWithMigration(t *testing.T) {
+	rec := JobRecord{ID: "job1", Type: "test-migrated", Data: []byte("garbage"), Info: []byte(`{"id":"job1"}`)}
+	job := restoreJob(rec, JobListFormatVersion)
+	assert.IsType(t, &RestoredJobInfo{}, job)
+	assert.Error(t, job.GetError())
+
+	RegisterJobMigration("test-migrated", func(rec JobRecord, formatVersion int) (GeneralJobInfo, error) {
+		return DummyJobInfo{ID: rec.ID, Type: rec.Type}, nil
+	})
+	job = restoreJob(rec, JobListFormatVersion)
+	assert.Equal(t, DummyJobInfo{ID: "job1", Type: "test-migrated"}, job)
+}
+
+func TestLoadJobListUnsupportedVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "status.gob")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	enc := gob.NewEncoder(f)
+	assert.NoError(t, enc.Encode(jobListHeader{Magic: jobListFormatMagic, Version: JobListFormatVersion + 1}))
+	assert.NoError(t, enc.Encode([]JobRecord{}))
+	f.Close()
+
+	_, err = LoadJobList(path)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	backups, _ := filepath.Glob(filepath.Join(dir, "status.gob.*.bak"))
+	assert.Len(t, backups, 1)
+}
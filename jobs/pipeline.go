@@ -0,0 +1,349 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	PipelineJobType = "pipeline"
+
+	PipelineStepPending  = "pending"
+	PipelineStepRunning  = "running"
+	PipelineStepFinished = "finished"
+	PipelineStepFailed   = "failed"
+	PipelineStepSkipped  = "skipped"
+)
+
+var (
+	// pipelineCheckInterval specifies how often a pipeline
+	// checks the status of a job of its running step
+	pipelineCheckInterval = 2 * time.Second
+)
+
+// PipelineStep is a request submitted once the previous step
+// of a pipeline succeeds. In case the request creates a job
+// (i.e. it responds with an object containing `id`), the step
+// finishes along with the job.
+type PipelineStep struct {
+	Name    string           `json:"name"`
+	Request ScheduledRequest `json:"request"`
+}
+
+// PipelineStepStatus describes the progress of a single
+// pipeline step
+type PipelineStepStatus struct {
+	PipelineStep
+	Status     string          `json:"status"`
+	HTTPStatus int             `json:"httpStatus,omitempty"`
+	JobID      string          `json:"jobId,omitempty"`
+	Job        *JobInfoCompact `json:"job,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// PipelineJobInfo is a parent job running its steps one after
+// another. Each step starts only after the previous one succeeds.
+// Once a step fails, the remaining ones are skipped.
+type PipelineJobInfo struct {
+	ID          string               `json:"id"`
+	Type        string               `json:"type"`
+	CorpusID    string               `json:"corpusId"`
+	Name        string               `json:"name"`
+	Start       JSONTime             `json:"start"`
+	Update      JSONTime             `json:"update"`
+	Finished    bool                 `json:"finished"`
+	Error       error                `json:"error,omitempty"`
+	NumRestarts int                  `json:"numRestarts"`
+	Steps       []PipelineStepStatus `json:"steps"`
+}
+
+func (j PipelineJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j PipelineJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j PipelineJobInfo) GetStartDT() JSONTime {
+	return j.Start
+}
+
+func (j PipelineJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j PipelineJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j PipelineJobInfo) AsFinished() GeneralJobInfo {
+	j.Update = CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j PipelineJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+// numFinishedSteps returns the number of successfully finished steps
+func (j PipelineJobInfo) numFinishedSteps() int {
+	var ans int
+	for _, step := range j.Steps {
+		if step.Status == PipelineStepFinished {
+			ans++
+		}
+	}
+	return ans
+}
+
+func (j PipelineJobInfo) FullInfo() any {
+	return struct {
+		ID               string               `json:"id"`
+		Type             string               `json:"type"`
+		CorpusID         string               `json:"corpusId"`
+		Name             string               `json:"name"`
+		Start            JSONTime             `json:"start"`
+		Update           JSONTime             `json:"update"`
+		Finished         bool                 `json:"finished"`
+		Error            string               `json:"error,omitempty"`
+		OK               bool                 `json:"ok"`
+		NumRestarts      int                  `json:"numRestarts"`
+		NumFinishedSteps int                  `json:"numFinishedSteps"`
+		Steps            []PipelineStepStatus `json:"steps"`
+	}{
+		ID:               j.ID,
+		Type:             j.Type,
+		CorpusID:         j.CorpusID,
+		Name:             j.Name,
+		Start:            j.Start,
+		Update:           j.Update,
+		Finished:         j.Finished,
+		Error:            ErrorToString(j.Error),
+		OK:               j.Error == nil,
+		NumRestarts:      j.NumRestarts,
+		NumFinishedSteps: j.numFinishedSteps(),
+		Steps:            j.Steps,
+	}
+}
+
+func (j PipelineJobInfo) CompactVersion() JobInfoCompact {
+	return JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j PipelineJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j PipelineJobInfo) WithError(err error) GeneralJobInfo {
+	j.Steps = j.cloneSteps()
+	j.Update = CurrentDatetime()
+	j.Error = err
+	return j
+}
+
+func (j PipelineJobInfo) cloneSteps() []PipelineStepStatus {
+	ans := make([]PipelineStepStatus, len(j.Steps))
+	copy(ans, j.Steps)
+	return ans
+}
+
+// clone creates a copy of the info which is not affected
+// by further changes of the original steps
+func (j PipelineJobInfo) clone() PipelineJobInfo {
+	j.Steps = j.cloneSteps()
+	return j
+}
+
+// isQueued tests whether a job is waiting in the job queue
+func (a *Actions) isQueued(jobID string) bool {
+	a.jobQueueLock.Lock()
+	defer a.jobQueueLock.Unlock()
+	for _, item := range a.jobQueue.Items() {
+		if item.GetID() == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForJob waits until a job finishes and returns its error (if any).
+// The onUpdate function is called with the current state of the job
+// each time it is checked.
+func (a *Actions) waitForJob(jobID string, onUpdate func(JobInfoCompact)) error {
+	ticker := time.NewTicker(pipelineCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		// jobs are moved from the queue to the job list under the queue
+		// lock so the order of the checks matters here
+		if a.isQueued(jobID) {
+			continue
+		}
+		a.jobListLock.Lock()
+		job, ok := a.jobList[jobID]
+		a.jobListLock.Unlock()
+		if !ok {
+			return fmt.Errorf("job %s not found", jobID)
+		}
+		onUpdate(job.CompactVersion())
+		if job.IsFinished() {
+			return job.GetError()
+		}
+	}
+	return nil
+}
+
+// runPipeline runs pipeline steps starting from the first unfinished one.
+// A running step with a known job (e.g. in case of a restarted pipeline)
+// is not submitted again, only its job is waited for.
+func (a *Actions) runPipeline(info PipelineJobInfo, updates chan<- GeneralJobInfo) {
+	defer close(updates)
+	jobLog := a.JobLogger(info.ID)
+	for i := range info.Steps {
+		step := &info.Steps[i]
+		if step.Status == PipelineStepFinished {
+			continue
+		}
+		var err error
+		if step.Status != PipelineStepRunning || step.JobID == "" {
+			step.Status = PipelineStepRunning
+			jobLog.Info().
+				Str("step", step.Name).
+				Str("method", step.Request.Method).
+				Str("path", step.Request.Path).
+				Msg("submitting pipeline step")
+			step.HTTPStatus, step.JobID, err = a.submitInternalRequest(step.Request)
+			info.Update = CurrentDatetime()
+			updates <- info.clone()
+		}
+		if err == nil && step.JobID != "" {
+			err = a.waitForJob(step.JobID, func(job JobInfoCompact) {
+				if step.Job == nil || *step.Job != job {
+					step.Job = &job
+					info.Update = CurrentDatetime()
+					updates <- info.clone()
+				}
+			})
+		}
+		if err != nil {
+			step.Status = PipelineStepFailed
+			step.Error = err.Error()
+			for j := i + 1; j < len(info.Steps); j++ {
+				info.Steps[j].Status = PipelineStepSkipped
+			}
+			updates <- info.WithError(fmt.Errorf("step %s failed: %w", step.Name, err)).AsFinished()
+			jobLog.Error().Err(err).Str("step", step.Name).Msg("pipeline step failed")
+			return
+		}
+		step.Status = PipelineStepFinished
+		info.Update = CurrentDatetime()
+		updates <- info.clone()
+	}
+	updates <- info.clone().AsFinished()
+}
+
+// RestartPipeline continues a pipeline interrupted e.g. by a restart
+// of the service. Jobs of running steps are expected to be restarted
+// before the pipeline so they can be waited for.
+func (a *Actions) RestartPipeline(info *PipelineJobInfo) error {
+	if err := a.TestAllowsJobRestart(info); err != nil {
+		return err
+	}
+	info.NumRestarts++
+	info.Update = CurrentDatetime()
+	updates := a.addJobInfo(info.clone())
+	go a.runPipeline(info.clone(), updates)
+	logger.Info().Msgf("Restarted pipeline %s", info.ID)
+	return nil
+}
+
+type pipelineArgs struct {
+	Name     string         `json:"name"`
+	CorpusID string         `json:"corpusId"`
+	Steps    []PipelineStep `json:"steps"`
+}
+
+func (args pipelineArgs) validate() error {
+	if len(args.Steps) == 0 {
+		return fmt.Errorf("no steps specified")
+	}
+	for i, step := range args.Steps {
+		if err := step.Request.Validate(); err != nil {
+			return fmt.Errorf("invalid step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// CreatePipeline creates a pipeline job running provided
+// requests one after another
+func (a *Actions) CreatePipeline(ctx *gin.Context) {
+	var args pipelineArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create pipeline: %w", err), http.StatusBadRequest)
+		return
+	}
+	if err := args.validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create pipeline: %w", err), http.StatusBadRequest)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create pipeline: %w", err), http.StatusInternalServerError)
+		return
+	}
+	info := PipelineJobInfo{
+		ID:       jobID.String(),
+		Type:     PipelineJobType,
+		CorpusID: args.CorpusID,
+		Name:     args.Name,
+		Start:    CurrentDatetime(),
+		Update:   CurrentDatetime(),
+		Steps:    make([]PipelineStepStatus, len(args.Steps)),
+	}
+	for i, step := range args.Steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		info.Steps[i] = PipelineStepStatus{PipelineStep: step, Status: PipelineStepPending}
+	}
+	updates := a.addJobInfo(info.clone())
+	go a.runPipeline(info.clone(), updates)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, info.FullInfo())
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestingPipelineActions(jobErr error) *Actions {
+	a := &Actions{
+		jobList:   make(map[string]GeneralJobInfo),
+		jobQueue:  &JobQueue{},
+		jobLogs:   newJobLogs(0),
+		jobEvents: newJobEventBroker(),
+	}
+	a.requestHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/liveAttributes/syn2020/data":
+			a.jobList["job-a"] = DummyJobInfo{ID: "job-a", Finished: true, Error: jobErr}
+			w.Write([]byte(`{"id": "job-a"}`))
+		case "/kontext/reset":
+			w.Write([]byte(`{"ok": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+		}
+	})
+	return a
+}
+
+func newTestingPipeline(paths ...string) PipelineJobInfo {
+	ans := PipelineJobInfo{ID: "pipeline-1", Type: PipelineJobType}
+	for _, p := range paths {
+		ans.Steps = append(
+			ans.Steps,
+			PipelineStepStatus{
+				PipelineStep: PipelineStep{Name: p, Request: ScheduledRequest{Method: "POST", Path: p}},
+				Status:       PipelineStepPending,
+			},
+		)
+	}
+	return ans
+}
+
+func runTestingPipeline(a *Actions, info PipelineJobInfo) PipelineJobInfo {
+	pipelineCheckInterval = time.Millisecond
+	updates := make(chan GeneralJobInfo, 100)
+	a.runPipeline(info, updates)
+	var last GeneralJobInfo
+	for upd := range updates {
+		last = upd
+	}
+	return last.(PipelineJobInfo)
+}
+
+func TestRunPipeline(t *testing.T) {
+	a := newTestingPipelineActions(nil)
+	ans := runTestingPipeline(a, newTestingPipeline("/liveAttributes/syn2020/data", "/kontext/reset"))
+	assert.True(t, ans.IsFinished())
+	assert.NoError(t, ans.GetError())
+	assert.Equal(t, 2, ans.numFinishedSteps())
+	assert.Equal(t, "job-a", ans.Steps[0].JobID)
+	assert.True(t, ans.Steps[0].Job.Finished)
+	assert.Equal(t, http.StatusOK, ans.Steps[1].HTTPStatus)
+	assert.Equal(t, "", ans.Steps[1].JobID)
+}
+
+func TestRunPipelineFailedJob(t *testing.T) {
+	a := newTestingPipelineActions(errors.New("missing vertical"))
+	ans := runTestingPipeline(a, newTestingPipeline("/liveAttributes/syn2020/data", "/kontext/reset"))
+	assert.True(t, ans.IsFinished())
+	assert.Error(t, ans.GetError())
+	assert.Equal(t, PipelineStepFailed, ans.Steps[0].Status)
+	assert.Equal(t, "missing vertical", ans.Steps[0].Error)
+	assert.Equal(t, PipelineStepSkipped, ans.Steps[1].Status)
+}
+
+func TestRunPipelineFailedRequest(t *testing.T) {
+	a := newTestingPipelineActions(nil)
+	ans := runTestingPipeline(a, newTestingPipeline("/foo", "/kontext/reset"))
+	assert.Error(t, ans.GetError())
+	assert.Equal(t, http.StatusNotFound, ans.Steps[0].HTTPStatus)
+	assert.Equal(t, PipelineStepSkipped, ans.Steps[1].Status)
+}
+
+func TestRunRestartedPipeline(t *testing.T) {
+	a := newTestingPipelineActions(nil)
+	a.jobList["job-b"] = DummyJobInfo{ID: "job-b", Finished: true}
+	info := newTestingPipeline("/liveAttributes/syn2020/data", "/kontext/reset")
+	info.Steps[0].Status = PipelineStepRunning
+	info.Steps[0].JobID = "job-b"
+	ans := runTestingPipeline(a, info)
+	assert.NoError(t, ans.GetError())
+	assert.Equal(t, "job-b", ans.Steps[0].JobID)
+	assert.NotContains(t, a.jobList, "job-a")
+}
+
+func TestPipelineArgsValidate(t *testing.T) {
+	assert.Error(t, pipelineArgs{}.validate())
+	assert.Error(t, pipelineArgs{Steps: []PipelineStep{{Request: ScheduledRequest{Method: "POST"}}}}.validate())
+	assert.NoError(t, pipelineArgs{Steps: []PipelineStep{{Request: ScheduledRequest{Method: "POST", Path: "/x"}}}}.validate())
+}
@@ -123,3 +123,58 @@ func (jq *JobQueue) PeekID() (string, error) {
 	}
 	return jq.firstEntry.initialState.GetID(), nil
 }
+
+// Items returns initial states of all the queued jobs
+// in the order they were enqueued
+func (jq *JobQueue) Items() []GeneralJobInfo {
+	ans := make([]GeneralJobInfo, 0, jq.Size())
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		ans = append(ans, curr.initialState)
+	}
+	return ans
+}
+
+// Remove removes a job with a specified ID from any position
+// of the queue. In case there is no such job, ErrorEmptyQueue
+// is returned.
+func (jq *JobQueue) Remove(jobID string) (*QueuedFunc, GeneralJobInfo, error) {
+	var prev *JobEntry
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		if curr.initialState.GetID() == jobID {
+			if prev == nil {
+				jq.firstEntry = curr.next
+
+			} else {
+				prev.next = curr.next
+			}
+			if jq.lastEntry == curr {
+				jq.lastEntry = prev
+			}
+			return curr.job, curr.initialState, nil
+		}
+		prev = curr
+	}
+	return nil, nil, ErrorEmptyQueue
+}
+
+// SelectNext returns ID of a queued job to be started next. Only
+// jobs accepted by `canRun` are considered. Out of them, the first
+// enqueued job with the highest priority is chosen.
+func (jq *JobQueue) SelectNext(
+	canRun func(GeneralJobInfo) bool,
+	priority func(GeneralJobInfo) int,
+) (string, bool) {
+	var best *JobEntry
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		if !canRun(curr.initialState) {
+			continue
+		}
+		if best == nil || priority(curr.initialState) > priority(best.initialState) {
+			best = curr
+		}
+	}
+	if best == nil {
+		return "", false
+	}
+	return best.initialState.GetID(), true
+}
@@ -121,3 +121,51 @@ func TestDelayNextOnTwoItemQueue(t *testing.T) {
 	assert.Equal(t, &f2, v)
 	assert.NoError(t, err)
 }
+
+func TestRemove(t *testing.T) {
+	q := JobQueue{}
+	f1 := func(chan<- GeneralJobInfo) {}
+	f2 := func(chan<- GeneralJobInfo) {}
+	f3 := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f1, &DummyJobInfo{ID: "1"})
+	q.Enqueue(&f2, &DummyJobInfo{ID: "2"})
+	q.Enqueue(&f3, &DummyJobInfo{ID: "3"})
+	f, st, err := q.Remove("3")
+	assert.NoError(t, err)
+	assert.Equal(t, &f3, f)
+	assert.Equal(t, "3", st.GetID())
+	assert.Equal(t, "2", q.lastEntry.initialState.GetID())
+	_, _, err = q.Remove("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", q.firstEntry.initialState.GetID())
+	_, _, err = q.Remove("1")
+	assert.Error(t, err)
+	_, _, err = q.Remove("2")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, q.Size())
+	assert.Nil(t, q.lastEntry)
+}
+
+func TestSelectNext(t *testing.T) {
+	q := JobQueue{}
+	f := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f, &DummyJobInfo{ID: "1", Type: "ngrams"})
+	q.Enqueue(&f, &DummyJobInfo{ID: "2", Type: "liveattrs"})
+	q.Enqueue(&f, &DummyJobInfo{ID: "3", Type: "liveattrs"})
+	priority := func(job GeneralJobInfo) int {
+		if job.GetType() == "liveattrs" {
+			return 1
+		}
+		return 0
+	}
+	all := func(GeneralJobInfo) bool { return true }
+	id, ok := q.SelectNext(all, priority)
+	assert.True(t, ok)
+	assert.Equal(t, "2", id)
+	noLiveattrs := func(job GeneralJobInfo) bool { return job.GetType() != "liveattrs" }
+	id, ok = q.SelectNext(noLiveattrs, priority)
+	assert.True(t, ok)
+	assert.Equal(t, "1", id)
+	_, ok = q.SelectNext(func(GeneralJobInfo) bool { return false }, priority)
+	assert.False(t, ok)
+}
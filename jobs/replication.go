@@ -0,0 +1,114 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+func init() {
+	gob.Register(&ReplicatedError{})
+}
+
+// ReplicatedError replaces errors of exported jobs as
+// general errors cannot be gob-encoded
+type ReplicatedError struct {
+	Message string
+}
+
+func (err *ReplicatedError) Error() string {
+	return err.Message
+}
+
+// exportableJob returns a version of a job with its error
+// (if any) replaced by ReplicatedError
+func exportableJob(job GeneralJobInfo) GeneralJobInfo {
+	err := job.GetError()
+	if err == nil {
+		return job
+	}
+	ans := job.WithError(&ReplicatedError{Message: err.Error()})
+	if job.IsFinished() && !ans.IsFinished() {
+		ans = ans.AsFinished()
+	}
+	return ans
+}
+
+// ExportJobHistory gob-encodes all the jobs (including
+// the finished ones) to a provided writer. The format is
+// the same as the one of the status data file (see
+// JobListFormatVersion). Jobs of types
+// which cannot be gob-encoded (i.e. not registered) are skipped.
+func (a *Actions) ExportJobHistory(w io.Writer) error {
+	a.jobListLock.Lock()
+	jobList := a.createJobList(false)
+	a.jobListLock.Unlock()
+	ans := make(JobInfoList, 0, len(jobList))
+	for _, job := range jobList {
+		job = exportableJob(job)
+		if err := gob.NewEncoder(io.Discard).Encode(JobInfoList{job}); err != nil {
+			logger.Warn().Err(err).Str("jobId", job.GetID()).Msg("job cannot be replicated, skipping")
+			continue
+		}
+		ans = append(ans, job)
+	}
+	return ans.writeTo(w)
+}
+
+// ImportJobHistory loads jobs exported by a primary instance
+// (see ExportJobHistory). Finished jobs are added to the job list,
+// unfinished ones are registered as detached so they can be restarted
+// once the instance takes over (see GetDetachedJobs). Detached jobs
+// imported before and no longer unfinished on the primary are removed.
+// The number of imported jobs is returned.
+func (a *Actions) ImportJobHistory(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import job history: %w", err)
+	}
+	imported, err := readJobList(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import job history: %w", err)
+	}
+	a.detachedJobsLock.Lock()
+	defer a.detachedJobsLock.Unlock()
+	for jobID := range a.replicatedJobs {
+		delete(a.detachedJobs, jobID)
+	}
+	a.replicatedJobs = make(map[string]bool)
+	a.jobListLock.Lock()
+	defer a.jobListLock.Unlock()
+	var ans int
+	for _, job := range imported {
+		if job == nil {
+			continue
+		}
+		if job.IsFinished() {
+			a.jobList[job.GetID()] = job
+
+		} else {
+			a.detachedJobs[job.GetID()] = job
+			a.replicatedJobs[job.GetID()] = true
+		}
+		ans++
+	}
+	return ans, nil
+}
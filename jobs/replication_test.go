@@ -0,0 +1,87 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gob.Register(DummyJobInfo{})
+}
+
+func newTestingReplicaActions() *Actions {
+	return &Actions{
+		jobList:        make(map[string]GeneralJobInfo),
+		detachedJobs:   make(map[string]GeneralJobInfo),
+		replicatedJobs: make(map[string]bool),
+	}
+}
+
+func TestExportImportJobHistory(t *testing.T) {
+	primary := newTestingReplicaActions()
+	primary.jobList["job1"] = DummyJobInfo{ID: "job1", CorpusID: "syn2020", Finished: true}
+	primary.jobList["job2"] = DummyJobInfo{ID: "job2", CorpusID: "susanne"}
+	var buff bytes.Buffer
+	assert.NoError(t, primary.ExportJobHistory(&buff))
+
+	standby := newTestingReplicaActions()
+	standby.detachedJobs["job0"] = DummyJobInfo{ID: "job0"}
+	n, err := standby.ImportJobHistory(bytes.NewReader(buff.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Contains(t, standby.jobList, "job1")
+	assert.NotContains(t, standby.jobList, "job2")
+	assert.Contains(t, standby.detachedJobs, "job2")
+	assert.Contains(t, standby.detachedJobs, "job0")
+
+	// job2 has finished on the primary in the meantime
+	primary.jobList["job2"] = primary.jobList["job2"].AsFinished()
+	buff.Reset()
+	assert.NoError(t, primary.ExportJobHistory(&buff))
+	_, err = standby.ImportJobHistory(&buff)
+	assert.NoError(t, err)
+	assert.Contains(t, standby.jobList, "job2")
+	assert.NotContains(t, standby.detachedJobs, "job2")
+	assert.Contains(t, standby.detachedJobs, "job0")
+}
+
+func TestExportJobHistoryWithErrors(t *testing.T) {
+	primary := newTestingReplicaActions()
+	primary.jobList["job1"] = DummyJobInfo{ID: "job1", Finished: true, Error: errors.New("failed to run")}
+	primary.jobList["job2"] = unregisteredJobInfo{DummyJobInfo{ID: "job2", Finished: true}}
+	var buff bytes.Buffer
+	assert.NoError(t, primary.ExportJobHistory(&buff))
+
+	standby := newTestingReplicaActions()
+	n, err := standby.ImportJobHistory(&buff)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, standby.jobList["job1"].IsFinished())
+	assert.EqualError(t, standby.jobList["job1"].GetError(), "failed to run")
+}
+
+type unregisteredJobInfo struct {
+	DummyJobInfo
+}
@@ -0,0 +1,350 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	jobRequestCtxKey = "jobRequest"
+)
+
+// JobRequest is an HTTP request a job has been created by.
+// It allows for an exact re-submission of the job (e.g. to reproduce
+// a data build after its configuration has changed).
+type JobRequest struct {
+	JobID       string   `json:"jobId"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Query       string   `json:"query"`
+	ContentType string   `json:"contentType,omitempty"`
+	Body        string   `json:"body"`
+	Created     JSONTime `json:"created"`
+}
+
+// ToHTTPRequest creates a new HTTP request equal to the recorded one
+func (jr *JobRequest) ToHTTPRequest() (*http.Request, error) {
+	u := url.URL{Path: jr.Path, RawQuery: jr.Query}
+	req, err := http.NewRequest(jr.Method, u.String(), strings.NewReader(jr.Body))
+	if err != nil {
+		return nil, err
+	}
+	if jr.ContentType != "" {
+		req.Header.Set("Content-Type", jr.ContentType)
+	}
+	return req, nil
+}
+
+// RerunOverrides specifies changes of a recorded request applied
+// when re-submitting it. Query contains URL arguments to be set
+// (an empty value removes the argument), Body contains top-level
+// fields of a JSON body to be set (a null value removes the field).
+type RerunOverrides struct {
+	Query map[string]string          `json:"query"`
+	Body  map[string]json.RawMessage `json:"body"`
+}
+
+// WithOverrides creates a copy of the request with applied overrides.
+// Body overrides require the recorded body to be a JSON object (or empty).
+func (jr *JobRequest) WithOverrides(ov RerunOverrides) (*JobRequest, error) {
+	ans := *jr
+	if len(ov.Query) > 0 {
+		args, err := url.ParseQuery(jr.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply URL argument overrides: %w", err)
+		}
+		for k, v := range ov.Query {
+			if v == "" {
+				args.Del(k)
+
+			} else {
+				args.Set(k, v)
+			}
+		}
+		ans.Query = args.Encode()
+	}
+	if len(ov.Body) > 0 {
+		body := make(map[string]json.RawMessage)
+		if strings.TrimSpace(jr.Body) != "" {
+			if err := json.Unmarshal([]byte(jr.Body), &body); err != nil {
+				return nil, fmt.Errorf("failed to apply body overrides: %w", err)
+			}
+		}
+		for k, v := range ov.Body {
+			if string(v) == "null" {
+				delete(body, k)
+
+			} else {
+				body[k] = v
+			}
+		}
+		rawBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply body overrides: %w", err)
+		}
+		ans.Body = string(rawBody)
+		if ans.ContentType == "" {
+			ans.ContentType = "application/json"
+		}
+	}
+	return &ans, nil
+}
+
+// newJobRequest records method, URL and body of a request.
+// The body of the original request remains readable.
+func newJobRequest(req *http.Request) (*JobRequest, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return &JobRequest{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       req.URL.RawQuery,
+		ContentType: req.Header.Get("Content-Type"),
+		Body:        string(body),
+		Created:     CurrentDatetime(),
+	}, nil
+}
+
+// RecordingRequest wraps a handler creating a job so the originating
+// request is available to the handler. To actually store the request
+// along with the job, the handler is expected to call AttachRequest.
+func (a *Actions) RecordingRequest(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		jobReq, err := newJobRequest(ctx.Request)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError("failed to read request: %w", err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		ctx.Set(jobRequestCtxKey, jobReq)
+		handler(ctx)
+	}
+}
+
+func (a *Actions) jobRequestPath(jobID string) string {
+	return filepath.Join(a.conf.JobRequestsDirPath, jobID+".json")
+}
+
+// AttachRequest stores a request recorded by RecordingRequest
+// as the originating request of a job. In case the request has
+// not been recorded, nothing is stored. In case
+// Conf.JobRequestsDirPath is configured, the request is also
+// written to the directory so it survives service restarts.
+func (a *Actions) AttachRequest(ctx *gin.Context, jobID string) {
+	v, ok := ctx.Get(jobRequestCtxKey)
+	if !ok {
+		return
+	}
+	jobReq := *(v.(*JobRequest))
+	jobReq.JobID = jobID
+	a.jobRequestsLock.Lock()
+	a.jobRequests[jobID] = &jobReq
+	a.jobRequestsLock.Unlock()
+	if a.conf.JobRequestsDirPath == "" {
+		return
+	}
+	rawData, err := json.Marshal(jobReq)
+	if err != nil {
+		logger.Error().Err(err).Str("jobId", jobID).Msg("failed to store job request")
+		return
+	}
+	// requests may contain sensitive data (e.g. configs with credentials)
+	if err := os.WriteFile(a.jobRequestPath(jobID), rawData, 0600); err != nil {
+		logger.Error().Err(err).Str("jobId", jobID).Msg("failed to store job request")
+	}
+}
+
+// getJobRequest returns the originating request of a job.
+// In case nothing is found, nil is returned.
+func (a *Actions) getJobRequest(jobID string) (*JobRequest, error) {
+	a.jobRequestsLock.Lock()
+	jobReq, ok := a.jobRequests[jobID]
+	a.jobRequestsLock.Unlock()
+	if ok || a.conf.JobRequestsDirPath == "" {
+		return jobReq, nil
+	}
+	// prevent escaping the directory via job ID
+	if filepath.Base(jobID) != jobID {
+		return nil, nil
+	}
+	isFile, err := fs.IsFile(a.jobRequestPath(jobID))
+	if err != nil || !isFile {
+		return nil, err
+	}
+	rawData, err := os.ReadFile(a.jobRequestPath(jobID))
+	if err != nil {
+		return nil, err
+	}
+	var ans JobRequest
+	if err := json.Unmarshal(rawData, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load request of job %s: %w", jobID, err)
+	}
+	return &ans, nil
+}
+
+// clearOldJobRequests removes old requests from memory. Requests
+// are kept as long as jobs of any type may be kept (see Conf.MaxRetention).
+// Requests stored in Conf.JobRequestsDirPath are kept.
+func (a *Actions) clearOldJobRequests() {
+	curr := CurrentDatetime()
+	maxRetention := a.conf.MaxRetention()
+	a.jobRequestsLock.Lock()
+	for k, v := range a.jobRequests {
+		if curr.Sub(v.Created) > maxRetention {
+			delete(a.jobRequests, k)
+		}
+	}
+	a.jobRequestsLock.Unlock()
+}
+
+// SetRequestHandler sets a handler used for re-submitting
+// recorded job requests (typically the HTTP engine itself)
+func (a *Actions) SetRequestHandler(handler http.Handler) {
+	a.requestHandler = handler
+}
+
+// JobRequest returns the originating request of a job
+func (a *Actions) JobRequest(ctx *gin.Context) {
+	jobReq, err := a.getJobRequest(ctx.Param("jobId"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to get job request: %w", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if jobReq == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("job request not found"), http.StatusNotFound)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, jobReq)
+}
+
+// copyRerunHeaders copies headers of a rerun request (e.g. a client
+// token used by quotas) to a re-submitted request. Headers describing
+// the body are kept from the recorded request.
+func copyRerunHeaders(rerunReq, req *http.Request) {
+	for k, v := range rerunReq.Header {
+		if k == "Content-Type" || k == "Content-Length" {
+			continue
+		}
+		req.Header[k] = slices.Clone(v)
+	}
+}
+
+// Rerun creates a new job by re-submitting the originating request
+// of a finished job. The request body may contain RerunOverrides
+// to change selected URL arguments and body fields. The re-submitted
+// request carries headers of the rerun request so the new job is
+// attributed (e.g. by quotas) to the client requesting the rerun.
+// The response is the same as the response of the original request
+// (i.e. typically an information about the newly created job).
+func (a *Actions) Rerun(ctx *gin.Context) {
+	jobReq, err := a.getJobRequest(ctx.Param("jobId"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: %w", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if jobReq == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("job request not found"), http.StatusNotFound)
+		return
+	}
+	if job, ok := a.GetJob(jobReq.JobID); ok && !job.IsFinished() {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: job %s has not finished yet", jobReq.JobID),
+			http.StatusConflict,
+		)
+		return
+	}
+	var overrides RerunOverrides
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&overrides); err != nil && err != io.EOF {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: %w", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	newReq, err := jobReq.WithOverrides(overrides)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: %w", err),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	if a.requestHandler == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: no request handler set"),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	req, err := newReq.ToHTTPRequest()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to rerun job: %w", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	copyRerunHeaders(ctx.Request, req)
+	logger.Info().
+		Str("jobId", jobReq.JobID).
+		Str("method", newReq.Method).
+		Str("path", newReq.Path).
+		Str("query", newReq.Query).
+		Msg("re-submitting job request")
+	a.requestHandler.ServeHTTP(ctx.Writer, req)
+}
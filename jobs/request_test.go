@@ -0,0 +1,156 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJobRequestKeepsBody(t *testing.T) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"/liveAttributes/corp1/data?append=1",
+		strings.NewReader(`{"maxNumErrors": 10}`),
+	)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	jobReq, err := newJobRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, jobReq.Method)
+	assert.Equal(t, "/liveAttributes/corp1/data", jobReq.Path)
+	assert.Equal(t, "append=1", jobReq.Query)
+	assert.Equal(t, `{"maxNumErrors": 10}`, jobReq.Body)
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"maxNumErrors": 10}`, string(body))
+}
+
+func TestJobRequestToHTTPRequest(t *testing.T) {
+	jobReq := JobRequest{
+		Method:      http.MethodPost,
+		Path:        "/liveAttributes/corp1/data",
+		Query:       "append=1&noCorpusUpdate=1",
+		ContentType: "application/json",
+		Body:        `{"maxNumErrors": 10}`,
+	}
+	req, err := jobReq.ToHTTPRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/liveAttributes/corp1/data", req.URL.Path)
+	assert.Equal(t, "1", req.URL.Query().Get("noCorpusUpdate"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, jobReq.Body, string(body))
+}
+
+func TestCopyRerunHeaders(t *testing.T) {
+	jobReq := JobRequest{
+		Method:      http.MethodPost,
+		Path:        "/liveAttributes/corp1/ngrams",
+		ContentType: "application/json",
+		Body:        `{"n": 2}`,
+	}
+	req, err := jobReq.ToHTTPRequest()
+	assert.NoError(t, err)
+	rerunReq := httptest.NewRequest(http.MethodPost, "/jobs/abc/_rerun", strings.NewReader(`{}`))
+	rerunReq.Header.Set("Authorization", "Bearer abc")
+	rerunReq.Header.Set("Content-Type", "text/plain")
+	copyRerunHeaders(rerunReq, req)
+	assert.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+}
+
+func TestJobRequestJSONRoundTrip(t *testing.T) {
+	jobReq := JobRequest{
+		JobID:   "a1b2",
+		Method:  http.MethodPost,
+		Path:    "/liveAttributes/corp1/data",
+		Created: CurrentDatetime(),
+	}
+	rawData, err := json.Marshal(jobReq)
+	assert.NoError(t, err)
+	var loaded JobRequest
+	assert.NoError(t, json.Unmarshal(rawData, &loaded))
+	assert.Equal(t, jobReq.JobID, loaded.JobID)
+	assert.Equal(
+		t,
+		jobReq.Created.Format("2006-01-02T15:04:05"),
+		loaded.Created.Format("2006-01-02T15:04:05"),
+	)
+}
+
+func TestJobRequestWithOverrides(t *testing.T) {
+	jobReq := JobRequest{
+		Method: http.MethodPost,
+		Path:   "/liveAttributes/corp1/data",
+		Query:  "append=1&noCorpusUpdate=1",
+		Body:   `{"maxNumErrors": 10, "atomStructure": "doc"}`,
+	}
+	newReq, err := jobReq.WithOverrides(RerunOverrides{
+		Query: map[string]string{"append": "", "reconfigure": "1"},
+		Body: map[string]json.RawMessage{
+			"maxNumErrors":  json.RawMessage("20"),
+			"atomStructure": json.RawMessage("null"),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "noCorpusUpdate=1&reconfigure=1", newReq.Query)
+	assert.JSONEq(t, `{"maxNumErrors": 20}`, newReq.Body)
+	assert.Equal(t, "application/json", newReq.ContentType)
+	// the original request must not be modified
+	assert.Equal(t, "append=1&noCorpusUpdate=1", jobReq.Query)
+}
+
+func TestJobRequestWithOverridesNonObjectBody(t *testing.T) {
+	jobReq := JobRequest{Method: http.MethodPost, Body: `[1, 2]`}
+	_, err := jobReq.WithOverrides(RerunOverrides{
+		Body: map[string]json.RawMessage{"maxNumErrors": json.RawMessage("20")},
+	})
+	assert.Error(t, err)
+}
+
+func TestAttachRequestStoresPrivateFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := &Actions{
+		conf:        &Conf{JobRequestsDirPath: t.TempDir()},
+		jobRequests: make(map[string]*JobRequest),
+	}
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(
+		http.MethodPost, "/liveAttributes/corp1/conf", strings.NewReader(`{"db": {"password": "x"}}`))
+	a.RecordingRequest(func(ctx *gin.Context) {
+		a.AttachRequest(ctx, "job1")
+	})(ctx)
+	info, err := os.Stat(a.jobRequestPath("job1"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	jobReq, err := a.getJobRequest("job1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db": {"password": "x"}}`, jobReq.Body)
+}
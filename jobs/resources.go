@@ -0,0 +1,284 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	dfltResourceSamplingInterval = 5 * time.Second
+
+	// maxNumJobResources is a max. number of jobs with recorded
+	// resource usage. Records of the oldest jobs are removed first.
+	maxNumJobResources = 500
+)
+
+// ResourceUsage describes system resources consumed by a job.
+// Jobs run within the MASM process (including libraries like
+// vert-tagextract) so the values are derived from process-wide
+// counters sampled during the job's execution. Resources consumed
+// while more jobs were running are split evenly among them (see
+// Exclusive).
+type ResourceUsage struct {
+
+	// CPUTime is a user + system CPU time in seconds
+	// (including child processes, e.g. decompression tools)
+	CPUTime float64 `json:"cpuTime"`
+
+	// PeakRSS is the max. resident set size (in bytes) of the process
+	// observed while the job was running
+	PeakRSS int64 `json:"peakRss"`
+
+	// BytesWritten is a number of bytes written to storage
+	BytesWritten int64 `json:"bytesWritten"`
+
+	// Exclusive is true if no other job was running along
+	// with the job (i.e. the values are not estimated)
+	Exclusive bool `json:"exclusive"`
+
+	// Running is true until the job finishes
+	Running bool `json:"running"`
+}
+
+// processStats are process-wide resource counters
+type processStats struct {
+	cpuTime      float64
+	rss          int64
+	bytesWritten int64
+}
+
+func timevalSeconds(tv syscall.Timeval) float64 {
+	return float64(tv.Sec) + float64(tv.Usec)/1e6
+}
+
+// readProcStatusValue reads a numeric value of a "name: value"
+// line of a /proc file. Zero and false are returned if not found.
+func readProcStatusValue(path, name string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || key != name {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return 0, false
+		}
+		v, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		return v, true
+	}
+	return 0, false
+}
+
+// readProcessStats reads current resource counters of the process.
+// In case some of the /proc files are not available, values provided
+// by getrusage are used (which are less accurate).
+func readProcessStats() processStats {
+	var ans processStats
+	var self, children syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &self); err != nil {
+		logger.Warn().Err(err).Msg("failed to read process resource usage")
+	}
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &children); err != nil {
+		logger.Warn().Err(err).Msg("failed to read resource usage of child processes")
+	}
+	ans.cpuTime = timevalSeconds(self.Utime) + timevalSeconds(self.Stime) +
+		timevalSeconds(children.Utime) + timevalSeconds(children.Stime)
+
+	if rss, ok := readProcStatusValue("/proc/self/status", "VmRSS"); ok {
+		ans.rss = rss
+
+	} else {
+		ans.rss = self.Maxrss * 1024
+	}
+	if written, ok := readProcStatusValue("/proc/self/io", "write_bytes"); ok {
+		ans.bytesWritten = written
+
+	} else {
+		ans.bytesWritten = (self.Oublock + children.Oublock) * 512
+	}
+	return ans
+}
+
+// jobResources records resource usage of individual jobs.
+// A nil value is valid and records nothing.
+type jobResources struct {
+	mu      sync.Mutex
+	usage   map[string]*ResourceUsage
+	order   []string
+	running map[string]bool
+	last    processStats
+
+	// readStats provides the current process counters
+	// (replaceable for testing)
+	readStats func() processStats
+}
+
+// sample distributes resources consumed since the last sample
+// among running jobs. It expects the lock to be held.
+func (jr *jobResources) sample() {
+	curr := jr.readStats()
+	if len(jr.running) > 0 {
+		n := float64(len(jr.running))
+		cpuTime := max(curr.cpuTime-jr.last.cpuTime, 0) / n
+		written := int64(float64(max(curr.bytesWritten-jr.last.bytesWritten, 0)) / n)
+		for jobID := range jr.running {
+			usage := jr.usage[jobID]
+			usage.CPUTime += cpuTime
+			usage.BytesWritten += written
+			usage.PeakRSS = max(usage.PeakRSS, curr.rss)
+			if len(jr.running) > 1 {
+				usage.Exclusive = false
+			}
+		}
+	}
+	jr.last = curr
+}
+
+// start begins recording resources of a job
+func (jr *jobResources) start(jobID string) {
+	if jr == nil {
+		return
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	jr.sample()
+	if _, ok := jr.usage[jobID]; !ok {
+		if len(jr.order) >= maxNumJobResources {
+			delete(jr.usage, jr.order[0])
+			jr.order = jr.order[1:]
+		}
+		jr.order = append(jr.order, jobID)
+	}
+	// a restarted job starts from scratch
+	jr.usage[jobID] = &ResourceUsage{Exclusive: len(jr.running) == 0, Running: true}
+	for running := range jr.running {
+		jr.usage[running].Exclusive = false
+	}
+	jr.running[jobID] = true
+}
+
+// finish stops recording resources of a job
+func (jr *jobResources) finish(jobID string) {
+	if jr == nil {
+		return
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if !jr.running[jobID] {
+		return
+	}
+	jr.sample()
+	delete(jr.running, jobID)
+	jr.usage[jobID].Running = false
+}
+
+// update records resources consumed since the last update
+func (jr *jobResources) update() {
+	if jr == nil {
+		return
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	jr.sample()
+}
+
+// get returns a copy of resource usage of a job
+// or nil if there is no record of the job
+func (jr *jobResources) get(jobID string) *ResourceUsage {
+	if jr == nil {
+		return nil
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	usage, ok := jr.usage[jobID]
+	if !ok {
+		return nil
+	}
+	ans := *usage
+	return &ans
+}
+
+func newJobResources() *jobResources {
+	ans := &jobResources{
+		usage:     make(map[string]*ResourceUsage),
+		running:   make(map[string]bool),
+		readStats: readProcessStats,
+	}
+	ans.last = ans.readStats()
+	return ans
+}
+
+// fullInfoWithResources adds resource usage and retry status (if any)
+// to a full information about a job (see GeneralJobInfo.FullInfo)
+func (a *Actions) fullInfoWithResources(job GeneralJobInfo) any {
+	info := job.FullInfo()
+	usage := a.jobResources.get(job.GetID())
+	retry := a.jobRetries.status(job.GetID())
+	if usage == nil && retry == nil {
+		return info
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return info
+	}
+	var ans map[string]any
+	if err := json.Unmarshal(data, &ans); err != nil {
+		// info is not a JSON object
+		return info
+	}
+	if usage != nil {
+		ans["resources"] = usage
+	}
+	if retry != nil {
+		ans["retrying"] = retry.Retrying
+		ans["retry"] = retry
+	}
+	return ans
+}
+
+// compactInfoWithResources adds resource usage (if any), the paused
+// flag and retry status to a compact information about a job
+func (a *Actions) compactInfoWithResources(job GeneralJobInfo) JobInfoCompact {
+	ans := job.CompactVersion()
+	ans.Resources = a.jobResources.get(job.GetID())
+	ans.Paused = a.isJobPaused(job.GetID())
+	ans.Retry = a.jobRetries.status(job.GetID())
+	ans.Retrying = ans.Retry != nil && ans.Retry.Retrying
+	return ans
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobResourcesSplitAmongRunningJobs(t *testing.T) {
+	stats := processStats{}
+	jr := newJobResources()
+	jr.readStats = func() processStats { return stats }
+	jr.last = stats
+
+	jr.start("job1")
+	stats = processStats{cpuTime: 2, rss: 1000, bytesWritten: 100}
+	jr.start("job2")
+	stats = processStats{cpuTime: 6, rss: 3000, bytesWritten: 300}
+	jr.finish("job1")
+	stats = processStats{cpuTime: 7, rss: 2000, bytesWritten: 400}
+	jr.finish("job2")
+
+	u1 := jr.get("job1")
+	assert.InDelta(t, 4.0, u1.CPUTime, 0.001)
+	assert.Equal(t, int64(3000), u1.PeakRSS)
+	assert.Equal(t, int64(200), u1.BytesWritten)
+	assert.False(t, u1.Exclusive)
+	assert.False(t, u1.Running)
+
+	u2 := jr.get("job2")
+	assert.InDelta(t, 3.0, u2.CPUTime, 0.001)
+	assert.Equal(t, int64(3000), u2.PeakRSS)
+	assert.Equal(t, int64(200), u2.BytesWritten)
+	assert.False(t, u2.Exclusive)
+}
+
+func TestJobResourcesExclusiveJob(t *testing.T) {
+	stats := processStats{cpuTime: 10, bytesWritten: 50}
+	jr := newJobResources()
+	jr.readStats = func() processStats { return stats }
+	jr.last = stats
+
+	jr.start("job1")
+	stats = processStats{cpuTime: 12, rss: 500, bytesWritten: 80}
+	jr.update()
+	assert.True(t, jr.get("job1").Running)
+	stats = processStats{cpuTime: 13, rss: 400, bytesWritten: 90}
+	jr.finish("job1")
+	u := jr.get("job1")
+	assert.InDelta(t, 3.0, u.CPUTime, 0.001)
+	assert.Equal(t, int64(500), u.PeakRSS)
+	assert.Equal(t, int64(40), u.BytesWritten)
+	assert.True(t, u.Exclusive)
+	assert.Nil(t, jr.get("job2"))
+}
+
+func TestNilJobResources(t *testing.T) {
+	var jr *jobResources
+	jr.start("job1")
+	jr.finish("job1")
+	assert.Nil(t, jr.get("job1"))
+}
@@ -0,0 +1,325 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	dfltRetryInitialDelaySecs = 30
+	dfltRetryMaxDelaySecs     = 1800
+	dfltRetryMultiplier       = 2
+	dfltRetryJitter           = 0.2
+)
+
+// dfltTransientErrors are patterns of error messages considered
+// transient in case a retry policy does not specify its own ones
+var dfltTransientErrors = []string{
+	`(?i)connection (refused|reset|lost)`,
+	`(?i)broken pipe`,
+	`(?i)bad connection`,
+	`(?i)invalid connection`,
+	`(?i)i/o timeout`,
+	`(?i)too many connections`,
+	`(?i)deadlock found`,
+	`(?i)lock wait timeout`,
+	`(?i)stale (nfs )?file handle`,
+	`(?i)resource temporarily unavailable`,
+}
+
+// ErrTransient can be wrapped by errors of jobs to mark them
+// as transient ones (i.e. a retry may succeed) regardless of
+// patterns configured in RetryPolicy.
+var ErrTransient = errors.New("transient error")
+
+// NewTransientError marks an error as a transient one
+func NewTransientError(err error) error {
+	return fmt.Errorf("%w: %w", ErrTransient, err)
+}
+
+// RetryPolicy configures automatic retries of failed jobs of a type.
+// A job is retried only in case it failed with a transient error.
+// A retried job keeps its ID and it is run again from the beginning.
+type RetryPolicy struct {
+
+	// MaxRetries is a max. number of retries of a job
+	MaxRetries int `json:"maxRetries"`
+
+	// InitialDelaySecs is a delay before the first retry (default 30).
+	InitialDelaySecs float64 `json:"initialDelaySecs"`
+
+	// MaxDelaySecs limits the delay between retries (default 1800)
+	MaxDelaySecs float64 `json:"maxDelaySecs"`
+
+	// Multiplier increases the delay of each subsequent retry (default 2)
+	Multiplier float64 `json:"multiplier"`
+
+	// Jitter is a max. relative random change of the delay
+	// (e.g. 0.2 means +-20%; default 0.2) so jobs failed at the same
+	// time are not retried at the same time.
+	Jitter float64 `json:"jitter"`
+
+	// TransientErrors are regular expressions matching messages of errors
+	// which should be retried. If empty, patterns of common database
+	// connection and network filesystem problems are used. Errors
+	// wrapping ErrTransient are always considered transient.
+	TransientErrors []string `json:"transientErrors"`
+
+	transientErrors []*regexp.Regexp
+}
+
+// Validate tests the policy and applies default values
+// of unspecified properties
+func (rp *RetryPolicy) Validate() error {
+	if rp.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries must not be negative")
+	}
+	if rp.InitialDelaySecs < 0 || rp.MaxDelaySecs < 0 {
+		return fmt.Errorf("retry delays must not be negative")
+	}
+	if rp.InitialDelaySecs == 0 {
+		rp.InitialDelaySecs = dfltRetryInitialDelaySecs
+	}
+	if rp.MaxDelaySecs == 0 {
+		rp.MaxDelaySecs = max(dfltRetryMaxDelaySecs, rp.InitialDelaySecs)
+	}
+	if rp.MaxDelaySecs < rp.InitialDelaySecs {
+		return fmt.Errorf("maxDelaySecs must not be lower than initialDelaySecs")
+	}
+	if rp.Multiplier == 0 {
+		rp.Multiplier = dfltRetryMultiplier
+	}
+	if rp.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	if rp.Jitter == 0 {
+		rp.Jitter = dfltRetryJitter
+	}
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	patterns := rp.TransientErrors
+	if len(patterns) == 0 {
+		patterns = dfltTransientErrors
+	}
+	rp.transientErrors = make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		var err error
+		rp.transientErrors[i], err = regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid transient error pattern %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// IsTransient tests whether a job error is worth retrying
+func (rp *RetryPolicy) IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTransient) {
+		return true
+	}
+	for _, p := range rp.transientErrors {
+		if p.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns a delay before a retry (numbered from 1)
+// including a random jitter
+func (rp *RetryPolicy) Delay(retry int) time.Duration {
+	secs := rp.InitialDelaySecs * math.Pow(rp.Multiplier, float64(retry-1))
+	secs = min(secs, rp.MaxDelaySecs)
+	secs *= 1 + rp.Jitter*(2*rand.Float64()-1)
+	return time.Duration(secs * float64(time.Second))
+}
+
+// RetryStatus describes automatic retries of a job
+type RetryStatus struct {
+
+	// Retrying is true while the job waits for its next run
+	Retrying bool `json:"retrying"`
+
+	// NumRetries is a number of retries performed or scheduled so far
+	NumRetries int `json:"numRetries"`
+
+	MaxRetries int `json:"maxRetries"`
+
+	// NextRun is a time of the scheduled retry (if Retrying)
+	NextRun *JSONTime `json:"nextRun,omitempty"`
+
+	// LastError is an error of the last failed run
+	LastError string `json:"lastError"`
+}
+
+// jobRetry is a state of retries of a job
+type jobRetry struct {
+	fn        *QueuedFunc
+	initState GeneralJobInfo
+	status    RetryStatus
+	timer     *time.Timer
+	cancelled bool
+}
+
+// jobRetries tracks jobs of types with a retry policy.
+// A nil value is valid and tracks nothing.
+type jobRetries struct {
+	mu   sync.Mutex
+	jobs map[string]*jobRetry
+}
+
+// track remembers how to run a job again
+func (jr *jobRetries) track(fn *QueuedFunc, initState GeneralJobInfo, policy *RetryPolicy) {
+	if jr == nil {
+		return
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if r, ok := jr.jobs[initState.GetID()]; ok {
+		r.status.Retrying = false
+		r.status.NextRun = nil
+		r.timer = nil
+		return
+	}
+	jr.jobs[initState.GetID()] = &jobRetry{
+		fn:        fn,
+		initState: initState,
+		status:    RetryStatus{MaxRetries: policy.MaxRetries},
+	}
+}
+
+// status returns a retry status of a job which has been
+// retried at least once (nil otherwise)
+func (jr *jobRetries) status(jobID string) *RetryStatus {
+	if jr == nil {
+		return nil
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	r, ok := jr.jobs[jobID]
+	if !ok || r.status.NumRetries == 0 {
+		return nil
+	}
+	ans := r.status
+	return &ans
+}
+
+func (jr *jobRetries) isRetrying(jobID string) bool {
+	st := jr.status(jobID)
+	return st != nil && st.Retrying
+}
+
+// remove forgets about a job (e.g. once it is removed from the job list)
+func (jr *jobRetries) remove(jobID string) {
+	if jr == nil {
+		return
+	}
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	delete(jr.jobs, jobID)
+}
+
+// retryPolicy returns a retry policy of a job type (nil if none)
+func (a *Actions) retryPolicy(jobType string) *RetryPolicy {
+	if a.conf == nil {
+		return nil
+	}
+	return a.conf.JobTypes[jobType].Retry
+}
+
+// scheduleRetry schedules a new run of a failed job in case the job's
+// type has a retry policy, the job's error is a transient one and
+// the max. number of retries has not been reached. Until the retry,
+// the job stays unfinished (but it does not occupy a running job slot).
+// The returned value is the job's state while waiting for the retry.
+func (a *Actions) scheduleRetry(job GeneralJobInfo) (GeneralJobInfo, bool) {
+	jobErr := job.GetError()
+	policy := a.retryPolicy(job.GetType())
+	if policy == nil || a.jobRetries == nil || !policy.IsTransient(jobErr) {
+		return nil, false
+	}
+	a.jobRetries.mu.Lock()
+	defer a.jobRetries.mu.Unlock()
+	r, ok := a.jobRetries.jobs[job.GetID()]
+	if !ok || r.cancelled || r.status.NumRetries >= policy.MaxRetries {
+		return nil, false
+	}
+	r.status.NumRetries++
+	r.status.Retrying = true
+	r.status.LastError = jobErr.Error()
+	delay := policy.Delay(r.status.NumRetries)
+	nextRun := JSONTime(time.Now().Add(delay))
+	r.status.NextRun = &nextRun
+	jobLog := a.JobLogger(job.GetID())
+	jobLog.Warn().
+		Err(jobErr).
+		Int("retry", r.status.NumRetries).
+		Str("delay", delay.Round(time.Second).String()).
+		Msg("job failed with a transient error, retry scheduled")
+	numRetries := r.status.NumRetries
+	r.timer = time.AfterFunc(delay, func() {
+		a.jobRetries.mu.Lock()
+		cancelled := r.cancelled
+		a.jobRetries.mu.Unlock()
+		if cancelled {
+			return
+		}
+		a.jobQueueLock.Lock()
+		a.jobQueue.Enqueue(r.fn, r.initState)
+		a.jobQueueLock.Unlock()
+		logger.Info().Str("jobId", job.GetID()).Int("retry", numRetries).Msg("re-enqueued job")
+	})
+	return r.initState.WithError(jobErr), true
+}
+
+// cancelRetry cancels a scheduled retry of a job. It returns
+// false if there is no scheduled retry.
+func (a *Actions) cancelRetry(jobID string) bool {
+	if a.jobRetries == nil {
+		return false
+	}
+	a.jobRetries.mu.Lock()
+	defer a.jobRetries.mu.Unlock()
+	r, ok := a.jobRetries.jobs[jobID]
+	if !ok || !r.status.Retrying {
+		return false
+	}
+	r.cancelled = true
+	r.status.Retrying = false
+	r.status.NextRun = nil
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return true
+}
+
+func newJobRetries() *jobRetries {
+	return &jobRetries{jobs: make(map[string]*jobRetry)}
+}
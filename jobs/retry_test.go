@@ -0,0 +1,141 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRetryActions(policy *RetryPolicy) *Actions {
+	a := newTestSchedulerActions(&Conf{
+		MaxNumConcurrentJobs: 2,
+		JobTypes:             map[string]JobTypeConf{"liveattrs": {Retry: policy}},
+	})
+	a.jobLogs = newJobLogs(10)
+	a.jobRetries = newJobRetries()
+	return a
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: 3}
+	assert.NoError(t, policy.Validate())
+	assert.Equal(t, float64(dfltRetryInitialDelaySecs), policy.InitialDelaySecs)
+	assert.Equal(t, float64(dfltRetryMaxDelaySecs), policy.MaxDelaySecs)
+	assert.Equal(t, float64(dfltRetryMultiplier), policy.Multiplier)
+	assert.Equal(t, dfltRetryJitter, policy.Jitter)
+
+	assert.Error(t, (&RetryPolicy{MaxRetries: -1}).Validate())
+	assert.Error(t, (&RetryPolicy{Jitter: 1.5}).Validate())
+	assert.Error(t, (&RetryPolicy{InitialDelaySecs: 10, MaxDelaySecs: 5}).Validate())
+	assert.Error(t, (&RetryPolicy{TransientErrors: []string{"("}}).Validate())
+}
+
+func TestRetryPolicyIsTransient(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: 1}
+	assert.NoError(t, policy.Validate())
+	assert.True(t, policy.IsTransient(errors.New("dial tcp 10.0.0.1:3306: connect: connection refused")))
+	assert.True(t, policy.IsTransient(errors.New("read /cnk/vert/syn2020: stale NFS file handle")))
+	assert.True(t, policy.IsTransient(NewTransientError(errors.New("whatever"))))
+	assert.False(t, policy.IsTransient(errors.New("invalid vertical file")))
+	assert.False(t, policy.IsTransient(nil))
+
+	policy = &RetryPolicy{MaxRetries: 1, TransientErrors: []string{"^busy"}}
+	assert.NoError(t, policy.Validate())
+	assert.True(t, policy.IsTransient(errors.New("busy server")))
+	assert.False(t, policy.IsTransient(errors.New("connection refused")))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{InitialDelaySecs: 10, MaxDelaySecs: 35, Jitter: 0.1}
+	assert.NoError(t, policy.Validate())
+	for retry, expected := range map[int]float64{1: 10, 2: 20, 3: 35, 4: 35} {
+		delay := policy.Delay(retry).Seconds()
+		assert.InDelta(t, expected, delay, expected*0.1+0.001, fmt.Sprintf("retry %d", retry))
+	}
+}
+
+func TestScheduleRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: 1, InitialDelaySecs: 0.01, Jitter: 0.01}
+	assert.NoError(t, policy.Validate())
+	a := newTestRetryActions(policy)
+	f := func(chan<- GeneralJobInfo) {}
+	a.EnqueueJob(&f, DummyJobInfo{ID: "1", Type: "liveattrs"})
+	a.scheduleJobs()
+
+	failed := DummyJobInfo{ID: "1", Type: "liveattrs", Finished: true, Error: errors.New("connection lost")}
+	retrying, ok := a.scheduleRetry(failed)
+	assert.True(t, ok)
+	assert.False(t, retrying.IsFinished())
+	a.jobList["1"] = retrying
+	info := a.compactInfoWithResources(retrying)
+	assert.True(t, info.Retrying)
+	assert.Equal(t, 1, info.Retry.NumRetries)
+	assert.Equal(t, "connection lost", info.Retry.LastError)
+	assert.Empty(t, a.runningJobsByType())
+
+	assert.Eventually(t, func() bool {
+		a.jobQueueLock.Lock()
+		defer a.jobQueueLock.Unlock()
+		return a.jobQueue.Size() == 1
+	}, time.Second, 5*time.Millisecond)
+	a.scheduleJobs()
+	assert.False(t, a.compactInfoWithResources(a.jobList["1"]).Retrying)
+
+	// max. number of retries reached
+	_, ok = a.scheduleRetry(failed)
+	assert.False(t, ok)
+}
+
+func TestScheduleRetryNonTransient(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: 1}
+	assert.NoError(t, policy.Validate())
+	a := newTestRetryActions(policy)
+	f := func(chan<- GeneralJobInfo) {}
+	a.EnqueueJob(&f, DummyJobInfo{ID: "1", Type: "liveattrs"})
+	a.EnqueueJob(&f, DummyJobInfo{ID: "2", Type: "ngrams"})
+	a.scheduleJobs()
+	_, ok := a.scheduleRetry(DummyJobInfo{ID: "1", Type: "liveattrs", Error: errors.New("invalid vertical")})
+	assert.False(t, ok)
+	_, ok = a.scheduleRetry(DummyJobInfo{ID: "2", Type: "ngrams", Error: errors.New("connection lost")})
+	assert.False(t, ok)
+	assert.Nil(t, a.compactInfoWithResources(a.jobList["1"]).Retry)
+}
+
+func TestCancelRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: 2, InitialDelaySecs: 0.05}
+	assert.NoError(t, policy.Validate())
+	a := newTestRetryActions(policy)
+	f := func(chan<- GeneralJobInfo) {}
+	a.EnqueueJob(&f, DummyJobInfo{ID: "1", Type: "liveattrs"})
+	a.scheduleJobs()
+	failed := DummyJobInfo{ID: "1", Type: "liveattrs", Error: errors.New("broken pipe")}
+	_, ok := a.scheduleRetry(failed)
+	assert.True(t, ok)
+	assert.True(t, a.cancelRetry("1"))
+	assert.False(t, a.cancelRetry("1"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, a.jobQueue.Size())
+	_, ok = a.scheduleRetry(failed)
+	assert.False(t, ok)
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// runningJobsByType returns numbers of unfinished jobs
+// of individual job types. Pipelines are not included as they
+// only wait for jobs of their steps. Jobs waiting for a retry
+// are not included either.
+func (a *Actions) runningJobsByType() map[string]int {
+	ans := make(map[string]int)
+	a.jobListLock.Lock()
+	for _, v := range a.jobList {
+		if !v.IsFinished() && v.GetType() != PipelineJobType && !a.jobRetries.isRetrying(v.GetID()) {
+			ans[v.GetType()]++
+		}
+	}
+	a.jobListLock.Unlock()
+	return ans
+}
+
+func (a *Actions) jobPriority(job GeneralJobInfo) int {
+	return a.conf.JobTypes[job.GetType()].Priority
+}
+
+// canRunJob tests whether a queued job can be started with respect
+// to its type's concurrency limit and its dependencies. Jobs which
+// cannot be run at all (e.g. due to a failed parent) are added
+// to `failed`.
+func (a *Actions) canRunJob(job GeneralJobInfo, running map[string]int, failed map[string]error) bool {
+	limit := a.conf.JobTypes[job.GetType()].MaxConcurrency
+	if limit > 0 && running[job.GetType()] >= limit {
+		return false
+	}
+	if _, ok := a.jobDeps[job.GetID()]; !ok {
+		return true
+	}
+	mustWait, err := a.jobDeps.MustWait(job.GetID())
+	if err != nil {
+		failed[job.GetID()] = fmt.Errorf("failed to obtain waiting status for job %s: %w", job.GetID(), err)
+		return false
+	}
+	if mustWait {
+		return false
+	}
+	hasFailedParent, err := a.jobDeps.HasFailedParent(job.GetID())
+	if err != nil {
+		failed[job.GetID()] = fmt.Errorf("failed to check parents of job %s: %w", job.GetID(), err)
+		return false
+
+	} else if hasFailedParent {
+		failed[job.GetID()] = fmt.Errorf("failed to run job %s due to failed parent(s)", job.GetID())
+		return false
+	}
+	return true
+}
+
+// scheduleJobs starts queued jobs as long as the global limit
+// of running jobs is not reached. Jobs are chosen by their type's
+// priority and only if their type's concurrency limit allows it.
+// The method expects jobQueueLock to be acquired.
+func (a *Actions) scheduleJobs() {
+	running := a.runningJobsByType()
+	numRunning := 0
+	for _, v := range running {
+		numRunning += v
+	}
+	for numRunning < a.conf.MaxNumConcurrentJobs {
+		failed := make(map[string]error)
+		nextJobID, ok := a.jobQueue.SelectNext(
+			func(job GeneralJobInfo) bool {
+				return a.canRunJob(job, running, failed)
+			},
+			a.jobPriority,
+		)
+		for jobID, err := range failed {
+			a.dequeueJobAsFailed(jobID, err)
+		}
+		if !ok {
+			return
+		}
+		job := a.dequeueAndRunJob(nextJobID)
+		if job == nil {
+			return
+		}
+		running[job.GetType()]++
+		numRunning++
+	}
+}
+
+// QueuedJob describes a job waiting to be started
+type QueuedJob struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	CorpusID  string   `json:"corpusId"`
+	Submitted JSONTime `json:"submitted"`
+	Priority  int      `json:"priority"`
+}
+
+// Queue lists jobs waiting to be started, in the order
+// they are going to be considered by the scheduler (i.e. by their
+// priority and the time of submission)
+func (a *Actions) Queue(ctx *gin.Context) {
+	a.jobQueueLock.Lock()
+	items := a.jobQueue.Items()
+	a.jobQueueLock.Unlock()
+	ans := make([]QueuedJob, len(items))
+	for i, item := range items {
+		ans[i] = QueuedJob{
+			ID:        item.GetID(),
+			Type:      item.GetType(),
+			CorpusID:  item.GetCorpus(),
+			Submitted: item.GetStartDT(),
+			Priority:  a.jobPriority(item),
+		}
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[i].Priority > ans[j].Priority
+	})
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
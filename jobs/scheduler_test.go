@@ -0,0 +1,74 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSchedulerActions(conf *Conf) *Actions {
+	return &Actions{
+		conf:         conf,
+		jobList:      make(map[string]GeneralJobInfo),
+		detachedJobs: make(map[string]GeneralJobInfo),
+		jobQueue:     &JobQueue{},
+		jobDeps:      make(JobsDeps),
+		tableUpdate:  make(chan TableUpdate, 100),
+		jobEvents:    newJobEventBroker(),
+	}
+}
+
+func TestScheduleJobsTypeLimit(t *testing.T) {
+	a := newTestSchedulerActions(&Conf{
+		MaxNumConcurrentJobs: 4,
+		JobTypes: map[string]JobTypeConf{
+			"liveattrs": {MaxConcurrency: 1},
+			"ngrams":    {Priority: 1},
+		},
+	})
+	f := func(chan<- GeneralJobInfo) {}
+	a.EnqueueJob(&f, &DummyJobInfo{ID: "1", Type: "liveattrs"})
+	a.EnqueueJob(&f, &DummyJobInfo{ID: "2", Type: "liveattrs"})
+	a.EnqueueJob(&f, &DummyJobInfo{ID: "3", Type: "ngrams"})
+	a.scheduleJobs()
+	assert.Contains(t, a.jobList, "1")
+	assert.Contains(t, a.jobList, "3")
+	assert.NotContains(t, a.jobList, "2")
+	assert.Equal(t, 1, a.jobQueue.Size())
+	assert.Equal(t, map[string]int{"liveattrs": 1, "ngrams": 1}, a.runningJobsByType())
+}
+
+func TestScheduleJobsGlobalLimitAndPriority(t *testing.T) {
+	a := newTestSchedulerActions(&Conf{
+		MaxNumConcurrentJobs: 1,
+		JobTypes: map[string]JobTypeConf{
+			"ngrams": {Priority: 1},
+		},
+	})
+	f := func(chan<- GeneralJobInfo) {}
+	a.EnqueueJob(&f, &DummyJobInfo{ID: "1", Type: "liveattrs"})
+	a.EnqueueJob(&f, &DummyJobInfo{ID: "2", Type: "ngrams"})
+	a.scheduleJobs()
+	assert.Contains(t, a.jobList, "2")
+	assert.NotContains(t, a.jobList, "1")
+	a.scheduleJobs()
+	assert.NotContains(t, a.jobList, "1")
+}
@@ -0,0 +1,458 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	schedulesCheckInterval = 30 * time.Second
+
+	// failedResponseExcerptSize is a max. size of a stored
+	// response of a failed internal request
+	failedResponseExcerptSize = 500
+)
+
+// ScheduledRequest is an HTTP request submitted by a schedule
+// (e.g. `POST /liveAttributes/syn2020/data?append=1`)
+type ScheduledRequest struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Query       string `json:"query"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Validate tests whether the request can be scheduled
+func (sr ScheduledRequest) Validate() error {
+	switch sr.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("unsupported method %s", sr.Method)
+	}
+	if !strings.HasPrefix(sr.Path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	if strings.HasPrefix(sr.Path, "/schedules") {
+		return fmt.Errorf("schedules cannot be managed by schedules")
+	}
+	return nil
+}
+
+func (sr ScheduledRequest) toJobRequest() *JobRequest {
+	return &JobRequest{
+		Method:      sr.Method,
+		Path:        sr.Path,
+		Query:       sr.Query,
+		ContentType: sr.ContentType,
+		Body:        sr.Body,
+	}
+}
+
+// Schedule specifies a request submitted repeatedly at times
+// specified by a cron-like spec (see CronSpec). Typically, the request
+// starts a job (e.g. a nightly liveattrs refresh or an index update).
+type Schedule struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Spec       string           `json:"spec"`
+	Request    ScheduledRequest `json:"request"`
+	Enabled    bool             `json:"enabled"`
+	Created    JSONTime         `json:"created"`
+	LastRun    *JSONTime        `json:"lastRun"`
+	LastStatus int              `json:"lastStatus,omitempty"`
+	LastJobID  string           `json:"lastJobId,omitempty"`
+	LastError  string           `json:"lastError,omitempty"`
+	NextRun    *JSONTime        `json:"nextRun"`
+	parsedSpec *CronSpec
+}
+
+// updateNextRun calculates the next run time based on `now`.
+// For disabled schedules, there is no next run.
+func (s *Schedule) updateNextRun(now time.Time) {
+	s.NextRun = nil
+	if !s.Enabled || s.parsedSpec == nil {
+		return
+	}
+	next := s.parsedSpec.Next(now)
+	if !next.IsZero() {
+		tmp := JSONTime(next)
+		s.NextRun = &tmp
+	}
+}
+
+// isDue tests whether the schedule should run at `now`
+func (s *Schedule) isDue(now time.Time) bool {
+	return s.Enabled && s.NextRun != nil && !time.Time(*s.NextRun).After(now)
+}
+
+// SchedulePatch specifies changes of an existing schedule.
+// Nil values are not changed.
+type SchedulePatch struct {
+	Name    *string           `json:"name"`
+	Spec    *string           `json:"spec"`
+	Request *ScheduledRequest `json:"request"`
+	Enabled *bool             `json:"enabled"`
+}
+
+// Apply applies the patch to a copy of a schedule
+func (sp SchedulePatch) Apply(s Schedule) (*Schedule, error) {
+	if sp.Name != nil {
+		s.Name = *sp.Name
+	}
+	if sp.Spec != nil {
+		spec, err := ParseCronSpec(*sp.Spec)
+		if err != nil {
+			return nil, err
+		}
+		s.Spec = *sp.Spec
+		s.parsedSpec = spec
+	}
+	if sp.Request != nil {
+		if err := sp.Request.Validate(); err != nil {
+			return nil, err
+		}
+		s.Request = *sp.Request
+	}
+	if sp.Enabled != nil {
+		s.Enabled = *sp.Enabled
+	}
+	return &s, nil
+}
+
+// newSchedule creates a schedule out of user input
+func newSchedule(name, spec string, req ScheduledRequest) (*Schedule, error) {
+	parsedSpec, err := ParseCronSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	ans := &Schedule{
+		ID:         id.String(),
+		Name:       name,
+		Spec:       spec,
+		Request:    req,
+		Enabled:    true,
+		Created:    CurrentDatetime(),
+		parsedSpec: parsedSpec,
+	}
+	ans.updateNextRun(time.Now())
+	return ans, nil
+}
+
+// loadSchedules loads schedules stored in Conf.SchedulesPath
+// (if configured and exists)
+func (a *Actions) loadSchedules() error {
+	if a.conf.SchedulesPath == "" {
+		return nil
+	}
+	isFile, err := fs.IsFile(a.conf.SchedulesPath)
+	if err != nil || !isFile {
+		return err
+	}
+	rawData, err := os.ReadFile(a.conf.SchedulesPath)
+	if err != nil {
+		return err
+	}
+	var items []*Schedule
+	if err := json.Unmarshal(rawData, &items); err != nil {
+		return fmt.Errorf("failed to load schedules: %w", err)
+	}
+	now := time.Now()
+	for _, item := range items {
+		item.parsedSpec, err = ParseCronSpec(item.Spec)
+		if err != nil {
+			logger.Error().Err(err).Str("scheduleId", item.ID).Msg("invalid stored schedule, disabling")
+			item.Enabled = false
+		}
+		item.updateNextRun(now)
+		a.schedules[item.ID] = item
+	}
+	return nil
+}
+
+// listSchedules returns schedules sorted by their creation.
+// The method expects schedulesLock to be acquired.
+func (a *Actions) listSchedules() []*Schedule {
+	ans := make([]*Schedule, 0, len(a.schedules))
+	for _, v := range a.schedules {
+		ans = append(ans, v)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].Created != ans[j].Created {
+			return ans[i].Created.Before(ans[j].Created)
+		}
+		return ans[i].ID < ans[j].ID
+	})
+	return ans
+}
+
+// saveSchedules stores all the schedules to Conf.SchedulesPath
+// (if configured). The method expects schedulesLock to be acquired.
+func (a *Actions) saveSchedules() error {
+	if a.conf.SchedulesPath == "" {
+		return nil
+	}
+	rawData, err := json.MarshalIndent(a.listSchedules(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := a.conf.SchedulesPath + ".tmp"
+	if err := os.WriteFile(tmpPath, rawData, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, a.conf.SchedulesPath)
+}
+
+// submitInternalRequest submits a request via the request handler
+// (i.e. as if it was sent by a client). It returns an HTTP status
+// of the response and an ID of a created job (if any). For responses
+// with status >= 300, an error containing an excerpt of the response
+// is returned.
+func (a *Actions) submitInternalRequest(sr ScheduledRequest) (int, string, error) {
+	req, err := sr.toJobRequest().ToHTTPRequest()
+	if err != nil {
+		return 0, "", err
+	}
+	if a.requestHandler == nil {
+		return 0, "", fmt.Errorf("no request handler set")
+	}
+	rec := httptest.NewRecorder()
+	a.requestHandler.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code >= 300 {
+		excerpt := []rune(string(body))
+		if len(excerpt) > failedResponseExcerptSize {
+			excerpt = excerpt[:failedResponseExcerptSize]
+		}
+		return rec.Code, "", fmt.Errorf("%s", string(excerpt))
+	}
+	var jobInfo struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(body, &jobInfo) == nil {
+		return rec.Code, jobInfo.ID, nil
+	}
+	return rec.Code, "", nil
+}
+
+// submitScheduledRequest submits the request of a schedule
+// and records the result
+func (a *Actions) submitScheduledRequest(sch *Schedule) {
+	now := CurrentDatetime()
+	sch.LastRun = &now
+	sch.LastJobID = ""
+	sch.LastError = ""
+	logger.Info().
+		Str("scheduleId", sch.ID).
+		Str("method", sch.Request.Method).
+		Str("path", sch.Request.Path).
+		Str("query", sch.Request.Query).
+		Msg("submitting scheduled request")
+	status, jobID, err := a.submitInternalRequest(sch.Request)
+	sch.LastStatus = status
+	sch.LastJobID = jobID
+	if err != nil {
+		sch.LastError = err.Error()
+		logger.Error().
+			Str("scheduleId", sch.ID).
+			Int("status", status).
+			Msg("scheduled request failed")
+	}
+}
+
+// runDueSchedules submits requests of all the schedules due at `now`
+func (a *Actions) runDueSchedules(now time.Time) {
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	var numRun int
+	for _, sch := range a.listSchedules() {
+		if !sch.isDue(now) {
+			continue
+		}
+		a.submitScheduledRequest(sch)
+		sch.updateNextRun(now)
+		numRun++
+	}
+	if numRun > 0 {
+		if err := a.saveSchedules(); err != nil {
+			logger.Error().Err(err).Msg("failed to save schedules")
+		}
+	}
+}
+
+// runScheduler regularly submits requests of due schedules
+// until an exit event is received
+func (a *Actions) runScheduler(exitEvent <-chan os.Signal) {
+	ticker := time.NewTicker(schedulesCheckInterval)
+	for {
+		select {
+		case <-ticker.C:
+			a.runDueSchedules(time.Now())
+		case <-exitEvent:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+type scheduleArgs struct {
+	Name    string           `json:"name"`
+	Spec    string           `json:"spec"`
+	Request ScheduledRequest `json:"request"`
+}
+
+// ListSchedules lists all the schedules
+func (a *Actions) ListSchedules(ctx *gin.Context) {
+	a.schedulesLock.Lock()
+	ans := a.listSchedules()
+	a.schedulesLock.Unlock()
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// GetSchedule returns a single schedule
+func (a *Actions) GetSchedule(ctx *gin.Context) {
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	sch, ok := a.schedules[ctx.Param("scheduleId")]
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("schedule not found"), http.StatusNotFound)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, sch)
+}
+
+// CreateSchedule creates a new (enabled) schedule
+func (a *Actions) CreateSchedule(ctx *gin.Context) {
+	var args scheduleArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create schedule: %w", err), http.StatusBadRequest)
+		return
+	}
+	sch, err := newSchedule(args.Name, args.Spec, args.Request)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create schedule: %w", err), http.StatusBadRequest)
+		return
+	}
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	a.schedules[sch.ID] = sch
+	if err := a.saveSchedules(); err != nil {
+		delete(a.schedules, sch.ID)
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to create schedule: %w", err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, sch)
+}
+
+// PatchSchedule changes selected properties of a schedule
+// (e.g. enables/disables it)
+func (a *Actions) PatchSchedule(ctx *gin.Context) {
+	var patch SchedulePatch
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&patch); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to update schedule: %w", err), http.StatusBadRequest)
+		return
+	}
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	curr, ok := a.schedules[ctx.Param("scheduleId")]
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("schedule not found"), http.StatusNotFound)
+		return
+	}
+	sch, err := patch.Apply(*curr)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to update schedule: %w", err), http.StatusBadRequest)
+		return
+	}
+	sch.updateNextRun(time.Now())
+	a.schedules[sch.ID] = sch
+	if err := a.saveSchedules(); err != nil {
+		a.schedules[sch.ID] = curr
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to update schedule: %w", err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, sch)
+}
+
+// DeleteSchedule removes a schedule
+func (a *Actions) DeleteSchedule(ctx *gin.Context) {
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	sch, ok := a.schedules[ctx.Param("scheduleId")]
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("schedule not found"), http.StatusNotFound)
+		return
+	}
+	delete(a.schedules, sch.ID)
+	if err := a.saveSchedules(); err != nil {
+		a.schedules[sch.ID] = sch
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to delete schedule: %w", err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, sch)
+}
+
+// RunSchedule submits the request of a schedule immediately
+// (regardless of its spec and whether it is enabled)
+func (a *Actions) RunSchedule(ctx *gin.Context) {
+	a.schedulesLock.Lock()
+	defer a.schedulesLock.Unlock()
+	sch, ok := a.schedules[ctx.Param("scheduleId")]
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("schedule not found"), http.StatusNotFound)
+		return
+	}
+	a.submitScheduledRequest(sch)
+	if err := a.saveSchedules(); err != nil {
+		logger.Error().Err(err).Msg("failed to save schedules")
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, sch)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledRequestValidate(t *testing.T) {
+	assert.NoError(t, ScheduledRequest{Method: "POST", Path: "/liveAttributes/syn2020/data"}.Validate())
+	assert.Error(t, ScheduledRequest{Method: "FOO", Path: "/liveAttributes/syn2020/data"}.Validate())
+	assert.Error(t, ScheduledRequest{Method: "POST", Path: "liveAttributes"}.Validate())
+	assert.Error(t, ScheduledRequest{Method: "POST", Path: "/schedules"}.Validate())
+}
+
+func TestSchedulePatchApply(t *testing.T) {
+	sch, err := newSchedule("nightly", "@daily", ScheduledRequest{Method: "POST", Path: "/x"})
+	assert.NoError(t, err)
+	enabled := false
+	spec := "0 3 * * *"
+	patched, err := SchedulePatch{Enabled: &enabled, Spec: &spec}.Apply(*sch)
+	assert.NoError(t, err)
+	assert.False(t, patched.Enabled)
+	assert.Equal(t, "0 3 * * *", patched.Spec)
+	assert.True(t, sch.Enabled)
+	invalid := "0 25 * * *"
+	_, err = SchedulePatch{Spec: &invalid}.Apply(*sch)
+	assert.Error(t, err)
+}
+
+func TestRunDueSchedules(t *testing.T) {
+	var numCalls int
+	a := &Actions{
+		conf:      &Conf{SchedulesPath: filepath.Join(t.TempDir(), "schedules.json")},
+		schedules: make(map[string]*Schedule),
+		requestHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			numCalls++
+			assert.Equal(t, "append=1", r.URL.RawQuery)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "job-1"}`))
+		}),
+	}
+	sch, err := newSchedule(
+		"nightly", "@daily",
+		ScheduledRequest{Method: "POST", Path: "/liveAttributes/syn2020/data", Query: "append=1"},
+	)
+	assert.NoError(t, err)
+	a.schedules[sch.ID] = sch
+	a.runDueSchedules(time.Now())
+	assert.Equal(t, 0, numCalls)
+
+	due := time.Time(*sch.NextRun)
+	a.runDueSchedules(due)
+	assert.Equal(t, 1, numCalls)
+	assert.Equal(t, "job-1", sch.LastJobID)
+	assert.Equal(t, http.StatusCreated, sch.LastStatus)
+	assert.Equal(t, due.AddDate(0, 0, 1), time.Time(*sch.NextRun))
+
+	// stored schedules are loaded again
+	a2 := &Actions{conf: a.conf, schedules: make(map[string]*Schedule)}
+	assert.NoError(t, a2.loadSchedules())
+	assert.Contains(t, a2.schedules, sch.ID)
+	assert.Equal(t, "job-1", a2.schedules[sch.ID].LastJobID)
+	assert.NotNil(t, a2.schedules[sch.ID].NextRun)
+}
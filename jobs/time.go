@@ -34,6 +34,20 @@ func (t JSONTime) MarshalJSON() ([]byte, error) {
 	return []byte("\"" + time.Time(t).Format(time.RFC3339) + "\""), nil
 }
 
+func (t *JSONTime) UnmarshalJSON(data []byte) error {
+	var v *time.Time
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*t = JSONTime(time.Time{})
+
+	} else {
+		*t = JSONTime(*v)
+	}
+	return nil
+}
+
 func (t JSONTime) Before(t2 JSONTime) bool {
 	return time.Time(t).Before(time.Time(t2))
 }
@@ -55,8 +69,12 @@ func (t JSONTime) GobEncode() ([]byte, error) {
 }
 
 func (t *JSONTime) GobDecode(data []byte) error {
-	v := time.Time(*t)
-	return v.UnmarshalBinary(data)
+	var v time.Time
+	if err := v.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	*t = JSONTime(v)
+	return nil
 }
 
 func CurrentDatetime() JSONTime {
@@ -0,0 +1,128 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// JobListExport is a JSON export of the job history which can be
+// imported to another MASM instance (see ExportJobs, ImportJobs)
+type JobListExport struct {
+	Version  int         `json:"version"`
+	Exported JSONTime    `json:"exported"`
+	Jobs     []JobRecord `json:"jobs"`
+}
+
+// JobListImportResult summarizes an import of a job history
+type JobListImportResult struct {
+	NumImported int `json:"numImported"`
+
+	// SkippedExisting lists jobs already present in the job list
+	SkippedExisting []string `json:"skippedExisting"`
+
+	// SkippedUnfinished lists jobs which had not finished
+	// at the time of the export
+	SkippedUnfinished []string `json:"skippedUnfinished"`
+}
+
+func (a *Actions) createJobListExport() JobListExport {
+	a.jobListLock.Lock()
+	jobList := a.createJobList(false)
+	a.jobListLock.Unlock()
+	sort.Sort(jobList)
+	ans := JobListExport{
+		Version:  JobListFormatVersion,
+		Exported: CurrentDatetime(),
+		Jobs:     make([]JobRecord, 0, len(jobList)),
+	}
+	for _, job := range jobList {
+		ans.Jobs = append(ans.Jobs, newJobRecord(job))
+	}
+	return ans
+}
+
+// importJobList adds finished jobs of an export to the job list.
+// Jobs with IDs already present in the list are replaced only
+// with `overwrite`.
+func (a *Actions) importJobList(data JobListExport, overwrite bool) (JobListImportResult, error) {
+	ans := JobListImportResult{
+		SkippedExisting:   []string{},
+		SkippedUnfinished: []string{},
+	}
+	if data.Version > JobListFormatVersion {
+		return ans, fmt.Errorf(
+			"job list format version %d is newer than the supported %d",
+			data.Version, JobListFormatVersion)
+	}
+	a.jobListLock.Lock()
+	defer a.jobListLock.Unlock()
+	for _, rec := range data.Jobs {
+		if !rec.Finished {
+			ans.SkippedUnfinished = append(ans.SkippedUnfinished, rec.ID)
+			continue
+		}
+		if _, ok := a.jobList[rec.ID]; ok && !overwrite {
+			ans.SkippedExisting = append(ans.SkippedExisting, rec.ID)
+			continue
+		}
+		a.jobList[rec.ID] = restoreJob(rec, data.Version)
+		ans.NumImported++
+	}
+	return ans, nil
+}
+
+// ExportJobs exports the job history (including the finished jobs)
+// as JSON. Jobs keep their IDs and timestamps and besides a JSON
+// version of the job information, they contain also the gob-encoded
+// jobs so they can be fully restored by ImportJobs.
+func (a *Actions) ExportJobs(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.createJobListExport())
+}
+
+// ImportJobs imports finished jobs exported by ExportJobs (typically
+// from another MASM instance). Unfinished jobs are skipped as they
+// cannot be restarted. Jobs already present in the job list are
+// skipped unless `overwrite=1` is set.
+func (a *Actions) ImportJobs(ctx *gin.Context) {
+	var data JobListExport
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&data); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to import jobs: %w", err), http.StatusBadRequest)
+		return
+	}
+	ans, err := a.importJobList(data, ctx.Request.URL.Query().Get("overwrite") == "1")
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to import jobs: %w", err), http.StatusBadRequest)
+		return
+	}
+	logger.Info().
+		Int("numImported", ans.NumImported).
+		Int("skippedExisting", len(ans.SkippedExisting)).
+		Int("skippedUnfinished", len(ans.SkippedUnfinished)).
+		Msg("imported jobs")
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
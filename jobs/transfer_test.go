@@ -0,0 +1,63 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportJobs(t *testing.T) {
+	start := JSONTime(time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC))
+	src := newTestingReplicaActions()
+	src.jobList["job1"] = DummyJobInfo{ID: "job1", CorpusID: "syn2020", Start: start, Update: start, Finished: true}
+	src.jobList["job2"] = DummyJobInfo{ID: "job2", CorpusID: "syn2020", Start: start}
+	src.jobList["job3"] = DummyJobInfo{
+		ID: "job3", CorpusID: "susanne", Start: start, Finished: true, Error: errors.New("failed")}
+	data, err := json.Marshal(src.createJobListExport())
+	assert.NoError(t, err)
+
+	var export JobListExport
+	assert.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, JobListFormatVersion, export.Version)
+	assert.Len(t, export.Jobs, 3)
+
+	dst := newTestingReplicaActions()
+	dst.jobList["job3"] = DummyJobInfo{ID: "job3", Finished: true}
+	ans, err := dst.importJobList(export, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ans.NumImported)
+	assert.Equal(t, []string{"job3"}, ans.SkippedExisting)
+	assert.Equal(t, []string{"job2"}, ans.SkippedUnfinished)
+	assert.Equal(t, src.jobList["job1"], dst.jobList["job1"])
+
+	ans, err = dst.importJobList(export, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ans.NumImported)
+	assert.Equal(t, "susanne", dst.jobList["job3"].GetCorpus())
+	assert.EqualError(t, dst.jobList["job3"].GetError(), "failed")
+
+	export.Version = JobListFormatVersion + 1
+	_, err = dst.importJobList(export, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"masm/v3/general/collections"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var defaultWebhookJobTypes = []string{"liveattrs", "liveattrs-idx-update", "ngram-generating"}
+
+// WebhookConf configures an HTTP call performed once
+// a job finishes
+type WebhookConf struct {
+	URL string `json:"url"`
+
+	// Method is an HTTP method to be used (POST by default)
+	Method string `json:"method"`
+
+	// AuthHeader is a value of the Authorization header (if any)
+	AuthHeader string `json:"authHeader"`
+
+	// PayloadTemplate is a Go text/template applied to WebhookPayload.
+	// The `json` function can be used to escape values. If empty,
+	// the payload is sent as JSON.
+	PayloadTemplate string `json:"payloadTemplate"`
+
+	// Corpora limits the webhook to jobs of specific corpora.
+	// If empty, jobs of all corpora are reported.
+	Corpora []string `json:"corpora"`
+
+	// JobTypes limits the webhook to specific job types. If empty,
+	// liveattrs, n-gram and index update jobs are reported.
+	JobTypes []string `json:"jobTypes"`
+}
+
+func (conf WebhookConf) method() string {
+	if conf.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(conf.Method)
+}
+
+func (conf WebhookConf) contentType() string {
+	if conf.PayloadTemplate == "" {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Validate tests whether the webhook is properly configured
+func (conf WebhookConf) Validate() error {
+	if conf.URL == "" {
+		return fmt.Errorf("missing webhook url")
+	}
+	if conf.PayloadTemplate != "" {
+		if _, err := conf.parseTemplate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (conf WebhookConf) parseTemplate() (*template.Template, error) {
+	tpl, err := template.New("payload").
+		Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				ans, err := json.Marshal(v)
+				return string(ans), err
+			},
+		}).
+		Parse(conf.PayloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook payload template: %w", err)
+	}
+	return tpl, nil
+}
+
+// Matches tests whether the webhook should be called for a job
+func (conf WebhookConf) Matches(job GeneralJobInfo) bool {
+	jobTypes := conf.JobTypes
+	if len(jobTypes) == 0 {
+		jobTypes = defaultWebhookJobTypes
+	}
+	if !collections.SliceContains(jobTypes, job.GetType()) {
+		return false
+	}
+	return len(conf.Corpora) == 0 || collections.SliceContains(conf.Corpora, job.GetCorpus())
+}
+
+// WebhookPayload is a final job status sent to webhooks
+type WebhookPayload struct {
+	JobID    string   `json:"jobId"`
+	JobType  string   `json:"jobType"`
+	CorpusID string   `json:"corpusId"`
+	OK       bool     `json:"ok"`
+	Error    string   `json:"error,omitempty"`
+	Start    JSONTime `json:"start"`
+	Finished JSONTime `json:"finished"`
+}
+
+func newWebhookPayload(job GeneralJobInfo) WebhookPayload {
+	return WebhookPayload{
+		JobID:    job.GetID(),
+		JobType:  job.GetType(),
+		CorpusID: job.GetCorpus(),
+		OK:       job.GetError() == nil,
+		Error:    ErrorToString(job.GetError()),
+		Start:    job.GetStartDT(),
+		Finished: job.CompactVersion().Update,
+	}
+}
+
+// Payload creates a request body for a webhook call
+func (conf WebhookConf) Payload(data WebhookPayload) ([]byte, error) {
+	if conf.PayloadTemplate == "" {
+		return json.Marshal(data)
+	}
+	tpl, err := conf.parseTemplate()
+	if err != nil {
+		return nil, err
+	}
+	var buff bytes.Buffer
+	if err := tpl.Execute(&buff, data); err != nil {
+		return nil, fmt.Errorf("failed to create webhook payload: %w", err)
+	}
+	return buff.Bytes(), nil
+}
+
+// Call sends a final status of a job to the webhook
+func (conf WebhookConf) Call(job GeneralJobInfo) error {
+	payload, err := conf.Payload(newWebhookPayload(job))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(conf.method(), conf.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook %s: %w", conf.URL, err)
+	}
+	req.Header.Set("Content-Type", conf.contentType())
+	if conf.AuthHeader != "" {
+		req.Header.Set("Authorization", conf.AuthHeader)
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook %s: %w", conf.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf(
+			"failed to call webhook %s - unexpected status code %d", conf.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// callWebhooks calls all the configured webhooks matching a finished job
+func (a *Actions) callWebhooks(job GeneralJobInfo) {
+	for _, hook := range a.conf.Webhooks {
+		if !hook.Matches(job) {
+			continue
+		}
+		if err := hook.Call(job); err != nil {
+			logger.Error().Err(err).Str("jobId", job.GetID()).Msg("failed to call job webhook")
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookMatches(t *testing.T) {
+	job := DummyJobInfo{ID: "1", Type: "liveattrs", CorpusID: "syn2020"}
+	assert.True(t, WebhookConf{}.Matches(job))
+	assert.True(t, WebhookConf{Corpora: []string{"syn2020"}}.Matches(job))
+	assert.False(t, WebhookConf{Corpora: []string{"susanne"}}.Matches(job))
+	assert.False(t, WebhookConf{}.Matches(DummyJobInfo{Type: "dummy-job"}))
+	assert.True(t, WebhookConf{JobTypes: []string{"dummy-job"}}.Matches(DummyJobInfo{Type: "dummy-job"}))
+}
+
+func TestWebhookPayloadTemplate(t *testing.T) {
+	conf := WebhookConf{
+		URL:             "http://localhost",
+		PayloadTemplate: `{"text": {{ json (printf "%s finished, ok: %t" .CorpusID .OK) }}}`,
+	}
+	assert.NoError(t, conf.Validate())
+	payload, err := conf.Payload(WebhookPayload{CorpusID: "syn2020", OK: true})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text": "syn2020 finished, ok: true"}`, string(payload))
+}
+
+func TestWebhookInvalidTemplate(t *testing.T) {
+	conf := WebhookConf{URL: "http://localhost", PayloadTemplate: "{{ .Foo"}
+	assert.Error(t, conf.Validate())
+	assert.Error(t, WebhookConf{}.Validate())
+}
+
+func TestWebhookCall(t *testing.T) {
+	var body, auth, method string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		auth = r.Header.Get("Authorization")
+		method = r.Method
+	}))
+	defer srv.Close()
+	conf := WebhookConf{URL: srv.URL, Method: "put", AuthHeader: "Bearer xyz"}
+	job := DummyJobInfo{
+		ID: "1", Type: "liveattrs", CorpusID: "syn2020", Finished: true, Error: errors.New("failed")}
+	assert.NoError(t, conf.Call(job))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "Bearer xyz", auth)
+	assert.JSONEq(
+		t,
+		`{"jobId": "1", "jobType": "liveattrs", "corpusId": "syn2020", "ok": false,
+		"error": "failed", "start": null, "finished": null}`,
+		body,
+	)
+}
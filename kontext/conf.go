@@ -20,4 +20,33 @@ package kontext
 
 type Conf struct {
 	SoftResetURL []string `json:"softResetUrl"`
+
+	// CorpusCacheInvalidationURL specifies KonText endpoints able to
+	// invalidate cached information of a single corpus. In case the value
+	// is not set, the global soft reset (SoftResetURL) is used instead.
+	CorpusCacheInvalidationURL []string `json:"corpusCacheInvalidationUrl"`
+
+	// SubcorpusCreateURL specifies a KonText endpoint creating
+	// subcorpora (typically `[KonText root]/subcorpus/create`)
+	SubcorpusCreateURL string `json:"subcorpusCreateUrl"`
+
+	// AuthHeaders specifies headers of user requests passed
+	// to KonText to act on behalf of the user. By default,
+	// `Authorization` and `Cookie` are passed.
+	AuthHeaders []string `json:"authHeaders"`
+}
+
+// GetAuthHeaders returns names of headers passed to KonText
+// (with the default applied)
+func (conf *Conf) GetAuthHeaders() []string {
+	if conf == nil || len(conf.AuthHeaders) == 0 {
+		return []string{"Authorization", "Cookie"}
+	}
+	return conf.AuthHeaders
+}
+
+// HasNotificationTargets tells whether there is any KonText endpoint
+// MASM can notify about changed corpus data
+func (conf *Conf) HasNotificationTargets() bool {
+	return conf != nil && (len(conf.SoftResetURL) > 0 || len(conf.CorpusCacheInvalidationURL) > 0)
 }
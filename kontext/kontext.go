@@ -19,6 +19,8 @@
 package kontext
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -41,3 +43,34 @@ func SendSoftReset(conf *Conf) error {
 	}
 	return nil
 }
+
+type corpusInvalidationPayload struct {
+	CorpusID string `json:"corpusId"`
+}
+
+// SendCorpusCacheInvalidation asks KonText instances to invalidate
+// cached data of a single corpus. In case no targeted invalidation
+// URLs are configured, the function falls back to the global soft reset.
+func SendCorpusCacheInvalidation(conf *Conf, corpusID string) error {
+	if conf == nil || len(conf.CorpusCacheInvalidationURL) == 0 {
+		return SendSoftReset(conf)
+	}
+	payload, err := json.Marshal(corpusInvalidationPayload{CorpusID: corpusID})
+	if err != nil {
+		return fmt.Errorf("failed to send corpus cache invalidation: %w", err)
+	}
+	for _, instance := range conf.CorpusCacheInvalidationURL {
+		resp, err := http.Post(instance, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf(
+				"kontext instance `%s` cache invalidation for %s failed - unexpected status code %d",
+				instance, corpusID, resp.StatusCode,
+			)
+		}
+	}
+	return nil
+}
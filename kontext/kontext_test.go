@@ -0,0 +1,68 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package kontext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpusCacheInvalidationPayload(t *testing.T) {
+	var received corpusInvalidationPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer srv.Close()
+	err := SendCorpusCacheInvalidation(
+		&Conf{CorpusCacheInvalidationURL: []string{srv.URL}}, "syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2020", received.CorpusID)
+}
+
+func TestCorpusCacheInvalidationFallback(t *testing.T) {
+	var numResets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numResets++
+	}))
+	defer srv.Close()
+	err := SendCorpusCacheInvalidation(&Conf{SoftResetURL: []string{srv.URL}}, "syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, numResets)
+}
+
+func TestCorpusCacheInvalidationErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	err := SendCorpusCacheInvalidation(
+		&Conf{CorpusCacheInvalidationURL: []string{srv.URL}}, "syn2020")
+	assert.Error(t, err)
+}
+
+func TestHasNotificationTargets(t *testing.T) {
+	var conf *Conf
+	assert.False(t, conf.HasNotificationTargets())
+	assert.False(t, (&Conf{}).HasNotificationTargets())
+	assert.True(t, (&Conf{SoftResetURL: []string{"http://localhost"}}).HasNotificationTargets())
+}
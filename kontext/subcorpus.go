@@ -0,0 +1,138 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package kontext
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	subcorpusCreateTimeout = 60 * time.Second
+)
+
+var (
+	ErrorSubcorpusNotConfigured = errors.New("KonText subcorpus creation URL not configured")
+)
+
+// RemoteError describes an unsuccessful response of KonText
+type RemoteError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *RemoteError) Error() string {
+	return fmt.Sprintf("KonText responded with status %d: %s", err.StatusCode, err.Message)
+}
+
+// SubcorpusArgs specifies a subcorpus created from a text type selection
+type SubcorpusArgs struct {
+	Corpus         string              `json:"corpname"`
+	Name           string              `json:"subcname"`
+	Description    string              `json:"description"`
+	AlignedCorpora []string            `json:"aligned_corpora"`
+	TextTypes      map[string][]string `json:"text_types"`
+	FormType       string              `json:"form_type"`
+}
+
+// subcorpusResponse is a (relevant part of) KonText's response.
+// The subcorpus ID is either a string or an object with the `id` key
+// (depending on KonText version).
+type subcorpusResponse struct {
+	SubcID   json.RawMessage `json:"subc_id"`
+	Messages [][]string      `json:"messages"`
+}
+
+func (resp subcorpusResponse) subcorpusID() (string, error) {
+	var id string
+	if err := json.Unmarshal(resp.SubcID, &id); err == nil && id != "" {
+		return id, nil
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(resp.SubcID, &obj); err == nil && obj.ID != "" {
+		return obj.ID, nil
+	}
+	return "", fmt.Errorf("KonText response does not contain a subcorpus ID")
+}
+
+// errorMessage extracts an error message from a KonText response.
+// In case there is none, a status text is used.
+func errorMessage(body []byte, statusCode int) string {
+	var resp subcorpusResponse
+	if err := json.Unmarshal(body, &resp); err == nil {
+		for _, msg := range resp.Messages {
+			if len(msg) == 2 && msg[0] == "error" {
+				return msg[1]
+			}
+		}
+	}
+	return http.StatusText(statusCode)
+}
+
+// CreateSubcorpus asks KonText to create a subcorpus on behalf of a user
+// whose authentication is passed via `authHeaders`. The ID of the new
+// subcorpus is returned. In case KonText rejects the request, *RemoteError
+// is returned.
+func CreateSubcorpus(conf *Conf, args SubcorpusArgs, authHeaders http.Header) (string, error) {
+	if conf == nil || conf.SubcorpusCreateURL == "" {
+		return "", ErrorSubcorpusNotConfigured
+	}
+	if args.FormType == "" {
+		args.FormType = "tt-sel"
+	}
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to create KonText subcorpus: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, conf.SubcorpusCreateURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create KonText subcorpus: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, name := range conf.GetAuthHeaders() {
+		for _, v := range authHeaders.Values(name) {
+			req.Header.Add(name, v)
+		}
+	}
+	client := http.Client{Timeout: subcorpusCreateTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create KonText subcorpus: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create KonText subcorpus: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return "", &RemoteError{StatusCode: resp.StatusCode, Message: errorMessage(body, resp.StatusCode)}
+	}
+	var ans subcorpusResponse
+	if err := json.Unmarshal(body, &ans); err != nil {
+		return "", fmt.Errorf("failed to decode KonText response: %w", err)
+	}
+	return ans.subcorpusID()
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package kontext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSubcorpus(t *testing.T) {
+	var received SubcorpusArgs
+	var receivedAuth, receivedOther string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		receivedAuth = r.Header.Get("Authorization")
+		receivedOther = r.Header.Get("X-Other")
+		w.Write([]byte(`{"subc_id": {"id": "abc123", "corpus_name": "syn2020"}}`))
+	}))
+	defer srv.Close()
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer xyz")
+	headers.Set("X-Other", "foo")
+	id, err := CreateSubcorpus(
+		&Conf{SubcorpusCreateURL: srv.URL},
+		SubcorpusArgs{
+			Corpus:    "syn2020",
+			Name:      "fiction",
+			TextTypes: map[string][]string{"doc.txtype": {"fiction"}},
+		},
+		headers,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", id)
+	assert.Equal(t, "tt-sel", received.FormType)
+	assert.Equal(t, []string{"fiction"}, received.TextTypes["doc.txtype"])
+	assert.Equal(t, "Bearer xyz", receivedAuth)
+	assert.Equal(t, "", receivedOther)
+}
+
+func TestCreateSubcorpusRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"messages": [["error", "access denied"]]}`))
+	}))
+	defer srv.Close()
+	_, err := CreateSubcorpus(&Conf{SubcorpusCreateURL: srv.URL}, SubcorpusArgs{}, http.Header{})
+	var remoteErr *RemoteError
+	assert.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, http.StatusForbidden, remoteErr.StatusCode)
+	assert.Equal(t, "access denied", remoteErr.Message)
+}
+
+func TestCreateSubcorpusNotConfigured(t *testing.T) {
+	_, err := CreateSubcorpus(&Conf{}, SubcorpusArgs{}, http.Header{})
+	assert.ErrorIs(t, err, ErrorSubcorpusNotConfigured)
+}
+
+func TestSubcorpusResponseID(t *testing.T) {
+	id, err := subcorpusResponse{SubcID: json.RawMessage(`"abc"`)}.subcorpusID()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	_, err = subcorpusResponse{}.subcorpusID()
+	assert.Error(t, err)
+}
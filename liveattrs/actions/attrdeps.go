@@ -0,0 +1,84 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/request/attrdeps"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// AttrDependency returns a measure of how strongly values of one
+// attribute are determined by values of another one (e.g. doc.title
+// => doc.author). This allows clients to decide which text type
+// widgets should be refreshed eagerly once a value is selected.
+func (a *Actions) AttrDependency(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get attribute dependency for corpus %s: %w"
+
+	var qry attrdeps.Payload
+	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	for _, attr := range []string{qry.Attr, qry.DependentAttr} {
+		if !isValidAttr(attr) {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("incorrect attribute %s", attr)),
+				http.StatusUnprocessableEntity,
+			)
+			return
+		}
+	}
+	if qry.Attr == qry.DependentAttr {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("attributes must differ")),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	ans, err := db.GetAttrDependency(a.laDB, corpInfo, attrTypes, qry)
+	if err == db.ErrorEmptyResult {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// DetectedAttrTypes returns declared attribute types along with numeric
+// types detected for attributes without a declared type. The detected
+// types can be declared via the `attrTypes` argument of the Create action
+// so numeric range semantics is applied in queries.
+func (a *Actions) DetectedAttrTypes(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to detect attribute types of %s: %w"
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	declared, err := a.laConfCache.GetDeclaredAttrTypes(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	undeclared := make([]string, 0, len(laConf.Structures)*5)
+	for _, attr := range laconf.GetSubcorpAttrs(laConf) {
+		if _, ok := declared[attr]; !ok {
+			undeclared = append(undeclared, attr)
+		}
+	}
+	sort.Strings(undeclared)
+	detected, err := db.DetectNumericAttrs(a.laDB, corpInfo, undeclared)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if declared == nil {
+		declared = make(laconf.AttrTypes)
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]laconf.AttrTypes{
+		"declared": declared,
+		"detected": detected,
+	})
+}
+
+// detectAttrTypes detects integer and date attributes among undeclared
+// attributes of extracted data and stores them so respective typed columns
+// are created (see db.ApplyAttrTypes). The detection runs in case it is
+// enabled by the job or in case some types have been already detected by
+// a previous job (values of the appended data may not fit the types anymore).
+func (a *Actions) detectAttrTypes(jobStatus *liveattrs.LiveAttrsJobInfo) error {
+	prevDetected, err := a.laConfCache.GetDetectedAttrTypes(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	if !jobStatus.Args.DetectAttrTypes && len(prevDetected) == 0 {
+		return nil
+	}
+	declared, err := a.laConfCache.GetDeclaredAttrTypes(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	undeclared := make([]string, 0, len(jobStatus.Args.VteConf.Structures)*5)
+	for _, attr := range laconf.GetSubcorpAttrs(&jobStatus.Args.VteConf) {
+		if _, ok := declared[attr]; !ok {
+			undeclared = append(undeclared, attr)
+		}
+	}
+	sort.Strings(undeclared)
+	detected, err := db.DetectColumnTypes(
+		a.laDB, vteGroupedName(&jobStatus.Args.VteConf), undeclared)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Str("corpusId", jobStatus.CorpusID).
+		Any("types", detected).
+		Msg("detected types of liveattrs columns")
+	return a.laConfCache.SaveDetectedAttrTypes(jobStatus.CorpusID, detected)
+}
@@ -19,19 +19,32 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"masm/v3/corpus"
 	"masm/v3/general/collections"
+	"masm/v3/liveattrs/db/qbuilder"
 	"masm/v3/liveattrs/db/qbuilder/laquery"
 	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/request/response"
 	"masm/v3/liveattrs/utils"
+	"net/http"
 	"reflect"
 
 	"github.com/rs/zerolog/log"
 )
 
+// queryErrorStatus returns a proper HTTP status for an error
+// of a liveattrs database query
+func queryErrorStatus(err error) int {
+	if errors.Is(err, qbuilder.ErrInvalidAttrValue) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func groupBibItems(data *response.QueryAns, bibLabel string) {
 	grouping := make(map[string]*response.ListedValue)
 	entry := data.AttrValues[bibLabel]
@@ -62,14 +75,66 @@ func groupBibItems(data *response.QueryAns, bibLabel string) {
 	}
 }
 
+// checkSubcorpus tests whether a subcorpus restriction (if any) can be
+// applied to a corpus. Structure IDs require the corpus to have
+// a bibliography ID attribute. Returned errors wrap ErrorInvalidSubcorpus.
+func checkSubcorpus(corpusInfo *corpus.DBInfo, subcorpus *query.Subcorpus) error {
+	if subcorpus == nil {
+		return nil
+	}
+	if err := subcorpus.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrorInvalidSubcorpus, err)
+	}
+	if len(subcorpus.StructIDs) > 0 && corpusInfo.BibIDAttr == "" {
+		return fmt.Errorf(
+			"%w: structIds cannot be used as bib. ID not defined for %s",
+			ErrorInvalidSubcorpus, corpusInfo.Name)
+	}
+	return nil
+}
+
+// getAttrValues searches attribute values matching a query. In case
+// the context deadline is exceeded while reading data, values found
+// so far are returned and the answer is marked by the Timeout flag.
 func (a *Actions) getAttrValues(
-	corpusInfo *corpus.DBInfo, qry query.Payload) (*response.QueryAns, error) {
+	ctx context.Context, corpusInfo *corpus.DBInfo, qry query.Payload) (*response.QueryAns, error) {
 
+	if err := checkSubcorpus(corpusInfo, qry.Subcorpus); err != nil {
+		return nil, err
+	}
 	laConf, err := a.laConfCache.Get(corpusInfo.Name) // set(self._get_subcorp_attrs(corpus))
 	if err != nil {
 		return nil, err
 	}
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	autocompleteConf, err := a.laConfCache.GetAutocompleteConf(corpusInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	attrLocales, err := a.laConfCache.GetAttrLocales(corpusInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	speakerConf, err := a.laConfCache.GetSpeakerConf(corpusInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	var valueOrders laconf.ValueOrders
+	if qry.SortOrder() == query.SortCustom {
+		valueOrders, err = a.laConfCache.GetValueOrders(corpusInfo.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
 	srchAttrs := collections.NewSet(laconf.GetSubcorpAttrs(laConf)...)
+	// speaker attributes are searched via the joined speaker table
+	// (see laquery.LAFilter)
+	for _, attr := range speakerConf.QualifiedAttrs() {
+		srchAttrs.Add(attr)
+	}
 	expandAttrs := collections.NewSet[string]()
 	if corpusInfo.BibLabelAttr != "" {
 		srchAttrs.Add(corpusInfo.BibLabelAttr)
@@ -83,11 +148,20 @@ func (a *Actions) getAttrValues(
 		if err != nil {
 			return nil, err
 		}
-		qry.Attrs[qry.AutocompleteAttr] = fmt.Sprintf("%%%s%%", acVals[0])
+		// the value is turned into a substring/fuzzy predicate
+		// by the query builder (see qbuilder.AutocompletePredicate)
+		qry.Attrs[qry.AutocompleteAttr] = acVals[0]
+	}
+	// values of attributes transformed into trees are aggregated
+	// by the server so they must not be summarized
+	for _, attr := range qry.TreeAttrs() {
+		expandAttrs.Add(utils.ImportKey(attr))
 	}
 	// also make sure that range attributes are expanded to full lists
 	for attr := range qry.Attrs {
-		if _, air := qry.Attrs.GetRegexpAttrVal(attr); air {
+		_, _, air := qry.Attrs.GetOperatorAttrVal(attr)
+		_, _, rng := qry.Attrs.GetRangeAttrVal(attr)
+		if air || rng {
 			expandAttrs.Add(utils.ImportKey(attr))
 		}
 	}
@@ -98,6 +172,10 @@ func (a *Actions) getAttrValues(
 		AlignedCorpora:      qry.Aligned,
 		AutocompleteAttr:    qry.AutocompleteAttr,
 		EmptyValPlaceholder: emptyValuePlaceholder,
+		AttrTypes:           attrTypes,
+		AutocompleteConf:    autocompleteConf,
+		Subcorpus:           qry.Subcorpus,
+		Speakers:            speakerConf,
 	}
 	dataIterator := laquery.DataIterator{
 		DB:      a.laDB,
@@ -107,6 +185,7 @@ func (a *Actions) getAttrValues(
 	ans := response.QueryAns{
 		Poscount:   0,
 		AttrValues: make(map[string]any),
+		DocCounts:  make(map[string]int),
 	}
 
 	for _, sattr := range qBuilder.SearchAttrs {
@@ -120,10 +199,19 @@ func (a *Actions) getAttrValues(
 	tmpAns := make(map[string]map[string]*response.ListedValue)
 	bibID := utils.ImportKey(qBuilder.CorpusInfo.BibIDAttr)
 	nilCol := make(map[string]int)
-	err = dataIterator.Iterate(func(row laquery.ResultRow) error {
+	// hidden columns (e.g. bib. ID) are not part of the response
+	// so we do not count documents for them
+	countedAttrs := collections.NewSet[string]()
+	for _, sattr := range qBuilder.SearchAttrs {
+		countedAttrs.Add(utils.ExportKey(utils.ImportKey(sattr)))
+	}
+	err = dataIterator.IterateContext(ctx, func(row laquery.ResultRow) error {
 		ans.Poscount += row.Poscount
 		for dbKey, dbVal := range row.Attrs {
 			colKey := utils.ExportKey(dbKey)
+			if dbVal != "" && countedAttrs.Contains(colKey) {
+				ans.DocCounts[colKey]++
+			}
 			switch tColVal := ans.AttrValues[colKey].(type) {
 			case []*response.ListedValue:
 				var valIdent string
@@ -170,7 +258,10 @@ func (a *Actions) getAttrValues(
 			Int("occurrences", num).
 			Msgf("liveAttributes getAttrValues encountered nil column")
 	}
-	if err != nil {
+	if errors.Is(err, context.DeadlineExceeded) {
+		ans.Timeout = true
+
+	} else if err != nil {
 		return &ans, err
 	}
 	for attr, v := range tmpAns {
@@ -183,15 +274,16 @@ func (a *Actions) getAttrValues(
 	if corpusInfo.BibGroupDuplicates > 0 {
 		groupBibItems(&ans, corpusInfo.BibLabelAttr)
 	}
-	maxAttrListSize := qry.MaxAttrListSize
-	if maxAttrListSize == 0 {
-		maxAttrListSize = dfltMaxAttrListSize
-	}
+	maxAttrListSize := a.conf.LA.AttrListSize.Effective(corpusInfo.Name, qry.MaxAttrListSize)
 	response.ExportAttrValues(
 		&ans,
 		qBuilder.AlignedCorpora,
 		expandAttrs.ToOrderedSlice(),
 		corpusInfo.Locale,
+		attrLocales,
+		attrTypes,
+		qry.SortOrder(),
+		valueOrders,
 		maxAttrListSize,
 	)
 	return &ans, nil
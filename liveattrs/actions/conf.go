@@ -23,15 +23,19 @@ import (
 	"fmt"
 	"io"
 	"masm/v3/corpus"
+	ladb "masm/v3/liveattrs/db"
 	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/qs"
+	"masm/v3/liveattrs/utils"
 	"net/http"
 	"path/filepath"
+	"strings"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
 	"github.com/czcorpus/vert-tagextract/v2/db"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 func (a *Actions) getPatchArgs(req *http.Request) (*laconf.PatchArgs, error) {
@@ -55,16 +59,31 @@ func (a *Actions) createConf(
 	if err != nil {
 		return nil, err
 	}
+	if err := corpusInfo.RegistryError(); err != nil {
+		return nil, err
+	}
 	corpusDBInfo, err := a.cncDB.LoadInfo(corpusID)
 	if err != nil {
 		return nil, err
 	}
 
+	// bibliography attributes are inferred only for the new config,
+	// jsonArgs are applied to runtime configs later
+	createArgs := *jsonArgs
+	jsonArgs.BibViewProvenance = laconf.InferBibView(&createArgs, corpusDBInfo)
+	if jsonArgs.BibViewProvenance != nil && jsonArgs.BibViewProvenance.Source == laconf.BibViewSourceCNCDB {
+		log.Info().
+			Str("corpusId", corpusID).
+			Str("bibIdAttr", jsonArgs.BibViewProvenance.IDAttr).
+			Str("bibLabelAttr", jsonArgs.BibViewProvenance.LabelAttr).
+			Msg("no bibView specified, using bibliography attributes from CNC database")
+	}
 	conf, err := laconf.Create(
 		a.conf.LA,
 		corpusInfo,
 		corpusDBInfo,
-		jsonArgs,
+		&createArgs,
+		a.structCoverageFn(corpusID),
 	)
 	if err != nil {
 		return conf, err
@@ -140,6 +159,11 @@ func (a *Actions) CreateConf(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
+	err = a.saveAuxConf(corpusID, jsonArgs)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
 	expConf := newConf.WithoutPasswords()
 	uniresp.WriteJSONResponse(ctx.Writer, &expConf)
 }
@@ -163,13 +187,20 @@ func (a *Actions) FlushCache(ctx *gin.Context) {
 // argument auto-kontext-setup=1) where the columns to be
 // fetched from a corresponding vertical and other parameters
 // with respect to a typical CNC setup used for its corpora.
+// The patch is applied to a copy of the current config so
+// an invalid patch leaves the config untouched.
 func (a *Actions) PatchConfig(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
-	conf, err := a.laConfCache.Get(corpusID)
+	currConf, err := a.laConfCache.Get(corpusID)
 	if err == laconf.ErrorNoSuchConfig {
 		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("no such config"), http.StatusNotFound)
 		return
+
+	} else if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
 	}
+	conf := *currConf
 
 	inferNgramColsStr, ok := ctx.GetQuery("auto-kontext-setup")
 	if !ok {
@@ -221,7 +252,13 @@ func (a *Actions) PatchConfig(ctx *gin.Context) {
 		}
 	}
 
-	err = a.applyPatchArgs(conf, jsonArgs)
+	if jsonArgs.BibView != nil && jsonArgs.BibView.IDAttr != "" {
+		jsonArgs.BibViewProvenance = &laconf.BibViewProvenance{
+			IDAttr: jsonArgs.BibView.IDAttr,
+			Source: laconf.BibViewSourceRequest,
+		}
+	}
+	err = a.applyPatchArgs(&conf, jsonArgs)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
 		return
@@ -233,17 +270,197 @@ func (a *Actions) PatchConfig(ctx *gin.Context) {
 		return
 	}
 
-	err = a.ensureVerticalFile(conf, corpusInfo)
+	err = a.ensureVerticalFile(&conf, corpusInfo)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
 
-	a.laConfCache.Save(conf)
+	err = a.laConfCache.Save(&conf)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
+	err = a.saveAuxConf(corpusID, jsonArgs)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
 	out := conf.WithoutPasswords()
 	uniresp.WriteJSONResponse(ctx.Writer, &out)
 }
 
+// PatchGroupConfig updates settings shared by members of a parallel
+// corpus group (see laconf.GroupPatchArgs). The update is transactional -
+// either configs of all the listed corpora are updated or none of them.
+func (a *Actions) PatchGroupConfig(ctx *gin.Context) {
+	var args laconf.GroupPatchArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+		return
+	}
+	if err := args.Validate(); err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+		return
+	}
+	confs := make([]*vteCnf.VTEConf, len(args.Corpora))
+	for i, corpusID := range args.Corpora {
+		currConf, err := a.laConfCache.Get(corpusID)
+		if err == laconf.ErrorNoSuchConfig {
+			uniresp.RespondWithErrorJSON(
+				ctx, fmt.Errorf("no such config: %s", corpusID), http.StatusNotFound)
+			return
+
+		} else if err != nil {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+			return
+		}
+		conf := *currConf
+		if err := args.Apply(&conf); err != nil {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+			return
+		}
+		confs[i] = &conf
+	}
+	if err := laconf.CheckGroup(confs); err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusConflict)
+		return
+	}
+	if err := a.laConfCache.SaveAll(confs); err != nil {
+		log.Error().Err(err).Strs("corpora", args.Corpora).Msg("failed to update liveattrs group config")
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
+	ans := make(map[string]vteCnf.VTEConf)
+	for _, conf := range confs {
+		ans[conf.Corpus] = conf.WithoutPasswords()
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// ListConfs lists summaries of all the stored liveattrs
+// configurations matching (optional) URL query filters
+func (a *Actions) ListConfs(ctx *gin.Context) {
+	filter := laconf.ConfFilter{
+		Corpus:         ctx.Query("corpus"),
+		ParallelCorpus: ctx.Query("parallelCorpus"),
+		AtomStructure:  ctx.Query("atomStructure"),
+		BibIDAttr:      ctx.Query("bibIdAttr"),
+		Vertical:       ctx.Query("vertical"),
+	}
+	ans, err := a.laConfCache.ListSummaries(filter)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
+	if a.conf.LA.DB.Type == "mysql" {
+		lastBuilds, err := ladb.GetLastIngestions(a.laDB)
+		if err != nil {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+			return
+		}
+		for _, summary := range ans {
+			if t, ok := lastBuilds[summary.Corpus]; ok {
+				summary.LastBuild = &t
+			}
+		}
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// regenerateConf creates a fresh configuration for a corpus based
+// on its actual registry while keeping the settings chosen by users
+// when creating the `cached` configuration (atom structure, bib. view,
+// self join, n-grams, max. num. of errors). The vertical file is
+// resolved from the registry.
+func (a *Actions) regenerateConf(
+	corpusID string,
+	cached *vteCnf.VTEConf,
+) (*vteCnf.VTEConf, error) {
+	corpusInfo, err := corpus.GetCorpusInfo(corpusID, a.conf.Corp, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := corpusInfo.RegistryError(); err != nil {
+		return nil, err
+	}
+	corpusDBInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	// laconf.Create expects attributes in the "struct.attr" form
+	exportAttr := func(attr string) string {
+		if strings.Contains(attr, ".") {
+			return attr
+		}
+		return utils.ExportKey(attr)
+	}
+	args := laconf.PatchArgs{
+		MaxNumErrors:  &cached.MaxNumErrors,
+		AtomStructure: &cached.AtomStructure,
+		Ngrams:        &cached.Ngrams,
+	}
+	if cached.BibView.IDAttr != "" {
+		args.BibView = &db.BibViewConf{IDAttr: exportAttr(cached.BibView.IDAttr)}
+	}
+	if len(cached.SelfJoin.ArgColumns) > 0 {
+		selfJoin := db.SelfJoinConf{
+			ArgColumns:  make([]string, len(cached.SelfJoin.ArgColumns)),
+			GeneratorFn: cached.SelfJoin.GeneratorFn,
+		}
+		for i, col := range cached.SelfJoin.ArgColumns {
+			selfJoin.ArgColumns[i] = exportAttr(col)
+		}
+		args.SelfJoin = &selfJoin
+	}
+	fresh, err := laconf.Create(
+		a.conf.LA,
+		corpusInfo,
+		corpusDBInfo,
+		&args,
+		a.structCoverageFn(corpusID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.ensureVerticalFile(fresh, corpusInfo); err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+// DiffConf compares the stored liveattrs configuration with
+// a freshly generated one (see regenerateConf) so it is possible
+// to find out whether the configuration should be re-created
+// due to changes in the corpus registry.
+func (a *Actions) DiffConf(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to compare liveattrs conf for %s: %w"
+	cached, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	fresh, err := a.regenerateConf(corpusID, cached)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	diff, err := laconf.DiffConfs(cached.WithoutPasswords(), fresh.WithoutPasswords())
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		map[string]any{"upToDate": len(diff) == 0, "diff": diff},
+	)
+}
+
 // QSDefaults shows the default configuration for
 // extracting n-grams for KonText query suggestion
 // engine and KonText tag builder widget
@@ -0,0 +1,97 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// ConfHistory lists backed up versions of a liveattrs
+// configuration (see laconf.LiveAttrsBuildConfProvider.Backup)
+func (a *Actions) ConfHistory(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get liveattrs conf history for %s: %w"
+	versions, err := a.laConfCache.ListVersions(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"versions": versions})
+}
+
+func (a *Actions) getVersionArg(ctx *gin.Context) (int, error) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid version %s", ctx.Param("version"))
+	}
+	return version, nil
+}
+
+// ViewConfVersion shows a backed up version of a liveattrs
+// configuration. Passwords are removed just like in ViewConf.
+func (a *Actions) ViewConfVersion(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get liveattrs conf version for %s: %w"
+	version, err := a.getVersionArg(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	conf, err := a.laConfCache.GetVersion(corpusID, version)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	out := conf.WithoutPasswords()
+	uniresp.WriteJSONResponse(ctx.Writer, &out)
+}
+
+// RestoreConfVersion replaces the current liveattrs configuration
+// by a backed up version. The replaced configuration is backed up
+// too so the action can be reverted.
+func (a *Actions) RestoreConfVersion(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to restore liveattrs conf version for %s: %w"
+	version, err := a.getVersionArg(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	conf, err := a.laConfCache.Restore(corpusID, version)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	out := conf.WithoutPasswords()
+	uniresp.WriteJSONResponse(ctx.Writer, &out)
+}
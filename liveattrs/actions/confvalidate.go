@@ -0,0 +1,161 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"masm/v3/corpus"
+	"masm/v3/db/mysql"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/czcorpus/vert-tagextract/v2/fs"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dbCheckTimeout = 5 * time.Second
+)
+
+// confValidationResponse is a response of ValidateConf
+type confValidationResponse struct {
+	OK          bool                   `json:"ok"`
+	Diagnostics laconf.ConfDiagnostics `json:"diagnostics"`
+}
+
+func checkVerticals(conf *vteCnf.VTEConf) error {
+	verticals := conf.GetDefinedVerticals()
+	if len(verticals) == 0 {
+		return fmt.Errorf("no vertical file configured")
+	}
+	for _, vert := range verticals {
+		if !fs.IsFile(vert) {
+			return fmt.Errorf("vertical file not found: %s", vert)
+		}
+	}
+	return nil
+}
+
+// checkDB tests whether it is possible to connect the configured
+// database (MySQL) or create the database file (SQLite)
+func checkDB(conf *vteCnf.VTEConf) error {
+	switch conf.DB.Type {
+	case "mysql":
+		db, err := mysql.OpenDB(&conf.DB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), dbCheckTimeout)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("failed to connect database: %w", err)
+		}
+		return nil
+	case "sqlite":
+		dir := filepath.Dir(conf.DB.Name)
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("invalid database directory %s: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid database directory %s: not a directory", dir)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported database type: %s", conf.DB.Type)
+}
+
+// ValidateConf checks a data extraction configuration without
+// starting a job. In case the request body contains a configuration,
+// it is checked. Otherwise, the stored configuration of the corpus
+// is checked. The response contains results of individual checks.
+func (a *Actions) ValidateConf(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to validate liveattrs conf for %s: %w"
+	rawData, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	var conf *vteCnf.VTEConf
+	if len(bytes.TrimSpace(rawData)) > 0 {
+		conf = new(vteCnf.VTEConf)
+		if err := json.Unmarshal(rawData, conf); err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+			return
+		}
+
+	} else {
+		conf, err = a.laConfCache.Get(corpusID)
+		if err == laconf.ErrorNoSuchConfig {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+			return
+
+		} else if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	corpusInfo, err := corpus.GetCorpusInfo(corpusID, a.conf.Corp, false)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	structAttrs, err := corpus.GetStructAttrs(corpusID, a.conf.Corp)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	posAttrs, err := corpus.GetCorpusAttrs(corpusID, a.conf.Corp)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+
+	diagnostics := make(laconf.ConfDiagnostics, 0, 10)
+	diagnostics.Add(laconf.CheckVertical, checkVerticals(conf))
+	diagnostics.Add(laconf.CheckRegistry, corpusInfo.RegistryError())
+	diagnostics = append(
+		diagnostics,
+		laconf.DiagnoseAgainstRegistry(
+			conf,
+			laconf.RegistryInfo{
+				Structs:     corpusInfo.IndexedStructs,
+				StructAttrs: structAttrs,
+				PosAttrs:    posAttrs,
+			},
+		)...,
+	)
+	diagnostics.Add(laconf.CheckDB, checkDB(conf))
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		confValidationResponse{OK: diagnostics.IsValid(), Diagnostics: diagnostics},
+	)
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/cooccurrence"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// Cooccurrence returns, for a (partial) selection of text types,
+// values of other attributes which still have non-zero intersection
+// with the selection. This allows clients to disable impossible
+// combinations without querying each attribute separately.
+func (a *Actions) Cooccurrence(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get attribute co-occurrence for corpus %s: %w"
+
+	var qry cooccurrence.Payload
+	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	for _, attr := range qry.TargetAttrs {
+		if !isValidAttr(attr) {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("incorrect attribute %s", attr)),
+				http.StatusUnprocessableEntity,
+			)
+			return
+		}
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if len(qry.TargetAttrs) == 0 {
+		laConf, err := a.laConfCache.Get(corpInfo.Name)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+		qry.TargetAttrs = laconf.GetSubcorpAttrs(laConf)
+		sort.Strings(qry.TargetAttrs)
+	}
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	idx, err := a.facetIndexes.Get(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if idx != nil {
+		if selection, ok := facetIndexSelection(idx, qry, corpInfo, attrTypes); ok {
+			ans, err := db.GetCooccurrenceFromIndex(idx, selection, qry, emptyValuePlaceholder)
+			if err != nil {
+				uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+				return
+			}
+			uniresp.WriteJSONResponse(ctx.Writer, ans)
+			return
+		}
+	}
+	ans, err := db.GetCooccurrence(a.laDB, corpInfo, attrTypes, qry, emptyValuePlaceholder)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/ttdb"
+	"masm/v3/liveattrs/laconf"
+	"path"
+	"path/filepath"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+)
+
+// CorpusArchiveFiles implements corpus.ArchiveFilesProvider. With MySQL,
+// a fresh SQLite text types database is dumped out of the liveattrs table.
+// Otherwise, the installed text types database (if any) is used.
+func (a *Actions) CorpusArchiveFiles(corpusID string, tmpDir string) ([]corpus.ArchiveFile, error) {
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		return []corpus.ArchiveFile{}, nil
+
+	} else if err != nil {
+		return nil, err
+	}
+	archivePath := path.Join("liveattrs", fmt.Sprintf("%s.db", corpusID))
+	if a.conf.LA.DB.Type == "mysql" {
+		corpInfo, err := a.cncDB.LoadInfo(corpusID)
+		if err != nil {
+			return nil, err
+		}
+		dbPath := filepath.Join(tmpDir, fmt.Sprintf("%s.db", corpusID))
+		_, err = ttdb.Rebuild(a.laDB, corpusID, corpInfo.GroupedName(), dbPath, ttdb.SchemaFromConf(laConf))
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump liveattrs data: %w", err)
+		}
+		return []corpus.ArchiveFile{{Path: dbPath, ArchivePath: archivePath}}, nil
+	}
+	dbPath, err := a.textTypesDbPath(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	if isFile, _ := fs.IsFile(dbPath); isFile {
+		return []corpus.ArchiveFile{{Path: dbPath, ArchivePath: archivePath}}, nil
+	}
+	return []corpus.ArchiveFile{}, nil
+}
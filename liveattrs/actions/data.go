@@ -20,8 +20,9 @@ package actions
 
 import (
 	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/general/confirm"
 	"masm/v3/jobs"
-	"masm/v3/kontext"
 	"masm/v3/liveattrs"
 	"masm/v3/liveattrs/db"
 	"net/http"
@@ -30,6 +31,7 @@ import (
 	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // Create starts a process of creating fresh liveattrs data for a a specified corpus.
@@ -40,6 +42,11 @@ import (
 //     be stored replacing the previous one. In case the option is not set, then the
 //     provided PatchArgs will be applied only to a temporary copy of a respective config
 //     keeping the stored value intact.
+//   - dryRun - instead of starting a job, process only a sample of the vertical file(s)
+//     into a temporary database and return detected columns and projected table size
+//     (see extractionDryRun). Nothing is stored in this mode.
+//   - detectAttrTypes - detect integer and date attributes after the extraction and
+//     store them in typed columns (see detectAttrTypes)
 //
 // request body:
 //
@@ -48,6 +55,7 @@ func (a *Actions) Create(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	baseErrTpl := "failed to generate liveattrs for %s: %w"
 	reconfigure := ctx.Request.URL.Query().Get("reconfigure") == "1"
+	dryRun := ctx.Request.URL.Query().Get("dryRun") == "1"
 
 	var err error
 	var conf *vteCnf.VTEConf
@@ -74,22 +82,43 @@ func (a *Actions) Create(ctx *gin.Context) {
 				ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 			return
 		}
+		if dryRun {
+			// dry run must not store anything
+			conf = newConf
 
-		err = a.laConfCache.Save(newConf)
-		if err != nil {
-			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-			return
-		}
-
-		conf, err = a.laConfCache.Get(corpusID)
-		if err != nil {
-			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-			return
+		} else {
+			err = a.laConfCache.Save(newConf)
+			if err != nil {
+				uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+				return
+			}
+			conf, err = a.laConfCache.Get(corpusID)
+			if err != nil {
+				uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+				return
+			}
 		}
 	}
 
 	runtimeConf := *conf
-	a.applyPatchArgs(&runtimeConf, jsonArgs)
+	err = a.applyPatchArgs(&runtimeConf, jsonArgs)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if dryRun {
+		a.extractionDryRun(ctx, corpusID, runtimeConf)
+		return
+	}
+	// auxiliary configs (attribute types, autocomplete, ...) are not part of the (temporary)
+	// runtime config so we store them regardless of whether the config is new or not
+	err = a.saveAuxConf(corpusID, jsonArgs)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
 	if !runtimeConf.HasConfiguredVertical() {
 		uniresp.WriteJSONErrorResponse(
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusConflict)
@@ -103,7 +132,11 @@ func (a *Actions) Create(ctx *gin.Context) {
 		return
 	}
 
-	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
+	// in the `enqueue` mode, a new job is accepted even if there is
+	// a running one and it is started once the running job finishes
+	enqueue := ctx.Request.URL.Query().Get("enqueue") == "1"
+	prevRunning, isRunning := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType)
+	if isRunning && !enqueue {
 		err := fmt.Errorf("the previous job %s not finished yet", prevRunning.GetID())
 		uniresp.WriteJSONErrorResponse(
 			ctx.Writer,
@@ -115,21 +148,85 @@ func (a *Actions) Create(ctx *gin.Context) {
 
 	append := ctx.Request.URL.Query().Get("append")
 	noCorpusUpdate := ctx.Request.URL.Query().Get("noCorpusUpdate")
+	detectAttrTypes := ctx.Request.URL.Query().Get("detectAttrTypes")
+	skipDuplicates := ctx.Request.URL.Query().Get("skipDuplicates")
+	verticals, ok := a.guardIngestedVerticals(ctx, corpusID, &runtimeConf, append == "1")
+	if !ok {
+		return
+	}
 	status := &liveattrs.LiveAttrsJobInfo{
 		ID:       jobID.String(),
 		CorpusID: corpusID,
 		Start:    jobs.CurrentDatetime(),
 		Args: liveattrs.JobInfoArgs{
-			VteConf:        runtimeConf,
-			Append:         append == "1",
-			NoCorpusUpdate: noCorpusUpdate == "1",
+			VteConf:         runtimeConf,
+			Append:          append == "1",
+			NoCorpusUpdate:  noCorpusUpdate == "1",
+			DetectAttrTypes: detectAttrTypes == "1",
+			Verticals:       verticals,
+			SkipDuplicates:  skipDuplicates == "1",
 		},
 	}
+	if isRunning {
+		status.Type = liveattrs.JobType
+		status.Update = status.Start
+		status.Pending = true
+		a.pendingJobs.add(status)
+		if _, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); !ok {
+			// the running job finished in the meantime
+			a.startPendingJob(corpusID)
+		}
+		log.Info().
+			Str("corpusId", corpusID).
+			Str("runningJobId", prevRunning.GetID()).
+			Msgf("Accepted pending liveAttributes job %s", status.ID)
+		a.jobActions.AttachRequest(ctx, status.ID)
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, status.FullInfo())
+		return
+	}
 	a.createDataFromJobStatus(status)
+	a.jobActions.AttachRequest(ctx, status.ID)
 	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, status.FullInfo())
 }
 
-// Delete removes all the live attributes data for a corpus
+// deletionSummary describes what is removed by the Delete action
+type deletionSummary struct {
+	Table                string   `json:"table"`
+	DropTable            bool     `json:"dropTable"`
+	NumRows              int      `json:"numRows"`
+	Files                []string `json:"files"`
+	NumIngestedVerticals int      `json:"numIngestedVerticals"`
+}
+
+func (a *Actions) createDeletionSummary(corpusDBInfo *corpus.DBInfo) (deletionSummary, error) {
+	ans := deletionSummary{
+		Table:     fmt.Sprintf("%s_liveattrs_entry", corpusDBInfo.GroupedName()),
+		DropTable: corpusDBInfo.GroupedName() == corpusDBInfo.Name,
+		Files:     []string{},
+	}
+	var err error
+	ans.NumRows, err = db.CountCorpusRows(a.laDB, corpusDBInfo.GroupedName(), corpusDBInfo.Name)
+	if err != nil {
+		return ans, err
+	}
+	if path := a.facetIndexes.Path(corpusDBInfo.Name); path != "" {
+		ans.Files = append(ans.Files, path)
+	}
+	if a.conf.LA.DB.Type == "mysql" {
+		ingested, err := db.GetIngestedVerticals(a.laDB, corpusDBInfo.Name)
+		if err != nil {
+			return ans, err
+		}
+		ans.NumIngestedVerticals = len(ingested)
+	}
+	return ans, nil
+}
+
+// Delete removes all the live attributes data for a corpus.
+// This is a two-step operation - without the `confirm` URL argument,
+// nothing is removed and a summary of the data to be removed is returned
+// along with a confirmation token. The data are removed once the token
+// is passed via the `confirm` argument.
 func (a *Actions) Delete(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	baseErrTpl := "failed to delete configuration for %s"
@@ -139,30 +236,69 @@ func (a *Actions) Delete(ctx *gin.Context) {
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	token := ctx.Request.URL.Query().Get("confirm")
+	if token == "" {
+		summary, err := a.createDeletionSummary(corpusDBInfo)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+		ans := a.confirmations.Issue(confirm.Operation{
+			Action:  deleteDataAction,
+			Target:  corpusID,
+			Summary: summary,
+		})
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, ans)
+		return
+	}
+	if err := a.confirmations.Consume(token, deleteDataAction, corpusID); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusPreconditionFailed)
+		return
+	}
+	if err := a.deleteCorpusData(corpusDBInfo); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	err = a.notifyKontext(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+// deleteCorpusData removes liveattrs data of a corpus from the liveattrs
+// database, unsets liveattrs properties of the corpus record and removes
+// auxiliary data (ingested verticals, facet index, quality report)
+func (a *Actions) deleteCorpusData(corpusDBInfo *corpus.DBInfo) error {
+	corpusID := corpusDBInfo.Name
 	tx0, err := a.laDB.Begin()
+	if err != nil {
+		return err
+	}
 	err = db.DeleteTable(
 		tx0,
 		corpusDBInfo.GroupedName(),
 		corpusID,
 	)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		tx0.Rollback()
-		return
+		return err
 	}
 	tx1, err := a.cncDB.StartTx()
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+		tx0.Rollback()
+		return err
 	}
 	err = a.cncDB.UnsetLiveAttrs(tx1, corpusID)
 	if err != nil {
+		tx0.Rollback()
 		tx1.Rollback()
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+		return err
 	}
 	// Now we commit tx0 and tx1 deliberately before soft reset below as a failed operation of
 	// cache reset does no permanent damage.
@@ -172,21 +308,20 @@ func (a *Actions) Delete(ctx *gin.Context) {
 	err = tx0.Commit()
 	if err != nil {
 		tx1.Rollback()
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+		return err
 	}
 	err = tx1.Commit() // in case this fails we're screwed as tx0 is already commited
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(
-			baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+		return err
 	}
-	err = kontext.SendSoftReset(a.conf.KonText)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+	if a.conf.LA.DB.Type == "mysql" {
+		if err := db.ClearIngestedVerticalsOfCorpus(a.laDB, corpusID); err != nil {
+			log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to clear ingested verticals")
+		}
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+	if err := a.facetIndexes.Remove(corpusID); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to remove facet index")
+	}
+	a.invalidateQualityReport(corpusID)
+	return nil
 }
@@ -22,15 +22,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"masm/v3/corpus"
 	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/export"
+	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/biblio"
 	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/spool"
 	"net/http"
 	"regexp"
 	"strconv"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltSpooledPageSize = 1000
 )
 
 var (
@@ -40,6 +49,10 @@ var (
 func (a *Actions) GetBibliography(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	baseErrTpl := "failed to get bibliography from corpus %s: %w"
+	format, ok := getExportFormat(ctx)
+	if !ok {
+		return
+	}
 
 	var qry biblio.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
@@ -66,6 +79,10 @@ func (a *Actions) GetBibliography(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	if format != export.FormatJSON {
+		writeExportedTable(ctx, format, corpusID+"-bibliography", bibliographyToTable(ans))
+		return
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
@@ -108,6 +125,10 @@ func isValidAttr(a string) bool {
 func (a *Actions) DocumentList(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	baseErrTpl := "failed to download document list from %s: %w"
+	format, ok := getExportFormat(ctx)
+	if !ok {
+		return
+	}
 	corpInfo, err := a.cncDB.LoadInfo(corpusID)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
@@ -185,9 +206,37 @@ func (a *Actions) DocumentList(ctx *gin.Context) {
 	if err != nil && err != io.EOF {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
-
+	}
+	if err := checkSubcorpus(corpInfo, qry.Subcorpus); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
 	}
 
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if format == export.FormatJSON && pageSize == 0 && a.docSpool.Enabled() {
+		numDocs, err := db.GetNumOfDocuments(
+			a.laDB, corpInfo, qry.Aligned, qry.Attrs, qry.Subcorpus, attrTypes)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, err),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		if numDocs >= a.docSpool.MinItems() {
+			a.spoolDocumentList(ctx, corpInfo, qry, attrTypes)
+			return
+		}
+	}
 	var ans []*db.DocumentRow
 	ans, err = db.GetDocuments(
 		a.laDB,
@@ -195,6 +244,8 @@ func (a *Actions) DocumentList(ctx *gin.Context) {
 		ctx.Request.URL.Query()["attr"],
 		qry.Aligned,
 		qry.Attrs,
+		qry.Subcorpus,
+		attrTypes,
 		pginfo,
 	)
 	if err != nil {
@@ -205,6 +256,126 @@ func (a *Actions) DocumentList(ctx *gin.Context) {
 		)
 		return
 	}
+	if !includeHidden(ctx) {
+		filters, err := a.laConfCache.GetValueFilters(corpusID)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, err),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		ans = filterHiddenDocuments(ans, filters)
+	}
+	if format != export.FormatJSON {
+		writeExportedTable(
+			ctx, format, corpusID+"-documents",
+			documentsToTable(ans, ctx.Request.URL.Query()["attr"]),
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// spoolDocumentList writes a (huge) list of matching documents
+// to a temporary file and responds with a handle which can be
+// used to fetch the list page by page (see DocumentListPage).
+func (a *Actions) spoolDocumentList(
+	ctx *gin.Context,
+	corpInfo *corpus.DBInfo,
+	qry query.Payload,
+	attrTypes laconf.AttrTypes,
+) {
+	baseErrTpl := "failed to spool document list from %s: %w"
+	filters := laconf.ValueFilters{}
+	if !includeHidden(ctx) {
+		var err error
+		filters, err = a.laConfCache.GetValueFilters(corpInfo.Name)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpInfo.Name, err),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+	}
+	res, err := a.docSpool.Create(
+		corpInfo.Name,
+		func(write func(item any) error) error {
+			var idx int
+			return db.IterDocuments(
+				a.laDB,
+				corpInfo,
+				ctx.Request.URL.Query()["attr"],
+				qry.Aligned,
+				qry.Attrs,
+				qry.Subcorpus,
+				attrTypes,
+				func(doc *db.DocumentRow) error {
+					if isHiddenDocument(doc, filters) {
+						return nil
+					}
+					doc.Idx = idx
+					idx++
+					return write(doc)
+				},
+			)
+		},
+	)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpInfo.Name, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpInfo.Name).
+		Str("resultId", res.ID).
+		Int("numItems", res.NumItems).
+		Msg("spooled document list")
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, res)
+}
+
+// DocumentListPage returns a page of a document list spooled
+// by DocumentList
+func (a *Actions) DocumentListPage(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	resultID := ctx.Param("resultId")
+	baseErrTpl := "failed to get document list page from %s: %w"
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", strconv.Itoa(dfltSpooledPageSize)))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if page < 1 || pageSize < 1 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl,
+				corpusID,
+				fmt.Errorf("page or pageSize argument incorrect (got: %d and %d)", page, pageSize)),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	ans, err := a.docSpool.GetPage(corpusID, resultID, page, pageSize)
+	if err == spool.ErrorResultNotFound {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
@@ -235,12 +406,27 @@ func (a *Actions) NumMatchingDocuments(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
+	if err := checkSubcorpus(corpInfo, qry.Subcorpus); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
 
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
 	ans, err := db.GetNumOfDocuments(
 		a.laDB,
 		corpInfo,
 		qry.Aligned,
 		qry.Attrs,
+		qry.Subcorpus,
+		attrTypes,
 	)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
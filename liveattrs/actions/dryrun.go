@@ -0,0 +1,153 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	vteLib "github.com/czcorpus/vert-tagextract/v2/library"
+	vteProc "github.com/czcorpus/vert-tagextract/v2/proc"
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltDryRunSampleAtoms = 1000
+)
+
+func (a *Actions) dryRunSampleAtoms(ctx *gin.Context) (int, error) {
+	if v := ctx.Request.URL.Query().Get("sampleAtoms"); v != "" {
+		ans, err := strconv.Atoi(v)
+		if err != nil || ans <= 0 {
+			return 0, fmt.Errorf("invalid sampleAtoms value: %s", v)
+		}
+		return ans, nil
+	}
+	if a.conf.LA.DryRunSampleAtoms > 0 {
+		return a.conf.LA.DryRunSampleAtoms, nil
+	}
+	return dfltDryRunSampleAtoms, nil
+}
+
+// runDryRunExtraction runs vert-tagextract with a configuration
+// modified to process a sample vertical into a temporary SQLite
+// database (dbPath).
+func runDryRunExtraction(conf vteCnf.VTEConf, samplePath, dbPath string) error {
+	conf.VerticalFile = samplePath
+	conf.VerticalFiles = nil
+	conf.DB = vtedb.Conf{Type: "sqlite", Name: dbPath}
+	// n-grams are not part of the projection
+	conf.Ngrams.VertColumns = nil
+	conf.Ngrams.NgramSize = 0
+	exitEvent := make(chan os.Signal)
+	defer close(exitEvent)
+	procStatus, err := vteLib.ExtractData(&conf, false, exitEvent)
+	if err != nil {
+		return fmt.Errorf("failed to start vert-tagextract: %w", err)
+	}
+	var ans error
+	for upd := range procStatus {
+		if upd.Error == vteProc.ErrorTooManyParsingErrors {
+			ans = upd.Error
+		}
+	}
+	return ans
+}
+
+// extractionDryRun runs data extraction over a sample (first N atoms)
+// of the configured vertical file(s) into a temporary SQLite database
+// and responds with detected columns, their cardinalities and a projected
+// size of the whole table. No data are written to the target database.
+func (a *Actions) extractionDryRun(ctx *gin.Context, corpusID string, conf vteCnf.VTEConf) {
+	baseErrTpl := "failed to perform dry run of liveattrs extraction for %s: %w"
+	numAtoms, err := a.dryRunSampleAtoms(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if !conf.HasConfiguredVertical() {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("no vertical file configured")),
+			http.StatusConflict,
+		)
+		return
+	}
+	tmpDir, err := os.MkdirTemp("", "masm-dryrun-*")
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warn().Err(err).Str("path", tmpDir).Msg("failed to remove temporary directory")
+		}
+	}()
+	verticals := conf.GetDefinedVerticals()
+	samplePath := filepath.Join(tmpDir, "sample.vert")
+	sample, err := liveattrs.CreateVerticalSample(verticals, conf.AtomStructure, numAtoms, samplePath)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	dbPath := filepath.Join(tmpDir, "sample.db")
+	if err := runDryRunExtraction(conf, samplePath, dbPath); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+	}
+	sdb, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	defer sdb.Close()
+	ans, err := db.GetSampleStats(sdb)
+	if err == db.ErrorEmptyResult {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("no data extracted from the sample")),
+			http.StatusUnprocessableEntity,
+		)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	ans.SampleAtoms = sample.NumAtoms
+	ans.SampleLines = sample.NumLines
+	numLines, err := liveattrs.EstimateNumLines(verticals)
+	if err != nil {
+		log.Warn().Err(err).Str("corpusId", corpusID).Msg("failed to estimate vertical size")
+		numLines = sample.NumLines
+	}
+	db.ProjectSampleStats(ans, numLines)
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
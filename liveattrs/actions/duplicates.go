@@ -0,0 +1,101 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"strings"
+)
+
+const (
+	// maxReportedDuplicates specifies how many duplicate documents
+	// are listed in an error of a job in the append mode
+	maxReportedDuplicates = 20
+)
+
+func formatDuplicates(docIDs []string) string {
+	if len(docIDs) <= maxReportedDuplicates {
+		return strings.Join(docIDs, ", ")
+	}
+	return fmt.Sprintf(
+		"%s and %d more",
+		strings.Join(docIDs[:maxReportedDuplicates], ", "),
+		len(docIDs)-maxReportedDuplicates,
+	)
+}
+
+// checkAppendedDuplicates tests whether data appended by a liveattrs job
+// contain documents (identified by the bibliography ID attribute) already
+// present in the previous data of the corpus. With `skipDuplicates`, the
+// appended rows of such documents are removed. Otherwise, all the appended
+// rows are removed and an error listing the documents is returned.
+func (a *Actions) checkAppendedDuplicates(jobStatus *liveattrs.LiveAttrsJobInfo) error {
+	vteConf := &jobStatus.Args.VteConf
+	if !jobStatus.Args.Append || vteConf.BibView.IDAttr == "" {
+		return nil
+	}
+	jobLog := a.jobActions.JobLogger(jobStatus.ID)
+	duplicates, err := db.FindAppendedDuplicates(
+		a.laDB, vteGroupedName(vteConf), jobStatus.CorpusID,
+		vteConf.BibView.IDAttr, jobStatus.AppendBaseRowID,
+	)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	if jobStatus.Args.SkipDuplicates {
+		numRemoved, err := db.RemoveAppendedRows(
+			a.laDB, vteGroupedName(vteConf), jobStatus.CorpusID,
+			vteConf.BibView.IDAttr, jobStatus.AppendBaseRowID, duplicates,
+		)
+		if err != nil {
+			return err
+		}
+		jobStatus.SkippedDuplicates = duplicates
+		jobLog.Warn().
+			Int("numDocuments", len(duplicates)).
+			Int64("numRows", numRemoved).
+			Msg("skipped appended data of already present documents")
+		return nil
+	}
+	_, err = db.RemoveAppendedRows(
+		a.laDB, vteGroupedName(vteConf), jobStatus.CorpusID,
+		vteConf.BibView.IDAttr, jobStatus.AppendBaseRowID, nil,
+	)
+	if err != nil {
+		return err
+	}
+	// the previous data must be restored to their original state
+	// (see RevertAttrTypes in createDataFromJobStatus)
+	attrTypes, err := a.laConfCache.GetAttrTypes(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	if err := db.ApplyAttrTypes(a.laDB, vteGroupedName(vteConf), attrTypes); err != nil {
+		return err
+	}
+	return fmt.Errorf(
+		"appended data contain already present documents: %s (use skipDuplicates=1 to skip them)",
+		formatDuplicates(duplicates),
+	)
+}
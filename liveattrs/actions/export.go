@@ -0,0 +1,106 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/export"
+	"masm/v3/liveattrs/request/response"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// getExportFormat reads the `format` URL argument. In case the value
+// is invalid, an error response is written and false is returned.
+func getExportFormat(ctx *gin.Context) (export.Format, bool) {
+	format, err := export.ParseFormat(ctx.Request.URL.Query().Get("format"))
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// writeExportedTable writes a table in a specified format. Because the response
+// may be already partially written, errors are only logged by gin.
+func writeExportedTable(ctx *gin.Context, format export.Format, filename string, table export.Table) {
+	if err := export.WriteTable(ctx.Writer, format, filename, table); err != nil {
+		ctx.Error(err)
+	}
+}
+
+// queryAnsToTable exports listed attribute values. Attributes
+// with summarized values (i.e. too long lists) are not included.
+func queryAnsToTable(ans *response.QueryAns) export.Table {
+	table := export.Table{
+		Header: []string{"attribute", "id", "value", "poscount"},
+		Rows:   make([][]string, 0, 100),
+	}
+	attrs := make([]string, 0, len(ans.AttrValues))
+	for attr := range ans.AttrValues {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	for _, attr := range attrs {
+		values, ok := ans.AttrValues[attr].([]*response.ListedValue)
+		if !ok {
+			continue
+		}
+		for _, v := range values {
+			table.Rows = append(
+				table.Rows,
+				[]string{attr, v.ID, v.Label, strconv.Itoa(v.Count)},
+			)
+		}
+	}
+	return table
+}
+
+func documentsToTable(docs []*db.DocumentRow, viewAttrs []string) export.Table {
+	table := export.Table{
+		Header: append([]string{"idx", "id", "label", "numOfPos"}, viewAttrs...),
+		Rows:   make([][]string, len(docs)),
+	}
+	for i, doc := range docs {
+		row := []string{strconv.Itoa(doc.Idx), doc.ID, doc.Label, strconv.Itoa(doc.NumPos)}
+		for _, attr := range viewAttrs {
+			row = append(row, doc.Attrs[attr])
+		}
+		table.Rows[i] = row
+	}
+	return table
+}
+
+func bibliographyToTable(bib map[string]string) export.Table {
+	table := export.Table{
+		Header: []string{"attribute", "value"},
+		Rows:   make([][]string, 0, len(bib)),
+	}
+	for attr, v := range bib {
+		table.Rows = append(table.Rows, []string{attr, v})
+	}
+	sort.Slice(table.Rows, func(i, j int) bool {
+		return table.Rows[i][0] < table.Rows[j][0]
+	})
+	return table
+}
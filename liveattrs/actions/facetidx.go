@@ -0,0 +1,166 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/facetidx"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/cooccurrence"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// facetIndexSelection tests whether a co-occurrence query can be
+// evaluated using a facet index and if so, it returns the selection
+// in the form required by the index. Aligned corpora, non-listing
+// values, typed attributes and the bibliography label attribute
+// are not supported by the index.
+func facetIndexSelection(
+	idx *facetidx.Index,
+	qry cooccurrence.Payload,
+	corpInfo *corpus.DBInfo,
+	attrTypes laconf.AttrTypes,
+) (map[string][]string, bool) {
+	if len(qry.Aligned) > 0 {
+		return nil, false
+	}
+	selection, ok := facetidx.SelectionFromAttrs(qry.Attrs)
+	if !ok {
+		return nil, false
+	}
+	attrs := make([]string, 0, len(selection)+len(qry.TargetAttrs))
+	for attr := range selection {
+		attrs = append(attrs, attr)
+	}
+	attrs = append(attrs, qry.TargetAttrs...)
+	for _, attr := range attrs {
+		if !idx.HasAttr(attr) || attr == corpInfo.BibLabelAttr ||
+			attrTypes.Get(attr) != laconf.AttrTypeString {
+			return nil, false
+		}
+	}
+	return selection, true
+}
+
+// refreshFacetIndex rebuilds an existing facet index of a corpus
+// (e.g. after liveattrs data have been re-created). Corpora without
+// a built index are ignored.
+func (a *Actions) refreshFacetIndex(corpusID string) {
+	if !a.facetIndexes.Exists(corpusID) {
+		return
+	}
+	idx, err := a.buildFacetIndex(corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to refresh facet index")
+		if err := a.facetIndexes.Remove(corpusID); err != nil {
+			log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to remove outdated facet index")
+		}
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Int("numRows", idx.NumRows()).
+		Msg("refreshed facet index")
+}
+
+func (a *Actions) buildFacetIndex(corpusID string) (*facetidx.Index, error) {
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	attrs := laconf.GetSubcorpAttrs(laConf)
+	sort.Strings(attrs)
+	idx, err := facetidx.Build(a.laDB, corpInfo, attrs)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.facetIndexes.Save(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// FacetIndexInfo shows information about a facet index of a corpus
+func (a *Actions) FacetIndexInfo(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get facet index of %s: %w"
+	idx, err := a.facetIndexes.Get(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if idx == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("index not found")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, idx.Info())
+}
+
+// BuildFacetIndex creates (or replaces) a facet index of a corpus.
+// Once available, the index is used by the Cooccurrence action
+// for all the compatible queries.
+func (a *Actions) BuildFacetIndex(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to build facet index of %s: %w"
+	if !a.facetIndexes.Enabled() || a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, corpusID, fmt.Errorf("facet indexes not configured (MySQL only)")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	idx, err := a.buildFacetIndex(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Int("numRows", idx.NumRows()).
+		Msg("built facet index")
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, idx.Info())
+}
+
+// DeleteFacetIndex removes a facet index of a corpus
+// (queries will be evaluated using the database again)
+func (a *Actions) DeleteFacetIndex(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to delete facet index of %s: %w"
+	if err := a.facetIndexes.Remove(corpusID); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
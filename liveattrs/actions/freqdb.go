@@ -50,5 +50,6 @@ func (a *Actions) CreateQuerySuggestions(ctx *gin.Context) {
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	a.jobActions.AttachRequest(ctx, jobInfo.GetID())
 	uniresp.WriteJSONResponse(ctx.Writer, jobInfo)
 }
@@ -0,0 +1,374 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/general/quota"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/request/biblio"
+	"masm/v3/liveattrs/request/fillattrs"
+	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/request/response"
+	"net/http"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// graphQLRequest is a standard GraphQL-over-HTTP request body
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// jsonScalar passes arbitrary JSON values (e.g. query attributes
+// or attribute values listing) through the GraphQL type system
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "arbitrary JSON value",
+	Serialize: func(value any) any {
+		return value
+	},
+	ParseValue: func(value any) any {
+		return value
+	},
+	ParseLiteral: parseJSONLiteral,
+})
+
+// parseJSONLiteral converts an inline GraphQL value into a value
+// with the same types encoding/json would produce
+func parseJSONLiteral(valueAST ast.Value) any {
+	switch tValue := valueAST.(type) {
+	case *ast.StringValue:
+		return tValue.Value
+	case *ast.EnumValue:
+		return tValue.Value
+	case *ast.BooleanValue:
+		return tValue.Value
+	case *ast.IntValue:
+		v, err := strconv.ParseFloat(tValue.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return v
+	case *ast.FloatValue:
+		v, err := strconv.ParseFloat(tValue.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return v
+	case *ast.ListValue:
+		ans := make([]any, len(tValue.Values))
+		for i, v := range tValue.Values {
+			ans[i] = parseJSONLiteral(v)
+		}
+		return ans
+	case *ast.ObjectValue:
+		ans := make(map[string]any)
+		for _, f := range tValue.Fields {
+			ans[f.Name.Value] = parseJSONLiteral(f.Value)
+		}
+		return ans
+	}
+	return nil
+}
+
+// strListArg returns a list of strings argument or nil if missing
+func strListArg(args map[string]any, name string) []string {
+	items, ok := args[name].([]any)
+	if !ok {
+		return nil
+	}
+	ans := make([]string, 0, len(items))
+	for _, item := range items {
+		if v, ok := item.(string); ok {
+			ans = append(ans, v)
+		}
+	}
+	return ans
+}
+
+// attrsArg returns query attributes argument (an empty one if missing)
+func attrsArg(args map[string]any, name string) (query.Attrs, error) {
+	switch tArg := args[name].(type) {
+	case nil:
+		return query.Attrs{}, nil
+	case map[string]any:
+		return query.Attrs(tArg), nil
+	default:
+		return nil, fmt.Errorf("argument %s must be an object", name)
+	}
+}
+
+func (a *Actions) resolveGQLAttrs(p graphql.ResolveParams) (any, error) {
+	corpusID := p.Args["corpusId"].(string)
+	if err := a.quotas.Charge(p.Context, corpusID, quota.OpQuery); err != nil {
+		return nil, err
+	}
+	attrs, err := attrsArg(p.Args, "attrs")
+	if err != nil {
+		return nil, err
+	}
+	qry := query.Payload{
+		Aligned: strListArg(p.Args, "aligned"),
+		Attrs:   attrs,
+	}
+	if v, ok := p.Args["autocompleteAttr"].(string); ok {
+		qry.AutocompleteAttr = v
+	}
+	if v, ok := p.Args["maxAttrListSize"].(int); ok {
+		qry.MaxAttrListSize = v
+	}
+	return a.runQuery(p.Context, nil, corpusID, qry)
+}
+
+func (a *Actions) resolveGQLFillAttrs(p graphql.ResolveParams) (any, error) {
+	corpusID := p.Args["corpusId"].(string)
+	corpusDBInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	qry := fillattrs.Payload{
+		Search: p.Args["search"].(string),
+		Values: strListArg(p.Args, "values"),
+		Fill:   strListArg(p.Args, "fill"),
+	}
+	return db.FillAttrs(a.laDB, corpusDBInfo, qry, nil)
+}
+
+func (a *Actions) resolveGQLBibliography(p graphql.ResolveParams) (any, error) {
+	corpusID := p.Args["corpusId"].(string)
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	laConf, err := a.laConfCache.Get(corpInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetBibliography(
+		a.laDB, corpInfo, laConf, biblio.Payload{ItemID: p.Args["itemId"].(string)})
+}
+
+func (a *Actions) resolveGQLDocuments(p graphql.ResolveParams) (any, error) {
+	corpusID := p.Args["corpusId"].(string)
+	if err := a.quotas.Charge(p.Context, corpusID, quota.OpDocumentList); err != nil {
+		return nil, err
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	if corpInfo.BibIDAttr == "" {
+		return nil, fmt.Errorf("bib. ID not defined for %s", corpusID)
+	}
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	if pageSize == 0 && page != 1 || pageSize < 0 || page < 0 {
+		return nil, fmt.Errorf("page or pageSize argument incorrect (got: %d and %d)", page, pageSize)
+	}
+	viewAttrs := strListArg(p.Args, "viewAttrs")
+	for _, v := range viewAttrs {
+		if !isValidAttr(v) {
+			return nil, fmt.Errorf("incorrect attribute %s", v)
+		}
+	}
+	attrs, err := attrsArg(p.Args, "attrs")
+	if err != nil {
+		return nil, err
+	}
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetDocuments(
+		a.laDB,
+		corpInfo,
+		viewAttrs,
+		strListArg(p.Args, "aligned"),
+		attrs,
+		nil,
+		attrTypes,
+		db.PageInfo{Page: page, PageSize: pageSize},
+	)
+}
+
+func (a *Actions) newGraphQLSchema() (graphql.Schema, error) {
+	attrsResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AttrsResult",
+		Fields: graphql.Fields{
+			"poscount": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*response.QueryAns).Poscount, nil
+				},
+			},
+			"aligned": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*response.QueryAns).AlignedCorpora, nil
+				},
+			},
+			"attrValues": &graphql.Field{
+				Type: jsonScalar,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*response.QueryAns).ExportedAttrValues(), nil
+				},
+			},
+			"docCounts": &graphql.Field{
+				Type: jsonScalar,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*response.QueryAns).DocCounts, nil
+				},
+			},
+		},
+	})
+
+	documentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Document",
+		Fields: graphql.Fields{
+			"idx": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*db.DocumentRow).Idx, nil
+				},
+			},
+			"id": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*db.DocumentRow).ID, nil
+				},
+			},
+			"label": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*db.DocumentRow).Label, nil
+				},
+			},
+			"attrs": &graphql.Field{
+				Type: jsonScalar,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*db.DocumentRow).Attrs, nil
+				},
+			},
+			"numOfPos": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*db.DocumentRow).NumPos, nil
+				},
+			},
+		},
+	})
+
+	strList := graphql.NewList(graphql.NewNonNull(graphql.String))
+	corpusIDArg := &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"attrs": &graphql.Field{
+				Type:        attrsResultType,
+				Description: "search attribute values based on selected values",
+				Args: graphql.FieldConfigArgument{
+					"corpusId":         corpusIDArg,
+					"attrs":            &graphql.ArgumentConfig{Type: jsonScalar},
+					"aligned":          &graphql.ArgumentConfig{Type: strList},
+					"autocompleteAttr": &graphql.ArgumentConfig{Type: graphql.String},
+					"maxAttrListSize":  &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: a.resolveGQLAttrs,
+			},
+			"fillAttrs": &graphql.Field{
+				Type:        jsonScalar,
+				Description: "find values of attributes related to provided values",
+				Args: graphql.FieldConfigArgument{
+					"corpusId": corpusIDArg,
+					"search":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"values":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(strList)},
+					"fill":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(strList)},
+				},
+				Resolve: a.resolveGQLFillAttrs,
+			},
+			"bibliography": &graphql.Field{
+				Type:        jsonScalar,
+				Description: "bibliographic information about an item",
+				Args: graphql.FieldConfigArgument{
+					"corpusId": corpusIDArg,
+					"itemId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: a.resolveGQLBibliography,
+			},
+			"documents": &graphql.Field{
+				Type:        graphql.NewList(documentType),
+				Description: "list of documents matching selected attributes",
+				Args: graphql.FieldConfigArgument{
+					"corpusId":  corpusIDArg,
+					"viewAttrs": &graphql.ArgumentConfig{Type: strList},
+					"attrs":     &graphql.ArgumentConfig{Type: jsonScalar},
+					"aligned":   &graphql.ArgumentConfig{Type: strList},
+					"page":      &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"pageSize":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: a.resolveGQLDocuments,
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// GraphQL provides liveattrs querying, filling of attributes,
+// bibliography and document listing via a single GraphQL endpoint.
+// Errors of individual fields are reported in the `errors` list
+// of the response as defined by the GraphQL specification.
+// Liveattrs queries and document listings are charged against
+// the respective quotas of their corpora.
+func (a *Actions) GraphQL(ctx *gin.Context) {
+	var req graphQLRequest
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to process GraphQL request: %w", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if req.Query == "" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				"failed to process GraphQL request: %w", fmt.Errorf("empty query")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	ans := graphql.Do(graphql.Params{
+		Schema:         a.gqlSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        a.quotas.WithRequestToken(ctx.Request.Context(), ctx.Request),
+	})
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
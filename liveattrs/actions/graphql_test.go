@@ -0,0 +1,128 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/general/quota"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONLiteral(t *testing.T) {
+	v := parseJSONLiteral(&ast.ObjectValue{
+		Fields: []*ast.ObjectField{
+			{
+				Name: &ast.Name{Value: "doc_txtype"},
+				Value: &ast.ListValue{
+					Values: []ast.Value{
+						&ast.StringValue{Value: "fiction"},
+						&ast.StringValue{Value: "poetry"},
+					},
+				},
+			},
+			{
+				Name:  &ast.Name{Value: "doc_year"},
+				Value: &ast.IntValue{Value: "1990"},
+			},
+			{
+				Name:  &ast.Name{Value: "doc_open"},
+				Value: &ast.BooleanValue{Value: true},
+			},
+		},
+	})
+	assert.Equal(
+		t,
+		map[string]any{
+			"doc_txtype": []any{"fiction", "poetry"},
+			"doc_year":   float64(1990),
+			"doc_open":   true,
+		},
+		v,
+	)
+}
+
+func TestAttrsArg(t *testing.T) {
+	attrs, err := attrsArg(map[string]any{}, "attrs")
+	assert.NoError(t, err)
+	assert.Len(t, attrs, 0)
+
+	attrs, err = attrsArg(map[string]any{"attrs": map[string]any{"doc_id": "x"}}, "attrs")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", attrs["doc_id"])
+
+	_, err = attrsArg(map[string]any{"attrs": "doc_id"}, "attrs")
+	assert.Error(t, err)
+}
+
+func TestGraphQLSchemaValidation(t *testing.T) {
+	a := &Actions{}
+	schema, err := a.newGraphQLSchema()
+	assert.NoError(t, err)
+	ans := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: "{ attrs { poscount } }",
+	})
+	assert.True(t, ans.HasErrors())
+	ans = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ documents(corpusId: "syn2020") { unknownField } }`,
+	})
+	assert.True(t, ans.HasErrors())
+}
+
+func TestGraphQLEmptyQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(
+		http.MethodPost, "/graphql", strings.NewReader(`{"query": ""}`))
+	(&Actions{}).GraphQL(ctx)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGraphQLQuotaExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := quota.NewTracker(&quota.Conf{
+		Limits: map[string]int{quota.OpQuery: 1, quota.OpDocumentList: 1},
+	}, nil)
+	tracker.Take(quota.AnonymousToken, "syn2020", quota.OpQuery)
+	tracker.Take(quota.AnonymousToken, "syn2020", quota.OpDocumentList)
+	a := &Actions{quotas: tracker}
+	schema, err := a.newGraphQLSchema()
+	assert.NoError(t, err)
+	a.gqlSchema = schema
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(
+		http.MethodPost,
+		"/graphql",
+		strings.NewReader(`{"query": "{ attrs(corpusId: \"syn2020\") { poscount } `+
+			`documents(corpusId: \"syn2020\") { id } }"}`),
+	)
+	a.GraphQL(ctx)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 2, strings.Count(rec.Body.String(), "daily quota exceeded"))
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// ValueHarmonization compares value sets of an attribute shared
+// by multiple corpora. It reports values present only in some
+// of the corpora and values which are probably near-duplicates
+// (e.g. differing just in case or by a typo).
+func (a *Actions) ValueHarmonization(ctx *gin.Context) {
+	attr := ctx.Query("attr")
+	corpora := ctx.QueryArray("corpus")
+	baseErrTpl := "failed to compare values of %s: %w"
+	if attr == "" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("missing attr argument"), http.StatusBadRequest)
+		return
+	}
+	if len(corpora) < 2 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, attr, fmt.Errorf("at least two corpora must be specified")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if len(corpora) > a.conf.LA.MultiQueryMaxCorpora {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, attr, fmt.Errorf("too many corpora (max. %d)", a.conf.LA.MultiQueryMaxCorpora)),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	maxDistance := liveattrs.DfltHarmonizationMaxDistance
+	if v := ctx.Query("maxDistance"); v != "" {
+		var err error
+		maxDistance, err = strconv.Atoi(v)
+		if err != nil || maxDistance < 0 {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, attr, fmt.Errorf("invalid maxDistance %s", v)),
+				http.StatusUnprocessableEntity,
+			)
+			return
+		}
+	}
+	corpValues := make(map[string]map[string]int)
+	for _, corpusID := range corpora {
+		laConf, err := a.laConfCache.Get(corpusID)
+		if err == laconf.ErrorNoSuchConfig {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer, uniresp.NewActionError(baseErrTpl, attr, fmt.Errorf("%w: %s", err, corpusID)), http.StatusNotFound)
+			return
+
+		} else if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, attr, err), http.StatusInternalServerError)
+			return
+		}
+		if !collections.SliceContains(laconf.GetSubcorpAttrs(laConf), attr) {
+			corpValues[corpusID] = nil
+			continue
+		}
+		corpInfo, err := a.cncDB.LoadInfo(corpusID)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, attr, err), http.StatusInternalServerError)
+			return
+		}
+		values, err := db.GetAttrValueCounts(a.laDB, corpInfo, attr)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, attr, err), http.StatusInternalServerError)
+			return
+		}
+		corpValues[corpusID] = values
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, liveattrs.NewHarmonizationReport(attr, corpValues, maxDistance))
+}
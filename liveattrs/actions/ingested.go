@@ -0,0 +1,142 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"net/http"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func verticalPaths(verticals []liveattrs.VerticalIdentity) []string {
+	ans := make([]string, len(verticals))
+	for i, v := range verticals {
+		ans[i] = v.Path
+	}
+	return ans
+}
+
+// guardIngestedVerticals identifies vertical files of a data extraction
+// job (MySQL only) so they can be registered once the job finishes.
+// In the append mode, verticals already ingested to the corpus data are
+// rejected (unless `force=1` is set) or removed from the configuration
+// (with `skipIngested=1`). In case the job cannot continue, an error
+// response is written and false is returned.
+func (a *Actions) guardIngestedVerticals(
+	ctx *gin.Context,
+	corpusID string,
+	conf *vteCnf.VTEConf,
+	isAppend bool,
+) ([]liveattrs.VerticalIdentity, bool) {
+	baseErrTpl := "failed to generate liveattrs for %s: %w"
+	if conf.DB.Type != "mysql" {
+		return []liveattrs.VerticalIdentity{}, true
+	}
+	verticals, err := liveattrs.IdentifyVerticals(conf.GetDefinedVerticals())
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !isAppend || ctx.Request.URL.Query().Get("force") == "1" {
+		return verticals, true
+	}
+	ingested, err := db.GetIngestedVerticals(a.laDB, corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return nil, false
+	}
+	found, rest := db.FindIngested(ingested, verticals)
+	if len(found) == 0 {
+		return verticals, true
+	}
+	if ctx.Request.URL.Query().Get("skipIngested") != "1" || len(rest) == 0 {
+		err := fmt.Errorf(
+			"vertical file(s) already ingested: %s (use force=1 to ingest them again)",
+			strings.Join(verticalPaths(found), ", "),
+		)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusConflict)
+		return nil, false
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Strs("verticals", verticalPaths(found)).
+		Msg("skipping already ingested vertical files")
+	conf.VerticalFile = ""
+	conf.VerticalFiles = verticalPaths(rest)
+	return rest, true
+}
+
+// registerIngestedVerticals stores identities of vertical files processed
+// by a finished data extraction job. For a job not in the append mode,
+// previous records of all the corpora sharing the table are removed first
+// (as the table has been re-created).
+func (a *Actions) registerIngestedVerticals(jobStatus *liveattrs.LiveAttrsJobInfo) {
+	groupedName := vteGroupedName(&jobStatus.Args.VteConf)
+	if !jobStatus.Args.Append {
+		if err := db.ClearIngestedVerticals(a.laDB, groupedName); err != nil {
+			log.Error().Err(err).Str("corpusId", jobStatus.CorpusID).Msg("failed to clear ingested verticals")
+			return
+		}
+	}
+	err := db.RegisterIngestedVerticals(
+		a.laDB, groupedName, jobStatus.CorpusID, jobStatus.Args.Verticals)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", jobStatus.CorpusID).Msg("failed to register ingested verticals")
+	}
+}
+
+// IngestedVerticals lists vertical files ingested to liveattrs
+// data of a corpus
+func (a *Actions) IngestedVerticals(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get ingested verticals of %s: %w"
+	ans, err := db.GetIngestedVerticals(a.laDB, corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// DuplicateVerticals lists vertical files ingested to multiple
+// corpora or multiple times to the same corpus
+func (a *Actions) DuplicateVerticals(ctx *gin.Context) {
+	baseErrTpl := "failed to find duplicate verticals: %w"
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	ans, err := db.FindDuplicateVerticals(a.laDB)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
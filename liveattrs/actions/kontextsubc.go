@@ -0,0 +1,125 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"masm/v3/kontext"
+	"masm/v3/liveattrs/request/query"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type kontextSubcorpusArgs struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Aligned     []string    `json:"aligned"`
+	Attrs       query.Attrs `json:"attrs"`
+}
+
+// textTypes converts a selection of text types to the form
+// required by KonText. Only value listings are supported.
+func (args kontextSubcorpusArgs) textTypes() (map[string][]string, error) {
+	if args.Name == "" {
+		return nil, fmt.Errorf("missing subcorpus name")
+	}
+	if len(args.Attrs) == 0 {
+		return nil, fmt.Errorf("empty text type selection")
+	}
+	attrs := make([]string, 0, len(args.Attrs))
+	for attr := range args.Attrs {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	ans := make(map[string][]string)
+	for _, attr := range attrs {
+		if !isValidAttr(attr) {
+			return nil, fmt.Errorf("incorrect attribute %s", attr)
+		}
+		values, err := args.Attrs.GetListingOf(attr)
+		if err != nil {
+			return nil, err
+		}
+		ans[attr] = values
+	}
+	return ans, nil
+}
+
+// CreateKontextSubcorpus creates a KonText subcorpus from a selection
+// of text types. KonText is called on behalf of the user (i.e. with
+// the user's authentication headers passed through) and the ID
+// of the new subcorpus is returned.
+func (a *Actions) CreateKontextSubcorpus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to create KonText subcorpus of %s: %w"
+	var args kontextSubcorpusArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	textTypes, err := args.textTypes()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	subcID, err := kontext.CreateSubcorpus(
+		a.conf.KonText,
+		kontext.SubcorpusArgs{
+			Corpus:         corpusID,
+			Name:           args.Name,
+			Description:    args.Description,
+			AlignedCorpora: args.Aligned,
+			TextTypes:      textTypes,
+		},
+		ctx.Request.Header,
+	)
+	var remoteErr *kontext.RemoteError
+	if err == kontext.ErrorSubcorpusNotConfigured {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotImplemented)
+		return
+
+	} else if errors.As(err, &remoteErr) {
+		status := http.StatusBadGateway
+		switch remoteErr.StatusCode {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			status = remoteErr.StatusCode
+		}
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), status)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadGateway)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("subcorpusId", subcID).
+		Msg("created KonText subcorpus")
+	uniresp.WriteJSONResponseWithStatus(
+		ctx.Writer,
+		http.StatusCreated,
+		map[string]any{"corpusId": corpusID, "subcorpusId": subcID, "name": args.Name},
+	)
+}
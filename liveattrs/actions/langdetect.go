@@ -0,0 +1,262 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var derivedAttrNameRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+func (a *Actions) langDetectionFromJobStatus(status *liveattrs.LangDetectJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		result, err := a.detectLanguages(status.CorpusID, status.Args)
+		if err != nil {
+			finalStatus.Error = err
+		}
+		finalStatus.Result = result
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+func (a *Actions) detectLanguages(
+	corpusID string,
+	args liveattrs.LangDetectJobInfoArgs,
+) (*liveattrs.LangDetectResult, error) {
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	detector := liveattrs.NewLangDetector()
+	ans := &liveattrs.LangDetectResult{
+		Attr:           args.DerivedAttr(),
+		Languages:      make(map[string]int),
+		SupportedLangs: detector.SupportedLanguages(),
+	}
+	values := make(map[string]string)
+	err = liveattrs.SampleDocuments(
+		laConf.GetDefinedVerticals(),
+		args.Struct,
+		args.IDAttr,
+		args.MaxChars,
+		func(doc liveattrs.DocumentSample) error {
+			if doc.ID == "" {
+				ans.NumMissingID++
+				return nil
+			}
+			lang := detector.Detect(doc.Text)
+			ans.Add(lang)
+			values[doc.ID] = lang
+			return nil
+		},
+	)
+	if err != nil {
+		return ans, fmt.Errorf("failed to sample documents: %w", err)
+	}
+	ans.NumUpdatedRows, err = db.StoreDerivedValues(
+		a.laDB,
+		vteGroupedName(laConf),
+		corpusID,
+		args.Struct+"."+args.IDAttr,
+		args.DerivedAttr(),
+		values,
+	)
+	if err != nil {
+		return ans, err
+	}
+	// the attribute must be configured so it is available
+	// in liveattrs queries
+	if !collections.SliceContains(laConf.Structures[args.Struct], args.Attr) {
+		newConf := *laConf
+		newConf.Structures = make(map[string][]string)
+		for k, v := range laConf.Structures {
+			newConf.Structures[k] = v
+		}
+		newConf.Structures[args.Struct] = append(
+			append([]string{}, laConf.Structures[args.Struct]...), args.Attr)
+		if err := a.laConfCache.Save(&newConf); err != nil {
+			return ans, fmt.Errorf("failed to update config: %w", err)
+		}
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("attr", ans.Attr).
+		Int("numDocs", ans.NumDocs).
+		Int("numUndetected", ans.NumUndetected).
+		Msg("stored detected languages of documents")
+	a.eqCache.Del(corpusID)
+	a.invalidateQualityReport(corpusID)
+	if err := a.facetIndexes.Remove(corpusID); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to remove facet index")
+	}
+	if err := a.notifyKontext(corpusID); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to notify KonText")
+	}
+	return ans, nil
+}
+
+// langDetectArgs reads and validates job arguments. For missing
+// arguments, values based on the corpus config are used.
+func langDetectArgs(ctx *gin.Context, conf *vteCnf.VTEConf) (liveattrs.LangDetectJobInfoArgs, error) {
+	args := liveattrs.LangDetectJobInfoArgs{
+		Struct:   ctx.Request.URL.Query().Get("struct"),
+		IDAttr:   ctx.Request.URL.Query().Get("idAttr"),
+		Attr:     ctx.DefaultQuery("attr", liveattrs.DfltLangDetectAttr),
+		MaxChars: liveattrs.DfltLangDetectMaxChars,
+	}
+	if args.Struct == "" {
+		args.Struct = conf.AtomStructure
+	}
+	if _, ok := conf.Structures[args.Struct]; !ok {
+		return args, fmt.Errorf("unknown structure %s", args.Struct)
+	}
+	if args.IDAttr == "" {
+		bibStruct, bibAttr, _ := strings.Cut(utils.ExportKey(conf.BibView.IDAttr), ".")
+		if bibStruct == args.Struct {
+			args.IDAttr = bibAttr
+		}
+	}
+	if args.IDAttr == "" {
+		return args, fmt.Errorf("missing document ID attribute (idAttr)")
+	}
+	if !collections.SliceContains(conf.Structures[args.Struct], args.IDAttr) {
+		return args, fmt.Errorf("unknown attribute %s.%s", args.Struct, args.IDAttr)
+	}
+	if !derivedAttrNameRegexp.MatchString(args.Attr) || args.Attr == args.IDAttr {
+		return args, fmt.Errorf("invalid attribute name %s", args.Attr)
+	}
+	if v := ctx.Request.URL.Query().Get("maxChars"); v != "" {
+		maxChars, err := strconv.Atoi(v)
+		if err != nil {
+			return args, fmt.Errorf("invalid maxChars value %s", v)
+		}
+		if maxChars < liveattrs.MinLangDetectMaxChars {
+			return args, fmt.Errorf(
+				"maxChars must be at least %d (shorter samples cannot be identified reliably)",
+				liveattrs.MinLangDetectMaxChars,
+			)
+		}
+		args.MaxChars = maxChars
+	}
+	return args, nil
+}
+
+// DetectLanguages starts a job identifying languages of documents
+// (based on text samples from the vertical) and storing them as
+// a derived attribute (MySQL only). The attribute is added to
+// the corpus config so it can be queried as any other attribute.
+// Please note that a complete rebuild of liveattrs data removes
+// the detected values and the job must be run again.
+func (a *Actions) DetectLanguages(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to detect languages of documents of %s: %w"
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if !laConf.HasConfiguredVertical() {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("no vertical file configured")),
+			http.StatusConflict,
+		)
+		return
+	}
+	args, err := langDetectArgs(ctx, laConf)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.LangDetectJobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	if _, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				baseErrTpl, corpusID, fmt.Errorf("cannot detect languages while a liveattrs job is running")),
+			http.StatusConflict,
+		)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := liveattrs.LangDetectJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.LangDetectJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     args,
+	}
+	a.langDetectionFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+func (a *Actions) RestartLangDetectJob(jinfo *liveattrs.LangDetectJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.langDetectionFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted language detection job %s", jinfo.ID)
+	return nil
+}
@@ -19,23 +19,31 @@
 package actions
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"masm/v3/cncdb"
+	"masm/v3/cnf"
 	"masm/v3/corpus"
 	"masm/v3/general"
+	"masm/v3/general/budget"
+	"masm/v3/general/confirm"
+	"masm/v3/general/quota"
 	"masm/v3/jobs"
 	"masm/v3/kontext"
 	"masm/v3/liveattrs"
 	"masm/v3/liveattrs/cache"
 	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/db/facetidx"
+	"masm/v3/liveattrs/export"
 	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/equery"
 	"masm/v3/liveattrs/request/fillattrs"
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/request/response"
+	"masm/v3/liveattrs/spool"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -44,6 +52,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
 	"github.com/rs/zerolog/log"
 
 	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
@@ -52,17 +61,35 @@ import (
 	vteProc "github.com/czcorpus/vert-tagextract/v2/proc"
 	"github.com/google/uuid"
 
+	"github.com/czcorpus/cnc-gokit/collections"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 )
 
 const (
 	emptyValuePlaceholder = "?"
-	dfltMaxAttrListSize   = 30
 	shortLabelMaxLength   = 30
+	confirmationTokenTTL  = 5 * time.Minute
+	deleteDataAction      = "deleteLiveAttrsData"
+
+	// extractionCheckpointInterval specifies how often checkpoints
+	// of running data extraction jobs are created
+	extractionCheckpointInterval = 30 * time.Second
 )
 
 var (
 	ErrorMissingVertical = errors.New("missing vertical file")
+
+	// ErrorTimeBudgetExceeded means that a request could not be answered
+	// (not even partially) within its time budget
+	ErrorTimeBudgetExceeded = errors.New("time budget of the request exceeded")
+
+	// ErrorInvalidSubcorpus means that a subcorpus restriction
+	// of a query cannot be applied (see query.Subcorpus)
+	ErrorInvalidSubcorpus = errors.New("invalid subcorpus")
+
+	// errExtractionStopped means that a data extraction job
+	// has been stopped before all the verticals were processed
+	errExtractionStopped = errors.New("data extraction stopped")
 )
 
 type CreateLiveAttrsReqBody struct {
@@ -91,6 +118,7 @@ type LAConf struct {
 	Ngram   *liveattrs.NgramDBConf
 	KonText *kontext.Conf
 	Corp    *corpus.CorporaSetup
+	Budgets cnf.RequestBudgetsConf
 }
 
 // Actions wraps liveattrs-related actions
@@ -121,15 +149,75 @@ type Actions struct {
 	// eqCache stores results for live-attributes empty queries (= initial text types data)
 	eqCache *cache.EmptyQueryCache
 
+	// gqlSchema is a GraphQL schema exposing selected liveattrs functions
+	gqlSchema graphql.Schema
+
+	// progress distributes progress of running data jobs to watching clients
+	progress *progressBroker
+
+	// facetIndexes stores precomputed facet indexes of corpora
+	facetIndexes *facetidx.Store
+
+	// docSpool stores huge document lists for paginated access
+	docSpool *spool.Spool
+
+	// confirmations stores tokens confirming destructive operations
+	confirmations *confirm.Registry
+
+	// pendingJobs stores liveattrs jobs waiting for a running
+	// job of the same corpus to finish
+	pendingJobs *pendingJobs
+
+	// qualityReports caches data quality reports of corpora
+	qualityReports *collections.ConcurrentMap[string, *liveattrs.QualityReport]
+
 	structAttrStats *db.StructAttrUsage
 
 	usageData chan<- db.RequestData
+
+	// kontextNotified stores times of last successful KonText cache
+	// invalidation for individual corpora (since masm start)
+	kontextNotified *collections.ConcurrentMap[string, time.Time]
+
+	// textTypesDbChecksums caches checksums of downloaded
+	// SQLite text types databases
+	textTypesDbChecksums *fileChecksums
+
+	// quotas limits operations of requests involving multiple
+	// corpora (single corpus endpoints are guarded in routing)
+	quotas *quota.Tracker
 }
 
 func (a *Actions) OnExit() {
 	close(a.usageData)
 }
 
+// NumUnflushedUsageRecords returns the number of attribute usage
+// records not stored to the database yet
+func (a *Actions) NumUnflushedUsageRecords() int {
+	return a.structAttrStats.NumUnflushed()
+}
+
+// CacheStats returns numbers of entries of liveattrs caches
+func (a *Actions) CacheStats() map[string]int {
+	return map[string]int{
+		"liveattrsQueries":        len(a.eqCache.Queries()),
+		"liveattrsConfs":          a.laConfCache.NumCached(),
+		"liveattrsQualityReports": a.qualityReports.Len(),
+	}
+}
+
+// notifyKontext asks KonText to invalidate its cached data of a corpus
+// and records the time of a successful notification (in case there
+// is a KonText instance to be notified).
+func (a *Actions) notifyKontext(corpusID string) error {
+	err := kontext.SendCorpusCacheInvalidation(a.conf.KonText, corpusID)
+	if err == nil && a.conf.KonText.HasNotificationTargets() {
+		a.kontextNotified.Set(corpusID, time.Now())
+	}
+	return err
+}
+
 // applyPatchArgs based on configuration stored in `jsonArgs`
 //
 // NOTE: no n-gram config means "do not touch the current" while zero
@@ -167,6 +255,9 @@ func (a *Actions) applyPatchArgs(
 	}
 
 	if jsonArgs.MaxNumErrors != nil {
+		if *jsonArgs.MaxNumErrors < 0 {
+			return fmt.Errorf("invalid max. number of errors: %d", *jsonArgs.MaxNumErrors)
+		}
 		targetConf.MaxNumErrors = *jsonArgs.MaxNumErrors
 	}
 
@@ -174,6 +265,124 @@ func (a *Actions) applyPatchArgs(
 		targetConf.SelfJoin = *jsonArgs.SelfJoin
 	}
 
+	if jsonArgs.AttrTypes != nil {
+		if err := jsonArgs.AttrTypes.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.Autocomplete != nil {
+		if err := jsonArgs.Autocomplete.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.Collations != nil {
+		if err := jsonArgs.Collations.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.MultiValues != nil {
+		if err := jsonArgs.MultiValues.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.Locales != nil {
+		if err := jsonArgs.Locales.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.ValueFilters != nil {
+		if err := jsonArgs.ValueFilters.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.ValueOrders != nil {
+		if err := jsonArgs.ValueOrders.Validate(targetConf.Structures); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.Speakers != nil {
+		if err := jsonArgs.Speakers.Validate(targetConf); err != nil {
+			return err
+		}
+	}
+
+	if jsonArgs.Timing != nil {
+		if err := jsonArgs.Timing.Validate(targetConf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveAuxConf stores auxiliary configs which are not part of VTEConf
+// (attribute types, autocomplete, collations, multi-value separators,
+// sorting locales, value filters, value orders, the speaker table
+// and segment timing)
+// from `jsonArgs` (if any) for a corpus. As sorting settings and speaker
+// attributes affect cached query results, the cache is cleared for
+// the corpus once they change.
+func (a *Actions) saveAuxConf(corpusID string, jsonArgs *laconf.PatchArgs) error {
+	if jsonArgs.AttrTypes != nil {
+		if err := a.laConfCache.SaveAttrTypes(corpusID, jsonArgs.AttrTypes); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Autocomplete != nil {
+		if err := a.laConfCache.SaveAutocompleteConf(corpusID, *jsonArgs.Autocomplete); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Collations != nil {
+		if err := a.laConfCache.SaveCollations(corpusID, jsonArgs.Collations); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.MultiValues != nil {
+		if err := a.laConfCache.SaveMultiValueSeparators(corpusID, jsonArgs.MultiValues); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Locales != nil {
+		if err := a.laConfCache.SaveAttrLocales(corpusID, jsonArgs.Locales); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.ValueFilters != nil {
+		if err := a.laConfCache.SaveValueFilters(corpusID, jsonArgs.ValueFilters); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.ValueOrders != nil {
+		if err := a.laConfCache.SaveValueOrders(corpusID, jsonArgs.ValueOrders); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.BibViewProvenance != nil {
+		if err := a.laConfCache.SaveBibViewProvenance(corpusID, *jsonArgs.BibViewProvenance); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Speakers != nil {
+		if err := a.laConfCache.SaveSpeakerConf(corpusID, *jsonArgs.Speakers); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Timing != nil {
+		if err := a.laConfCache.SaveTimingConf(corpusID, *jsonArgs.Timing); err != nil {
+			return err
+		}
+	}
+	if jsonArgs.Locales != nil || jsonArgs.ValueOrders != nil || jsonArgs.Speakers != nil {
+		a.eqCache.Del(corpusID)
+	}
 	return nil
 }
 
@@ -216,57 +425,217 @@ func (a *Actions) ensureVerticalFile(vconf *vteCnf.VTEConf, corpusInfo *corpus.I
 	return nil
 }
 
+// vteGroupedName returns a name identifying a liveattrs table
+// a vert-tagextract configuration writes to
+func vteGroupedName(vconf *vteCnf.VTEConf) string {
+	if vconf.ParallelCorpus != "" {
+		return vconf.ParallelCorpus
+	}
+	return vconf.Corpus
+}
+
 // createDataFromJobStatus starts data extraction and generation
 // based on (initial) job status
 func (a *Actions) createDataFromJobStatus(initialStatus *liveattrs.LiveAttrsJobInfo) {
 	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
-		a.vteExitEvents[initialStatus.ID] = make(chan os.Signal)
-		procStatus, err := vteLib.ExtractData(
-			&initialStatus.Args.VteConf,
-			initialStatus.Args.Append,
-			a.vteExitEvents[initialStatus.ID],
-		)
+		if initialStatus.Args.Append && initialStatus.Args.VteConf.DB.Type == "mysql" {
+			// vert-tagextract is not able to insert empty values to typed columns
+			err := db.RevertAttrTypes(a.laDB, vteGroupedName(&initialStatus.Args.VteConf))
+			if err != nil {
+				updateJobChan <- initialStatus.WithError(err).AsFinished()
+				close(updateJobChan)
+				a.startPendingJob(initialStatus.CorpusID)
+				return
+			}
+			// a restarted job keeps the original base so its partially
+			// appended data are not considered as previous ones
+			if initialStatus.NumRestarts == 0 {
+				initialStatus.AppendBaseRowID, err = db.GetMaxRowID(
+					a.laDB, vteGroupedName(&initialStatus.Args.VteConf))
+				if err != nil {
+					updateJobChan <- initialStatus.WithError(err).AsFinished()
+					close(updateJobChan)
+					a.startPendingJob(initialStatus.CorpusID)
+					return
+				}
+			}
+		}
+		// compressed verticals are decompressed on the fly (vert-tagextract
+		// reads named pipes instead of the original files)
+		definedVerticals := initialStatus.Args.VteConf.GetDefinedVerticals()
+		var verticals *liveattrs.DecompressedVerticals
+		var err error
+		if initialStatus.Checkpoint != nil {
+			// a restarted job continues from its last checkpoint
+			verticals, err = liveattrs.OpenResumedVerticals(definedVerticals, initialStatus.Checkpoint)
+
+		} else {
+			verticals, err = liveattrs.OpenDecompressedVerticals(definedVerticals)
+		}
 		if err != nil {
-			updateJobChan <- initialStatus.WithError(
-				fmt.Errorf("failed to start vert-tagextract: %s", err)).AsFinished()
+			updateJobChan <- initialStatus.WithError(err).AsFinished()
 			close(updateJobChan)
+			a.startPendingJob(initialStatus.CorpusID)
+			return
 		}
+		// the extraction can be paused between verticals
+		// (see jobs.Actions.PauseJob and extractVertical)
+		pauseGate := a.jobActions.PauseGate(initialStatus.ID)
+		// the channel is buffered and it is never closed so a stop
+		// signal sent between verticals neither blocks nor panics
+		a.vteExitEvents[initialStatus.ID] = make(chan os.Signal, 1)
+		jobLog := a.jobActions.JobLogger(initialStatus.ID)
 		go func() {
 			defer func() {
+				if err := verticals.Close(); err != nil {
+					jobLog.Warn().Err(err).Msg("failed to clean up decompressed verticals")
+				}
+				a.progress.finish(initialStatus.ID)
 				close(updateJobChan)
-				close(a.vteExitEvents[initialStatus.ID])
 				delete(a.vteExitEvents, initialStatus.ID)
+				a.startPendingJob(initialStatus.CorpusID)
 			}()
 			jobStatus := liveattrs.LiveAttrsJobInfo{
-				ID:          initialStatus.ID,
-				Type:        liveattrs.JobType,
-				CorpusID:    initialStatus.CorpusID,
-				Start:       initialStatus.Start,
-				Update:      jobs.CurrentDatetime(),
-				NumRestarts: initialStatus.NumRestarts,
-				Args:        initialStatus.Args,
+				ID:              initialStatus.ID,
+				Type:            liveattrs.JobType,
+				CorpusID:        initialStatus.CorpusID,
+				Start:           initialStatus.Start,
+				Update:          jobs.CurrentDatetime(),
+				NumRestarts:     initialStatus.NumRestarts,
+				Args:            initialStatus.Args,
+				Checkpoint:      initialStatus.Checkpoint,
+				AppendBaseRowID: initialStatus.AppendBaseRowID,
 			}
+			numLines, err := liveattrs.EstimateNumLines(definedVerticals)
+			if err != nil {
+				jobLog.Warn().Err(err).Msg("failed to estimate vertical size")
 
-			for upd := range procStatus {
-				if upd.Error == vteProc.ErrorTooManyParsingErrors {
-					jobStatus.Error = upd.Error
-				}
-				jobStatus.ProcessedAtoms = upd.ProcessedAtoms
-				jobStatus.ProcessedLines = upd.ProcessedLines
-				updateJobChan <- jobStatus
+			} else {
+				jobStatus.EstimatedNumLines = numLines
+			}
 
-				if upd.Error == vteProc.ErrorTooManyParsingErrors {
-					log.Error().Err(upd.Error).Msg("live attributes extraction failed")
-					return
+			// in case of a resumed job, vert-tagextract counts lines and atoms
+			// from the checkpoint (including the repeated open structures)
+			ext := verticalExtraction{updateJobChan: updateJobChan, jobStatus: &jobStatus}
+			if cp := initialStatus.Checkpoint; cp != nil {
+				ext.baseLines = cp.ProcessedLines - len(cp.OpenTags)
+				ext.baseAtoms = cp.ProcessedAtoms
+			}
+			ext.cpScanner, err = liveattrs.NewCheckpointScanner(
+				definedVerticals, initialStatus.Args.VteConf.AtomStructure, initialStatus.Checkpoint)
+			if err != nil {
+				jobLog.Warn().Err(err).Msg("failed to create checkpoint scanner, no checkpoints will be available")
+
+			} else {
+				defer ext.cpScanner.Close()
+			}
+			ext.lastCheckpoint = time.Now()
 
-				} else if upd.Error != nil {
-					log.Error().Err(upd.Error).Msg("(just registered)")
+			for i, path := range verticals.Paths {
+				if i > 0 {
+					// the previous vertical has been committed so a paused
+					// job does not keep an open transaction
+					pauseGate.Wait()
+				}
+				appendData := initialStatus.Args.Append || initialStatus.Checkpoint != nil || i > 0
+				if err := a.extractVertical(&ext, path, appendData); err != nil {
+					jobLog.Error().Err(err).Msg("live attributes extraction failed")
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				// the gate is released once the job is stopped (in case
+				// vert-tagextract has received the stop signal, data of the
+				// actual vertical have been rolled back)
+				if pauseGate.IsReleased() {
+					jobLog.Warn().Msg("live attributes extraction stopped")
+					updateJobChan <- jobStatus.WithError(errExtractionStopped)
+					return
 				}
 			}
+			// a failed feeder of a decompressed vertical looks like a regular
+			// end of file to vert-tagextract so the data would be incomplete
+			if err := verticals.Err(); err != nil {
+				jobLog.Error().Err(err).Msg("live attributes extraction failed")
+				updateJobChan <- jobStatus.WithError(err)
+				return
+			}
 
+			// once extracted, the data are post-processed as a whole
+			// so there is nothing to resume from
+			jobStatus.Checkpoint = nil
 			a.eqCache.Del(jobStatus.CorpusID)
 			switch jobStatus.Args.VteConf.DB.Type {
 			case "mysql":
+				if err := a.checkAppendedDuplicates(&jobStatus); err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				// multi-value attributes must be split before typed
+				// columns are created (see ApplyAttrTypes)
+				separators, err := a.laConfCache.GetMultiValueSeparators(jobStatus.CorpusID)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				err = db.SplitMultiValues(
+					a.laDB, vteGroupedName(&jobStatus.Args.VteConf), jobStatus.CorpusID, separators)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				if err := a.applyValueMerges(&jobStatus); err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				err = a.detectAttrTypes(&jobStatus)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				attrTypes, err := a.laConfCache.GetAttrTypes(jobStatus.CorpusID)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				err = db.ApplyAttrTypes(
+					a.laDB, vteGroupedName(&jobStatus.Args.VteConf), attrTypes)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				collations, err := a.laConfCache.GetCollations(jobStatus.CorpusID)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				err = db.ApplyCollations(
+					a.laDB, vteGroupedName(&jobStatus.Args.VteConf), collations)
+				if err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				if err := a.extractSpeakers(&jobStatus); err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				if err := a.storeSegmentDurations(&jobStatus); err != nil {
+					updateJobChan <- jobStatus.WithError(err)
+					return
+				}
+				if len(jobStatus.Args.VteConf.SelfJoin.ArgColumns) > 0 {
+					report, err := db.GetMergeReport(
+						a.laDB, vteGroupedName(&jobStatus.Args.VteConf))
+					if err != nil {
+						jobLog.Error().Err(err).Str("corpusId", jobStatus.CorpusID).Msg("failed to create merge report")
+
+					} else {
+						jobStatus.MergeReport = report
+					}
+				}
+				a.registerIngestedVerticals(&jobStatus)
+				a.storeValueCounts(&jobStatus)
+				a.refreshFacetIndex(jobStatus.CorpusID)
+				a.invalidateQualityReport(jobStatus.CorpusID)
 				if !jobStatus.Args.NoCorpusUpdate {
 					transact, err := a.cncDB.StartTx()
 					if err != nil {
@@ -285,7 +654,7 @@ func (a *Actions) createDataFromJobStatus(initialStatus *liveattrs.LiveAttrsJobI
 						updateJobChan <- jobStatus.WithError(err)
 						transact.Rollback()
 					}
-					err = kontext.SendSoftReset(a.conf.KonText)
+					err = a.notifyKontext(jobStatus.CorpusID)
 					if err != nil {
 						updateJobChan <- jobStatus.WithError(err)
 					}
@@ -295,7 +664,7 @@ func (a *Actions) createDataFromJobStatus(initialStatus *liveattrs.LiveAttrsJobI
 					}
 				}
 			case "sqlite":
-				err = kontext.SendSoftReset(a.conf.KonText)
+				err = a.notifyKontext(jobStatus.CorpusID)
 				if err != nil {
 					updateJobChan <- initialStatus.WithError(err)
 				}
@@ -306,61 +675,220 @@ func (a *Actions) createDataFromJobStatus(initialStatus *liveattrs.LiveAttrsJobI
 	a.jobActions.EnqueueJob(&fn, initialStatus)
 }
 
+// verticalExtraction keeps a state of data extraction
+// shared by all the verticals of a job
+type verticalExtraction struct {
+	updateJobChan  chan<- jobs.GeneralJobInfo
+	jobStatus      *liveattrs.LiveAttrsJobInfo
+	cpScanner      *liveattrs.CheckpointScanner
+	lastCheckpoint time.Time
+
+	// baseLines and baseAtoms are numbers of lines and atoms
+	// processed before the actual vertical (vert-tagextract counts
+	// them for each vertical separately)
+	baseLines int
+	baseAtoms int
+}
+
+// extractVertical extracts data of a single vertical file. Each vertical
+// is processed by a separate run of vert-tagextract which writes its
+// data within a single transaction committed once the vertical is
+// processed. I.e. the job can be paused only between verticals
+// (see jobs.PauseGate).
+func (a *Actions) extractVertical(ext *verticalExtraction, path string, appendData bool) error {
+	jobStatus := ext.jobStatus
+	jobLog := a.jobActions.JobLogger(jobStatus.ID)
+	vteConf := jobStatus.Args.VteConf
+	vteConf.VerticalFile = ""
+	vteConf.VerticalFiles = []string{path}
+	procStatus, err := vteLib.ExtractData(&vteConf, appendData, a.vteExitEvents[jobStatus.ID])
+	if err != nil {
+		return fmt.Errorf("failed to start vert-tagextract: %s", err)
+	}
+	var numLines, numAtoms int
+	for upd := range procStatus {
+		if upd.Error == vteProc.ErrorTooManyParsingErrors {
+			jobStatus.Error = upd.Error
+		}
+		numLines = max(numLines, upd.ProcessedLines)
+		numAtoms = max(numAtoms, upd.ProcessedAtoms)
+		jobStatus.UpdateProgress(ext.baseAtoms+upd.ProcessedAtoms, ext.baseLines+upd.ProcessedLines)
+		if ext.cpScanner != nil && time.Since(ext.lastCheckpoint) >= extractionCheckpointInterval {
+			cp, err := ext.cpScanner.Advance(jobStatus.ProcessedLines)
+			if err != nil {
+				jobLog.Warn().Err(err).Msg("failed to create extraction checkpoint")
+			}
+			jobStatus.Checkpoint = cp
+			ext.lastCheckpoint = time.Now()
+		}
+		ext.updateJobChan <- *jobStatus
+		a.progress.publish(*jobStatus)
+
+		if upd.Error == vteProc.ErrorTooManyParsingErrors {
+			// vert-tagextract must be able to send its remaining statuses
+			go func() {
+				for range procStatus {
+				}
+			}()
+			return upd.Error
+
+		} else if upd.Error != nil {
+			jobLog.Error().Err(upd.Error).Msg("(just registered)")
+		}
+	}
+	ext.baseLines += numLines
+	ext.baseAtoms += numAtoms
+	return nil
+}
+
 func (a *Actions) runStopJobListener() {
 	for id := range a.jobStopChannel {
 		if job, ok := a.jobActions.GetJob(id); ok {
 			if tJob, ok2 := job.(*liveattrs.LiveAttrsJobInfo); ok2 {
+				// a paused job would not read the stop signal
+				a.jobActions.ReleasePauseGate(tJob.ID)
 				if stopChan, ok3 := a.vteExitEvents[tJob.ID]; ok3 {
-					stopChan <- os.Interrupt
+					select {
+					case stopChan <- os.Interrupt:
+					default: // the job has a pending stop signal already
+					}
 				}
 			}
 		}
 	}
 }
 
-func (a *Actions) Query(ctx *gin.Context) {
+// runQuery loads (or obtains from cache) liveattrs values matching the
+// provided query. The returned value still contains document counts
+// (see Payload.IncludeDocCounts).
+// runQuery searches attribute values. In case the budget `bgt` is exceeded
+// while querying the liveattrs database, partial results are returned
+// (see response.QueryAns.Timeout). A nil budget means no time limits.
+func (a *Actions) runQuery(
+	ctx context.Context,
+	bgt *budget.Budget,
+	corpusID string,
+	qry query.Payload,
+) (*response.QueryAns, error) {
 	t0 := time.Now()
-	corpusID := ctx.Param("corpusId")
-	baseErrTpl := "failed to query liveattrs in corpus %s: %w"
-	var qry query.Payload
-	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-		return
-	}
-	corpInfo, err := a.cncDB.LoadInfo(corpusID)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		return
+	cncCtx, cancel := bgt.Start(ctx, cnf.BudgetStageCNCDB)
+	corpInfo, err := a.cncDB.LoadInfoContext(cncCtx, corpusID)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		bgt.MarkExceeded(cnf.BudgetStageCNCDB)
+		return nil, fmt.Errorf("%w (stage %s)", ErrorTimeBudgetExceeded, cnf.BudgetStageCNCDB)
+
+	} else if err != nil {
+		return nil, err
 	}
 	usageEntry := db.RequestData{
 		CorpusID: corpusID,
 		Payload:  qry,
 		Created:  time.Now(),
 	}
-
+	if err := qry.Validate(); err != nil {
+		return nil, err
+	}
 	ans := a.eqCache.Get(corpusID, qry)
 	if ans != nil {
-		uniresp.WriteJSONResponse(ctx.Writer, &ans)
 		usageEntry.IsCached = true
 		usageEntry.ProcTime = time.Since(t0)
 		a.usageData <- usageEntry
+		return ans, nil
+	}
+	laCtx, cancel := bgt.Start(ctx, cnf.BudgetStageLADB)
+	defer cancel()
+	ans, err = a.getAttrValues(laCtx, corpInfo, qry)
+	if err != nil {
+		return nil, err
+	}
+	usageEntry.ProcTime = time.Since(t0)
+	a.usageData <- usageEntry
+	if ans.Timeout {
+		// partial results must not be cached
+		bgt.MarkExceeded(cnf.BudgetStageLADB)
+
+	} else {
+		a.eqCache.Set(corpusID, qry, ans)
+	}
+	return ans, nil
+}
+
+// finishBudget marks the answer as incomplete in case the budget
+// has been exceeded in any of the stages
+func finishBudget(
+	corpusID string,
+	bgt *budget.Budget,
+	ans *response.QueryAns,
+) *response.QueryAns {
+	exceeded := bgt.Exceeded()
+	if len(exceeded) == 0 {
+		return ans
+	}
+	log.Warn().
+		Str("corpusId", corpusID).
+		Strs("stages", exceeded).
+		Msg("time budget of liveattrs query exceeded, returning partial results")
+	return ans.WithTimeoutStages(exceeded)
+}
+
+func (a *Actions) Query(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to query liveattrs in corpus %s: %w"
+	format, ok := getExportFormat(ctx)
+	if !ok {
+		return
+	}
+	var qry query.Payload
+	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := qry.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
-	ans, err = a.getAttrValues(corpInfo, qry)
+	bgt := a.conf.Budgets.NewBudget(ctx.FullPath())
+	ans, err := a.runQuery(ctx.Request.Context(), bgt, corpusID, qry)
 	if err == laconf.ErrorNoSuchConfig {
 		log.Error().Err(err).Msgf("configuration not found for %s", corpusID)
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
 		return
 
+	} else if errors.Is(err, ErrorInvalidSubcorpus) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+
+	} else if errors.Is(err, ErrorTimeBudgetExceeded) {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to query liveattrs")
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusGatewayTimeout)
+		return
+
 	} else if err != nil {
 		log.Error().Err(err).Msg("")
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+		return
+	}
+	postCtx, cancel := bgt.Start(ctx.Request.Context(), cnf.BudgetStagePost)
+	defer cancel()
+	if !qry.IncludeDocCounts {
+		ans = ans.WithoutDocCounts()
+	}
+	ans, err = a.applyValueFilters(ctx, corpusID, ans)
+	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	usageEntry.ProcTime = time.Since(t0)
-	a.usageData <- usageEntry
-	a.eqCache.Set(corpusID, qry, ans)
+	ans = ans.WithTransforms(qry.Transforms)
+	if postCtx.Err() != nil {
+		bgt.MarkExceeded(cnf.BudgetStagePost)
+	}
+	ans = finishBudget(corpusID, bgt, ans)
+	if format != export.FormatJSON {
+		writeExportedTable(ctx, format, corpusID, queryAnsToTable(ans))
+		return
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
@@ -379,7 +907,19 @@ func (a *Actions) FillAttrs(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	ans, err := db.FillAttrs(a.laDB, corpusDBInfo, qry)
+	if err := checkSubcorpus(corpusDBInfo, qry.Subcorpus); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	var attrTypes laconf.AttrTypes
+	if qry.Subcorpus != nil {
+		attrTypes, err = a.laConfCache.GetAttrTypes(corpusID)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+	}
+	ans, err := db.FillAttrs(a.laDB, corpusDBInfo, qry, attrTypes)
 	if err == db.ErrorEmptyResult {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
 		return
@@ -401,18 +941,48 @@ func (a *Actions) GetAdhocSubcSize(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	if err := qry.Unit.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
 	corpora := append([]string{corpusID}, qry.Aligned...)
 	corpusDBInfo, err := a.cncDB.LoadInfo(corpusID)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	size, err := db.GetSubcSize(a.laDB, corpusDBInfo, corpora, qry.Attrs)
+	attrTypes, err := a.laConfCache.GetAttrTypes(corpusID)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, response.GetSubcSize{Total: size})
+	sizes, err := db.GetSubcSize(a.laDB, corpusDBInfo, corpora, qry.Attrs, attrTypes, qry.Unit)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+		return
+	}
+	ans := response.GetSubcSize{Total: sizes[0], Unit: string(qry.Unit.Normalized())}
+	if len(corpora) > 1 {
+		ans.Breakdown = make(map[string]int)
+		for i, corp := range corpora {
+			ans.Breakdown[corp] = sizes[i]
+		}
+	}
+	timingConf, err := a.laConfCache.GetTimingConf(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if timingConf.IsEnabled() {
+		duration, err := db.GetSubcDuration(
+			a.laDB, corpusDBInfo, corpora, qry.Attrs, attrTypes, timingConf.GetDurationAttr())
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+			return
+		}
+		ans.Duration = &duration
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
 func (a *Actions) AttrValAutocomplete(ctx *gin.Context) {
@@ -425,16 +995,51 @@ func (a *Actions) AttrValAutocomplete(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
-	corpInfo, err := a.cncDB.LoadInfo(corpusID)
-	if err != nil {
+	if err := qry.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	bgt := a.conf.Budgets.NewBudget(ctx.FullPath())
+	cncCtx, cancel := bgt.Start(ctx.Request.Context(), cnf.BudgetStageCNCDB)
+	corpInfo, err := a.cncDB.LoadInfoContext(cncCtx, corpusID)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w (stage %s)", ErrorTimeBudgetExceeded, cnf.BudgetStageCNCDB)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusGatewayTimeout)
+		return
+
+	} else if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	ans, err := a.getAttrValues(corpInfo, qry)
+	laCtx, cancel := bgt.Start(ctx.Request.Context(), cnf.BudgetStageLADB)
+	defer cancel()
+	ans, err := a.getAttrValues(laCtx, corpInfo, qry)
+	if errors.Is(err, ErrorInvalidSubcorpus) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), queryErrorStatus(err))
+		return
+	}
+	if ans.Timeout {
+		bgt.MarkExceeded(cnf.BudgetStageLADB)
+	}
+	postCtx, cancel := bgt.Start(ctx.Request.Context(), cnf.BudgetStagePost)
+	defer cancel()
+	if !qry.IncludeDocCounts {
+		ans = ans.WithoutDocCounts()
+	}
+	ans, err = a.applyValueFilters(ctx, corpusID, ans)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	if postCtx.Err() != nil {
+		bgt.MarkExceeded(cnf.BudgetStagePost)
+	}
+	ans = finishBudget(corpusID, bgt, ans)
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
@@ -499,6 +1104,7 @@ func (a *Actions) UpdateIndexes(ctx *gin.Context) {
 		Args:     liveattrs.IdxJobInfoArgs{MaxColumns: maxColumns},
 	}
 	a.updateIndexesFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
 	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, &newStatus)
 }
 
@@ -507,11 +1113,25 @@ func (a *Actions) RestartLiveAttrsJob(jinfo *liveattrs.LiveAttrsJobInfo) error {
 	if err != nil {
 		return err
 	}
+	if jinfo.Checkpoint != nil {
+		if err := jinfo.Checkpoint.Validate(jinfo.Args.VteConf.GetDefinedVerticals()); err != nil {
+			log.Warn().Err(err).Str("jobId", jinfo.ID).Msg("cannot resume liveAttributes job, starting from scratch")
+			jinfo.Checkpoint = nil
+		}
+	}
 	jinfo.Start = jobs.CurrentDatetime()
 	jinfo.NumRestarts++
 	jinfo.Update = jobs.CurrentDatetime()
 	a.createDataFromJobStatus(jinfo)
-	log.Info().Msgf("Restarted liveAttributes job %s", jinfo.ID)
+	if jinfo.Checkpoint != nil {
+		log.Info().
+			Int("verticalIdx", jinfo.Checkpoint.VerticalIdx).
+			Int("lineOffset", jinfo.Checkpoint.LineOffset).
+			Msgf("Restarted liveAttributes job %s from a checkpoint", jinfo.ID)
+
+	} else {
+		log.Info().Msgf("Restarted liveAttributes job %s", jinfo.ID)
+	}
 	return nil
 }
 
@@ -519,6 +1139,14 @@ func (a *Actions) RestartIdxUpdateJob(jinfo *liveattrs.IdxUpdateJobInfo) error {
 	return nil
 }
 
+// structCoverageFn provides coverage of corpus positions
+// by structures as needed for atom structure inference
+func (a *Actions) structCoverageFn(corpusID string) laconf.StructCoverageFn {
+	return func(structs []string) (map[string]float64, error) {
+		return corpus.GetStructCoverage(corpusID, a.conf.Corp, structs)
+	}
+}
+
 func (a *Actions) InferredAtomStructure(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 
@@ -531,12 +1159,21 @@ func (a *Actions) InferredAtomStructure(ctx *gin.Context) {
 		return
 	}
 
-	ans := map[string]any{"structure": nil}
-	if len(conf.Structures) == 1 {
-		for k := range conf.Structures {
-			ans["structure"] = k
-			break
-		}
+	ans := map[string]any{"structure": nil, "strategy": a.conf.LA.AtomInference.Strategy}
+	atom, err := laconf.InferAtomStructure(
+		laconf.SortedStructNames(conf.Structures),
+		a.conf.LA.AtomInference,
+		a.structCoverageFn(corpusID),
+	)
+	if err == nil {
+		ans["structure"] = atom
+
+	} else if err != laconf.ErrorAtomNotInferable {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to get inferred atom structure: %w", err),
+			http.StatusInternalServerError,
+		)
+		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
@@ -549,14 +1186,19 @@ func NewActions(
 	jobActions *jobs.Actions,
 	cncDB *cncdb.CNCMySQLHandler,
 	laDB *sql.DB,
+	quotas *quota.Tracker,
 	version general.VersionInfo,
 ) *Actions {
 	usageChan := make(chan db.RequestData)
 	vteExitEvents := make(map[string]chan os.Signal)
 	go func() {
 		for v := range exitEvent {
-			for _, ch := range vteExitEvents {
-				ch <- v
+			for jobID, ch := range vteExitEvents {
+				jobActions.ReleasePauseGate(jobID)
+				select {
+				case ch <- v:
+				default: // the job has a pending stop signal already
+				}
 			}
 		}
 	}()
@@ -570,13 +1212,30 @@ func NewActions(
 			conf.LA.ConfDirPath,
 			conf.LA.DB,
 		),
-		cncDB:           cncDB,
-		laDB:            laDB,
-		eqCache:         cache.NewEmptyQueryCache(),
-		structAttrStats: db.NewStructAttrUsage(laDB, usageChan),
-		usageData:       usageChan,
+		cncDB:                cncDB,
+		laDB:                 laDB,
+		quotas:               quotas,
+		eqCache:              cache.NewEmptyQueryCache(),
+		progress:             newProgressBroker(),
+		facetIndexes:         facetidx.NewStore(conf.LA.FacetIndexDirPath),
+		docSpool:             spool.NewSpool(conf.LA.DocumentListSpool),
+		confirmations:        confirm.NewRegistry(confirmationTokenTTL),
+		pendingJobs:          newPendingJobs(),
+		qualityReports:       collections.NewConcurrentMap[string, *liveattrs.QualityReport](),
+		structAttrStats:      db.NewStructAttrUsage(laDB, usageChan),
+		usageData:            usageChan,
+		kontextNotified:      collections.NewConcurrentMap[string, time.Time](),
+		textTypesDbChecksums: newFileChecksums(),
+	}
+	gqlSchema, err := actions.newGraphQLSchema()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create liveattrs GraphQL schema")
 	}
+	actions.gqlSchema = gqlSchema
 	go actions.structAttrStats.RunHandler()
 	go actions.runStopJobListener()
+	if actions.docSpool.Enabled() {
+		go actions.docSpool.RunCleanup()
+	}
 	return actions
 }
@@ -0,0 +1,144 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/general/quota"
+	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/request/response"
+	"net/http"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// multiQueryItem is a result of a single corpus query
+// within a multi-corpus query
+type multiQueryItem struct {
+	Result *response.QueryAns `json:"result,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+type multiQueryJob struct {
+	corpusID string
+	qry      query.Payload
+}
+
+// multiQueryNumWorkers determines number of workers for processing
+// numCorpora corpora. There is always at least one worker.
+func multiQueryNumWorkers(maxWorkers, numCorpora int) int {
+	ans := maxWorkers
+	if ans > numCorpora {
+		ans = numCorpora
+	}
+	if ans < 1 {
+		ans = 1
+	}
+	return ans
+}
+
+// MultiQuery runs liveattrs queries for multiple corpora at once.
+// The request body is a map corpusID => query.Payload. Individual
+// queries run concurrently with the number of workers limited
+// by `liveAttrs.multiQueryMaxWorkers`. The number of corpora
+// in a single request is limited by `liveAttrs.multiQueryMaxCorpora`.
+// A failure of a query does not affect other queries - it is reported
+// in the respective result item. Each corpus is charged against
+// the `query` quota (a corpus with the quota exceeded is reported
+// as a failed item).
+func (a *Actions) MultiQuery(ctx *gin.Context) {
+	var qry map[string]query.Payload
+	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to run multi-corpus query: %w", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if len(qry) == 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to run multi-corpus query: %w", fmt.Errorf("no corpora specified")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if len(qry) > a.conf.LA.MultiQueryMaxCorpora {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				"failed to run multi-corpus query: %w",
+				fmt.Errorf("too many corpora (max. %d)", a.conf.LA.MultiQueryMaxCorpora),
+			),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	// each of the corpora is charged separately (as if queried via `query`)
+	reqCtx := a.quotas.WithRequestToken(ctx.Request.Context(), ctx.Request)
+	numWorkers := multiQueryNumWorkers(a.conf.LA.MultiQueryMaxWorkers, len(qry))
+	jobs := make(chan multiQueryJob)
+	ans := make(map[string]multiQueryItem)
+	var ansLock sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				var item multiQueryItem
+				if err := a.quotas.Charge(reqCtx, job.corpusID, quota.OpQuery); err != nil {
+					item.Error = err.Error()
+					ansLock.Lock()
+					ans[job.corpusID] = item
+					ansLock.Unlock()
+					continue
+				}
+				res, err := a.runQuery(reqCtx, nil, job.corpusID, job.qry)
+				if err != nil {
+					log.Error().Err(err).Str("corpusId", job.corpusID).Msg("failed to run liveattrs query")
+					item.Error = err.Error()
+
+				} else if !job.qry.IncludeDocCounts {
+					item.Result = res.WithoutDocCounts().WithTransforms(job.qry.Transforms)
+
+				} else {
+					item.Result = res.WithTransforms(job.qry.Transforms)
+				}
+				ansLock.Lock()
+				ans[job.corpusID] = item
+				ansLock.Unlock()
+			}
+		}()
+	}
+	for corpusID, corpQry := range qry {
+		jobs <- multiQueryJob{corpusID: corpusID, qry: corpQry}
+	}
+	close(jobs)
+	wg.Wait()
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
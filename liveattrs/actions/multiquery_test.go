@@ -0,0 +1,86 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/general/quota"
+	"masm/v3/liveattrs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func runMultiQuery(t *testing.T, body string) *httptest.ResponseRecorder {
+	return runMultiQueryWithQuotas(t, body, nil)
+}
+
+func runMultiQueryWithQuotas(t *testing.T, body string, quotas *quota.Tracker) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	a := &Actions{
+		conf: LAConf{
+			LA: &liveattrs.Conf{
+				MultiQueryMaxWorkers: 2,
+				MultiQueryMaxCorpora: 2,
+			},
+		},
+		quotas: quotas,
+	}
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(
+		http.MethodPost, "/liveAttributes/_multiQuery", strings.NewReader(body))
+	a.MultiQuery(ctx)
+	return rec
+}
+
+func TestMultiQueryInvalidBody(t *testing.T) {
+	rec := runMultiQuery(t, "[1, 2")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMultiQueryNoCorpora(t *testing.T) {
+	rec := runMultiQuery(t, "{}")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no corpora specified")
+}
+
+func TestMultiQueryTooManyCorpora(t *testing.T) {
+	rec := runMultiQuery(t, `{"corp1": {}, "corp2": {}, "corp3": {}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "too many corpora")
+}
+
+func TestMultiQueryQuotaExceeded(t *testing.T) {
+	tracker := quota.NewTracker(&quota.Conf{Limits: map[string]int{quota.OpQuery: 1}}, nil)
+	tracker.Take(quota.AnonymousToken, "corp1", quota.OpQuery)
+	rec := runMultiQueryWithQuotas(t, `{"corp1": {}}`, tracker)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "daily quota exceeded")
+}
+
+func TestMultiQueryNumWorkers(t *testing.T) {
+	assert.Equal(t, 2, multiQueryNumWorkers(4, 2))
+	assert.Equal(t, 4, multiQueryNumWorkers(4, 10))
+	assert.Equal(t, 1, multiQueryNumWorkers(0, 3))
+	assert.Equal(t, 1, multiQueryNumWorkers(-3, 3))
+}
@@ -53,6 +53,10 @@ type reqArgs struct {
 	// in the data extraction configuration ("vertColumns" section).
 	PosColIdx int                `json:"posColIdx"` // TODO do we need this?
 	PosTagset qs.SupportedTagset `json:"posTagset"`
+
+	// NgramOptions filter n-grams imported to the frequency
+	// database (n-gram sizes, min. frequency, PoS lists)
+	freqdb.NgramOptions
 }
 
 func (args reqArgs) Validate() error {
@@ -142,6 +146,14 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	if err := args.NgramOptions.Validate(laConf.Ngrams.NgramSize); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, err),
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
 	posFn, err := applyPosProperties(laConf, args.PosColIdx, args.PosTagset)
 	if err == errorPosNotDefined {
 		uniresp.WriteJSONErrorResponse(
@@ -177,6 +189,7 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 		corpusDBInfo.Name,
 		posFn,
 		*args.ColMapping,
+		args.NgramOptions,
 	)
 	jobInfo, err := generator.GenerateAfter(corpusID, ctx.Request.URL.Query().Get("parentJobId"))
 	if err != nil {
@@ -184,5 +197,6 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	a.jobActions.AttachRequest(ctx, jobInfo.GetID())
 	uniresp.WriteJSONResponse(ctx.Writer, jobInfo.FullInfo())
 }
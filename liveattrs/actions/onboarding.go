@@ -0,0 +1,116 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db"
+	"time"
+)
+
+func (a *Actions) checkLiveAttrs(corpusID string, corpusInfo *corpus.DBInfo) corpus.OnboardingItem {
+	ans := corpus.OnboardingItem{Name: "liveattrsBuilt"}
+	if corpusInfo == nil {
+		ans.Details = "cannot determine without corpus database record"
+		return ans
+	}
+	if _, err := a.laConfCache.Get(corpusID); err != nil {
+		ans.Details = "liveattrs configuration not found"
+		return ans
+	}
+	tableName := fmt.Sprintf("%s_liveattrs_entry", corpusInfo.GroupedName())
+	exists, err := db.TableExists(a.laDB, tableName)
+	if err != nil {
+		ans.Details = err.Error()
+		return ans
+	}
+	ans.OK = exists
+	if !exists {
+		ans.Details = fmt.Sprintf("table %s not found", tableName)
+	}
+	return ans
+}
+
+func (a *Actions) checkDefaults(corpusID string) corpus.OnboardingItem {
+	ans := corpus.OnboardingItem{Name: "defaultsInferred"}
+	viewOpts, err := a.cncDB.GetDefaultViewOpts(corpusID)
+	if err != nil {
+		ans.Details = err.Error()
+		return ans
+	}
+	ans.OK = len(viewOpts.Attrs) > 0
+	if !ans.OK {
+		ans.Details = "default view options not set"
+	}
+	return ans
+}
+
+func (a *Actions) checkNgrams(corpusInfo *corpus.DBInfo) corpus.OnboardingItem {
+	ans := corpus.OnboardingItem{Name: "ngramsPresent"}
+	if corpusInfo == nil {
+		ans.Details = "cannot determine without corpus database record"
+		return ans
+	}
+	exists, err := db.TableExists(a.laDB, fmt.Sprintf("%s_word", corpusInfo.GroupedName()))
+	if err != nil {
+		ans.Details = err.Error()
+		return ans
+	}
+	if exists {
+		ans.OK = true
+		return ans
+	}
+	exists, err = db.TableExists(a.laDB, fmt.Sprintf("%s_colcounts", corpusInfo.GroupedName()))
+	if err != nil {
+		ans.Details = err.Error()
+
+	} else if exists {
+		ans.Details = "n-grams extracted but the frequency database has not been generated"
+
+	} else {
+		ans.Details = "n-grams not extracted"
+	}
+	return ans
+}
+
+func (a *Actions) checkKontextNotified(corpusID string) corpus.OnboardingItem {
+	ans := corpus.OnboardingItem{Name: "kontextNotified"}
+	notified, ok := a.kontextNotified.GetWithTest(corpusID)
+	if !ok {
+		ans.Details = "no notification sent since masm start"
+		return ans
+	}
+	ans.OK = true
+	ans.Details = notified.Format(time.RFC3339)
+	return ans
+}
+
+// OnboardingItems provides statuses of liveattrs and KonText related
+// corpus onboarding steps (see corpus.OnboardingChecker).
+// Please note that KonText notifications are tracked only in memory
+// so a masm restart resets the respective item.
+func (a *Actions) OnboardingItems(corpusID string, corpusInfo *corpus.DBInfo) []corpus.OnboardingItem {
+	return []corpus.OnboardingItem{
+		a.checkLiveAttrs(corpusID, corpusInfo),
+		a.checkDefaults(corpusID),
+		a.checkNgrams(corpusInfo),
+		a.checkKontextNotified(corpusID),
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"net/http"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// pendingJobs stores accepted liveattrs jobs waiting for
+// a running job of the same corpus to finish. Jobs of a corpus
+// are started in the order they were accepted.
+type pendingJobs struct {
+	data map[string][]*liveattrs.LiveAttrsJobInfo
+	lock sync.Mutex
+}
+
+func (pj *pendingJobs) add(job *liveattrs.LiveAttrsJobInfo) {
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	pj.data[job.CorpusID] = append(pj.data[job.CorpusID], job)
+}
+
+// pop removes and returns the oldest pending job of a corpus
+func (pj *pendingJobs) pop(corpusID string) (*liveattrs.LiveAttrsJobInfo, bool) {
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	items := pj.data[corpusID]
+	if len(items) == 0 {
+		return nil, false
+	}
+	ans := items[0]
+	if len(items) == 1 {
+		delete(pj.data, corpusID)
+
+	} else {
+		pj.data[corpusID] = items[1:]
+	}
+	return ans, true
+}
+
+func (pj *pendingJobs) list(corpusID string) []*liveattrs.LiveAttrsJobInfo {
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	ans := make([]*liveattrs.LiveAttrsJobInfo, len(pj.data[corpusID]))
+	copy(ans, pj.data[corpusID])
+	return ans
+}
+
+func (pj *pendingJobs) remove(corpusID, jobID string) (*liveattrs.LiveAttrsJobInfo, bool) {
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	items := pj.data[corpusID]
+	for i, item := range items {
+		if item.ID == jobID {
+			items = append(items[:i:i], items[i+1:]...)
+			if len(items) == 0 {
+				delete(pj.data, corpusID)
+
+			} else {
+				pj.data[corpusID] = items
+			}
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+func newPendingJobs() *pendingJobs {
+	return &pendingJobs{
+		data: make(map[string][]*liveattrs.LiveAttrsJobInfo),
+	}
+}
+
+// startPendingJob starts the oldest pending job of a corpus (if any).
+// It is expected to be called once a liveattrs job of the corpus finishes.
+func (a *Actions) startPendingJob(corpusID string) {
+	job, ok := a.pendingJobs.pop(corpusID)
+	if !ok {
+		return
+	}
+	job.Pending = false
+	job.Start = jobs.CurrentDatetime()
+	job.Update = jobs.CurrentDatetime()
+	a.createDataFromJobStatus(job)
+	log.Info().Str("corpusId", corpusID).Msgf("Started pending liveAttributes job %s", job.ID)
+}
+
+// PendingJobs lists liveattrs jobs of a corpus accepted in the `enqueue`
+// mode and waiting for a running job to finish
+func (a *Actions) PendingJobs(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	items := a.pendingJobs.list(corpusID)
+	ans := make([]any, len(items))
+	for i, item := range items {
+		ans[i] = item.FullInfo()
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// CancelPendingJob removes a pending job so it will not be started
+func (a *Actions) CancelPendingJob(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	job, ok := a.pendingJobs.remove(corpusID, ctx.Param("jobId"))
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(
+				"failed to cancel pending job for %s: %w", corpusID, fmt.Errorf("job not found")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, job.FullInfo())
+}
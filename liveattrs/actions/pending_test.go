@@ -0,0 +1,67 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingJobsOrder(t *testing.T) {
+	pj := newPendingJobs()
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job1", CorpusID: "syn2020"})
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job2", CorpusID: "syn2020"})
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job3", CorpusID: "intercorp_v13_en"})
+	assert.Len(t, pj.list("syn2020"), 2)
+
+	job, ok := pj.pop("syn2020")
+	assert.True(t, ok)
+	assert.Equal(t, "job1", job.ID)
+	job, ok = pj.pop("syn2020")
+	assert.True(t, ok)
+	assert.Equal(t, "job2", job.ID)
+	_, ok = pj.pop("syn2020")
+	assert.False(t, ok)
+	assert.Len(t, pj.list("intercorp_v13_en"), 1)
+}
+
+func TestPendingJobsRemove(t *testing.T) {
+	pj := newPendingJobs()
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job1", CorpusID: "syn2020"})
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job2", CorpusID: "syn2020"})
+	pj.add(&liveattrs.LiveAttrsJobInfo{ID: "job3", CorpusID: "syn2020"})
+
+	_, ok := pj.remove("syn2020", "job4")
+	assert.False(t, ok)
+	_, ok = pj.remove("intercorp_v13_en", "job1")
+	assert.False(t, ok)
+	job, ok := pj.remove("syn2020", "job2")
+	assert.True(t, ok)
+	assert.Equal(t, "job2", job.ID)
+
+	items := pj.list("syn2020")
+	assert.Len(t, items, 2)
+	assert.Equal(t, "job1", items[0].ID)
+	assert.Equal(t, "job3", items[1].ID)
+	pj.remove("syn2020", "job1")
+	pj.remove("syn2020", "job3")
+	assert.Len(t, pj.data, 0)
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"net/http"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	progressSubscriberBufferSize = 10
+)
+
+// jobProgress is a progress report sent to clients
+// watching a running liveattrs data job
+type jobProgress struct {
+	JobID             string        `json:"jobId"`
+	ProcessedLines    int           `json:"processedLines"`
+	ProcessedAtoms    int           `json:"processedAtoms"`
+	EstimatedNumLines int           `json:"estimatedNumLines"`
+	LinesPerSecond    float64       `json:"linesPerSecond"`
+	EstimatedEnd      jobs.JSONTime `json:"estimatedEnd"`
+	Finished          bool          `json:"finished"`
+}
+
+func newJobProgress(status liveattrs.LiveAttrsJobInfo) jobProgress {
+	return jobProgress{
+		JobID:             status.ID,
+		ProcessedLines:    status.ProcessedLines,
+		ProcessedAtoms:    status.ProcessedAtoms,
+		EstimatedNumLines: status.EstimatedNumLines,
+		LinesPerSecond:    status.LinesPerSecond,
+		EstimatedEnd:      status.EstimatedEnd,
+		Finished:          status.Finished,
+	}
+}
+
+// progressBroker distributes intermediate statuses of running
+// liveattrs jobs to subscribed clients. Slow subscribers may miss
+// some intermediate values but they are always notified about
+// a job end (by closing their channel).
+type progressBroker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan jobProgress]struct{}
+}
+
+func (pb *progressBroker) subscribe(jobID string) chan jobProgress {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	ch := make(chan jobProgress, progressSubscriberBufferSize)
+	if _, ok := pb.subs[jobID]; !ok {
+		pb.subs[jobID] = make(map[chan jobProgress]struct{})
+	}
+	pb.subs[jobID][ch] = struct{}{}
+	return ch
+}
+
+func (pb *progressBroker) unsubscribe(jobID string, ch chan jobProgress) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if _, ok := pb.subs[jobID][ch]; ok {
+		delete(pb.subs[jobID], ch)
+		close(ch)
+	}
+	if len(pb.subs[jobID]) == 0 {
+		delete(pb.subs, jobID)
+	}
+}
+
+func (pb *progressBroker) publish(status liveattrs.LiveAttrsJobInfo) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for ch := range pb.subs[status.ID] {
+		select {
+		case ch <- newJobProgress(status):
+		default:
+		}
+	}
+}
+
+// finish closes all the subscriptions of a job
+func (pb *progressBroker) finish(jobID string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for ch := range pb.subs[jobID] {
+		close(ch)
+	}
+	delete(pb.subs, jobID)
+}
+
+func newProgressBroker() *progressBroker {
+	return &progressBroker{
+		subs: make(map[string]map[chan jobProgress]struct{}),
+	}
+}
+
+// DataProgress streams progress (processed lines and atoms) of
+// a currently running liveattrs data job of a corpus using
+// Server-Sent Events. The stream ends with a `finished` event
+// once the job ends or when the client disconnects.
+func (a *Actions) DataProgress(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to watch liveattrs data progress for %s: %w"
+	job, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType)
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("no running job found")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	var lastProgress jobProgress
+	switch tJob := job.(type) {
+	case *liveattrs.LiveAttrsJobInfo:
+		lastProgress = newJobProgress(*tJob)
+	case liveattrs.LiveAttrsJobInfo:
+		lastProgress = newJobProgress(tJob)
+	default:
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid job type")),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	updates := a.progress.subscribe(job.GetID())
+	defer a.progress.unsubscribe(job.GetID(), updates)
+
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.SSEvent("progress", lastProgress)
+	ctx.Writer.Flush()
+	for {
+		select {
+		case upd, ok := <-updates:
+			if !ok {
+				lastProgress.Finished = true
+				ctx.SSEvent("finished", lastProgress)
+				ctx.Writer.Flush()
+				return
+			}
+			lastProgress = upd
+			ctx.SSEvent("progress", upd)
+			ctx.Writer.Flush()
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
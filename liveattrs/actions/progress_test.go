@@ -0,0 +1,66 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressBrokerPublish(t *testing.T) {
+	pb := newProgressBroker()
+	ch := pb.subscribe("job1")
+	other := pb.subscribe("job2")
+	pb.publish(liveattrs.LiveAttrsJobInfo{ID: "job1", ProcessedLines: 100, ProcessedAtoms: 3})
+	upd := <-ch
+	assert.Equal(t, jobProgress{JobID: "job1", ProcessedLines: 100, ProcessedAtoms: 3}, upd)
+	assert.Len(t, other, 0)
+}
+
+func TestProgressBrokerDropsOnFullBuffer(t *testing.T) {
+	pb := newProgressBroker()
+	ch := pb.subscribe("job1")
+	for i := 0; i < progressSubscriberBufferSize+5; i++ {
+		pb.publish(liveattrs.LiveAttrsJobInfo{ID: "job1", ProcessedLines: i})
+	}
+	assert.Len(t, ch, progressSubscriberBufferSize)
+}
+
+func TestProgressBrokerFinish(t *testing.T) {
+	pb := newProgressBroker()
+	ch := pb.subscribe("job1")
+	pb.finish("job1")
+	_, ok := <-ch
+	assert.False(t, ok)
+	// unsubscribing after finish must not close the channel again
+	pb.unsubscribe("job1", ch)
+	assert.Len(t, pb.subs, 0)
+}
+
+func TestProgressBrokerUnsubscribe(t *testing.T) {
+	pb := newProgressBroker()
+	ch := pb.subscribe("job1")
+	pb.unsubscribe("job1", ch)
+	pb.publish(liveattrs.LiveAttrsJobInfo{ID: "job1", ProcessedLines: 10})
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Len(t, pb.subs, 0)
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"sort"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// dfltQualityPlaceholders are values typically used in metadata
+// to express an unknown value
+var dfltQualityPlaceholders = []string{"?", "-", "N/A", "unknown"}
+
+func (a *Actions) qualityReportFromJobStatus(status *liveattrs.QualityJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		report, err := a.createQualityReport(status.CorpusID, status.Args.Placeholders)
+		if err != nil {
+			finalStatus.Error = err
+
+		} else {
+			a.qualityReports.Set(status.CorpusID, report)
+		}
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+func (a *Actions) createQualityReport(corpusID string, placeholders []string) (*liveattrs.QualityReport, error) {
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	attrs := laconf.GetSubcorpAttrs(laConf)
+	sort.Strings(attrs)
+	return db.GetAttrQuality(a.laDB, corpInfo, attrs, placeholders)
+}
+
+// invalidateQualityReport removes a cached quality report
+// of a corpus (e.g. once its data change)
+func (a *Actions) invalidateQualityReport(corpusID string) {
+	a.qualityReports.Delete(corpusID)
+}
+
+// Quality returns data quality metrics (fill rate, share of placeholder
+// values, cardinality, suspicious values) of structural attributes
+// of a corpus. The metrics are calculated by a job and cached. In case
+// there is no cached report (or `refresh=1` is passed), the job is
+// started and its status is returned instead.
+func (a *Actions) Quality(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get data quality of corpus %s: %w"
+	if ctx.Request.URL.Query().Get("refresh") != "1" {
+		if report, ok := a.qualityReports.GetWithTest(corpusID); ok {
+			uniresp.WriteJSONResponse(ctx.Writer, report)
+			return
+		}
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.QualityJobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	_, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	placeholders := ctx.Request.URL.Query()["placeholder"]
+	if len(placeholders) == 0 {
+		placeholders = dfltQualityPlaceholders
+	}
+	for _, p := range placeholders {
+		if p == "" {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("empty placeholder")),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := liveattrs.QualityJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.QualityJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     liveattrs.QualityJobInfoArgs{Placeholders: placeholders},
+	}
+	a.qualityReportFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+func (a *Actions) RestartQualityJob(jinfo *liveattrs.QualityJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.qualityReportFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted data quality job %s", jinfo.ID)
+	return nil
+}
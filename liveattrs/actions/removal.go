@@ -0,0 +1,134 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"os"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	removedItemLiveAttrsTable  = "liveattrsTable"
+	removedItemLiveAttrsConfig = "liveattrsConfig"
+	removedItemTextTypesDb     = "textTypesDb"
+	removedItemValueCounts     = "valueCounts"
+)
+
+// CorpusRemovalPlan implements corpus.CorpusDataRemover
+func (a *Actions) CorpusRemovalPlan(
+	corpusID string, corpusInfo *corpus.DBInfo, opts corpus.RemovalOptions,
+) []corpus.RemovedItem {
+	ans := make([]corpus.RemovedItem, 0, 3)
+	if corpusInfo != nil {
+		item := corpus.RemovedItem{
+			Kind:   removedItemLiveAttrsTable,
+			Target: fmt.Sprintf("%s_liveattrs_entry", corpusInfo.GroupedName()),
+		}
+		summary, err := a.createDeletionSummary(corpusInfo)
+		if err != nil {
+			// typically, there are no liveattrs data for the corpus
+			log.Debug().Err(err).Str("corpusId", corpusID).Msg("no liveattrs data to remove")
+
+		} else {
+			item.Details = fmt.Sprintf(
+				"rows: %d, drop table: %t, files: %d, ingested verticals: %d",
+				summary.NumRows, summary.DropTable, len(summary.Files), summary.NumIngestedVerticals,
+			)
+			ans = append(ans, item)
+		}
+	}
+	if a.conf.LA.DB.Type == "mysql" {
+		snapshots, err := db.GetValueCountsSnapshots(a.laDB, corpusID)
+		if err != nil {
+			ans = append(
+				ans, corpus.RemovedItem{Kind: removedItemValueCounts, Target: corpusID, Error: err.Error()})
+
+		} else if len(snapshots) > 0 {
+			ans = append(
+				ans,
+				corpus.RemovedItem{
+					Kind:    removedItemValueCounts,
+					Target:  corpusID,
+					Details: fmt.Sprintf("snapshots: %d", len(snapshots)),
+				},
+			)
+		}
+	}
+	if _, err := a.laConfCache.Get(corpusID); err == nil {
+		ans = append(ans, corpus.RemovedItem{Kind: removedItemLiveAttrsConfig, Target: corpusID})
+
+	} else if err != laconf.ErrorNoSuchConfig {
+		ans = append(
+			ans, corpus.RemovedItem{Kind: removedItemLiveAttrsConfig, Target: corpusID, Error: err.Error()})
+	}
+	if a.conf.LA.TextTypesDbDirPath != "" {
+		if path, err := a.textTypesDbPath(corpusID); err == nil {
+			if isFile, _ := fs.IsFile(path); isFile {
+				ans = append(ans, corpus.RemovedItem{Kind: removedItemTextTypesDb, Target: path})
+			}
+		}
+	}
+	return ans
+}
+
+// RemoveCorpusData implements corpus.CorpusDataRemover. Besides
+// the data removed by the Delete action, it also removes the liveattrs
+// configuration including the auxiliary configuration files (a backup
+// of all of them is kept), value counts snapshots and the SQLite text
+// types database.
+func (a *Actions) RemoveCorpusData(
+	corpusID string, corpusInfo *corpus.DBInfo, opts corpus.RemovalOptions,
+) []corpus.RemovedItem {
+	ans := a.CorpusRemovalPlan(corpusID, corpusInfo, opts)
+	for i, item := range ans {
+		if item.Error != "" {
+			continue
+		}
+		var err error
+		switch item.Kind {
+		case removedItemLiveAttrsTable:
+			err = a.deleteCorpusData(corpusInfo)
+		case removedItemValueCounts:
+			err = db.DeleteValueCountsSnapshots(a.laDB, corpusID)
+		case removedItemLiveAttrsConfig:
+			err = a.laConfCache.Clear(corpusID)
+		case removedItemTextTypesDb:
+			err = os.Remove(item.Target)
+			a.textTypesDbChecksums.remove(item.Target)
+		}
+		if err != nil {
+			ans[i].Error = err.Error()
+
+		} else {
+			ans[i].Removed = true
+		}
+	}
+	if len(ans) > 0 {
+		if err := a.notifyKontext(corpusID); err != nil {
+			log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to notify KonText about removed corpus")
+		}
+	}
+	return ans
+}
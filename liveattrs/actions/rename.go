@@ -0,0 +1,127 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/db/ttdb"
+	"masm/v3/liveattrs/laconf"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	renameStepLiveAttrsTables = "liveattrsTables"
+	renameStepLiveAttrsConfig = "liveattrsConfig"
+	renameStepTextTypesDb     = "textTypesDb"
+	renameStepFacetIndex      = "facetIndex"
+)
+
+// CorpusRenamePlan implements corpus.CorpusRenamer
+func (a *Actions) CorpusRenamePlan(corpusID, newID string, corpusInfo *corpus.DBInfo) []corpus.RenameStep {
+	ans := make([]corpus.RenameStep, 0, 4)
+	if corpusInfo != nil && a.conf.LA.DB.Type == "mysql" {
+		groupedName := corpusInfo.GroupedName()
+		tables, err := db.ExistingCorpusTables(a.laDB, groupedName)
+		if err != nil {
+			ans = append(
+				ans,
+				corpus.RenameStep{Kind: renameStepLiveAttrsTables, Source: groupedName, Error: err.Error()},
+			)
+
+		} else if len(tables) > 0 {
+			step := corpus.RenameStep{
+				Kind:    renameStepLiveAttrsTables,
+				Source:  groupedName,
+				Target:  groupedName,
+				Details: fmt.Sprintf("corpus_id changed in %d table(s)", len(tables)),
+			}
+			if groupedName == corpusID {
+				step.Target = newID
+				step.Details = fmt.Sprintf("%d table(s) renamed, corpus_id changed", len(tables))
+			}
+			ans = append(ans, step)
+		}
+	}
+	if _, err := a.laConfCache.Get(corpusID); err == nil {
+		ans = append(ans, corpus.RenameStep{Kind: renameStepLiveAttrsConfig, Source: corpusID, Target: newID})
+
+	} else if err != laconf.ErrorNoSuchConfig {
+		ans = append(
+			ans,
+			corpus.RenameStep{Kind: renameStepLiveAttrsConfig, Source: corpusID, Target: newID, Error: err.Error()},
+		)
+	}
+	if a.conf.LA.TextTypesDbDirPath != "" {
+		path, err := a.textTypesDbPath(corpusID)
+		newPath, err2 := a.textTypesDbPath(newID)
+		if err == nil && err2 == nil {
+			if isFile, _ := fs.IsFile(path); isFile {
+				ans = append(ans, corpus.RenameStep{Kind: renameStepTextTypesDb, Source: path, Target: newPath})
+			}
+		}
+	}
+	if path := a.facetIndexes.Path(corpusID); path != "" {
+		if isFile, _ := fs.IsFile(path); isFile {
+			ans = append(
+				ans,
+				corpus.RenameStep{Kind: renameStepFacetIndex, Source: path, Details: "removed, rebuild required"},
+			)
+		}
+	}
+	return ans
+}
+
+// RenameCorpusData implements corpus.CorpusRenamer. The rename
+// stops at the first failed step.
+func (a *Actions) RenameCorpusData(corpusID, newID string, corpusInfo *corpus.DBInfo) []corpus.RenameStep {
+	ans := a.CorpusRenamePlan(corpusID, newID, corpusInfo)
+	for i, step := range ans {
+		if step.Error != "" {
+			return ans
+		}
+		var err error
+		switch step.Kind {
+		case renameStepLiveAttrsTables:
+			err = db.RenameCorpus(a.laDB, corpusInfo.GroupedName(), corpusID, newID)
+		case renameStepLiveAttrsConfig:
+			err = a.laConfCache.Rename(corpusID, newID)
+		case renameStepTextTypesDb:
+			err = ttdb.Rename(step.Source, step.Target, corpusID, newID)
+			a.textTypesDbChecksums.remove(step.Source)
+		case renameStepFacetIndex:
+			err = a.facetIndexes.Remove(corpusID)
+		}
+		if err != nil {
+			ans[i].Error = err.Error()
+			return ans
+		}
+		ans[i].Done = true
+	}
+	if len(ans) > 0 {
+		a.invalidateQualityReport(corpusID)
+		if err := a.notifyKontext(newID); err != nil {
+			log.Error().Err(err).Str("corpusId", newID).Msg("failed to notify KonText about renamed corpus")
+		}
+	}
+	return ans
+}
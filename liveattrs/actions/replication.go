@@ -0,0 +1,82 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"masm/v3/liveattrs/cache"
+	"masm/v3/liveattrs/request/query"
+
+	"github.com/rs/zerolog/log"
+)
+
+const warmUpBudgetEndpoint = "/liveAttributes/:corpusId/query"
+
+// ExportConfFiles provides all the stored liveattrs config files
+// for replication to a standby instance
+func (a *Actions) ExportConfFiles() (map[string]json.RawMessage, error) {
+	return a.laConfCache.ExportFiles()
+}
+
+// ImportConfFiles stores liveattrs config files replicated
+// from a primary instance. Cached results of changed corpora
+// are removed. IDs of the changed corpora are returned.
+func (a *Actions) ImportConfFiles(files map[string]json.RawMessage) ([]string, error) {
+	changed, err := a.laConfCache.ImportFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	for _, corpusID := range changed {
+		a.eqCache.Del(corpusID)
+	}
+	return changed, nil
+}
+
+// WarmUpHints returns queries with cached results. Another instance
+// can use them to warm up its own cache (see WarmUp).
+func (a *Actions) WarmUpHints() []cache.CachedQuery {
+	return a.eqCache.Queries()
+}
+
+// WarmUp runs provided (empty) queries which are not cached yet
+// so their results get cached. Failed queries are only logged.
+// The number of newly cached results is returned.
+func (a *Actions) WarmUp(ctx context.Context, hints []cache.CachedQuery) int {
+	var ans int
+	for _, hint := range hints {
+		if ctx.Err() != nil {
+			break
+		}
+		qry := query.Payload{Aligned: hint.Aligned, Sort: hint.Sort}
+		if a.eqCache.Get(hint.CorpusID, qry) != nil {
+			continue
+		}
+		bgt := a.conf.Budgets.NewBudget(warmUpBudgetEndpoint)
+		res, err := a.runQuery(ctx, bgt, hint.CorpusID, qry)
+		if err != nil {
+			log.Error().Err(err).Str("corpusId", hint.CorpusID).Msg("failed to warm up liveattrs cache")
+			continue
+		}
+		if !res.Timeout {
+			ans++
+		}
+	}
+	return ans
+}
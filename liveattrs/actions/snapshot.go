@@ -0,0 +1,120 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"masm/v3/liveattrs/db/snapshot"
+	"net/http"
+	"os"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func removeTempFile(path string) {
+	if err := os.Remove(path); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("failed to remove temporary file")
+	}
+}
+
+// ExportSnapshot writes liveattrs data of a corpus as a downloadable
+// SQLite file which can be imported to another masm instance
+// via ImportSnapshot.
+func (a *Actions) ExportSnapshot(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to export liveattrs snapshot of %s: %w"
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	tmp, err := os.CreateTemp("", "masm-snapshot-*.db")
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	tmp.Close()
+	defer removeTempFile(tmp.Name())
+	meta, err := snapshot.Export(a.laDB, corpusID, corpInfo.GroupedName(), tmp.Name())
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Int("numRows", meta.NumRows).
+		Msg("exported liveattrs snapshot")
+	ctx.FileAttachment(tmp.Name(), fmt.Sprintf("%s.liveattrs.db", corpusID))
+}
+
+// ImportSnapshot loads liveattrs data of a corpus from an SQLite file
+// (created by ExportSnapshot) sent as the request body. Existing data
+// of the corpus are replaced.
+func (a *Actions) ImportSnapshot(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to import liveattrs snapshot of %s: %w"
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	tmp, err := os.CreateTemp("", "masm-snapshot-*.db")
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	defer removeTempFile(tmp.Name())
+	_, err = io.Copy(tmp, ctx.Request.Body)
+	tmp.Close()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	meta, err := snapshot.Import(a.laDB, corpusID, corpInfo.GroupedName(), tmp.Name())
+	if errors.Is(err, snapshot.ErrorIncompatibleSnapshot) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	a.eqCache.Del(corpusID)
+	uniresp.WriteJSONResponse(ctx.Writer, meta)
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"strings"
+)
+
+// extractSpeakers fills in the speaker table of a corpus (if configured)
+// based on vertical files processed by a liveattrs job
+func (a *Actions) extractSpeakers(jobStatus *liveattrs.LiveAttrsJobInfo) error {
+	spConf, err := a.laConfCache.GetSpeakerConf(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	if !spConf.IsEnabled() {
+		return nil
+	}
+	vteConf := &jobStatus.Args.VteConf
+	_, docIDAttr, _ := strings.Cut(spConf.DocIDAttr, ".")
+	entries, err := liveattrs.ExtractSpeakers(
+		vteConf.GetDefinedVerticals(),
+		liveattrs.SpeakerScanArgs{
+			AtomStruct:    vteConf.AtomStructure,
+			DocIDAttr:     docIDAttr,
+			SpeakerStruct: spConf.Struct,
+			SpeakerIDAttr: spConf.IDAttr,
+			SpeakerAttrs:  spConf.Attrs,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return db.StoreSpeakers(
+		a.laDB, vteGroupedName(vteConf), jobStatus.CorpusID, spConf, entries, jobStatus.Args.Append)
+}
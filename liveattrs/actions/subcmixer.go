@@ -23,30 +23,44 @@ import (
 	"fmt"
 	"masm/v3/common"
 	"masm/v3/general/collections"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
 	"masm/v3/liveattrs/subcmixer"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 const (
 	corpusMaxSize = 500000000
 )
 
-type subcmixerRatio struct {
-	AttrName  string  `json:"attrName"`
-	AttrValue string  `json:"attrValue"`
-	Ratio     float64 `json:"ratio"`
-}
-
 type subcmixerArgs struct {
-	Corpora   []string         `json:"corpora"`
-	TextTypes []subcmixerRatio `json:"textTypes"`
+	Corpora   []string                   `json:"corpora"`
+	TextTypes []liveattrs.SubcmixerRatio `json:"textTypes"`
+
+	// Algorithm specifies how texts are selected
+	// (see subcmixer.Algorithm)
+	Algorithm subcmixer.Algorithm `json:"algorithm"`
+
+	// Seed makes results reproducible. In case it is not
+	// specified, a random one is used (and returned along
+	// with the result).
+	Seed *int64 `json:"seed"`
 }
 
 func (sa *subcmixerArgs) validate() error {
+	if len(sa.Corpora) == 0 {
+		return fmt.Errorf("no corpora specified")
+	}
+	if err := sa.Algorithm.Validate(); err != nil {
+		return err
+	}
 	currStruct := ""
 	for _, tt := range sa.TextTypes {
 		strc := strings.Split(tt.AttrName, ".")
@@ -58,7 +72,7 @@ func (sa *subcmixerArgs) validate() error {
 	return nil
 }
 
-func importTaskArgs(args subcmixerArgs) ([]subcmixer.TaskArgs, error) {
+func importTaskArgs(textTypes []liveattrs.SubcmixerRatio) ([]subcmixer.TaskArgs, error) {
 	ans := [][]subcmixer.TaskArgs{
 		{
 			{
@@ -69,12 +83,12 @@ func importTaskArgs(args subcmixerArgs) ([]subcmixer.TaskArgs, error) {
 			},
 		},
 	}
-	groupedRatios := collections.NewMultidict[subcmixerRatio]()
-	for _, item := range args.TextTypes {
+	groupedRatios := collections.NewMultidict[liveattrs.SubcmixerRatio]()
+	for _, item := range textTypes {
 		groupedRatios.Add(item.AttrName, item)
 	}
 	counter := 1
-	err := groupedRatios.ForEach(func(k string, expressions []subcmixerRatio) error {
+	err := groupedRatios.ForEach(func(k string, expressions []liveattrs.SubcmixerRatio) error {
 		tmp := []subcmixer.TaskArgs{}
 		for _, pg := range ans[len(ans)-1] {
 			for _, item := range expressions {
@@ -109,24 +123,16 @@ func importTaskArgs(args subcmixerArgs) ([]subcmixer.TaskArgs, error) {
 	return ret, nil
 }
 
-func (a *Actions) MixSubcorpus(ctx *gin.Context) {
-	var args subcmixerArgs
-	err := json.NewDecoder(ctx.Request.Body).Decode(&args)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("failed to mix subcorpus: %w", err), http.StatusBadRequest)
-		return
-	}
-	baseErrTpl := "failed to mix subcorpus for %s: %w"
-	err = args.validate()
+// mixSubcorpus creates a subcorpus composition. Progress updates
+// are passed to `onProgress`.
+func (a *Actions) mixSubcorpus(
+	args liveattrs.SubcmixerJobInfoArgs,
+	onProgress subcmixer.ProgressFn,
+) (*subcmixer.CorpusComposition, error) {
+	onProgress(subcmixer.Progress{Phase: subcmixer.ProgressPhasePreparing})
+	conditions, err := importTaskArgs(args.TextTypes)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusUnprocessableEntity)
-	}
-	conditions, err := importTaskArgs(args)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
+		return nil, err
 	}
 	laTableName := fmt.Sprintf("%s_liveattrs_entry", args.Corpora[0])
 	catTree, err := subcmixer.NewCategoryTree(
@@ -138,15 +144,11 @@ func (a *Actions) MixSubcorpus(ctx *gin.Context) {
 		corpusMaxSize,
 	)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 	corpusDBInfo, err := a.cncDB.LoadInfo(args.Corpora[0])
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 	mm, err := subcmixer.NewMetadataModel(
 		a.laDB,
@@ -154,11 +156,103 @@ func (a *Actions) MixSubcorpus(ctx *gin.Context) {
 		catTree,
 		corpusDBInfo.BibIDAttr,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return mm.SolveWithProgress(args.Algorithm, args.Seed, onProgress), nil
+}
+
+func (a *Actions) mixSubcorpusFromJobStatus(status *liveattrs.SubcmixerJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		result, err := a.mixSubcorpus(
+			status.Args,
+			func(progress subcmixer.Progress) {
+				update := finalStatus
+				update.Progress = progress
+				update.Update = jobs.CurrentDatetime()
+				updateJobChan <- &update
+				finalStatus.Progress = progress
+			},
+		)
+		if err != nil {
+			finalStatus.Error = err
+
+		} else {
+			finalStatus.Progress.Phase = subcmixer.ProgressPhaseFinished
+			finalStatus.Result = result
+		}
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+// MixSubcorpus starts a job creating a subcorpus matching provided
+// text types and their required ratios. The job reports its progress
+// and once finished, it contains the subcorpus composition as its result.
+func (a *Actions) MixSubcorpus(ctx *gin.Context) {
+	var args subcmixerArgs
+	err := json.NewDecoder(ctx.Request.Body).Decode(&args)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to mix subcorpus: %w", err), http.StatusBadRequest)
+		return
+	}
+	err = args.validate()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to mix subcorpus: %w", err), http.StatusUnprocessableEntity)
+		return
+	}
+	baseErrTpl := "failed to mix subcorpus for %s: %w"
+	if _, err := importTaskArgs(args.TextTypes); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusUnprocessableEntity)
+		return
+	}
+	jobID, err := uuid.NewUUID()
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
 			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
 		return
 	}
-	ans := mm.Solve()
-	uniresp.WriteJSONResponse(ctx.Writer, ans)
+	seed := time.Now().UnixNano()
+	if args.Seed != nil {
+		seed = *args.Seed
+	}
+	newStatus := liveattrs.SubcmixerJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.SubcmixerJobType,
+		CorpusID: args.Corpora[0],
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args: liveattrs.SubcmixerJobInfoArgs{
+			Corpora:   args.Corpora,
+			TextTypes: args.TextTypes,
+			Algorithm: args.Algorithm.Normalized(),
+			Seed:      seed,
+		},
+	}
+	a.mixSubcorpusFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// RestartSubcmixerJob starts an interrupted subcorpus mixing job
+// again (with the same seed so the result is not affected)
+func (a *Actions) RestartSubcmixerJob(jinfo *liveattrs.SubcmixerJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	jinfo.Progress = subcmixer.Progress{}
+	a.mixSubcorpusFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted subcorpus mixing job %s", jinfo.ID)
+	return nil
 }
@@ -0,0 +1,49 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+)
+
+// storeSegmentDurations calculates durations of segments of a corpus
+// (if segment timing is configured) processed by a liveattrs job
+func (a *Actions) storeSegmentDurations(jobStatus *liveattrs.LiveAttrsJobInfo) error {
+	timingConf, err := a.laConfCache.GetTimingConf(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	if !timingConf.IsEnabled() {
+		return nil
+	}
+	numInvalid, err := db.StoreSegmentDurations(
+		a.laDB, vteGroupedName(&jobStatus.Args.VteConf), jobStatus.CorpusID, timingConf)
+	if err != nil {
+		return err
+	}
+	if numInvalid > 0 {
+		jobLog := a.jobActions.JobLogger(jobStatus.ID)
+		jobLog.Warn().
+			Str("corpusId", jobStatus.CorpusID).
+			Int("numSegments", numInvalid).
+			Msg("found segments without valid timing")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db/ttdb"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"os"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func (a *Actions) ttDbCheckFromJobStatus(status *liveattrs.TTDbCheckJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		result, err := a.checkTextTypesDb(status.CorpusID, status.Args)
+		if err != nil {
+			finalStatus.Error = err
+		}
+		finalStatus.Result = result
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+// checkTextTypesDb verifies a text types database of a corpus and
+// (if requested) rebuilds an invalid one. In case the database stays
+// invalid, an error is returned (along with the result) so the job
+// is reported as failed.
+func (a *Actions) checkTextTypesDb(
+	corpusID string,
+	args liveattrs.TTDbCheckJobInfoArgs,
+) (*liveattrs.TTDbCheckResult, error) {
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	dbPath, err := a.textTypesDbPath(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	schema := ttdb.SchemaFromConf(laConf)
+	ans := &liveattrs.TTDbCheckResult{}
+	ans.Check, err = ttdb.Check(dbPath, schema)
+	if err != nil && !(os.IsNotExist(err) && args.Repair) {
+		return nil, err
+	}
+	if ans.Check != nil && ans.Check.IsValid() {
+		return ans, nil
+	}
+	if !args.Repair {
+		return ans, fmt.Errorf("text types database %s is not valid", dbPath)
+	}
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return ans, err
+	}
+	ans.Repair, err = ttdb.Rebuild(a.laDB, corpusID, corpInfo.GroupedName(), dbPath, schema)
+	if err != nil {
+		return ans, fmt.Errorf("failed to rebuild text types database: %w", err)
+	}
+	ans.Repaired = true
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("path", dbPath).
+		Int("numRows", ans.Repair.NumRows).
+		Msg("rebuilt text types database")
+	if err := a.notifyKontext(corpusID); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to notify KonText")
+	}
+	return ans, nil
+}
+
+// CheckTextTypesDb starts a job verifying an installed SQLite text
+// types database of a corpus (`PRAGMA integrity_check` and presence
+// of tables and columns required by the corpus liveattrs config).
+// With `repair=1` (MySQL only), an invalid (or missing) database
+// is rebuilt out of the data stored in the MySQL liveattrs table.
+func (a *Actions) CheckTextTypesDb(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to check text types database of %s: %w"
+	args := liveattrs.TTDbCheckJobInfoArgs{
+		Repair: ctx.Request.URL.Query().Get("repair") == "1",
+	}
+	if args.Repair && a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("repair supported only with MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if _, err := a.textTypesDbPath(corpusID); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	_, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.TTDbCheckJobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	if job, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("data job %s is running", job.GetID())),
+			http.StatusConflict,
+		)
+		return
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := liveattrs.TTDbCheckJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.TTDbCheckJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     args,
+	}
+	a.ttDbCheckFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+func (a *Actions) RestartTTDbCheckJob(jinfo *liveattrs.TTDbCheckJobInfo) error {
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.ttDbCheckFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted text types database check job %s", jinfo.ID)
+	return nil
+}
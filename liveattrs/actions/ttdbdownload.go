@@ -0,0 +1,178 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"masm/v3/liveattrs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// fileChecksum is a SHA-256 checksum of a specific
+// version of a file (given by its size and mod. time)
+type fileChecksum struct {
+	size    int64
+	modTime time.Time
+	sum     []byte
+}
+
+// fileChecksums caches checksums of files so huge files
+// are not read again unless they change
+type fileChecksums struct {
+	mu    sync.Mutex
+	items map[string]fileChecksum
+}
+
+// get returns a checksum of an opened file
+func (fc *fileChecksums) get(path string, f *os.File, info os.FileInfo) ([]byte, error) {
+	fc.mu.Lock()
+	item, ok := fc.items[path]
+	fc.mu.Unlock()
+	if ok && item.size == info.Size() && item.modTime.Equal(info.ModTime()) {
+		return item.sum, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	item = fileChecksum{size: info.Size(), modTime: info.ModTime(), sum: h.Sum(nil)}
+	fc.mu.Lock()
+	fc.items[path] = item
+	fc.mu.Unlock()
+	return item.sum, nil
+}
+
+// remove removes a cached checksum of a (removed) file
+func (fc *fileChecksums) remove(path string) {
+	fc.mu.Lock()
+	delete(fc.items, path)
+	fc.mu.Unlock()
+}
+
+func newFileChecksums() *fileChecksums {
+	return &fileChecksums{items: make(map[string]fileChecksum)}
+}
+
+// textTypesDbPath returns a path of an SQLite text types database
+// of a corpus (see laconf.LiveAttrsBuildConfProvider)
+func (a *Actions) textTypesDbPath(corpusID string) (string, error) {
+	if corpusID == "" || corpusID == "." || corpusID == ".." || filepath.Base(corpusID) != corpusID {
+		return "", fmt.Errorf("invalid corpus ID %s", corpusID)
+	}
+	return filepath.Join(a.conf.LA.TextTypesDbDirPath, fmt.Sprintf("%s.db", corpusID)), nil
+}
+
+// DownloadTextTypesDb provides a generated SQLite text types database
+// of a corpus so other installations can fetch it directly from MASM.
+// Requests must be authorized by one of the configured tokens. Ranged
+// requests (e.g. resuming of interrupted downloads) are supported and
+// the response contains a SHA-256 checksum of the whole file (also used
+// as ETag so `If-Range` can be used to detect changes of the file).
+func (a *Actions) DownloadTextTypesDb(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to download text types database of %s: %w"
+	dlConf := a.conf.LA.TextTypesDbDownload
+	if dlConf == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("download not enabled")),
+			http.StatusNotFound,
+		)
+		return
+	}
+	if !dlConf.IsAuthorized(ctx.Request) {
+		ctx.Header("WWW-Authenticate", "Bearer")
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("unauthorized")),
+			http.StatusUnauthorized,
+		)
+		return
+	}
+	if a.conf.LA.DB.Type != "sqlite" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for SQLite database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	dbPath, err := a.textTypesDbPath(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	// the database is being (re)generated so it may be inconsistent
+	if job, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("data job %s is running", job.GetID())),
+			http.StatusConflict,
+		)
+		return
+	}
+	f, err := os.Open(dbPath)
+	if os.IsNotExist(err) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("database not found")),
+			http.StatusNotFound,
+		)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	sum, err := a.textTypesDbChecksums.get(dbPath, f, info)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	hexSum := hex.EncodeToString(sum)
+	ctx.Header("ETag", fmt.Sprintf("\"%s\"", hexSum))
+	ctx.Header("Repr-Digest", fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(sum)))
+	ctx.Header("X-Checksum-Sha256", hexSum)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.db\"", corpusID))
+	if ctx.Request.Header.Get("Range") == "" && ctx.Request.Method == http.MethodGet {
+		log.Info().Str("corpusId", corpusID).Int64("size", info.Size()).Msg("downloading text types database")
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, filepath.Base(dbPath), info.ModTime(), f)
+}
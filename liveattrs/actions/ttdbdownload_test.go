@@ -0,0 +1,76 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"crypto/sha256"
+	"io"
+	"masm/v3/liveattrs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileChecksumsCached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syn2020.db")
+	assert.NoError(t, os.WriteFile(path, []byte("first version"), 0644))
+	fc := newFileChecksums()
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	info, err := f.Stat()
+	assert.NoError(t, err)
+	sum, err := fc.get(path, f, info)
+	assert.NoError(t, err)
+	expected := sha256.Sum256([]byte("first version"))
+	assert.Equal(t, expected[:], sum)
+	// the file must be readable from the start again
+	pos, err := f.Seek(0, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), pos)
+
+	// a changed file is read again
+	assert.NoError(t, os.WriteFile(path, []byte("second version"), 0644))
+	assert.NoError(t, os.Chtimes(path, time.Now(), info.ModTime().Add(time.Minute)))
+	f2, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f2.Close()
+	info2, err := f2.Stat()
+	assert.NoError(t, err)
+	sum, err = fc.get(path, f2, info2)
+	assert.NoError(t, err)
+	expected = sha256.Sum256([]byte("second version"))
+	assert.Equal(t, expected[:], sum)
+}
+
+func TestTextTypesDbPath(t *testing.T) {
+	a := &Actions{conf: LAConf{LA: &liveattrs.Conf{TextTypesDbDirPath: "/var/local/corpora/metadata"}}}
+	path, err := a.textTypesDbPath("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/local/corpora/metadata/syn2020.db", path)
+
+	for _, corpusID := range []string{"", ".", "..", "../syn2020", "foo/syn2020"} {
+		_, err := a.textTypesDbPath(corpusID)
+		assert.Error(t, err, corpusID)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"fmt"
+	"masm/v3/jobs"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// groupConfs returns configs of all the corpora sharing liveattrs
+// table with the corpus configured by `conf` (including the corpus)
+func (a *Actions) groupConfs(conf *vteCnf.VTEConf) ([]*vteCnf.VTEConf, error) {
+	if conf.ParallelCorpus == "" {
+		return []*vteCnf.VTEConf{conf}, nil
+	}
+	summaries, err := a.laConfCache.ListSummaries(laconf.ConfFilter{ParallelCorpus: conf.ParallelCorpus})
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]*vteCnf.VTEConf, len(summaries))
+	for i, summary := range summaries {
+		ans[i], err = a.laConfCache.Get(summary.Corpus)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func (a *Actions) unusedColumnsFromJobStatus(status *liveattrs.UnusedColsJobInfo) {
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		finalStatus := *status
+		report, err := a.findUnusedColumns(status.CorpusID, status.Args)
+		if err != nil {
+			finalStatus.Error = err
+		}
+		finalStatus.Result = report
+		finalStatus.Update = jobs.CurrentDatetime()
+		finalStatus.Finished = true
+		updateJobChan <- &finalStatus
+	}
+	a.jobActions.EnqueueJob(&fn, status)
+}
+
+func (a *Actions) findUnusedColumns(
+	corpusID string,
+	args liveattrs.UnusedColsJobInfoArgs,
+) (*liveattrs.UnusedColumnsReport, error) {
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	confs, err := a.groupConfs(laConf)
+	if err != nil {
+		return nil, err
+	}
+	corpora := make([]string, len(confs))
+	attrs := make(map[string]bool)
+	protected := make(map[string]bool)
+	for i, conf := range confs {
+		corpora[i] = conf.Corpus
+		for _, attr := range laconf.GetSubcorpAttrs(conf) {
+			attrs[attr] = true
+		}
+		for col := range liveattrs.ProtectedColumns(conf) {
+			protected[col] = true
+		}
+	}
+	attrList := make([]string, 0, len(attrs))
+	for attr := range attrs {
+		attrList = append(attrList, attr)
+	}
+	report, err := db.FindUnusedColumns(
+		a.laDB,
+		fmt.Sprintf("%s_liveattrs_entry", corpInfo.GroupedName()),
+		corpora,
+		attrList,
+		protected,
+		time.Now().AddDate(0, -args.Months, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+	report.CorpusID = corpusID
+	if !args.Drop || len(report.Columns) == 0 {
+		return report, nil
+	}
+	// configs go first so in case the table cannot be altered,
+	// the columns are just not filled in by future data extractions
+	newConfs := make([]*vteCnf.VTEConf, len(confs))
+	for i, conf := range confs {
+		newConf := *conf
+		newConf.Structures = laconf.WithoutSubcorpAttrs(conf, report.Attrs())
+		newConfs[i] = &newConf
+	}
+	if err := a.laConfCache.SaveAll(newConfs); err != nil {
+		return report, fmt.Errorf("failed to update configs: %w", err)
+	}
+	if err := db.DropColumns(a.laDB, report.Table, report.Attrs()); err != nil {
+		return report, fmt.Errorf("failed to drop columns: %w", err)
+	}
+	report.Dropped = true
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("table", report.Table).
+		Strs("attrs", report.Attrs()).
+		Msg("dropped unused liveattrs columns")
+	for _, c := range corpora {
+		a.eqCache.Del(c)
+		a.invalidateQualityReport(c)
+		if err := a.facetIndexes.Remove(c); err != nil {
+			log.Error().Err(err).Str("corpusId", c).Msg("failed to remove facet index")
+		}
+		if err := a.notifyKontext(c); err != nil {
+			log.Error().Err(err).Str("corpusId", c).Msg("failed to notify KonText")
+		}
+	}
+	return report, nil
+}
+
+// UnusedColumns starts a job searching for liveattrs columns
+// not queried for a specified number of months (MySQL only).
+// With `drop=1` (and `confirm` set to the name of the liveattrs
+// table), the found columns are removed from the table and
+// from the configs of all the corpora sharing the table.
+func (a *Actions) UnusedColumns(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to search for unused columns of %s: %w"
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	args := liveattrs.UnusedColsJobInfoArgs{
+		Months: liveattrs.DfltUnusedColsMonths,
+		Drop:   ctx.Request.URL.Query().Get("drop") == "1",
+	}
+	if v := ctx.Request.URL.Query().Get("months"); v != "" {
+		months, err := strconv.Atoi(v)
+		if err != nil || months < 1 {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid months value %s", v)),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		args.Months = months
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.UnusedColsJobType); ok {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, prevRunning.FullInfo())
+		return
+	}
+	if args.Drop {
+		groupedName := vteGroupedName(laConf)
+		if ctx.Request.URL.Query().Get("confirm") != groupedName {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError(
+					baseErrTpl, corpusID,
+					fmt.Errorf("dropping columns must be confirmed by confirm=[name of the corpus or parallel corpus]")),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		confs, err := a.groupConfs(laConf)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+		for _, conf := range confs {
+			if _, ok := a.jobActions.LastUnfinishedJobOfType(conf.Corpus, liveattrs.JobType); ok {
+				uniresp.WriteJSONErrorResponse(
+					ctx.Writer,
+					uniresp.NewActionError(
+						baseErrTpl, corpusID,
+						fmt.Errorf("cannot drop columns while a liveattrs job of %s is running", conf.Corpus)),
+					http.StatusConflict,
+				)
+				return
+			}
+		}
+	}
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newStatus := liveattrs.UnusedColsJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.UnusedColsJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args:     args,
+	}
+	a.unusedColumnsFromJobStatus(&newStatus)
+	a.jobActions.AttachRequest(ctx, newStatus.ID)
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, newStatus.FullInfo())
+}
+
+// RestartUnusedColsJob restarts an interrupted job. Jobs dropping
+// columns are not restarted as the situation may have changed
+// since the drop was confirmed.
+func (a *Actions) RestartUnusedColsJob(jinfo *liveattrs.UnusedColsJobInfo) error {
+	if jinfo.Args.Drop {
+		return fmt.Errorf("jobs dropping columns cannot be restarted")
+	}
+	err := a.jobActions.TestAllowsJobRestart(jinfo)
+	if err != nil {
+		return err
+	}
+	jinfo.Start = jobs.CurrentDatetime()
+	jinfo.NumRestarts++
+	jinfo.Update = jobs.CurrentDatetime()
+	a.unusedColumnsFromJobStatus(jinfo)
+	log.Info().Msgf("Restarted unused columns job %s", jinfo.ID)
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltValueCountsMinShift = 0.5
+	dfltValueCountsMaxItems = 100
+)
+
+var errorNoSuchSnapshot = errors.New("no such snapshot")
+
+// storeValueCounts stores a snapshot of attribute value counts
+// of a corpus after a finished data extraction job (MySQL only)
+// and logs a warning in case some values have disappeared since
+// the previous snapshot.
+func (a *Actions) storeValueCounts(jobStatus *liveattrs.LiveAttrsJobInfo) {
+	corpusID := jobStatus.CorpusID
+	corpInfo, err := a.cncDB.LoadInfo(corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to store value counts")
+		return
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to store value counts")
+		return
+	}
+	attrs := laconf.GetSubcorpAttrs(laConf)
+	sort.Strings(attrs)
+	counts, err := db.LoadValueCounts(a.laDB, corpInfo, attrs)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to store value counts")
+		return
+	}
+	prev, err := db.GetValueCountsSnapshots(a.laDB, corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to store value counts")
+		return
+	}
+	snapshot, err := db.StoreValueCountsSnapshot(a.laDB, corpusID, jobStatus.ID, counts)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to store value counts")
+		return
+	}
+	if len(prev) == 0 {
+		return
+	}
+	prevCounts, err := db.LoadValueCountsSnapshot(a.laDB, prev[0].ID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to compare value counts")
+		return
+	}
+	diff := liveattrs.DiffValueCounts(prevCounts, counts, dfltValueCountsMinShift, 0)
+	if diff.NumRemovedValues > 0 || len(diff.RemovedAttrs) > 0 {
+		log.Warn().
+			Str("corpusId", corpusID).
+			Int64("snapshotId", snapshot.ID).
+			Int64("prevSnapshotId", prev[0].ID).
+			Int("numRemovedValues", diff.NumRemovedValues).
+			Strs("removedAttrs", diff.RemovedAttrs).
+			Int("numShifts", diff.NumShifts).
+			Msg("some attribute values have disappeared since the previous build")
+	}
+}
+
+// findDiffSnapshots selects two snapshots to be compared. The `snapshots`
+// are expected to be sorted from the latest one. By default (zero IDs),
+// the latest snapshot is compared with the previous one.
+func findDiffSnapshots(
+	snapshots []*liveattrs.ValueCountsSnapshot,
+	fromID, toID int64,
+) (from, to *liveattrs.ValueCountsSnapshot, err error) {
+	toIdx := -1
+	for i, s := range snapshots {
+		if toID == 0 || s.ID == toID {
+			toIdx = i
+			break
+		}
+	}
+	if toIdx == -1 {
+		return nil, nil, fmt.Errorf("%w: %d", errorNoSuchSnapshot, toID)
+	}
+	to = snapshots[toIdx]
+	if fromID == 0 {
+		if toIdx+1 >= len(snapshots) {
+			return nil, nil, fmt.Errorf("%w: no snapshot older than %d", errorNoSuchSnapshot, to.ID)
+		}
+		return snapshots[toIdx+1], to, nil
+	}
+	for _, s := range snapshots {
+		if s.ID == fromID {
+			return s, to, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %d", errorNoSuchSnapshot, fromID)
+}
+
+// ValueCountsSnapshots lists stored value counts snapshots of a corpus
+// (created by finished data extraction jobs)
+func (a *Actions) ValueCountsSnapshots(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get value counts snapshots of %s: %w"
+	ans, err := db.GetValueCountsSnapshots(a.laDB, corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// ValueCountsDiff compares attribute value counts of two builds of
+// a corpus (`from` and `to` snapshot IDs; by default the latest build
+// is compared with the previous one). New values, removed values and
+// values with poscount changed at least by `minShift` (a ratio) are
+// reported, each list is limited to `maxItems` items.
+func (a *Actions) ValueCountsDiff(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to compare value counts of %s: %w"
+	fromID, err := strconv.ParseInt(ctx.DefaultQuery("from", "0"), 10, 64)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	toID, err := strconv.ParseInt(ctx.DefaultQuery("to", "0"), 10, 64)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	minShift, err := strconv.ParseFloat(
+		ctx.DefaultQuery("minShift", strconv.FormatFloat(dfltValueCountsMinShift, 'f', -1, 64)), 64)
+	if err != nil || minShift <= 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid minShift argument")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	maxItems, err := strconv.Atoi(ctx.DefaultQuery("maxItems", strconv.Itoa(dfltValueCountsMaxItems)))
+	if err != nil || maxItems < 0 {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("invalid maxItems argument")),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	snapshots, err := db.GetValueCountsSnapshots(a.laDB, corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	from, to, err := findDiffSnapshots(snapshots, fromID, toID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+	}
+	fromCounts, err := db.LoadValueCountsSnapshot(a.laDB, from.ID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	toCounts, err := db.LoadValueCountsSnapshot(a.laDB, to.ID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	ans := liveattrs.DiffValueCounts(fromCounts, toCounts, minShift, maxItems)
+	ans.From = from
+	ans.To = to
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
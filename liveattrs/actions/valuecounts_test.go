@@ -0,0 +1,52 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDiffSnapshots(t *testing.T) {
+	snapshots := []*liveattrs.ValueCountsSnapshot{{ID: 7}, {ID: 5}, {ID: 2}}
+
+	from, to, err := findDiffSnapshots(snapshots, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), from.ID)
+	assert.Equal(t, int64(7), to.ID)
+
+	from, to, err = findDiffSnapshots(snapshots, 0, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), from.ID)
+	assert.Equal(t, int64(5), to.ID)
+
+	from, to, err = findDiffSnapshots(snapshots, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), from.ID)
+	assert.Equal(t, int64(7), to.ID)
+
+	_, _, err = findDiffSnapshots(snapshots, 0, 2)
+	assert.ErrorIs(t, err, errorNoSuchSnapshot)
+	_, _, err = findDiffSnapshots(snapshots, 3, 0)
+	assert.ErrorIs(t, err, errorNoSuchSnapshot)
+	_, _, err = findDiffSnapshots([]*liveattrs.ValueCountsSnapshot{}, 0, 0)
+	assert.ErrorIs(t, err, errorNoSuchSnapshot)
+}
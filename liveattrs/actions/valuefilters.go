@@ -0,0 +1,78 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// includeHidden tests whether a request asks for values normally
+// hidden by configured value filters. The argument is intended
+// for administrators - please note that MASM itself does not
+// enforce this (see root.RoleAdmin).
+func includeHidden(ctx *gin.Context) bool {
+	return ctx.Request.URL.Query().Get("includeHidden") == "1"
+}
+
+// applyValueFilters removes values hidden by corpus' value filters
+// from a query answer (unless the request asks for hidden values)
+func (a *Actions) applyValueFilters(
+	ctx *gin.Context,
+	corpusID string,
+	ans *response.QueryAns,
+) (*response.QueryAns, error) {
+	if includeHidden(ctx) {
+		return ans, nil
+	}
+	filters, err := a.laConfCache.GetValueFilters(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return ans, nil
+	}
+	return ans.WithoutHiddenValues(filters.IsHidden), nil
+}
+
+// isHiddenDocument tests whether any of document's attributes
+// contains a hidden value
+func isHiddenDocument(doc *db.DocumentRow, filters laconf.ValueFilters) bool {
+	for attr, value := range doc.Attrs {
+		if filters.IsHidden(attr, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterHiddenDocuments removes documents with any of their attributes
+// containing a hidden value
+func filterHiddenDocuments(docs []*db.DocumentRow, filters laconf.ValueFilters) []*db.DocumentRow {
+	ans := make([]*db.DocumentRow, 0, len(docs))
+	for _, doc := range docs {
+		if !isHiddenDocument(doc, filters) {
+			ans = append(ans, doc)
+		}
+	}
+	return ans
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/db"
+	"masm/v3/liveattrs/laconf"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type valueMergeArgs struct {
+	Attr      string   `json:"attr"`
+	Values    []string `json:"values"`
+	Canonical string   `json:"canonical"`
+}
+
+type valueMergeResponse struct {
+	laconf.ValueMerge
+	NumRows int64 `json:"numRows"`
+}
+
+// applyValueMerges applies stored value merges to freshly
+// extracted data of a corpus
+func (a *Actions) applyValueMerges(jobStatus *liveattrs.LiveAttrsJobInfo) error {
+	merges, err := a.laConfCache.GetValueMerges(jobStatus.CorpusID)
+	if err != nil {
+		return err
+	}
+	_, err = db.ApplyValueMerges(
+		a.laDB, vteGroupedName(&jobStatus.Args.VteConf), jobStatus.CorpusID, merges)
+	return err
+}
+
+// onValuesMerged invalidates everything depending on attribute values
+func (a *Actions) onValuesMerged(corpusID string) {
+	a.eqCache.Del(corpusID)
+	a.invalidateQualityReport(corpusID)
+	a.refreshFacetIndex(corpusID)
+	if err := a.notifyKontext(corpusID); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to notify KonText")
+	}
+}
+
+// prepareValueMergeAction loads the corpus config and tests whether
+// its values can be changed right now. In case of a problem, an error
+// response is written and nil is returned.
+func (a *Actions) prepareValueMergeAction(ctx *gin.Context, baseErrTpl string) *vteCnf.VTEConf {
+	corpusID := ctx.Param("corpusId")
+	if a.conf.LA.DB.Type != "mysql" {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("supported only for MySQL database")),
+			http.StatusBadRequest,
+		)
+		return nil
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return nil
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return nil
+	}
+	if job, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("data job %s is running", job.GetID())),
+			http.StatusConflict,
+		)
+		return nil
+	}
+	return laConf
+}
+
+// ValueMerges lists merges of attribute values of a corpus
+// in the order they are applied
+func (a *Actions) ValueMerges(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	merges, err := a.laConfCache.GetValueMerges(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to get value merges of %s: %w", corpusID, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, merges)
+}
+
+// MergeValues replaces two or more values of an attribute with a single
+// canonical value (e.g. when curating metadata of a publisher). The merge
+// is stored and applied again after each data extraction so it survives
+// corpus rebuilds. The bibliography view is based on the liveattrs table
+// so it reflects the change too.
+func (a *Actions) MergeValues(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to merge values of %s: %w"
+	laConf := a.prepareValueMergeAction(ctx, baseErrTpl)
+	if laConf == nil {
+		return
+	}
+	var args valueMergeArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	mergeID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	merge := laconf.NewValueMerge(mergeID.String(), args.Attr, args.Values, args.Canonical)
+	if err := merge.Validate(laConf.Structures); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+	}
+	merges, err := a.laConfCache.GetValueMerges(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	numRows, err := db.ApplyValueMerges(
+		a.laDB, vteGroupedName(laConf), corpusID, laconf.ValueMerges{merge})
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	newMerges := append(append(laconf.ValueMerges{}, merges...), merge)
+	if err := a.laConfCache.SaveValueMerges(corpusID, newMerges); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("attr", merge.Attr).
+		Strs("values", merge.Values).
+		Str("canonical", merge.Canonical).
+		Int64("numRows", numRows[merge.ID]).
+		Msg("merged attribute values")
+	a.onValuesMerged(corpusID)
+	uniresp.WriteJSONResponseWithStatus(
+		ctx.Writer,
+		http.StatusCreated,
+		valueMergeResponse{ValueMerge: merge, NumRows: numRows[merge.ID]},
+	)
+}
+
+// UndoValueMerge restores values replaced by a merge and removes
+// the merge so it is not applied on future rebuilds
+func (a *Actions) UndoValueMerge(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to undo value merge of %s: %w"
+	laConf := a.prepareValueMergeAction(ctx, baseErrTpl)
+	if laConf == nil {
+		return
+	}
+	merges, err := a.laConfCache.GetValueMerges(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	merge, ok := merges.Find(ctx.Param("mergeId"))
+	if !ok {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError(baseErrTpl, corpusID, fmt.Errorf("merge %s not found", ctx.Param("mergeId"))),
+			http.StatusNotFound,
+		)
+		return
+	}
+	numRows, err := db.UndoValueMerge(a.laDB, vteGroupedName(laConf), corpusID, merge)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if err := a.laConfCache.SaveValueMerges(corpusID, merges.Without(merge.ID)); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("mergeId", merge.ID).
+		Int64("numRows", numRows).
+		Msg("undone attribute value merge")
+	a.onValuesMerged(corpusID)
+	uniresp.WriteJSONResponse(ctx.Writer, valueMergeResponse{ValueMerge: merge, NumRows: numRows})
+}
@@ -21,19 +21,25 @@ package cache
 import (
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/request/response"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
 )
 
-func mkKey(corpusID string, aligned []string) string {
-	return strings.Join(append(aligned, corpusID), ":")
+func mkKey(corpusID string, aligned []string, sortOrder string) string {
+	key := strings.Join(append(aligned, corpusID), ":")
+	if sortOrder != query.SortAlpha {
+		key += "#" + sortOrder
+	}
+	return key
 }
 
 // EmptyQueryCache provides caching for any query with attributes empty.
 // It is perfectly OK to Get/Set any query but only the ones with attributes
-// empty will be actually stored. For other ones, nil is always returned by Get.
+// empty (and without a subcorpus restriction) will be actually stored.
+// For other ones, nil is always returned by Get.
 type EmptyQueryCache struct {
 
 	// data contains cached results for initial corpus+aligned corpora text types listings
@@ -44,16 +50,35 @@ type EmptyQueryCache struct {
 	// changes
 	corpKeyDeps map[string][]string
 
+	// queries contains the queries cached results belong to
+	queries map[string]CachedQuery
+
 	lock sync.Mutex
 }
 
+// CachedQuery describes a query with a cached result
+// (e.g. for warming up a cache of another instance)
+type CachedQuery struct {
+	CorpusID string   `json:"corpusId"`
+	Aligned  []string `json:"aligned"`
+	Sort     string   `json:"sort"`
+}
+
+// isCacheable tests whether a result of a query can be cached.
+// Besides queries with attributes or a subcorpus, this does not
+// apply for queries with values transformed into trees as they
+// change the way values are summarized.
+func isCacheable(qry query.Payload) bool {
+	return len(qry.Attrs) == 0 && qry.Subcorpus == nil && len(qry.TreeAttrs()) == 0
+}
+
 // Get returns a cached result based on provided corpus (and possible aligned corpora)
 // In case nothing is found, nil is returned
 func (qc *EmptyQueryCache) Get(corpusID string, qry query.Payload) *response.QueryAns {
-	if len(qry.Attrs) > 0 {
+	if !isCacheable(qry) {
 		return nil
 	}
-	return qc.data[mkKey(corpusID, qry.Aligned)]
+	return qc.data[mkKey(corpusID, qry.Aligned, qry.SortOrder())]
 }
 
 // setKeyCorpusDependency create a dependency between corpus and cache key
@@ -73,12 +98,17 @@ func (qc *EmptyQueryCache) setKeyCorpusDependency(corpusID, key string) {
 }
 
 func (qc *EmptyQueryCache) Set(corpusID string, qry query.Payload, value *response.QueryAns) {
-	if len(qry.Attrs) > 0 {
+	if !isCacheable(qry) {
 		return
 	}
 	qc.lock.Lock()
-	cKey := mkKey(corpusID, qry.Aligned)
+	cKey := mkKey(corpusID, qry.Aligned, qry.SortOrder())
 	qc.data[cKey] = value
+	qc.queries[cKey] = CachedQuery{
+		CorpusID: corpusID,
+		Aligned:  qry.Aligned,
+		Sort:     qry.SortOrder(),
+	}
 	qc.setKeyCorpusDependency(corpusID, cKey)
 	for _, alignedCorpusID := range qry.Aligned {
 		qc.setKeyCorpusDependency(alignedCorpusID, cKey)
@@ -111,6 +141,7 @@ func (qc *EmptyQueryCache) Del(corpusID string) {
 	var totalPruned int
 	for _, key := range cInv {
 		delete(qc.data, key)
+		delete(qc.queries, key)
 		totalPruned += qc.pruneKeyInDeps(key)
 	}
 	delete(qc.corpKeyDeps, corpusID)
@@ -122,9 +153,27 @@ func (qc *EmptyQueryCache) Del(corpusID string) {
 	qc.lock.Unlock()
 }
 
+// Queries returns all the queries with cached results
+// sorted by their corpora
+func (qc *EmptyQueryCache) Queries() []CachedQuery {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	keys := make([]string, 0, len(qc.queries))
+	for k := range qc.queries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ans := make([]CachedQuery, len(keys))
+	for i, k := range keys {
+		ans[i] = qc.queries[k]
+	}
+	return ans
+}
+
 func NewEmptyQueryCache() *EmptyQueryCache {
 	return &EmptyQueryCache{
 		data:        make(map[string]*response.QueryAns),
 		corpKeyDeps: make(map[string][]string),
+		queries:     make(map[string]CachedQuery),
 	}
 }
@@ -97,3 +97,45 @@ func TestCacheDelAligned(t *testing.T) {
 	assert.Equal(t, 0, len(qcache.data))
 	assert.Equal(t, 0, len(qcache.corpKeyDeps))
 }
+
+func TestCacheGetDifferentSort(t *testing.T) {
+	qcache, qry, value := createTestingCache()
+	qry.Sort = query.SortCount
+	assert.Nil(t, qcache.Get("corp1", qry))
+	qry.Sort = query.SortAlpha
+	assert.Equal(t, value, *qcache.Get("corp1", qry))
+}
+
+func TestCacheIgnoresSubcorpus(t *testing.T) {
+	qcache, qry, _ := createTestingCache()
+	qry.Subcorpus = &query.Subcorpus{StructIDs: []string{"doc1"}}
+	assert.Nil(t, qcache.Get("corp1", qry))
+	qcache.Set("corp1", qry, &response.QueryAns{})
+	qry.Subcorpus = nil
+	assert.NotNil(t, qcache.Get("corp1", qry))
+	assert.NotEmpty(t, qcache.Get("corp1", qry).AttrValues)
+}
+
+func TestCacheIgnoresTreeTransforms(t *testing.T) {
+	qcache, qry, _ := createTestingCache()
+	qry.Transforms = []query.Transform{{Type: query.TransformTree, Attr: "attrA", Separator: "/"}}
+	assert.Nil(t, qcache.Get("corp1", qry))
+	qcache.Set("corp1", qry, &response.QueryAns{})
+	qry.Transforms = []query.Transform{{Type: query.TransformTopN, Attr: "attrA", N: 1}}
+	assert.NotEmpty(t, qcache.Get("corp1", qry).AttrValues)
+}
+
+func TestCacheQueries(t *testing.T) {
+	qcache, _, value := createTestingCache()
+	qcache.Set("corp0", query.Payload{Sort: query.SortCount}, &value)
+	assert.Equal(
+		t,
+		[]CachedQuery{
+			{CorpusID: "corp0", Sort: query.SortCount},
+			{CorpusID: "corp1", Aligned: []string{"corp2", "corp3"}, Sort: query.SortAlpha},
+		},
+		qcache.Queries(),
+	)
+	qcache.Del("corp2")
+	assert.Equal(t, []CachedQuery{{CorpusID: "corp0", Sort: query.SortCount}}, qcache.Queries())
+}
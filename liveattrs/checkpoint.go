@@ -0,0 +1,252 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"masm/v3/jobs"
+	"os"
+	"strings"
+)
+
+// ExtractionCheckpoint describes a position in vertical files
+// up to which data have been extracted. The position is always
+// outside of an atom structure so the extraction can be resumed
+// (in the append mode) without creating incomplete atoms.
+type ExtractionCheckpoint struct {
+
+	// VerticalIdx is an index of the vertical file (within
+	// the defined verticals) the extraction is to be resumed in
+	VerticalIdx int `json:"verticalIdx"`
+
+	// Vertical identifies the vertical file so it can be tested
+	// whether the file has not changed in the meantime
+	Vertical VerticalIdentity `json:"vertical"`
+
+	// LineOffset is a number of already processed lines of the vertical
+	LineOffset int `json:"lineOffset"`
+
+	// OpenTags contains lines with start tags of structures open
+	// at LineOffset (they must be repeated once the extraction is resumed)
+	OpenTags []string `json:"openTags,omitempty"`
+
+	// ProcessedLines is a total number of processed lines
+	// (of all the verticals) at the checkpoint
+	ProcessedLines int `json:"processedLines"`
+
+	// ProcessedAtoms is a total number of processed atoms
+	// (of all the verticals) at the checkpoint
+	ProcessedAtoms int `json:"processedAtoms"`
+
+	Created jobs.JSONTime `json:"created"`
+}
+
+// Validate tests whether the checkpoint can be used to resume
+// the extraction of provided verticals
+func (cp *ExtractionCheckpoint) Validate(paths []string) error {
+	if cp.VerticalIdx < 0 || cp.VerticalIdx >= len(paths) {
+		return fmt.Errorf("invalid checkpoint vertical index %d", cp.VerticalIdx)
+	}
+	ident, err := IdentifyVertical(paths[cp.VerticalIdx])
+	if err != nil {
+		return fmt.Errorf("failed to validate checkpoint: %w", err)
+	}
+	if !ident.SameAs(cp.Vertical) {
+		return fmt.Errorf("vertical %s has changed since the checkpoint", paths[cp.VerticalIdx])
+	}
+	return nil
+}
+
+type openStruct struct {
+	name string
+	line string
+}
+
+// CheckpointScanner reads vertical files in parallel with an extraction
+// job and provides checkpoints for numbers of lines processed by the job.
+type CheckpointScanner struct {
+	paths      []string
+	atomStruct string
+	fileIdx    int
+	file       io.Closer
+	rd         io.ReadCloser
+	scanner    *bufio.Scanner
+	vertical   VerticalIdentity
+	lineOffset int
+	numLines   int
+	numAtoms   int
+	open       []openStruct
+	last       *ExtractionCheckpoint
+}
+
+func (cs *CheckpointScanner) atomOpen() bool {
+	for _, st := range cs.open {
+		if st.name == cs.atomStruct {
+			return true
+		}
+	}
+	return cs.atomStruct == "" && len(cs.open) > 0
+}
+
+func (cs *CheckpointScanner) setCheckpoint() {
+	cp := &ExtractionCheckpoint{
+		VerticalIdx:    cs.fileIdx,
+		Vertical:       cs.vertical,
+		LineOffset:     cs.lineOffset,
+		ProcessedLines: cs.numLines,
+		ProcessedAtoms: cs.numAtoms,
+		Created:        jobs.CurrentDatetime(),
+	}
+	if len(cs.open) > 0 {
+		cp.OpenTags = make([]string, len(cs.open))
+		for i, st := range cs.open {
+			cp.OpenTags[i] = st.line
+		}
+	}
+	cs.last = cp
+}
+
+func (cs *CheckpointScanner) processLine(line string) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "<") {
+		if m := structCloseRegexp.FindStringSubmatch(trimmed); m != nil {
+			for i := len(cs.open) - 1; i >= 0; i-- {
+				if cs.open[i].name == m[1] {
+					cs.open = cs.open[:i]
+					break
+				}
+			}
+			if m[1] == cs.atomStruct {
+				cs.numAtoms++
+			}
+
+		} else if m := structOpenRegexp.FindStringSubmatch(trimmed); m != nil && !strings.HasSuffix(trimmed, "/>") {
+			cs.open = append(cs.open, openStruct{name: m[1], line: line})
+		}
+	}
+	cs.numLines++
+	cs.lineOffset++
+}
+
+func (cs *CheckpointScanner) openFile() error {
+	path := cs.paths[cs.fileIdx]
+	var err error
+	cs.vertical, err = IdentifyVertical(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open vertical %s: %w", path, err)
+	}
+	rd, _, err := decompressedReader(f, path)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	cs.file = f
+	cs.rd = rd
+	cs.scanner = bufio.NewScanner(rd)
+	cs.scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	cs.lineOffset = 0
+	cs.open = cs.open[:0]
+	return nil
+}
+
+func (cs *CheckpointScanner) closeFile() {
+	if cs.scanner != nil {
+		cs.rd.Close()
+		cs.file.Close()
+		cs.scanner = nil
+	}
+}
+
+// Advance reads verticals up to the provided total number of processed
+// lines and returns the last checkpoint found so far (nil if there
+// is none).
+func (cs *CheckpointScanner) Advance(processedLines int) (*ExtractionCheckpoint, error) {
+	for cs.numLines < processedLines {
+		if cs.scanner == nil {
+			if cs.fileIdx >= len(cs.paths) {
+				break
+			}
+			if err := cs.openFile(); err != nil {
+				return cs.last, err
+			}
+			cs.setCheckpoint()
+		}
+		if !cs.scanner.Scan() {
+			err := cs.scanner.Err()
+			cs.closeFile()
+			if err != nil {
+				return cs.last, fmt.Errorf("failed to read %s: %w", cs.paths[cs.fileIdx], err)
+			}
+			cs.fileIdx++
+			continue
+		}
+		cs.processLine(cs.scanner.Text())
+		if !cs.atomOpen() {
+			cs.setCheckpoint()
+		}
+	}
+	return cs.last, nil
+}
+
+// Close releases the actually read vertical file
+func (cs *CheckpointScanner) Close() {
+	cs.closeFile()
+}
+
+// NewCheckpointScanner creates a scanner of provided verticals. In case
+// a checkpoint is provided, the scanner continues from its position.
+func NewCheckpointScanner(
+	paths []string,
+	atomStruct string,
+	from *ExtractionCheckpoint,
+) (*CheckpointScanner, error) {
+	ans := &CheckpointScanner{
+		paths:      paths,
+		atomStruct: atomStruct,
+	}
+	if from == nil {
+		return ans, nil
+	}
+	ans.fileIdx = from.VerticalIdx
+	if err := ans.openFile(); err != nil {
+		return nil, err
+	}
+	for ans.lineOffset < from.LineOffset && ans.scanner.Scan() {
+		ans.lineOffset++
+	}
+	if err := ans.scanner.Err(); err != nil {
+		ans.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", paths[from.VerticalIdx], err)
+	}
+	for _, line := range from.OpenTags {
+		if m := structOpenRegexp.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			ans.open = append(ans.open, openStruct{name: m[1], line: line})
+		}
+	}
+	ans.numLines = from.ProcessedLines
+	ans.numAtoms = from.ProcessedAtoms
+	ans.last = from
+	return ans, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	checkpointTestVert1 = "<text id=\"t1\">\n<doc id=\"1\">\nfoo\nbar\n</doc>\n<doc id=\"2\">\nbaz\n</doc>\n</text>\n"
+	checkpointTestVert2 = "<text id=\"t2\">\n<doc id=\"3\">\nfoo\n<g/>\nbar\n</doc>\n</text>\n"
+)
+
+func prepareCheckpointVerticals(t *testing.T) []string {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "vert1"), filepath.Join(dir, "vert2.gz")}
+	assert.NoError(t, os.WriteFile(paths[0], []byte(checkpointTestVert1), 0644))
+	writeGzipFile(t, paths[1], checkpointTestVert2)
+	return paths
+}
+
+func TestCheckpointScannerAdvance(t *testing.T) {
+	paths := prepareCheckpointVerticals(t)
+	scanner, err := NewCheckpointScanner(paths, "doc", nil)
+	assert.NoError(t, err)
+	defer scanner.Close()
+
+	cp, err := scanner.Advance(0)
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+
+	// inside the first doc
+	cp, err = scanner.Advance(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cp.VerticalIdx)
+	assert.Equal(t, 1, cp.LineOffset)
+	assert.Equal(t, []string{"<text id=\"t1\">"}, cp.OpenTags)
+	assert.Equal(t, 0, cp.ProcessedAtoms)
+
+	// inside the second doc
+	cp, err = scanner.Advance(7)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, cp.LineOffset)
+	assert.Equal(t, 5, cp.ProcessedLines)
+	assert.Equal(t, 1, cp.ProcessedAtoms)
+
+	// inside the doc of the second vertical
+	cp, err = scanner.Advance(13)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cp.VerticalIdx)
+	assert.Equal(t, 1, cp.LineOffset)
+	assert.Equal(t, 10, cp.ProcessedLines)
+	assert.Equal(t, 2, cp.ProcessedAtoms)
+	assert.Equal(t, []string{"<text id=\"t2\">"}, cp.OpenTags)
+	assert.NoError(t, cp.Validate(paths))
+
+	cp, err = scanner.Advance(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, cp.LineOffset)
+	assert.Equal(t, 3, cp.ProcessedAtoms)
+	assert.Empty(t, cp.OpenTags)
+}
+
+func TestCheckpointScannerFromCheckpoint(t *testing.T) {
+	paths := prepareCheckpointVerticals(t)
+	scanner, err := NewCheckpointScanner(paths, "doc", nil)
+	assert.NoError(t, err)
+	from, err := scanner.Advance(7)
+	assert.NoError(t, err)
+	scanner.Close()
+
+	scanner, err = NewCheckpointScanner(paths, "doc", from)
+	assert.NoError(t, err)
+	defer scanner.Close()
+	cp, err := scanner.Advance(13)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cp.VerticalIdx)
+	assert.Equal(t, 10, cp.ProcessedLines)
+	assert.Equal(t, 2, cp.ProcessedAtoms)
+}
+
+func TestCheckpointValidate(t *testing.T) {
+	paths := prepareCheckpointVerticals(t)
+	scanner, err := NewCheckpointScanner(paths, "doc", nil)
+	assert.NoError(t, err)
+	cp, err := scanner.Advance(3)
+	assert.NoError(t, err)
+	scanner.Close()
+	assert.NoError(t, cp.Validate(paths))
+	assert.Error(t, cp.Validate([]string{}))
+	assert.NoError(t, os.WriteFile(paths[0], []byte("<doc>\n</doc>\n"), 0644))
+	assert.Error(t, cp.Validate(paths))
+}
+
+func TestOpenResumedVerticals(t *testing.T) {
+	paths := prepareCheckpointVerticals(t)
+	scanner, err := NewCheckpointScanner(paths, "doc", nil)
+	assert.NoError(t, err)
+	cp, err := scanner.Advance(7)
+	assert.NoError(t, err)
+	scanner.Close()
+
+	verticals, err := OpenResumedVerticals(paths, cp)
+	assert.NoError(t, err)
+	defer verticals.Close()
+	if assert.Len(t, verticals.Paths, 2) {
+		data, err := os.ReadFile(verticals.Paths[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "<text id=\"t1\">\n<doc id=\"2\">\nbaz\n</doc>\n</text>\n", string(data))
+		f, err := os.Open(verticals.Paths[1])
+		assert.NoError(t, err)
+		defer f.Close()
+		data, err = io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, checkpointTestVert2, string(data))
+	}
+}
@@ -19,9 +19,98 @@
 package liveattrs
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"masm/v3/liveattrs/spool"
+	"net/http"
+	"strings"
+
 	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
 )
 
+const (
+	// AtomInferenceSingle infers atom structure only in case
+	// there is just a single structure involved
+	AtomInferenceSingle = "single"
+
+	// AtomInferencePreferred picks the first available structure
+	// from AtomInferenceConf.PreferredStructs
+	AtomInferencePreferred = "preferred"
+
+	// AtomInferenceCoverage picks the structure with ratio of covered
+	// corpus positions closest to 1.0
+	AtomInferenceCoverage = "coverage"
+)
+
+// AtomInferenceConf specifies how an atom structure is inferred
+// in case it is not specified explicitly
+type AtomInferenceConf struct {
+	Strategy         string   `json:"strategy"`
+	PreferredStructs []string `json:"preferredStructs"`
+}
+
+func (conf AtomInferenceConf) Validate() error {
+	switch conf.Strategy {
+	case AtomInferenceSingle, AtomInferencePreferred, AtomInferenceCoverage:
+		return nil
+	default:
+		return fmt.Errorf("invalid atom inference strategy: %s", conf.Strategy)
+	}
+}
+
+// AttrListSizeConf configures how many values of an attribute can be
+// listed in a response before the attribute is just summarized
+// (see query.Payload.MaxAttrListSize)
+type AttrListSizeConf struct {
+
+	// Default is used in case a client does not specify the size
+	Default int `json:"default"`
+
+	// Max is an upper limit of client-supplied sizes (zero means no limit)
+	Max int `json:"max"`
+
+	// Corpora overrides Default for specific corpora (corpus ID => size)
+	Corpora map[string]int `json:"corpora"`
+}
+
+func (conf AttrListSizeConf) Validate() error {
+	if conf.Default <= 0 {
+		return fmt.Errorf("default must be a positive number")
+	}
+	if conf.Max < 0 {
+		return fmt.Errorf("max must not be negative")
+	}
+	if conf.Max > 0 && conf.Default > conf.Max {
+		return fmt.Errorf("default (%d) must not be greater than max (%d)", conf.Default, conf.Max)
+	}
+	for corpusID, size := range conf.Corpora {
+		if size <= 0 {
+			return fmt.Errorf("size for corpus %s must be a positive number", corpusID)
+		}
+		if conf.Max > 0 && size > conf.Max {
+			return fmt.Errorf("size for corpus %s (%d) must not be greater than max (%d)", corpusID, size, conf.Max)
+		}
+	}
+	return nil
+}
+
+// Effective returns a size to be used for a corpus based on a client-supplied
+// size (zero or a negative value means the client does not specify the size).
+// Sizes exceeding Max are clamped.
+func (conf AttrListSizeConf) Effective(corpusID string, requested int) int {
+	ans := requested
+	if ans <= 0 {
+		ans = conf.Default
+		if size, ok := conf.Corpora[corpusID]; ok {
+			ans = size
+		}
+	}
+	if conf.Max > 0 && ans > conf.Max {
+		ans = conf.Max
+	}
+	return ans
+}
+
 type Conf struct {
 	DB *vtedb.Conf `json:"db"`
 
@@ -31,9 +120,74 @@ type Conf struct {
 	ConfDirPath          string `json:"confDirPath"`
 	VertMaxNumErrors     int    `json:"vertMaxNumErrors"`
 	VerticalFilesDirPath string `json:"verticalFilesDirPath"`
+
+	// TextTypesDbDownload enables downloading of SQLite text types
+	// databases from TextTypesDbDirPath. If nil, the download is disabled.
+	TextTypesDbDownload *TextTypesDbDownloadConf `json:"textTypesDbDownload"`
+
+	// MultiQueryMaxWorkers limits number of concurrently processed
+	// corpora in a multi-corpus query
+	MultiQueryMaxWorkers int `json:"multiQueryMaxWorkers"`
+
+	// MultiQueryMaxCorpora limits number of corpora a single
+	// multi-corpus query can contain
+	MultiQueryMaxCorpora int `json:"multiQueryMaxCorpora"`
+
+	AtomInference AtomInferenceConf `json:"atomInference"`
+
+	// FacetIndexDirPath is a directory where precomputed facet indexes
+	// are stored. If empty, facet indexes are disabled.
+	FacetIndexDirPath string `json:"facetIndexDirPath"`
+
+	// DocumentListSpool configures spooling of huge document lists
+	// to disk (see spool.Conf)
+	DocumentListSpool spool.Conf `json:"documentListSpool"`
+
+	// DryRunSampleAtoms is a default number of atoms (e.g. documents)
+	// processed by a dry-run data extraction
+	DryRunSampleAtoms int `json:"dryRunSampleAtoms"`
+
+	// AttrListSize configures numbers of listed attribute values
+	AttrListSize AttrListSizeConf `json:"attrListSize"`
 }
 
 type NgramDBConf struct {
 	URL             string   `json:"url"`
 	ReadAccessUsers []string `json:"readAccessUsers"`
 }
+
+// TextTypesDbDownloadConf configures downloading of generated
+// SQLite text types databases by other installations
+type TextTypesDbDownloadConf struct {
+
+	// Tokens are accepted values of the `Authorization: Bearer ...`
+	// request header
+	Tokens []string `json:"tokens"`
+}
+
+func (conf *TextTypesDbDownloadConf) Validate() error {
+	if len(conf.Tokens) == 0 {
+		return fmt.Errorf("at least one token must be specified")
+	}
+	for _, token := range conf.Tokens {
+		if token == "" {
+			return fmt.Errorf("tokens must not be empty")
+		}
+	}
+	return nil
+}
+
+// IsAuthorized tests whether a request contains one of the tokens
+func (conf *TextTypesDbDownloadConf) IsAuthorized(req *http.Request) bool {
+	auth := req.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, t := range conf.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testingAttrListSizeConf() AttrListSizeConf {
+	return AttrListSizeConf{
+		Default: 30,
+		Max:     500,
+		Corpora: map[string]int{"syn2020": 10},
+	}
+}
+
+func TestAttrListSizeEffective(t *testing.T) {
+	conf := testingAttrListSizeConf()
+	assert.Equal(t, 30, conf.Effective("susanne", 0))
+	assert.Equal(t, 10, conf.Effective("syn2020", 0))
+	assert.Equal(t, 10, conf.Effective("syn2020", -1))
+	assert.Equal(t, 100, conf.Effective("syn2020", 100))
+	assert.Equal(t, 500, conf.Effective("susanne", 100000))
+}
+
+func TestAttrListSizeEffectiveNoMax(t *testing.T) {
+	conf := AttrListSizeConf{Default: 30}
+	assert.Equal(t, 100000, conf.Effective("susanne", 100000))
+}
+
+func TestAttrListSizeValidate(t *testing.T) {
+	conf := testingAttrListSizeConf()
+	assert.NoError(t, conf.Validate())
+	conf.Corpora["syn2015"] = 501
+	assert.Error(t, conf.Validate())
+	conf = testingAttrListSizeConf()
+	conf.Default = 600
+	assert.Error(t, conf.Validate())
+	conf = testingAttrListSizeConf()
+	conf.Default = 0
+	assert.Error(t, conf.Validate())
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/attrdeps"
+	"masm/v3/liveattrs/request/response"
+)
+
+// valuePairCount is a number of items with a specific
+// combination of values of two attributes
+type valuePairCount struct {
+	value    string
+	depValue string
+	numItems int
+}
+
+// calcAttrDependency calculates dependency measures (see response.AttrDependency)
+// out of numbers of items for value combinations
+func calcAttrDependency(counts []valuePairCount, ans *response.AttrDependency) {
+	maxPerValue := make(map[string]int)
+	depTotals := make(map[string]int)
+	for _, cnt := range counts {
+		ans.NumItems += cnt.numItems
+		depTotals[cnt.depValue] += cnt.numItems
+		if cnt.numItems > maxPerValue[cnt.value] {
+			maxPerValue[cnt.value] = cnt.numItems
+		}
+	}
+	ans.NumAttrValues = len(maxPerValue)
+	ans.NumDependentAttrValues = len(depTotals)
+	if ans.NumItems == 0 {
+		return
+	}
+	var numPredicted, maxDep int
+	for _, v := range maxPerValue {
+		numPredicted += v
+	}
+	for _, v := range depTotals {
+		if v > maxDep {
+			maxDep = v
+		}
+	}
+	ans.Ratio = float64(numPredicted) / float64(ans.NumItems)
+	if maxDep == ans.NumItems {
+		// a constant is trivially determined by anything
+		ans.Lambda = 1
+		return
+	}
+	ans.Lambda = float64(numPredicted-maxDep) / float64(ans.NumItems-maxDep)
+}
+
+// GetAttrDependency calculates how strongly values of an attribute
+// are determined by values of another attribute within a selection
+// specified by `qry`. In case there are no matching items,
+// ErrorEmptyResult is returned.
+func GetAttrDependency(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrTypes laconf.AttrTypes,
+	qry attrdeps.Payload,
+) (*response.AttrDependency, error) {
+	adep := adhoc.AttrDependency{
+		Selection: adhoc.Selection{
+			CorpusInfo:     corpusInfo,
+			AttrMap:        qry.Attrs,
+			AlignedCorpora: qry.Aligned,
+			AttrTypes:      attrTypes,
+		},
+		Attr:          qry.Attr,
+		DependentAttr: qry.DependentAttr,
+	}
+	sqlq, args, err := adep.Query()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := laDB.Query(sqlq, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make([]valuePairCount, 0, 100)
+	for rows.Next() {
+		var value, depValue sql.NullString
+		var cnt valuePairCount
+		if err := rows.Scan(&value, &depValue, &cnt.numItems); err != nil {
+			return nil, err
+		}
+		cnt.value = value.String
+		cnt.depValue = depValue.String
+		counts = append(counts, cnt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, ErrorEmptyResult
+	}
+	ans := &response.AttrDependency{Attr: qry.Attr, DependentAttr: qry.DependentAttr}
+	calcAttrDependency(counts, ans)
+	return ans, nil
+}
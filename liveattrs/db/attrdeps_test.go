@@ -0,0 +1,73 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/request/response"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcAttrDependencyFunctional(t *testing.T) {
+	var ans response.AttrDependency
+	calcAttrDependency(
+		[]valuePairCount{
+			{value: "t1", depValue: "a1", numItems: 3},
+			{value: "t2", depValue: "a1", numItems: 2},
+			{value: "t3", depValue: "a2", numItems: 5},
+		},
+		&ans,
+	)
+	assert.Equal(t, 10, ans.NumItems)
+	assert.Equal(t, 3, ans.NumAttrValues)
+	assert.Equal(t, 2, ans.NumDependentAttrValues)
+	assert.Equal(t, 1.0, ans.Ratio)
+	assert.Equal(t, 1.0, ans.Lambda)
+}
+
+func TestCalcAttrDependencyPartial(t *testing.T) {
+	var ans response.AttrDependency
+	calcAttrDependency(
+		[]valuePairCount{
+			{value: "fiction", depValue: "a1", numItems: 6},
+			{value: "fiction", depValue: "a2", numItems: 2},
+			{value: "news", depValue: "a2", numItems: 1},
+			{value: "news", depValue: "a3", numItems: 1},
+		},
+		&ans,
+	)
+	assert.Equal(t, 10, ans.NumItems)
+	assert.InDelta(t, 0.7, ans.Ratio, 0.0001)
+	// the most frequent value a1 covers 6 items
+	assert.InDelta(t, 0.25, ans.Lambda, 0.0001)
+}
+
+func TestCalcAttrDependencyConstant(t *testing.T) {
+	var ans response.AttrDependency
+	calcAttrDependency(
+		[]valuePairCount{
+			{value: "t1", depValue: "cs", numItems: 3},
+			{value: "t2", depValue: "cs", numItems: 2},
+		},
+		&ans,
+	)
+	assert.Equal(t, 1.0, ans.Ratio)
+	assert.Equal(t, 1.0, ans.Lambda)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/attrstats"
+	"masm/v3/liveattrs/request/response"
+)
+
+// histogramBinning calculates a width of histogram bins for values
+// between minVal and maxVal. In case all the values are the same,
+// a single bin of width 1 is used.
+func histogramBinning(minVal, maxVal float64, numBins int) (float64, int) {
+	binWidth := (maxVal - minVal) / float64(numBins)
+	if binWidth == 0 {
+		return 1, 1
+	}
+	return binWidth, numBins
+}
+
+// binBoundaries returns a range of values covered by a histogram bin
+func binBoundaries(minVal, binWidth float64, binIdx int) (float64, float64) {
+	from := minVal + float64(binIdx)*binWidth
+	return from, from + binWidth
+}
+
+// GetAttrStats calculates min, max, mean and a histogram of numeric
+// values of an attribute within a selection specified by `qry`.
+// In case there are no numeric values, ErrorEmptyResult is returned.
+func GetAttrStats(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrTypes laconf.AttrTypes,
+	qry attrstats.Payload,
+) (*response.AttrStats, error) {
+	numBins := qry.NumBins
+	if numBins <= 0 {
+		numBins = attrstats.DfltNumBins
+	}
+	astats := adhoc.AttrStats{
+		Selection: adhoc.Selection{
+			CorpusInfo:     corpusInfo,
+			AttrMap:        qry.Attrs,
+			AlignedCorpora: qry.Aligned,
+			AttrTypes:      attrTypes,
+		},
+		Attr: qry.Attr,
+	}
+	sqlq, args, err := astats.SummaryQuery()
+	if err != nil {
+		return nil, err
+	}
+	var minVal, maxVal, meanVal sql.NullFloat64
+	ans := &response.AttrStats{Attr: qry.Attr, Histogram: []*response.HistogramBin{}}
+	err = laDB.QueryRow(sqlq, args...).Scan(&minVal, &maxVal, &meanVal, &ans.NumItems)
+	if err != nil {
+		return nil, err
+	}
+	if ans.NumItems == 0 || !minVal.Valid || !maxVal.Valid {
+		return nil, ErrorEmptyResult
+	}
+	ans.Min = minVal.Float64
+	ans.Max = maxVal.Float64
+	ans.Mean = meanVal.Float64
+
+	binWidth, numBins := histogramBinning(ans.Min, ans.Max, numBins)
+	sqlq, args, err = astats.HistogramQuery(ans.Min, binWidth, numBins)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := laDB.Query(sqlq, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var binIdx int
+		var bin response.HistogramBin
+		if err := rows.Scan(&binIdx, &bin.NumItems, &bin.Poscount); err != nil {
+			return nil, err
+		}
+		bin.From, bin.To = binBoundaries(ans.Min, binWidth, binIdx)
+		ans.Histogram = append(ans.Histogram, &bin)
+	}
+	return ans, rows.Err()
+}
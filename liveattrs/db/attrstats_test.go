@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramBinning(t *testing.T) {
+	binWidth, numBins := histogramBinning(1990, 2010, 10)
+	assert.Equal(t, 2.0, binWidth)
+	assert.Equal(t, 10, numBins)
+}
+
+func TestHistogramBinningSingleValue(t *testing.T) {
+	binWidth, numBins := histogramBinning(2001, 2001, 10)
+	assert.Equal(t, 1.0, binWidth)
+	assert.Equal(t, 1, numBins)
+}
+
+func TestBinBoundaries(t *testing.T) {
+	from, to := binBoundaries(1990, 2, 0)
+	assert.Equal(t, 1990.0, from)
+	assert.Equal(t, 1992.0, to)
+	// the max. value is clamped to the last bin (see adhoc.AttrStats.HistogramQuery)
+	from, to = binBoundaries(1990, 2, 9)
+	assert.Equal(t, 2008.0, from)
+	assert.Equal(t, 2010.0, to)
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"sort"
+	"strings"
+)
+
+// origTypeCommentPrefix marks typed columns. The rest of the column
+// comment contains the original column type as created by vert-tagextract
+// so the column can be reverted before the table is appended with new data.
+const origTypeCommentPrefix = "masm-orig-type:"
+
+// columnInfo describes a liveattrs table column
+type columnInfo struct {
+	columnType string
+	comment    string
+
+	// collation is empty for non-string columns
+	collation string
+}
+
+func (c columnInfo) isTyped() bool {
+	return strings.HasPrefix(c.comment, origTypeCommentPrefix)
+}
+
+func (c columnInfo) origType() string {
+	return strings.TrimPrefix(c.comment, origTypeCommentPrefix)
+}
+
+func loadColumns(laDB *sql.DB, tableName string) (map[string]columnInfo, error) {
+	rows, err := laDB.Query(
+		"SELECT column_name, column_type, column_comment, COALESCE(collation_name, '') "+
+			"FROM information_schema.columns "+
+			"WHERE table_schema = DATABASE() AND table_name = ?",
+		tableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make(map[string]columnInfo)
+	for rows.Next() {
+		var name string
+		var col columnInfo
+		if err := rows.Scan(&name, &col.columnType, &col.comment, &col.collation); err != nil {
+			return nil, err
+		}
+		ans[name] = col
+	}
+	return ans, rows.Err()
+}
+
+// attrTypesSQL generates statements converting still untyped
+// columns of typed attributes. Values which cannot be converted
+// to the target type (including empty values) are set to NULL.
+// Already typed columns are left untouched.
+func attrTypesSQL(
+	tableName string,
+	columns map[string]columnInfo,
+	attrTypes laconf.AttrTypes,
+) []string {
+	attrs := make([]string, 0, len(attrTypes))
+	for attr := range attrTypes {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	ans := make([]string, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		attrType := attrTypes[attr]
+		sqlType := attrType.SQLType()
+		if sqlType == "" {
+			continue
+		}
+		col := utils.ImportKey(attr)
+		colInfo, ok := columns[col]
+		if !ok || colInfo.isTyped() {
+			continue
+		}
+		ans = append(
+			ans,
+			fmt.Sprintf(
+				"UPDATE `%s` SET %s = NULL WHERE %s NOT REGEXP '%s'",
+				tableName, col, col, attrType.ValuePattern(),
+			),
+			fmt.Sprintf(
+				"ALTER TABLE `%s` MODIFY %s %s COMMENT '%s%s'",
+				tableName, col, sqlType, origTypeCommentPrefix, colInfo.columnType,
+			),
+		)
+	}
+	return ans
+}
+
+// revertAttrTypesSQL generates statements converting typed columns
+// back to their original types (NULL values are kept).
+func revertAttrTypesSQL(tableName string, columns map[string]columnInfo) []string {
+	cols := make([]string, 0, len(columns))
+	for col := range columns {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	ans := make([]string, 0, len(cols))
+	for _, col := range cols {
+		colInfo := columns[col]
+		if !colInfo.isTyped() {
+			continue
+		}
+		ans = append(
+			ans,
+			fmt.Sprintf("ALTER TABLE `%s` MODIFY %s %s", tableName, col, colInfo.origType()),
+		)
+	}
+	return ans
+}
+
+func runStatements(laDB *sql.DB, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := laDB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to run `%s`: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// ApplyAttrTypes converts columns of attributes with declared non-string
+// types into respective typed columns. Values not valid for a respective
+// type (including empty values) are stored as NULL.
+// Please note that ALTER TABLE commits implicitly in MySQL so the operation
+// cannot be atomic. But as only still untyped columns are converted, the
+// function can be safely run again in case of a failure.
+func ApplyAttrTypes(laDB *sql.DB, groupedName string, attrTypes laconf.AttrTypes) error {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to apply attribute types: %w", err)
+	}
+	if err := runStatements(laDB, attrTypesSQL(tableName, columns, attrTypes)); err != nil {
+		return fmt.Errorf("failed to apply attribute types: %w", err)
+	}
+	return nil
+}
+
+// RevertAttrTypes converts all the typed columns back to their original
+// types. This is needed before appending data to an existing table as
+// vert-tagextract stores empty values as empty strings (see ApplyAttrTypes).
+func RevertAttrTypes(laDB *sql.DB, groupedName string) error {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to revert attribute types: %w", err)
+	}
+	if err := runStatements(laDB, revertAttrTypesSQL(tableName, columns)); err != nil {
+		return fmt.Errorf("failed to revert attribute types: %w", err)
+	}
+	return nil
+}
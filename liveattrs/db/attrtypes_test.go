@@ -0,0 +1,93 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrTypesSQLUntypedColumns(t *testing.T) {
+	columns := map[string]columnInfo{
+		"doc_year":    {columnType: "varchar(255)"},
+		"doc_pubdate": {columnType: "varchar(255)"},
+		"doc_title":   {columnType: "varchar(255)"},
+	}
+	attrTypes := laconf.AttrTypes{
+		"doc.year":    laconf.AttrTypeInt,
+		"doc.pubdate": laconf.AttrTypeDate,
+		"doc.title":   laconf.AttrTypeString,
+	}
+	stmts := attrTypesSQL("syn_liveattrs_entry", columns, attrTypes)
+	assert.Equal(
+		t,
+		[]string{
+			"UPDATE `syn_liveattrs_entry` SET doc_pubdate = NULL WHERE doc_pubdate NOT REGEXP " +
+				"'^[0-9]{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$'",
+			"ALTER TABLE `syn_liveattrs_entry` MODIFY doc_pubdate DATE COMMENT 'masm-orig-type:varchar(255)'",
+			"UPDATE `syn_liveattrs_entry` SET doc_year = NULL WHERE doc_year NOT REGEXP '^-?[0-9]+$'",
+			"ALTER TABLE `syn_liveattrs_entry` MODIFY doc_year INTEGER COMMENT 'masm-orig-type:varchar(255)'",
+		},
+		stmts,
+	)
+}
+
+func TestAttrTypesSQLTypedColumnUntouched(t *testing.T) {
+	// an already typed column must not be updated as e.g. '' = 0
+	// for INTEGER columns in MySQL
+	columns := map[string]columnInfo{
+		"doc_year": {columnType: "int(11)", comment: "masm-orig-type:varchar(255)"},
+	}
+	stmts := attrTypesSQL(
+		"syn_liveattrs_entry", columns, laconf.AttrTypes{"doc.year": laconf.AttrTypeInt})
+	assert.Len(t, stmts, 0)
+}
+
+func TestAttrTypesAppendMode(t *testing.T) {
+	// first run: the table contains typed columns from a previous job
+	columns := map[string]columnInfo{
+		"doc_year":  {columnType: "int(11)", comment: "masm-orig-type:varchar(255)"},
+		"doc_title": {columnType: "varchar(255)"},
+	}
+	assert.Equal(
+		t,
+		[]string{"ALTER TABLE `intercorp_liveattrs_entry` MODIFY doc_year varchar(255)"},
+		revertAttrTypesSQL("intercorp_liveattrs_entry", columns),
+	)
+	// after the reverted table is appended, the column is converted again
+	columns["doc_year"] = columnInfo{columnType: "varchar(255)"}
+	stmts := attrTypesSQL(
+		"intercorp_liveattrs_entry", columns, laconf.AttrTypes{"doc.year": laconf.AttrTypeInt})
+	assert.Equal(
+		t,
+		[]string{
+			"UPDATE `intercorp_liveattrs_entry` SET doc_year = NULL WHERE doc_year NOT REGEXP '^-?[0-9]+$'",
+			"ALTER TABLE `intercorp_liveattrs_entry` MODIFY doc_year INTEGER COMMENT 'masm-orig-type:varchar(255)'",
+		},
+		stmts,
+	)
+}
+
+func TestAttrTypesSQLMissingColumn(t *testing.T) {
+	stmts := attrTypesSQL(
+		"syn_liveattrs_entry", map[string]columnInfo{}, laconf.AttrTypes{"doc.year": laconf.AttrTypeInt})
+	assert.Len(t, stmts, 0)
+}
@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"masm/v3/corpus"
 	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/equery"
 	"masm/v3/liveattrs/request/query"
 )
 
@@ -42,21 +44,71 @@ func DeleteTable(tx *sql.Tx, groupedName string, corpusName string) error {
 	return err
 }
 
-func GetSubcSize(laDB *sql.DB, corpusInfo *corpus.DBInfo, corpora []string, attrMap query.Attrs) (int, error) {
+// CountCorpusRows returns number of liveattrs entries of a corpus
+func CountCorpusRows(laDB *sql.DB, groupedName string, corpusName string) (int, error) {
+	var ans int
+	row := laDB.QueryRow(
+		fmt.Sprintf("SELECT COUNT(*) FROM %s_liveattrs_entry WHERE corpus_id = ?", groupedName),
+		corpusName,
+	)
+	if err := row.Scan(&ans); err != nil {
+		return 0, err
+	}
+	return ans, nil
+}
+
+// TableExists tests whether a table of the specified name is present
+// in the current database.
+func TableExists(laDB *sql.DB, tableName string) (bool, error) {
+	var num int
+	row := laDB.QueryRow(
+		"SELECT COUNT(*) FROM information_schema.tables "+
+			"WHERE table_schema = DATABASE() AND table_name = ?",
+		tableName,
+	)
+	if err := row.Scan(&num); err != nil {
+		return false, err
+	}
+	return num > 0, nil
+}
+
+// GetSubcSize calculates size of an ad-hoc subcorpus in a specified unit.
+// The returned slice contains sizes for each of the `corpora` (in the same
+// order) where the first item is the main corpus.
+func GetSubcSize(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	corpora []string,
+	attrMap query.Attrs,
+	attrTypes laconf.AttrTypes,
+	unit equery.SizeUnit,
+) ([]int, error) {
 	sizeCalc := adhoc.SubcSize{
 		CorpusInfo:          corpusInfo,
 		AttrMap:             attrMap,
 		AlignedCorpora:      corpora[1:],
 		EmptyValPlaceholder: "", // TODO !!!!
+		AttrTypes:           attrTypes,
+		Unit:                unit,
+	}
+	sqlq, args, err := sizeCalc.Query()
+	if err != nil {
+		return []int{}, err
 	}
-	sqlq, args := sizeCalc.Query()
 	cur := laDB.QueryRow(sqlq, args...)
-	var ans sql.NullInt64
-	if err := cur.Scan(&ans); err != nil {
-		return 0, err
+	sizes := make([]sql.NullInt64, len(corpora))
+	scanVals := make([]any, len(corpora))
+	for i := range sizes {
+		scanVals[i] = &sizes[i]
+	}
+	if err := cur.Scan(scanVals...); err != nil {
+		return []int{}, err
 	}
-	if ans.Valid {
-		return int(ans.Int64), nil
+	ans := make([]int, len(corpora))
+	for i, v := range sizes {
+		if v.Valid {
+			ans[i] = int(v.Int64)
+		}
 	}
-	return 0, nil
+	return ans, nil
 }
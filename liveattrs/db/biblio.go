@@ -22,6 +22,7 @@ import (
 	"database/sql"
 	"fmt"
 	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder"
 	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/biblio"
 	"masm/v3/liveattrs/request/query"
@@ -182,18 +183,7 @@ func mkPlaceholder(times int) string {
 	return strings.Join(ans, ", ")
 }
 
-func extractRegexp(v map[string]any) string {
-	v2, ok := v["regexp"]
-	if ok {
-		v3, ok := v2.(string)
-		if ok {
-			return v3
-		}
-	}
-	return ""
-}
-
-func attrsToSQL(attrs query.Attrs) (string, []any) {
+func attrsToSQL(attrs query.Attrs, attrTypes laconf.AttrTypes) (string, []any) {
 	if len(attrs) == 0 {
 		return "1", []any{}
 	}
@@ -212,29 +202,80 @@ func attrsToSQL(attrs query.Attrs) (string, []any) {
 				}
 			}
 		case map[string]any:
-			v := extractRegexp(tValues)
-			if v != "" {
-				sql = append(
-					sql,
-					fmt.Sprintf("t1.%s REGEXP ?", utils.ImportKey(attr)),
-				)
-				sqlValues = append(sqlValues, v)
-
-			} else {
-				log.Error().Msgf("Incorrect value passed as attribute value filter - map[string]any should contain only 'regexp'")
+			if negVals, ok := attrs.GetNegatedValues(attr); ok {
+				pred, predVals := qbuilder.TypedNegationPredicate(
+					"t1."+utils.ImportKey(attr), attrTypes.Get(attr), negVals)
+				sql = append(sql, pred)
+				for _, v := range predVals {
+					sqlValues = append(sqlValues, v)
+				}
+				continue
+			}
+			if from, to, ok := attrs.GetRangeAttrVal(attr); ok {
+				pred, predVals := qbuilder.RangePredicate(
+					"t1."+utils.ImportKey(attr), attrTypes.Get(attr), from, to)
+				sql = append(sql, pred)
+				for _, v := range predVals {
+					sqlValues = append(sqlValues, v)
+				}
+				continue
+			}
+			op, opVal, ok := attrs.GetOperatorAttrVal(attr)
+			if !ok {
+				log.Error().Msgf("Incorrect value passed as attribute value filter for %s", attr)
+				continue
 			}
+			pred, predVal, err := qbuilder.OperatorPredicate(
+				"t1."+utils.ImportKey(attr), op, opVal)
+			if err != nil {
+				log.Error().Err(err).Msgf("failed to process attribute value filter for %s", attr)
+				continue
+			}
+			sql = append(sql, pred)
+			sqlValues = append(sqlValues, predVal)
 		default:
 			panic(fmt.Sprintf("cannot process non-list attribute values; found: %s", reflect.TypeOf(values)))
 		}
 	}
+	if len(sql) == 0 {
+		return "1", []any{}
+	}
 	return strings.Join(sql, " AND "), sqlValues
 }
 
+// subcorpusToSQL creates a condition restricting entries
+// to a subcorpus (see query.Subcorpus)
+func subcorpusToSQL(
+	subcorpus *query.Subcorpus,
+	bibIDAttr string,
+	attrTypes laconf.AttrTypes,
+) (string, []any) {
+	where := make([]string, 0, 2)
+	values := make([]any, 0, len(subcorpus.StructIDs)+len(subcorpus.Definition))
+	if len(subcorpus.StructIDs) > 0 {
+		where = append(
+			where,
+			qbuilder.InPredicate("t1."+utils.ImportKey(bibIDAttr), len(subcorpus.StructIDs)),
+		)
+		for _, v := range subcorpus.StructIDs {
+			values = append(values, v)
+		}
+	}
+	if len(subcorpus.Definition) > 0 {
+		dSql, dValues := attrsToSQL(subcorpus.Definition, attrTypes)
+		where = append(where, dSql)
+		values = append(values, dValues...)
+	}
+	return strings.Join(where, " AND "), values
+}
+
 func buildQuery(
 	selection []string,
 	corpusInfo *corpus.DBInfo,
 	alignedCorpora []string,
 	filterAttrs query.Attrs,
+	subcorpus *query.Subcorpus,
+	attrTypes laconf.AttrTypes,
 ) (string, []any) {
 	sql := strings.Builder{}
 	sql.WriteString(fmt.Sprintf(
@@ -255,9 +296,14 @@ func buildQuery(
 	for _, w := range whereSQL {
 		sql.WriteString(" AND " + w)
 	}
-	aSql, aValues := attrsToSQL(filterAttrs)
+	aSql, aValues := attrsToSQL(filterAttrs, attrTypes)
 	sql.WriteString(" AND " + aSql)
 	queryArgs = append(queryArgs, aValues...)
+	if subcorpus != nil {
+		sSql, sValues := subcorpusToSQL(subcorpus, corpusInfo.BibIDAttr, attrTypes)
+		sql.WriteString(" AND " + sSql)
+		queryArgs = append(queryArgs, sValues...)
+	}
 	sql.WriteString(fmt.Sprintf(" GROUP BY t1.%s", utils.ImportKey(corpusInfo.BibIDAttr)))
 	return sql.String(), queryArgs
 }
@@ -267,8 +313,10 @@ func GetNumOfDocuments(
 	corpusInfo *corpus.DBInfo,
 	alignedCorpora []string,
 	attrs query.Attrs,
+	subcorpus *query.Subcorpus,
+	attrTypes laconf.AttrTypes,
 ) (int, error) {
-	sql, args := buildQuery([]string{"t1.*"}, corpusInfo, alignedCorpora, attrs)
+	sql, args := buildQuery([]string{"t1.*"}, corpusInfo, alignedCorpora, attrs, subcorpus, attrTypes)
 	wsql := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS docitems", sql)
 	row := db.QueryRow(wsql, args...)
 	var ans int
@@ -293,8 +341,54 @@ func GetDocuments(
 	viewAttrs []string,
 	alignedCorpora []string,
 	filterAttrs query.Attrs,
+	subcorpus *query.Subcorpus,
+	attrTypes laconf.AttrTypes,
 	page PageInfo,
 ) ([]*DocumentRow, error) {
+	if page.MaxItems == 0 {
+		var err error
+		page.MaxItems, err = GetNumOfDocuments(
+			db, corpusInfo, alignedCorpora, filterAttrs, subcorpus, attrTypes)
+		if err != nil {
+			return []*DocumentRow{}, err
+		}
+	}
+	ans := make([]*DocumentRow, 0, page.NumItems())
+	err := IterDocuments(
+		db,
+		corpusInfo,
+		viewAttrs,
+		alignedCorpora,
+		filterAttrs,
+		subcorpus,
+		attrTypes,
+		func(doc *DocumentRow) error {
+			doc.Idx += page.Offset()
+			ans = append(ans, doc)
+			return nil
+		},
+	)
+	if err != nil {
+		return []*DocumentRow{}, err
+	}
+	return ans, nil
+}
+
+// IterDocuments passes documents matching a selection one by one
+// to the `fn` function without keeping them in memory. This is
+// suitable for huge results (see GetDocuments for a convenient
+// variant). In case `fn` returns an error, the iteration stops
+// and the error is returned.
+func IterDocuments(
+	db *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	viewAttrs []string,
+	alignedCorpora []string,
+	filterAttrs query.Attrs,
+	subcorpus *query.Subcorpus,
+	attrTypes laconf.AttrTypes,
+	fn func(doc *DocumentRow) error,
+) error {
 	wpAttrs := attrsWithPrefix(viewAttrs)
 	selAttrs := make([]string, 0, len(wpAttrs)+2)
 	selAttrs = append(
@@ -307,27 +401,19 @@ func GetDocuments(
 	)
 	selAttrs = append(selAttrs, "SUM(t1.poscount)")
 	selAttrs = append(selAttrs, wpAttrs...)
-	sqlq, args := buildQuery(selAttrs, corpusInfo, alignedCorpora, filterAttrs)
-	//page.ToSQL(), TODO
+	sqlq, args := buildQuery(selAttrs, corpusInfo, alignedCorpora, filterAttrs, subcorpus, attrTypes)
 	rows, err := db.Query(sqlq, args...)
 	if err == sql.ErrNoRows {
-		return []*DocumentRow{}, nil
+		return nil
 
 	} else if err != nil {
-		return []*DocumentRow{}, err
-	}
-	if page.MaxItems == 0 {
-		var err error
-		page.MaxItems, err = GetNumOfDocuments(db, corpusInfo, alignedCorpora, filterAttrs)
-		if err != nil {
-			return []*DocumentRow{}, err
-		}
+		return err
 	}
-	ans := make([]*DocumentRow, 0, page.NumItems())
+	defer rows.Close()
 	attrVals := make([]sql.NullString, len(viewAttrs))
 	scanVals := make([]any, 3+len(viewAttrs))
 
-	i := page.Offset()
+	var i int
 	for rows.Next() {
 		docEntryLabel := sql.NullString{}
 		docEntry := &DocumentRow{Idx: i}
@@ -336,16 +422,15 @@ func GetDocuments(
 		scanVals[1] = &docEntryLabel
 		scanVals[2] = &docEntry.NumPos
 
-		if docEntryLabel.Valid {
-			docEntry.Label = docEntryLabel.String
-		}
-
 		for i := range attrVals {
 			scanVals[3+i] = &attrVals[i]
 		}
 		err := rows.Scan(scanVals...)
 		if err != nil {
-			return []*DocumentRow{}, err
+			return err
+		}
+		if docEntryLabel.Valid {
+			docEntry.Label = docEntryLabel.String
 		}
 		for i := 3; i < len(scanVals); i++ {
 			if v, ok := scanVals[i].(*sql.NullString); ok {
@@ -354,8 +439,10 @@ func GetDocuments(
 				}
 			}
 		}
-		ans = append(ans, docEntry)
+		if err := fn(docEntry); err != nil {
+			return err
+		}
 		i++
 	}
-	return ans, nil
+	return rows.Err()
 }
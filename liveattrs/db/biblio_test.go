@@ -0,0 +1,73 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrsToSQLEmpty(t *testing.T) {
+	sql, values := attrsToSQL(query.Attrs{}, laconf.AttrTypes{})
+	assert.Equal(t, "1", sql)
+	assert.Len(t, values, 0)
+}
+
+func TestAttrsToSQLWildcard(t *testing.T) {
+	sql, values := attrsToSQL(query.Attrs{"doc.author": map[string]any{"wildcard": "Nov*"}}, laconf.AttrTypes{})
+	assert.Equal(t, "t1.doc_author LIKE ?", sql)
+	assert.Equal(t, []any{"Nov%"}, values)
+}
+
+func TestAttrsToSQLRegexp(t *testing.T) {
+	sql, values := attrsToSQL(query.Attrs{"doc.author": map[string]any{"regexp": "^Nov.*"}}, laconf.AttrTypes{})
+	assert.Equal(t, "t1.doc_author REGEXP ?", sql)
+	assert.Equal(t, []any{"^Nov.*"}, values)
+}
+
+func TestAttrsToSQLNegation(t *testing.T) {
+	sql, values := attrsToSQL(query.Attrs{"doc.genre": map[string]any{"not": []any{"fiction", "poetry"}}}, laconf.AttrTypes{})
+	assert.Equal(t, "t1.doc_genre NOT IN (?, ?)", sql)
+	assert.Equal(t, []any{"fiction", "poetry"}, values)
+}
+
+func TestAttrsToSQLDateRange(t *testing.T) {
+	sql, values := attrsToSQL(
+		query.Attrs{"doc.pubdate": map[string]any{"from": "2001-01-01"}},
+		laconf.AttrTypes{"doc.pubdate": laconf.AttrTypeDate},
+	)
+	assert.Equal(t, "CAST(t1.doc_pubdate AS DATE) >= CAST(? AS DATE)", sql)
+	assert.Equal(t, []any{"2001-01-01"}, values)
+}
+
+func TestSubcorpusToSQL(t *testing.T) {
+	sql, values := subcorpusToSQL(
+		&query.Subcorpus{
+			StructIDs:  []string{"d1", "d2"},
+			Definition: query.Attrs{"doc.genre": []any{"fiction"}},
+		},
+		"doc.id",
+		laconf.AttrTypes{},
+	)
+	assert.Equal(t, "t1.doc_id IN (?, ?) AND  t1.doc_genre IN (?) ", sql)
+	assert.Equal(t, []any{"d1", "d2", "fiction"}, values)
+}
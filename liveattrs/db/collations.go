@@ -0,0 +1,76 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"sort"
+	"strings"
+)
+
+// collationsSQL generates statements changing collations of string
+// columns. Typed columns and columns already having the required
+// collation are left untouched.
+func collationsSQL(
+	tableName string,
+	columns map[string]columnInfo,
+	collations laconf.ColumnCollations,
+) []string {
+	attrs := make([]string, 0, len(collations))
+	for attr := range collations {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	ans := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		col := utils.ImportKey(attr)
+		colInfo, ok := columns[col]
+		if !ok || colInfo.isTyped() || colInfo.collation == "" ||
+			colInfo.collation == collations[attr] {
+			continue
+		}
+		stmt := fmt.Sprintf(
+			"ALTER TABLE `%s` MODIFY %s %s COLLATE %s",
+			tableName, col, colInfo.columnType, collations[attr],
+		)
+		if colInfo.comment != "" {
+			stmt += fmt.Sprintf(" COMMENT '%s'", strings.ReplaceAll(colInfo.comment, "'", "''"))
+		}
+		ans = append(ans, stmt)
+	}
+	return ans
+}
+
+// ApplyCollations sets configured collations of liveattrs table columns.
+// As vert-tagextract creates the table using the default collation,
+// this must be done after each data extraction.
+func ApplyCollations(laDB *sql.DB, groupedName string, collations laconf.ColumnCollations) error {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to apply column collations: %w", err)
+	}
+	if err := runStatements(laDB, collationsSQL(tableName, columns, collations)); err != nil {
+		return fmt.Errorf("failed to apply column collations: %w", err)
+	}
+	return nil
+}
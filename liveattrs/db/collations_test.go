@@ -0,0 +1,54 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollationsSQL(t *testing.T) {
+	columns := map[string]columnInfo{
+		"doc_author": {columnType: "varchar(255)", collation: "utf8mb4_general_ci"},
+		"doc_lang":   {columnType: "varchar(255)", collation: "utf8mb4_bin"},
+		"doc_title":  {columnType: "text", collation: "utf8mb4_general_ci"},
+	}
+	collations := laconf.ColumnCollations{
+		"doc.author": "utf8mb4_czech_ci",
+		"doc.lang":   "utf8mb4_bin",
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"ALTER TABLE `syn_liveattrs_entry` MODIFY doc_author varchar(255) COLLATE utf8mb4_czech_ci",
+		},
+		collationsSQL("syn_liveattrs_entry", columns, collations),
+	)
+}
+
+func TestCollationsSQLTypedColumnUntouched(t *testing.T) {
+	columns := map[string]columnInfo{
+		"doc_year": {columnType: "int(11)", comment: "masm-orig-type:varchar(255)"},
+	}
+	stmts := collationsSQL(
+		"syn_liveattrs_entry", columns, laconf.ColumnCollations{"doc.year": "utf8mb4_bin"})
+	assert.Empty(t, stmts)
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+)
+
+// detectedIntPattern matches integers which can be safely stored
+// in an INTEGER column. Unlike with declared types, we cannot rely
+// on the user here so longer values (e.g. IDs) are kept as strings.
+const detectedIntPattern = "^-?[0-9]{1,9}$"
+
+// columnTypeDetectionQuery returns a query counting non-empty values
+// of an attribute along with numbers of values suitable for typed
+// columns (int, date). All the corpora sharing the table are examined
+// as typed columns are shared too.
+func columnTypeDetectionQuery(tableName, attr string) string {
+	col := utils.ImportKey(attr)
+	return fmt.Sprintf(
+		"SELECT COUNT(*), COALESCE(SUM(%s REGEXP '%s'), 0), COALESCE(SUM(%s REGEXP '%s'), 0) "+
+			"FROM `%s` WHERE %s IS NOT NULL AND %s <> ''",
+		col, detectedIntPattern, col, laconf.AttrTypeDate.ValuePattern(),
+		tableName, col, col,
+	)
+}
+
+// detectedColumnType returns a type with a typed column matching all the
+// non-empty values of an attribute. In case there is no such type,
+// an empty value is returned.
+func detectedColumnType(numValues, numInts, numDates int) laconf.AttrType {
+	if numValues == 0 {
+		return ""
+	}
+	if numInts == numValues {
+		return laconf.AttrTypeInt
+	}
+	if numDates == numValues {
+		return laconf.AttrTypeDate
+	}
+	return ""
+}
+
+// DetectColumnTypes examines extracted values of attributes `attrs` and
+// returns types for attributes which can be stored in typed (integer
+// or date) columns. Attributes with missing or already typed columns
+// are ignored.
+func DetectColumnTypes(laDB *sql.DB, groupedName string, attrs []string) (laconf.AttrTypes, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect column types: %w", err)
+	}
+	ans := make(laconf.AttrTypes)
+	for _, attr := range attrs {
+		colInfo, ok := columns[utils.ImportKey(attr)]
+		if !ok || colInfo.isTyped() {
+			continue
+		}
+		var numValues, numInts, numDates int
+		err := laDB.QueryRow(columnTypeDetectionQuery(tableName, attr)).
+			Scan(&numValues, &numInts, &numDates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect type of %s: %w", attr, err)
+		}
+		if tp := detectedColumnType(numValues, numInts, numDates); tp != "" {
+			ans[attr] = tp
+		}
+	}
+	return ans, nil
+}
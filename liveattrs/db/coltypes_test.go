@@ -0,0 +1,44 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnTypeDetectionQuery(t *testing.T) {
+	assert.Equal(
+		t,
+		"SELECT COUNT(*), COALESCE(SUM(doc_year REGEXP '^-?[0-9]{1,9}$'), 0), "+
+			"COALESCE(SUM(doc_year REGEXP '^[0-9]{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$'), 0) "+
+			"FROM `syn_liveattrs_entry` WHERE doc_year IS NOT NULL AND doc_year <> ''",
+		columnTypeDetectionQuery("syn_liveattrs_entry", "doc.year"),
+	)
+}
+
+func TestDetectedColumnType(t *testing.T) {
+	assert.Equal(t, laconf.AttrType(""), detectedColumnType(0, 0, 0))
+	assert.Equal(t, laconf.AttrTypeInt, detectedColumnType(10, 10, 0))
+	assert.Equal(t, laconf.AttrTypeDate, detectedColumnType(10, 0, 10))
+	assert.Equal(t, laconf.AttrType(""), detectedColumnType(10, 5, 5))
+	assert.Equal(t, laconf.AttrType(""), detectedColumnType(10, 9, 0))
+}
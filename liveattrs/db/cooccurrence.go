@@ -0,0 +1,147 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/facetidx"
+	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/cooccurrence"
+	"masm/v3/liveattrs/request/response"
+)
+
+// cooccurrenceRowMatches tests whether a row with provided constraint flags
+// belongs to the selection with constraint of the attribute `attr` removed.
+func cooccurrenceRowMatches(attr string, constrained []string, flags []bool) bool {
+	for i, flag := range flags {
+		if !flag && constrained[i] != attr {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCooccurrence finds values of target attributes which are still
+// combinable with the selection specified by `qry` along with their
+// position counts. For a target attribute which is also a part
+// of the selection, its own constraint is ignored so a client can see
+// alternatives to the current choice.
+func GetCooccurrence(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrTypes laconf.AttrTypes,
+	qry cooccurrence.Payload,
+	emptyValPlaceholder string,
+) (*response.Cooccurrence, error) {
+	cooc := adhoc.Cooccurrence{
+		Selection: adhoc.Selection{
+			CorpusInfo:     corpusInfo,
+			AttrMap:        qry.Attrs,
+			AlignedCorpora: qry.Aligned,
+			AttrTypes:      attrTypes,
+		},
+		TargetAttrs: qry.TargetAttrs,
+	}
+	constrained, err := cooc.ConstrainedAttrs()
+	if err != nil {
+		return nil, err
+	}
+	sqlq, args, err := cooc.Query()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := laDB.Query(sqlq, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for _, attr := range qry.TargetAttrs {
+		counts[attr] = make(map[string]int)
+	}
+	var poscount int
+	values := make([]sql.NullString, len(qry.TargetAttrs))
+	flags := make([]bool, len(constrained))
+	dest := make([]any, 0, 1+len(values)+len(flags))
+	dest = append(dest, &poscount)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	for i := range flags {
+		dest = append(dest, &flags[i])
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, attr := range qry.TargetAttrs {
+			if !cooccurrenceRowMatches(attr, constrained, flags) {
+				continue
+			}
+			val := values[i].String
+			if val == "" {
+				val = emptyValPlaceholder
+			}
+			counts[attr][val] += poscount
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newCooccurrenceResponse(counts, qry.MaxValues), nil
+}
+
+func newCooccurrenceResponse(counts map[string]map[string]int, maxValues int) *response.Cooccurrence {
+	ans := &response.Cooccurrence{Values: make(map[string]any)}
+	for attr, attrCounts := range counts {
+		if maxValues > 0 && len(attrCounts) > maxValues {
+			ans.Values[attr] = response.SummarizedValue{Length: len(attrCounts)}
+
+		} else {
+			ans.Values[attr] = attrCounts
+		}
+	}
+	return ans
+}
+
+// GetCooccurrenceFromIndex is an alternative to GetCooccurrence
+// using a precomputed facet index instead of the database.
+// The selection must be convertible via facetidx.SelectionFromAttrs
+// and all the involved attributes must be indexed.
+func GetCooccurrenceFromIndex(
+	idx *facetidx.Index,
+	selection map[string][]string,
+	qry cooccurrence.Payload,
+	emptyValPlaceholder string,
+) (*response.Cooccurrence, error) {
+	counts, err := idx.Facets(selection, qry.TargetAttrs)
+	if err != nil {
+		return nil, err
+	}
+	for _, attrCounts := range counts {
+		if cnt, ok := attrCounts[""]; ok {
+			delete(attrCounts, "")
+			attrCounts[emptyValPlaceholder] += cnt
+		}
+	}
+	return newCooccurrenceResponse(counts, qry.MaxValues), nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooccurrenceRowMatchesAllSatisfied(t *testing.T) {
+	constrained := []string{"doc.genre", "doc.year"}
+	assert.True(t, cooccurrenceRowMatches("doc.genre", constrained, []bool{true, true}))
+	assert.True(t, cooccurrenceRowMatches("doc.author", constrained, []bool{true, true}))
+}
+
+func TestCooccurrenceRowMatchesOwnConstraintIgnored(t *testing.T) {
+	constrained := []string{"doc.genre", "doc.year"}
+	assert.True(t, cooccurrenceRowMatches("doc.genre", constrained, []bool{false, true}))
+	assert.False(t, cooccurrenceRowMatches("doc.year", constrained, []bool{false, true}))
+	assert.False(t, cooccurrenceRowMatches("doc.author", constrained, []bool{false, true}))
+}
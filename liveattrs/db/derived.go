@@ -0,0 +1,103 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/utils"
+	"sort"
+)
+
+// derivedColumnType is an SQL type of columns created
+// for derived attributes (i.e. attributes not present
+// in the vertical)
+const derivedColumnType = "VARCHAR(255)"
+
+func addColumnSQL(tableName, col string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", tableName, col, derivedColumnType)
+}
+
+func setDerivedValueSQL(tableName, col, idCol string) string {
+	return fmt.Sprintf(
+		"UPDATE `%s` SET `%s` = ? WHERE corpus_id = ? AND `%s` = ?",
+		tableName, col, idCol,
+	)
+}
+
+// StoreDerivedValues writes values of a derived attribute `attr` to rows
+// of the corpus `corpusID` identified by values of the attribute `idAttr`
+// (`values` maps the IDs to the derived values). In case the table has
+// no column for the attribute, the column is created. The function returns
+// the number of updated rows.
+func StoreDerivedValues(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	idAttr, attr string,
+	values map[string]string,
+) (int64, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+	}
+	idCol := utils.ImportKey(idAttr)
+	if _, ok := columns[idCol]; !ok {
+		return 0, fmt.Errorf("failed to store derived attribute %s: missing column %s", attr, idCol)
+	}
+	col := utils.ImportKey(attr)
+	if _, ok := columns[col]; !ok {
+		if _, err := laDB.Exec(addColumnSQL(tableName, col)); err != nil {
+			return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+		}
+	}
+	ids := make([]string, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	tx, err := laDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+	}
+	stmt, err := tx.Prepare(setDerivedValueSQL(tableName, col, idCol))
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+	}
+	defer stmt.Close()
+	var ans int64
+	for _, id := range ids {
+		res, err := stmt.Exec(values[id], corpusID, id)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+		}
+		num, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+		}
+		ans += num
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to store derived attribute %s: %w", attr, err)
+	}
+	return ans, nil
+}
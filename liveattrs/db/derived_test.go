@@ -0,0 +1,41 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddColumnSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"ALTER TABLE `syn2020_liveattrs_entry` ADD COLUMN `doc_lang_detected` VARCHAR(255)",
+		addColumnSQL("syn2020_liveattrs_entry", "doc_lang_detected"),
+	)
+}
+
+func TestSetDerivedValueSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"UPDATE `syn2020_liveattrs_entry` SET `doc_lang_detected` = ? WHERE corpus_id = ? AND `doc_id` = ?",
+		setDerivedValueSQL("syn2020_liveattrs_entry", "doc_lang_detected", "doc_id"),
+	)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/request/response"
+)
+
+// findSampleTable finds a liveattrs table in an SQLite database
+// created by a dry-run data extraction
+func findSampleTable(sdb *sql.DB) (string, error) {
+	var ans string
+	row := sdb.QueryRow(
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE '%liveattrs_entry'")
+	if err := row.Scan(&ans); err == sql.ErrNoRows {
+		return "", ErrorEmptyResult
+
+	} else if err != nil {
+		return "", err
+	}
+	return ans, nil
+}
+
+// GetSampleStats examines a liveattrs table created by a dry-run
+// data extraction to an SQLite database. Sizes of the sample (rows,
+// bytes) and cardinalities of all the columns (except for `id`)
+// are returned.
+func GetSampleStats(sdb *sql.DB) (*response.ExtractionDryRun, error) {
+	table, err := findSampleTable(sdb)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := sdb.Query(fmt.Sprintf("PRAGMA table_info(`%s`)", table))
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]string, 0, 20)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if name != "id" {
+			cols = append(cols, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	ans := &response.ExtractionDryRun{Columns: make([]response.DryRunColumn, 0, len(cols))}
+	row := sdb.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM `%s`", table))
+	if err := row.Scan(&ans.SampleRows); err != nil {
+		return nil, err
+	}
+	for _, col := range cols {
+		item := response.DryRunColumn{Name: col}
+		row := sdb.QueryRow(fmt.Sprintf("SELECT COUNT(DISTINCT `%s`) FROM `%s`", col, table))
+		if err := row.Scan(&item.Cardinality); err != nil {
+			return nil, err
+		}
+		ans.Columns = append(ans.Columns, item)
+	}
+	var pageCount, pageSize int64
+	if err := sdb.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return nil, err
+	}
+	if err := sdb.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return nil, err
+	}
+	ans.SampleSizeBytes = pageCount * pageSize
+	return ans, nil
+}
+
+// ProjectSampleStats extrapolates sizes of a sample table to
+// the whole vertical based on the ratio of numbers of lines
+// of the sample and the whole vertical.
+func ProjectSampleStats(stats *response.ExtractionDryRun, estimatedNumLines int) {
+	stats.EstimatedNumLines = estimatedNumLines
+	if stats.SampleLines == 0 || estimatedNumLines <= stats.SampleLines {
+		stats.ProjectedRows = stats.SampleRows
+		stats.ProjectedSizeBytes = stats.SampleSizeBytes
+		return
+	}
+	ratio := float64(estimatedNumLines) / float64(stats.SampleLines)
+	stats.ProjectedRows = int(float64(stats.SampleRows) * ratio)
+	stats.ProjectedSizeBytes = int64(float64(stats.SampleSizeBytes) * ratio)
+}
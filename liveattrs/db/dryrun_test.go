@@ -0,0 +1,49 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/request/response"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectSampleStats(t *testing.T) {
+	stats := &response.ExtractionDryRun{
+		SampleLines:     1000,
+		SampleRows:      10,
+		SampleSizeBytes: 4096,
+	}
+	ProjectSampleStats(stats, 100000)
+	assert.Equal(t, 100000, stats.EstimatedNumLines)
+	assert.Equal(t, 1000, stats.ProjectedRows)
+	assert.Equal(t, int64(409600), stats.ProjectedSizeBytes)
+}
+
+func TestProjectSampleStatsWholeVertical(t *testing.T) {
+	stats := &response.ExtractionDryRun{
+		SampleLines:     1000,
+		SampleRows:      10,
+		SampleSizeBytes: 4096,
+	}
+	ProjectSampleStats(stats, 900)
+	assert.Equal(t, 10, stats.ProjectedRows)
+	assert.Equal(t, int64(4096), stats.ProjectedSizeBytes)
+}
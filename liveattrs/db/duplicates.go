@@ -0,0 +1,104 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GetMaxRowID returns the highest row ID of a liveattrs table.
+// Rows inserted later (e.g. by a job in the append mode) have
+// higher IDs. For a missing or an empty table, 0 is returned.
+func GetMaxRowID(laDB *sql.DB, groupedName string) (int64, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	exists, err := TableExists(laDB, tableName)
+	if err != nil || !exists {
+		return 0, err
+	}
+	var ans sql.NullInt64
+	row := laDB.QueryRow(fmt.Sprintf("SELECT MAX(id) FROM `%s`", tableName))
+	if err := row.Scan(&ans); err != nil {
+		return 0, err
+	}
+	return ans.Int64, nil
+}
+
+func findDuplicatesSQL(tableName, idCol string) string {
+	return fmt.Sprintf(
+		"SELECT DISTINCT t1.`%s` FROM `%s` AS t1 "+
+			"WHERE t1.corpus_id = ? AND t1.id > ? AND t1.`%s` IS NOT NULL AND EXISTS ("+
+			"SELECT 1 FROM `%s` AS t2 WHERE t2.corpus_id = t1.corpus_id AND t2.id <= ? AND t2.`%s` = t1.`%s`"+
+			") ORDER BY t1.`%s`",
+		idCol, tableName, idCol, tableName, idCol, idCol, idCol,
+	)
+}
+
+// FindAppendedDuplicates searches rows of a corpus inserted after
+// the row `baseRowID` (i.e. appended data) for documents (identified
+// by the column `idCol`) already present in the previous data.
+func FindAppendedDuplicates(
+	laDB *sql.DB,
+	groupedName, corpusID, idCol string,
+	baseRowID int64,
+) ([]string, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	rows, err := laDB.Query(
+		findDuplicatesSQL(tableName, idCol), corpusID, baseRowID, baseRowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find appended duplicates: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]string, 0, 10)
+	for rows.Next() {
+		var docID string
+		if err := rows.Scan(&docID); err != nil {
+			return nil, fmt.Errorf("failed to find appended duplicates: %w", err)
+		}
+		ans = append(ans, docID)
+	}
+	return ans, rows.Err()
+}
+
+// RemoveAppendedRows removes rows of a corpus inserted after the row
+// `baseRowID`. In case `docIDs` are provided, only the rows of the
+// respective documents (identified by the column `idCol`) are removed.
+func RemoveAppendedRows(
+	laDB *sql.DB,
+	groupedName, corpusID, idCol string,
+	baseRowID int64,
+	docIDs []string,
+) (int64, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	sqlq := fmt.Sprintf("DELETE FROM `%s` WHERE corpus_id = ? AND id > ?", tableName)
+	args := []any{corpusID, baseRowID}
+	if len(docIDs) > 0 {
+		sqlq += fmt.Sprintf(
+			" AND `%s` IN (%s)", idCol, strings.TrimSuffix(strings.Repeat("?, ", len(docIDs)), ", "))
+		for _, v := range docIDs {
+			args = append(args, v)
+		}
+	}
+	res, err := laDB.Exec(sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove appended rows: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicatesSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.`doc_id` FROM `syn_liveattrs_entry` AS t1 "+
+			"WHERE t1.corpus_id = ? AND t1.id > ? AND t1.`doc_id` IS NOT NULL AND EXISTS ("+
+			"SELECT 1 FROM `syn_liveattrs_entry` AS t2 WHERE t2.corpus_id = t1.corpus_id "+
+			"AND t2.id <= ? AND t2.`doc_id` = t1.`doc_id`) ORDER BY t1.`doc_id`",
+		findDuplicatesSQL("syn_liveattrs_entry", "doc_id"),
+	)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package facetidx
+
+import "math/bits"
+
+// Bitmap is a compact set of row indices. Sparse sets are stored
+// as a sorted array of indices, dense ones as a plain bitset
+// (the representation is chosen by Optimize based on the number
+// of members). Exported fields are required by the gob encoding.
+type Bitmap struct {
+	Size  int
+	Array []uint32
+	Words []uint64
+}
+
+// Add adds a row index to the bitmap. Indices must be added
+// in ascending order and before Optimize is called.
+func (b *Bitmap) Add(i uint32) {
+	b.Array = append(b.Array, i)
+}
+
+// Optimize switches the bitmap to the most compact representation.
+// Once there are more than Size/32 members, a bitset is smaller
+// than a list of 32-bit indices.
+func (b *Bitmap) Optimize() {
+	if b.Words != nil || len(b.Array) <= b.Size/32 {
+		return
+	}
+	b.Words = make([]uint64, numWords(b.Size))
+	for _, i := range b.Array {
+		b.Words[i/64] |= 1 << (i % 64)
+	}
+	b.Array = nil
+}
+
+// Len returns number of members
+func (b *Bitmap) Len() int {
+	if b.Words == nil {
+		return len(b.Array)
+	}
+	var ans int
+	for _, w := range b.Words {
+		ans += bits.OnesCount64(w)
+	}
+	return ans
+}
+
+// ForEach calls fn for each member of the bitmap
+// (in ascending order)
+func (b *Bitmap) ForEach(fn func(i uint32)) {
+	if b.Words == nil {
+		for _, i := range b.Array {
+			fn(i)
+		}
+		return
+	}
+	for wi, w := range b.Words {
+		for w != 0 {
+			fn(uint32(wi*64 + bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+}
+
+// orInto adds members of the bitmap to a mask
+func (b *Bitmap) orInto(m mask) {
+	if b.Words == nil {
+		for _, i := range b.Array {
+			m.set(i)
+		}
+		return
+	}
+	for wi, w := range b.Words {
+		m[wi] |= w
+	}
+}
+
+func numWords(size int) int {
+	return (size + 63) / 64
+}
+
+// mask is a bitset used for evaluating selections
+type mask []uint64
+
+func newMask(size int) mask {
+	return make(mask, numWords(size))
+}
+
+func (m mask) set(i uint32) {
+	m[i/64] |= 1 << (i % 64)
+}
+
+func (m mask) has(i uint32) bool {
+	return m[i/64]&(1<<(i%64)) != 0
+}
+
+// and intersects the mask with another one in place
+func (m mask) and(other mask) {
+	for i := range m {
+		m[i] &= other[i]
+	}
+}
+
+func (m mask) clone() mask {
+	ans := make(mask, len(m))
+	copy(ans, m)
+	return ans
+}
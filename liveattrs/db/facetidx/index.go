@@ -0,0 +1,251 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package facetidx
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/utils"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Index is a precomputed membership of liveattrs entries (rows)
+// in attribute values. It allows calculating faceted counts
+// of a selection without querying the database.
+type Index struct {
+	CorpusID  string
+	Created   time.Time
+	Poscounts []int
+	Values    map[string]map[string]*Bitmap
+}
+
+// NumRows returns number of indexed liveattrs entries
+func (idx *Index) NumRows() int {
+	return len(idx.Poscounts)
+}
+
+// HasAttr tests whether an attribute (in the `struct.attr` form)
+// is indexed
+func (idx *Index) HasAttr(attr string) bool {
+	_, ok := idx.Values[attr]
+	return ok
+}
+
+// Attrs returns sorted indexed attributes
+func (idx *Index) Attrs() []string {
+	ans := make([]string, 0, len(idx.Values))
+	for attr := range idx.Values {
+		ans = append(ans, attr)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// Info returns basic information about the index
+func (idx *Index) Info() IndexInfo {
+	ans := IndexInfo{
+		CorpusID:  idx.CorpusID,
+		Created:   idx.Created,
+		NumRows:   idx.NumRows(),
+		Attrs:     idx.Attrs(),
+		NumValues: make(map[string]int),
+	}
+	for attr, values := range idx.Values {
+		ans.NumValues[attr] = len(values)
+	}
+	return ans
+}
+
+// selectionMask creates a mask of rows matching values of all the
+// constrained attributes except for `ignoredAttr`. For no applicable
+// constraints, nil is returned (= all the rows match).
+func (idx *Index) selectionMask(attrMasks map[string]mask, ignoredAttr string) mask {
+	var ans mask
+	for attr, m := range attrMasks {
+		if attr == ignoredAttr {
+			continue
+		}
+		if ans == nil {
+			ans = m.clone()
+
+		} else {
+			ans.and(m)
+		}
+	}
+	return ans
+}
+
+// Facets calculates, for each of target attributes, position counts
+// of its values within the selection. The selection is evaluated as
+// a conjunction of constrained attributes, each matching any of
+// its listed values. Same as with the SQL-based co-occurrence,
+// a target attribute's own constraint is ignored. Only values
+// with at least one matching row are returned.
+func (idx *Index) Facets(
+	selection map[string][]string,
+	targetAttrs []string,
+) (map[string]map[string]int, error) {
+	attrMasks := make(map[string]mask)
+	for attr, values := range selection {
+		attrValues, ok := idx.Values[attr]
+		if !ok {
+			return nil, fmt.Errorf("attribute %s not indexed", attr)
+		}
+		m := newMask(idx.NumRows())
+		for _, v := range values {
+			if bm, ok := attrValues[v]; ok {
+				bm.orInto(m)
+			}
+		}
+		attrMasks[attr] = m
+	}
+	ans := make(map[string]map[string]int)
+	for _, attr := range targetAttrs {
+		attrValues, ok := idx.Values[attr]
+		if !ok {
+			return nil, fmt.Errorf("attribute %s not indexed", attr)
+		}
+		sel := idx.selectionMask(attrMasks, attr)
+		counts := make(map[string]int)
+		for v, bm := range attrValues {
+			var sum int
+			var found bool
+			bm.ForEach(func(i uint32) {
+				if sel == nil || sel.has(i) {
+					sum += idx.Poscounts[i]
+					found = true
+				}
+			})
+			if found {
+				counts[v] = sum
+			}
+		}
+		ans[attr] = counts
+	}
+	return ans, nil
+}
+
+// IndexInfo provides basic information about an index
+type IndexInfo struct {
+	CorpusID  string         `json:"corpusId"`
+	Created   time.Time      `json:"created"`
+	NumRows   int            `json:"numRows"`
+	Attrs     []string       `json:"attrs"`
+	NumValues map[string]int `json:"numValues"`
+}
+
+// SelectionFromAttrs converts a text type selection into the form
+// usable with Index.Facets. Only plain value listings are supported
+// by the index so in case the selection contains other value types
+// (regular expressions, ranges, negations), false is returned.
+func SelectionFromAttrs(attrs query.Attrs) (map[string][]string, bool) {
+	ans := make(map[string][]string)
+	for attr, v := range attrs {
+		switch v.(type) {
+		case string, []any:
+		default:
+			return nil, false
+		}
+		values, err := attrs.GetListingOf(attr)
+		if err != nil {
+			return nil, false
+		}
+		ans[attr] = values
+	}
+	return ans, true
+}
+
+// Build creates a new index of provided attributes (in the `struct.attr`
+// form) from liveattrs data of a corpus
+func Build(laDB *sql.DB, corpusInfo *corpus.DBInfo, attrs []string) (*Index, error) {
+	cols := make([]string, 0, len(attrs)+1)
+	cols = append(cols, "poscount")
+	for _, attr := range attrs {
+		cols = append(cols, utils.ImportKey(attr))
+	}
+	rows, err := laDB.Query(
+		fmt.Sprintf(
+			"SELECT %s FROM `%s_liveattrs_entry` "+
+				"WHERE corpus_id = ? AND poscount IS NOT NULL ORDER BY id",
+			strings.Join(cols, ", "), corpusInfo.GroupedName(),
+		),
+		corpusInfo.Name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build facet index: %w", err)
+	}
+	defer rows.Close()
+	ans := &Index{
+		CorpusID:  corpusInfo.Name,
+		Created:   time.Now(),
+		Poscounts: make([]int, 0, 1000),
+		Values:    make(map[string]map[string]*Bitmap),
+	}
+	for _, attr := range attrs {
+		ans.Values[attr] = make(map[string]*Bitmap)
+	}
+	var poscount int
+	values := make([]sql.NullString, len(attrs))
+	dest := make([]any, 0, len(values)+1)
+	dest = append(dest, &poscount)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to build facet index: %w", err)
+		}
+		rowIdx := len(ans.Poscounts)
+		if rowIdx == math.MaxUint32 {
+			return nil, fmt.Errorf("failed to build facet index: too many rows")
+		}
+		for i, attr := range attrs {
+			ans.addRow(attr, values[i].String, uint32(rowIdx))
+		}
+		ans.Poscounts = append(ans.Poscounts, poscount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to build facet index: %w", err)
+	}
+	ans.optimize()
+	return ans, nil
+}
+
+func (idx *Index) addRow(attr, value string, rowIdx uint32) {
+	bm, ok := idx.Values[attr][value]
+	if !ok {
+		bm = &Bitmap{}
+		idx.Values[attr][value] = bm
+	}
+	bm.Add(rowIdx)
+}
+
+func (idx *Index) optimize() {
+	for _, values := range idx.Values {
+		for _, bm := range values {
+			bm.Size = idx.NumRows()
+			bm.Optimize()
+		}
+	}
+}
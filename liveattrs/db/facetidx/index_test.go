@@ -0,0 +1,151 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package facetidx
+
+import (
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bitmapMembers(bm *Bitmap) []uint32 {
+	ans := make([]uint32, 0, bm.Len())
+	bm.ForEach(func(i uint32) {
+		ans = append(ans, i)
+	})
+	return ans
+}
+
+func TestBitmapSparse(t *testing.T) {
+	bm := &Bitmap{Size: 1000}
+	bm.Add(3)
+	bm.Add(70)
+	bm.Add(999)
+	bm.Optimize()
+	assert.Nil(t, bm.Words)
+	assert.Equal(t, 3, bm.Len())
+	assert.Equal(t, []uint32{3, 70, 999}, bitmapMembers(bm))
+}
+
+func TestBitmapDense(t *testing.T) {
+	bm := &Bitmap{Size: 130}
+	for i := uint32(0); i < 130; i += 2 {
+		bm.Add(i)
+	}
+	bm.Optimize()
+	assert.Nil(t, bm.Array)
+	assert.Len(t, bm.Words, 3)
+	assert.Equal(t, 65, bm.Len())
+	members := bitmapMembers(bm)
+	assert.Equal(t, uint32(0), members[0])
+	assert.Equal(t, uint32(128), members[64])
+}
+
+// createTestIndex creates an index of the following rows:
+//
+//	poscount | doc.genre | doc.lang
+//	10       | fiction   | cs
+//	20       | fiction   | en
+//	30       | poetry    | cs
+//	40       |           | en
+func createTestIndex() *Index {
+	idx := &Index{
+		CorpusID: "foo",
+		Values: map[string]map[string]*Bitmap{
+			"doc.genre": {},
+			"doc.lang":  {},
+		},
+	}
+	rows := [][2]string{{"fiction", "cs"}, {"fiction", "en"}, {"poetry", "cs"}, {"", "en"}}
+	for i, row := range rows {
+		idx.addRow("doc.genre", row[0], uint32(i))
+		idx.addRow("doc.lang", row[1], uint32(i))
+		idx.Poscounts = append(idx.Poscounts, (i+1)*10)
+	}
+	idx.optimize()
+	return idx
+}
+
+func TestFacetsNoSelection(t *testing.T) {
+	idx := createTestIndex()
+	ans, err := idx.Facets(map[string][]string{}, []string{"doc.genre", "doc.lang"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"fiction": 30, "poetry": 30, "": 40}, ans["doc.genre"])
+	assert.Equal(t, map[string]int{"cs": 40, "en": 60}, ans["doc.lang"])
+}
+
+func TestFacetsIgnoreOwnConstraint(t *testing.T) {
+	idx := createTestIndex()
+	ans, err := idx.Facets(
+		map[string][]string{"doc.genre": {"fiction"}, "doc.lang": {"cs"}},
+		[]string{"doc.genre", "doc.lang"},
+	)
+	assert.NoError(t, err)
+	// genre counts are restricted by lang = cs only
+	assert.Equal(t, map[string]int{"fiction": 10, "poetry": 30}, ans["doc.genre"])
+	// lang counts are restricted by genre = fiction only
+	assert.Equal(t, map[string]int{"cs": 10, "en": 20}, ans["doc.lang"])
+}
+
+func TestFacetsUnknownValueAndAttr(t *testing.T) {
+	idx := createTestIndex()
+	ans, err := idx.Facets(
+		map[string][]string{"doc.genre": {"drama"}},
+		[]string{"doc.lang"},
+	)
+	assert.NoError(t, err)
+	assert.Empty(t, ans["doc.lang"])
+
+	_, err = idx.Facets(map[string][]string{}, []string{"doc.title"})
+	assert.Error(t, err)
+}
+
+func TestSelectionFromAttrs(t *testing.T) {
+	sel, ok := SelectionFromAttrs(query.Attrs{
+		"doc.genre": []any{"fiction", "poetry"},
+		"doc.lang":  "cs",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, map[string][]string{"doc.genre": {"fiction", "poetry"}, "doc.lang": {"cs"}}, sel)
+
+	_, ok = SelectionFromAttrs(query.Attrs{"doc.genre": map[string]any{"regexp": "^f"}})
+	assert.False(t, ok)
+}
+
+func TestStoreSaveLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+	idx, err := store.Get("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, idx)
+	assert.NoError(t, store.Save(createTestIndex()))
+	assert.True(t, store.Exists("foo"))
+
+	store2 := NewStore(store.dirPath)
+	idx, err = store2.Get("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, idx.NumRows())
+	ans, err := idx.Facets(map[string][]string{"doc.lang": {"en"}}, []string{"doc.genre"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"fiction": 20, "": 40}, ans["doc.genre"])
+
+	assert.NoError(t, store2.Remove("foo"))
+	assert.False(t, store2.Exists("foo"))
+	assert.NoError(t, store2.Remove("foo"))
+}
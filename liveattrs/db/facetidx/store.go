@@ -0,0 +1,145 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package facetidx
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps facet indexes of corpora on disk (one file per corpus)
+// and caches the loaded ones in memory.
+type Store struct {
+	dirPath string
+	mu      sync.Mutex
+	indexes map[string]*Index
+}
+
+func (store *Store) indexPath(corpusID string) string {
+	return filepath.Join(store.dirPath, fmt.Sprintf("%s.facets.gob", corpusID))
+}
+
+// Enabled tests whether the store is configured
+// (i.e. whether facet indexes are available at all)
+func (store *Store) Enabled() bool {
+	return store.dirPath != ""
+}
+
+// Get returns an index of a corpus. In case the index has
+// not been built, nil is returned (without error).
+func (store *Store) Get(corpusID string) (*Index, error) {
+	if !store.Enabled() {
+		return nil, nil
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if idx, ok := store.indexes[corpusID]; ok {
+		return idx, nil
+	}
+	f, err := os.Open(store.indexPath(corpusID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load facet index of %s: %w", corpusID, err)
+	}
+	defer f.Close()
+	var idx Index
+	if err := gob.NewDecoder(f).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("failed to load facet index of %s: %w", corpusID, err)
+	}
+	store.indexes[corpusID] = &idx
+	return &idx, nil
+}
+
+// Exists tests whether an index of a corpus has been built
+func (store *Store) Exists(corpusID string) bool {
+	if !store.Enabled() {
+		return false
+	}
+	_, err := os.Stat(store.indexPath(corpusID))
+	return err == nil
+}
+
+// Path returns a path of an index file of a corpus. In case
+// the index has not been built, an empty string is returned.
+func (store *Store) Path(corpusID string) string {
+	if !store.Exists(corpusID) {
+		return ""
+	}
+	return store.indexPath(corpusID)
+}
+
+// Save stores an index to disk and replaces its cached version
+func (store *Store) Save(idx *Index) error {
+	if !store.Enabled() {
+		return fmt.Errorf("facet index directory not configured")
+	}
+	if err := os.MkdirAll(store.dirPath, 0755); err != nil {
+		return fmt.Errorf("failed to save facet index of %s: %w", idx.CorpusID, err)
+	}
+	tmp, err := os.CreateTemp(store.dirPath, "facets-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save facet index of %s: %w", idx.CorpusID, err)
+	}
+	defer os.Remove(tmp.Name())
+	err = gob.NewEncoder(tmp).Encode(idx)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save facet index of %s: %w", idx.CorpusID, err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if err := os.Rename(tmp.Name(), store.indexPath(idx.CorpusID)); err != nil {
+		return fmt.Errorf("failed to save facet index of %s: %w", idx.CorpusID, err)
+	}
+	store.indexes[idx.CorpusID] = idx
+	return nil
+}
+
+// Remove deletes an index of a corpus. Removing
+// a non-existing index is not an error.
+func (store *Store) Remove(corpusID string) error {
+	if !store.Enabled() {
+		return nil
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.indexes, corpusID)
+	err := os.Remove(store.indexPath(corpusID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove facet index of %s: %w", corpusID, err)
+	}
+	return nil
+}
+
+// NewStore creates a new index store. In case dirPath is empty,
+// the store is disabled.
+func NewStore(dirPath string) *Store {
+	return &Store{
+		dirPath: dirPath,
+		indexes: make(map[string]*Index),
+	}
+}
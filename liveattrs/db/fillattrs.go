@@ -22,6 +22,7 @@ import (
 	"database/sql"
 	"fmt"
 	"masm/v3/corpus"
+	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/fillattrs"
 	"masm/v3/liveattrs/utils"
 	"strings"
@@ -34,6 +35,7 @@ func FillAttrs(
 	db *sql.DB,
 	corpusInfo *corpus.DBInfo,
 	qry fillattrs.Payload,
+	attrTypes laconf.AttrTypes,
 ) (map[string]map[string]string, error) {
 
 	selAttrs := make([]string, len(qry.Fill)+1)
@@ -46,7 +48,7 @@ func FillAttrs(
 		valuesPlaceholders[i] = "?"
 	}
 	sql1 := fmt.Sprintf(
-		"SELECT %s FROM `%s_liveattrs_entry` WHERE %s IN (%s)",
+		"SELECT %s FROM `%s_liveattrs_entry` AS t1 WHERE %s IN (%s)",
 		strings.Join(selAttrs, ", "),
 		corpusInfo.GroupedName(),
 		utils.ImportKey(qry.Search),
@@ -56,6 +58,12 @@ func FillAttrs(
 	for i, v := range qry.Values {
 		sqlVals[i] = v
 	}
+	if qry.Subcorpus != nil {
+		sSql, sValues := subcorpusToSQL(qry.Subcorpus, corpusInfo.BibIDAttr, attrTypes)
+		sql1 += " AND t1.corpus_id = ? AND " + sSql
+		sqlVals = append(sqlVals, corpusInfo.Name)
+		sqlVals = append(sqlVals, sValues...)
+	}
 
 	rows, err := db.Query(sql1, sqlVals...)
 	ans := make(map[string]map[string]string)
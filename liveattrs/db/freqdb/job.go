@@ -23,7 +23,11 @@ import (
 	"time"
 )
 
+// NgramJobInfoArgs contains arguments the n-gram
+// frequency database is generated with
 type NgramJobInfoArgs struct {
+	ColMapping QSAttributes `json:"colMapping"`
+	Options    NgramOptions `json:"options"`
 }
 
 // NgramJobInfo
@@ -125,6 +129,7 @@ func (j NgramJobInfo) WithError(err error) jobs.GeneralJobInfo {
 		Update:      jobs.JSONTime(time.Now()),
 		Finished:    j.Finished,
 		Error:       err,
+		Args:        j.Args,
 		Result:      j.Result,
 		NumRestarts: j.NumRestarts,
 	}
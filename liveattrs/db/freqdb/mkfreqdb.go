@@ -52,6 +52,7 @@ type NgramFreqGenerator struct {
 	posFn       *modders.StringTransformerChain
 	jobActions  *jobs.Actions
 	qsaAttrs    QSAttributes
+	opts        NgramOptions
 }
 
 func (nfg *NgramFreqGenerator) createTables(tx *sql.Tx) error {
@@ -180,8 +181,9 @@ func (nfg *NgramFreqGenerator) findTotalNumLines() (int, error) {
 		fmt.Sprintf(
 			"SELECT COUNT(*) "+
 				"FROM %s_colcounts "+
-				"WHERE %s <> ? ", nfg.groupedName, nfg.qsaAttrs.ExportCols("tag")[0]),
+				"WHERE %s <> ? AND `count` >= ? ", nfg.groupedName, nfg.qsaAttrs.ExportCols("tag")[0]),
 		NonWordCSCNC2020Tag,
+		nfg.opts.MinFreq,
 	)
 	if row.Err() != nil {
 		return -1, row.Err()
@@ -218,20 +220,21 @@ func (nfg *NgramFreqGenerator) run(tx *sql.Tx, currStatus *genNgramsStatus, stat
 	log.Info().Msgf(
 		"About to process %d lines of raw n-grams for corpus %s. Time estimation (seconds): %d",
 		total, nfg.corpusName, estim)
-	var numStop int
+	var numStop, numFiltered int
 	t0 := time.Now()
 	// TODO the following query is not general enough
 	rows, err := nfg.db.Query(
 		fmt.Sprintf(
 			"SELECT %s, `count` AS abs, arf "+
 				"FROM %s_colcounts "+
-				"WHERE col4 <> ? "+
+				"WHERE col4 <> ? AND `count` >= ? "+
 				"ORDER BY %s ",
 			strings.Join(nfg.qsaAttrs.ExportCols("word", "sublemma", "lemma", "tag"), ", "),
 			nfg.groupedName,
 			strings.Join(nfg.qsaAttrs.ExportCols("lemma", "sublemma", "word", "tag"), ", "),
 		),
 		NonWordCSCNC2020Tag,
+		nfg.opts.MinFreq,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run n-gram generator: %w", err)
@@ -251,6 +254,10 @@ func (nfg *NgramFreqGenerator) run(tx *sql.Tx, currStatus *genNgramsStatus, stat
 			numStop++
 			continue
 		}
+		if !nfg.opts.Accepts(rec.word, nfg.posFn.Transform(rec.tag)) {
+			numFiltered++
+			continue
+		}
 		words, sublemmas, currLemma, err = nfg.procLine(tx, rec, currLemma, words, sublemmas)
 		if err != nil {
 			return fmt.Errorf("failed to run n-gram generator: %w", err)
@@ -282,7 +289,7 @@ func (nfg *NgramFreqGenerator) run(tx *sql.Tx, currStatus *genNgramsStatus, stat
 		log.Err(err).Msg("failed to write proc_time statistics")
 	}
 	statusChan <- *currStatus
-	log.Info().Msgf("num stop words: %d", numStop)
+	log.Info().Msgf("num stop words: %d, num filtered n-grams: %d", numStop, numFiltered)
 	return nil
 }
 
@@ -338,7 +345,10 @@ func (nfg *NgramFreqGenerator) GenerateAfter(corpusID, parentJobID string) (Ngra
 		Start:    jobs.CurrentDatetime(),
 		Update:   jobs.CurrentDatetime(),
 		Finished: false,
-		Args:     NgramJobInfoArgs{},
+		Args: NgramJobInfoArgs{
+			ColMapping: nfg.qsaAttrs,
+			Options:    nfg.opts,
+		},
 	}
 	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
 		statusChan := make(chan genNgramsStatus)
@@ -372,6 +382,7 @@ func NewNgramFreqGenerator(
 	corpusName string,
 	posFn *modders.StringTransformerChain,
 	qsaAttrs QSAttributes,
+	opts NgramOptions,
 ) *NgramFreqGenerator {
 	return &NgramFreqGenerator{
 		db:          db,
@@ -380,5 +391,6 @@ func NewNgramFreqGenerator(
 		corpusName:  corpusName,
 		posFn:       posFn,
 		qsaAttrs:    qsaAttrs,
+		opts:        opts,
 	}
 }
@@ -0,0 +1,81 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package freqdb
+
+import (
+	"errors"
+	"fmt"
+	"masm/v3/general/collections"
+	"strings"
+)
+
+// NgramOptions specifies which n-grams of raw n-gram data
+// (as extracted by vert-tagextract) are imported to the n-gram
+// frequency database. Zero values mean no filtering.
+type NgramOptions struct {
+	// NgramSizes specifies allowed numbers of tokens of n-grams
+	NgramSizes []int `json:"ngramSizes,omitempty"`
+
+	// MinFreq is a min. absolute frequency of an n-gram form
+	MinFreq int `json:"minFreq,omitempty"`
+
+	// PosWhitelist contains the only allowed PoS values (as provided
+	// by the configured PoS transformation)
+	PosWhitelist []string `json:"posWhitelist,omitempty"`
+
+	// PosBlacklist contains PoS values to be skipped
+	PosBlacklist []string `json:"posBlacklist,omitempty"`
+}
+
+// Validate tests the options for correct values. The maxNgramSize
+// argument is the size of extracted n-grams (zero if not known).
+func (opts NgramOptions) Validate(maxNgramSize int) error {
+	for _, n := range opts.NgramSizes {
+		if n < 1 {
+			return fmt.Errorf("invalid n-gram size %d", n)
+		}
+		if maxNgramSize > 0 && n > maxNgramSize {
+			return fmt.Errorf(
+				"n-gram size %d not available in extracted data (max. %d)", n, maxNgramSize)
+		}
+	}
+	if opts.MinFreq < 0 {
+		return errors.New("invalid value for minFreq")
+	}
+	for _, pos := range opts.PosWhitelist {
+		if collections.SliceContains(opts.PosBlacklist, pos) {
+			return fmt.Errorf("PoS %s is both whitelisted and blacklisted", pos)
+		}
+	}
+	return nil
+}
+
+// Accepts tests whether an n-gram (tokens separated by spaces)
+// with a PoS value passes size and PoS filters. The frequency
+// is filtered directly in the database.
+func (opts NgramOptions) Accepts(ngram, pos string) bool {
+	if len(opts.NgramSizes) > 0 &&
+		!collections.SliceContains(opts.NgramSizes, len(strings.Fields(ngram))) {
+		return false
+	}
+	if len(opts.PosWhitelist) > 0 && !collections.SliceContains(opts.PosWhitelist, pos) {
+		return false
+	}
+	return !collections.SliceContains(opts.PosBlacklist, pos)
+}
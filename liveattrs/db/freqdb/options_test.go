@@ -0,0 +1,50 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package freqdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNgramOptionsValidate(t *testing.T) {
+	assert.NoError(t, NgramOptions{}.Validate(0))
+	assert.NoError(t, NgramOptions{NgramSizes: []int{1, 2}, MinFreq: 5}.Validate(2))
+	assert.Error(t, NgramOptions{NgramSizes: []int{3}}.Validate(2))
+	assert.Error(t, NgramOptions{NgramSizes: []int{0}}.Validate(0))
+	assert.Error(t, NgramOptions{MinFreq: -1}.Validate(0))
+	assert.Error(t, NgramOptions{PosWhitelist: []string{"N"}, PosBlacklist: []string{"N"}}.Validate(0))
+}
+
+func TestNgramOptionsAccepts(t *testing.T) {
+	assert.True(t, NgramOptions{}.Accepts("big dog", "N"))
+
+	opts := NgramOptions{NgramSizes: []int{1}}
+	assert.True(t, opts.Accepts("dog", "N"))
+	assert.False(t, opts.Accepts("big dog", "N"))
+
+	opts = NgramOptions{PosWhitelist: []string{"N", "A"}}
+	assert.True(t, opts.Accepts("dog", "N"))
+	assert.False(t, opts.Accepts("run", "V"))
+
+	opts = NgramOptions{PosBlacklist: []string{"Z"}}
+	assert.True(t, opts.Accepts("dog", "N"))
+	assert.False(t, opts.Accepts(",", "Z"))
+}
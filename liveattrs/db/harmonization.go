@@ -0,0 +1,61 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/utils"
+)
+
+// GetAttrValueCounts returns numbers of liveattrs entries (atoms)
+// with individual values of an attribute. NULL values are counted
+// as empty strings.
+func GetAttrValueCounts(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attr string,
+) (map[string]int, error) {
+	col := utils.ImportKey(attr)
+	rows, err := laDB.Query(
+		fmt.Sprintf(
+			"SELECT %s, COUNT(*) FROM `%s_liveattrs_entry` WHERE corpus_id = ? GROUP BY %s",
+			col, corpusInfo.GroupedName(), col,
+		),
+		corpusInfo.Name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values of %s: %w", attr, err)
+	}
+	defer rows.Close()
+	ans := make(map[string]int)
+	for rows.Next() {
+		var value sql.NullString
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, fmt.Errorf("failed to get values of %s: %w", attr, err)
+		}
+		ans[value.String] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get values of %s: %w", attr, err)
+	}
+	return ans, nil
+}
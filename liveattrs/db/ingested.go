@@ -0,0 +1,232 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"masm/v3/liveattrs"
+	"time"
+)
+
+// IngestedVertical is a bookkeeping record of a vertical file
+// whose data have been extracted to a liveattrs table
+type IngestedVertical struct {
+	liveattrs.VerticalIdentity
+	CorpusID string    `json:"corpusId"`
+	Ingested time.Time `json:"ingested"`
+
+	// NumIngestions is the number of times the file has been
+	// ingested to the current data of the corpus (i.e. since
+	// the last re-creation of the liveattrs table)
+	NumIngestions int `json:"numIngestions"`
+}
+
+// GetIngestedVerticals returns records of vertical files ingested
+// to liveattrs data of a corpus (sorted by the time of ingestion)
+func GetIngestedVerticals(laDB *sql.DB, corpusID string) ([]IngestedVertical, error) {
+	rows, err := laDB.Query(
+		"SELECT path, size, hash, num_ingestions, UNIX_TIMESTAMP(ingested) FROM ingested_verticals "+
+			"WHERE corpus_id = ? ORDER BY ingested, path",
+		corpusID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]IngestedVertical, 0, 10)
+	for rows.Next() {
+		item := IngestedVertical{CorpusID: corpusID}
+		var ingested int64
+		if err := rows.Scan(
+			&item.Path, &item.Size, &item.Hash, &item.NumIngestions, &ingested); err != nil {
+			return nil, err
+		}
+		item.Ingested = time.Unix(ingested, 0)
+		ans = append(ans, item)
+	}
+	return ans, rows.Err()
+}
+
+// GetLastIngestions returns times of the latest ingestion
+// of a vertical file for all the corpora with some ingested verticals
+func GetLastIngestions(laDB *sql.DB) (map[string]time.Time, error) {
+	rows, err := laDB.Query(
+		"SELECT corpus_id, UNIX_TIMESTAMP(MAX(ingested)) FROM ingested_verticals " +
+			"GROUP BY corpus_id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make(map[string]time.Time)
+	for rows.Next() {
+		var corpusID string
+		var ingested int64
+		if err := rows.Scan(&corpusID, &ingested); err != nil {
+			return nil, err
+		}
+		ans[corpusID] = time.Unix(ingested, 0)
+	}
+	return ans, rows.Err()
+}
+
+// RegisterIngestedVerticals stores records of vertical files ingested
+// to liveattrs data of a corpus. Records of already registered
+// files are updated (including the number of ingestions).
+func RegisterIngestedVerticals(
+	laDB *sql.DB,
+	groupedName string,
+	corpusID string,
+	verticals []liveattrs.VerticalIdentity,
+) error {
+	tx, err := laDB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, vert := range verticals {
+		_, err := tx.Exec(
+			"INSERT INTO ingested_verticals "+
+				"(corpus_id, grouped_name, hash, size, path, ingested) "+
+				"VALUES (?, ?, ?, ?, ?, NOW()) "+
+				"ON DUPLICATE KEY UPDATE path = VALUES(path), ingested = NOW(), "+
+				"num_ingestions = num_ingestions + 1",
+			corpusID, groupedName, vert.Hash, vert.Size, vert.Path,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClearIngestedVerticals removes all the records of vertical files
+// ingested to a liveattrs table (i.e. for all the corpora stored in
+// the table). This should be called once the table is re-created.
+func ClearIngestedVerticals(laDB *sql.DB, groupedName string) error {
+	_, err := laDB.Exec(
+		"DELETE FROM ingested_verticals WHERE grouped_name = ?", groupedName)
+	return err
+}
+
+// ClearIngestedVerticalsOfCorpus removes records of vertical files
+// ingested to liveattrs data of a corpus
+func ClearIngestedVerticalsOfCorpus(laDB *sql.DB, corpusID string) error {
+	_, err := laDB.Exec(
+		"DELETE FROM ingested_verticals WHERE corpus_id = ?", corpusID)
+	return err
+}
+
+// FindIngested splits verticals into the ones already ingested
+// (i.e. present among `ingested`) and the others.
+func FindIngested(
+	ingested []IngestedVertical,
+	verticals []liveattrs.VerticalIdentity,
+) (found []liveattrs.VerticalIdentity, rest []liveattrs.VerticalIdentity) {
+	found = make([]liveattrs.VerticalIdentity, 0, len(verticals))
+	rest = make([]liveattrs.VerticalIdentity, 0, len(verticals))
+	for _, vert := range verticals {
+		var isIngested bool
+		for _, item := range ingested {
+			if item.SameAs(vert) {
+				isIngested = true
+				break
+			}
+		}
+		if isIngested {
+			found = append(found, vert)
+
+		} else {
+			rest = append(rest, vert)
+		}
+	}
+	return
+}
+
+// DuplicateVertical is a vertical file (identified by its size
+// and hash) ingested to multiple corpora or multiple times
+// to the same corpus
+type DuplicateVertical struct {
+	liveattrs.VerticalIdentity
+	MultipleCorpora    bool               `json:"multipleCorpora"`
+	MultipleIngestions bool               `json:"multipleIngestions"`
+	Ingestions         []IngestedVertical `json:"ingestions"`
+}
+
+// groupDuplicateVerticals groups records of ingested verticals
+// (sorted by hash and size) by the file identity. Only files ingested
+// to multiple corpora or multiple times are returned.
+func groupDuplicateVerticals(items []IngestedVertical) []DuplicateVertical {
+	ans := make([]DuplicateVertical, 0, 10)
+	for i := 0; i < len(items); {
+		group := DuplicateVertical{
+			VerticalIdentity: liveattrs.VerticalIdentity{
+				Path: items[i].Path,
+				Size: items[i].Size,
+				Hash: items[i].Hash,
+			},
+			Ingestions: make([]IngestedVertical, 0, 2),
+		}
+		for ; i < len(items) && items[i].SameAs(group.VerticalIdentity); i++ {
+			group.Ingestions = append(group.Ingestions, items[i])
+			if items[i].NumIngestions > 1 {
+				group.MultipleIngestions = true
+			}
+		}
+		group.MultipleCorpora = len(group.Ingestions) > 1
+		if group.MultipleCorpora || group.MultipleIngestions {
+			ans = append(ans, group)
+		}
+	}
+	return ans
+}
+
+// FindDuplicateVerticals searches records of ingested verticals of all
+// the corpora for files ingested to multiple corpora or multiple times
+// to the same corpus (which is typically caused by a mistake in a corpus
+// configuration).
+func FindDuplicateVerticals(laDB *sql.DB) ([]DuplicateVertical, error) {
+	rows, err := laDB.Query(
+		"SELECT corpus_id, path, size, hash, num_ingestions, UNIX_TIMESTAMP(ingested) " +
+			"FROM ingested_verticals WHERE (hash, size) IN (" +
+			"SELECT hash, size FROM ingested_verticals GROUP BY hash, size " +
+			"HAVING COUNT(*) > 1 OR MAX(num_ingestions) > 1" +
+			") ORDER BY hash, size, ingested, corpus_id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]IngestedVertical, 0, 10)
+	for rows.Next() {
+		var item IngestedVertical
+		var ingested int64
+		err := rows.Scan(
+			&item.CorpusID, &item.Path, &item.Size, &item.Hash, &item.NumIngestions, &ingested)
+		if err != nil {
+			return nil, err
+		}
+		item.Ingested = time.Unix(ingested, 0)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return groupDuplicateVerticals(items), nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindIngested(t *testing.T) {
+	ingested := []IngestedVertical{
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/old/a.vert", Size: 10, Hash: "aaa"}},
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/b.vert", Size: 20, Hash: "bbb"}},
+	}
+	verticals := []liveattrs.VerticalIdentity{
+		{Path: "/new/a.vert", Size: 10, Hash: "aaa"},
+		{Path: "/b.vert", Size: 21, Hash: "bbb"},
+		{Path: "/c.vert", Size: 30, Hash: "ccc"},
+	}
+	found, rest := FindIngested(ingested, verticals)
+	assert.Equal(t, []liveattrs.VerticalIdentity{verticals[0]}, found)
+	assert.Equal(t, []liveattrs.VerticalIdentity{verticals[1], verticals[2]}, rest)
+}
+
+func TestGroupDuplicateVerticals(t *testing.T) {
+	items := []IngestedVertical{
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/a.vert", Size: 10, Hash: "aaa"}, CorpusID: "c1", NumIngestions: 1},
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/x/a.vert", Size: 10, Hash: "aaa"}, CorpusID: "c2", NumIngestions: 1},
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/b.vert", Size: 20, Hash: "bbb"}, CorpusID: "c1", NumIngestions: 1},
+		{VerticalIdentity: liveattrs.VerticalIdentity{Path: "/c.vert", Size: 30, Hash: "ccc"}, CorpusID: "c3", NumIngestions: 2},
+	}
+	ans := groupDuplicateVerticals(items)
+	assert.Len(t, ans, 2)
+	assert.Equal(t, "aaa", ans[0].Hash)
+	assert.True(t, ans[0].MultipleCorpora)
+	assert.False(t, ans[0].MultipleIngestions)
+	assert.Equal(t, items[:2], ans[0].Ingestions)
+	assert.Equal(t, "ccc", ans[1].Hash)
+	assert.False(t, ans[1].MultipleCorpora)
+	assert.True(t, ans[1].MultipleIngestions)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs"
+	"sort"
+)
+
+// mergeReportQueries returns queries for counting atoms with (and without)
+// join keys, duplicate keys and unmatched atoms. All the queries return
+// pairs (corpus_id, count) (the first query returns one more count).
+func mergeReportQueries(groupedName string) (atoms, duplicates, unmatched string) {
+	table := fmt.Sprintf("`%s_liveattrs_entry`", groupedName)
+	atoms = fmt.Sprintf(
+		"SELECT corpus_id, COUNT(*), "+
+			"COALESCE(SUM(item_id IS NOT NULL AND item_id <> ''), 0) "+
+			"FROM %s GROUP BY corpus_id",
+		table,
+	)
+	duplicates = fmt.Sprintf(
+		"SELECT d.corpus_id, COUNT(*) FROM ("+
+			"SELECT corpus_id, item_id FROM %s "+
+			"WHERE item_id IS NOT NULL AND item_id <> '' "+
+			"GROUP BY corpus_id, item_id HAVING COUNT(*) > 1) AS d "+
+			"GROUP BY d.corpus_id",
+		table,
+	)
+	unmatched = fmt.Sprintf(
+		"SELECT t1.corpus_id, COUNT(*) FROM %s AS t1 "+
+			"WHERE t1.item_id IS NOT NULL AND t1.item_id <> '' AND NOT EXISTS ("+
+			"SELECT 1 FROM %s AS t2 "+
+			"WHERE t2.item_id = t1.item_id AND t2.corpus_id <> t1.corpus_id) "+
+			"GROUP BY t1.corpus_id",
+		table, table,
+	)
+	return
+}
+
+func scanCorpusCounts(
+	laDB *sql.DB,
+	sqlq string,
+	fn func(corpusID string, count int),
+) error {
+	rows, err := laDB.Query(sqlq)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var corpusID string
+		var count int
+		if err := rows.Scan(&corpusID, &count); err != nil {
+			return err
+		}
+		fn(corpusID, count)
+	}
+	return rows.Err()
+}
+
+// GetMergeReport creates a verification report of a self-joined
+// (parallel) liveattrs table. The report contains stats for each
+// corpus stored in the table.
+func GetMergeReport(laDB *sql.DB, groupedName string) (*liveattrs.MergeReport, error) {
+	atomsSQL, duplicatesSQL, unmatchedSQL := mergeReportQueries(groupedName)
+	stats := make(map[string]*liveattrs.CorpusMergeStats)
+	rows, err := laDB.Query(atomsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item liveattrs.CorpusMergeStats
+		if err := rows.Scan(&item.CorpusID, &item.NumAtoms, &item.NumWithKey); err != nil {
+			return nil, err
+		}
+		stats[item.CorpusID] = &item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	err = scanCorpusCounts(laDB, duplicatesSQL, func(corpusID string, count int) {
+		if item, ok := stats[corpusID]; ok {
+			item.NumDuplicateKeys = count
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = scanCorpusCounts(laDB, unmatchedSQL, func(corpusID string, count int) {
+		if item, ok := stats[corpusID]; ok {
+			item.NumUnmatched = count
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	ans := &liveattrs.MergeReport{Corpora: make([]*liveattrs.CorpusMergeStats, 0, len(stats))}
+	for _, item := range stats {
+		if item.NumWithKey > 0 {
+			item.UnmatchedRatio = float64(item.NumUnmatched) / float64(item.NumWithKey)
+		}
+		ans.Corpora = append(ans.Corpora, item)
+	}
+	sort.Slice(ans.Corpora, func(i, j int) bool {
+		return ans.Corpora[i].CorpusID < ans.Corpora[j].CorpusID
+	})
+	return ans, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeReportQueries(t *testing.T) {
+	atoms, duplicates, unmatched := mergeReportQueries("intercorp_v13")
+	assert.Equal(
+		t,
+		"SELECT corpus_id, COUNT(*), COALESCE(SUM(item_id IS NOT NULL AND item_id <> ''), 0) "+
+			"FROM `intercorp_v13_liveattrs_entry` GROUP BY corpus_id",
+		atoms,
+	)
+	assert.Contains(t, duplicates, "GROUP BY corpus_id, item_id HAVING COUNT(*) > 1")
+	assert.Contains(
+		t,
+		unmatched,
+		"NOT EXISTS (SELECT 1 FROM `intercorp_v13_liveattrs_entry` AS t2 "+
+			"WHERE t2.item_id = t1.item_id AND t2.corpus_id <> t1.corpus_id)",
+	)
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"sort"
+	"strings"
+)
+
+// splitMultiValue splits a multi-value attribute value into individual
+// values. Surrounding whitespaces and empty values are removed.
+func splitMultiValue(value, sep string) []string {
+	items := strings.Split(value, sep)
+	ans := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			ans = append(ans, item)
+		}
+	}
+	return ans
+}
+
+// copyRowSQL generates a statement copying a row specified by its `id`
+// with the column `col` set to a new value. All the columns except
+// the `id` column (which is auto-generated) are copied.
+func copyRowSQL(tableName, col string, columns map[string]columnInfo) string {
+	cols := make([]string, 0, len(columns))
+	for c := range columns {
+		if c != "id" {
+			cols = append(cols, c)
+		}
+	}
+	sort.Strings(cols)
+	srcCols := make([]string, len(cols))
+	for i, c := range cols {
+		if c == col {
+			srcCols[i] = "?"
+
+		} else {
+			srcCols[i] = c
+		}
+	}
+	return fmt.Sprintf(
+		"INSERT INTO `%s` (%s) SELECT %s FROM `%s` WHERE id = ?",
+		tableName, strings.Join(cols, ", "), strings.Join(srcCols, ", "), tableName,
+	)
+}
+
+type multiValueRow struct {
+	id    int64
+	value string
+}
+
+func findMultiValueRows(
+	tx *sql.Tx,
+	tableName, col, corpusID, sep string,
+) ([]multiValueRow, error) {
+	rows, err := tx.Query(
+		fmt.Sprintf(
+			"SELECT id, %s FROM `%s` WHERE corpus_id = ? AND INSTR(%s, ?) > 0",
+			col, tableName, col,
+		),
+		corpusID, sep,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]multiValueRow, 0, 100)
+	for rows.Next() {
+		var row multiValueRow
+		if err := rows.Scan(&row.id, &row.value); err != nil {
+			return nil, err
+		}
+		ans = append(ans, row)
+	}
+	return ans, rows.Err()
+}
+
+// SplitMultiValues replaces each row of the corpus `corpusID` containing
+// a multi-value attribute by rows with the individual values. In case
+// more multi-value attributes are configured, rows for all the combinations
+// of their values are created. The function must be applied before typed
+// columns are created (see ApplyAttrTypes).
+func SplitMultiValues(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	separators laconf.MultiValueSeparators,
+) error {
+	if len(separators) == 0 {
+		return nil
+	}
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to split multi-value attributes: %w", err)
+	}
+	attrs := make([]string, 0, len(separators))
+	for attr := range separators {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	tx, err := laDB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to split multi-value attributes: %w", err)
+	}
+	for _, attr := range attrs {
+		col := utils.ImportKey(attr)
+		if _, ok := columns[col]; !ok {
+			continue
+		}
+		sep := separators[attr]
+		mvRows, err := findMultiValueRows(tx, tableName, col, corpusID, sep)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to split multi-value attribute %s: %w", attr, err)
+		}
+		copySQL := copyRowSQL(tableName, col, columns)
+		for _, row := range mvRows {
+			values := splitMultiValue(row.value, sep)
+			if len(values) == 0 {
+				// keep the row so its positions are still counted
+				continue
+			}
+			for _, v := range values {
+				if _, err := tx.Exec(copySQL, v, row.id); err != nil {
+					tx.Rollback()
+					return fmt.Errorf("failed to split multi-value attribute %s: %w", attr, err)
+				}
+			}
+			_, err := tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE id = ?", tableName), row.id)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to split multi-value attribute %s: %w", attr, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMultiValue(t *testing.T) {
+	assert.Equal(t, []string{"Novák", "Svoboda"}, splitMultiValue("Novák | Svoboda", "|"))
+	assert.Equal(t, []string{"foo"}, splitMultiValue("foo||", "|"))
+	assert.Empty(t, splitMultiValue(" | ", "|"))
+}
+
+func TestCopyRowSQL(t *testing.T) {
+	columns := map[string]columnInfo{
+		"id":         {columnType: "int(11)"},
+		"corpus_id":  {columnType: "varchar(255)"},
+		"doc_author": {columnType: "varchar(255)"},
+		"poscount":   {columnType: "int(11)"},
+	}
+	assert.Equal(
+		t,
+		"INSERT INTO `syn_liveattrs_entry` (corpus_id, doc_author, poscount) "+
+			"SELECT corpus_id, ?, poscount FROM `syn_liveattrs_entry` WHERE id = ?",
+		copyRowSQL("syn_liveattrs_entry", "doc_author", columns),
+	)
+}
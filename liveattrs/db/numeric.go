@@ -0,0 +1,81 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+)
+
+// numericDetectionQuery returns a query counting non-empty values
+// of an attribute along with numbers of values matching patterns
+// of numeric types (int, number)
+func numericDetectionQuery(groupedName, attr string) string {
+	col := utils.ImportKey(attr)
+	return fmt.Sprintf(
+		"SELECT COUNT(*), COALESCE(SUM(%s REGEXP '%s'), 0), COALESCE(SUM(%s REGEXP '%s'), 0) "+
+			"FROM `%s_liveattrs_entry` WHERE corpus_id = ? AND %s IS NOT NULL AND %s <> ''",
+		col, laconf.AttrTypeInt.ValuePattern(), col, laconf.AttrTypeNumber.ValuePattern(),
+		groupedName, col, col,
+	)
+}
+
+// detectedNumericType returns a numeric type matching all the non-empty
+// values of an attribute. In case there is no such type, an empty
+// value is returned.
+func detectedNumericType(numValues, numInts, numNumbers int) laconf.AttrType {
+	if numValues == 0 {
+		return ""
+	}
+	if numInts == numValues {
+		return laconf.AttrTypeInt
+	}
+	if numNumbers == numValues {
+		return laconf.AttrTypeNumber
+	}
+	return ""
+}
+
+// DetectNumericAttrs examines values of attributes `attrs` and returns
+// numeric types suitable for attributes with all the (non-empty) values
+// being numbers. Attributes with other values are not included.
+func DetectNumericAttrs(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrs []string,
+) (laconf.AttrTypes, error) {
+	ans := make(laconf.AttrTypes)
+	for _, attr := range attrs {
+		var numValues, numInts, numNumbers int
+		err := laDB.QueryRow(
+			numericDetectionQuery(corpusInfo.GroupedName(), attr),
+			corpusInfo.Name,
+		).Scan(&numValues, &numInts, &numNumbers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect type of %s: %w", attr, err)
+		}
+		if tp := detectedNumericType(numValues, numInts, numNumbers); tp != "" {
+			ans[attr] = tp
+		}
+	}
+	return ans, nil
+}
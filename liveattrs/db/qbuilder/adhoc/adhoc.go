@@ -21,57 +21,160 @@ package adhoc
 import (
 	"fmt"
 	"masm/v3/corpus"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/equery"
 	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/utils"
+	"sort"
 	"strings"
 )
 
-// SubcSize is a generator for an SQL query + args for obtaining a subcorpus based
-// on ad-hoc selection of text types
-type SubcSize struct {
+// Selection represents an ad-hoc selection of text types (possibly
+// including aligned corpora) and provides SQL parts for accessing
+// matching liveattrs entries (aliased as `t1`).
+type Selection struct {
 	CorpusInfo          *corpus.DBInfo
 	AttrMap             query.Attrs
 	AlignedCorpora      []string
 	EmptyValPlaceholder string
+	AttrTypes           laconf.AttrTypes
 }
 
-// Query generates the result
-// Please note that this is largely similar to laquery.AttrArgs.ExportSQL()
-func (ssize *SubcSize) Query() (ansSQL string, whereValues []any) {
+func (sel *Selection) predicateArgs() PredicateArgs {
+	return PredicateArgs{
+		data:                sel.AttrMap,
+		emptyValPlaceholder: sel.EmptyValPlaceholder,
+		bibLabel:            sel.CorpusInfo.BibLabelAttr,
+		attrTypes:           sel.AttrTypes,
+	}
+}
+
+type namedPredicate struct {
+	attr   string
+	sql    string
+	values []any
+}
+
+// predicates returns SQL predicates of the selected attributes
+// (sorted by attribute names)
+func (sel *Selection) predicates() ([]namedPredicate, error) {
+	aargs := sel.predicateArgs()
+	attrs := make([]string, 0, len(sel.AttrMap))
+	for attr := range sel.AttrMap {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	ans := make([]namedPredicate, 0, len(attrs))
+	for _, attr := range attrs {
+		pred, values, err := aargs.attrPredicate(attr, sel.AttrMap[attr], "t1")
+		if err != nil {
+			return nil, err
+		}
+		if pred != "" {
+			ans = append(ans, namedPredicate{attr: attr, sql: pred, values: values})
+		}
+	}
+	return ans, nil
+}
+
+// fromBaseWhere generates FROM (including joins of aligned corpora)
+// and WHERE conditions not related to selected attributes
+func (sel *Selection) fromBaseWhere() (fromSQL string, where []string, whereValues []any) {
 	joinSQL := make([]string, 0, 10)
-	whereSQL := []string{
+	where = []string{
 		"t1.corpus_id = ?",
 		"t1.poscount is NOT NULL",
 	}
-	whereValues = []any{ssize.CorpusInfo.Name}
-	for i, item := range ssize.AlignedCorpora {
+	whereValues = []any{sel.CorpusInfo.Name}
+	for i, item := range sel.AlignedCorpora {
 		iOffs := i + 2
 		joinSQL = append(
 			joinSQL,
 			fmt.Sprintf(
 				"JOIN `%s_liveattrs_entry` AS t%d ON t1.item_id = t%d.item_id",
-				ssize.CorpusInfo.GroupedName(), iOffs, iOffs,
+				sel.CorpusInfo.GroupedName(), iOffs, iOffs,
 			),
 		)
-		whereSQL = append(
-			whereSQL,
+		where = append(
+			where,
 			fmt.Sprintf("t%d.corpus_id = ?", iOffs),
 		)
 		whereValues = append(whereValues, item)
 	}
+	fromSQL = fmt.Sprintf(
+		"`%s_liveattrs_entry` AS t1 %s",
+		sel.CorpusInfo.GroupedName(),
+		strings.Join(joinSQL, " "),
+	)
+	return
+}
 
-	aargs := PredicateArgs{
-		data:                ssize.AttrMap,
-		emptyValPlaceholder: ssize.EmptyValPlaceholder,
-		bibLabel:            ssize.CorpusInfo.BibLabelAttr,
+// FromWhere generates FROM (including joins of aligned corpora)
+// and WHERE SQL parts along with respective query arguments.
+// Please note that this is largely similar to laquery.AttrArgs.ExportSQL()
+func (sel *Selection) FromWhere() (fromSQL string, whereSQL string, whereValues []any, err error) {
+	fromSQL, where, whereValues := sel.fromBaseWhere()
+	aargs := sel.predicateArgs()
+	where2, args2, err := aargs.ExportSQL("t1", sel.CorpusInfo.Name)
+	if err != nil {
+		return
 	}
-	where2, args2 := aargs.ExportSQL("t1", ssize.CorpusInfo.Name)
-	whereSQL = append(whereSQL, where2)
+	where = append(where, where2)
 	whereValues = append(whereValues, args2...)
+	whereSQL = strings.Join(where, " AND ")
+	return
+}
+
+// SubcSize is a generator for an SQL query + args for obtaining a subcorpus based
+// on ad-hoc selection of text types
+type SubcSize struct {
+	CorpusInfo          *corpus.DBInfo
+	AttrMap             query.Attrs
+	AlignedCorpora      []string
+	EmptyValPlaceholder string
+	AttrTypes           laconf.AttrTypes
+	Unit                equery.SizeUnit
+}
+
+// sizeExpr returns an SQL aggregation expression
+// matching the required size unit for a table alias
+func (ssize *SubcSize) sizeExpr(alias string) string {
+	switch ssize.Unit.Normalized() {
+	case equery.SizeUnitDocuments:
+		if ssize.CorpusInfo.BibIDAttr != "" {
+			return fmt.Sprintf(
+				"COUNT(DISTINCT %s.%s)", alias, utils.ImportKey(ssize.CorpusInfo.BibIDAttr))
+		}
+		return fmt.Sprintf("COUNT(DISTINCT %s.id)", alias)
+	default:
+		return fmt.Sprintf("SUM(%s.poscount)", alias)
+	}
+}
+
+// Query generates the result. The query returns one size
+// column for the main corpus and then one column for each
+// of the aligned corpora (in the order of AlignedCorpora).
+func (ssize *SubcSize) Query() (ansSQL string, whereValues []any, err error) {
+	sel := Selection{
+		CorpusInfo:          ssize.CorpusInfo,
+		AttrMap:             ssize.AttrMap,
+		AlignedCorpora:      ssize.AlignedCorpora,
+		EmptyValPlaceholder: ssize.EmptyValPlaceholder,
+		AttrTypes:           ssize.AttrTypes,
+	}
+	fromSQL, whereSQL, whereValues, err := sel.FromWhere()
+	if err != nil {
+		return
+	}
+	sizeCols := make([]string, 0, len(ssize.AlignedCorpora)+1)
+	for i := 0; i <= len(ssize.AlignedCorpora); i++ {
+		sizeCols = append(sizeCols, ssize.sizeExpr(fmt.Sprintf("t%d", i+1)))
+	}
 	ansSQL = fmt.Sprintf(
-		"SELECT SUM(t1.poscount) FROM `%s_liveattrs_entry` AS t1 %s WHERE %s",
-		ssize.CorpusInfo.GroupedName(),
-		strings.Join(joinSQL, " "),
-		strings.Join(whereSQL, " AND "),
+		"SELECT %s FROM %s WHERE %s",
+		strings.Join(sizeCols, ", "),
+		fromSQL,
+		whereSQL,
 	)
 	return
 }
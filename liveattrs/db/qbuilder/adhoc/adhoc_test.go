@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownOperatorObject(t *testing.T) {
+	sel := Selection{
+		CorpusInfo: &corpus.DBInfo{Name: "syn2020"},
+		AttrMap:    query.Attrs{"doc.genre": map[string]any{"foo": "fiction"}},
+	}
+	_, _, err := (&SubcSize{CorpusInfo: sel.CorpusInfo, AttrMap: sel.AttrMap}).Query()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+
+	cooc := &Cooccurrence{Selection: sel, TargetAttrs: []string{"doc.year"}}
+	_, _, err = cooc.Query()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+	_, err = cooc.ConstrainedAttrs()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+
+	sel.AttrMap = query.Attrs{"doc.genre": map[string]any{"regexp": ""}}
+	_, _, err = (&SelectionShare{Selection: sel}).Query()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+}
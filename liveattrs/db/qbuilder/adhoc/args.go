@@ -21,11 +21,10 @@ package adhoc
 import (
 	"fmt"
 	"masm/v3/liveattrs/db/qbuilder"
+	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/utils"
 	"strings"
-
-	"github.com/rs/zerolog/log"
 )
 
 // PredicateArgs represent arguments required
@@ -34,6 +33,7 @@ import (
 type PredicateArgs struct {
 	data                query.Attrs
 	emptyValPlaceholder string
+	attrTypes           laconf.AttrTypes
 	bibLabel            string
 }
 
@@ -44,76 +44,126 @@ func (args *PredicateArgs) importValue(value string) string {
 	return value
 }
 
-func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []any) {
-	where := make([]string, 0, 20)
-	sqlValues := make([]any, 0, 20)
-	for dkey, values := range args.data {
-		key := utils.ImportKey(dkey)
-		cnfItem := make([]string, 0, 20)
-		switch tValues := values.(type) {
-		case []any:
-			for _, value := range tValues {
-				tValue, ok := value.(string)
-				if !ok {
+// attrPredicate creates an SQL predicate (and respective query arguments)
+// for a single attribute of the selection. In case no predicate can be
+// created, an empty string is returned. In case the value cannot be
+// processed, an error wrapping qbuilder.ErrInvalidAttrValue is returned.
+func (args *PredicateArgs) attrPredicate(
+	dkey string,
+	values any,
+	itemPrefix string,
+) (string, []any, error) {
+	sqlValues := make([]any, 0, 10)
+	key := utils.ImportKey(dkey)
+	cnfItem := make([]string, 0, 20)
+	switch tValues := values.(type) {
+	case []any:
+		for _, value := range tValues {
+			tValue, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if len(tValue) == 0 || tValue[0] != '@' {
+				if qbuilder.IsNullValue(args.importValue(tValue), args.attrTypes.Get(dkey)) {
+					cnfItem = append(cnfItem, fmt.Sprintf("%s.%s IS NULL", itemPrefix, key))
 					continue
 				}
-				if len(tValue) == 0 || tValue[0] != '@' {
-					cnfItem = append(
-						cnfItem,
-						fmt.Sprintf(
-							"%s.%s %s ?",
-							itemPrefix, key, qbuilder.CmpOperator(tValue),
-						),
-					)
-					sqlValues = append(sqlValues, args.importValue(tValue))
-
-				} else {
-					cnfItem = append(
-						cnfItem,
-						fmt.Sprintf(
-							"%s.%s %s ?",
-							itemPrefix, args.bibLabel,
-							qbuilder.CmpOperator(tValue[1:]),
-						),
-					)
-					sqlValues = append(sqlValues, args.importValue(tValue[1:]))
-				}
-			}
-		case string:
-			cnfItem = append(
-				cnfItem,
-				fmt.Sprintf(
-					"%s.%s LIKE ?",
-					itemPrefix, key),
-			)
-			sqlValues = append(sqlValues, args.importValue(tValues))
-		case map[string]any:
-			regexpVal, ok := args.data.GetRegexpAttrVal(dkey)
-			if ok {
-				cnfItem = append(cnfItem, fmt.Sprintf("%s.%s REGEXP ?", itemPrefix, key))
-				sqlValues = append(sqlValues, args.importValue(regexpVal))
+				cnfItem = append(
+					cnfItem,
+					fmt.Sprintf(
+						"%s.%s %s ?",
+						itemPrefix, key, qbuilder.CmpOperator(tValue),
+					),
+				)
+				sqlValues = append(sqlValues, args.importValue(tValue))
 
-				// TODO add support for this
 			} else {
-				// TODO handle in a better way
-				log.Error().Msgf(
-					"failed to determine type of liveattrs attribute %s (corpus %s)", key, corpusID)
+				cnfItem = append(
+					cnfItem,
+					fmt.Sprintf(
+						"%s.%s %s ?",
+						itemPrefix, args.bibLabel,
+						qbuilder.CmpOperator(tValue[1:]),
+					),
+				)
+				sqlValues = append(sqlValues, args.importValue(tValue[1:]))
+			}
+		}
+	case string:
+		cnfItem = append(
+			cnfItem,
+			fmt.Sprintf(
+				"%s.%s LIKE ?",
+				itemPrefix, key),
+		)
+		sqlValues = append(sqlValues, args.importValue(tValues))
+	case map[string]any:
+		if negVals, ok := args.data.GetNegatedValues(dkey); ok {
+			for i, v := range negVals {
+				negVals[i] = args.importValue(v)
+			}
+			pred, predVals := qbuilder.TypedNegationPredicate(
+				fmt.Sprintf("%s.%s", itemPrefix, key), args.attrTypes.Get(dkey), negVals)
+			cnfItem = append(cnfItem, pred)
+			for _, v := range predVals {
+				sqlValues = append(sqlValues, v)
 			}
-		default: // TODO can this even happen???
-			cnfItem = append(
-				cnfItem,
-				fmt.Sprintf(
-					"LOWER(%s.%s) %s LOWER(?)",
-					itemPrefix, key, qbuilder.CmpOperator(fmt.Sprintf("%v", tValues)),
-				),
-			)
-			sqlValues = append(sqlValues, args.importValue(fmt.Sprintf("%v", tValues)))
+			break
+		}
+		if from, to, ok := args.data.GetRangeAttrVal(dkey); ok {
+			pred, predVals := qbuilder.RangePredicate(
+				fmt.Sprintf("%s.%s", itemPrefix, key), args.attrTypes.Get(dkey), from, to)
+			cnfItem = append(cnfItem, pred)
+			for _, v := range predVals {
+				sqlValues = append(sqlValues, v)
+			}
+			break
+		}
+		op, opVal, ok := args.data.GetOperatorAttrVal(dkey)
+		if !ok {
+			return "", nil, fmt.Errorf(
+				"%w: unsupported value of attribute %s", qbuilder.ErrInvalidAttrValue, dkey)
+		}
+		pred, predVal, err := qbuilder.OperatorPredicate(
+			fmt.Sprintf("%s.%s", itemPrefix, key), op, args.importValue(opVal))
+		if err != nil {
+			return "", nil, fmt.Errorf("attribute %s: %w", dkey, err)
+		}
+		cnfItem = append(cnfItem, pred)
+		sqlValues = append(sqlValues, predVal)
+	default: // TODO can this even happen???
+		cnfItem = append(
+			cnfItem,
+			fmt.Sprintf(
+				"LOWER(%s.%s) %s LOWER(?)",
+				itemPrefix, key, qbuilder.CmpOperator(fmt.Sprintf("%v", tValues)),
+			),
+		)
+		sqlValues = append(sqlValues, args.importValue(fmt.Sprintf("%v", tValues)))
+	}
+	if len(cnfItem) == 0 {
+		return "", sqlValues, nil
+	}
+	return fmt.Sprintf("(%s)", strings.Join(cnfItem, " OR ")), sqlValues, nil
+}
+
+// ExportSQL creates SQL conditions (and respective query arguments)
+// of the selected attributes. In case an attribute value cannot
+// be processed, an error wrapping qbuilder.ErrInvalidAttrValue is returned.
+func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []any, error) {
+	where := make([]string, 0, 20)
+	sqlValues := make([]any, 0, 20)
+	for dkey, values := range args.data {
+		pred, predValues, err := args.attrPredicate(dkey, values, itemPrefix)
+		if err != nil {
+			return "", nil, err
 		}
-		if len(cnfItem) > 0 {
-			where = append(where, fmt.Sprintf("(%s)", strings.Join(cnfItem, " OR ")))
+		if pred != "" {
+			where = append(where, pred)
+			sqlValues = append(sqlValues, predValues...)
 		}
 	}
 	where = append(where, fmt.Sprintf("%s.corpus_id = ?", itemPrefix))
 	sqlValues = append(sqlValues, corpusID)
-	return strings.Join(where, " AND "), sqlValues
+	return strings.Join(where, " AND "), sqlValues, nil
 }
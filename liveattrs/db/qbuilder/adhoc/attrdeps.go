@@ -0,0 +1,49 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/utils"
+)
+
+// AttrDependency is a generator for an SQL query + args for obtaining
+// numbers of items for each combination of values of two attributes
+// within an ad-hoc selection of text types.
+type AttrDependency struct {
+	Selection
+	Attr          string
+	DependentAttr string
+}
+
+// Query generates the query. The returned columns are: value of Attr,
+// value of DependentAttr and number of matching items.
+func (adep *AttrDependency) Query() (ansSQL string, whereValues []any, err error) {
+	fromSQL, whereSQL, whereValues, err := adep.FromWhere()
+	if err != nil {
+		return
+	}
+	col1 := "t1." + utils.ImportKey(adep.Attr)
+	col2 := "t1." + utils.ImportKey(adep.DependentAttr)
+	ansSQL = fmt.Sprintf(
+		"SELECT %s, %s, COUNT(*) FROM %s WHERE %s GROUP BY %s, %s",
+		col1, col2, fromSQL, whereSQL, col1, col2,
+	)
+	return
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrDependencyQuery(t *testing.T) {
+	adep := &AttrDependency{
+		Selection: Selection{
+			CorpusInfo: &corpus.DBInfo{
+				Name: "syn2020",
+			},
+			AttrMap: query.Attrs{"doc.genre": []any{"fiction"}},
+		},
+		Attr:          "doc.title",
+		DependentAttr: "doc.author",
+	}
+	sqlq, args, err := adep.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT t1.doc_title, t1.doc_author, COUNT(*) FROM `syn2020_liveattrs_entry` AS t1  "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL AND (t1.doc_genre = ?) AND t1.corpus_id = ? "+
+			"GROUP BY t1.doc_title, t1.doc_author",
+		sqlq,
+	)
+	assert.Equal(t, []any{"syn2020", "fiction", "syn2020"}, args)
+}
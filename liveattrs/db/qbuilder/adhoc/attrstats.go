@@ -0,0 +1,78 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/utils"
+)
+
+// AttrStats is a generator for SQL queries + args for obtaining
+// basic statistics of a numeric attribute within an ad-hoc selection
+// of text types. Non-numeric values of the attribute are ignored.
+type AttrStats struct {
+	Selection
+	Attr string
+}
+
+func (astats *AttrStats) numericValue() string {
+	return fmt.Sprintf("CAST(t1.%s AS DECIMAL(20, 4))", utils.ImportKey(astats.Attr))
+}
+
+func (astats *AttrStats) numericFilter() string {
+	return fmt.Sprintf("t1.%s REGEXP '^-?[0-9]+(\\\\.[0-9]+)?$'", utils.ImportKey(astats.Attr))
+}
+
+// SummaryQuery generates a query returning min, max, mean value
+// and a number of matching items
+func (astats *AttrStats) SummaryQuery() (ansSQL string, whereValues []any, err error) {
+	fromSQL, whereSQL, whereValues, err := astats.FromWhere()
+	if err != nil {
+		return
+	}
+	ansSQL = fmt.Sprintf(
+		"SELECT MIN(%s), MAX(%s), AVG(%s), COUNT(*) FROM %s WHERE %s AND %s",
+		astats.numericValue(), astats.numericValue(), astats.numericValue(),
+		fromSQL, whereSQL, astats.numericFilter(),
+	)
+	return
+}
+
+// HistogramQuery generates a query returning numbers of items
+// and positions for each of numBins bins of the same width
+// starting at minVal. Bins with no items are not returned.
+func (astats *AttrStats) HistogramQuery(
+	minVal, binWidth float64,
+	numBins int,
+) (ansSQL string, whereValues []any, err error) {
+	fromSQL, whereSQL, whereValues, err := astats.FromWhere()
+	if err != nil {
+		return
+	}
+	binExpr := fmt.Sprintf(
+		"LEAST(FLOOR((%s - %f) / %f), %d)",
+		astats.numericValue(), minVal, binWidth, numBins-1,
+	)
+	ansSQL = fmt.Sprintf(
+		"SELECT %s AS bin, COUNT(*), SUM(t1.poscount) FROM %s WHERE %s AND %s "+
+			"GROUP BY bin ORDER BY bin",
+		binExpr, fromSQL, whereSQL, astats.numericFilter(),
+	)
+	return
+}
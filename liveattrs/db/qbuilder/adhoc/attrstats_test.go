@@ -0,0 +1,77 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingAttrStats(attrs query.Attrs) *AttrStats {
+	return &AttrStats{
+		Selection: Selection{
+			CorpusInfo: &corpus.DBInfo{
+				Name: "syn2020",
+			},
+			AttrMap: attrs,
+		},
+		Attr: "doc.year",
+	}
+}
+
+func TestSummaryQuery(t *testing.T) {
+	astats := createTestingAttrStats(query.Attrs{"doc.genre": []any{"fiction"}})
+	sqlq, args, err := astats.SummaryQuery()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT MIN(CAST(t1.doc_year AS DECIMAL(20, 4))), MAX(CAST(t1.doc_year AS DECIMAL(20, 4))), "+
+			"AVG(CAST(t1.doc_year AS DECIMAL(20, 4))), COUNT(*) FROM `syn2020_liveattrs_entry` AS t1  "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL AND (t1.doc_genre = ?) AND t1.corpus_id = ? "+
+			"AND t1.doc_year REGEXP '^-?[0-9]+(\\\\.[0-9]+)?$'",
+		sqlq,
+	)
+	assert.Equal(t, []any{"syn2020", "fiction", "syn2020"}, args)
+}
+
+func TestHistogramQuery(t *testing.T) {
+	astats := createTestingAttrStats(query.Attrs{})
+	sqlq, _, err := astats.HistogramQuery(1990, 2, 10)
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		sqlq,
+		"SELECT LEAST(FLOOR((CAST(t1.doc_year AS DECIMAL(20, 4)) - 1990.000000) / 2.000000), 9) AS bin",
+	)
+	assert.Contains(t, sqlq, "GROUP BY bin ORDER BY bin")
+}
+
+func TestHistogramQuerySingleBin(t *testing.T) {
+	astats := createTestingAttrStats(query.Attrs{})
+	sqlq, _, err := astats.HistogramQuery(2001, 1, 1)
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		sqlq,
+		"LEAST(FLOOR((CAST(t1.doc_year AS DECIMAL(20, 4)) - 2001.000000) / 1.000000), 0) AS bin",
+	)
+}
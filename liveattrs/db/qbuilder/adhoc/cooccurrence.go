@@ -0,0 +1,96 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/utils"
+	"strings"
+)
+
+// Cooccurrence is a generator for an SQL query + args for obtaining values
+// of target attributes still combinable with a (partial) selection of text types.
+// Besides target attributes, the query returns for each constrained attribute
+// of the selection a flag (0/1) telling whether the attribute's own constraint
+// is satisfied. Only rows violating at most one constraint are returned.
+// This allows evaluating "the selection without attribute X" for all
+// the attributes in a single pass.
+type Cooccurrence struct {
+	Selection
+	TargetAttrs []string
+}
+
+// ConstrainedAttrs returns attributes with a flag column in the query
+// (in the order of the columns)
+func (cooc *Cooccurrence) ConstrainedAttrs() ([]string, error) {
+	preds, err := cooc.predicates()
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]string, len(preds))
+	for i, p := range preds {
+		ans[i] = p.attr
+	}
+	return ans, nil
+}
+
+// Query generates the query. The returned columns are: poscount, values
+// of TargetAttrs and flags of ConstrainedAttrs (in the respective order).
+func (cooc *Cooccurrence) Query() (ansSQL string, whereValues []any, err error) {
+	preds, err := cooc.predicates()
+	if err != nil {
+		return
+	}
+	selCols := make([]string, 0, 1+len(cooc.TargetAttrs)+len(preds))
+	selCols = append(selCols, "t1.poscount")
+	for _, attr := range cooc.TargetAttrs {
+		selCols = append(selCols, "t1."+utils.ImportKey(attr))
+	}
+	flags := make([]string, len(preds))
+	selValues := make([]any, 0, 10)
+	flagValues := make([]any, 0, 10)
+	for i, p := range preds {
+		// NULL (e.g. comparison with an empty typed value) means "not satisfied"
+		flags[i] = fmt.Sprintf("COALESCE(%s, 0)", p.sql)
+		selValues = append(selValues, p.values...)
+		flagValues = append(flagValues, p.values...)
+	}
+	selCols = append(selCols, flags...)
+
+	fromSQL, where, baseValues := cooc.fromBaseWhere()
+	if len(preds) > 1 {
+		where = append(
+			where,
+			fmt.Sprintf("(%s) >= %d", strings.Join(flags, " + "), len(preds)-1),
+		)
+	}
+	ansSQL = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s",
+		strings.Join(selCols, ", "),
+		fromSQL,
+		strings.Join(where, " AND "),
+	)
+	whereValues = make([]any, 0, len(selValues)+len(baseValues)+len(flagValues))
+	whereValues = append(whereValues, selValues...)
+	whereValues = append(whereValues, baseValues...)
+	if len(preds) > 1 {
+		whereValues = append(whereValues, flagValues...)
+	}
+	return
+}
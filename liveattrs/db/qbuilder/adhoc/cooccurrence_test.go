@@ -0,0 +1,77 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingCooccurrence(attrs query.Attrs) *Cooccurrence {
+	return &Cooccurrence{
+		Selection: Selection{
+			CorpusInfo: &corpus.DBInfo{
+				Name: "syn2020",
+			},
+			AttrMap: attrs,
+		},
+		TargetAttrs: []string{"doc.genre", "doc.year"},
+	}
+}
+
+func TestCooccurrenceQuery(t *testing.T) {
+	cooc := createTestingCooccurrence(query.Attrs{
+		"doc.year":  []any{"2001", "2002"},
+		"doc.genre": []any{"fiction"},
+	})
+	constrained, err := cooc.ConstrainedAttrs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"doc.genre", "doc.year"}, constrained)
+	sqlq, args, err := cooc.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT t1.poscount, t1.doc_genre, t1.doc_year, COALESCE((t1.doc_genre = ?), 0), "+
+			"COALESCE((t1.doc_year = ? OR t1.doc_year = ?), 0) FROM `syn2020_liveattrs_entry` AS t1  "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL AND "+
+			"(COALESCE((t1.doc_genre = ?), 0) + COALESCE((t1.doc_year = ? OR t1.doc_year = ?), 0)) >= 1",
+		sqlq,
+	)
+	assert.Equal(
+		t,
+		[]any{"fiction", "2001", "2002", "syn2020", "fiction", "2001", "2002"},
+		args,
+	)
+}
+
+func TestCooccurrenceQuerySingleConstraint(t *testing.T) {
+	cooc := createTestingCooccurrence(query.Attrs{"doc.genre": []any{"fiction"}})
+	sqlq, args, err := cooc.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT t1.poscount, t1.doc_genre, t1.doc_year, COALESCE((t1.doc_genre = ?), 0) "+
+			"FROM `syn2020_liveattrs_entry` AS t1  WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL",
+		sqlq,
+	)
+	assert.Equal(t, []any{"fiction", "syn2020"}, args)
+}
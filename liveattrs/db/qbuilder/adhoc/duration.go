@@ -0,0 +1,46 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/utils"
+)
+
+// SubcDuration is a generator for an SQL query + args for obtaining
+// a total duration of segments (see laconf.TimingConf) within an ad-hoc
+// selection of text types
+type SubcDuration struct {
+	Selection
+	DurationAttr string
+}
+
+// Query generates a query returning the total duration
+// (NULL in case no segment with a valid duration matches)
+func (sdur *SubcDuration) Query() (ansSQL string, whereValues []any, err error) {
+	fromSQL, whereSQL, whereValues, err := sdur.FromWhere()
+	if err != nil {
+		return
+	}
+	ansSQL = fmt.Sprintf(
+		"SELECT SUM(t1.%s) FROM %s WHERE %s",
+		utils.ImportKey(sdur.DurationAttr), fromSQL, whereSQL,
+	)
+	return
+}
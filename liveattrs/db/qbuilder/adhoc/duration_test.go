@@ -0,0 +1,48 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubcDurationQuery(t *testing.T) {
+	sdur := &SubcDuration{
+		Selection: Selection{
+			CorpusInfo: &corpus.DBInfo{
+				Name: "oral2013",
+			},
+			AttrMap: query.Attrs{"seg.duration": map[string]any{"from": "5"}},
+		},
+		DurationAttr: "seg.duration",
+	}
+	sqlq, args, err := sdur.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT SUM(t1.seg_duration) FROM `oral2013_liveattrs_entry` AS t1  "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL AND (t1.seg_duration >= ?) AND t1.corpus_id = ?",
+		sqlq,
+	)
+	assert.Equal(t, []any{"oral2013", "5", "oral2013"}, args)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"fmt"
+	"masm/v3/liveattrs/utils"
+	"strings"
+)
+
+// SelectionShare is a generator for an SQL query + args for obtaining sizes
+// of a selection of text types relative to the whole corpus, broken down
+// by values of target attributes. Both the whole corpus size and the size
+// of the selection are calculated in a single pass using conditional
+// aggregation. Aligned corpora are considered a part of the selection
+// (i.e. they do not restrict the whole corpus).
+type SelectionShare struct {
+	Selection
+	TargetAttrs []string
+}
+
+// selectionCondition returns an SQL condition matching entries
+// of the selection (including aligned corpora).
+func (share *SelectionShare) selectionCondition() (string, []any, error) {
+	preds, err := share.predicates()
+	if err != nil {
+		return "", nil, err
+	}
+	cond := make([]string, 0, len(preds)+len(share.AlignedCorpora))
+	values := make([]any, 0, 10)
+	for _, p := range preds {
+		cond = append(cond, p.sql)
+		values = append(values, p.values...)
+	}
+	for _, item := range share.AlignedCorpora {
+		cond = append(
+			cond,
+			fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM `%s_liveattrs_entry` AS t2 "+
+					"WHERE t2.item_id = t1.item_id AND t2.corpus_id = ?)",
+				share.CorpusInfo.GroupedName(),
+			),
+		)
+		values = append(values, item)
+	}
+	if len(cond) == 0 {
+		return "1 = 1", values, nil
+	}
+	return strings.Join(cond, " AND "), values, nil
+}
+
+// Query generates the query. The returned columns are: values
+// of TargetAttrs (in the respective order), size of the whole
+// corpus part and size of the selection part.
+func (share *SelectionShare) Query() (ansSQL string, whereValues []any, err error) {
+	cond, condValues, err := share.selectionCondition()
+	if err != nil {
+		return
+	}
+	groupCols := make([]string, len(share.TargetAttrs))
+	for i, attr := range share.TargetAttrs {
+		groupCols[i] = "t1." + utils.ImportKey(attr)
+	}
+	selCols := make([]string, 0, len(groupCols)+2)
+	selCols = append(selCols, groupCols...)
+	selCols = append(
+		selCols,
+		"SUM(t1.poscount)",
+		fmt.Sprintf("SUM(CASE WHEN %s THEN t1.poscount ELSE 0 END)", cond),
+	)
+	ansSQL = fmt.Sprintf(
+		"SELECT %s FROM `%s_liveattrs_entry` AS t1 "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL",
+		strings.Join(selCols, ", "),
+		share.CorpusInfo.GroupedName(),
+	)
+	if len(groupCols) > 0 {
+		ansSQL += " GROUP BY " + strings.Join(groupCols, ", ")
+	}
+	whereValues = make([]any, 0, len(condValues)+1)
+	whereValues = append(whereValues, condValues...)
+	whereValues = append(whereValues, share.CorpusInfo.Name)
+	return
+}
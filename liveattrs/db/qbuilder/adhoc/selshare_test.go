@@ -0,0 +1,82 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package adhoc
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectionShareQuery(t *testing.T) {
+	share := &SelectionShare{
+		Selection: Selection{
+			CorpusInfo: &corpus.DBInfo{Name: "syn2020"},
+			AttrMap: query.Attrs{
+				"doc.year":  []any{"2001", "2002"},
+				"doc.genre": []any{"fiction"},
+			},
+		},
+		TargetAttrs: []string{"doc.txtype"},
+	}
+	sqlq, args, err := share.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT t1.doc_txtype, SUM(t1.poscount), SUM(CASE WHEN (t1.doc_genre = ?) AND "+
+			"(t1.doc_year = ? OR t1.doc_year = ?) THEN t1.poscount ELSE 0 END) "+
+			"FROM `syn2020_liveattrs_entry` AS t1 WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL "+
+			"GROUP BY t1.doc_txtype",
+		sqlq,
+	)
+	assert.Equal(t, []any{"fiction", "2001", "2002", "syn2020"}, args)
+}
+
+func TestSelectionShareQueryAligned(t *testing.T) {
+	share := &SelectionShare{
+		Selection: Selection{
+			CorpusInfo:     &corpus.DBInfo{Name: "intercorp_v13_cs", ParallelCorpus: "intercorp_v13"},
+			AlignedCorpora: []string{"intercorp_v13_en"},
+		},
+		TargetAttrs: []string{"div.txtype", "div.srclang"},
+	}
+	sqlq, args, err := share.Query()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT t1.div_txtype, t1.div_srclang, SUM(t1.poscount), SUM(CASE WHEN "+
+			"EXISTS (SELECT 1 FROM `intercorp_v13_liveattrs_entry` AS t2 WHERE t2.item_id = t1.item_id "+
+			"AND t2.corpus_id = ?) THEN t1.poscount ELSE 0 END) FROM `intercorp_v13_liveattrs_entry` AS t1 "+
+			"WHERE t1.corpus_id = ? AND t1.poscount is NOT NULL GROUP BY t1.div_txtype, t1.div_srclang",
+		sqlq,
+	)
+	assert.Equal(t, []any{"intercorp_v13_en", "intercorp_v13_cs"}, args)
+}
+
+func TestSelectionShareQueryEmptySelection(t *testing.T) {
+	share := &SelectionShare{
+		Selection:   Selection{CorpusInfo: &corpus.DBInfo{Name: "syn2020"}},
+		TargetAttrs: []string{"doc.genre"},
+	}
+	sqlq, _, err := share.Query()
+	assert.NoError(t, err)
+	assert.Contains(t, sqlq, "SUM(CASE WHEN 1 = 1 THEN t1.poscount ELSE 0 END)")
+}
@@ -21,11 +21,10 @@ package laquery
 import (
 	"fmt"
 	"masm/v3/liveattrs/db/qbuilder"
+	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/utils"
 	"strings"
-
-	"github.com/rs/zerolog/log"
 )
 
 type PredicateArgs struct {
@@ -34,6 +33,20 @@ type PredicateArgs struct {
 	bibLabel            string
 	autocompleteAttr    string
 	emptyValPlaceholder string
+	attrTypes           laconf.AttrTypes
+	autocompleteConf    laconf.AutocompleteConf
+
+	// speakerAttrs contains columns stored in the speaker
+	// table (see laconf.SpeakerConf)
+	speakerAttrs map[string]bool
+}
+
+// columnPrefix returns a table alias of a column
+func (args *PredicateArgs) columnPrefix(key, itemPrefix string) string {
+	if args.speakerAttrs[key] {
+		return speakerTableAlias
+	}
+	return itemPrefix
 }
 
 func (args *PredicateArgs) Len() int {
@@ -47,7 +60,10 @@ func (args *PredicateArgs) importValue(value string) string {
 	return value
 }
 
-func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []string) {
+// ExportSQL creates SQL conditions (and respective query arguments)
+// of the selected attributes. In case an attribute value cannot
+// be processed, an error wrapping qbuilder.ErrInvalidAttrValue is returned.
+func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []string, error) {
 	where := make([]string, 0, 20)
 	sqlValues := make([]string, 0, 20)
 	for dkey, values := range args.data {
@@ -55,6 +71,7 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 		if args.autocompleteAttr == args.bibLabel && key == args.bibID {
 			continue
 		}
+		colPrefix := args.columnPrefix(key, itemPrefix)
 		cnfItem := make([]string, 0, 20)
 		switch tValues := values.(type) {
 		case []any:
@@ -64,11 +81,15 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 					continue
 				}
 				if len(tValue) == 0 || tValue[0] != '@' {
+					if qbuilder.IsNullValue(args.importValue(tValue), args.attrTypes.Get(dkey)) {
+						cnfItem = append(cnfItem, fmt.Sprintf("%s.%s IS NULL", colPrefix, key))
+						continue
+					}
 					cnfItem = append(
 						cnfItem,
 						fmt.Sprintf(
 							"%s.%s %s ?",
-							itemPrefix, key, qbuilder.CmpOperator(tValue),
+							colPrefix, key, qbuilder.CmpOperator(tValue),
 						),
 					)
 					sqlValues = append(sqlValues, args.importValue(tValue))
@@ -86,31 +107,63 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 				}
 			}
 		case string:
+			if dkey == args.autocompleteAttr {
+				pred, predVals := qbuilder.AutocompletePredicate(
+					fmt.Sprintf("%s.%s", colPrefix, key),
+					args.importValue(tValues),
+					args.autocompleteConf,
+				)
+				cnfItem = append(cnfItem, pred)
+				sqlValues = append(sqlValues, predVals...)
+				break
+			}
 			cnfItem = append(
 				cnfItem,
 				fmt.Sprintf(
 					"%s.%s LIKE ?",
-					itemPrefix, key),
+					colPrefix, key),
 			)
 			sqlValues = append(sqlValues, args.importValue(tValues))
 		case map[string]any:
-			regexpVal, ok := args.data.GetRegexpAttrVal(dkey)
-			if ok {
-				cnfItem = append(cnfItem, fmt.Sprintf("%s.%s REGEXP ?", itemPrefix, key))
-				sqlValues = append(sqlValues, args.importValue(regexpVal))
-
-				// TODO add support for this
-			} else {
-				// TODO handle in a better way
-				log.Error().Msgf(
-					"failed to determine type of liveattrs attribute %s (corpus %s)", key, corpusID)
+			if negVals, ok := args.data.GetNegatedValues(dkey); ok {
+				for i, v := range negVals {
+					negVals[i] = args.importValue(v)
+				}
+				pred, predVals := qbuilder.TypedNegationPredicate(
+					fmt.Sprintf("%s.%s", colPrefix, key), args.attrTypes.Get(dkey), negVals)
+				cnfItem = append(cnfItem, pred)
+				for _, v := range predVals {
+					sqlValues = append(sqlValues, v)
+				}
+				break
+			}
+			if from, to, ok := args.data.GetRangeAttrVal(dkey); ok {
+				pred, predVals := qbuilder.RangePredicate(
+					fmt.Sprintf("%s.%s", colPrefix, key), args.attrTypes.Get(dkey), from, to)
+				cnfItem = append(cnfItem, pred)
+				for _, v := range predVals {
+					sqlValues = append(sqlValues, v)
+				}
+				break
+			}
+			op, opVal, ok := args.data.GetOperatorAttrVal(dkey)
+			if !ok {
+				return "", nil, fmt.Errorf(
+					"%w: unsupported value of attribute %s", qbuilder.ErrInvalidAttrValue, dkey)
+			}
+			pred, predVal, err := qbuilder.OperatorPredicate(
+				fmt.Sprintf("%s.%s", colPrefix, key), op, args.importValue(opVal))
+			if err != nil {
+				return "", nil, fmt.Errorf("attribute %s: %w", dkey, err)
 			}
+			cnfItem = append(cnfItem, pred)
+			sqlValues = append(sqlValues, predVal)
 		default: // TODO can this even happen???
 			cnfItem = append(
 				cnfItem,
 				fmt.Sprintf(
 					"LOWER(%s.%s) %s LOWER(?)",
-					itemPrefix, key, qbuilder.CmpOperator(fmt.Sprintf("%v", tValues)),
+					colPrefix, key, qbuilder.CmpOperator(fmt.Sprintf("%v", tValues)),
 				),
 			)
 			sqlValues = append(sqlValues, args.importValue(fmt.Sprintf("%v", tValues)))
@@ -122,7 +175,7 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 	}
 	where = append(where, fmt.Sprintf("%s.corpus_id = ?", itemPrefix))
 	sqlValues = append(sqlValues, corpusID)
-	return strings.Join(where, " AND "), sqlValues
+	return strings.Join(where, " AND "), sqlValues, nil
 }
 
 type QueryComponents struct {
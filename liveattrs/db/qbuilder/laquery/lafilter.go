@@ -19,15 +19,32 @@
 package laquery
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"masm/v3/corpus"
 	"masm/v3/general/collections"
+	"masm/v3/liveattrs/db/qbuilder"
+	"masm/v3/liveattrs/laconf"
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/utils"
 	"strings"
 )
 
+const (
+	// speakerTableAlias is an alias of the joined speaker table
+	speakerTableAlias = "s"
+
+	// entryColsSQL are common columns selected for each entry
+	entryColsSQL = "t1.poscount, t1.id"
+
+	// speakerEntryColsSQL are common columns selected for each entry
+	// in case the speaker table is joined. Entries without speakers
+	// are kept as they are.
+	speakerEntryColsSQL = "COALESCE(s.poscount, t1.poscount), " +
+		"CONCAT(t1.id, ':', COALESCE(s.id, ''))"
+)
+
 type LAFilter struct {
 	CorpusInfo          *corpus.DBInfo
 	AttrMap             query.Attrs
@@ -35,17 +52,103 @@ type LAFilter struct {
 	AlignedCorpora      []string
 	AutocompleteAttr    string
 	EmptyValPlaceholder string
+	AttrTypes           laconf.AttrTypes
+	AutocompleteConf    laconf.AutocompleteConf
+
+	// Subcorpus (if not nil) restricts searched entries
+	Subcorpus *query.Subcorpus
+
+	// Speakers (if enabled) specifies attributes stored in the speaker
+	// table which is joined once any of them is searched or filtered
+	Speakers *laconf.SpeakerConf
 }
 
 func (b *LAFilter) attrToSQL(values []string, prefix string) []string {
+	spAttrs := b.speakerAttrs()
 	ans := make([]string, len(values))
 	for i, v := range values {
-		ans[i] = prefix + "." + utils.ImportKey(v)
+		key := utils.ImportKey(v)
+		if spAttrs[key] {
+			ans[i] = speakerTableAlias + "." + key
+			continue
+		}
+		ans[i] = prefix + "." + key
+	}
+	return ans
+}
+
+// speakerAttrs returns speaker attributes (in the "import" form)
+// or nil in case speakers are not configured
+func (b *LAFilter) speakerAttrs() map[string]bool {
+	if !b.Speakers.IsEnabled() {
+		return nil
+	}
+	ans := make(map[string]bool)
+	for _, attr := range b.Speakers.QualifiedAttrs() {
+		ans[utils.ImportKey(attr)] = true
 	}
 	return ans
 }
 
-func (b *LAFilter) CreateSQL() QueryComponents {
+// usesSpeakers tests whether the query requires
+// the speaker table to be joined
+func (b *LAFilter) usesSpeakers() bool {
+	spAttrs := b.speakerAttrs()
+	if len(spAttrs) == 0 {
+		return false
+	}
+	for _, attr := range b.SearchAttrs {
+		if spAttrs[utils.ImportKey(attr)] {
+			return true
+		}
+	}
+	for attr := range b.AttrMap {
+		if spAttrs[utils.ImportKey(attr)] {
+			return true
+		}
+	}
+	if b.Subcorpus != nil {
+		for attr := range b.Subcorpus.Definition {
+			if spAttrs[utils.ImportKey(attr)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subcorpusSQL creates a condition restricting entries
+// to the subcorpus (see query.Subcorpus)
+func (b *LAFilter) subcorpusSQL(bibID, bibLabel string) (string, []string, error) {
+	where := make([]string, 0, 2)
+	values := make([]string, 0, len(b.Subcorpus.StructIDs)+len(b.Subcorpus.Definition))
+	if len(b.Subcorpus.StructIDs) > 0 {
+		where = append(where, qbuilder.InPredicate("t1."+bibID, len(b.Subcorpus.StructIDs)))
+		values = append(values, b.Subcorpus.StructIDs...)
+	}
+	if len(b.Subcorpus.Definition) > 0 {
+		defItems := PredicateArgs{
+			data:                b.Subcorpus.Definition,
+			bibID:               bibID,
+			bibLabel:            bibLabel,
+			emptyValPlaceholder: b.EmptyValPlaceholder,
+			attrTypes:           b.AttrTypes,
+			speakerAttrs:        b.speakerAttrs(),
+		}
+		defSQL, defValues, err := defItems.ExportSQL("t1", b.CorpusInfo.Name)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid subcorpus definition: %w", err)
+		}
+		where = append(where, defSQL)
+		values = append(values, defValues...)
+	}
+	return strings.Join(where, " AND "), values, nil
+}
+
+// CreateSQL generates the query. In case the selected attributes
+// cannot be processed, an error wrapping qbuilder.ErrInvalidAttrValue
+// is returned.
+func (b *LAFilter) CreateSQL() (QueryComponents, error) {
 	bibID := utils.ImportKey(b.CorpusInfo.BibIDAttr)
 	bibLabel := utils.ImportKey(b.CorpusInfo.BibLabelAttr)
 	attrItems := PredicateArgs{
@@ -54,13 +157,39 @@ func (b *LAFilter) CreateSQL() QueryComponents {
 		bibLabel:            bibLabel,
 		autocompleteAttr:    b.AutocompleteAttr,
 		emptyValPlaceholder: b.EmptyValPlaceholder,
+		attrTypes:           b.AttrTypes,
+		autocompleteConf:    b.AutocompleteConf,
+		speakerAttrs:        b.speakerAttrs(),
+	}
+	whereSQL0, whereValues0, err := attrItems.ExportSQL("t1", b.CorpusInfo.Name) // TODO py uses 'info.id' here
+	if err != nil {
+		return QueryComponents{}, err
 	}
-	whereSQL0, whereValues0 := attrItems.ExportSQL("t1", b.CorpusInfo.Name) // TODO py uses 'info.id' here
 	whereSQL := make([]string, 0, 20)
 	whereSQL = append(whereSQL, whereSQL0)
 	whereValues := make([]string, 0, 20+len(whereValues0))
 	whereValues = append(whereValues, whereValues0...)
+	if b.Subcorpus != nil {
+		subcSQL, subcValues, err := b.subcorpusSQL(bibID, bibLabel)
+		if err != nil {
+			return QueryComponents{}, err
+		}
+		whereSQL = append(whereSQL, " AND "+subcSQL)
+		whereValues = append(whereValues, subcValues...)
+	}
 	joinSQL := make([]string, 0, 20)
+	entryCols := entryColsSQL
+	if b.usesSpeakers() {
+		entryCols = speakerEntryColsSQL
+		joinSQL = append(
+			joinSQL,
+			fmt.Sprintf(
+				"LEFT JOIN `%s_liveattrs_speaker` AS %s ON %s.doc_key = t1.%s AND %s.corpus_id = t1.corpus_id",
+				b.CorpusInfo.GroupedName(), speakerTableAlias, speakerTableAlias,
+				utils.ImportKey(b.Speakers.DocIDAttr), speakerTableAlias,
+			),
+		)
+	}
 	for i, item := range b.AlignedCorpora {
 		joinSQL = append(
 			joinSQL,
@@ -80,7 +209,8 @@ func (b *LAFilter) CreateSQL() QueryComponents {
 	var sqlTemplate string
 	if len(whereSQL) > 0 {
 		sqlTemplate = fmt.Sprintf(
-			"SELECT DISTINCT t1.poscount, t1.id, %s FROM `%s_liveattrs_entry` AS t1 %s WHERE %s",
+			"SELECT DISTINCT %s, %s FROM `%s_liveattrs_entry` AS t1 %s WHERE %s",
+			entryCols,
 			strings.Join(b.attrToSQL(selectedAttrs.ToOrderedSlice(), "t1"), ", "),
 			b.CorpusInfo.GroupedName(),
 			strings.Join(joinSQL, " "),
@@ -89,7 +219,8 @@ func (b *LAFilter) CreateSQL() QueryComponents {
 
 	} else {
 		sqlTemplate = fmt.Sprintf(
-			"SELECT DISTINCT t1.poscount, %s FROM `%s_liveattrs_entry` AS t1 %s",
+			"SELECT DISTINCT %s, %s FROM `%s_liveattrs_entry` AS t1 %s",
+			entryCols,
 			strings.Join(b.attrToSQL(selectedAttrs.ToOrderedSlice(), "t1"), ", "),
 			b.CorpusInfo.GroupedName(),
 			strings.Join(joinSQL, " "),
@@ -100,13 +231,12 @@ func (b *LAFilter) CreateSQL() QueryComponents {
 		selectedAttrs: selectedAttrs.ToOrderedSlice(),
 		hiddenAttrs:   hiddenAttrs.ToOrderedSlice(),
 		whereValues:   whereValues,
-	}
+	}, nil
 }
 
 type ResultRow struct {
-	Attrs     map[string]string
-	Poscount  int
-	Wordcount int
+	Attrs    map[string]string
+	Poscount int
 }
 
 type DataIterator struct {
@@ -115,15 +245,26 @@ type DataIterator struct {
 }
 
 func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
-	qc := di.Builder.CreateSQL()
+	return di.IterateContext(context.Background(), fn)
+}
+
+// IterateContext is the same as Iterate but the query is cancelled once
+// the context is done. In such case, rows processed so far remain
+// processed and the context's error is returned.
+func (di *DataIterator) IterateContext(ctx context.Context, fn func(row ResultRow) error) error {
+	qc, err := di.Builder.CreateSQL()
+	if err != nil {
+		return err
+	}
 	args := make([]any, len(qc.whereValues))
 	for i, v := range qc.whereValues {
 		args[i] = v
 	}
-	rows, err := di.DB.Query(qc.sqlTemplate, args...)
+	rows, err := di.DB.QueryContext(ctx, qc.sqlTemplate, args...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	colnames, err := rows.Columns()
 	if err != nil {
 		return err
@@ -144,9 +285,8 @@ func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
 		}
 		for i, colname := range colnames[2:] {
 			// we ignore 1 st item which is db ID
-			if ansAttrs[i+1].Valid {
-				ansRow.Attrs[colname] = ansAttrs[i+1].String
-			}
+			// (NULL means an empty value of a typed attribute)
+			ansRow.Attrs[colname] = ansAttrs[i+1].String
 		}
 		err = fn(ansRow)
 		if err != nil {
@@ -154,5 +294,5 @@ func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
 		}
 
 	}
-	return nil
+	return rows.Err()
 }
@@ -0,0 +1,277 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laquery
+
+import (
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingFilter(attrs query.Attrs, aligned []string) *LAFilter {
+	return &LAFilter{
+		CorpusInfo: &corpus.DBInfo{
+			Name:           "intercorp_v13_cs",
+			ParallelCorpus: "intercorp_v13",
+		},
+		AttrMap:             attrs,
+		SearchAttrs:         []string{"doc_genre"},
+		AlignedCorpora:      aligned,
+		EmptyValPlaceholder: "?",
+	}
+}
+
+func TestNegationSingleValue(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": map[string]any{"not": "fiction"}},
+		[]string{},
+	)
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_genre NOT IN (?))")
+	assert.Equal(t, []string{"fiction", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestNegationValueListing(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": map[string]any{"not": []any{"fiction", "?"}}},
+		[]string{},
+	)
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_genre NOT IN (?, ?))")
+	assert.Equal(t, []string{"fiction", "", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestNegationWithAlignedCorpora(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": map[string]any{"not": []any{"fiction"}}},
+		[]string{"intercorp_v13_en", "intercorp_v13_de"},
+	)
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"JOIN `intercorp_v13_liveattrs_entry` AS t2 ON t1.item_id = t2.item_id "+
+			"JOIN `intercorp_v13_liveattrs_entry` AS t3 ON t1.item_id = t3.item_id",
+	)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"WHERE (t1.doc_genre NOT IN (?)) AND t1.corpus_id = ?  AND t2.corpus_id = ?  AND t3.corpus_id = ?",
+	)
+	assert.Equal(
+		t,
+		[]string{"fiction", "intercorp_v13_cs", "intercorp_v13_en", "intercorp_v13_de"},
+		qc.whereValues,
+	)
+}
+
+func TestDateRange(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.pubdate": map[string]any{"from": "2001-01-01", "to": "2005-12-31"}},
+		[]string{},
+	)
+	filter.AttrTypes = laconf.AttrTypes{"doc.pubdate": laconf.AttrTypeDate}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"(CAST(t1.doc_pubdate AS DATE) >= CAST(? AS DATE) AND CAST(t1.doc_pubdate AS DATE) <= CAST(? AS DATE))",
+	)
+	assert.Equal(t, []string{"2001-01-01", "2005-12-31", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestUntypedOpenRange(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.year": map[string]any{"from": "1990"}},
+		[]string{},
+	)
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_year >= ?)")
+	assert.Equal(t, []string{"1990", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestNumberRange(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.rating": map[string]any{"from": "2,5"}},
+		[]string{},
+	)
+	filter.AttrTypes = laconf.AttrTypes{"doc.rating": laconf.AttrTypeNumber}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"(CAST(REPLACE(t1.doc_rating, ',', '.') AS DECIMAL(65, 10)) >= "+
+			"CAST(REPLACE(?, ',', '.') AS DECIMAL(65, 10)) AND "+
+			"t1.doc_rating REGEXP '^-?[0-9]+([.,][0-9]+)?$')",
+	)
+	assert.Equal(t, []string{"2,5", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestTypedEmptyValue(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.pubdate": []any{"?", "2001-01-01"}},
+		[]string{},
+	)
+	filter.AttrTypes = laconf.AttrTypes{"doc.pubdate": laconf.AttrTypeDate}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_pubdate IS NULL OR t1.doc_pubdate = ?)")
+	assert.Equal(t, []string{"2001-01-01", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestUntypedEmptyValue(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": []any{"?"}},
+		[]string{},
+	)
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_genre = ?)")
+	assert.Equal(t, []string{"", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestTypedNegation(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.year": map[string]any{"not": []any{"1990"}}},
+		[]string{},
+	)
+	filter.AttrTypes = laconf.AttrTypes{"doc.year": laconf.AttrTypeInt}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "((t1.doc_year IS NULL OR t1.doc_year NOT IN (?)))")
+	assert.Equal(t, []string{"1990", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestTypedNegationOfEmptyValue(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.year": map[string]any{"not": []any{"?", "1990"}}},
+		[]string{},
+	)
+	filter.AttrTypes = laconf.AttrTypes{"doc.year": laconf.AttrTypeInt}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_year IS NOT NULL AND t1.doc_year NOT IN (?))")
+	assert.Equal(t, []string{"1990", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestAutocompleteAttr(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.author": "Capek"},
+		[]string{},
+	)
+	filter.AutocompleteAttr = "doc.author"
+	filter.AutocompleteConf = laconf.AutocompleteConf{IgnoreDiacritics: true}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_author COLLATE utf8mb4_general_ci LIKE ?)")
+	assert.Equal(t, []string{"%Capek%", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestSubcorpusRestriction(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": []any{"fiction"}},
+		[]string{"intercorp_v13_en"},
+	)
+	filter.CorpusInfo.BibIDAttr = "doc.id"
+	filter.Subcorpus = &query.Subcorpus{
+		StructIDs:  []string{"d1", "d2"},
+		Definition: query.Attrs{"doc.lang": []any{"cs"}},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"WHERE (t1.doc_genre = ?) AND t1.corpus_id = ?  AND t1.doc_id IN (?, ?) AND "+
+			"(t1.doc_lang = ?) AND t1.corpus_id = ?  AND t2.corpus_id = ?",
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"fiction", "intercorp_v13_cs", "d1", "d2", "cs", "intercorp_v13_cs", "intercorp_v13_en"},
+		qc.whereValues,
+	)
+}
+
+func TestSpeakerAttrsJoin(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": []any{"fiction"}, "sp.sex": []any{"F"}},
+		[]string{},
+	)
+	filter.SearchAttrs = []string{"doc_genre", "sp.sex"}
+	filter.Speakers = &laconf.SpeakerConf{
+		Struct:    "sp",
+		IDAttr:    "id",
+		Attrs:     []string{"sex"},
+		DocIDAttr: "doc.id",
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Contains(
+		t,
+		qc.sqlTemplate,
+		"LEFT JOIN `intercorp_v13_liveattrs_speaker` AS s ON s.doc_key = t1.doc_id AND s.corpus_id = t1.corpus_id",
+	)
+	assert.Contains(t, qc.sqlTemplate, "t1.doc_genre, s.sp_sex FROM")
+	// the order of attribute conditions is not defined
+	assert.Contains(t, qc.sqlTemplate, "(t1.doc_genre = ?)")
+	assert.Contains(t, qc.sqlTemplate, "(s.sp_sex = ?)")
+	assert.Contains(t, qc.sqlTemplate, "AND t1.corpus_id = ?")
+	assert.ElementsMatch(t, []string{"fiction", "F", "intercorp_v13_cs"}, qc.whereValues)
+}
+
+func TestSpeakerAttrsNoJoin(t *testing.T) {
+	filter := createTestingFilter(query.Attrs{"doc.genre": []any{"fiction"}}, []string{})
+	filter.Speakers = &laconf.SpeakerConf{
+		Struct:    "sp",
+		IDAttr:    "id",
+		Attrs:     []string{"sex"},
+		DocIDAttr: "doc.id",
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.NotContains(t, qc.sqlTemplate, "_liveattrs_speaker")
+	assert.Contains(t, qc.sqlTemplate, "SELECT DISTINCT t1.poscount, t1.id,")
+}
+
+func TestUnknownOperatorObject(t *testing.T) {
+	filter := createTestingFilter(
+		query.Attrs{"doc.genre": map[string]any{"foo": "fiction"}},
+		[]string{},
+	)
+	_, err := filter.CreateSQL()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+
+	filter = createTestingFilter(query.Attrs{}, []string{})
+	filter.Subcorpus = &query.Subcorpus{
+		Definition: query.Attrs{"doc.genre": map[string]any{"regexp": ""}},
+	}
+	_, err = filter.CreateSQL()
+	assert.ErrorIs(t, err, qbuilder.ErrInvalidAttrValue)
+}
@@ -18,7 +18,19 @@
 
 package qbuilder
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
+	"math"
+	"strings"
+)
+
+// ErrInvalidAttrValue means that a selected attribute value
+// cannot be converted into an SQL predicate (e.g. an object
+// with an unknown operator)
+var ErrInvalidAttrValue = errors.New("invalid attribute value")
 
 func CmpOperator(val string) string {
 	if strings.Contains(val, "%") {
@@ -26,3 +38,200 @@ func CmpOperator(val string) string {
 	}
 	return "="
 }
+
+// WildcardToLike converts a wildcard expression (`*` for any
+// string, `?` for a single character) into an SQL LIKE pattern.
+// Characters with special meaning in LIKE are escaped.
+func WildcardToLike(val string) string {
+	var ans strings.Builder
+	for _, c := range val {
+		switch c {
+		case '*':
+			ans.WriteRune('%')
+		case '?':
+			ans.WriteRune('_')
+		case '%', '_', '\\':
+			ans.WriteRune('\\')
+			ans.WriteRune(c)
+		default:
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+// EscapeLike escapes characters with special meaning in SQL LIKE
+// so the value is matched literally
+func EscapeLike(val string) string {
+	var ans strings.Builder
+	for _, c := range val {
+		switch c {
+		case '%', '_', '\\':
+			ans.WriteRune('\\')
+			ans.WriteRune(c)
+		default:
+			ans.WriteRune(c)
+		}
+	}
+	return ans.String()
+}
+
+// Trigrams returns distinct character trigrams of a lowercased text
+// (in order of their first occurrence). Texts shorter than three
+// characters produce no trigrams.
+func Trigrams(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	ans := make([]string, 0, len(runes))
+	seen := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		tri := string(runes[i : i+3])
+		if !seen[tri] {
+			seen[tri] = true
+			ans = append(ans, tri)
+		}
+	}
+	return ans
+}
+
+// AutocompletePredicate creates an SQL predicate (and values to be passed
+// as query arguments) matching column values containing the provided text.
+// With conf.IgnoreDiacritics, the column is compared using an accent insensitive
+// collation. With conf.Fuzzy, also values containing at least
+// conf.FuzzyMinSimilarity of text's trigrams are matched.
+func AutocompletePredicate(
+	column, text string,
+	conf laconf.AutocompleteConf,
+) (string, []string) {
+	colExpr := column
+	if conf.IgnoreDiacritics {
+		colExpr = fmt.Sprintf("%s COLLATE %s", column, conf.GetCollation())
+	}
+	pred := fmt.Sprintf("%s LIKE ?", colExpr)
+	values := []string{"%" + EscapeLike(text) + "%"}
+	if !conf.Fuzzy {
+		return pred, values
+	}
+	trigrams := Trigrams(text)
+	if len(trigrams) < 2 {
+		return pred, values
+	}
+	triPreds := make([]string, len(trigrams))
+	for i, tri := range trigrams {
+		triPreds[i] = fmt.Sprintf("(%s LIKE ?)", colExpr)
+		values = append(values, "%"+EscapeLike(tri)+"%")
+	}
+	minMatches := int(math.Ceil(conf.GetFuzzyMinSimilarity() * float64(len(trigrams))))
+	if minMatches < 1 {
+		minMatches = 1
+	}
+	return fmt.Sprintf(
+		"(%s OR (%s) >= %d)", pred, strings.Join(triPreds, " + "), minMatches), values
+}
+
+// OperatorPredicate creates an SQL predicate and a respective
+// value for an operator-based attribute value (see query.Attrs.GetOperatorAttrVal)
+func OperatorPredicate(column, op, val string) (string, string, error) {
+	switch op {
+	case query.OperatorRegexp:
+		return fmt.Sprintf("%s REGEXP ?", column), val, nil
+	case query.OperatorWildcard:
+		return fmt.Sprintf("%s LIKE ?", column), WildcardToLike(val), nil
+	default:
+		return "", "", fmt.Errorf("%w: unsupported operator %s", ErrInvalidAttrValue, op)
+	}
+}
+
+// NegationPredicate creates an SQL predicate excluding
+// numValues values (to be passed as query arguments) of a column.
+func NegationPredicate(column string, numValues int) string {
+	placeholders := make([]string, numValues)
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("%s NOT IN (%s)", column, strings.Join(placeholders, ", "))
+}
+
+// InPredicate creates an SQL predicate matching numValues
+// values (to be passed as query arguments) of a column.
+func InPredicate(column string, numValues int) string {
+	placeholders := make([]string, numValues)
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+}
+
+// IsNullValue tells whether an attribute value is stored as NULL
+// in the database. This applies for empty values of typed (non-string)
+// attributes.
+func IsNullValue(value string, attrType laconf.AttrType) bool {
+	return value == "" && attrType.SQLType() != ""
+}
+
+// TypedNegationPredicate is a variant of NegationPredicate respecting
+// the fact that empty values of typed attributes are stored as NULL.
+// The returned values are to be passed as query arguments.
+func TypedNegationPredicate(
+	column string,
+	attrType laconf.AttrType,
+	values []string,
+) (string, []string) {
+	if attrType.SQLType() == "" {
+		return NegationPredicate(column, len(values)), values
+	}
+	nonEmpty := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	if len(nonEmpty) < len(values) {
+		if len(nonEmpty) == 0 {
+			return fmt.Sprintf("%s IS NOT NULL", column), nonEmpty
+		}
+		return fmt.Sprintf(
+			"%s IS NOT NULL AND %s", column, NegationPredicate(column, len(nonEmpty))), nonEmpty
+	}
+	return fmt.Sprintf(
+		"(%s IS NULL OR %s)", column, NegationPredicate(column, len(nonEmpty))), nonEmpty
+}
+
+func typedExpr(expr string, attrType laconf.AttrType) string {
+	switch attrType {
+	case laconf.AttrTypeInt:
+		return fmt.Sprintf("CAST(%s AS SIGNED)", expr)
+	case laconf.AttrTypeDate:
+		return fmt.Sprintf("CAST(%s AS DATE)", expr)
+	case laconf.AttrTypeNumber:
+		return fmt.Sprintf("CAST(REPLACE(%s, ',', '.') AS DECIMAL(65, 10))", expr)
+	}
+	return expr
+}
+
+// RangePredicate creates an SQL predicate for a range of values
+// (from, to - both inclusive; an empty boundary means "unlimited")
+// respecting the attribute type. The returned values are to be passed
+// as query arguments. As numbers (laconf.AttrTypeNumber) are stored
+// as strings, values which are not numbers never match the range.
+func RangePredicate(column string, attrType laconf.AttrType, from, to string) (string, []string) {
+	preds := make([]string, 0, 3)
+	values := make([]string, 0, 2)
+	if from != "" {
+		preds = append(
+			preds,
+			fmt.Sprintf("%s >= %s", typedExpr(column, attrType), typedExpr("?", attrType)),
+		)
+		values = append(values, from)
+	}
+	if to != "" {
+		preds = append(
+			preds,
+			fmt.Sprintf("%s <= %s", typedExpr(column, attrType), typedExpr("?", attrType)),
+		)
+		values = append(values, to)
+	}
+	if attrType == laconf.AttrTypeNumber && len(preds) > 0 {
+		preds = append(preds, fmt.Sprintf("%s REGEXP '%s'", column, attrType.ValuePattern()))
+	}
+	return strings.Join(preds, " AND "), values
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package qbuilder
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildcardToLike(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"Nov*", "Nov%"},
+		{"N?v*k", "N_v%k"},
+		{"100%", "100\\%"},
+		{"doc_id", "doc\\_id"},
+		{"a\\b", "a\\\\b"},
+		{"*_?%\\", "%\\__\\%\\\\"},
+		{"Čapek", "Čapek"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, WildcardToLike(c.input), "input: %s", c.input)
+	}
+}
+
+func TestOperatorPredicateWildcard(t *testing.T) {
+	pred, val, err := OperatorPredicate("t1.doc_author", "wildcard", "Nov*_")
+	assert.NoError(t, err)
+	assert.Equal(t, "t1.doc_author LIKE ?", pred)
+	assert.Equal(t, "Nov%\\_", val)
+}
+
+func TestOperatorPredicateUnsupported(t *testing.T) {
+	_, _, err := OperatorPredicate("t1.doc_author", "foo", "bar")
+	assert.Error(t, err)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, "Nov*\\_\\%\\\\", EscapeLike("Nov*_%\\"))
+	assert.Equal(t, "Čapek", EscapeLike("Čapek"))
+}
+
+func TestTrigrams(t *testing.T) {
+	assert.Equal(t, []string{"čap", "ape", "pek"}, Trigrams("Čapek"))
+	assert.Equal(t, []string{"aaa"}, Trigrams("aaaaa"))
+	assert.Equal(t, []string{}, Trigrams("ab"))
+}
+
+func TestAutocompletePredicatePlain(t *testing.T) {
+	pred, vals := AutocompletePredicate("t1.doc_author", "Cap_", laconf.AutocompleteConf{})
+	assert.Equal(t, "t1.doc_author LIKE ?", pred)
+	assert.Equal(t, []string{"%Cap\\_%"}, vals)
+}
+
+func TestAutocompletePredicateIgnoreDiacritics(t *testing.T) {
+	pred, vals := AutocompletePredicate(
+		"t1.doc_author", "Capek", laconf.AutocompleteConf{IgnoreDiacritics: true})
+	assert.Equal(t, "t1.doc_author COLLATE utf8mb4_general_ci LIKE ?", pred)
+	assert.Equal(t, []string{"%Capek%"}, vals)
+
+	pred, _ = AutocompletePredicate(
+		"t1.doc_author",
+		"Capek",
+		laconf.AutocompleteConf{IgnoreDiacritics: true, Collation: "utf8mb4_0900_ai_ci"},
+	)
+	assert.Equal(t, "t1.doc_author COLLATE utf8mb4_0900_ai_ci LIKE ?", pred)
+}
+
+func TestAutocompletePredicateFuzzy(t *testing.T) {
+	pred, vals := AutocompletePredicate(
+		"t1.doc_author",
+		"Capek",
+		laconf.AutocompleteConf{IgnoreDiacritics: true, Fuzzy: true},
+	)
+	col := "t1.doc_author COLLATE utf8mb4_general_ci"
+	assert.Equal(
+		t,
+		"("+col+" LIKE ? OR (("+col+" LIKE ?) + ("+col+" LIKE ?) + ("+col+" LIKE ?)) >= 2)",
+		pred,
+	)
+	assert.Equal(t, []string{"%Capek%", "%cap%", "%ape%", "%pek%"}, vals)
+}
+
+func TestAutocompletePredicateFuzzyShortText(t *testing.T) {
+	pred, vals := AutocompletePredicate(
+		"t1.doc_author", "Cap", laconf.AutocompleteConf{Fuzzy: true, FuzzyMinSimilarity: 1})
+	assert.Equal(t, "t1.doc_author LIKE ?", pred)
+	assert.Equal(t, []string{"%Cap%"}, vals)
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/utils"
+	"time"
+)
+
+// GetAttrQuality calculates data quality metrics of attributes `attrs`
+// of a corpus. Values listed in `placeholders` are considered
+// an explicit expression of an unknown value.
+func GetAttrQuality(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrs []string,
+	placeholders []string,
+) (*liveattrs.QualityReport, error) {
+	ans := &liveattrs.QualityReport{
+		CorpusID: corpusInfo.Name,
+		Created:  time.Now(),
+		Attrs:    make([]liveattrs.AttrQuality, 0, len(attrs)),
+	}
+	for _, attr := range attrs {
+		aq, err := getSingleAttrQuality(laDB, corpusInfo, attr, placeholders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quality of %s: %w", attr, err)
+		}
+		ans.Attrs = append(ans.Attrs, aq)
+	}
+	ans.SortByIssues()
+	return ans, nil
+}
+
+func getSingleAttrQuality(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attr string,
+	placeholders []string,
+) (liveattrs.AttrQuality, error) {
+	ans := liveattrs.AttrQuality{Attr: attr}
+	col := utils.ImportKey(attr)
+	rows, err := laDB.Query(
+		fmt.Sprintf(
+			"SELECT %s, COUNT(*) FROM `%s_liveattrs_entry` WHERE corpus_id = ? GROUP BY %s",
+			col, corpusInfo.GroupedName(), col,
+		),
+		corpusInfo.Name,
+	)
+	if err != nil {
+		return ans, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value sql.NullString
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return ans, err
+		}
+		ans.AddValue(value.String, count, placeholders)
+	}
+	if err := rows.Err(); err != nil {
+		return ans, err
+	}
+	ans.Finalize()
+	return ans, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// corpusTableNames returns names of all the liveattrs tables
+// of a corpus group (some of them may not exist)
+func corpusTableNames(groupedName string) []string {
+	return []string{
+		fmt.Sprintf("%s_liveattrs_entry", groupedName),
+		ValueMergeUndoTableName(groupedName),
+		SpeakerTableName(groupedName),
+	}
+}
+
+// ExistingCorpusTables returns names of existing liveattrs tables
+// of a corpus group
+func ExistingCorpusTables(laDB *sql.DB, groupedName string) ([]string, error) {
+	ans := make([]string, 0, 3)
+	for _, table := range corpusTableNames(groupedName) {
+		exists, err := TableExists(laDB, table)
+		if err != nil {
+			return []string{}, err
+		}
+		if exists {
+			ans = append(ans, table)
+		}
+	}
+	return ans, nil
+}
+
+// renamedTableName returns a table name with the corpus group
+// prefix replaced
+func renamedTableName(table, groupedName, newGroupedName string) string {
+	return newGroupedName + strings.TrimPrefix(table, groupedName)
+}
+
+// RenameCorpus changes corpus ID in all the liveattrs tables of the
+// corpus group and in auxiliary tables (ingested verticals, attribute usage,
+// value counts snapshots).
+// In case the corpus has its own tables (i.e. it is not a part of a group),
+// the tables are renamed too. Please note that MySQL commits renamed tables
+// implicitly so in case of a failure, the tables may stay renamed.
+func RenameCorpus(laDB *sql.DB, groupedName, corpusID, newCorpusID string) error {
+	tables, err := ExistingCorpusTables(laDB, groupedName)
+	if err != nil {
+		return err
+	}
+	newGroupedName := groupedName
+	if groupedName == corpusID {
+		newGroupedName = newCorpusID
+		renames := make([]string, len(tables))
+		for i, table := range tables {
+			tables[i] = renamedTableName(table, groupedName, newGroupedName)
+			renames[i] = fmt.Sprintf("`%s` TO `%s`", table, tables[i])
+		}
+		if len(renames) > 0 {
+			if _, err := laDB.Exec("RENAME TABLE " + strings.Join(renames, ", ")); err != nil {
+				return fmt.Errorf("failed to rename tables: %w", err)
+			}
+		}
+	}
+	tx, err := laDB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		_, err := tx.Exec(
+			fmt.Sprintf("UPDATE `%s` SET corpus_id = ? WHERE corpus_id = ?", table),
+			newCorpusID, corpusID,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.Exec(
+		"UPDATE ingested_verticals SET corpus_id = ?, grouped_name = ? WHERE corpus_id = ?",
+		newCorpusID, newGroupedName, corpusID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE `usage` SET corpus_id = ? WHERE corpus_id = ?", newCorpusID, corpusID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE value_counts_snapshots SET corpus_id = ? WHERE corpus_id = ?", newCorpusID, corpusID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/response"
+	"masm/v3/liveattrs/request/selshare"
+	"sort"
+)
+
+type shareCounts struct {
+	corpusSize    int
+	selectionSize int
+}
+
+func shareRatio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
+
+// GetSelectionShare calculates, for a selection specified by `qry`,
+// its share of the whole corpus in total and per values of the target
+// attributes (e.g. the selection covers 12% of fiction but 58%
+// of journalism). All the numbers are obtained in a single query.
+func GetSelectionShare(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrTypes laconf.AttrTypes,
+	qry selshare.Payload,
+	emptyValPlaceholder string,
+) (*response.SelectionShare, error) {
+	share := adhoc.SelectionShare{
+		Selection: adhoc.Selection{
+			CorpusInfo:     corpusInfo,
+			AttrMap:        qry.Attrs,
+			AlignedCorpora: qry.Aligned,
+			AttrTypes:      attrTypes,
+		},
+		TargetAttrs: qry.TargetAttrs,
+	}
+	sqlq, args, err := share.Query()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := laDB.Query(sqlq, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]*shareCounts)
+	for _, attr := range qry.TargetAttrs {
+		counts[attr] = make(map[string]*shareCounts)
+	}
+	var total shareCounts
+	var corpusSize, selectionSize sql.NullInt64
+	values := make([]sql.NullString, len(qry.TargetAttrs))
+	dest := make([]any, 0, len(values)+2)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	dest = append(dest, &corpusSize, &selectionSize)
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		total.corpusSize += int(corpusSize.Int64)
+		total.selectionSize += int(selectionSize.Int64)
+		for i, attr := range qry.TargetAttrs {
+			val := values[i].String
+			if val == "" {
+				val = emptyValPlaceholder
+			}
+			item, ok := counts[attr][val]
+			if !ok {
+				item = &shareCounts{}
+				counts[attr][val] = item
+			}
+			item.corpusSize += int(corpusSize.Int64)
+			item.selectionSize += int(selectionSize.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newSelectionShareResponse(total, counts), nil
+}
+
+func newSelectionShareResponse(
+	total shareCounts,
+	counts map[string]map[string]*shareCounts,
+) *response.SelectionShare {
+	ans := &response.SelectionShare{
+		CorpusSize:    total.corpusSize,
+		SelectionSize: total.selectionSize,
+		Share:         shareRatio(total.selectionSize, total.corpusSize),
+		Attrs:         make(map[string][]*response.ValueShare),
+	}
+	for attr, attrCounts := range counts {
+		items := make([]*response.ValueShare, 0, len(attrCounts))
+		for val, cnt := range attrCounts {
+			items = append(items, &response.ValueShare{
+				Value:         val,
+				CorpusSize:    cnt.corpusSize,
+				SelectionSize: cnt.selectionSize,
+				Share:         shareRatio(cnt.selectionSize, cnt.corpusSize),
+			})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].CorpusSize != items[j].CorpusSize {
+				return items[i].CorpusSize > items[j].CorpusSize
+			}
+			return items[i].Value < items[j].Value
+		})
+		ans.Attrs[attr] = items
+	}
+	return ans
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSelectionShareResponse(t *testing.T) {
+	ans := newSelectionShareResponse(
+		shareCounts{corpusSize: 300, selectionSize: 70},
+		map[string]map[string]*shareCounts{
+			"doc.genre": {
+				"fiction":    {corpusSize: 100, selectionSize: 12},
+				"journalism": {corpusSize: 100, selectionSize: 58},
+				"science":    {corpusSize: 100, selectionSize: 0},
+			},
+		},
+	)
+	assert.Equal(t, 300, ans.CorpusSize)
+	assert.Equal(t, 70, ans.SelectionSize)
+	assert.InDelta(t, 0.2333, ans.Share, 0.0001)
+	items := ans.Attrs["doc.genre"]
+	assert.Len(t, items, 3)
+	assert.Equal(t, "fiction", items[0].Value)
+	assert.InDelta(t, 0.12, items[0].Share, 0.0001)
+	assert.Equal(t, "journalism", items[1].Value)
+	assert.InDelta(t, 0.58, items[1].Share, 0.0001)
+	assert.Equal(t, 0.0, items[2].Share)
+}
+
+func TestNewSelectionShareResponseEmptyCorpus(t *testing.T) {
+	ans := newSelectionShareResponse(shareCounts{}, map[string]map[string]*shareCounts{})
+	assert.Equal(t, 0.0, ans.Share)
+	assert.Empty(t, ans.Attrs)
+}
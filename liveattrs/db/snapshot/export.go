@@ -0,0 +1,174 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func createSnapshotTables(tx *sql.Tx, columns []Column, dataCols []string) error {
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE %s (name TEXT PRIMARY KEY, value TEXT)", metaTable),
+		fmt.Sprintf(
+			"CREATE TABLE %s (ordinal INTEGER, name TEXT, column_type TEXT, "+
+				"is_nullable INTEGER, is_primary INTEGER, extra TEXT, comment TEXT)",
+			columnsTable,
+		),
+		fmt.Sprintf(
+			"CREATE TABLE %s (%s)", dataTable, joinQuoted(dataCols, quoteSQLiteIdent)),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	for i, col := range columns {
+		_, err := tx.Exec(
+			fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?, ?, ?)", columnsTable),
+			i, col.Name, col.ColumnType, col.IsNullable, col.IsPrimary, col.Extra, col.Comment,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMeta(tx *sql.Tx, meta *Meta) error {
+	values := [][2]string{
+		{"version", meta.Version},
+		{"corpusId", meta.CorpusID},
+		{"groupedName", meta.GroupedName},
+		{"created", meta.Created.Format(time.RFC3339)},
+		{"numRows", strconv.Itoa(meta.NumRows)},
+	}
+	for _, item := range values {
+		_, err := tx.Exec(
+			fmt.Sprintf("INSERT INTO %s (name, value) VALUES (?, ?)", metaTable),
+			item[0], item[1],
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes liveattrs data of a corpus stored in MySQL to a new SQLite
+// file `path`. For corpora sharing a single table (aligned corpora),
+// only rows of the corpus are exported.
+func Export(laDB *sql.DB, corpusID, groupedName, path string) (*Meta, error) {
+	columns, err := loadColumns(laDB, tableName(groupedName))
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no liveattrs table found for %s", groupedName)
+	}
+	dataCols := dataColumns(columns)
+	colsByName := make(map[string]Column)
+	for _, col := range columns {
+		colsByName[col.Name] = col
+	}
+
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer sdb.Close()
+	tx, err := sdb.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if err := createSnapshotTables(tx, columns, dataCols); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	rows, err := laDB.Query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE corpus_id = ?",
+			joinQuoted(dataCols, quoteIdent), quoteIdent(tableName(groupedName)),
+		),
+		corpusID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	defer rows.Close()
+	meta := &Meta{
+		Version:     Version,
+		CorpusID:    corpusID,
+		GroupedName: groupedName,
+		Created:     time.Now(),
+	}
+	batchSize := insertBatchSize(len(dataCols))
+	batch := make([]any, 0, batchSize*len(dataCols))
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		numRows := len(batch) / len(dataCols)
+		_, err := tx.Exec(
+			fmt.Sprintf(
+				"INSERT INTO %s VALUES %s", dataTable, placeholders(numRows, len(dataCols))),
+			batch...,
+		)
+		batch = batch[:0]
+		return err
+	}
+	values := make([]any, len(dataCols))
+	dest := make([]any, len(dataCols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		for i, v := range values {
+			batch = append(batch, exportValue(v, colsByName[dataCols[i]]))
+		}
+		meta.NumRows++
+		if len(batch) >= batchSize*len(dataCols) {
+			if err := flush(); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := writeMeta(tx, meta); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return meta, tx.Commit()
+}
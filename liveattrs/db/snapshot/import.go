@@ -0,0 +1,223 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func readMeta(sdb *sql.DB) (*Meta, error) {
+	rows, err := sdb.Query(fmt.Sprintf("SELECT name, value FROM %s", metaTable))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorIncompatibleSnapshot, err)
+	}
+	defer rows.Close()
+	ans := &Meta{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "version":
+			ans.Version = value
+		case "corpusId":
+			ans.CorpusID = value
+		case "groupedName":
+			ans.GroupedName = value
+		case "created":
+			ans.Created, err = time.Parse(time.RFC3339, value)
+		case "numRows":
+			ans.NumRows, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s", ErrorIncompatibleSnapshot, name)
+		}
+	}
+	return ans, rows.Err()
+}
+
+// readColumns reads column definitions stored in a snapshot.
+// Columns with unsupported properties are rejected.
+func readColumns(sdb *sql.DB) ([]Column, error) {
+	rows, err := sdb.Query(
+		fmt.Sprintf(
+			"SELECT name, column_type, is_nullable, is_primary, extra, comment "+
+				"FROM %s ORDER BY ordinal",
+			columnsTable,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorIncompatibleSnapshot, err)
+	}
+	defer rows.Close()
+	ans := make([]Column, 0, 50)
+	for rows.Next() {
+		var col Column
+		if err := rows.Scan(
+			&col.Name, &col.ColumnType, &col.IsNullable, &col.IsPrimary,
+			&col.Extra, &col.Comment); err != nil {
+			return nil, err
+		}
+		if err := validateColumn(col); err != nil {
+			return nil, err
+		}
+		ans = append(ans, col)
+	}
+	return ans, rows.Err()
+}
+
+// sameColumnNames tests whether both column lists contain the same
+// columns (regardless of their order)
+func sameColumnNames(cols1, cols2 []Column) bool {
+	if len(cols1) != len(cols2) {
+		return false
+	}
+	names1 := make([]string, len(cols1))
+	for i, col := range cols1 {
+		names1[i] = col.Name
+	}
+	names2 := make([]string, len(cols2))
+	for i, col := range cols2 {
+		names2[i] = col.Name
+	}
+	sort.Strings(names1)
+	sort.Strings(names2)
+	for i := range names1 {
+		if names1[i] != names2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Import loads liveattrs data of a corpus from an SQLite snapshot file
+// `path` (see Export) to MySQL. In case the target table does not exist,
+// it is created (without secondary indexes). Otherwise, the table must
+// have the same columns as the snapshot. Any existing rows of the corpus
+// are replaced.
+func Import(laDB *sql.DB, corpusID, groupedName, path string) (*Meta, error) {
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer sdb.Close()
+	meta, err := readMeta(sdb)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Version != Version {
+		return nil, fmt.Errorf(
+			"%w: unsupported version %s", ErrorIncompatibleSnapshot, meta.Version)
+	}
+	if meta.CorpusID != corpusID {
+		return nil, fmt.Errorf(
+			"%w: snapshot of a different corpus (%s)", ErrorIncompatibleSnapshot, meta.CorpusID)
+	}
+	columns, err := readColumns(sdb)
+	if err != nil {
+		return nil, err
+	}
+	table := tableName(groupedName)
+	currColumns, err := loadColumns(laDB, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(currColumns) == 0 {
+		if _, err := laDB.Exec(createTableSQL(table, columns)); err != nil {
+			return nil, err
+		}
+
+	} else if !sameColumnNames(columns, currColumns) {
+		return nil, fmt.Errorf(
+			"%w: columns of the table %s do not match the snapshot",
+			ErrorIncompatibleSnapshot, table,
+		)
+	}
+
+	dataCols := dataColumns(columns)
+	rows, err := sdb.Query(
+		fmt.Sprintf("SELECT %s FROM %s", joinQuoted(dataCols, quoteSQLiteIdent), dataTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tx, err := laDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		fmt.Sprintf("DELETE FROM %s WHERE corpus_id = ?", quoteIdent(table)), corpusID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	batchSize := insertBatchSize(len(dataCols))
+	batch := make([]any, 0, batchSize*len(dataCols))
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		numRows := len(batch) / len(dataCols)
+		_, err := tx.Exec(
+			fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES %s",
+				quoteIdent(table), joinQuoted(dataCols, quoteIdent),
+				placeholders(numRows, len(dataCols)),
+			),
+			batch...,
+		)
+		batch = batch[:0]
+		return err
+	}
+	values := make([]any, len(dataCols))
+	dest := make([]any, len(dataCols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	numRows := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		batch = append(batch, values...)
+		numRows++
+		if len(batch) >= batchSize*len(dataCols) {
+			if err := flush(); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	meta.NumRows = numRows
+	return meta, tx.Commit()
+}
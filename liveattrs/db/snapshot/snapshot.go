@@ -0,0 +1,222 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package snapshot provides export and import of liveattrs data
+// of a single corpus in the form of a portable SQLite file.
+// This allows moving data between masm instances without
+// re-parsing corpus vertical files.
+package snapshot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// Version is increased on incompatible changes of snapshot structure
+	Version = "1"
+
+	metaTable    = "snapshot_meta"
+	columnsTable = "snapshot_columns"
+	dataTable    = "liveattrs_entry"
+
+	// maxInsertPlaceholders limits number of values in a single
+	// multi-row INSERT statement
+	maxInsertPlaceholders = 10000
+)
+
+var (
+	ErrorIncompatibleSnapshot = errors.New("incompatible snapshot")
+
+	// allowedColumnType and allowedColumnExtra specify column properties
+	// accepted from a snapshot file. As the properties are used
+	// in a CREATE TABLE statement, anything else is rejected.
+	allowedColumnType = regexp.MustCompile(
+		`(?i)^((var)?char\(\d+\)|(tiny|small|medium|big)?int(\(\d+\))?( unsigned)?|` +
+			`(tiny|medium|long)?text|date|datetime|float|double|decimal\(\d+,\d+\))$`)
+	allowedColumnExtra = regexp.MustCompile(`(?i)^(auto_increment)?$`)
+)
+
+// Column describes a column of a liveattrs table as stored in MySQL
+type Column struct {
+	Name       string
+	ColumnType string
+	IsNullable bool
+	IsPrimary  bool
+	Extra      string
+	Comment    string
+}
+
+// Meta contains basic information about a snapshot
+type Meta struct {
+	Version     string    `json:"version"`
+	CorpusID    string    `json:"corpusId"`
+	GroupedName string    `json:"groupedName"`
+	Created     time.Time `json:"created"`
+	NumRows     int       `json:"numRows"`
+}
+
+func tableName(groupedName string) string {
+	return fmt.Sprintf("%s_liveattrs_entry", groupedName)
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteSQLiteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// dataColumns returns names of columns to be copied. Auto-increment
+// columns are skipped as their values are not portable between tables
+// shared by multiple (aligned) corpora.
+func dataColumns(columns []Column) []string {
+	ans := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !strings.Contains(strings.ToLower(col.Extra), "auto_increment") {
+			ans = append(ans, col.Name)
+		}
+	}
+	return ans
+}
+
+func joinQuoted(names []string, quoteFn func(string) string) string {
+	ans := make([]string, len(names))
+	for i, name := range names {
+		ans[i] = quoteFn(name)
+	}
+	return strings.Join(ans, ", ")
+}
+
+// placeholders creates a list of `numRows` tuples, each
+// with `numCols` placeholders
+func placeholders(numRows, numCols int) string {
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", numCols), ", ") + ")"
+	ans := make([]string, numRows)
+	for i := range ans {
+		ans[i] = tuple
+	}
+	return strings.Join(ans, ", ")
+}
+
+// insertBatchSize returns max. number of rows inserted by a single statement
+func insertBatchSize(numCols int) int {
+	if numCols == 0 {
+		return 1
+	}
+	return max(1, maxInsertPlaceholders/numCols)
+}
+
+func loadColumns(laDB *sql.DB, table string) ([]Column, error) {
+	rows, err := laDB.Query(
+		"SELECT column_name, column_type, is_nullable, column_key, extra, column_comment "+
+			"FROM information_schema.columns "+
+			"WHERE table_schema = DATABASE() AND table_name = ? "+
+			"ORDER BY ordinal_position",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]Column, 0, 50)
+	for rows.Next() {
+		var col Column
+		var nullable, key string
+		if err := rows.Scan(
+			&col.Name, &col.ColumnType, &nullable, &key, &col.Extra, &col.Comment); err != nil {
+			return nil, err
+		}
+		col.IsNullable = nullable == "YES"
+		col.IsPrimary = key == "PRI"
+		ans = append(ans, col)
+	}
+	return ans, rows.Err()
+}
+
+// validateColumn tests whether a column obtained from a snapshot
+// can be safely used to create a table
+func validateColumn(col Column) error {
+	if !allowedColumnType.MatchString(col.ColumnType) {
+		return fmt.Errorf(
+			"%w: unsupported type %q of column %s", ErrorIncompatibleSnapshot, col.ColumnType, col.Name)
+	}
+	if !allowedColumnExtra.MatchString(col.Extra) {
+		return fmt.Errorf(
+			"%w: unsupported extra %q of column %s", ErrorIncompatibleSnapshot, col.Extra, col.Name)
+	}
+	return nil
+}
+
+// quoteComment quotes a column comment as an SQL string literal
+// (backslashes are escaped too as MySQL treats them as escape
+// characters by default)
+func quoteComment(comment string) string {
+	comment = strings.ReplaceAll(comment, "\\", "\\\\")
+	return "'" + strings.ReplaceAll(comment, "'", "''") + "'"
+}
+
+// createTableSQL generates a MySQL CREATE TABLE statement based
+// on columns stored in a snapshot. Please note that (non-primary)
+// indexes are not part of the snapshot. Columns are expected
+// to be validated (see validateColumn).
+func createTableSQL(table string, columns []Column) string {
+	defs := make([]string, 0, len(columns)+1)
+	pk := make([]string, 0, 1)
+	for _, col := range columns {
+		def := quoteIdent(col.Name) + " " + col.ColumnType
+		if !col.IsNullable {
+			def += " NOT NULL"
+		}
+		if col.Extra != "" {
+			def += " " + col.Extra
+		}
+		if col.Comment != "" {
+			def += " COMMENT " + quoteComment(col.Comment)
+		}
+		defs = append(defs, def)
+		if col.IsPrimary {
+			pk = append(pk, quoteIdent(col.Name))
+		}
+	}
+	if len(pk) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pk, ", ")))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(defs, ", "))
+}
+
+// exportValue converts a value obtained from MySQL to a portable form
+func exportValue(v any, col Column) any {
+	switch tv := v.(type) {
+	case []byte:
+		return string(tv)
+	case time.Time:
+		if col.ColumnType == "date" {
+			return tv.Format("2006-01-02")
+		}
+		return tv.Format("2006-01-02 15:04:05")
+	}
+	return v
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testingColumns() []Column {
+	return []Column{
+		{Name: "id", ColumnType: "int(11)", IsPrimary: true, Extra: "auto_increment"},
+		{Name: "corpus_id", ColumnType: "varchar(255)"},
+		{Name: "doc_title", ColumnType: "text", IsNullable: true},
+		{Name: "doc_pubdate", ColumnType: "date", IsNullable: true, Comment: "masm-orig-type:text"},
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, "(?, ?, ?), (?, ?, ?)", placeholders(2, 3))
+}
+
+func TestInsertBatchSize(t *testing.T) {
+	assert.Equal(t, 1000, insertBatchSize(10))
+	assert.Equal(t, 1, insertBatchSize(20000))
+}
+
+func TestDataColumnsSkipsAutoIncrement(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"corpus_id", "doc_title", "doc_pubdate"},
+		dataColumns(testingColumns()),
+	)
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"CREATE TABLE `syn2020_liveattrs_entry` (`id` int(11) NOT NULL auto_increment, "+
+			"`corpus_id` varchar(255) NOT NULL, `doc_title` text, "+
+			"`doc_pubdate` date COMMENT 'masm-orig-type:text', PRIMARY KEY (`id`))",
+		createTableSQL("syn2020_liveattrs_entry", testingColumns()),
+	)
+}
+
+func TestCreateTableSQLEscapesComment(t *testing.T) {
+	cols := []Column{{Name: "doc_title", ColumnType: "text", IsNullable: true, Comment: "it's\\"}}
+	assert.Equal(
+		t,
+		"CREATE TABLE `t` (`doc_title` text COMMENT 'it''s\\\\')",
+		createTableSQL("t", cols),
+	)
+}
+
+func TestValidateColumn(t *testing.T) {
+	for _, col := range testingColumns() {
+		assert.NoError(t, validateColumn(col))
+	}
+	assert.NoError(t, validateColumn(Column{Name: "a", ColumnType: "INT(10) unsigned"}))
+	assert.NoError(t, validateColumn(Column{Name: "a", ColumnType: "decimal(10,2)"}))
+	assert.ErrorIs(
+		t,
+		validateColumn(Column{Name: "a", ColumnType: "int, b int) ENGINE=MEMORY; DROP TABLE x; --"}),
+		ErrorIncompatibleSnapshot,
+	)
+	assert.ErrorIs(
+		t,
+		validateColumn(Column{Name: "a", ColumnType: "int", Extra: "DEFAULT (SLEEP(10))"}),
+		ErrorIncompatibleSnapshot,
+	)
+}
+
+func TestSameColumnNames(t *testing.T) {
+	cols := testingColumns()
+	reversed := []Column{cols[3], cols[2], cols[1], cols[0]}
+	assert.True(t, sameColumnNames(cols, reversed))
+	assert.False(t, sameColumnNames(cols, cols[1:]))
+}
+
+func TestExportValue(t *testing.T) {
+	cols := testingColumns()
+	dt := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+	assert.Equal(t, "foo", exportValue([]byte("foo"), cols[2]))
+	assert.Equal(t, "2020-05-17", exportValue(dt, cols[3]))
+	assert.Equal(t, "2020-05-17 10:30:00", exportValue(dt, cols[2]))
+	assert.Nil(t, exportValue(nil, cols[2]))
+}
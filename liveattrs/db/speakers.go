@@ -0,0 +1,146 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"strings"
+)
+
+// SpeakerTableName returns a name of a table with speakers
+// of spoken corpora (see laconf.SpeakerConf)
+func SpeakerTableName(groupedName string) string {
+	return fmt.Sprintf("%s_liveattrs_speaker", groupedName)
+}
+
+func speakerColumns(conf *laconf.SpeakerConf) []string {
+	ans := make([]string, len(conf.Attrs))
+	for i, attr := range conf.QualifiedAttrs() {
+		ans[i] = utils.ImportKey(attr)
+	}
+	return ans
+}
+
+func createSpeakerTableSQL(tableName string, conf *laconf.SpeakerConf) string {
+	cols := make([]string, 0, len(conf.Attrs)+6)
+	cols = append(
+		cols,
+		"id INTEGER PRIMARY KEY AUTO_INCREMENT",
+		"corpus_id VARCHAR(255) NOT NULL",
+		"doc_key VARCHAR(255) NOT NULL",
+		"speaker_id VARCHAR(255) NOT NULL",
+		"poscount INTEGER NOT NULL",
+	)
+	for _, col := range speakerColumns(conf) {
+		cols = append(cols, fmt.Sprintf("`%s` %s", col, derivedColumnType))
+	}
+	cols = append(cols, "INDEX (corpus_id, doc_key)")
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (%s)", tableName, strings.Join(cols, ", "))
+}
+
+func insertSpeakerSQL(tableName string, conf *laconf.SpeakerConf) string {
+	cols := append([]string{"corpus_id", "doc_key", "speaker_id", "poscount"}, speakerColumns(conf)...)
+	for i, col := range cols {
+		cols[i] = "`" + col + "`"
+	}
+	return fmt.Sprintf(
+		"INSERT INTO `%s` (%s) VALUES (%s)",
+		tableName,
+		strings.Join(cols, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "),
+	)
+}
+
+// StoreSpeakers writes extracted speakers of a corpus to the speaker
+// table (the table is created if needed, missing attribute columns are
+// added). In the append mode, only speakers of the documents in `entries`
+// are replaced. Otherwise, all the speakers of the corpus are replaced.
+func StoreSpeakers(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	conf *laconf.SpeakerConf,
+	entries []*liveattrs.SpeakerEntry,
+	appendMode bool,
+) error {
+	tableName := SpeakerTableName(groupedName)
+	if _, err := laDB.Exec(createSpeakerTableSQL(tableName, conf)); err != nil {
+		return fmt.Errorf("failed to store speakers: %w", err)
+	}
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to store speakers: %w", err)
+	}
+	for _, col := range speakerColumns(conf) {
+		if _, ok := columns[col]; !ok {
+			if _, err := laDB.Exec(addColumnSQL(tableName, col)); err != nil {
+				return fmt.Errorf("failed to store speakers: %w", err)
+			}
+		}
+	}
+	tx, err := laDB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store speakers: %w", err)
+	}
+	if appendMode {
+		deleted := make(map[string]bool)
+		for _, entry := range entries {
+			if deleted[entry.DocID] {
+				continue
+			}
+			_, err := tx.Exec(
+				fmt.Sprintf("DELETE FROM `%s` WHERE corpus_id = ? AND doc_key = ?", tableName),
+				corpusID, entry.DocID,
+			)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to store speakers: %w", err)
+			}
+			deleted[entry.DocID] = true
+		}
+
+	} else {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE corpus_id = ?", tableName), corpusID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to store speakers: %w", err)
+		}
+	}
+	stmt, err := tx.Prepare(insertSpeakerSQL(tableName, conf))
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to store speakers: %w", err)
+	}
+	defer stmt.Close()
+	for _, entry := range entries {
+		args := make([]any, 0, len(conf.Attrs)+4)
+		args = append(args, corpusID, entry.DocID, entry.SpeakerID, entry.Poscount)
+		for _, attr := range conf.Attrs {
+			args = append(args, entry.Attrs[attr])
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to store speakers: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSpeakerTableSQL(t *testing.T) {
+	conf := &laconf.SpeakerConf{Struct: "sp", IDAttr: "id", Attrs: []string{"sex", "age"}, DocIDAttr: "doc.id"}
+	assert.Equal(
+		t,
+		"CREATE TABLE IF NOT EXISTS `ortofon_v1_liveattrs_speaker` (id INTEGER PRIMARY KEY AUTO_INCREMENT, "+
+			"corpus_id VARCHAR(255) NOT NULL, doc_key VARCHAR(255) NOT NULL, speaker_id VARCHAR(255) NOT NULL, "+
+			"poscount INTEGER NOT NULL, `sp_sex` VARCHAR(255), `sp_age` VARCHAR(255), INDEX (corpus_id, doc_key))",
+		createSpeakerTableSQL(SpeakerTableName("ortofon_v1"), conf),
+	)
+}
+
+func TestInsertSpeakerSQL(t *testing.T) {
+	conf := &laconf.SpeakerConf{Struct: "sp", IDAttr: "id", Attrs: []string{"sex", "age"}, DocIDAttr: "doc.id"}
+	assert.Equal(
+		t,
+		"INSERT INTO `ortofon_v1_liveattrs_speaker` (`corpus_id`, `doc_key`, `speaker_id`, `poscount`, "+
+			"`sp_sex`, `sp_age`) VALUES (?, ?, ?, ?, ?, ?)",
+		insertSpeakerSQL(SpeakerTableName("ortofon_v1"), conf),
+	)
+}
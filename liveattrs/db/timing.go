@@ -0,0 +1,131 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs/db/qbuilder/adhoc"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/utils"
+)
+
+// durationColumnType is an SQL type of the column
+// storing segment durations (see laconf.TimingConf)
+const durationColumnType = "DOUBLE"
+
+func numericOffsetExpr(col string) string {
+	return fmt.Sprintf("CAST(REPLACE(`%s`, ',', '.') AS DECIMAL(65, 10))", col)
+}
+
+// setDurationsSQL creates a query calculating segment durations
+// in seconds. Segments with missing or invalid offsets (including
+// the ones ending before they start) get NULL.
+func setDurationsSQL(tableName, durationCol, startCol, endCol string, unitsPerSecond int) string {
+	numPattern := laconf.AttrTypeNumber.ValuePattern()
+	return fmt.Sprintf(
+		"UPDATE `%s` SET `%s` = CASE WHEN `%s` REGEXP '%s' AND `%s` REGEXP '%s' AND %s >= %s "+
+			"THEN (%s - %s) / %d END WHERE corpus_id = ?",
+		tableName, durationCol,
+		startCol, numPattern, endCol, numPattern,
+		numericOffsetExpr(endCol), numericOffsetExpr(startCol),
+		numericOffsetExpr(endCol), numericOffsetExpr(startCol), unitsPerSecond,
+	)
+}
+
+// StoreSegmentDurations calculates durations of segments (atom structures)
+// of the corpus `corpusID` based on their start and end offsets. In case
+// the table has no column for the duration attribute, the column is created.
+// The function returns the number of segments without a valid duration.
+func StoreSegmentDurations(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	conf *laconf.TimingConf,
+) (int, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	durationAttr := conf.GetDurationAttr()
+	columns, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store segment durations: %w", err)
+	}
+	startCol := utils.ImportKey(conf.StartAttr)
+	endCol := utils.ImportKey(conf.EndAttr)
+	for _, col := range []string{startCol, endCol} {
+		if _, ok := columns[col]; !ok {
+			return 0, fmt.Errorf("failed to store segment durations: missing column %s", col)
+		}
+	}
+	durationCol := utils.ImportKey(durationAttr)
+	if _, ok := columns[durationCol]; !ok {
+		_, err := laDB.Exec(fmt.Sprintf(
+			"ALTER TABLE `%s` ADD COLUMN `%s` %s", tableName, durationCol, durationColumnType))
+		if err != nil {
+			return 0, fmt.Errorf("failed to store segment durations: %w", err)
+		}
+	}
+	_, err = laDB.Exec(
+		setDurationsSQL(tableName, durationCol, startCol, endCol, conf.UnitsPerSecond()),
+		corpusID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store segment durations: %w", err)
+	}
+	var numInvalid int
+	row := laDB.QueryRow(
+		fmt.Sprintf("SELECT COUNT(*) FROM `%s` WHERE corpus_id = ? AND `%s` IS NULL", tableName, durationCol),
+		corpusID,
+	)
+	if err := row.Scan(&numInvalid); err != nil {
+		return 0, fmt.Errorf("failed to store segment durations: %w", err)
+	}
+	return numInvalid, nil
+}
+
+// GetSubcDuration calculates a total duration (in seconds) of segments
+// of an ad-hoc subcorpus (see laconf.TimingConf). The first item of
+// `corpora` is the main corpus, others are aligned ones.
+func GetSubcDuration(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	corpora []string,
+	attrMap query.Attrs,
+	attrTypes laconf.AttrTypes,
+	durationAttr string,
+) (float64, error) {
+	durationCalc := adhoc.SubcDuration{
+		Selection: adhoc.Selection{
+			CorpusInfo:     corpusInfo,
+			AttrMap:        attrMap,
+			AlignedCorpora: corpora[1:],
+			AttrTypes:      attrTypes,
+		},
+		DurationAttr: durationAttr,
+	}
+	sqlq, args, err := durationCalc.Query()
+	if err != nil {
+		return 0, err
+	}
+	var ans sql.NullFloat64
+	if err := laDB.QueryRow(sqlq, args...).Scan(&ans); err != nil {
+		return 0, err
+	}
+	return ans.Float64, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDurationsSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"UPDATE `oral_liveattrs_entry` SET `seg_duration` = CASE WHEN `seg_start` REGEXP '^-?[0-9]+([.,][0-9]+)?$' "+
+			"AND `seg_end` REGEXP '^-?[0-9]+([.,][0-9]+)?$' "+
+			"AND CAST(REPLACE(`seg_end`, ',', '.') AS DECIMAL(65, 10)) >= CAST(REPLACE(`seg_start`, ',', '.') AS DECIMAL(65, 10)) "+
+			"THEN (CAST(REPLACE(`seg_end`, ',', '.') AS DECIMAL(65, 10)) - CAST(REPLACE(`seg_start`, ',', '.') AS DECIMAL(65, 10))) / 1000 END "+
+			"WHERE corpus_id = ?",
+		setDurationsSQL("oral_liveattrs_entry", "seg_duration", "seg_start", "seg_end", 1000),
+	)
+}
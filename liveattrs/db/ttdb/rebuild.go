@@ -0,0 +1,249 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package ttdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxInsertPlaceholders limits number of values in a single
+// multi-row INSERT statement
+const maxInsertPlaceholders = 10000
+
+// mysqlColumn is a column of a liveattrs table stored in MySQL
+type mysqlColumn struct {
+	name     string
+	dataType string
+	extra    string
+}
+
+// sqliteType returns a type used by vert-tagextract for the column
+func (c mysqlColumn) sqliteType() string {
+	if strings.Contains(c.dataType, "int") {
+		return "INTEGER"
+	}
+	return "TEXT"
+}
+
+func loadMySQLColumns(laDB *sql.DB, table string) ([]mysqlColumn, error) {
+	rows, err := laDB.Query(
+		"SELECT column_name, data_type, extra "+
+			"FROM information_schema.columns "+
+			"WHERE table_schema = DATABASE() AND table_name = ? "+
+			"ORDER BY ordinal_position",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]mysqlColumn, 0, 50)
+	for rows.Next() {
+		var col mysqlColumn
+		if err := rows.Scan(&col.name, &col.dataType, &col.extra); err != nil {
+			return nil, err
+		}
+		// the auto-increment primary key is generated by SQLite
+		if strings.Contains(strings.ToLower(col.extra), "auto_increment") {
+			continue
+		}
+		col.dataType = strings.ToLower(col.dataType)
+		ans = append(ans, col)
+	}
+	return ans, rows.Err()
+}
+
+// schemaStatements creates statements creating tables, indexes
+// and views the same way vert-tagextract does
+func schemaStatements(columns []mysqlColumn, schema Schema) []string {
+	colDefs := make([]string, len(columns))
+	hasCol := make(map[string]bool)
+	for i, col := range columns {
+		colDefs[i] = quoteSQLiteIdent(col.name) + " " + col.sqliteType()
+		hasCol[col.name] = true
+	}
+	ans := []string{
+		fmt.Sprintf("CREATE TABLE %s (key TEXT PRIMARY KEY, value TEXT)", cacheTable),
+		fmt.Sprintf(
+			"CREATE TABLE %s (id INTEGER PRIMARY KEY AUTOINCREMENT, %s)",
+			dataTable, strings.Join(colDefs, ", ")),
+	}
+	if hasCol["item_id"] && hasCol["corpus_id"] {
+		ans = append(
+			ans,
+			fmt.Sprintf("CREATE UNIQUE INDEX item_id_corpus_id_idx ON %s(item_id, corpus_id)", dataTable),
+		)
+	}
+	for _, col := range schema.IndexedCols {
+		if hasCol[col] {
+			ans = append(
+				ans,
+				fmt.Sprintf(
+					"CREATE INDEX %s ON %s(%s)",
+					quoteSQLiteIdent(col+"_idx"), dataTable, quoteSQLiteIdent(col)),
+			)
+		}
+	}
+	if schema.HasBibView() {
+		viewCols := make([]string, len(schema.BibViewCols))
+		for i, col := range schema.BibViewCols {
+			viewCols[i] = quoteSQLiteIdent(col)
+			if col == schema.BibViewIDAttr {
+				viewCols[i] += " AS id"
+			}
+		}
+		ans = append(
+			ans,
+			fmt.Sprintf(
+				"CREATE VIEW %s AS SELECT %s FROM %s",
+				bibView, strings.Join(viewCols, ", "), dataTable),
+		)
+	}
+	return ans
+}
+
+func copyRows(laDB *sql.DB, tx *sql.Tx, table, corpusID string, columns []mysqlColumn) (int, error) {
+	names := make([]string, len(columns))
+	sqliteNames := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdent(col.name)
+		sqliteNames[i] = quoteSQLiteIdent(col.name)
+	}
+	rows, err := laDB.Query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE corpus_id = ?", strings.Join(names, ", "), quoteIdent(table)),
+		corpusID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	batchSize := max(1, maxInsertPlaceholders/len(columns))
+	batch := make([]any, 0, batchSize*len(columns))
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		numRows := len(batch) / len(columns)
+		_, err := tx.Exec(
+			fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES %s",
+				dataTable,
+				strings.Join(sqliteNames, ", "),
+				strings.TrimSuffix(strings.Repeat(tuple+", ", numRows), ", "),
+			),
+			batch...,
+		)
+		batch = batch[:0]
+		return err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var numRows int
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return numRows, err
+		}
+		for _, v := range values {
+			if bv, ok := v.([]byte); ok {
+				v = string(bv)
+			}
+			batch = append(batch, v)
+		}
+		numRows++
+		if len(batch) >= batchSize*len(columns) {
+			if err := flush(); err != nil {
+				return numRows, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return numRows, err
+	}
+	return numRows, flush()
+}
+
+func writeDatabase(laDB *sql.DB, path, table, corpusID string, columns []mysqlColumn, schema Schema) error {
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+	tx, err := sdb.Begin()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range schemaStatements(columns, schema) {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	if _, err := copyRows(laDB, tx, table, corpusID, columns); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to copy data: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Rebuild replaces a text types database of a corpus with a new one
+// created out of the corpus data stored in the MySQL liveattrs table
+// of the `groupedName` (i.e. the corpus or its parallel corpus group).
+// The new database is verified before it replaces the original one
+// so in case of a failure, the original database is kept untouched.
+func Rebuild(laDB *sql.DB, corpusID, groupedName, path string, schema Schema) (*Report, error) {
+	table := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	columns, err := loadMySQLColumns(laDB, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no liveattrs table found for %s", groupedName)
+	}
+	tmpPath := path + ".rebuild"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := writeDatabase(laDB, tmpPath, table, corpusID, columns, schema); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	report, err := Check(tmpPath, schema)
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if !report.IsValid() {
+		os.Remove(tmpPath)
+		return report, fmt.Errorf("rebuilt database is not valid")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	report.Path = path
+	return report, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package ttdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// Rename changes corpus ID of all the items stored in a text types
+// database and moves the database to newPath. The cache table is
+// cleared as it may contain results referring to the original corpus.
+// In case of a failure, the original database is kept in place.
+func Rename(path, newPath, corpusID, newCorpusID string) error {
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+	sdb, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=rw", path))
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+	tx, err := sdb.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		fmt.Sprintf("UPDATE %s SET corpus_id = ? WHERE corpus_id = ?", dataTable),
+		newCorpusID, corpusID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", cacheTable)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := sdb.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path, newPath)
+}
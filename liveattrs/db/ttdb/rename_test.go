@@ -0,0 +1,53 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package ttdb
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRename(t *testing.T) {
+	schema := testingSchema()
+	path := createTestingDb(t, testingColumns(), schema)
+	newPath := filepath.Join(filepath.Dir(path), "syn2020v2.db")
+	assert.NoError(t, Rename(path, newPath, "syn2020", "syn2020v2"))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	sdb, err := sql.Open("sqlite3", newPath)
+	assert.NoError(t, err)
+	defer sdb.Close()
+	var corpusID string
+	assert.NoError(t, sdb.QueryRow("SELECT corpus_id FROM liveattrs_entry").Scan(&corpusID))
+	assert.Equal(t, "syn2020v2", corpusID)
+}
+
+func TestRenameExistingTarget(t *testing.T) {
+	path := createTestingDb(t, testingColumns(), testingSchema())
+	newPath := filepath.Join(filepath.Dir(path), "syn2020v2.db")
+	assert.NoError(t, os.WriteFile(newPath, []byte{}, 0644))
+	assert.Error(t, Rename(path, newPath, "syn2020", "syn2020v2"))
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
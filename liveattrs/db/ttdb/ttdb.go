@@ -0,0 +1,241 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package ttdb provides verification and repair of SQLite text types
+// databases (i.e. liveattrs databases created by vert-tagextract and
+// installed for KonText in the form of one file per corpus).
+package ttdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	dataTable  = "liveattrs_entry"
+	cacheTable = "cache"
+	bibView    = "bibliography"
+
+	// maxIntegrityErrors is a max. number of reported
+	// integrity_check messages
+	maxIntegrityErrors = 20
+)
+
+// Schema describes the structure of a text types database
+// as created by vert-tagextract
+type Schema struct {
+
+	// Columns are structural attribute columns (e.g. doc_title)
+	Columns []string
+
+	SelfJoin bool
+
+	BibViewIDAttr string
+
+	BibViewCols []string
+
+	IndexedCols []string
+}
+
+// ExpectedColumns returns all the columns of the data table
+// (except for the primary key)
+func (s Schema) ExpectedColumns() []string {
+	ans := make([]string, 0, len(s.Columns)+4)
+	ans = append(ans, s.Columns...)
+	ans = append(ans, "poscount", "wordcount", "corpus_id")
+	if s.SelfJoin {
+		ans = append(ans, "item_id")
+	}
+	return ans
+}
+
+// HasBibView tests whether the bibliography view is expected
+func (s Schema) HasBibView() bool {
+	return s.BibViewIDAttr != "" && len(s.BibViewCols) > 0
+}
+
+// SchemaFromConf creates a schema of a database created
+// by vert-tagextract with the `conf`
+func SchemaFromConf(conf *vteCnf.VTEConf) Schema {
+	ans := Schema{
+		Columns:       make([]string, 0, 50),
+		SelfJoin:      conf.SelfJoin.IsConfigured(),
+		BibViewIDAttr: conf.BibView.IDAttr,
+		BibViewCols:   conf.BibView.Cols,
+		IndexedCols:   conf.IndexedCols,
+	}
+	for strct, attrs := range conf.Structures {
+		for _, attr := range attrs {
+			ans.Columns = append(ans.Columns, fmt.Sprintf("%s_%s", strct, attr))
+		}
+	}
+	sort.Strings(ans.Columns)
+	return ans
+}
+
+// Report is a result of a text types database verification
+type Report struct {
+	Path string `json:"path"`
+
+	// IntegrityErrors contains messages of `PRAGMA integrity_check`
+	// (or an error preventing the check from being run at all)
+	IntegrityErrors []string `json:"integrityErrors"`
+
+	MissingTables []string `json:"missingTables"`
+
+	MissingColumns []string `json:"missingColumns"`
+
+	NumRows int `json:"numRows"`
+}
+
+// IsValid tests whether the database passed all the checks
+func (r *Report) IsValid() bool {
+	return len(r.IntegrityErrors) == 0 && len(r.MissingTables) == 0 && len(r.MissingColumns) == 0
+}
+
+func newReport(path string) *Report {
+	return &Report{
+		Path:            path,
+		IntegrityErrors: []string{},
+		MissingTables:   []string{},
+		MissingColumns:  []string{},
+	}
+}
+
+func integrityCheck(sdb *sql.DB) ([]string, error) {
+	rows, err := sdb.Query(fmt.Sprintf("PRAGMA integrity_check(%d)", maxIntegrityErrors))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]string, 0, 5)
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			ans = append(ans, msg)
+		}
+	}
+	return ans, rows.Err()
+}
+
+// schemaObjects returns names of tables and views
+func schemaObjects(sdb *sql.DB) (map[string]bool, error) {
+	rows, err := sdb.Query("SELECT name FROM sqlite_master WHERE type IN ('table', 'view')")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ans[name] = true
+	}
+	return ans, rows.Err()
+}
+
+func tableColumns(sdb *sql.DB, table string) (map[string]bool, error) {
+	rows, err := sdb.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ans[name] = true
+	}
+	return ans, rows.Err()
+}
+
+// Check verifies integrity of a text types database and tests whether
+// it contains all the tables and columns of the `schema`. The database
+// is opened read-only. Problems of the database (including a damaged file
+// which cannot be read at all) are reported via Report, the returned
+// error is reserved for a missing file and similar problems.
+func Check(path string, schema Schema) (*Report, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	sdb, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, err
+	}
+	defer sdb.Close()
+	ans := newReport(path)
+	ans.IntegrityErrors, err = integrityCheck(sdb)
+	if err != nil {
+		// typically "file is not a database"
+		ans.IntegrityErrors = []string{err.Error()}
+		return ans, nil
+	}
+	objects, err := schemaObjects(sdb)
+	if err != nil {
+		ans.IntegrityErrors = append(ans.IntegrityErrors, err.Error())
+		return ans, nil
+	}
+	expectedObjects := []string{dataTable, cacheTable}
+	if schema.HasBibView() {
+		expectedObjects = append(expectedObjects, bibView)
+	}
+	for _, obj := range expectedObjects {
+		if !objects[obj] {
+			ans.MissingTables = append(ans.MissingTables, obj)
+		}
+	}
+	if !objects[dataTable] {
+		return ans, nil
+	}
+	cols, err := tableColumns(sdb, dataTable)
+	if err != nil {
+		ans.IntegrityErrors = append(ans.IntegrityErrors, err.Error())
+		return ans, nil
+	}
+	for _, col := range schema.ExpectedColumns() {
+		if !cols[col] {
+			ans.MissingColumns = append(ans.MissingColumns, col)
+		}
+	}
+	row := sdb.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", dataTable))
+	if err := row.Scan(&ans.NumRows); err != nil {
+		ans.IntegrityErrors = append(ans.IntegrityErrors, err.Error())
+	}
+	return ans, nil
+}
+
+func quoteSQLiteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
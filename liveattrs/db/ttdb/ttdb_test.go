@@ -0,0 +1,98 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package ttdb
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testingSchema() Schema {
+	return Schema{
+		Columns:       []string{"doc_id", "doc_title"},
+		BibViewIDAttr: "doc_id",
+		BibViewCols:   []string{"doc_id", "doc_title"},
+		IndexedCols:   []string{"doc_title"},
+	}
+}
+
+func createTestingDb(t *testing.T, columns []mysqlColumn, schema Schema) string {
+	path := filepath.Join(t.TempDir(), "syn2020.db")
+	sdb, err := sql.Open("sqlite3", path)
+	assert.NoError(t, err)
+	defer sdb.Close()
+	for _, stmt := range schemaStatements(columns, schema) {
+		_, err := sdb.Exec(stmt)
+		assert.NoError(t, err)
+	}
+	_, err = sdb.Exec(
+		"INSERT INTO liveattrs_entry (doc_id, doc_title, poscount, wordcount, corpus_id) " +
+			"VALUES ('d1', 'Title', 10, 8, 'syn2020')")
+	assert.NoError(t, err)
+	return path
+}
+
+func testingColumns() []mysqlColumn {
+	return []mysqlColumn{
+		{name: "doc_id", dataType: "varchar"},
+		{name: "doc_title", dataType: "text"},
+		{name: "poscount", dataType: "int"},
+		{name: "wordcount", dataType: "int"},
+		{name: "corpus_id", dataType: "varchar"},
+	}
+}
+
+func TestCheckValidDatabase(t *testing.T) {
+	schema := testingSchema()
+	path := createTestingDb(t, testingColumns(), schema)
+	report, err := Check(path, schema)
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 1, report.NumRows)
+}
+
+func TestCheckMissingSchemaParts(t *testing.T) {
+	schema := testingSchema()
+	path := createTestingDb(t, testingColumns(), Schema{Columns: schema.Columns})
+	schema.Columns = append(schema.Columns, "doc_author")
+	report, err := Check(path, schema)
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+	assert.Equal(t, []string{"bibliography"}, report.MissingTables)
+	assert.Equal(t, []string{"doc_author"}, report.MissingColumns)
+	assert.Empty(t, report.IntegrityErrors)
+}
+
+func TestCheckDamagedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syn2020.db")
+	assert.NoError(t, os.WriteFile(path, []byte("definitely not an SQLite database file"), 0644))
+	report, err := Check(path, testingSchema())
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+	assert.NotEmpty(t, report.IntegrityErrors)
+}
+
+func TestCheckMissingFile(t *testing.T) {
+	_, err := Check(filepath.Join(t.TempDir(), "syn2020.db"), testingSchema())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LoadAttrUsage returns usage statistics of columns summed over
+// all the `corpora` (e.g. members of a parallel corpus group).
+// Columns are identified by their names (i.e. `doc_title`).
+func LoadAttrUsage(laDB *sql.DB, corpora []string) (map[string]liveattrs.AttrUsage, error) {
+	ans := make(map[string]liveattrs.AttrUsage)
+	if len(corpora) == 0 {
+		return ans, nil
+	}
+	args := make([]any, len(corpora))
+	for i, c := range corpora {
+		args[i] = c
+	}
+	rows, err := laDB.Query(
+		"SELECT structattr_name, SUM(num_used), UNIX_TIMESTAMP(MAX(last_used)) "+
+			"FROM `usage` "+
+			fmt.Sprintf("WHERE corpus_id IN (%s) ", strings.TrimSuffix(strings.Repeat("?, ", len(corpora)), ", "))+
+			"GROUP BY structattr_name",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var usage liveattrs.AttrUsage
+		var lastUsed sql.NullInt64
+		if err := rows.Scan(&name, &usage.NumUsed, &lastUsed); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			t := time.Unix(lastUsed.Int64, 0)
+			usage.LastUsed = &t
+		}
+		ans[name] = usage
+	}
+	return ans, rows.Err()
+}
+
+// columnSizesSQL generates a query returning sizes (in bytes)
+// of values of the columns
+func columnSizesSQL(tableName string, columns []string) string {
+	exprs := make([]string, len(columns))
+	for i, col := range columns {
+		exprs[i] = fmt.Sprintf("COALESCE(SUM(LENGTH(`%s`)), 0)", col)
+	}
+	return fmt.Sprintf("SELECT %s FROM `%s`", strings.Join(exprs, ", "), tableName)
+}
+
+// dropColumnsSQL generates a statement removing the columns
+// from a table
+func dropColumnsSQL(tableName string, columns []string) string {
+	drops := make([]string, len(columns))
+	for i, col := range columns {
+		drops[i] = fmt.Sprintf("DROP COLUMN `%s`", col)
+	}
+	return fmt.Sprintf("ALTER TABLE `%s` %s", tableName, strings.Join(drops, ", "))
+}
+
+// FindUnusedColumns searches for columns of the attributes `attrs`
+// (in the `doc.title` form) not queried by any of the `corpora`
+// since the `since` time. The columns `protected` (in the `doc_title`
+// form) are never reported. The columns are sorted by their size.
+func FindUnusedColumns(
+	laDB *sql.DB,
+	tableName string,
+	corpora []string,
+	attrs []string,
+	protected map[string]bool,
+	since time.Time,
+) (*liveattrs.UnusedColumnsReport, error) {
+	usage, err := LoadAttrUsage(laDB, corpora)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+	existing, err := loadColumns(laDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load columns: %w", err)
+	}
+	ans := &liveattrs.UnusedColumnsReport{
+		Table:       tableName,
+		Corpora:     corpora,
+		Created:     time.Now(),
+		UnusedSince: since,
+		Columns:     []liveattrs.UnusedColumn{},
+	}
+	columns := make([]string, 0, len(attrs))
+	sort.Strings(attrs)
+	for _, attr := range attrs {
+		col := utils.ImportKey(attr)
+		if _, ok := existing[col]; !ok || protected[col] || !usage[col].IsUnusedSince(since) {
+			continue
+		}
+		columns = append(columns, col)
+		ans.Columns = append(ans.Columns, liveattrs.UnusedColumn{
+			Attr:     attr,
+			NumUsed:  usage[col].NumUsed,
+			LastUsed: usage[col].LastUsed,
+		})
+	}
+	if len(columns) == 0 {
+		return ans, nil
+	}
+	sizes := make([]int64, len(columns))
+	sizePtrs := make([]any, len(columns))
+	for i := range sizes {
+		sizePtrs[i] = &sizes[i]
+	}
+	if err := laDB.QueryRow(columnSizesSQL(tableName, columns)).Scan(sizePtrs...); err != nil {
+		return nil, fmt.Errorf("failed to get column sizes: %w", err)
+	}
+	for i := range ans.Columns {
+		ans.Columns[i].DataSize = sizes[i]
+		ans.TotalDataSize += sizes[i]
+	}
+	ans.SortByCost()
+	return ans, nil
+}
+
+// DropColumns removes the columns of the attributes `attrs`
+// (in the `doc.title` form) from a table
+func DropColumns(laDB *sql.DB, tableName string, attrs []string) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+	columns := make([]string, len(attrs))
+	for i, attr := range attrs {
+		columns[i] = utils.ImportKey(attr)
+	}
+	_, err := laDB.Exec(dropColumnsSQL(tableName, columns))
+	return err
+}
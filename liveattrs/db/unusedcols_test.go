@@ -0,0 +1,42 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnSizesSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"SELECT COALESCE(SUM(LENGTH(`doc_title`)), 0), COALESCE(SUM(LENGTH(`doc_author`)), 0) "+
+			"FROM `syn2020_liveattrs_entry`",
+		columnSizesSQL("syn2020_liveattrs_entry", []string{"doc_title", "doc_author"}),
+	)
+}
+
+func TestDropColumnsSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"ALTER TABLE `intercorp_v16_liveattrs_entry` DROP COLUMN `doc_title`, DROP COLUMN `div_author`",
+		dropColumnsSQL("intercorp_v16_liveattrs_entry", []string{"doc_title", "div_author"}),
+	)
+}
@@ -29,6 +29,7 @@ import (
 	"masm/v3/liveattrs/request/query"
 	"masm/v3/liveattrs/utils"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -79,13 +80,19 @@ func (rd RequestData) toZeroLog(evt *zerolog.Event) {
 type StructAttrUsage struct {
 	db      *sql.DB
 	channel <-chan RequestData
+
+	// numUnflushed is the number of received records
+	// not stored to the database yet
+	numUnflushed atomic.Int64
 }
 
 func (sau *StructAttrUsage) RunHandler() {
 	for data := range sau.channel {
 		data.toZeroLog(log.Info())
 		if !data.IsCached {
+			sau.numUnflushed.Add(1)
 			err := sau.save(data)
+			sau.numUnflushed.Add(-1)
 			if err != nil {
 				log.Error().Err(err).Msg("Unable to save struct. attrs usage data")
 			}
@@ -93,8 +100,15 @@ func (sau *StructAttrUsage) RunHandler() {
 	}
 }
 
+// NumUnflushed returns the number of usage records
+// being currently stored to the database
+func (sau *StructAttrUsage) NumUnflushed() int {
+	return int(sau.numUnflushed.Load())
+}
+
 func (sau *StructAttrUsage) save(data RequestData) error {
-	sql_template := "INSERT INTO `usage` (`corpus_id`, `structattr_name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `num_used`=`num_used`+1"
+	sql_template := "INSERT INTO `usage` (`corpus_id`, `structattr_name`, `last_used`) VALUES (?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE `num_used`=`num_used`+1, `last_used`=NOW()"
 	context, err := sau.db.Begin()
 	if err != nil {
 		return err
@@ -163,6 +177,28 @@ func (res *updIdxResult) MarshalJSON() ([]byte, error) {
 
 // --
 
+// autoIndexSQL returns a name and a CREATE INDEX statement of an index
+// for a column. For typed (integer, date) columns of tables shared by more
+// corpora, the `corpus_id` column goes first so range conditions on the typed
+// column can still use the index. Such indexes have a different name so
+// an existing index with the original column order gets replaced.
+func autoIndexSQL(corpusInfo *corpus.DBInfo, column string, isTyped bool) (string, string) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", corpusInfo.GroupedName())
+	if corpusInfo.GroupedName() == corpusInfo.Name {
+		name := fmt.Sprintf("%s_autoindex", column)
+		return name, fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`%s`)", name, tableName, column)
+	}
+	if isTyped {
+		name := fmt.Sprintf("%s_range_autoindex", column)
+		return name, fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`corpus_id`, `%s`)", name, tableName, column)
+	}
+	name := fmt.Sprintf("%s_autoindex", column)
+	return name, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`%s`, `corpus_id`)", name, tableName, column)
+}
+
 func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updIdxResult {
 	// get most used columns
 	rows, err := laDB.Query(
@@ -183,21 +219,22 @@ func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updI
 		columns = append(columns, structattrName)
 	}
 
-	// create indexes if necessary with `_autoindex` appendix
-	var sqlTemplate string
-	if corpusInfo.GroupedName() == corpusInfo.Name {
-		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s_liveattrs_entry` (`%s`)"
-	} else {
-		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s_liveattrs_entry` (`%s`, `corpus_id`)"
+	columnInfos, err := loadColumns(laDB, fmt.Sprintf("%s_liveattrs_entry", corpusInfo.GroupedName()))
+	if err != nil {
+		return updIdxResult{Error: err}
 	}
+
+	// create indexes if necessary with `_autoindex` appendix
 	usedIndexes := make([]any, len(columns))
 	context, err := laDB.Begin()
 	if err != nil {
 		return updIdxResult{Error: err}
 	}
 	for i, column := range columns {
-		usedIndexes[i] = fmt.Sprintf("%s_autoindex", column)
-		_, err := context.Query(fmt.Sprintf(sqlTemplate, usedIndexes[i], corpusInfo.GroupedName(), column))
+		var stmt string
+		usedIndexes[i], stmt = autoIndexSQL(
+			corpusInfo, column, columnInfos[column].isTyped())
+		_, err := context.Query(stmt)
 		if err != nil {
 			return updIdxResult{Error: err}
 		}
@@ -209,7 +246,7 @@ func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updI
 	for i := 0; i < len(valuesPlaceholders); i++ {
 		valuesPlaceholders[i] = "?"
 	}
-	sqlTemplate = fmt.Sprintf(
+	sqlTemplate := fmt.Sprintf(
 		"SELECT INDEX_NAME FROM information_schema.statistics where TABLE_NAME = ? AND INDEX_NAME LIKE '%%_autoindex' AND INDEX_NAME NOT IN (%s)",
 		strings.Join(valuesPlaceholders, ", "),
 	)
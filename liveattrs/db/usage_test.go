@@ -0,0 +1,55 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"masm/v3/corpus"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoIndexSQL(t *testing.T) {
+	single := &corpus.DBInfo{Name: "syn2020"}
+	name, stmt := autoIndexSQL(single, "doc_year", true)
+	assert.Equal(t, "doc_year_autoindex", name)
+	assert.Equal(
+		t,
+		"CREATE INDEX IF NOT EXISTS `doc_year_autoindex` ON `syn2020_liveattrs_entry` (`doc_year`)",
+		stmt,
+	)
+
+	parallel := &corpus.DBInfo{Name: "intercorp_v13_en", ParallelCorpus: "intercorp_v13"}
+	name, stmt = autoIndexSQL(parallel, "doc_author", false)
+	assert.Equal(t, "doc_author_autoindex", name)
+	assert.Equal(
+		t,
+		"CREATE INDEX IF NOT EXISTS `doc_author_autoindex` ON `intercorp_v13_liveattrs_entry` "+
+			"(`doc_author`, `corpus_id`)",
+		stmt,
+	)
+	name, stmt = autoIndexSQL(parallel, "doc_year", true)
+	assert.Equal(t, "doc_year_range_autoindex", name)
+	assert.Equal(
+		t,
+		"CREATE INDEX IF NOT EXISTS `doc_year_range_autoindex` ON `intercorp_v13_liveattrs_entry` "+
+			"(`corpus_id`, `doc_year`)",
+		stmt,
+	)
+}
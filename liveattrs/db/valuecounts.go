@@ -0,0 +1,256 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/liveattrs"
+	"masm/v3/liveattrs/utils"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MaxSnapshotValuesPerAttr limits the number of distinct values
+	// of an attribute stored in a value counts snapshot. Attributes
+	// with more values (typically titles, IDs) are not stored.
+	MaxSnapshotValuesPerAttr = 10000
+
+	// NumKeptSnapshots is the number of the latest value counts
+	// snapshots kept for each corpus
+	NumKeptSnapshots = 10
+)
+
+func valueCountsSQL(tableName, col string) string {
+	return fmt.Sprintf(
+		"SELECT `%s`, COUNT(*), COALESCE(SUM(poscount), 0) FROM `%s` "+
+			"WHERE corpus_id = ? GROUP BY `%s` LIMIT %d",
+		col, tableName, col, MaxSnapshotValuesPerAttr+1,
+	)
+}
+
+// LoadValueCounts calculates current value counts of attributes `attrs`
+// (in the `struct.attr` form) of a corpus. Attributes with too many
+// distinct values (see MaxSnapshotValuesPerAttr) are skipped.
+func LoadValueCounts(
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	attrs []string,
+) (liveattrs.AttrValueCounts, error) {
+	tableName := fmt.Sprintf("%s_liveattrs_entry", corpusInfo.GroupedName())
+	ans := make(liveattrs.AttrValueCounts)
+	for _, attr := range attrs {
+		rows, err := laDB.Query(valueCountsSQL(tableName, utils.ImportKey(attr)), corpusInfo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load value counts of %s: %w", attr, err)
+		}
+		values := make(map[string]liveattrs.ValueCount)
+		for rows.Next() {
+			var value sql.NullString
+			var count liveattrs.ValueCount
+			if err := rows.Scan(&value, &count.NumItems, &count.Poscount); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to load value counts of %s: %w", attr, err)
+			}
+			values[value.String] = count
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load value counts of %s: %w", attr, err)
+		}
+		if len(values) > MaxSnapshotValuesPerAttr {
+			log.Warn().
+				Str("corpusId", corpusInfo.Name).
+				Str("attr", attr).
+				Msg("too many distinct values, attribute not included in value counts")
+			continue
+		}
+		ans[attr] = values
+	}
+	return ans, nil
+}
+
+// StoreValueCountsSnapshot stores value counts of a corpus created by
+// a data extraction job `jobID`. Only NumKeptSnapshots latest snapshots
+// of the corpus are kept.
+func StoreValueCountsSnapshot(
+	laDB *sql.DB,
+	corpusID string,
+	jobID string,
+	counts liveattrs.AttrValueCounts,
+) (*liveattrs.ValueCountsSnapshot, error) {
+	tx, err := laDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	ans := &liveattrs.ValueCountsSnapshot{
+		CorpusID: corpusID,
+		JobID:    jobID,
+		Created:  time.Now(),
+	}
+	res, err := tx.Exec(
+		"INSERT INTO value_counts_snapshots (corpus_id, job_id, created) VALUES (?, ?, ?)",
+		corpusID, jobID, ans.Created,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	ans.ID, err = res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	stmt, err := tx.Prepare(
+		"INSERT INTO value_counts (snapshot_id, attr, value, num_items, poscount) " +
+			"VALUES (?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	defer stmt.Close()
+	for attr, values := range counts {
+		for value, count := range values {
+			if _, err := stmt.Exec(ans.ID, attr, value, count.NumItems, count.Poscount); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	if err := pruneValueCountsSnapshots(tx, corpusID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return ans, tx.Commit()
+}
+
+// pruneValueCountsSnapshots removes all the snapshots of a corpus
+// except for NumKeptSnapshots latest ones
+func pruneValueCountsSnapshots(tx *sql.Tx, corpusID string) error {
+	rows, err := tx.Query(
+		"SELECT id FROM value_counts_snapshots WHERE corpus_id = ? ORDER BY id DESC",
+		corpusID,
+	)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, 0, NumKeptSnapshots+1)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil || len(ids) <= NumKeptSnapshots {
+		return err
+	}
+	for _, id := range ids[NumKeptSnapshots:] {
+		if _, err := tx.Exec("DELETE FROM value_counts WHERE snapshot_id = ?", id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM value_counts_snapshots WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetValueCountsSnapshots returns stored value counts snapshots
+// of a corpus (the latest first)
+func GetValueCountsSnapshots(laDB *sql.DB, corpusID string) ([]*liveattrs.ValueCountsSnapshot, error) {
+	rows, err := laDB.Query(
+		"SELECT id, job_id, UNIX_TIMESTAMP(created) FROM value_counts_snapshots "+
+			"WHERE corpus_id = ? ORDER BY id DESC",
+		corpusID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]*liveattrs.ValueCountsSnapshot, 0, NumKeptSnapshots)
+	for rows.Next() {
+		item := &liveattrs.ValueCountsSnapshot{CorpusID: corpusID}
+		var created int64
+		if err := rows.Scan(&item.ID, &item.JobID, &created); err != nil {
+			return nil, err
+		}
+		item.Created = time.Unix(created, 0)
+		ans = append(ans, item)
+	}
+	return ans, rows.Err()
+}
+
+// LoadValueCountsSnapshot loads value counts stored in a snapshot
+func LoadValueCountsSnapshot(laDB *sql.DB, snapshotID int64) (liveattrs.AttrValueCounts, error) {
+	rows, err := laDB.Query(
+		"SELECT attr, value, num_items, poscount FROM value_counts WHERE snapshot_id = ?",
+		snapshotID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make(liveattrs.AttrValueCounts)
+	for rows.Next() {
+		var attr, value string
+		var count liveattrs.ValueCount
+		if err := rows.Scan(&attr, &value, &count.NumItems, &count.Poscount); err != nil {
+			return nil, err
+		}
+		if _, ok := ans[attr]; !ok {
+			ans[attr] = make(map[string]liveattrs.ValueCount)
+		}
+		ans[attr][value] = count
+	}
+	return ans, rows.Err()
+}
+
+// DeleteValueCountsSnapshots removes all the value counts
+// snapshots of a corpus
+func DeleteValueCountsSnapshots(laDB *sql.DB, corpusID string) error {
+	tx, err := laDB.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE c FROM value_counts AS c "+
+			"JOIN value_counts_snapshots AS s ON c.snapshot_id = s.id "+
+			"WHERE s.corpus_id = ?",
+		corpusID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM value_counts_snapshots WHERE corpus_id = ?", corpusID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/utils"
+	"strings"
+)
+
+// ValueMergeUndoTableName returns a name of a table storing original
+// values replaced by value merges (see laconf.ValueMerge) so the merges
+// can be undone
+func ValueMergeUndoTableName(groupedName string) string {
+	return fmt.Sprintf("%s_liveattrs_merge_undo", groupedName)
+}
+
+func createValueMergeUndoTableSQL(tableName string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s` ("+
+			"merge_id VARCHAR(64) NOT NULL, "+
+			"corpus_id VARCHAR(255) NOT NULL, "+
+			"entry_id BIGINT NOT NULL, "+
+			"value TEXT, "+
+			"INDEX (merge_id, corpus_id))",
+		tableName,
+	)
+}
+
+func valueMergeBackupSQL(undoTable, entryTable, col string, numValues int) string {
+	return fmt.Sprintf(
+		"INSERT INTO `%s` (merge_id, corpus_id, entry_id, value) "+
+			"SELECT ?, corpus_id, id, `%s` FROM `%s` WHERE corpus_id = ? AND `%s` IN (%s)",
+		undoTable, col, entryTable, col,
+		strings.TrimSuffix(strings.Repeat("?, ", numValues), ", "),
+	)
+}
+
+func valueMergeUpdateSQL(entryTable, col string, numValues int) string {
+	return fmt.Sprintf(
+		"UPDATE `%s` SET `%s` = ? WHERE corpus_id = ? AND `%s` IN (%s)",
+		entryTable, col, col,
+		strings.TrimSuffix(strings.Repeat("?, ", numValues), ", "),
+	)
+}
+
+// valueMergeUndoSQL restores original values of rows still containing
+// the canonical value (i.e. rows not changed since the merge)
+func valueMergeUndoSQL(undoTable, entryTable, col string) string {
+	return fmt.Sprintf(
+		"UPDATE `%s` AS e JOIN `%s` AS u ON e.id = u.entry_id "+
+			"SET e.`%s` = u.value "+
+			"WHERE u.merge_id = ? AND u.corpus_id = ? AND e.corpus_id = u.corpus_id AND e.`%s` = ?",
+		entryTable, undoTable, col, col,
+	)
+}
+
+func applyValueMerge(
+	tx *sql.Tx,
+	groupedName, corpusID string,
+	merge laconf.ValueMerge,
+) (int64, error) {
+	entryTable := fmt.Sprintf("%s_liveattrs_entry", groupedName)
+	undoTable := ValueMergeUndoTableName(groupedName)
+	col := utils.ImportKey(merge.Attr)
+	// undo data of a previous application (e.g. before a rebuild) are obsolete
+	_, err := tx.Exec(
+		fmt.Sprintf("DELETE FROM `%s` WHERE merge_id = ? AND corpus_id = ?", undoTable),
+		merge.ID, corpusID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	args := make([]any, 0, len(merge.Values)+2)
+	args = append(args, merge.ID, corpusID)
+	for _, v := range merge.Values {
+		args = append(args, v)
+	}
+	if _, err := tx.Exec(valueMergeBackupSQL(undoTable, entryTable, col, len(merge.Values)), args...); err != nil {
+		return 0, err
+	}
+	args[0] = merge.Canonical
+	res, err := tx.Exec(valueMergeUpdateSQL(entryTable, col, len(merge.Values)), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ApplyValueMerges replaces merged values of the corpus `corpusID`
+// by their canonical values. The merges are applied in the order
+// they are listed. Original values are stored so each merge can be
+// undone (see UndoValueMerge). The function returns numbers of changed
+// rows for individual merges.
+func ApplyValueMerges(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	merges laconf.ValueMerges,
+) (map[string]int64, error) {
+	ans := make(map[string]int64)
+	if len(merges) == 0 {
+		return ans, nil
+	}
+	_, err := laDB.Exec(createValueMergeUndoTableSQL(ValueMergeUndoTableName(groupedName)))
+	if err != nil {
+		return ans, fmt.Errorf("failed to merge values: %w", err)
+	}
+	tx, err := laDB.Begin()
+	if err != nil {
+		return ans, fmt.Errorf("failed to merge values: %w", err)
+	}
+	for _, merge := range merges {
+		numRows, err := applyValueMerge(tx, groupedName, corpusID, merge)
+		if err != nil {
+			tx.Rollback()
+			return map[string]int64{}, fmt.Errorf("failed to merge values of %s: %w", merge.Attr, err)
+		}
+		ans[merge.ID] = numRows
+	}
+	return ans, tx.Commit()
+}
+
+// UndoValueMerge restores values replaced by a merge. Only rows still
+// containing the canonical value are restored so changes made after
+// the merge are preserved. The function returns the number of restored
+// rows.
+func UndoValueMerge(
+	laDB *sql.DB,
+	groupedName, corpusID string,
+	merge laconf.ValueMerge,
+) (int64, error) {
+	undoTable := ValueMergeUndoTableName(groupedName)
+	_, err := laDB.Exec(createValueMergeUndoTableSQL(undoTable))
+	if err != nil {
+		return 0, fmt.Errorf("failed to undo value merge: %w", err)
+	}
+	tx, err := laDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to undo value merge: %w", err)
+	}
+	res, err := tx.Exec(
+		valueMergeUndoSQL(
+			undoTable, fmt.Sprintf("%s_liveattrs_entry", groupedName), utils.ImportKey(merge.Attr)),
+		merge.ID, corpusID, merge.Canonical,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to undo value merge: %w", err)
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to undo value merge: %w", err)
+	}
+	_, err = tx.Exec(
+		fmt.Sprintf("DELETE FROM `%s` WHERE merge_id = ? AND corpus_id = ?", undoTable),
+		merge.ID, corpusID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to undo value merge: %w", err)
+	}
+	return numRows, tx.Commit()
+}
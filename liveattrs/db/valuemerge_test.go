@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueMergeSQL(t *testing.T) {
+	assert.Equal(
+		t,
+		"INSERT INTO `syn_liveattrs_merge_undo` (merge_id, corpus_id, entry_id, value) "+
+			"SELECT ?, corpus_id, id, `doc_publisher` FROM `syn_liveattrs_entry` "+
+			"WHERE corpus_id = ? AND `doc_publisher` IN (?, ?)",
+		valueMergeBackupSQL("syn_liveattrs_merge_undo", "syn_liveattrs_entry", "doc_publisher", 2),
+	)
+	assert.Equal(
+		t,
+		"UPDATE `syn_liveattrs_entry` SET `doc_publisher` = ? WHERE corpus_id = ? AND `doc_publisher` IN (?)",
+		valueMergeUpdateSQL("syn_liveattrs_entry", "doc_publisher", 1),
+	)
+	assert.Equal(
+		t,
+		"UPDATE `syn_liveattrs_entry` AS e JOIN `syn_liveattrs_merge_undo` AS u ON e.id = u.entry_id "+
+			"SET e.`doc_publisher` = u.value "+
+			"WHERE u.merge_id = ? AND u.corpus_id = ? AND e.corpus_id = u.corpus_id AND e.`doc_publisher` = ?",
+		valueMergeUndoSQL("syn_liveattrs_merge_undo", "syn_liveattrs_entry", "doc_publisher"),
+	)
+}
@@ -0,0 +1,357 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Compression specifies a compression format of a vertical file
+type Compression string
+
+const (
+	CompressionNone  Compression = ""
+	CompressionGzip  Compression = "gzip"
+	CompressionBzip2 Compression = "bzip2"
+	CompressionXz    Compression = "xz"
+
+	// xzCommand is an external command used to decompress xz files
+	// as there is no xz support in the standard library
+	xzCommand = "xz"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// detectCompression detects a compression format based
+// on initial bytes of a file
+func detectCompression(header []byte) Compression {
+	if bytes.HasPrefix(header, gzipMagic) {
+		return CompressionGzip
+
+	} else if bytes.HasPrefix(header, bzip2Magic) {
+		return CompressionBzip2
+
+	} else if bytes.HasPrefix(header, xzMagic) {
+		return CompressionXz
+	}
+	return CompressionNone
+}
+
+// DetectFileCompression detects a compression format of a file
+// based on its content (i.e. regardless of its suffix)
+func DetectFileCompression(path string) (Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return CompressionNone, err
+	}
+	defer f.Close()
+	header := make([]byte, len(xzMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return CompressionNone, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return detectCompression(header[:n]), nil
+}
+
+// cmdReader reads standard output of an external decompression command
+type cmdReader struct {
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	cmd    *exec.Cmd
+	done   bool
+}
+
+func (cr *cmdReader) wait() error {
+	if cr.done {
+		return nil
+	}
+	cr.done = true
+	if err := cr.cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(cr.stderr.String()))
+	}
+	return nil
+}
+
+func (cr *cmdReader) Read(p []byte) (int, error) {
+	n, err := cr.stdout.Read(p)
+	if err == io.EOF {
+		if werr := cr.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close stops the command in case it is still running
+// (i.e. in case the data have not been read completely)
+func (cr *cmdReader) Close() error {
+	if !cr.done {
+		cr.cmd.Process.Kill()
+		cr.done = true
+		cr.cmd.Wait()
+	}
+	return nil
+}
+
+func newXzReader(r io.Reader) (*cmdReader, error) {
+	ans := &cmdReader{cmd: exec.Command(xzCommand, "--decompress", "--stdout")}
+	ans.cmd.Stdin = r
+	ans.cmd.Stderr = &ans.stderr
+	stdout, err := ans.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	ans.stdout = stdout
+	if err := ans.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+// decompressedReader wraps a reader of a vertical file with
+// a decompressing reader in case the data are compressed
+// (gzip, bzip2 and xz are detected based on the data).
+// The returned reader must be closed once it is not needed.
+func decompressedReader(r io.Reader, path string) (io.ReadCloser, bool, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(xzMagic))
+	switch detectCompression(header) {
+	case CompressionGzip:
+		rd, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return rd, true, nil
+	case CompressionBzip2:
+		return io.NopCloser(bzip2.NewReader(br)), true, nil
+	case CompressionXz:
+		rd, err := newXzReader(br)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return rd, true, nil
+	}
+	return io.NopCloser(br), false, nil
+}
+
+// DecompressedVerticals provides paths of vertical files with
+// compressed verticals replaced by named pipes fed with decompressed
+// data. This allows vert-tagextract to process compressed verticals
+// without any temporary copies of decompressed data.
+type DecompressedVerticals struct {
+	Paths  []string
+	tmpDir string
+	pipes  []string
+
+	// errs contains errors of pipe feeders (see Err)
+	errs   []error
+	errsMu sync.Mutex
+}
+
+func (dv *DecompressedVerticals) addError(err error) {
+	dv.errsMu.Lock()
+	defer dv.errsMu.Unlock()
+	dv.errs = append(dv.errs, err)
+}
+
+// Err returns errors encountered while feeding the pipes
+// (e.g. a corrupted compressed file). As a reader of a pipe
+// cannot distinguish a failed feeder from a regular end of file,
+// Err should be checked once all the verticals have been read.
+func (dv *DecompressedVerticals) Err() error {
+	dv.errsMu.Lock()
+	defer dv.errsMu.Unlock()
+	return errors.Join(dv.errs...)
+}
+
+// OpenDecompressedVerticals prepares named pipes for compressed
+// vertical files. Decompression of a file starts once its pipe
+// is opened for reading. The returned value must be closed once
+// the verticals are processed.
+func OpenDecompressedVerticals(paths []string) (*DecompressedVerticals, error) {
+	ans := &DecompressedVerticals{Paths: make([]string, len(paths))}
+	for i, path := range paths {
+		compr, err := DetectFileCompression(path)
+		if err != nil {
+			ans.Close()
+			return nil, err
+		}
+		if compr == CompressionNone {
+			ans.Paths[i] = path
+			continue
+		}
+		if ans.tmpDir == "" {
+			ans.tmpDir, err = os.MkdirTemp("", "masm-verticals-*")
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare decompressed verticals: %w", err)
+			}
+		}
+		pipe := filepath.Join(ans.tmpDir, fmt.Sprintf("vertical-%d", i))
+		if err := syscall.Mkfifo(pipe, 0600); err != nil {
+			ans.Close()
+			return nil, fmt.Errorf("failed to prepare decompressed vertical %s: %w", path, err)
+		}
+		ans.pipes = append(ans.pipes, pipe)
+		ans.Paths[i] = pipe
+		log.Info().
+			Str("path", path).
+			Str("compression", string(compr)).
+			Msg("vertical will be decompressed on the fly")
+		go ans.feedPipe(path, pipe, nil)
+	}
+	return ans, nil
+}
+
+// OpenResumedVerticals prepares verticals for an extraction resumed
+// from a checkpoint. Verticals before the checkpoint one are omitted
+// and the checkpoint vertical is provided via a named pipe starting
+// with the structures open at the checkpoint followed by the lines
+// not processed yet. The returned value must be closed once the verticals
+// are processed.
+func OpenResumedVerticals(paths []string, from *ExtractionCheckpoint) (*DecompressedVerticals, error) {
+	if from.VerticalIdx < 0 || from.VerticalIdx >= len(paths) {
+		return nil, fmt.Errorf("invalid checkpoint vertical index %d", from.VerticalIdx)
+	}
+	ans, err := OpenDecompressedVerticals(paths[from.VerticalIdx+1:])
+	if err != nil {
+		return nil, err
+	}
+	if ans.tmpDir == "" {
+		ans.tmpDir, err = os.MkdirTemp("", "masm-verticals-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare resumed verticals: %w", err)
+		}
+	}
+	path := paths[from.VerticalIdx]
+	pipe := filepath.Join(ans.tmpDir, "vertical-resumed")
+	if err := syscall.Mkfifo(pipe, 0600); err != nil {
+		ans.Close()
+		return nil, fmt.Errorf("failed to prepare resumed vertical %s: %w", path, err)
+	}
+	ans.pipes = append(ans.pipes, pipe)
+	ans.Paths = append([]string{pipe}, ans.Paths...)
+	log.Info().
+		Str("path", path).
+		Int("lineOffset", from.LineOffset).
+		Msg("vertical will be resumed from a checkpoint")
+	go ans.feedPipe(path, pipe, from)
+	return ans, nil
+}
+
+// skipLines reads n lines from a reader
+func skipLines(br *bufio.Reader, n int) error {
+	for i := 0; i < n; {
+		_, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		i++
+	}
+	return nil
+}
+
+// feedPipe writes decompressed data of a vertical file to a named pipe.
+// In case a checkpoint is provided, the data continue from its position.
+// Errors are logged and collected (see DecompressedVerticals.Err).
+func (dv *DecompressedVerticals) feedPipe(path, pipe string, from *ExtractionCheckpoint) {
+	dst, err := os.OpenFile(pipe, os.O_WRONLY, 0) // blocks until there is a reader
+	if os.IsNotExist(err) {
+		return // already closed without being read
+
+	} else if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("failed to open pipe for decompressed vertical")
+		dv.addError(fmt.Errorf("failed to open pipe for vertical %s: %w", path, err))
+		return
+	}
+	defer dst.Close()
+	if err := feedPipeData(dst, path, from); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("failed to feed pipe with decompressed vertical")
+		dv.addError(err)
+	}
+}
+
+// feedPipeData writes decompressed data of a vertical file
+// (optionally starting from a checkpoint) to dst.
+func feedPipeData(dst io.Writer, path string, from *ExtractionCheckpoint) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open vertical %s: %w", path, err)
+	}
+	defer src.Close()
+	rd, _, err := decompressedReader(src, path)
+	if err != nil {
+		return fmt.Errorf("failed to decompress vertical %s: %w", path, err)
+	}
+	defer rd.Close()
+	var data io.Reader = rd
+	if from != nil {
+		br := bufio.NewReader(rd)
+		if err := skipLines(br, from.LineOffset); err != nil {
+			return fmt.Errorf("failed to skip processed lines of vertical %s: %w", path, err)
+		}
+		for _, line := range from.OpenTags {
+			if _, err := io.WriteString(dst, line+"\n"); err != nil {
+				return fmt.Errorf("failed to write resumed vertical %s: %w", path, err)
+			}
+		}
+		data = br
+	}
+	if _, err := io.Copy(dst, data); err != nil {
+		return fmt.Errorf("failed to decompress vertical %s: %w", path, err)
+	}
+	return nil
+}
+
+// Close releases all the pipes (including the ones not read at all)
+// and removes their temporary directory.
+func (dv *DecompressedVerticals) Close() error {
+	for _, pipe := range dv.pipes {
+		// a writer still waiting for a reader gets unblocked
+		// and stops on a broken pipe
+		f, err := os.OpenFile(pipe, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err == nil {
+			f.Close()
+		}
+	}
+	if dv.tmpDir != "" {
+		return os.RemoveAll(dv.tmpDir)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeGzipFile(t *testing.T, path, data string) {
+	var buff bytes.Buffer
+	wr := gzip.NewWriter(&buff)
+	_, err := wr.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, wr.Close())
+	assert.NoError(t, os.WriteFile(path, buff.Bytes(), 0644))
+}
+
+func TestDetectCompression(t *testing.T) {
+	assert.Equal(t, CompressionGzip, detectCompression([]byte{0x1f, 0x8b, 0x08, 0x00}))
+	assert.Equal(t, CompressionBzip2, detectCompression([]byte("BZh91AY")))
+	assert.Equal(t, CompressionXz, detectCompression([]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}))
+	assert.Equal(t, CompressionNone, detectCompression([]byte("<doc id=\"1\">")))
+	assert.Equal(t, CompressionNone, detectCompression([]byte{}))
+}
+
+func TestDecompressedReaderIgnoresSuffix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vertikala")
+	writeGzipFile(t, path, "foo\nbar\n")
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	rd, compressed, err := decompressedReader(f, path)
+	assert.NoError(t, err)
+	defer rd.Close()
+	assert.True(t, compressed)
+	data, err := io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(data))
+}
+
+func TestDecompressedReaderXz(t *testing.T) {
+	if _, err := exec.LookPath(xzCommand); err != nil {
+		t.Skip("xz not available")
+	}
+	path := filepath.Join(t.TempDir(), "vert.xz")
+	cmd := exec.Command(xzCommand, "--compress", "--stdout")
+	cmd.Stdin = bytes.NewBufferString("foo\nbar\nbaz\n")
+	data, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+	n, err := EstimateNumLines([]string{path})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestOpenDecompressedVerticals(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "vert1")
+	assert.NoError(t, os.WriteFile(plainPath, []byte("foo\n"), 0644))
+	gzPath := filepath.Join(dir, "vert2.gz")
+	writeGzipFile(t, gzPath, "bar\nbaz\n")
+
+	verticals, err := OpenDecompressedVerticals([]string{plainPath, gzPath})
+	assert.NoError(t, err)
+	assert.Equal(t, plainPath, verticals.Paths[0])
+	assert.NotEqual(t, gzPath, verticals.Paths[1])
+	data, err := os.ReadFile(verticals.Paths[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "bar\nbaz\n", string(data))
+	assert.NoError(t, verticals.Err())
+	assert.NoError(t, verticals.Close())
+	_, err = os.Stat(verticals.Paths[1])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOpenDecompressedVerticalsCorrupted(t *testing.T) {
+	gzPath := filepath.Join(t.TempDir(), "vert.gz")
+	writeGzipFile(t, gzPath, strings.Repeat("foo\tbar\n", 1000))
+	data, err := os.ReadFile(gzPath)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(gzPath, data[:len(data)/2], 0644))
+
+	verticals, err := OpenDecompressedVerticals([]string{gzPath})
+	assert.NoError(t, err)
+	_, err = os.ReadFile(verticals.Paths[0])
+	assert.NoError(t, err) // the reader sees just a regular end of file
+	assert.Error(t, verticals.Err())
+	assert.NoError(t, verticals.Close())
+}
+
+func TestDecompressedVerticalsCloseUnread(t *testing.T) {
+	gzPath := filepath.Join(t.TempDir(), "vert.gz")
+	writeGzipFile(t, gzPath, "foo\n")
+	verticals, err := OpenDecompressedVerticals([]string{gzPath})
+	assert.NoError(t, err)
+	assert.NoError(t, verticals.Close())
+}
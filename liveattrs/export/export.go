@@ -0,0 +1,120 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format specifies an output format of tabular liveattrs data
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+	FormatXLSX Format = "xlsx"
+
+	xlsxSheetName = "Sheet1"
+)
+
+// ParseFormat validates and returns a format specified by a URL
+// argument. An empty value means FormatJSON.
+func ParseFormat(v string) (Format, error) {
+	switch Format(v) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV, FormatTSV, FormatXLSX:
+		return Format(v), nil
+	}
+	return "", fmt.Errorf("unsupported export format: %s", v)
+}
+
+func (f Format) contentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatTSV:
+		return "text/tab-separated-values; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/json"
+}
+
+// Table is a generic tabular representation of exported data
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+func writeSeparated(w http.ResponseWriter, table Table, sep rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	if err := cw.Write(table.Header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func writeXLSX(w http.ResponseWriter, table Table) error {
+	xf := excelize.NewFile()
+	defer xf.Close()
+	for i, row := range append([][]string{table.Header}, table.Rows...) {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		xrow := make([]any, len(row))
+		for j, v := range row {
+			xrow[j] = v
+		}
+		if err := xf.SetSheetRow(xlsxSheetName, cell, &xrow); err != nil {
+			return err
+		}
+	}
+	return xf.Write(w)
+}
+
+// WriteTable writes table data in a specified format as
+// a downloadable file named `filename` (without a suffix).
+// The FormatJSON format is not supported by the function.
+func WriteTable(w http.ResponseWriter, format Format, filename string, table Table) error {
+	w.Header().Set("Content-Type", format.contentType())
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s.%s\"", filename, format),
+	)
+	switch format {
+	case FormatCSV:
+		return writeSeparated(w, table, ',')
+	case FormatTSV:
+		return writeSeparated(w, table, '\t')
+	case FormatXLSX:
+		return writeXLSX(w, table)
+	}
+	return fmt.Errorf("format %s cannot be exported as a table", format)
+}
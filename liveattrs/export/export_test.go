@@ -0,0 +1,89 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingTable() Table {
+	return Table{
+		Header: []string{"attribute", "value"},
+		Rows: [][]string{
+			{"doc.title", "Hello, world"},
+			{"doc.author", "John \"Johnny\" Doe"},
+			{"doc.note", "tab\tseparated"},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, f)
+	f, err = ParseFormat("xlsx")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, f)
+}
+
+func TestParseFormatRejectsUnknown(t *testing.T) {
+	_, err := ParseFormat("xls")
+	assert.Error(t, err)
+	_, err = ParseFormat("CSV")
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := WriteTable(w, FormatCSV, "syn2020", createTestingTable())
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"attribute,value\n"+
+			"doc.title,\"Hello, world\"\n"+
+			"doc.author,\"John \"\"Johnny\"\" Doe\"\n"+
+			"doc.note,tab\tseparated\n",
+		w.Body.String(),
+	)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=\"syn2020.csv\"", w.Header().Get("Content-Disposition"))
+}
+
+func TestWriteTSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := WriteTable(w, FormatTSV, "syn2020", createTestingTable())
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"attribute\tvalue\n"+
+			"doc.title\tHello, world\n"+
+			"doc.author\t\"John \"\"Johnny\"\" Doe\"\n"+
+			"doc.note\t\"tab\tseparated\"\n",
+		w.Body.String(),
+	)
+}
+
+func TestWriteTableJSONNotSupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := WriteTable(w, FormatJSON, "syn2020", createTestingTable())
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DfltHarmonizationMaxDistance is the default max. edit distance
+	// of (normalized) values considered near-duplicates
+	DfltHarmonizationMaxDistance = 1
+
+	// minSimilarValueLength is a min. length of (normalized) values
+	// compared by their edit distance (short values, e.g. codes,
+	// are too similar to each other)
+	minSimilarValueLength = 4
+
+	// maxSimilarityCheckValues is a max. number of distinct values
+	// compared by their edit distance (the comparison is quadratic)
+	maxSimilarityCheckValues = 3000
+)
+
+const (
+	// NearDuplicateNormalized means values differing only
+	// in case, whitespace or diacritics
+	NearDuplicateNormalized = "normalized"
+
+	// NearDuplicateSimilar means values with a small edit distance
+	NearDuplicateSimilar = "similar"
+)
+
+// PartialValue is a value present only in some of compared corpora
+type PartialValue struct {
+	Value string `json:"value"`
+
+	// Counts contains numbers of entries (atoms) with the value
+	// in corpora where the value is present
+	Counts map[string]int `json:"counts"`
+
+	MissingIn []string `json:"missingIn"`
+}
+
+// NearDuplicate contains values probably representing the same thing
+type NearDuplicate struct {
+	Values   []string `json:"values"`
+	Kind     string   `json:"kind"`
+	Distance int      `json:"distance"`
+}
+
+// HarmonizationReport compares value sets of an attribute
+// shared by multiple corpora
+type HarmonizationReport struct {
+	Attr    string    `json:"attr"`
+	Corpora []string  `json:"corpora"`
+	Created time.Time `json:"created"`
+
+	// CorporaWithoutAttr lists corpora not having the attribute at all
+	CorporaWithoutAttr []string `json:"corporaWithoutAttr"`
+
+	// NumValues is a number of distinct (non-empty) values in all the corpora
+	NumValues int `json:"numValues"`
+
+	// NumSharedValues is a number of values present in all the corpora
+	NumSharedValues int `json:"numSharedValues"`
+
+	PartialValues  []PartialValue  `json:"partialValues"`
+	NearDuplicates []NearDuplicate `json:"nearDuplicates"`
+
+	// SimilarityCheckSkipped is true in case there are too many
+	// values to search for similar ones (values differing only
+	// by normalization are still reported)
+	SimilarityCheckSkipped bool `json:"similarityCheckSkipped"`
+}
+
+// normalizeValue removes differences in case, whitespace
+// and diacritics of a value
+func normalizeValue(value string) string {
+	tr := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	ans, _, err := transform.String(tr, value)
+	if err != nil {
+		ans = value
+	}
+	return strings.ToLower(strings.Join(strings.Fields(ans), " "))
+}
+
+// editDistance calculates the Levenshtein distance of two strings
+func editDistance(s1, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	prev := make([]int, len(r2)+1)
+	curr := make([]int, len(r2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		curr[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(r2)]
+}
+
+// findNearDuplicates finds groups of values with the same normalized
+// form and pairs of values with similar normalized forms
+// (with the edit distance up to `maxDistance`)
+func findNearDuplicates(values []string, maxDistance int) ([]NearDuplicate, bool) {
+	ans := make([]NearDuplicate, 0, 10)
+	groups := make(map[string][]string)
+	normalized := make([]string, 0, len(values))
+	for _, v := range values {
+		key := normalizeValue(v)
+		if _, ok := groups[key]; !ok {
+			normalized = append(normalized, key)
+		}
+		groups[key] = append(groups[key], v)
+	}
+	for _, key := range normalized {
+		if len(groups[key]) > 1 {
+			ans = append(ans, NearDuplicate{Values: groups[key], Kind: NearDuplicateNormalized})
+		}
+	}
+	if maxDistance <= 0 {
+		return ans, false
+	}
+	if len(normalized) > maxSimilarityCheckValues {
+		return ans, true
+	}
+	for i, key1 := range normalized {
+		len1 := len([]rune(key1))
+		if len1 < minSimilarValueLength {
+			continue
+		}
+		for _, key2 := range normalized[i+1:] {
+			len2 := len([]rune(key2))
+			if len2 < minSimilarValueLength || len1-len2 > maxDistance || len2-len1 > maxDistance {
+				continue
+			}
+			if dist := editDistance(key1, key2); dist <= maxDistance {
+				ans = append(ans, NearDuplicate{
+					Values:   []string{groups[key1][0], groups[key2][0]},
+					Kind:     NearDuplicateSimilar,
+					Distance: dist,
+				})
+			}
+		}
+	}
+	return ans, false
+}
+
+// NewHarmonizationReport creates a report based on numbers of entries
+// with individual values of an attribute in corpora (corpus => value => count).
+// Corpora not having the attribute are expected to be nil.
+func NewHarmonizationReport(
+	attr string,
+	corpValues map[string]map[string]int,
+	maxDistance int,
+) *HarmonizationReport {
+	ans := &HarmonizationReport{
+		Attr:               attr,
+		Corpora:            make([]string, 0, len(corpValues)),
+		Created:            time.Now(),
+		CorporaWithoutAttr: []string{},
+		PartialValues:      []PartialValue{},
+	}
+	allValues := make(map[string]map[string]int)
+	for corpusID, values := range corpValues {
+		ans.Corpora = append(ans.Corpora, corpusID)
+		if values == nil {
+			ans.CorporaWithoutAttr = append(ans.CorporaWithoutAttr, corpusID)
+			continue
+		}
+		for value, count := range values {
+			if value == "" {
+				continue
+			}
+			if _, ok := allValues[value]; !ok {
+				allValues[value] = make(map[string]int)
+			}
+			allValues[value][corpusID] = count
+		}
+	}
+	sort.Strings(ans.Corpora)
+	sort.Strings(ans.CorporaWithoutAttr)
+	sortedValues := make([]string, 0, len(allValues))
+	for value := range allValues {
+		sortedValues = append(sortedValues, value)
+	}
+	sort.Strings(sortedValues)
+	ans.NumValues = len(sortedValues)
+	for _, value := range sortedValues {
+		counts := allValues[value]
+		if len(counts) == len(ans.Corpora) {
+			ans.NumSharedValues++
+			continue
+		}
+		item := PartialValue{Value: value, Counts: counts, MissingIn: []string{}}
+		for _, corpusID := range ans.Corpora {
+			if _, ok := counts[corpusID]; !ok {
+				item.MissingIn = append(item.MissingIn, corpusID)
+			}
+		}
+		ans.PartialValues = append(ans.PartialValues, item)
+	}
+	ans.NearDuplicates, ans.SimilarityCheckSkipped = findNearDuplicates(sortedValues, maxDistance)
+	return ans
+}
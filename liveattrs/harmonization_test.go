@@ -0,0 +1,80 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	assert.Equal(t, "beletrie", normalizeValue("Beletrie"))
+	assert.Equal(t, "odborna literatura", normalizeValue(" Odborná  literatura "))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("fiction", "fiction"))
+	assert.Equal(t, 2, editDistance("fiction", "fictoin"))
+	assert.Equal(t, 1, editDistance("fiction", "fictio"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 1, editDistance("kůň", "kuň"))
+}
+
+func TestNewHarmonizationReport(t *testing.T) {
+	report := NewHarmonizationReport(
+		"doc.txtype",
+		map[string]map[string]int{
+			"syn2020": {"fiction": 10, "poetry": 5, "Journalism": 3, "": 2},
+			"syn2015": {"fiction": 7, "poetry": 1, "journalism": 2, "technical": 1},
+			"oral":    nil,
+		},
+		DfltHarmonizationMaxDistance,
+	)
+	assert.Equal(t, []string{"oral", "syn2015", "syn2020"}, report.Corpora)
+	assert.Equal(t, []string{"oral"}, report.CorporaWithoutAttr)
+	assert.Equal(t, 5, report.NumValues)
+	assert.Equal(t, 0, report.NumSharedValues)
+	if assert.Len(t, report.PartialValues, 5) {
+		assert.Equal(t, "Journalism", report.PartialValues[0].Value)
+		assert.Equal(t, []string{"oral", "syn2015"}, report.PartialValues[0].MissingIn)
+		assert.Equal(t, map[string]int{"syn2020": 3}, report.PartialValues[0].Counts)
+	}
+	assert.Equal(
+		t,
+		[]NearDuplicate{{Values: []string{"Journalism", "journalism"}, Kind: NearDuplicateNormalized}},
+		report.NearDuplicates,
+	)
+}
+
+func TestFindNearDuplicatesSimilar(t *testing.T) {
+	ans, skipped := findNearDuplicates([]string{"fiction", "fictoin", "ficton", "poem", "poet"}, 1)
+	assert.False(t, skipped)
+	assert.Equal(
+		t,
+		[]NearDuplicate{
+			{Values: []string{"fiction", "ficton"}, Kind: NearDuplicateSimilar, Distance: 1},
+			{Values: []string{"fictoin", "ficton"}, Kind: NearDuplicateSimilar, Distance: 1},
+			{Values: []string{"poem", "poet"}, Kind: NearDuplicateSimilar, Distance: 1},
+		},
+		ans,
+	)
+	ans, _ = findNearDuplicates([]string{"fiction", "ficton"}, 0)
+	assert.Empty(t, ans)
+}
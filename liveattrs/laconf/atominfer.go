@@ -0,0 +1,104 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"errors"
+	"fmt"
+	"masm/v3/general/collections"
+	"masm/v3/liveattrs"
+	"math"
+	"sort"
+)
+
+var (
+	ErrorAtomNotInferable = errors.New("atom structure cannot be inferred")
+)
+
+// StructCoverageFn returns ratios of corpus positions covered
+// by provided structures
+type StructCoverageFn func(structs []string) (map[string]float64, error)
+
+// SortedStructNames returns alphabetically sorted names
+// of structures from a vert-tagextract structures configuration
+func SortedStructNames(structs map[string][]string) []string {
+	ans := make([]string, 0, len(structs))
+	for k := range structs {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// InferAtomStructure selects an atom structure out of provided
+// candidate structures using a strategy specified in conf.
+// In case there is only one candidate, it is always selected.
+// If no structure can be selected, ErrorAtomNotInferable is returned.
+func InferAtomStructure(
+	candidates []string,
+	conf liveattrs.AtomInferenceConf,
+	coverage StructCoverageFn,
+) (string, error) {
+	if len(candidates) == 0 {
+		return "", ErrorAtomNotInferable
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	switch conf.Strategy {
+	case liveattrs.AtomInferenceSingle:
+		return "", ErrorAtomNotInferable
+	case liveattrs.AtomInferencePreferred:
+		for _, st := range conf.PreferredStructs {
+			if collections.SliceContains(candidates, st) {
+				return st, nil
+			}
+		}
+		return "", ErrorAtomNotInferable
+	case liveattrs.AtomInferenceCoverage:
+		if coverage == nil {
+			return "", fmt.Errorf("failed to infer atom structure: coverage not available")
+		}
+		cov, err := coverage(candidates)
+		if err != nil {
+			return "", fmt.Errorf("failed to infer atom structure: %w", err)
+		}
+		sorted := make([]string, len(candidates))
+		copy(sorted, candidates)
+		sort.Strings(sorted)
+		var ans string
+		bestDist := math.Inf(1)
+		for _, st := range sorted {
+			v, ok := cov[st]
+			if !ok {
+				continue
+			}
+			if dist := math.Abs(1.0 - v); dist < bestDist {
+				bestDist = dist
+				ans = st
+			}
+		}
+		if ans == "" {
+			return "", ErrorAtomNotInferable
+		}
+		return ans, nil
+	default:
+		return "", fmt.Errorf("failed to infer atom structure: unknown strategy %s", conf.Strategy)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"masm/v3/liveattrs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferAtomSingleCandidate(t *testing.T) {
+	for _, strategy := range []string{
+		liveattrs.AtomInferenceSingle,
+		liveattrs.AtomInferencePreferred,
+		liveattrs.AtomInferenceCoverage,
+	} {
+		atom, err := InferAtomStructure(
+			[]string{"opus"}, liveattrs.AtomInferenceConf{Strategy: strategy}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "opus", atom)
+	}
+}
+
+func TestInferAtomSingleStrategy(t *testing.T) {
+	_, err := InferAtomStructure(
+		[]string{"doc", "text"},
+		liveattrs.AtomInferenceConf{Strategy: liveattrs.AtomInferenceSingle},
+		nil,
+	)
+	assert.Equal(t, ErrorAtomNotInferable, err)
+}
+
+func TestInferAtomPreferredStrategy(t *testing.T) {
+	conf := liveattrs.AtomInferenceConf{
+		Strategy:         liveattrs.AtomInferencePreferred,
+		PreferredStructs: []string{"doc", "text"},
+	}
+	atom, err := InferAtomStructure([]string{"p", "text", "doc"}, conf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", atom)
+
+	atom, err = InferAtomStructure([]string{"p", "text"}, conf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "text", atom)
+
+	_, err = InferAtomStructure([]string{"p", "s"}, conf, nil)
+	assert.Equal(t, ErrorAtomNotInferable, err)
+}
+
+func TestInferAtomCoverageStrategy(t *testing.T) {
+	conf := liveattrs.AtomInferenceConf{Strategy: liveattrs.AtomInferenceCoverage}
+	coverage := func(structs []string) (map[string]float64, error) {
+		return map[string]float64{"doc": 0.98, "p": 0.91, "head": 0.02}, nil
+	}
+	atom, err := InferAtomStructure([]string{"p", "head", "doc"}, conf, coverage)
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", atom)
+}
+
+func TestInferAtomCoverageTieIsDeterministic(t *testing.T) {
+	conf := liveattrs.AtomInferenceConf{Strategy: liveattrs.AtomInferenceCoverage}
+	coverage := func(structs []string) (map[string]float64, error) {
+		return map[string]float64{"text": 1.0, "doc": 1.0}, nil
+	}
+	atom, err := InferAtomStructure([]string{"text", "doc"}, conf, coverage)
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", atom)
+}
+
+func TestInferAtomCoverageError(t *testing.T) {
+	conf := liveattrs.AtomInferenceConf{Strategy: liveattrs.AtomInferenceCoverage}
+	coverage := func(structs []string) (map[string]float64, error) {
+		return nil, fmt.Errorf("manatee error")
+	}
+	_, err := InferAtomStructure([]string{"text", "doc"}, conf, coverage)
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrorAtomNotInferable, err)
+}
+
+func TestSortedStructNames(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"doc", "p", "text"},
+		SortedStructNames(map[string][]string{"text": nil, "doc": {"id"}, "p": nil}),
+	)
+}
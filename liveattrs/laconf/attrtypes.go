@@ -0,0 +1,120 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import "fmt"
+
+// AttrType specifies a data type of a structural attribute
+// as used for storing values and for evaluating range queries.
+type AttrType string
+
+const (
+	AttrTypeString AttrType = "string"
+	AttrTypeInt    AttrType = "int"
+	AttrTypeDate   AttrType = "date"
+
+	// AttrTypeNumber is for numeric (possibly decimal) values which
+	// are kept in string columns. Both '.' and ',' are accepted
+	// as decimal separators.
+	AttrTypeNumber AttrType = "number"
+)
+
+// Validate tests whether the type is supported
+func (t AttrType) Validate() error {
+	switch t {
+	case AttrTypeString, AttrTypeInt, AttrTypeDate, AttrTypeNumber:
+		return nil
+	}
+	return fmt.Errorf("unsupported attribute type: %s", t)
+}
+
+// SQLType returns a column type a respective attribute should be
+// stored in. For strings and numbers, empty value is returned which
+// means "keep the column as created by vert-tagextract".
+func (t AttrType) SQLType() string {
+	switch t {
+	case AttrTypeInt:
+		return "INTEGER"
+	case AttrTypeDate:
+		return "DATE"
+	}
+	return ""
+}
+
+// ValuePattern returns a regular expression matching (string) values
+// which can be converted to the respective SQL type. For strings, empty
+// value is returned.
+func (t AttrType) ValuePattern() string {
+	switch t {
+	case AttrTypeInt:
+		return "^-?[0-9]+$"
+	case AttrTypeNumber:
+		return "^-?[0-9]+([.,][0-9]+)?$"
+	case AttrTypeDate:
+		return "^[0-9]{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$"
+	}
+	return ""
+}
+
+// IsNumeric tests whether values of the type are to be compared
+// and sorted as numbers
+func (t AttrType) IsNumeric() bool {
+	return t == AttrTypeInt || t == AttrTypeNumber
+}
+
+// isKnownAttr tests whether an attribute (in dot notation)
+// is one of structural attributes in `structures`
+func isKnownAttr(structures map[string][]string, attr string) bool {
+	for strct, attrs := range structures {
+		for _, sattr := range attrs {
+			if fmt.Sprintf("%s.%s", strct, sattr) == attr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AttrTypes maps structural attributes (in dot notation, e.g. "doc.pubdate")
+// to their types. Attributes not present in the map are considered
+// to be strings.
+type AttrTypes map[string]AttrType
+
+// Get returns a type of a specified attribute
+// (AttrTypeString in case nothing is declared)
+func (at AttrTypes) Get(attr string) AttrType {
+	if v, ok := at[attr]; ok {
+		return v
+	}
+	return AttrTypeString
+}
+
+// Validate tests whether all the declared types are supported
+// and whether all the attributes are known structural attributes.
+func (at AttrTypes) Validate(structures map[string][]string) error {
+	for attr, tp := range at {
+		if err := tp.Validate(); err != nil {
+			return fmt.Errorf("invalid type of %s: %w", attr, err)
+		}
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot declare type of unknown attribute %s", attr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	DfltAutocompleteCollation     = "utf8mb4_general_ci"
+	DfltAutocompleteMinSimilarity = 0.5
+)
+
+var (
+	collationRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+)
+
+// AutocompleteConf specifies how attribute values are matched
+// in autocomplete queries. The zero value means plain substring
+// matching.
+type AutocompleteConf struct {
+
+	// IgnoreDiacritics enables diacritics (and case) insensitive
+	// matching using the Collation
+	IgnoreDiacritics bool `json:"ignoreDiacritics"`
+
+	// Collation is an accent insensitive collation compatible with
+	// character set of liveattrs tables (default: utf8mb4_general_ci)
+	Collation string `json:"collation,omitempty"`
+
+	// Fuzzy enables trigram-based matching so also values
+	// containing just a part of typed character trigrams are found
+	Fuzzy bool `json:"fuzzy"`
+
+	// FuzzyMinSimilarity is a minimum ratio (0, 1] of typed text
+	// trigrams a value must contain (default: 0.5)
+	FuzzyMinSimilarity float64 `json:"fuzzyMinSimilarity,omitempty"`
+}
+
+// GetCollation returns configured collation or the default one
+func (conf AutocompleteConf) GetCollation() string {
+	if conf.Collation == "" {
+		return DfltAutocompleteCollation
+	}
+	return conf.Collation
+}
+
+// GetFuzzyMinSimilarity returns configured min. similarity
+// or the default one
+func (conf AutocompleteConf) GetFuzzyMinSimilarity() float64 {
+	if conf.FuzzyMinSimilarity == 0 {
+		return DfltAutocompleteMinSimilarity
+	}
+	return conf.FuzzyMinSimilarity
+}
+
+// Validate tests whether the configuration is usable. Please note that
+// collation is inserted directly into SQL queries so it must be
+// a valid identifier.
+func (conf AutocompleteConf) Validate() error {
+	if conf.Collation != "" && !collationRegexp.MatchString(conf.Collation) {
+		return fmt.Errorf("invalid autocomplete collation: %s", conf.Collation)
+	}
+	if conf.FuzzyMinSimilarity < 0 || conf.FuzzyMinSimilarity > 1 {
+		return fmt.Errorf(
+			"invalid autocomplete fuzzyMinSimilarity: %01.2f", conf.FuzzyMinSimilarity)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutocompleteConfDefaults(t *testing.T) {
+	var conf AutocompleteConf
+	assert.Equal(t, DfltAutocompleteCollation, conf.GetCollation())
+	assert.Equal(t, DfltAutocompleteMinSimilarity, conf.GetFuzzyMinSimilarity())
+	assert.NoError(t, conf.Validate())
+}
+
+func TestAutocompleteConfValidate(t *testing.T) {
+	assert.NoError(t, AutocompleteConf{Collation: "utf8mb4_0900_ai_ci"}.Validate())
+	assert.Error(t, AutocompleteConf{Collation: "utf8mb4_bin; DROP TABLE x"}.Validate())
+	assert.Error(t, AutocompleteConf{FuzzyMinSimilarity: 1.5}.Validate())
+	assert.Error(t, AutocompleteConf{FuzzyMinSimilarity: -0.1}.Validate())
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+)
+
+// suffixes of auxiliary configuration files stored along with corpora
+// configs (e.g. `syn2020.attrTypes.json`)
+const (
+	attrTypesSuffix         = ".attrTypes"
+	detectedAttrTypesSuffix = ".detectedAttrTypes"
+	autocompleteSuffix      = ".autocomplete"
+	collationsSuffix        = ".collations"
+	multiValuesSuffix       = ".multiValues"
+	localesSuffix           = ".locales"
+	valueFiltersSuffix      = ".valueFilters"
+	valueOrdersSuffix       = ".valueOrders"
+	valueMergesSuffix       = ".valueMerges"
+	bibViewSuffix           = ".bibView"
+	speakersSuffix          = ".speakers"
+	timingSuffix            = ".timing"
+)
+
+// auxConfSuffixes lists suffixes of all the auxiliary configuration
+// files (in the order of LiveAttrsBuildConfProvider.auxConfs). It is
+// used for recognizing auxiliary files (see e.g. storedCorpora).
+var auxConfSuffixes = []string{
+	attrTypesSuffix, detectedAttrTypesSuffix, autocompleteSuffix, collationsSuffix,
+	multiValuesSuffix, localesSuffix, valueFiltersSuffix, valueOrdersSuffix,
+	valueMergesSuffix, bibViewSuffix, speakersSuffix, timingSuffix,
+}
+
+// auxConfCache is a type independent interface of auxConf
+type auxConfCache interface {
+	path(corpusID string) string
+	uncache(corpusID string)
+}
+
+// auxConf is a loader and a cache for an auxiliary configuration
+// of corpora stored in files `[corpus ID][suffix].json`. In case
+// there is no file for a corpus, a default value is used.
+type auxConf[T any] struct {
+	confDirPath string
+	suffix      string
+
+	// dflt creates a value used in case nothing is stored
+	// (the value is also used as a target for JSON decoding)
+	dflt func() T
+
+	mu   sync.RWMutex
+	data map[string]T
+}
+
+func (ac *auxConf[T]) path(corpusID string) string {
+	return path.Join(ac.confDirPath, corpusID+ac.suffix+".json")
+}
+
+// get returns a configuration of a corpus. In case the configuration
+// is not cached, it is loaded from its file.
+func (ac *auxConf[T]) get(corpusID string) (T, error) {
+	ac.mu.RLock()
+	v, ok := ac.data[corpusID]
+	ac.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+	ans := ac.dflt()
+	confPath := ac.path(corpusID)
+	isFile, err := fs.IsFile(confPath)
+	if err != nil {
+		return ans, err
+	}
+	if isFile {
+		rawData, err := os.ReadFile(confPath)
+		if err != nil {
+			return ans, err
+		}
+		if err := json.Unmarshal(rawData, &ans); err != nil {
+			return ans, err
+		}
+	}
+	ac.mu.Lock()
+	ac.data[corpusID] = ans
+	ac.mu.Unlock()
+	return ans, nil
+}
+
+// save stores a configuration of a corpus to its file
+// and to the cache
+func (ac *auxConf[T]) save(corpusID string, value T) error {
+	rawData, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ac.path(corpusID), rawData, 0644); err != nil {
+		return err
+	}
+	ac.mu.Lock()
+	ac.data[corpusID] = value
+	ac.mu.Unlock()
+	return nil
+}
+
+func (ac *auxConf[T]) uncache(corpusID string) {
+	ac.mu.Lock()
+	delete(ac.data, corpusID)
+	ac.mu.Unlock()
+}
+
+func newAuxConf[T any](confDirPath, suffix string, dflt func() T) *auxConf[T] {
+	return &auxConf[T]{
+		confDirPath: confDirPath,
+		suffix:      suffix,
+		dflt:        dflt,
+		data:        make(map[string]T),
+	}
+}
+
+// auxConfPaths returns paths of all the auxiliary configuration
+// files of a corpus (some of them may not exist)
+func (lcache *LiveAttrsBuildConfProvider) auxConfPaths(corpusID string) []string {
+	auxConfs := lcache.auxConfs()
+	ans := make([]string, len(auxConfs))
+	for i, ac := range auxConfs {
+		ans[i] = ac.path(corpusID)
+	}
+	return ans
+}
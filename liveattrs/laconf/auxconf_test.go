@@ -0,0 +1,70 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuxConfsMatchSuffixes(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, nil)
+	auxConfs := lcache.auxConfs()
+	assert.Equal(t, len(auxConfSuffixes), len(auxConfs))
+	for i, ac := range auxConfs {
+		assert.Equal(t, path.Join(dir, "syn2020"+auxConfSuffixes[i]+".json"), ac.path("syn2020"))
+	}
+}
+
+func TestAuxConfGetSave(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, nil)
+	types, err := lcache.GetDeclaredAttrTypes("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, AttrTypes{}, types)
+	speakers, err := lcache.GetSpeakerConf("syn2020")
+	assert.NoError(t, err)
+	assert.Nil(t, speakers)
+
+	assert.NoError(t, lcache.SaveAttrTypes("syn2020", AttrTypes{"doc.year": AttrTypeInt}))
+	assert.NoError(t, lcache.SaveSpeakerConf("syn2020", SpeakerConf{Struct: "sp", IDAttr: "id"}))
+	info, err := os.Stat(path.Join(dir, "syn2020.attrTypes.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	lcache2 := NewLiveAttrsBuildConfProvider(dir, nil)
+	types, err = lcache2.GetDeclaredAttrTypes("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, AttrTypes{"doc.year": AttrTypeInt}, types)
+	speakers, err = lcache2.GetSpeakerConf("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, &SpeakerConf{Struct: "sp", IDAttr: "id"}, speakers)
+
+	// a changed file is loaded once the corpus is uncached
+	assert.NoError(t, lcache.SaveAttrTypes("syn2020", AttrTypes{"doc.year": AttrTypeDate}))
+	types, _ = lcache2.GetDeclaredAttrTypes("syn2020")
+	assert.Equal(t, AttrTypeInt, types["doc.year"])
+	lcache2.Uncache("syn2020")
+	types, _ = lcache2.GetDeclaredAttrTypes("syn2020")
+	assert.Equal(t, AttrTypeDate, types["doc.year"])
+}
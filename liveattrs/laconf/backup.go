@@ -0,0 +1,201 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+)
+
+const backupDirName = "backup"
+
+// nextBackupVersion returns a version number for a new backup
+// of a corpus config based on existing backup file names
+// (<corpus>.<version>.json and <corpus>.<version><aux suffix>.json).
+func nextBackupVersion(fileNames []string, corpusID string) int {
+	ans := 1
+	for _, name := range fileNames {
+		if !strings.HasPrefix(name, corpusID+".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		rawVer, _, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(name, corpusID+"."), ".json"), ".")
+		ver, err := strconv.Atoi(rawVer)
+		if err != nil {
+			continue
+		}
+		if ver >= ans {
+			ans = ver + 1
+		}
+	}
+	return ans
+}
+
+// ConfVersion describes a backed up version of a corpus config
+type ConfVersion struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+func (lcache *LiveAttrsBuildConfProvider) backupDirPath() string {
+	return path.Join(lcache.confDirPath, backupDirName)
+}
+
+func (lcache *LiveAttrsBuildConfProvider) versionPath(corpusID string, version int) string {
+	return path.Join(lcache.backupDirPath(), fmt.Sprintf("%s.%d.json", corpusID, version))
+}
+
+// Backup stores a copy of the current config file of a corpus
+// to the backup directory (<confDirPath>/backup/<corpus>.<version>.json)
+// with version being one more than the highest existing one.
+// It returns a path of the created backup. In case there is no
+// config file for the corpus, ErrorNoSuchConfig is returned.
+func (lcache *LiveAttrsBuildConfProvider) Backup(corpusID string) (string, error) {
+	data, err := os.ReadFile(path.Join(lcache.confDirPath, corpusID+".json"))
+	if os.IsNotExist(err) {
+		return "", ErrorNoSuchConfig
+
+	} else if err != nil {
+		return "", fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+	}
+	backupPath, err := lcache.newBackupPath(corpusID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+	}
+	return backupPath, nil
+}
+
+// newBackupPath returns a path of a new backup version of a corpus
+// config. The backup directory is created if needed.
+func (lcache *LiveAttrsBuildConfProvider) newBackupPath(corpusID string) (string, error) {
+	if err := os.MkdirAll(lcache.backupDirPath(), 0755); err != nil {
+		return "", fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+	}
+	entries, err := os.ReadDir(lcache.backupDirPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return lcache.versionPath(corpusID, nextBackupVersion(names, corpusID)), nil
+}
+
+// backupAuxConfs stores copies of existing auxiliary config files
+// of a corpus along with a backup of its main config created by Backup
+// (`<corpus>.<version><suffix>.json`). In case there is no main config
+// backup (an empty mainBackupPath), a new version is used. The copies
+// are intended for manual recovery (Restore handles main configs only).
+func (lcache *LiveAttrsBuildConfProvider) backupAuxConfs(corpusID, mainBackupPath string) error {
+	for _, auxPath := range lcache.auxConfPaths(corpusID) {
+		data, err := os.ReadFile(auxPath)
+		if os.IsNotExist(err) {
+			continue
+
+		} else if err != nil {
+			return fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+		}
+		if mainBackupPath == "" {
+			mainBackupPath, err = lcache.newBackupPath(corpusID)
+			if err != nil {
+				return err
+			}
+		}
+		backupPath := strings.TrimSuffix(mainBackupPath, ".json") +
+			strings.TrimPrefix(path.Base(auxPath), corpusID)
+		if err := os.WriteFile(backupPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to back up config of %s: %w", corpusID, err)
+		}
+	}
+	return nil
+}
+
+// ListVersions returns backed up versions of a corpus config
+// sorted from the oldest one. In case there are no versions,
+// an empty list is returned.
+func (lcache *LiveAttrsBuildConfProvider) ListVersions(corpusID string) ([]ConfVersion, error) {
+	ans := make([]ConfVersion, 0, 10)
+	entries, err := os.ReadDir(lcache.backupDirPath())
+	if os.IsNotExist(err) {
+		return ans, nil
+
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list config versions of %s: %w", corpusID, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, corpusID+".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ver, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, corpusID+"."), ".json"))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list config versions of %s: %w", corpusID, err)
+		}
+		ans = append(ans, ConfVersion{Version: ver, Created: info.ModTime()})
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Version < ans[j].Version })
+	return ans, nil
+}
+
+// GetVersion loads a backed up version of a corpus config.
+// In case there is no such version, ErrorNoSuchConfig is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetVersion(corpusID string, version int) (*vteconf.VTEConf, error) {
+	versionPath := lcache.versionPath(corpusID, version)
+	isFile, err := fs.IsFile(versionPath)
+	if err != nil {
+		return nil, err
+	}
+	if !isFile {
+		return nil, ErrorNoSuchConfig
+	}
+	return LoadConf(versionPath)
+}
+
+// Restore replaces the current config of a corpus by a backed up
+// version. The replaced config is backed up as a new version
+// so the restoring can be reverted too.
+func (lcache *LiveAttrsBuildConfProvider) Restore(corpusID string, version int) (*vteconf.VTEConf, error) {
+	conf, err := lcache.GetVersion(corpusID, version)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Corpus != corpusID {
+		return nil, fmt.Errorf(
+			"config version %d of %s belongs to a different corpus %s", version, corpusID, conf.Corpus)
+	}
+	if err := lcache.Save(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBackupVersion(t *testing.T) {
+	assert.Equal(t, 1, nextBackupVersion([]string{}, "syn2020"))
+	assert.Equal(
+		t,
+		4,
+		nextBackupVersion(
+			[]string{
+				"syn2020.1.json", "syn2020.3.json", "syn2020_x.7.json", "syn2020.foo.json",
+				"syn2020.2.attrTypes.json",
+			},
+			"syn2020",
+		),
+	)
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	lcache := &LiveAttrsBuildConfProvider{confDirPath: dir}
+	_, err := lcache.Backup("syn2020")
+	assert.ErrorIs(t, err, ErrorNoSuchConfig)
+
+	assert.NoError(t, os.WriteFile(path.Join(dir, "syn2020.json"), []byte(`{"corpus": "syn2020"}`), 0644))
+	p1, err := lcache.Backup("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(dir, "backup", "syn2020.1.json"), p1)
+	p2, err := lcache.Backup("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(dir, "backup", "syn2020.2.json"), p2)
+	data, err := os.ReadFile(p2)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"corpus": "syn2020"}`, string(data))
+}
+
+func TestListVersions(t *testing.T) {
+	dir := t.TempDir()
+	lcache := &LiveAttrsBuildConfProvider{confDirPath: dir}
+	versions, err := lcache.ListVersions("syn2020")
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+
+	assert.NoError(t, os.MkdirAll(path.Join(dir, "backup"), 0755))
+	for _, name := range []string{"syn2020.10.json", "syn2020.2.json", "syn2020_x.1.json", "syn2020.tmp"} {
+		assert.NoError(t, os.WriteFile(path.Join(dir, "backup", name), []byte("{}"), 0644))
+	}
+	versions, err = lcache.ListVersions("syn2020")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, 10, versions[1].Version)
+	assert.False(t, versions[0].Created.IsZero())
+}
+
+func TestClearBacksUpAuxConfs(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, nil)
+	assert.NoError(t, os.WriteFile(path.Join(dir, "syn2020.json"), []byte(`{"corpus": "syn2020"}`), 0644))
+	assert.NoError(t, os.WriteFile(path.Join(dir, "syn2020.attrTypes.json"), []byte(`{"doc.year": "int"}`), 0644))
+	assert.NoError(t, lcache.Clear("syn2020"))
+	_, err := os.Stat(path.Join(dir, "syn2020.attrTypes.json"))
+	assert.True(t, os.IsNotExist(err))
+	data, err := os.ReadFile(path.Join(dir, "backup", "syn2020.1.attrTypes.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"doc.year": "int"}`, string(data))
+	versions, err := lcache.ListVersions("syn2020")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	// auxiliary files without a main config get a new version
+	assert.NoError(t, os.WriteFile(path.Join(dir, "syn2020.speakers.json"), []byte(`{}`), 0644))
+	assert.NoError(t, lcache.Clear("syn2020"))
+	_, err = os.Stat(path.Join(dir, "backup", "syn2020.2.speakers.json"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"masm/v3/corpus"
+
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+)
+
+// BibViewSource specifies where bibliography attributes
+// of a data extraction configuration come from
+type BibViewSource string
+
+const (
+	// BibViewSourceRequest means the attributes were specified
+	// explicitly by the `bibView` argument
+	BibViewSourceRequest BibViewSource = "request"
+
+	// BibViewSourceCNCDB means the attributes were inferred from
+	// the corpus' bib_id_* and bib_label_* columns in the CNC database
+	BibViewSourceCNCDB BibViewSource = "cncdb"
+)
+
+// BibViewProvenance records the origin of bibliography attributes
+// of a generated configuration. It is stored along with the config
+// by LiveAttrsBuildConfProvider. Attributes use dot notation
+// (e.g. "doc.id").
+type BibViewProvenance struct {
+	IDAttr    string        `json:"idAttr"`
+	LabelAttr string        `json:"labelAttr,omitempty"`
+	Source    BibViewSource `json:"source"`
+}
+
+// InferBibView fills in bibliography attributes in case they are not
+// specified in jsonArgs and the CNC database defines them for the corpus.
+// Provenance of the resulting attributes is returned (nil in case there
+// are no bibliography attributes at all).
+func InferBibView(jsonArgs *PatchArgs, corpusDBInfo *corpus.DBInfo) *BibViewProvenance {
+	if jsonArgs.BibView != nil {
+		if jsonArgs.BibView.IDAttr == "" {
+			return nil
+		}
+		return &BibViewProvenance{
+			IDAttr: jsonArgs.BibView.IDAttr,
+			Source: BibViewSourceRequest,
+		}
+	}
+	if corpusDBInfo.BibIDAttr == "" {
+		return nil
+	}
+	jsonArgs.BibView = &vtedb.BibViewConf{IDAttr: corpusDBInfo.BibIDAttr}
+	return &BibViewProvenance{
+		IDAttr:    corpusDBInfo.BibIDAttr,
+		LabelAttr: corpusDBInfo.BibLabelAttr,
+		Source:    BibViewSourceCNCDB,
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"masm/v3/corpus"
+	"testing"
+
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferBibViewFromCNCDB(t *testing.T) {
+	args := PatchArgs{}
+	dbInfo := corpus.DBInfo{BibIDAttr: "doc.id", BibLabelAttr: "doc.title"}
+	prov := InferBibView(&args, &dbInfo)
+	assert.Equal(t, &BibViewProvenance{IDAttr: "doc.id", LabelAttr: "doc.title", Source: BibViewSourceCNCDB}, prov)
+	assert.Equal(t, "doc.id", args.BibView.IDAttr)
+}
+
+func TestInferBibViewExplicit(t *testing.T) {
+	args := PatchArgs{BibView: &vtedb.BibViewConf{IDAttr: "opus.id"}}
+	dbInfo := corpus.DBInfo{BibIDAttr: "doc.id"}
+	prov := InferBibView(&args, &dbInfo)
+	assert.Equal(t, &BibViewProvenance{IDAttr: "opus.id", Source: BibViewSourceRequest}, prov)
+	assert.Equal(t, "opus.id", args.BibView.IDAttr)
+}
+
+func TestInferBibViewNothing(t *testing.T) {
+	args := PatchArgs{}
+	assert.Nil(t, InferBibView(&args, &corpus.DBInfo{}))
+	assert.Nil(t, args.BibView)
+
+	args = PatchArgs{BibView: &vtedb.BibViewConf{}}
+	assert.Nil(t, InferBibView(&args, &corpus.DBInfo{BibIDAttr: "doc.id"}))
+	assert.Equal(t, "", args.BibView.IDAttr)
+}
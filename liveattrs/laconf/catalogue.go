@@ -0,0 +1,161 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"masm/v3/liveattrs/utils"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/rs/zerolog/log"
+)
+
+// ConfSummary contains key properties of a stored config
+type ConfSummary struct {
+	Corpus         string     `json:"corpus"`
+	ParallelCorpus string     `json:"parallelCorpus,omitempty"`
+	AtomStructure  string     `json:"atomStructure"`
+	BibIDAttr      string     `json:"bibIdAttr"`
+	Verticals      []string   `json:"verticals"`
+	LastBuild      *time.Time `json:"lastBuild"`
+}
+
+// NewConfSummary creates a summary of a config. The bibliography
+// ID attribute is exported to the `struct.attr` form.
+func NewConfSummary(conf *vteconf.VTEConf) *ConfSummary {
+	ans := &ConfSummary{
+		Corpus:         conf.Corpus,
+		ParallelCorpus: conf.ParallelCorpus,
+		AtomStructure:  conf.AtomStructure,
+		Verticals:      conf.GetDefinedVerticals(),
+	}
+	if conf.BibView.IDAttr != "" {
+		ans.BibIDAttr = exportAttr(conf.BibView.IDAttr)
+	}
+	return ans
+}
+
+func exportAttr(attr string) string {
+	if strings.Contains(attr, ".") {
+		return attr
+	}
+	return utils.ExportKey(attr)
+}
+
+// ConfFilter specifies which config summaries are listed.
+// Empty fields match anything.
+type ConfFilter struct {
+
+	// Corpus matches a substring of a corpus name
+	Corpus string
+
+	ParallelCorpus string
+	AtomStructure  string
+
+	// BibIDAttr can be specified both in the `struct.attr`
+	// and in the `struct_attr` form
+	BibIDAttr string
+
+	// Vertical matches a substring of any of vertical file paths
+	Vertical string
+}
+
+// Matches tests whether a summary matches the filter
+func (f ConfFilter) Matches(summary *ConfSummary) bool {
+	if f.Corpus != "" && !strings.Contains(summary.Corpus, f.Corpus) {
+		return false
+	}
+	if f.ParallelCorpus != "" && summary.ParallelCorpus != f.ParallelCorpus {
+		return false
+	}
+	if f.AtomStructure != "" && summary.AtomStructure != f.AtomStructure {
+		return false
+	}
+	if f.BibIDAttr != "" && summary.BibIDAttr != exportAttr(f.BibIDAttr) {
+		return false
+	}
+	if f.Vertical != "" {
+		var found bool
+		for _, v := range summary.Verticals {
+			if strings.Contains(v, f.Vertical) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// storedCorpora returns names of corpora with a config
+// file stored in the config directory (sorted)
+func (lcache *LiveAttrsBuildConfProvider) storedCorpora() ([]string, error) {
+	entries, err := os.ReadDir(lcache.confDirPath)
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		var isAux bool
+		for _, suff := range auxConfSuffixes {
+			if strings.HasSuffix(name, suff) {
+				isAux = true
+				break
+			}
+		}
+		if !isAux {
+			ans = append(ans, name)
+		}
+	}
+	sort.Strings(ans)
+	return ans, nil
+}
+
+// ListSummaries returns summaries of all the stored configs
+// matching a filter. Configs which cannot be loaded are skipped
+// (and logged). The LastBuild property is not filled in
+// as it is not known to the provider.
+func (lcache *LiveAttrsBuildConfProvider) ListSummaries(filter ConfFilter) ([]*ConfSummary, error) {
+	corpora, err := lcache.storedCorpora()
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]*ConfSummary, 0, len(corpora))
+	for _, corpusID := range corpora {
+		conf, err := lcache.loadFromFile(corpusID, false)
+		if err != nil {
+			log.Warn().Err(err).Str("corpusId", corpusID).Msg("skipping unreadable liveattrs config")
+			continue
+		}
+		summary := NewConfSummary(conf)
+		if filter.Matches(summary) {
+			ans = append(ans, summary)
+		}
+	}
+	return ans, nil
+}
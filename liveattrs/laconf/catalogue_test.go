@@ -0,0 +1,72 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfFilterMatches(t *testing.T) {
+	summary := &ConfSummary{
+		Corpus:         "intercorp_v16_cs",
+		ParallelCorpus: "intercorp_v16",
+		AtomStructure:  "p",
+		BibIDAttr:      "doc.id",
+		Verticals:      []string{"/data/vert/intercorp_v16_cs.vert"},
+	}
+	assert.True(t, ConfFilter{}.Matches(summary))
+	assert.True(t, ConfFilter{Corpus: "v16"}.Matches(summary))
+	assert.True(t, ConfFilter{BibIDAttr: "doc_id"}.Matches(summary))
+	assert.True(t, ConfFilter{BibIDAttr: "doc.id", AtomStructure: "p"}.Matches(summary))
+	assert.True(t, ConfFilter{Vertical: "/data/vert"}.Matches(summary))
+	assert.False(t, ConfFilter{AtomStructure: "doc"}.Matches(summary))
+	assert.False(t, ConfFilter{ParallelCorpus: "intercorp"}.Matches(summary))
+	assert.False(t, ConfFilter{Vertical: "syn"}.Matches(summary))
+}
+
+func TestStoredCorpora(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"syn2020.json", "susanne.json", "susanne.attrTypes.json",
+		"susanne.bibView.json", "susanne.speakers.json",
+		"susanne.timing.json", "notes.txt",
+	} {
+		assert.NoError(t, os.WriteFile(path.Join(dir, name), []byte("{}"), 0644))
+	}
+	assert.NoError(t, os.Mkdir(path.Join(dir, "backup"), 0755))
+	lcache := NewLiveAttrsBuildConfProvider(dir, nil)
+	corpora, err := lcache.storedCorpora()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"susanne", "syn2020"}, corpora)
+}
+
+func TestStoredCorporaIgnoresAllAuxConfs(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, nil)
+	for _, confPath := range append(lcache.auxConfPaths("oral2013"), lcache.confPath("oral2013")) {
+		assert.NoError(t, os.WriteFile(confPath, []byte("{}"), 0644))
+	}
+	corpora, err := lcache.storedCorpora()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"oral2013"}, corpora)
+}
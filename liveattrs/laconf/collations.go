@@ -0,0 +1,52 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import "fmt"
+
+// ColumnCollations maps structural attributes (in dot notation,
+// e.g. "doc.author") to collations of respective liveattrs table
+// columns (e.g. "utf8mb4_czech_ci", "utf8mb4_bin"). As the collation
+// is part of a column definition, MySQL respects it in all comparisons
+// including ORDER BY, GROUP BY and DISTINCT. Attributes not present in
+// the map keep the collation of the table.
+type ColumnCollations map[string]string
+
+// Get returns a collation of a specified attribute
+// (empty string in case nothing is configured)
+func (cc ColumnCollations) Get(attr string) string {
+	return cc[attr]
+}
+
+// Validate tests whether all the attributes are known structural
+// attributes. Please note that collations are inserted directly
+// into SQL statements so they must be valid identifiers. Whether
+// a collation is compatible with the table's character set is
+// checked by the database once the collation is applied.
+func (cc ColumnCollations) Validate(structures map[string][]string) error {
+	for attr, collation := range cc {
+		if !collationRegexp.MatchString(collation) {
+			return fmt.Errorf("invalid collation of %s: %s", attr, collation)
+		}
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot set collation of unknown attribute %s", attr)
+		}
+	}
+	return nil
+}
@@ -34,6 +34,25 @@ func GetSubcorpAttrs(vteConf *vteconf.VTEConf) []string {
 	return ans
 }
 
+// WithoutSubcorpAttrs returns a copy of structures of a config
+// with the `attrs` (in the `doc.title` form) removed
+func WithoutSubcorpAttrs(vteConf *vteconf.VTEConf, attrs []string) map[string][]string {
+	removed := make(map[string]bool)
+	for _, attr := range attrs {
+		removed[attr] = true
+	}
+	ans := make(map[string][]string)
+	for strct, sAttrs := range vteConf.Structures {
+		ans[strct] = make([]string, 0, len(sAttrs))
+		for _, attr := range sAttrs {
+			if !removed[fmt.Sprintf("%s.%s", strct, attr)] {
+				ans[strct] = append(ans[strct], attr)
+			}
+		}
+	}
+	return ans
+}
+
 func LoadConf(path string) (*vteconf.VTEConf, error) {
 	return vteconf.LoadConf(path)
 }
@@ -0,0 +1,199 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+	"sort"
+	"strings"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+)
+
+const (
+	CheckVertical            = "vertical"
+	CheckRegistry            = "registry"
+	CheckAtomStructure       = "atomStructure"
+	CheckAtomParentStructure = "atomParentStructure"
+	CheckStructures          = "structures"
+	CheckBibView             = "bibView"
+	CheckSelfJoin            = "selfJoin"
+	CheckIndexedCols         = "indexedCols"
+	CheckNgrams              = "ngrams"
+	CheckDB                  = "db"
+)
+
+// ConfDiagnostic is a result of a single check of a data extraction
+// configuration
+type ConfDiagnostic struct {
+	Check   string `json:"check"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// ConfDiagnostics is a list of results of configuration checks
+type ConfDiagnostics []ConfDiagnostic
+
+// Add appends a result of a check. A nil error means the check passed.
+func (cd *ConfDiagnostics) Add(check string, err error) {
+	item := ConfDiagnostic{Check: check, OK: err == nil}
+	if err != nil {
+		item.Message = err.Error()
+	}
+	*cd = append(*cd, item)
+}
+
+// IsValid returns true if all the checks passed
+func (cd ConfDiagnostics) IsValid() bool {
+	for _, item := range cd {
+		if !item.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistryInfo contains properties of an actual corpus registry
+// a configuration is checked against
+type RegistryInfo struct {
+
+	// Structs contains names of indexed structures
+	Structs []string
+
+	// StructAttrs maps structure names to their attributes
+	StructAttrs map[string][]string
+
+	// PosAttrs contains names of positional attributes
+	PosAttrs []string
+}
+
+// configuredColumns returns names of DB columns (e.g. "doc_title")
+// a configuration creates for structural attributes
+func configuredColumns(conf *vteconf.VTEConf) []string {
+	ans := make([]string, 0, 20)
+	for strct, attrs := range conf.Structures {
+		for _, attr := range attrs {
+			ans = append(ans, strct+"_"+attr)
+		}
+	}
+	return ans
+}
+
+// unknownColumns returns (sorted) columns not present in `known`
+func unknownColumns(cols []string, known []string) []string {
+	ans := make([]string, 0, len(cols))
+	for _, col := range cols {
+		if !collections.SliceContains(known, col) {
+			ans = append(ans, col)
+		}
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func checkAtomStructure(conf *vteconf.VTEConf, reg RegistryInfo) error {
+	if conf.AtomStructure == "" {
+		return fmt.Errorf("atom structure not specified")
+	}
+	if !collections.SliceContains(reg.Structs, conf.AtomStructure) {
+		return fmt.Errorf("atom structure %s does not exist in corpus", conf.AtomStructure)
+	}
+	return nil
+}
+
+func checkAtomParentStructure(conf *vteconf.VTEConf, reg RegistryInfo) error {
+	if conf.AtomParentStructure != "" &&
+		!collections.SliceContains(reg.Structs, conf.AtomParentStructure) {
+		return fmt.Errorf(
+			"atom parent structure %s does not exist in corpus", conf.AtomParentStructure)
+	}
+	return nil
+}
+
+func checkStructures(conf *vteconf.VTEConf, reg RegistryInfo) error {
+	unknown := make([]string, 0, 10)
+	for strct, attrs := range conf.Structures {
+		for _, attr := range attrs {
+			if !collections.SliceContains(reg.StructAttrs[strct], attr) {
+				unknown = append(unknown, strct+"."+attr)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown structural attributes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func checkBibView(conf *vteconf.VTEConf, columns []string) error {
+	if conf.BibView.IDAttr == "" && len(conf.BibView.Cols) == 0 {
+		return nil
+	}
+	cols := append([]string{conf.BibView.IDAttr}, conf.BibView.Cols...)
+	if unknown := unknownColumns(cols, columns); len(unknown) > 0 {
+		return fmt.Errorf(
+			"bib. view refers to unconfigured columns: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func checkSelfJoin(conf *vteconf.VTEConf, columns []string) error {
+	if unknown := unknownColumns(conf.SelfJoin.ArgColumns, columns); len(unknown) > 0 {
+		return fmt.Errorf(
+			"self join refers to unconfigured columns: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func checkIndexedCols(conf *vteconf.VTEConf, columns []string) error {
+	if unknown := unknownColumns(conf.IndexedCols, columns); len(unknown) > 0 {
+		return fmt.Errorf(
+			"indexed columns refer to unconfigured columns: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func checkNgrams(conf *vteconf.VTEConf, reg RegistryInfo) error {
+	for _, col := range conf.Ngrams.VertColumns {
+		if col.Idx < 0 || col.Idx >= len(reg.PosAttrs) {
+			return fmt.Errorf(
+				"n-gram column index %d out of range (corpus has %d positional attributes)",
+				col.Idx, len(reg.PosAttrs),
+			)
+		}
+	}
+	return nil
+}
+
+// DiagnoseAgainstRegistry checks whether structures, attributes and column
+// mappings of a configuration match an actual corpus registry.
+func DiagnoseAgainstRegistry(conf *vteconf.VTEConf, reg RegistryInfo) ConfDiagnostics {
+	columns := configuredColumns(conf)
+	ans := make(ConfDiagnostics, 0, 7)
+	ans.Add(CheckAtomStructure, checkAtomStructure(conf, reg))
+	ans.Add(CheckAtomParentStructure, checkAtomParentStructure(conf, reg))
+	ans.Add(CheckStructures, checkStructures(conf, reg))
+	ans.Add(CheckBibView, checkBibView(conf, columns))
+	ans.Add(CheckSelfJoin, checkSelfJoin(conf, columns))
+	ans.Add(CheckIndexedCols, checkIndexedCols(conf, columns))
+	ans.Add(CheckNgrams, checkNgrams(conf, reg))
+	return ans
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingRegistryInfo() RegistryInfo {
+	return RegistryInfo{
+		Structs: []string{"doc", "p", "s"},
+		StructAttrs: map[string][]string{
+			"doc": {"id", "title", "author"},
+			"p":   {"id"},
+		},
+		PosAttrs: []string{"word", "lemma", "tag"},
+	}
+}
+
+func findDiagnostic(diagnostics ConfDiagnostics, check string) ConfDiagnostic {
+	for _, item := range diagnostics {
+		if item.Check == check {
+			return item
+		}
+	}
+	return ConfDiagnostic{}
+}
+
+func TestDiagnoseAgainstRegistryValid(t *testing.T) {
+	conf := &vteconf.VTEConf{
+		AtomStructure: "doc",
+		Structures:    map[string][]string{"doc": {"id", "title"}},
+		BibView:       vtedb.BibViewConf{IDAttr: "doc_id", Cols: []string{"doc_id", "doc_title"}},
+	}
+	conf.Ngrams.VertColumns = vtedb.VertColumns{vtedb.VertColumn{Idx: 0}, vtedb.VertColumn{Idx: 2}}
+	diagnostics := DiagnoseAgainstRegistry(conf, createTestingRegistryInfo())
+	assert.True(t, diagnostics.IsValid())
+}
+
+func TestDiagnoseAgainstRegistryInvalid(t *testing.T) {
+	conf := &vteconf.VTEConf{
+		AtomStructure: "text",
+		Structures:    map[string][]string{"doc": {"id", "genre"}},
+		BibView:       vtedb.BibViewConf{IDAttr: "doc_id", Cols: []string{"doc_author"}},
+	}
+	conf.SelfJoin.ArgColumns = []string{"doc_id"}
+	conf.Ngrams.VertColumns = vtedb.VertColumns{vtedb.VertColumn{Idx: 3}}
+	diagnostics := DiagnoseAgainstRegistry(conf, createTestingRegistryInfo())
+	assert.False(t, diagnostics.IsValid())
+	assert.False(t, findDiagnostic(diagnostics, CheckAtomStructure).OK)
+	assert.True(t, findDiagnostic(diagnostics, CheckAtomParentStructure).OK)
+	assert.Equal(
+		t,
+		"unknown structural attributes: doc.genre",
+		findDiagnostic(diagnostics, CheckStructures).Message,
+	)
+	assert.Equal(
+		t,
+		"bib. view refers to unconfigured columns: doc_author",
+		findDiagnostic(diagnostics, CheckBibView).Message,
+	)
+	assert.True(t, findDiagnostic(diagnostics, CheckSelfJoin).OK)
+	assert.False(t, findDiagnostic(diagnostics, CheckNgrams).OK)
+}
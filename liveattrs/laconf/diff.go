@@ -0,0 +1,113 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ConfDiffItem describes a difference between two configurations.
+// Path is a JSON path of the differing value (e.g. "structures.doc[1]").
+// A missing value is represented by nil.
+type ConfDiffItem struct {
+	Path   string `json:"path"`
+	Cached any    `json:"cached"`
+	Fresh  any    `json:"fresh"`
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func diffValues(path string, cached, fresh any, ans *[]ConfDiffItem) {
+	switch tCached := cached.(type) {
+	case map[string]any:
+		tFresh, ok := fresh.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(tCached)+len(tFresh))
+		for k := range tCached {
+			keys = append(keys, k)
+		}
+		for k := range tFresh {
+			if _, ok := tCached[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValues(joinDiffPath(path, k), tCached[k], tFresh[k], ans)
+		}
+		return
+	case []any:
+		tFresh, ok := fresh.([]any)
+		if !ok {
+			break
+		}
+		size := len(tCached)
+		if len(tFresh) > size {
+			size = len(tFresh)
+		}
+		for i := 0; i < size; i++ {
+			var v1, v2 any
+			if i < len(tCached) {
+				v1 = tCached[i]
+			}
+			if i < len(tFresh) {
+				v2 = tFresh[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), v1, v2, ans)
+		}
+		return
+	}
+	if !reflect.DeepEqual(cached, fresh) {
+		*ans = append(*ans, ConfDiffItem{Path: path, Cached: cached, Fresh: fresh})
+	}
+}
+
+// DiffConfs compares JSON representations of two configurations
+// and returns a list of differing values sorted by their paths.
+// Please note that orders of list items matter.
+func DiffConfs(cached, fresh any) ([]ConfDiffItem, error) {
+	var cachedData, freshData any
+	rawCached, err := json.Marshal(cached)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare confs: %w", err)
+	}
+	if err := json.Unmarshal(rawCached, &cachedData); err != nil {
+		return nil, fmt.Errorf("failed to compare confs: %w", err)
+	}
+	rawFresh, err := json.Marshal(fresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare confs: %w", err)
+	}
+	if err := json.Unmarshal(rawFresh, &freshData); err != nil {
+		return nil, fmt.Errorf("failed to compare confs: %w", err)
+	}
+	ans := make([]ConfDiffItem, 0, 10)
+	diffValues("", cachedData, freshData, &ans)
+	return ans, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfsEqual(t *testing.T) {
+	conf := map[string]any{
+		"corpus":     "syn2020",
+		"structures": map[string][]string{"doc": {"id", "title"}},
+	}
+	diff, err := DiffConfs(conf, conf)
+	assert.NoError(t, err)
+	assert.Len(t, diff, 0)
+}
+
+func TestDiffConfs(t *testing.T) {
+	cached := map[string]any{
+		"corpus":        "syn2020",
+		"atomStructure": "doc",
+		"structures":    map[string][]string{"doc": {"id", "title"}},
+		"maxNumErrors":  100,
+	}
+	fresh := map[string]any{
+		"corpus":        "syn2020",
+		"atomStructure": "text",
+		"structures":    map[string][]string{"doc": {"id", "title", "author"}, "p": {"id"}},
+	}
+	diff, err := DiffConfs(cached, fresh)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]ConfDiffItem{
+			{Path: "atomStructure", Cached: "doc", Fresh: "text"},
+			{Path: "maxNumErrors", Cached: float64(100), Fresh: nil},
+			{Path: "structures.doc[2]", Cached: nil, Fresh: "author"},
+			{Path: "structures.p", Cached: nil, Fresh: []any{"id"}},
+		},
+		diff,
+	)
+}
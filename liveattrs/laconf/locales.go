@@ -0,0 +1,61 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	// LocaleBinary disables locale-aware sorting of attribute values
+	LocaleBinary = "binary"
+)
+
+// AttrLocales maps structural attributes (in dot notation, e.g. "doc.author")
+// to locales used for sorting their values (e.g. "cs_CZ"). The special
+// value LocaleBinary means byte-wise sorting (suitable e.g. for ISO codes).
+// Attributes not present in the map are sorted using the corpus locale.
+type AttrLocales map[string]string
+
+// Get returns a locale of a specified attribute or `dflt`
+// in case nothing is configured
+func (al AttrLocales) Get(attr, dflt string) string {
+	if v, ok := al[attr]; ok {
+		return v
+	}
+	return dflt
+}
+
+// Validate tests whether all the attributes are known structural
+// attributes and whether all the locales are valid.
+func (al AttrLocales) Validate(structures map[string][]string) error {
+	for attr, locale := range al {
+		if locale != LocaleBinary {
+			if _, err := language.Parse(locale); err != nil {
+				return fmt.Errorf("invalid locale of %s: %s", attr, locale)
+			}
+		}
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot set locale of unknown attribute %s", attr)
+		}
+	}
+	return nil
+}
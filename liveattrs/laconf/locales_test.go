@@ -0,0 +1,41 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrLocalesValidate(t *testing.T) {
+	structures := map[string][]string{"doc": {"author", "lang"}}
+	assert.NoError(
+		t,
+		AttrLocales{"doc.author": "cs_CZ", "doc.lang": LocaleBinary}.Validate(structures),
+	)
+	assert.Error(t, AttrLocales{"doc.title": "cs_CZ"}.Validate(structures))
+	assert.Error(t, AttrLocales{"doc.author": "not a locale"}.Validate(structures))
+}
+
+func TestAttrLocalesGet(t *testing.T) {
+	locales := AttrLocales{"doc.lang": LocaleBinary}
+	assert.Equal(t, LocaleBinary, locales.Get("doc.lang", "cs_CZ"))
+	assert.Equal(t, "cs_CZ", locales.Get("doc.author", "cs_CZ"))
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import "fmt"
+
+// MultiValueSeparators maps structural attributes (in dot notation,
+// e.g. "doc.author") containing multiple values to separators of
+// the values (typically "|"). For such attributes, each value is
+// stored in its own row of the liveattrs table.
+type MultiValueSeparators map[string]string
+
+// Get returns a separator of a specified attribute
+// (empty string in case the attribute is single-valued)
+func (mvs MultiValueSeparators) Get(attr string) string {
+	return mvs[attr]
+}
+
+// Validate tests whether all the attributes are known structural
+// attributes and whether all the separators are non-empty.
+func (mvs MultiValueSeparators) Validate(structures map[string][]string) error {
+	for attr, sep := range mvs {
+		if sep == "" {
+			return fmt.Errorf("empty multi-value separator of %s", attr)
+		}
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot set multi-value separator of unknown attribute %s", attr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// NumberFormat describes how numbers are written in a locale
+type NumberFormat struct {
+	DecimalSep rune
+
+	// GroupSep is a digit grouping separator (e.g. "1,234" in English).
+	// Zero value means no grouping is recognized.
+	GroupSep rune
+}
+
+// DefaultNumberFormat is used for the binary (and unknown) locale
+var DefaultNumberFormat = NumberFormat{DecimalSep: '.'}
+
+// NumberFormatOf returns a number format of a locale (e.g. "cs_CZ").
+// The separators are obtained by formatting a sample number
+// (1234.5) using locale-specific rules.
+func NumberFormatOf(locale string) NumberFormat {
+	if locale == "" || locale == LocaleBinary {
+		return DefaultNumberFormat
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return DefaultNumberFormat
+	}
+	sample := []rune(message.NewPrinter(tag).Sprintf("%.1f", 1234.5))
+	// expected form: 1[group]234[decimal]5
+	if len(sample) < 6 || sample[0] != '1' || sample[len(sample)-1] != '5' {
+		return DefaultNumberFormat
+	}
+	ans := NumberFormat{DecimalSep: sample[len(sample)-2]}
+	if len(sample) == 7 {
+		ans.GroupSep = sample[1]
+	}
+	return ans
+}
+
+func (nf NumberFormat) isGroupSep(r rune) bool {
+	if nf.GroupSep == 0 {
+		return false
+	}
+	if unicode.IsSpace(nf.GroupSep) {
+		// values typed by humans typically use a plain space
+		// instead of a non-breaking one
+		return unicode.IsSpace(r)
+	}
+	return r == nf.GroupSep
+}
+
+// Parse parses a number written according to the format.
+// Only plain decimal notation is accepted (i.e. no exponents,
+// no special values like "NaN"). Digit groups (if used) must
+// have exactly three digits.
+func (nf NumberFormat) Parse(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	var buff strings.Builder
+	buff.Grow(len(value))
+	numDigits := 0
+	hasDecimal := false
+	lastSep := false
+	// groupDigits is the number of digits since the last group
+	// separator (-1 if there is no separator)
+	groupDigits := -1
+	for i, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			buff.WriteRune(r)
+			numDigits++
+			lastSep = false
+			if groupDigits >= 0 && !hasDecimal {
+				groupDigits++
+			}
+		case r == '-' && i == 0:
+			buff.WriteRune(r)
+		case r == nf.DecimalSep && !hasDecimal && numDigits > 0 && !lastSep:
+			if groupDigits >= 0 && groupDigits != 3 {
+				return 0, false
+			}
+			buff.WriteRune('.')
+			hasDecimal = true
+			lastSep = true
+		case nf.isGroupSep(r) && !hasDecimal && numDigits > 0 && !lastSep:
+			if groupDigits >= 0 && groupDigits != 3 || groupDigits < 0 && numDigits > 3 {
+				return 0, false
+			}
+			groupDigits = 0
+			lastSep = true
+		default:
+			return 0, false
+		}
+	}
+	if numDigits == 0 || lastSep || (!hasDecimal && groupDigits >= 0 && groupDigits != 3) {
+		return 0, false
+	}
+	ans, err := strconv.ParseFloat(buff.String(), 64)
+	return ans, err == nil
+}
+
+// IsNumericValues tests whether all the non-empty values are numbers
+// according to the format. At least one non-empty value is required.
+func (nf NumberFormat) IsNumericValues(values []string) bool {
+	numNonEmpty := 0
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if _, ok := nf.Parse(v); !ok {
+			return false
+		}
+		numNonEmpty++
+	}
+	return numNonEmpty > 0
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberFormatOf(t *testing.T) {
+	assert.Equal(t, NumberFormat{DecimalSep: ',', GroupSep: '\u00a0'}, NumberFormatOf("cs_CZ"))
+	assert.Equal(t, NumberFormat{DecimalSep: '.', GroupSep: ','}, NumberFormatOf("en_US"))
+	assert.Equal(t, NumberFormat{DecimalSep: ',', GroupSep: '.'}, NumberFormatOf("de_DE"))
+	assert.Equal(t, DefaultNumberFormat, NumberFormatOf(LocaleBinary))
+	assert.Equal(t, DefaultNumberFormat, NumberFormatOf(""))
+}
+
+func TestNumberFormatParse(t *testing.T) {
+	cs := NumberFormatOf("cs_CZ")
+	v, ok := cs.Parse("1 999,5")
+	assert.True(t, ok)
+	assert.Equal(t, 1999.5, v)
+	v, ok = cs.Parse("-20")
+	assert.True(t, ok)
+	assert.Equal(t, -20.0, v)
+	_, ok = cs.Parse("1.5")
+	assert.False(t, ok)
+
+	en := NumberFormatOf("en_US")
+	v, ok = en.Parse("1,234,567.25")
+	assert.True(t, ok)
+	assert.Equal(t, 1234567.25, v)
+	// digit groups must have three digits
+	_, ok = en.Parse("12,5")
+	assert.False(t, ok)
+	_, ok = en.Parse("1234,567")
+	assert.False(t, ok)
+}
+
+func TestNumberFormatParseInvalid(t *testing.T) {
+	for _, v := range []string{"", "-", "1e5", "NaN", "Inf", "12a", "1..2", "1.", ".5", "--1", "1-"} {
+		_, ok := DefaultNumberFormat.Parse(v)
+		assert.False(t, ok, v)
+	}
+}
+
+func TestIsNumericValues(t *testing.T) {
+	assert.True(t, DefaultNumberFormat.IsNumericValues([]string{"1999", "", "20"}))
+	assert.False(t, DefaultNumberFormat.IsNumericValues([]string{"1999", "unknown"}))
+	assert.False(t, DefaultNumberFormat.IsNumericValues([]string{"", ""}))
+}
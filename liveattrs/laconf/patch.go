@@ -45,6 +45,41 @@ type PatchArgs struct {
 	SelfJoin      *vteDb.SelfJoinConf `json:"selfJoin"`
 	BibView       *vteDb.BibViewConf  `json:"bibView"`
 	Ngrams        *vteCnf.NgramConf   `json:"ngrams"`
+
+	// The following auxiliary configs are not part of VTEConf.
+	// They are stored along with the config by LiveAttrsBuildConfProvider.
+
+	// AttrTypes specifies types of typed (e.g. numeric) attributes
+	AttrTypes AttrTypes `json:"attrTypes"`
+
+	// Autocomplete specifies matching of values in autocomplete queries
+	Autocomplete *AutocompleteConf `json:"autocomplete"`
+
+	// Collations specifies MySQL collations of attribute columns
+	Collations ColumnCollations `json:"collations"`
+
+	// MultiValues specifies separators of multi-value attributes
+	MultiValues MultiValueSeparators `json:"multiValues"`
+
+	// Locales specifies locales used for sorting attribute values
+	Locales AttrLocales `json:"locales"`
+
+	// ValueFilters specifies filters of attribute values in responses
+	ValueFilters ValueFilters `json:"valueFilters"`
+
+	// ValueOrders specifies explicit orders of attribute values
+	ValueOrders ValueOrders `json:"valueOrders"`
+
+	// Speakers specifies extraction of speaker attributes
+	Speakers *SpeakerConf `json:"speakers"`
+
+	// Timing specifies extraction of segment durations
+	Timing *TimingConf `json:"timing"`
+
+	// BibViewProvenance cannot be set by users. It is filled in
+	// when a config is created or patched (see InferBibView)
+	// and stored along with the config by LiveAttrsBuildConfProvider.
+	BibViewProvenance *BibViewProvenance `json:"-"`
 }
 
 func (la *PatchArgs) GetVerticalFiles() []string {
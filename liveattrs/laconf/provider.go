@@ -29,6 +29,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 
@@ -49,6 +50,7 @@ func Create(
 	corpusInfo *corpus.Info,
 	corpusDBInfo *corpus.DBInfo,
 	jsonArgs *PatchArgs,
+	coverage StructCoverageFn,
 ) (*vteconf.VTEConf, error) {
 	maxNumErr := conf.VertMaxNumErrors
 	if jsonArgs.MaxNumErrors != nil {
@@ -85,18 +87,32 @@ func Create(
 		} else {
 			newConf.Structures[bibIdElms[0]] = []string{bibIdElms[1]}
 		}
+		// label of bibliography items must be extracted too
+		bibLabelElms := strings.Split(corpusDBInfo.BibLabelAttr, ".")
+		if len(bibLabelElms) == 2 {
+			labelCol := fmt.Sprintf("%s_%s", bibLabelElms[0], bibLabelElms[1])
+			if !collections.SliceContains(newConf.BibView.Cols, labelCol) {
+				newConf.BibView.Cols = append(newConf.BibView.Cols, labelCol)
+			}
+			if !collections.SliceContains(newConf.Structures[bibLabelElms[0]], bibLabelElms[1]) {
+				newConf.Structures[bibLabelElms[0]] = append(newConf.Structures[bibLabelElms[0]], bibLabelElms[1])
+			}
+		}
 	}
 	if jsonArgs.AtomStructure == nil {
-		if len(newConf.Structures) == 1 {
-			for k := range newConf.Structures {
-				newConf.AtomStructure = k
-				break
-			}
-			log.Info().Msgf("no atomStructure, inferred value: %s", newConf.AtomStructure)
+		atom, err := InferAtomStructure(
+			SortedStructNames(newConf.Structures), conf.AtomInference, coverage)
+		if err == ErrorAtomNotInferable {
+			return nil, fmt.Errorf(
+				"no atomStructure specified and the value cannot be inferred (strategy: %s)",
+				conf.AtomInference.Strategy,
+			)
 
-		} else {
-			return nil, fmt.Errorf("no atomStructure specified and the value cannot be inferred due to multiple involved structures")
+		} else if err != nil {
+			return nil, err
 		}
+		newConf.AtomStructure = atom
+		log.Info().Msgf("no atomStructure, inferred value: %s", newConf.AtomStructure)
 
 	} else {
 		newConf.AtomStructure = jsonArgs.GetAtomStructure()
@@ -168,9 +184,28 @@ func Create(
 // So at least in theory - the stored vte config files should not
 // deprecate.
 type LiveAttrsBuildConfProvider struct {
-	confDirPath  string
-	globalDBConf *vtedb.Conf
-	data         map[string]*vteconf.VTEConf
+	confDirPath   string
+	globalDBConf  *vtedb.Conf
+	data          map[string]*vteconf.VTEConf
+	attrTypes     *auxConf[AttrTypes]
+	detectedTypes *auxConf[AttrTypes]
+	autocomplete  *auxConf[AutocompleteConf]
+	collations    *auxConf[ColumnCollations]
+	multiValues   *auxConf[MultiValueSeparators]
+	locales       *auxConf[AttrLocales]
+	valueFilters  *auxConf[ValueFilters]
+	valueOrders   *auxConf[ValueOrders]
+	valueMerges   *auxConf[ValueMerges]
+	bibView       *auxConf[*BibViewProvenance]
+	speakers      *auxConf[*SpeakerConf]
+	timing        *auxConf[*TimingConf]
+
+	// mu guards data as the provider is accessed concurrently
+	// by HTTP actions (auxiliary configs have their own locks)
+	mu sync.RWMutex
+
+	// txMu serializes multi-corpus transactions (see SaveAll)
+	txMu sync.Mutex
 }
 
 func (lcache *LiveAttrsBuildConfProvider) loadFromFile(corpname string, storeToCache bool) (*vteconf.VTEConf, error) {
@@ -185,7 +220,9 @@ func (lcache *LiveAttrsBuildConfProvider) loadFromFile(corpname string, storeToC
 			return nil, err
 		}
 		if storeToCache {
+			lcache.mu.Lock()
 			lcache.data[corpname] = v
+			lcache.mu.Unlock()
 		}
 		if lcache.globalDBConf.Type == "mysql" {
 			v.DB = *lcache.globalDBConf
@@ -201,7 +238,10 @@ func (lcache *LiveAttrsBuildConfProvider) loadFromFile(corpname string, storeToC
 // In case there is no other error but the configuration does not exist,
 // the method returns ErrorNoSuchConfig error
 func (lcache *LiveAttrsBuildConfProvider) Get(corpname string) (*vteconf.VTEConf, error) {
-	if v, ok := lcache.data[corpname]; ok {
+	lcache.mu.RLock()
+	v, ok := lcache.data[corpname]
+	lcache.mu.RUnlock()
+	if ok {
 		return v, nil
 	}
 	return lcache.loadFromFile(corpname, true)
@@ -230,44 +270,294 @@ func (lcache *LiveAttrsBuildConfProvider) GetUncachedWithoutPasswords(corpname s
 	return &ans, nil
 }
 
-// Save saves a provided configuration to a file for later use
+// Save saves a provided configuration to a file for later use.
+// A previously saved configuration (if any) is backed up first
+// (see Backup).
 func (lcache *LiveAttrsBuildConfProvider) Save(data *vteconf.VTEConf) error {
 	rawData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return err
 	}
+	if _, err := lcache.Backup(data.Corpus); err != nil && err != ErrorNoSuchConfig {
+		return err
+	}
 	confPath := path.Join(lcache.confDirPath, data.Corpus+".json")
-	err = os.WriteFile(confPath, rawData, 0777)
+	err = os.WriteFile(confPath, rawData, 0644)
 	if err != nil {
 		return err
 	}
+	lcache.mu.Lock()
 	lcache.data[data.Corpus] = data
+	lcache.mu.Unlock()
 	if data.DB.Type == "mysql" {
 		data.DB = *lcache.globalDBConf
 	}
 	return nil
 }
 
+// GetDeclaredAttrTypes returns attribute type declarations for a corpus.
+// In case nothing is declared, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetDeclaredAttrTypes(corpname string) (AttrTypes, error) {
+	return lcache.attrTypes.get(corpname)
+}
+
+// GetAttrTypes returns effective attribute types of a corpus, i.e.
+// types detected during data extraction (see GetDetectedAttrTypes)
+// overridden by declared types.
+func (lcache *LiveAttrsBuildConfProvider) GetAttrTypes(corpname string) (AttrTypes, error) {
+	declared, err := lcache.GetDeclaredAttrTypes(corpname)
+	if err != nil {
+		return declared, err
+	}
+	detected, err := lcache.GetDetectedAttrTypes(corpname)
+	if err != nil {
+		return declared, err
+	}
+	ans := make(AttrTypes)
+	for attr, tp := range detected {
+		ans[attr] = tp
+	}
+	for attr, tp := range declared {
+		ans[attr] = tp
+	}
+	return ans, nil
+}
+
+// SaveAttrTypes stores attribute type declarations for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveAttrTypes(corpname string, types AttrTypes) error {
+	return lcache.attrTypes.save(corpname, types)
+}
+
+// GetDetectedAttrTypes returns attribute types detected automatically
+// during data extraction (typed columns). In case nothing has been
+// detected, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetDetectedAttrTypes(corpname string) (AttrTypes, error) {
+	return lcache.detectedTypes.get(corpname)
+}
+
+// SaveDetectedAttrTypes stores automatically detected attribute types
+// for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveDetectedAttrTypes(corpname string, types AttrTypes) error {
+	return lcache.detectedTypes.save(corpname, types)
+}
+
+// GetAutocompleteConf returns autocomplete configuration for a corpus.
+// In case nothing is configured, a zero value (= plain matching)
+// is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetAutocompleteConf(corpname string) (AutocompleteConf, error) {
+	return lcache.autocomplete.get(corpname)
+}
+
+// SaveAutocompleteConf stores autocomplete configuration for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveAutocompleteConf(corpname string, conf AutocompleteConf) error {
+	return lcache.autocomplete.save(corpname, conf)
+}
+
+// GetCollations returns column collations for a corpus.
+// In case nothing is configured, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetCollations(corpname string) (ColumnCollations, error) {
+	return lcache.collations.get(corpname)
+}
+
+// SaveCollations stores column collations for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveCollations(corpname string, collations ColumnCollations) error {
+	return lcache.collations.save(corpname, collations)
+}
+
+// GetMultiValueSeparators returns separators of multi-value attributes
+// for a corpus. In case nothing is configured, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetMultiValueSeparators(corpname string) (MultiValueSeparators, error) {
+	return lcache.multiValues.get(corpname)
+}
+
+// SaveMultiValueSeparators stores separators of multi-value attributes for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveMultiValueSeparators(corpname string, separators MultiValueSeparators) error {
+	return lcache.multiValues.save(corpname, separators)
+}
+
+// GetAttrLocales returns per-attribute sorting locales for a corpus.
+// In case nothing is configured, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetAttrLocales(corpname string) (AttrLocales, error) {
+	return lcache.locales.get(corpname)
+}
+
+// SaveAttrLocales stores per-attribute sorting locales for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveAttrLocales(corpname string, locales AttrLocales) error {
+	return lcache.locales.save(corpname, locales)
+}
+
+// GetValueFilters returns filters of values hidden from query responses
+// for a corpus. In case nothing is configured, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetValueFilters(corpname string) (ValueFilters, error) {
+	return lcache.valueFilters.get(corpname)
+}
+
+// SaveValueFilters stores filters of values hidden from query responses for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveValueFilters(corpname string, filters ValueFilters) error {
+	return lcache.valueFilters.save(corpname, filters)
+}
+
+// GetValueMerges returns merges of attribute values of a corpus
+// (in the order they are applied). In case nothing is configured,
+// an empty list is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetValueMerges(corpname string) (ValueMerges, error) {
+	return lcache.valueMerges.get(corpname)
+}
+
+// SaveValueMerges stores merges of attribute values of a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveValueMerges(corpname string, merges ValueMerges) error {
+	return lcache.valueMerges.save(corpname, merges)
+}
+
+// GetValueOrders returns explicit orders of attribute values for a corpus.
+// In case nothing is configured, an empty map is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetValueOrders(corpname string) (ValueOrders, error) {
+	return lcache.valueOrders.get(corpname)
+}
+
+// SaveValueOrders stores explicit orders of attribute values for a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveValueOrders(corpname string, orders ValueOrders) error {
+	return lcache.valueOrders.save(corpname, orders)
+}
+
+// GetSpeakerConf returns a config of the speaker table of a corpus.
+// In case nothing is configured, nil is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetSpeakerConf(corpname string) (*SpeakerConf, error) {
+	return lcache.speakers.get(corpname)
+}
+
+// SaveSpeakerConf stores a config of the speaker table of a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveSpeakerConf(corpname string, conf SpeakerConf) error {
+	return lcache.speakers.save(corpname, &conf)
+}
+
+// GetTimingConf returns a config of segment timing of a corpus.
+// In case nothing is configured, nil is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetTimingConf(corpname string) (*TimingConf, error) {
+	return lcache.timing.get(corpname)
+}
+
+// SaveTimingConf stores a config of segment timing of a corpus
+func (lcache *LiveAttrsBuildConfProvider) SaveTimingConf(corpname string, conf TimingConf) error {
+	return lcache.timing.save(corpname, &conf)
+}
+
+// GetBibViewProvenance returns the origin of bibliography attributes
+// of a corpus config. In case nothing is recorded, nil is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetBibViewProvenance(corpname string) (*BibViewProvenance, error) {
+	return lcache.bibView.get(corpname)
+}
+
+// SaveBibViewProvenance stores the origin of bibliography attributes
+// of a corpus config
+func (lcache *LiveAttrsBuildConfProvider) SaveBibViewProvenance(corpname string, provenance BibViewProvenance) error {
+	return lcache.bibView.save(corpname, &provenance)
+}
+
+// auxConfs returns caches of all the auxiliary configurations
+func (lcache *LiveAttrsBuildConfProvider) auxConfs() []auxConfCache {
+	return []auxConfCache{
+		lcache.attrTypes, lcache.detectedTypes, lcache.autocomplete, lcache.collations,
+		lcache.multiValues, lcache.locales, lcache.valueFilters, lcache.valueOrders,
+		lcache.valueMerges, lcache.bibView, lcache.speakers, lcache.timing,
+	}
+}
+
 // Uncache removes item corpusID from cache and returns true if the item
 // was present. Otherwise does nothing and returns false.
 func (lcache *LiveAttrsBuildConfProvider) Uncache(corpusID string) bool {
+	lcache.mu.Lock()
+	defer lcache.mu.Unlock()
 	_, ok := lcache.data[corpusID]
 	delete(lcache.data, corpusID)
+	for _, ac := range lcache.auxConfs() {
+		ac.uncache(corpusID)
+	}
 	return ok
 }
 
-// Clear removes a configuration from memory and from filesystem
+// Clear removes a configuration from memory and from filesystem.
+// The removed configuration file and the auxiliary configuration
+// files are backed up first (see Backup and backupAuxConfs).
 func (lcache *LiveAttrsBuildConfProvider) Clear(corpusID string) error {
-	delete(lcache.data, corpusID)
+	backupPath, err := lcache.Backup(corpusID)
+	if err != nil && err != ErrorNoSuchConfig {
+		return err
+	}
+	if err := lcache.backupAuxConfs(corpusID, backupPath); err != nil {
+		return err
+	}
+	lcache.Uncache(corpusID)
+	confPaths := append(
+		[]string{path.Join(lcache.confDirPath, corpusID+".json")},
+		lcache.auxConfPaths(corpusID)...,
+	)
+	for _, confPath := range confPaths {
+		isFile, err := fs.IsFile(confPath)
+		if err != nil {
+			return err
+		}
+		if isFile {
+			if err := os.Remove(confPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rename moves a configuration (including all the auxiliary
+// configuration files) of a corpus to a new corpus ID. The original
+// configuration file is backed up (see Backup).
+func (lcache *LiveAttrsBuildConfProvider) Rename(corpusID, newCorpusID string) error {
 	confPath := path.Join(lcache.confDirPath, corpusID+".json")
-	isFile, err := fs.IsFile(confPath)
+	if isFile, err := fs.IsFile(confPath); err != nil {
+		return err
+
+	} else if !isFile {
+		return ErrorNoSuchConfig
+	}
+	// we load the raw file to keep the stored DB configuration
+	conf, err := LoadConf(confPath)
 	if err != nil {
 		return err
 	}
-	if isFile {
-		return os.Remove(confPath)
+	if conf.Corpus != corpusID {
+		return fmt.Errorf("configuration of %s refers to a different corpus %s", corpusID, conf.Corpus)
 	}
-	return nil
+	newConfPath := path.Join(lcache.confDirPath, newCorpusID+".json")
+	if isFile, _ := fs.IsFile(newConfPath); isFile {
+		return fmt.Errorf("configuration of %s already exists", newCorpusID)
+	}
+	newConf := *conf
+	newConf.Corpus = newCorpusID
+	if newConf.ParallelCorpus == corpusID {
+		newConf.ParallelCorpus = newCorpusID
+	}
+	if err := lcache.Save(&newConf); err != nil {
+		return err
+	}
+	newPaths := lcache.auxConfPaths(newCorpusID)
+	for i, auxPath := range lcache.auxConfPaths(corpusID) {
+		isFile, err := fs.IsFile(auxPath)
+		if err != nil {
+			return err
+		}
+		if isFile {
+			if err := os.Rename(auxPath, newPaths[i]); err != nil {
+				return err
+			}
+		}
+	}
+	lcache.Uncache(newCorpusID)
+	return lcache.Clear(corpusID)
+}
+
+// NumCached returns the number of corpora configs loaded in memory
+func (lcache *LiveAttrsBuildConfProvider) NumCached() int {
+	lcache.mu.RLock()
+	defer lcache.mu.RUnlock()
+	return len(lcache.data)
 }
 
 func NewLiveAttrsBuildConfProvider(confDirPath string, globalDBConf *vtedb.Conf) *LiveAttrsBuildConfProvider {
@@ -275,5 +565,29 @@ func NewLiveAttrsBuildConfProvider(confDirPath string, globalDBConf *vtedb.Conf)
 		confDirPath:  confDirPath,
 		globalDBConf: globalDBConf,
 		data:         make(map[string]*vteconf.VTEConf),
+		attrTypes: newAuxConf(
+			confDirPath, attrTypesSuffix, func() AttrTypes { return make(AttrTypes) }),
+		detectedTypes: newAuxConf(
+			confDirPath, detectedAttrTypesSuffix, func() AttrTypes { return make(AttrTypes) }),
+		autocomplete: newAuxConf(
+			confDirPath, autocompleteSuffix, func() AutocompleteConf { return AutocompleteConf{} }),
+		collations: newAuxConf(
+			confDirPath, collationsSuffix, func() ColumnCollations { return make(ColumnCollations) }),
+		multiValues: newAuxConf(
+			confDirPath, multiValuesSuffix, func() MultiValueSeparators { return make(MultiValueSeparators) }),
+		locales: newAuxConf(
+			confDirPath, localesSuffix, func() AttrLocales { return make(AttrLocales) }),
+		valueFilters: newAuxConf(
+			confDirPath, valueFiltersSuffix, func() ValueFilters { return make(ValueFilters) }),
+		valueOrders: newAuxConf(
+			confDirPath, valueOrdersSuffix, func() ValueOrders { return make(ValueOrders) }),
+		valueMerges: newAuxConf(
+			confDirPath, valueMergesSuffix, func() ValueMerges { return make(ValueMerges, 0, 10) }),
+		bibView: newAuxConf(
+			confDirPath, bibViewSuffix, func() *BibViewProvenance { return nil }),
+		speakers: newAuxConf(
+			confDirPath, speakersSuffix, func() *SpeakerConf { return nil }),
+		timing: newAuxConf(
+			confDirPath, timingSuffix, func() *TimingConf { return nil }),
 	}
 }
@@ -0,0 +1,62 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, &vtedb.Conf{Type: "sqlite"})
+	assert.NoError(t, os.WriteFile(
+		path.Join(dir, "syn2020.json"),
+		[]byte(`{"corpus": "syn2020", "parallelCorpus": "syn2020", "db": {"type": "sqlite"}}`),
+		0644,
+	))
+	assert.NoError(t, os.WriteFile(path.Join(dir, "syn2020.attrTypes.json"), []byte(`{}`), 0644))
+
+	assert.NoError(t, lcache.Rename("syn2020", "syn2020v2"))
+	conf, err := lcache.Get("syn2020v2")
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2020v2", conf.Corpus)
+	assert.Equal(t, "syn2020v2", conf.ParallelCorpus)
+	_, err = os.Stat(path.Join(dir, "syn2020v2.attrTypes.json"))
+	assert.NoError(t, err)
+	_, err = lcache.Get("syn2020")
+	assert.Equal(t, ErrorNoSuchConfig, err)
+	_, err = os.Stat(path.Join(dir, "syn2020.attrTypes.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRenameExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, &vtedb.Conf{Type: "sqlite"})
+	for _, corpusID := range []string{"syn2020", "syn2015"} {
+		assert.NoError(t, os.WriteFile(
+			path.Join(dir, corpusID+".json"), []byte(`{"corpus": "`+corpusID+`"}`), 0644))
+	}
+	assert.Error(t, lcache.Rename("syn2020", "syn2015"))
+	assert.Error(t, lcache.Rename("susanne", "syn2000"))
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+)
+
+// corpusOfConfFile returns a corpus a config file (either
+// a corpus config or an auxiliary one) belongs to. For other
+// files, an empty string is returned.
+func corpusOfConfFile(fileName string) string {
+	if !strings.HasSuffix(fileName, ".json") || strings.ContainsAny(fileName, "/\\") {
+		return ""
+	}
+	name := strings.TrimSuffix(fileName, ".json")
+	for _, suff := range auxConfSuffixes {
+		if strings.HasSuffix(name, suff) {
+			return strings.TrimSuffix(name, suff)
+		}
+	}
+	return name
+}
+
+// isMainConfFile tests whether a file is a corpus config
+// (i.e. not an auxiliary one)
+func isMainConfFile(fileName string) bool {
+	corpusID := corpusOfConfFile(fileName)
+	return corpusID != "" && corpusID+".json" == fileName
+}
+
+// exportedConf removes database settings (except for the database
+// type) from a corpus config as they contain credentials and they
+// are specific for each instance anyway
+func exportedConf(data []byte) ([]byte, error) {
+	var conf vteconf.VTEConf
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+	conf.DB = vtedb.Conf{Type: conf.DB.Type}
+	return json.MarshalIndent(conf, "", "  ")
+}
+
+// importedConf fills in local database settings to an exported
+// corpus config (see exportedConf)
+func (lcache *LiveAttrsBuildConfProvider) importedConf(data []byte) ([]byte, error) {
+	var conf vteconf.VTEConf
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+	if lcache.globalDBConf != nil {
+		conf.DB = *lcache.globalDBConf
+	}
+	return json.MarshalIndent(conf, "", "  ")
+}
+
+// ExportFiles returns contents of all the stored config files
+// (corpora configs along with their auxiliary files) mapped by
+// their file names. Backed up versions are not exported. Database
+// settings are removed from corpora configs (see ImportFiles).
+func (lcache *LiveAttrsBuildConfProvider) ExportFiles() (map[string]json.RawMessage, error) {
+	entries, err := os.ReadDir(lcache.confDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export config files: %w", err)
+	}
+	ans := make(map[string]json.RawMessage)
+	for _, entry := range entries {
+		if entry.IsDir() || corpusOfConfFile(entry.Name()) == "" {
+			continue
+		}
+		data, err := os.ReadFile(path.Join(lcache.confDirPath, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to export config files: %w", err)
+		}
+		if !json.Valid(data) {
+			continue
+		}
+		if isMainConfFile(entry.Name()) {
+			data, err = exportedConf(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to export config file %s: %w", entry.Name(), err)
+			}
+		}
+		ans[entry.Name()] = data
+	}
+	return ans, nil
+}
+
+// ImportFiles stores config files (as produced by ExportFiles)
+// differing from the local ones and removes affected corpora
+// from memory. Local files not present in `files` are kept.
+// Corpora configs get local database settings.
+// Sorted IDs of changed corpora are returned.
+func (lcache *LiveAttrsBuildConfProvider) ImportFiles(files map[string]json.RawMessage) ([]string, error) {
+	changed := make(map[string]bool)
+	for name, data := range files {
+		corpusID := corpusOfConfFile(name)
+		if corpusID == "" {
+			return nil, fmt.Errorf("failed to import config files: invalid file name %s", name)
+		}
+		if isMainConfFile(name) {
+			var err error
+			data, err = lcache.importedConf(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import config file %s: %w", name, err)
+			}
+		}
+		confPath := path.Join(lcache.confDirPath, name)
+		curr, err := os.ReadFile(confPath)
+		if err == nil && bytes.Equal(curr, data) {
+			continue
+
+		} else if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to import config files: %w", err)
+		}
+		if err := os.WriteFile(confPath, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to import config files: %w", err)
+		}
+		changed[corpusID] = true
+	}
+	ans := make([]string, 0, len(changed))
+	for corpusID := range changed {
+		lcache.Uncache(corpusID)
+		ans = append(ans, corpusID)
+	}
+	sort.Strings(ans)
+	return ans, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpusOfConfFile(t *testing.T) {
+	assert.Equal(t, "syn2020", corpusOfConfFile("syn2020.json"))
+	assert.Equal(t, "syn2020", corpusOfConfFile("syn2020.attrTypes.json"))
+	assert.Equal(t, "syn2020", corpusOfConfFile("syn2020.bibView.json"))
+	assert.Equal(t, "oral2013", corpusOfConfFile("oral2013.timing.json"))
+	assert.Equal(t, "", corpusOfConfFile("notes.txt"))
+	assert.Equal(t, "", corpusOfConfFile("../syn2020.json"))
+}
+
+func TestExportImportFiles(t *testing.T) {
+	srcDir := t.TempDir()
+	for name, data := range map[string]string{
+		"syn2020.json":           `{"corpus": "syn2020", "db": {"type": "mysql", "password": "secret"}}`,
+		"syn2020.attrTypes.json": `{"doc.year": "int"}`,
+		"susanne.json":           `{"corpus": "susanne"}`,
+		"notes.txt":              "foo",
+	} {
+		assert.NoError(t, os.WriteFile(path.Join(srcDir, name), []byte(data), 0644))
+	}
+	assert.NoError(t, os.Mkdir(path.Join(srcDir, "backup"), 0755))
+	files, err := NewLiveAttrsBuildConfProvider(srcDir, nil).ExportFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.NotContains(t, string(files["syn2020.json"]), "secret")
+	assert.Contains(t, string(files["syn2020.json"]), `"type": "mysql"`)
+
+	dstDir := t.TempDir()
+	dst := NewLiveAttrsBuildConfProvider(
+		dstDir, &vtedb.Conf{Type: "mysql", Name: "masm", Password: "local"})
+	changed, err := dst.ImportFiles(map[string]json.RawMessage{"susanne.json": files["susanne.json"]})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"susanne"}, changed)
+	changed, err = dst.ImportFiles(files)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"syn2020"}, changed)
+	data, err := os.ReadFile(path.Join(dstDir, "syn2020.attrTypes.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"doc.year": "int"}`, string(data))
+	data, err = os.ReadFile(path.Join(dstDir, "syn2020.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"password": "local"`)
+
+	changed, err = dst.ImportFiles(files)
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+
+	_, err = dst.ImportFiles(map[string]json.RawMessage{"../x.json": json.RawMessage("{}")})
+	assert.Error(t, err)
+}
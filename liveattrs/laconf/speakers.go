@@ -0,0 +1,121 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+)
+
+var attrNameRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// SpeakerConf configures a secondary liveattrs table of speakers
+// for spoken corpora. Each row of the table describes a speaker within
+// a document (atom) along with the number of positions the speaker
+// uttered there. Speaker attributes (e.g. "sp.sex", "sp.age") can be then
+// combined with document attributes in liveattrs queries without
+// making the speaker structure the atom (i.e. without duplicating
+// document attributes for each utterance).
+type SpeakerConf struct {
+
+	// Struct is a structure of speaker turns (e.g. "sp")
+	Struct string `json:"struct"`
+
+	// IDAttr is an attribute of Struct identifying speakers
+	IDAttr string `json:"idAttr"`
+
+	// Attrs are speaker attributes of Struct stored in the table
+	Attrs []string `json:"attrs"`
+
+	// DocIDAttr is an attribute of the atom structure (in dot
+	// notation) identifying documents. Speakers are linked to
+	// the liveattrs entries by its values.
+	DocIDAttr string `json:"docIdAttr"`
+}
+
+// IsEnabled tests whether the speaker table is configured
+func (sc *SpeakerConf) IsEnabled() bool {
+	return sc != nil && sc.Struct != ""
+}
+
+// QualifiedAttrs returns speaker attributes in dot notation
+func (sc *SpeakerConf) QualifiedAttrs() []string {
+	if !sc.IsEnabled() {
+		return []string{}
+	}
+	ans := make([]string, len(sc.Attrs))
+	for i, attr := range sc.Attrs {
+		ans[i] = sc.Struct + "." + attr
+	}
+	return ans
+}
+
+// IsSpeakerAttr tests whether an attribute (in dot notation)
+// is stored in the speaker table
+func (sc *SpeakerConf) IsSpeakerAttr(attr string) bool {
+	if !sc.IsEnabled() {
+		return false
+	}
+	for _, sattr := range sc.Attrs {
+		if sc.Struct+"."+sattr == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate tests whether the speaker table can be created for
+// a corpus configured by `conf`. Speaker attributes must not be
+// extracted to the main liveattrs table as they would be ambiguous
+// there. An empty config (i.e. disabled speaker table) is valid.
+func (sc *SpeakerConf) Validate(conf *vteconf.VTEConf) error {
+	if !sc.IsEnabled() {
+		return nil
+	}
+	if sc.Struct == conf.AtomStructure {
+		return fmt.Errorf("speaker structure must differ from the atom structure")
+	}
+	if !attrNameRegexp.MatchString(sc.Struct) {
+		return fmt.Errorf("invalid speaker structure %s", sc.Struct)
+	}
+	if sc.IDAttr == "" {
+		return fmt.Errorf("missing speaker ID attribute")
+	}
+	if len(sc.Attrs) == 0 {
+		return fmt.Errorf("no speaker attributes specified")
+	}
+	for _, attr := range append([]string{sc.IDAttr}, sc.Attrs...) {
+		if !attrNameRegexp.MatchString(attr) {
+			return fmt.Errorf("invalid speaker attribute %s", attr)
+		}
+	}
+	for _, attr := range sc.QualifiedAttrs() {
+		if isKnownAttr(conf.Structures, attr) {
+			return fmt.Errorf("speaker attribute %s must not be extracted to the liveattrs table", attr)
+		}
+	}
+	if !isKnownAttr(conf.Structures, sc.DocIDAttr) ||
+		!strings.HasPrefix(sc.DocIDAttr, conf.AtomStructure+".") {
+		return fmt.Errorf("document ID attribute must be an attribute of the atom structure")
+	}
+	return nil
+}
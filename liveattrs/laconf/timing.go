@@ -0,0 +1,114 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"strings"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+)
+
+const (
+	TimingUnitSeconds      = "s"
+	TimingUnitMilliseconds = "ms"
+
+	dfltDurationAttrName = "duration"
+)
+
+// TimingConf configures segment-level timing of audio-aligned corpora.
+// Start and end offsets of atom structures (segments) are extracted as
+// regular attributes. After data extraction, a duration of each segment
+// (in seconds) is stored in a derived numeric attribute which can be
+// used in range filters and which is summed up in subcorpus sizes.
+type TimingConf struct {
+
+	// StartAttr is an attribute of the atom structure containing
+	// a start offset of a segment (e.g. "seg.start")
+	StartAttr string `json:"startAttr"`
+
+	// EndAttr is an attribute of the atom structure containing
+	// an end offset of a segment (e.g. "seg.end")
+	EndAttr string `json:"endAttr"`
+
+	// Unit is a unit of the offsets - either "s" (default)
+	// or "ms"
+	Unit string `json:"unit"`
+
+	// DurationAttr is a name of the derived attribute (in dot notation)
+	// storing segment durations. The default is "[atom].duration".
+	DurationAttr string `json:"durationAttr"`
+}
+
+// IsEnabled tests whether segment timing is configured
+func (tc *TimingConf) IsEnabled() bool {
+	return tc != nil && tc.StartAttr != "" && tc.EndAttr != ""
+}
+
+// GetDurationAttr returns the derived attribute storing
+// segment durations (in dot notation)
+func (tc *TimingConf) GetDurationAttr() string {
+	if tc.DurationAttr != "" {
+		return tc.DurationAttr
+	}
+	strct, _, _ := strings.Cut(tc.StartAttr, ".")
+	return strct + "." + dfltDurationAttrName
+}
+
+// UnitsPerSecond returns a number of offset units per second
+func (tc *TimingConf) UnitsPerSecond() int {
+	if tc.Unit == TimingUnitMilliseconds {
+		return 1000
+	}
+	return 1
+}
+
+// Validate tests whether segment timing can be applied to a corpus
+// configured by `conf`. Both offsets must be extracted attributes
+// of the atom structure while the duration attribute must not be
+// extracted from the vertical. An empty config (i.e. disabled timing)
+// is valid.
+func (tc *TimingConf) Validate(conf *vteconf.VTEConf) error {
+	if tc == nil || (tc.StartAttr == "" && tc.EndAttr == "") {
+		return nil
+	}
+	atomPrefix := conf.AtomStructure + "."
+	for _, attr := range []string{tc.StartAttr, tc.EndAttr} {
+		if !isKnownAttr(conf.Structures, attr) || !strings.HasPrefix(attr, atomPrefix) {
+			return fmt.Errorf("timing attribute %s must be an attribute of the atom structure", attr)
+		}
+	}
+	if tc.StartAttr == tc.EndAttr {
+		return fmt.Errorf("start and end timing attributes must differ")
+	}
+	switch tc.Unit {
+	case "", TimingUnitSeconds, TimingUnitMilliseconds:
+	default:
+		return fmt.Errorf("unsupported timing unit %s", tc.Unit)
+	}
+	durationAttr := tc.GetDurationAttr()
+	strct, attr, ok := strings.Cut(durationAttr, ".")
+	if !ok || strct != conf.AtomStructure || !attrNameRegexp.MatchString(attr) {
+		return fmt.Errorf("invalid duration attribute %s", durationAttr)
+	}
+	if isKnownAttr(conf.Structures, durationAttr) {
+		return fmt.Errorf("duration attribute %s must not be extracted from the vertical", durationAttr)
+	}
+	return nil
+}
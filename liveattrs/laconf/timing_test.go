@@ -0,0 +1,57 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingConfValidate(t *testing.T) {
+	conf := &vteconf.VTEConf{
+		AtomStructure: "seg",
+		Structures: map[string][]string{
+			"doc": {"id", "start"},
+			"seg": {"id", "start", "end"},
+		},
+	}
+	assert.NoError(t, (&TimingConf{StartAttr: "seg.start", EndAttr: "seg.end"}).Validate(conf))
+	assert.NoError(t, (&TimingConf{}).Validate(conf))
+	assert.Error(t, (&TimingConf{StartAttr: "doc.start", EndAttr: "seg.end"}).Validate(conf))
+	assert.Error(t, (&TimingConf{StartAttr: "seg.start", EndAttr: "seg.start"}).Validate(conf))
+	assert.Error(t, (&TimingConf{StartAttr: "seg.start", EndAttr: "seg.end", Unit: "min"}).Validate(conf))
+	assert.Error(
+		t,
+		(&TimingConf{StartAttr: "seg.start", EndAttr: "seg.end", DurationAttr: "seg.id"}).Validate(conf),
+	)
+	assert.Error(
+		t,
+		(&TimingConf{StartAttr: "seg.start", EndAttr: "seg.end", DurationAttr: "doc.duration"}).Validate(conf),
+	)
+}
+
+func TestTimingConfDurationAttr(t *testing.T) {
+	tc := &TimingConf{StartAttr: "seg.start", EndAttr: "seg.end", Unit: TimingUnitMilliseconds}
+	assert.Equal(t, "seg.duration", tc.GetDurationAttr())
+	assert.Equal(t, 1000, tc.UnitsPerSecond())
+	tc.DurationAttr = "seg.length"
+	assert.Equal(t, "seg.length", tc.GetDurationAttr())
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/rs/zerolog/log"
+)
+
+const txFileSuffix = ".tx"
+
+// GroupPatchArgs contains settings shared by all the members
+// of a parallel corpus group. Unlike PatchArgs, the settings
+// are applied to all the members at once (see SaveAll).
+type GroupPatchArgs struct {
+	Corpora []string `json:"corpora"`
+
+	// ParallelCorpus is a name of the group (= also a name
+	// of the shared liveattrs table)
+	ParallelCorpus *string `json:"parallelCorpus"`
+
+	// MergeFn is a function generating merged values
+	// of self-joined columns (selfJoin.generatorFn)
+	MergeFn *string `json:"mergeFn"`
+
+	// DBName is a name of the liveattrs database (relevant
+	// only for SQLite as MySQL settings are global)
+	DBName *string `json:"dbName"`
+}
+
+// Validate tests whether the args contain at least one corpus
+// (without duplicities) and at least one setting to be applied
+func (args *GroupPatchArgs) Validate() error {
+	if len(args.Corpora) == 0 {
+		return errors.New("no corpora specified")
+	}
+	used := make(map[string]bool)
+	for _, corp := range args.Corpora {
+		if used[corp] {
+			return fmt.Errorf("corpus %s specified more than once", corp)
+		}
+		used[corp] = true
+	}
+	if args.ParallelCorpus == nil && args.MergeFn == nil && args.DBName == nil {
+		return errors.New("no update data provided")
+	}
+	return nil
+}
+
+// Apply sets the shared settings to a provided config
+func (args *GroupPatchArgs) Apply(conf *vteconf.VTEConf) error {
+	if args.ParallelCorpus != nil {
+		conf.ParallelCorpus = *args.ParallelCorpus
+	}
+	if args.MergeFn != nil {
+		if len(conf.SelfJoin.ArgColumns) == 0 {
+			return fmt.Errorf("cannot set mergeFn of %s: no selfJoin configured", conf.Corpus)
+		}
+		conf.SelfJoin.GeneratorFn = *args.MergeFn
+	}
+	if args.DBName != nil {
+		conf.DB.Name = *args.DBName
+	}
+	return nil
+}
+
+// CheckGroup tests whether provided configs can form a parallel
+// corpus group - i.e. whether they share all the group settings
+func CheckGroup(confs []*vteconf.VTEConf) error {
+	for _, conf := range confs[1:] {
+		if conf.ParallelCorpus != confs[0].ParallelCorpus {
+			return fmt.Errorf(
+				"parallelCorpus of %s differs from %s", conf.Corpus, confs[0].Corpus)
+		}
+		if conf.SelfJoin.GeneratorFn != confs[0].SelfJoin.GeneratorFn {
+			return fmt.Errorf(
+				"selfJoin.generatorFn of %s differs from %s", conf.Corpus, confs[0].Corpus)
+		}
+		if conf.DB.Name != confs[0].DB.Name {
+			return fmt.Errorf("db.name of %s differs from %s", conf.Corpus, confs[0].Corpus)
+		}
+	}
+	return nil
+}
+
+func (lcache *LiveAttrsBuildConfProvider) confPath(corpusID string) string {
+	return path.Join(lcache.confDirPath, corpusID+".json")
+}
+
+// rollback restores original contents of config files
+// (nil content means there was no file)
+func (lcache *LiveAttrsBuildConfProvider) rollback(corpora []string, originals [][]byte) {
+	for i, corpusID := range corpora {
+		var err error
+		if originals[i] == nil {
+			err = os.Remove(lcache.confPath(corpusID))
+
+		} else {
+			err = os.WriteFile(lcache.confPath(corpusID), originals[i], 0644)
+		}
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("corpusId", corpusID).
+				Msg("failed to roll back liveattrs config, manual fix needed")
+		}
+	}
+}
+
+// SaveAll saves provided configurations so that either all of them
+// are stored or none of the stored configs is modified. New versions
+// are written to temporary files first, then the current versions are
+// backed up (see Backup) and finally the temporary files replace
+// the current ones. In case any of the replacements fails, already
+// replaced configs are restored. Please note that backups created
+// within a failed transaction are kept.
+func (lcache *LiveAttrsBuildConfProvider) SaveAll(confs []*vteconf.VTEConf) error {
+	lcache.txMu.Lock()
+	defer lcache.txMu.Unlock()
+
+	tmpPaths := make([]string, 0, len(confs))
+	cleanup := func() {
+		for _, p := range tmpPaths {
+			os.Remove(p)
+		}
+	}
+	for _, conf := range confs {
+		rawData, err := json.MarshalIndent(conf, "", "  ")
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to save config of %s: %w", conf.Corpus, err)
+		}
+		tmpPath := lcache.confPath(conf.Corpus) + txFileSuffix
+		if err := os.WriteFile(tmpPath, rawData, 0644); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to save config of %s: %w", conf.Corpus, err)
+		}
+		tmpPaths = append(tmpPaths, tmpPath)
+	}
+
+	corpora := make([]string, len(confs))
+	originals := make([][]byte, len(confs))
+	for i, conf := range confs {
+		corpora[i] = conf.Corpus
+		data, err := os.ReadFile(lcache.confPath(conf.Corpus))
+		if err != nil && !os.IsNotExist(err) {
+			cleanup()
+			return fmt.Errorf("failed to save config of %s: %w", conf.Corpus, err)
+		}
+		originals[i] = data
+	}
+	for i, conf := range confs {
+		if originals[i] == nil {
+			continue
+		}
+		if _, err := lcache.Backup(conf.Corpus); err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	for i, conf := range confs {
+		if err := os.Rename(tmpPaths[i], lcache.confPath(conf.Corpus)); err != nil {
+			lcache.rollback(corpora[:i], originals[:i])
+			cleanup()
+			return fmt.Errorf("failed to save config of %s: %w", conf.Corpus, err)
+		}
+	}
+
+	lcache.mu.Lock()
+	for _, conf := range confs {
+		lcache.data[conf.Corpus] = conf
+	}
+	lcache.mu.Unlock()
+	for _, conf := range confs {
+		if conf.DB.Type == "mysql" {
+			conf.DB = *lcache.globalDBConf
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupPatchArgsValidate(t *testing.T) {
+	fn := "identity"
+	assert.Error(t, (&GroupPatchArgs{MergeFn: &fn}).Validate())
+	assert.Error(t, (&GroupPatchArgs{Corpora: []string{"a", "b"}}).Validate())
+	assert.Error(t, (&GroupPatchArgs{Corpora: []string{"a", "b", "a"}, MergeFn: &fn}).Validate())
+	assert.NoError(t, (&GroupPatchArgs{Corpora: []string{"a", "b"}, MergeFn: &fn}).Validate())
+}
+
+func TestGroupPatchArgsApply(t *testing.T) {
+	fn := "intercorp"
+	group := "intercorp_v16"
+	args := GroupPatchArgs{ParallelCorpus: &group, MergeFn: &fn}
+	conf := vteconf.VTEConf{Corpus: "intercorp_v16_cs"}
+	assert.Error(t, args.Apply(&conf))
+
+	conf.SelfJoin.ArgColumns = []string{"doc_id"}
+	assert.NoError(t, args.Apply(&conf))
+	assert.Equal(t, "intercorp", conf.SelfJoin.GeneratorFn)
+	assert.Equal(t, "intercorp_v16", conf.ParallelCorpus)
+}
+
+func TestCheckGroup(t *testing.T) {
+	c1 := &vteconf.VTEConf{Corpus: "c1", ParallelCorpus: "grp"}
+	c2 := &vteconf.VTEConf{Corpus: "c2", ParallelCorpus: "grp"}
+	assert.NoError(t, CheckGroup([]*vteconf.VTEConf{c1, c2}))
+	c2.DB.Name = "other"
+	assert.Error(t, CheckGroup([]*vteconf.VTEConf{c1, c2}))
+}
+
+func TestSaveAll(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, &vtedb.Conf{})
+	assert.NoError(t, os.WriteFile(path.Join(dir, "c1.json"), []byte(`{"corpus": "c1"}`), 0644))
+	err := lcache.SaveAll([]*vteconf.VTEConf{
+		{Corpus: "c1", ParallelCorpus: "grp"},
+		{Corpus: "c2", ParallelCorpus: "grp"},
+	})
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path.Join(dir, "c2.json"))
+	assert.NoError(t, err)
+	var conf vteconf.VTEConf
+	assert.NoError(t, json.Unmarshal(data, &conf))
+	assert.Equal(t, "grp", conf.ParallelCorpus)
+	versions, err := lcache.ListVersions("c1")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestSaveAllFailureKeepsConfigs(t *testing.T) {
+	dir := t.TempDir()
+	lcache := NewLiveAttrsBuildConfProvider(dir, &vtedb.Conf{})
+	assert.NoError(t, os.WriteFile(path.Join(dir, "c1.json"), []byte(`{"corpus": "c1"}`), 0644))
+	// c2 cannot be written as there is a directory in place of its config
+	assert.NoError(t, os.Mkdir(path.Join(dir, "c2.json"), 0755))
+	err := lcache.SaveAll([]*vteconf.VTEConf{
+		{Corpus: "c1", ParallelCorpus: "grp"},
+		{Corpus: "c2", ParallelCorpus: "grp"},
+	})
+	assert.Error(t, err)
+	data, err := os.ReadFile(path.Join(dir, "c1.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"corpus": "c1"}`, string(data))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), txFileSuffix)
+	}
+}
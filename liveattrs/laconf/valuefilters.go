@@ -0,0 +1,71 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+)
+
+// ValueFilter specifies values of an attribute hidden from query
+// responses. Either a list of excluded values (blacklist) or a list
+// of the only allowed values (whitelist) can be specified.
+type ValueFilter struct {
+	Exclude []string `json:"exclude,omitempty"`
+	Include []string `json:"include,omitempty"`
+}
+
+// IsHidden tests whether a value should be hidden
+func (vf ValueFilter) IsHidden(value string) bool {
+	if len(vf.Include) > 0 {
+		return !collections.SliceContains(vf.Include, value)
+	}
+	return collections.SliceContains(vf.Exclude, value)
+}
+
+// ValueFilters maps structural attributes (in dot notation, e.g. "doc.title")
+// to their value filters. The filters affect only responses - the data
+// remain untouched.
+type ValueFilters map[string]ValueFilter
+
+// IsHidden tests whether a value of an attribute should be hidden.
+// Values of attributes without a filter are never hidden.
+func (vfs ValueFilters) IsHidden(attr, value string) bool {
+	vf, ok := vfs[attr]
+	if !ok {
+		return false
+	}
+	return vf.IsHidden(value)
+}
+
+// Validate tests whether all the attributes are known structural
+// attributes and whether each filter is either a blacklist or
+// a whitelist.
+func (vfs ValueFilters) Validate(structures map[string][]string) error {
+	for attr, vf := range vfs {
+		if len(vf.Include) > 0 && len(vf.Exclude) > 0 {
+			return fmt.Errorf(
+				"value filter of %s cannot contain both excluded and included values", attr)
+		}
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot set value filter of unknown attribute %s", attr)
+		}
+	}
+	return nil
+}
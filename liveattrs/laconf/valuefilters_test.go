@@ -0,0 +1,49 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueFiltersIsHidden(t *testing.T) {
+	filters := ValueFilters{
+		"doc.title": {Exclude: []string{"TEST DOCUMENT"}},
+		"doc.genre": {Include: []string{"fiction", "poetry"}},
+	}
+	assert.True(t, filters.IsHidden("doc.title", "TEST DOCUMENT"))
+	assert.False(t, filters.IsHidden("doc.title", "R.U.R."))
+	assert.True(t, filters.IsHidden("doc.genre", "technical"))
+	assert.False(t, filters.IsHidden("doc.genre", "poetry"))
+	assert.False(t, filters.IsHidden("doc.author", "TEST DOCUMENT"))
+}
+
+func TestValueFiltersValidate(t *testing.T) {
+	structures := map[string][]string{"doc": {"title", "genre"}}
+	assert.NoError(t, ValueFilters{"doc.title": {Exclude: []string{"x"}}}.Validate(structures))
+	assert.Error(t, ValueFilters{"doc.author": {Exclude: []string{"x"}}}.Validate(structures))
+	assert.Error(
+		t,
+		ValueFilters{
+			"doc.title": {Exclude: []string{"x"}, Include: []string{"y"}},
+		}.Validate(structures),
+	)
+}
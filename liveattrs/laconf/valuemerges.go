@@ -0,0 +1,110 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+	"masm/v3/general/collections"
+	"time"
+)
+
+// ValueMerge replaces values of an attribute with a single canonical
+// value (e.g. "MF Dnes" and "Mladá fronta DNES" with the latter one).
+// Merges are applied to the liveattrs table once created and again
+// after each data extraction so they survive corpus rebuilds.
+type ValueMerge struct {
+	ID string `json:"id"`
+
+	// Attr is a structural attribute in dot notation (e.g. "doc.publisher")
+	Attr string `json:"attr"`
+
+	// Values are replaced by Canonical
+	Values []string `json:"values"`
+
+	Canonical string `json:"canonical"`
+
+	Created time.Time `json:"created"`
+}
+
+// Validate tests whether the attribute is a known structural attribute
+// and whether there is something to merge
+func (vm ValueMerge) Validate(structures map[string][]string) error {
+	if !isKnownAttr(structures, vm.Attr) {
+		return fmt.Errorf("cannot merge values of unknown attribute %s", vm.Attr)
+	}
+	if vm.Canonical == "" {
+		return fmt.Errorf("canonical value of %s must not be empty", vm.Attr)
+	}
+	if len(vm.Values) == 0 {
+		return fmt.Errorf("no values of %s to merge", vm.Attr)
+	}
+	for _, v := range vm.Values {
+		if v == "" {
+			return fmt.Errorf("merged values of %s must not be empty", vm.Attr)
+		}
+		if v == vm.Canonical {
+			return fmt.Errorf("merged values of %s must not contain the canonical value", vm.Attr)
+		}
+	}
+	return nil
+}
+
+// NewValueMerge creates a merge of `values` into `canonical`. The canonical
+// value can be passed also among the values (it is removed from them)
+// and duplicate values are removed.
+func NewValueMerge(id, attr string, values []string, canonical string) ValueMerge {
+	ans := ValueMerge{
+		ID:        id,
+		Attr:      attr,
+		Values:    make([]string, 0, len(values)),
+		Canonical: canonical,
+		Created:   time.Now(),
+	}
+	for _, v := range values {
+		if v != canonical && !collections.SliceContains(ans.Values, v) {
+			ans.Values = append(ans.Values, v)
+		}
+	}
+	return ans
+}
+
+// ValueMerges is a list of merges of a corpus in the order
+// they are applied
+type ValueMerges []ValueMerge
+
+// Find returns a merge with the `id`
+func (vms ValueMerges) Find(id string) (ValueMerge, bool) {
+	for _, vm := range vms {
+		if vm.ID == id {
+			return vm, true
+		}
+	}
+	return ValueMerge{}, false
+}
+
+// Without returns a copy of the list without the merge `id`
+func (vms ValueMerges) Without(id string) ValueMerges {
+	ans := make(ValueMerges, 0, len(vms))
+	for _, vm := range vms {
+		if vm.ID != id {
+			ans = append(ans, vm)
+		}
+	}
+	return ans
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewValueMerge(t *testing.T) {
+	vm := NewValueMerge(
+		"m1", "doc.publisher", []string{"MF Dnes", "Mladá fronta DNES", "MF Dnes"}, "Mladá fronta DNES")
+	assert.Equal(t, []string{"MF Dnes"}, vm.Values)
+	assert.Equal(t, "Mladá fronta DNES", vm.Canonical)
+}
+
+func TestValueMergeValidate(t *testing.T) {
+	structures := map[string][]string{"doc": {"publisher"}}
+	assert.NoError(t, NewValueMerge("m1", "doc.publisher", []string{"MF Dnes"}, "Mladá fronta DNES").Validate(structures))
+	assert.Error(t, NewValueMerge("m1", "doc.author", []string{"MF Dnes"}, "Mladá fronta DNES").Validate(structures))
+	assert.Error(t, NewValueMerge("m1", "doc.publisher", []string{"MF Dnes"}, "").Validate(structures))
+	assert.Error(t, NewValueMerge("m1", "doc.publisher", []string{"MF Dnes"}, "MF Dnes").Validate(structures))
+	assert.Error(t, NewValueMerge("m1", "doc.publisher", []string{""}, "Mladá fronta DNES").Validate(structures))
+}
+
+func TestValueMergesFindWithout(t *testing.T) {
+	merges := ValueMerges{
+		NewValueMerge("m1", "doc.publisher", []string{"MF Dnes"}, "Mladá fronta DNES"),
+		NewValueMerge("m2", "doc.publisher", []string{"LN"}, "Lidové noviny"),
+	}
+	vm, ok := merges.Find("m2")
+	assert.True(t, ok)
+	assert.Equal(t, "Lidové noviny", vm.Canonical)
+	_, ok = merges.Find("m3")
+	assert.False(t, ok)
+	rest := merges.Without("m1")
+	assert.Len(t, rest, 1)
+	assert.Equal(t, "m2", rest[0].ID)
+	assert.Len(t, merges, 2)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package laconf
+
+import (
+	"fmt"
+)
+
+// ValueOrders maps structural attributes (in dot notation, e.g. "doc.genre")
+// to explicit orders of their values used when a query requests custom
+// sorting. Values not present in an order follow the listed ones.
+type ValueOrders map[string][]string
+
+// Validate tests whether all the attributes are known structural
+// attributes and whether the orders do not contain duplicate values.
+func (vo ValueOrders) Validate(structures map[string][]string) error {
+	for attr, order := range vo {
+		if !isKnownAttr(structures, attr) {
+			return fmt.Errorf("cannot set value order of unknown attribute %s", attr)
+		}
+		seen := make(map[string]bool)
+		for _, v := range order {
+			if seen[v] {
+				return fmt.Errorf("duplicate value %s in value order of %s", v, attr)
+			}
+			seen[v] = true
+		}
+	}
+	return nil
+}
@@ -33,6 +33,20 @@ type JobInfoArgs struct {
 	Append         bool           `json:"append"`
 	VteConf        vteCnf.VTEConf `json:"vteConf"`
 	NoCorpusUpdate bool           `json:"noCorpusUpdate"`
+
+	// DetectAttrTypes enables automatic detection of integer and date
+	// attributes stored then in typed columns (MySQL only). Once
+	// enabled, the detection is repeated with each following job.
+	DetectAttrTypes bool `json:"detectAttrTypes"`
+
+	// Verticals contains identities of processed vertical files
+	// (MySQL only) to be registered once the job finishes
+	Verticals []VerticalIdentity `json:"verticals,omitempty"`
+
+	// SkipDuplicates makes a job in the append mode remove appended
+	// data of documents already present in the previous data instead
+	// of failing (MySQL only, see LiveAttrsJobInfo.AppendBaseRowID)
+	SkipDuplicates bool `json:"skipDuplicates,omitempty"`
 }
 
 func (jargs JobInfoArgs) WithoutPasswords() JobInfoArgs {
@@ -54,6 +68,38 @@ type LiveAttrsJobInfo struct {
 	ProcessedLines int           `json:"processedLines"`
 	NumRestarts    int           `json:"numRestarts"`
 	Args           JobInfoArgs   `json:"args"`
+
+	// MergeReport is available for finished jobs with
+	// configured self-join (mergeAttrs)
+	MergeReport *MergeReport `json:"mergeReport,omitempty"`
+
+	// EstimatedNumLines is an estimated total number of lines
+	// of processed vertical file(s) (see EstimateNumLines)
+	EstimatedNumLines int `json:"estimatedNumLines"`
+
+	// LinesPerSecond is an average processing throughput
+	LinesPerSecond float64 `json:"linesPerSecond"`
+
+	// EstimatedEnd is an estimated time of data extraction
+	// completion (if known)
+	EstimatedEnd jobs.JSONTime `json:"estimatedEnd"`
+
+	// Pending is true for an accepted job waiting for
+	// a running job of the same corpus to finish
+	Pending bool `json:"pending,omitempty"`
+
+	// Checkpoint is the last known position of data extraction
+	// a restarted job can continue from (see ExtractionCheckpoint)
+	Checkpoint *ExtractionCheckpoint `json:"checkpoint,omitempty"`
+
+	// AppendBaseRowID is the highest row ID of the liveattrs table
+	// before a job in the append mode started (MySQL only). Rows with
+	// higher IDs are the appended ones.
+	AppendBaseRowID int64 `json:"appendBaseRowId,omitempty"`
+
+	// SkippedDuplicates lists documents removed from appended data
+	// as they had already been present in the previous data
+	SkippedDuplicates []string `json:"skippedDuplicates,omitempty"`
 }
 
 func (j LiveAttrsJobInfo) GetID() string {
@@ -88,31 +134,45 @@ func (j LiveAttrsJobInfo) IsFinished() bool {
 
 func (j LiveAttrsJobInfo) FullInfo() any {
 	return struct {
-		ID             string        `json:"id"`
-		Type           string        `json:"type"`
-		CorpusID       string        `json:"corpusId"`
-		Start          jobs.JSONTime `json:"start"`
-		Update         jobs.JSONTime `json:"update"`
-		Finished       bool          `json:"finished"`
-		Error          string        `json:"error,omitempty"`
-		OK             bool          `json:"ok"`
-		ProcessedAtoms int           `json:"processedAtoms"`
-		ProcessedLines int           `json:"processedLines"`
-		NumRestarts    int           `json:"numRestarts"`
-		Args           JobInfoArgs   `json:"args"`
+		ID                string                `json:"id"`
+		Type              string                `json:"type"`
+		CorpusID          string                `json:"corpusId"`
+		Start             jobs.JSONTime         `json:"start"`
+		Update            jobs.JSONTime         `json:"update"`
+		Finished          bool                  `json:"finished"`
+		Error             string                `json:"error,omitempty"`
+		OK                bool                  `json:"ok"`
+		ProcessedAtoms    int                   `json:"processedAtoms"`
+		ProcessedLines    int                   `json:"processedLines"`
+		NumRestarts       int                   `json:"numRestarts"`
+		Args              JobInfoArgs           `json:"args"`
+		MergeReport       *MergeReport          `json:"mergeReport,omitempty"`
+		EstimatedNumLines int                   `json:"estimatedNumLines"`
+		LinesPerSecond    float64               `json:"linesPerSecond"`
+		EstimatedEnd      jobs.JSONTime         `json:"estimatedEnd"`
+		Pending           bool                  `json:"pending,omitempty"`
+		Checkpoint        *ExtractionCheckpoint `json:"checkpoint,omitempty"`
+		SkippedDuplicates []string              `json:"skippedDuplicates,omitempty"`
 	}{
-		ID:             j.ID,
-		Type:           j.Type,
-		CorpusID:       j.CorpusID,
-		Start:          j.Start,
-		Update:         j.Update,
-		Finished:       j.Finished,
-		Error:          jobs.ErrorToString(j.Error),
-		OK:             j.Error == nil,
-		ProcessedAtoms: j.ProcessedAtoms,
-		ProcessedLines: j.ProcessedLines,
-		NumRestarts:    j.NumRestarts,
-		Args:           j.Args.WithoutPasswords(),
+		ID:                j.ID,
+		Type:              j.Type,
+		CorpusID:          j.CorpusID,
+		Start:             j.Start,
+		Update:            j.Update,
+		Finished:          j.Finished,
+		Error:             jobs.ErrorToString(j.Error),
+		OK:                j.Error == nil,
+		ProcessedAtoms:    j.ProcessedAtoms,
+		ProcessedLines:    j.ProcessedLines,
+		NumRestarts:       j.NumRestarts,
+		Args:              j.Args.WithoutPasswords(),
+		MergeReport:       j.MergeReport,
+		EstimatedNumLines: j.EstimatedNumLines,
+		LinesPerSecond:    j.LinesPerSecond,
+		EstimatedEnd:      j.EstimatedEnd,
+		Pending:           j.Pending,
+		Checkpoint:        j.Checkpoint,
+		SkippedDuplicates: j.SkippedDuplicates,
 	}
 }
 
@@ -138,13 +198,18 @@ func (j LiveAttrsJobInfo) GetError() error {
 // the Error property set to the value of 'err'.
 func (j LiveAttrsJobInfo) WithError(err error) jobs.GeneralJobInfo {
 	return LiveAttrsJobInfo{
-		ID:          j.ID,
-		Type:        JobType,
-		CorpusID:    j.CorpusID,
-		Start:       j.Start,
-		Update:      jobs.JSONTime(time.Now()),
-		Error:       err,
-		NumRestarts: j.NumRestarts,
-		Args:        j.Args,
+		ID:                j.ID,
+		Type:              JobType,
+		CorpusID:          j.CorpusID,
+		Start:             j.Start,
+		Update:            jobs.JSONTime(time.Now()),
+		Error:             err,
+		NumRestarts:       j.NumRestarts,
+		Args:              j.Args,
+		MergeReport:       j.MergeReport,
+		EstimatedNumLines: j.EstimatedNumLines,
+		LinesPerSecond:    j.LinesPerSecond,
+		AppendBaseRowID:   j.AppendBaseRowID,
+		SkippedDuplicates: j.SkippedDuplicates,
 	}
 }
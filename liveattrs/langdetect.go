@@ -0,0 +1,434 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"fmt"
+	"masm/v3/jobs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	LangDetectJobType = "liveattrs-lang-detection"
+
+	// DfltLangDetectAttr is a default name of a derived attribute
+	// (within the document structure) storing detected languages
+	DfltLangDetectAttr = "lang_detected"
+
+	// DfltLangDetectMaxChars is a default max. size of a text sample
+	// (in characters) taken from the beginning of each document
+	DfltLangDetectMaxChars = 2000
+
+	// MinLangDetectMaxChars is the smallest allowed max. size of a text
+	// sample - shorter samples cannot be reliably identified by LangDetector
+	MinLangDetectMaxChars = 200
+
+	// minLangDetectHits specifies how many stopwords (or words with
+	// characters typical for a language) must be found in a sample
+	// so the language can be decided
+	minLangDetectHits = 3
+
+	// minLangDetectWords specifies how many words a sample must
+	// have so its language can be decided
+	minLangDetectWords = 10
+)
+
+var structAttrRegexp = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_\-]*)="([^"]*)"`)
+
+// langStopwords contains very frequent words of supported languages.
+// The lists are intentionally short - for document-sized samples,
+// they are sufficient to tell languages apart.
+var langStopwords = map[string][]string{
+	"cs": {"a", "je", "se", "na", "v", "že", "to", "s", "z", "do", "o", "jako", "ale", "by", "jsem",
+		"jsou", "k", "pro", "který", "které", "také", "jeho", "byl", "bylo", "až", "už", "jsme", "není", "když", "tak"},
+	"sk": {"a", "je", "sa", "na", "v", "že", "to", "s", "z", "do", "o", "ako", "ale", "by", "som",
+		"sú", "k", "pre", "ktorý", "ktoré", "tiež", "jeho", "bol", "bolo", "už", "nie", "keď", "tak", "aj", "alebo"},
+	"pl": {"i", "w", "się", "nie", "na", "z", "że", "do", "to", "jest", "jak", "ale", "o", "co", "tak",
+		"po", "od", "przez", "dla", "jego", "był", "być", "oraz", "już", "czy", "są", "tylko", "który", "która", "także"},
+	"en": {"the", "of", "and", "to", "in", "is", "that", "it", "for", "was", "on", "with", "as", "be", "by",
+		"at", "this", "are", "from", "or", "an", "have", "not", "which", "but", "his", "they", "were", "has", "been"},
+	"de": {"der", "die", "und", "in", "den", "von", "zu", "das", "mit", "sich", "des", "auf", "für", "ist", "im",
+		"dem", "nicht", "ein", "eine", "als", "auch", "es", "an", "werden", "aus", "er", "hat", "dass", "sie", "nach"},
+	"fr": {"le", "la", "de", "et", "les", "des", "en", "un", "une", "du", "est", "que", "dans", "qui", "pour",
+		"pas", "au", "sur", "par", "ne", "se", "il", "ce", "avec", "sont", "plus", "mais", "ou", "aux", "été"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "del", "se", "las", "un", "por", "con", "no", "una",
+		"su", "para", "es", "al", "lo", "como", "más", "pero", "sus", "le", "ya", "o", "fue", "este", "ha"},
+	"it": {"il", "di", "che", "e", "la", "in", "un", "per", "non", "una", "del", "con", "si", "da", "sono",
+		"le", "della", "al", "come", "anche", "gli", "è", "ma", "più", "nel", "alla", "questo", "dei", "lo", "ha"},
+	"pt": {"de", "que", "o", "a", "e", "do", "da", "em", "um", "para", "com", "não", "uma", "os", "no",
+		"se", "na", "por", "mais", "as", "dos", "como", "mas", "ao", "ele", "das", "à", "seu", "sua", "ou"},
+	"nl": {"de", "en", "van", "het", "een", "in", "is", "dat", "op", "te", "zijn", "met", "voor", "niet", "die",
+		"er", "aan", "ook", "als", "bij", "door", "maar", "om", "dan", "wordt", "nog", "uit", "of", "worden", "was"},
+	"hu": {"a", "az", "és", "hogy", "nem", "is", "egy", "meg", "de", "van", "volt", "csak", "már", "ez", "mint",
+		"még", "ki", "el", "azt", "kell", "mert", "vagy", "ha", "sem", "pedig", "lesz", "fel", "nagyon", "ezt", "minden"},
+	"ru": {"и", "в", "не", "на", "что", "с", "он", "как", "по", "это", "но", "к", "из", "у", "за",
+		"от", "же", "все", "так", "его", "для", "было", "она", "только", "бы", "был", "уже", "или", "мы", "они"},
+	"uk": {"і", "в", "не", "на", "що", "з", "та", "як", "до", "це", "але", "й", "у", "за", "від",
+		"він", "його", "для", "так", "вже", "було", "був", "вона", "які", "який", "при", "ще", "також", "або", "ми"},
+}
+
+// langChars contains characters typical for a language (i.e. not used
+// by the other supported languages sharing the same stopwords). They
+// help with closely related languages (cs/sk, ru/uk).
+var langChars = map[string]string{
+	"cs": "řůě",
+	"sk": "äľĺŕô",
+	"pl": "ąęłśźżń",
+	"hu": "őű",
+	"de": "ß",
+	"ru": "ыэёъ",
+	"uk": "їєґі",
+}
+
+// LangDetector identifies a language of a text sample based
+// on stopwords and characters typical for supported languages.
+// It is intended for document-sized samples of the supported
+// languages only - short texts are left undecided and texts
+// in other languages may be identified as a related supported
+// language.
+type LangDetector struct {
+	stopwords map[string][]string
+}
+
+// Detect returns an ISO 639-1 code of a language of the `text`.
+// In case the language cannot be decided (too short text, an unsupported
+// language, a tie between languages), an empty string is returned.
+// The text is normalized first so decomposed characters (a letter
+// followed by a combining mark) are handled the same way as
+// the precomposed ones.
+func (ld *LangDetector) Detect(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(norm.NFC.String(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r)
+	})
+	if len(words) < minLangDetectWords {
+		return ""
+	}
+	for _, word := range words {
+		for _, lang := range ld.stopwords[word] {
+			scores[lang]++
+		}
+		for lang, chars := range langChars {
+			if strings.ContainsAny(word, chars) {
+				scores[lang]++
+			}
+		}
+	}
+	var best, second int
+	var ans string
+	for lang, score := range scores {
+		if score > best {
+			second = best
+			best = score
+			ans = lang
+
+		} else if score > second {
+			second = score
+		}
+	}
+	if best < minLangDetectHits || best == second {
+		return ""
+	}
+	return ans
+}
+
+// SupportedLanguages returns sorted codes of languages
+// the detector is able to identify
+func (ld *LangDetector) SupportedLanguages() []string {
+	ans := make([]string, 0, len(langStopwords))
+	for lang := range langStopwords {
+		ans = append(ans, lang)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// NewLangDetector creates a detector with built-in language profiles
+func NewLangDetector() *LangDetector {
+	ans := &LangDetector{stopwords: make(map[string][]string)}
+	for lang, words := range langStopwords {
+		for _, w := range words {
+			ans.stopwords[w] = append(ans.stopwords[w], lang)
+		}
+	}
+	return ans
+}
+
+// DocumentSample is a beginning of a document's text
+type DocumentSample struct {
+	ID   string
+	Text string
+}
+
+type docSampler struct {
+	docStruct string
+	idAttr    string
+	maxChars  int
+	numChars  int
+	inDoc     bool
+	curr      DocumentSample
+	text      strings.Builder
+	fn        func(DocumentSample) error
+}
+
+func (ds *docSampler) processLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+	if m := structCloseRegexp.FindStringSubmatch(trimmed); m != nil {
+		if m[1] == ds.docStruct && ds.inDoc {
+			ds.inDoc = false
+			ds.curr.Text = ds.text.String()
+			return ds.fn(ds.curr)
+		}
+		return nil
+
+	} else if m := structOpenRegexp.FindStringSubmatch(trimmed); m != nil {
+		if m[1] == ds.docStruct {
+			ds.inDoc = true
+			ds.curr = DocumentSample{}
+			ds.text.Reset()
+			ds.numChars = 0
+			for _, am := range structAttrRegexp.FindAllStringSubmatch(m[2], -1) {
+				if am[1] == ds.idAttr {
+					ds.curr.ID = am[2]
+					break
+				}
+			}
+		}
+		return nil
+	}
+	if !ds.inDoc || ds.numChars >= ds.maxChars || strings.HasPrefix(trimmed, "<") {
+		return nil
+	}
+	word, _, _ := strings.Cut(line, "\t")
+	if word == "" {
+		return nil
+	}
+	if ds.numChars > 0 {
+		ds.text.WriteByte(' ')
+		ds.numChars++
+	}
+	ds.text.WriteString(word)
+	ds.numChars += utf8.RuneCountInString(word)
+	return nil
+}
+
+// SampleDocuments reads vertical files and calls `fn` for each document
+// (a structure `docStruct`) with the document's ID (a value of the
+// attribute `idAttr`) and a text sample made of its positions (the first
+// column of the vertical). The sample consists of whole positions and it ends
+// with the first position reaching `maxChars` characters.
+// Compressed verticals are supported.
+func SampleDocuments(
+	paths []string,
+	docStruct string,
+	idAttr string,
+	maxChars int,
+	fn func(DocumentSample) error,
+) error {
+	ds := &docSampler{
+		docStruct: docStruct,
+		idAttr:    idAttr,
+		maxChars:  maxChars,
+		fn:        fn,
+	}
+	for _, path := range paths {
+		if err := ds.sampleFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ds *docSampler) sampleFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rd, _, err := decompressedReader(f, path)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := ds.processLine(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+type LangDetectJobInfoArgs struct {
+	// Struct is a structure representing documents
+	Struct string `json:"struct"`
+
+	// IDAttr is an attribute of Struct identifying documents
+	// (values are matched with the respective liveattrs column)
+	IDAttr string `json:"idAttr"`
+
+	// Attr is a name of a derived attribute of Struct
+	// the detected languages are stored to
+	Attr string `json:"attr"`
+
+	MaxChars int `json:"maxChars"`
+}
+
+// DerivedAttr returns the derived attribute in the dot notation
+func (args LangDetectJobInfoArgs) DerivedAttr() string {
+	return args.Struct + "." + args.Attr
+}
+
+// LangDetectResult summarizes a language detection job
+type LangDetectResult struct {
+	Attr           string         `json:"attr"`
+	NumDocs        int            `json:"numDocs"`
+	NumUndetected  int            `json:"numUndetected"`
+	NumMissingID   int            `json:"numMissingId"`
+	NumUpdatedRows int64          `json:"numUpdatedRows"`
+	Languages      map[string]int `json:"languages"`
+	SupportedLangs []string       `json:"supportedLangs"`
+}
+
+// Add records a detected language of a document
+func (r *LangDetectResult) Add(lang string) {
+	r.NumDocs++
+	if lang == "" {
+		r.NumUndetected++
+		return
+	}
+	r.Languages[lang]++
+}
+
+// LangDetectJobInfo collects information about a job detecting
+// languages of documents and storing them as a derived attribute
+type LangDetectJobInfo struct {
+	ID          string                `json:"id"`
+	Type        string                `json:"type"`
+	CorpusID    string                `json:"corpusId"`
+	Start       jobs.JSONTime         `json:"start"`
+	Update      jobs.JSONTime         `json:"update"`
+	Finished    bool                  `json:"finished"`
+	Error       error                 `json:"error,omitempty"`
+	NumRestarts int                   `json:"numRestarts"`
+	Args        LangDetectJobInfoArgs `json:"args"`
+	Result      *LangDetectResult     `json:"result"`
+}
+
+func (j LangDetectJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j LangDetectJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j LangDetectJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j LangDetectJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j LangDetectJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j LangDetectJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j LangDetectJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j LangDetectJobInfo) FullInfo() any {
+	return struct {
+		ID          string                `json:"id"`
+		Type        string                `json:"type"`
+		CorpusID    string                `json:"corpusId"`
+		Start       jobs.JSONTime         `json:"start"`
+		Update      jobs.JSONTime         `json:"update"`
+		Finished    bool                  `json:"finished"`
+		Error       string                `json:"error,omitempty"`
+		OK          bool                  `json:"ok"`
+		NumRestarts int                   `json:"numRestarts"`
+		Args        LangDetectJobInfoArgs `json:"args"`
+		Result      *LangDetectResult     `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
+
+func (j LangDetectJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j LangDetectJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j LangDetectJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return LangDetectJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
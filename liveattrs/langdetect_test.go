@@ -0,0 +1,101 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLangDetectorDetect(t *testing.T) {
+	ld := NewLangDetector()
+	assert.Equal(t, "en", ld.Detect("The history of the city is long and it was one of the most important places in the region."))
+	assert.Equal(t, "cs", ld.Detect("Byl to dlouhý den a když přišel domů, bylo už pozdě. Řekl, že je unavený."))
+	assert.Equal(t, "sk", ld.Detect("Bol to dlhý deň a keď prišiel domov, bolo už neskoro. Povedal, že je unavený a nie je hladný."))
+	assert.Equal(t, "de", ld.Detect("Der Hund und die Katze sind nicht im Haus, weil es draußen schön ist."))
+	assert.Equal(t, "ru", ld.Detect("Он сказал, что это было только начало, и мы уже не могли остановиться."))
+}
+
+func TestLangDetectorUndecided(t *testing.T) {
+	ld := NewLangDetector()
+	assert.Equal(t, "", ld.Detect(""))
+	assert.Equal(t, "", ld.Detect("Lorem ipsum dolor sit amet"))
+	assert.Equal(t, "", ld.Detect("12 345 678"))
+}
+
+func TestLangDetectorShortInputs(t *testing.T) {
+	ld := NewLangDetector()
+	assert.Equal(t, "", ld.Detect("je"))
+	assert.Equal(t, "", ld.Detect("je se na"))
+	assert.Equal(t, "", ld.Detect("The cat is on the mat."))
+	assert.Equal(t, "", ld.Detect("Řekl, že je unavený a že se vrátí."))
+}
+
+func TestLangDetectorDecomposedText(t *testing.T) {
+	ld := NewLangDetector()
+	// "ř" and "ě" as letters followed by combining carons
+	text := "Byl to dlouhy\u0301 den a kdyz\u030c pr\u030cis\u030cel domu\u030a, bylo uz\u030c pozde\u030c."
+	assert.Equal(t, "cs", ld.Detect(text))
+}
+
+func TestSampleDocuments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert")
+	err := os.WriteFile(path, []byte(testVertical), 0644)
+	assert.NoError(t, err)
+	samples := make([]DocumentSample, 0, 3)
+	err = SampleDocuments([]string{path}, "doc", "id", 100, func(s DocumentSample) error {
+		samples = append(samples, s)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]DocumentSample{{ID: "1", Text: "word1"}, {ID: "2", Text: "word2"}, {ID: "3", Text: "word3"}},
+		samples,
+	)
+}
+
+func TestSampleDocumentsMaxChars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert")
+	err := os.WriteFile(path, []byte("<doc n=\"x\">\naaa\tA\nbbb\tB\nccc\tC\n</doc>\n"), 0644)
+	assert.NoError(t, err)
+	var sample DocumentSample
+	err = SampleDocuments([]string{path}, "doc", "id", 5, func(s DocumentSample) error {
+		sample = s
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentSample{Text: "aaa bbb"}, sample)
+}
+
+func TestSampleDocumentsMaxCharsMultibyte(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert")
+	err := os.WriteFile(path, []byte("<doc n=\"x\">\nřeč\tA\nžít\tB\npád\tC\n</doc>\n"), 0644)
+	assert.NoError(t, err)
+	var sample DocumentSample
+	err = SampleDocuments([]string{path}, "doc", "id", 5, func(s DocumentSample) error {
+		sample = s
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentSample{Text: "řeč žít"}, sample)
+}
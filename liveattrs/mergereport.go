@@ -0,0 +1,51 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+// CorpusMergeStats describes how well atoms of a single corpus
+// were joined with atoms of other (aligned) corpora via
+// the self-join key (a.k.a. item_id).
+type CorpusMergeStats struct {
+	CorpusID string `json:"corpusId"`
+
+	// NumAtoms is the total number of atoms (typically sentences)
+	NumAtoms int `json:"numAtoms"`
+
+	// NumWithKey is the number of atoms with a non-empty join key
+	NumWithKey int `json:"numWithKey"`
+
+	// NumDuplicateKeys is the number of distinct join keys
+	// used by more than one atom of the corpus
+	NumDuplicateKeys int `json:"numDuplicateKeys"`
+
+	// NumUnmatched is the number of atoms with a join key
+	// not found in any other corpus
+	NumUnmatched int `json:"numUnmatched"`
+
+	// UnmatchedRatio is NumUnmatched / NumWithKey
+	UnmatchedRatio float64 `json:"unmatchedRatio"`
+}
+
+// MergeReport is a verification report of a parallel corpus
+// build with configured mergeAttrs (self-join). As failed
+// joins do not produce any errors during data extraction,
+// the report is the only way to detect them.
+type MergeReport struct {
+	Corpora []*CorpusMergeStats `json:"corpora"`
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"masm/v3/jobs"
+	"os"
+	"time"
+)
+
+// numSampleLines is a number of lines read from a vertical file
+// to estimate its total number of lines
+const numSampleLines = 100000
+
+// countingReader counts bytes read from the wrapped reader
+type countingReader struct {
+	r        io.Reader
+	numBytes int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.numBytes += int64(n)
+	return n, err
+}
+
+// estimateFileLines estimates the number of lines of a (possibly compressed)
+// vertical file by reading its first numSampleLines lines and extrapolating
+// the number of (raw file) bytes per line to the whole file size.
+func estimateFileLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	finfo, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	counter := &countingReader{r: f}
+	rd, compressed, err := decompressedReader(counter, path)
+	if err != nil {
+		return 0, err
+	}
+	defer rd.Close()
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var numLines int
+	var numLineBytes int64
+	for numLines < numSampleLines && scanner.Scan() {
+		numLines++
+		numLineBytes += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if numLines < numSampleLines {
+		// the whole file has been read
+		return numLines, nil
+	}
+	sampleSize := numLineBytes
+	if compressed {
+		// for compressed files, we can only use the number of compressed
+		// bytes read so far (including the scanner's read-ahead); the reader
+		// must be closed first as external decompressors read concurrently
+		rd.Close()
+		sampleSize = counter.numBytes
+	}
+	if sampleSize == 0 {
+		return numLines, nil
+	}
+	return int(float64(finfo.Size()) / float64(sampleSize) * float64(numLines)), nil
+}
+
+// EstimateNumLines estimates the total number of lines
+// of provided vertical files
+func EstimateNumLines(paths []string) (int, error) {
+	var ans int
+	for _, path := range paths {
+		n, err := estimateFileLines(path)
+		if err != nil {
+			return 0, err
+		}
+		ans += n
+	}
+	return ans, nil
+}
+
+// estimateCompletion calculates a processing throughput (lines per second)
+// and an estimated time of completion. In case the completion cannot be
+// estimated (e.g. nothing processed yet or the total number of lines
+// is unknown or already exceeded), zero time is returned.
+func estimateCompletion(
+	processedLines, totalLines int,
+	elapsed time.Duration,
+	now time.Time,
+) (float64, time.Time) {
+	if elapsed <= 0 || processedLines == 0 {
+		return 0, time.Time{}
+	}
+	rate := float64(processedLines) / elapsed.Seconds()
+	if totalLines <= processedLines {
+		return rate, time.Time{}
+	}
+	remaining := time.Duration(float64(totalLines-processedLines) / rate * float64(time.Second))
+	return rate, now.Add(remaining)
+}
+
+// UpdateProgress sets the number of processed lines and atoms
+// and recalculates the throughput and the estimated time
+// of completion.
+func (j *LiveAttrsJobInfo) UpdateProgress(processedAtoms, processedLines int) {
+	now := time.Now()
+	j.ProcessedAtoms = processedAtoms
+	j.ProcessedLines = processedLines
+	j.Update = jobs.JSONTime(now)
+	rate, eta := estimateCompletion(
+		processedLines, j.EstimatedNumLines, now.Sub(time.Time(j.Start)), now)
+	j.LinesPerSecond = rate
+	j.EstimatedEnd = jobs.JSONTime(eta)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateNumLinesSmallFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert.txt")
+	err := os.WriteFile(path, []byte("<doc>\nfoo\nbar\n</doc>\n"), 0644)
+	assert.NoError(t, err)
+	n, err := EstimateNumLines([]string{path, path})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, n)
+}
+
+func TestEstimateNumLinesExtrapolates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert.txt")
+	line := "word\tlemma\ttag\n"
+	err := os.WriteFile(path, []byte(strings.Repeat(line, 3*numSampleLines)), 0644)
+	assert.NoError(t, err)
+	n, err := EstimateNumLines([]string{path})
+	assert.NoError(t, err)
+	assert.InDelta(t, 3*numSampleLines, n, 0.01*numSampleLines)
+}
+
+func TestEstimateNumLinesGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert.gz")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	wr := gzip.NewWriter(f)
+	_, err = wr.Write([]byte("foo\nbar\nbaz\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, wr.Close())
+	assert.NoError(t, f.Close())
+	n, err := EstimateNumLines([]string{path})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestEstimateNumLinesMissingFile(t *testing.T) {
+	_, err := EstimateNumLines([]string{filepath.Join(t.TempDir(), "nonexistent")})
+	assert.Error(t, err)
+}
+
+func TestEstimateCompletion(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rate, eta := estimateCompletion(1000, 3000, 10*time.Second, now)
+	assert.InDelta(t, 100.0, rate, 0.0001)
+	assert.Equal(t, now.Add(20*time.Second), eta)
+}
+
+func TestEstimateCompletionUnknown(t *testing.T) {
+	now := time.Now()
+	rate, eta := estimateCompletion(0, 3000, 10*time.Second, now)
+	assert.Equal(t, 0.0, rate)
+	assert.True(t, eta.IsZero())
+
+	rate, eta = estimateCompletion(5000, 3000, 10*time.Second, now)
+	assert.InDelta(t, 500.0, rate, 0.0001)
+	assert.True(t, eta.IsZero())
+}
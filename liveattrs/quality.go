@@ -0,0 +1,264 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"masm/v3/jobs"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	QualityJobType = "liveattrs-quality"
+
+	// maxQualitySamples is a max. number of suspicious values
+	// reported for a single attribute
+	maxQualitySamples = 5
+)
+
+// AttrQuality contains data quality metrics of a single
+// structural attribute. All the counts are numbers of liveattrs
+// entries (i.e. atoms), not distinct values.
+type AttrQuality struct {
+	Attr string `json:"attr"`
+
+	NumRows int `json:"numRows"`
+
+	// NumFilled is the number of entries with a value different
+	// from NULL, empty string and configured placeholders
+	NumFilled int `json:"numFilled"`
+
+	FillRate float64 `json:"fillRate"`
+
+	// NumMissing is the number of entries with NULL or empty value
+	NumMissing int `json:"numMissing"`
+
+	// NumPlaceholders is the number of entries with a value
+	// used to express "unknown" (e.g. "?", "N/A")
+	NumPlaceholders int `json:"numPlaceholders"`
+
+	PlaceholderRatio float64 `json:"placeholderRatio"`
+
+	// Cardinality is the number of distinct filled values
+	Cardinality int `json:"cardinality"`
+
+	// NumWhitespaceIssues is the number of entries with leading,
+	// trailing or repeated whitespace
+	NumWhitespaceIssues int `json:"numWhitespaceIssues"`
+
+	// NumControlChars is the number of entries containing
+	// control characters (e.g. tab, newline)
+	NumControlChars int `json:"numControlChars"`
+
+	SuspiciousSamples []string `json:"suspiciousSamples"`
+}
+
+// AddValue registers `count` entries with the `value`. NULL values
+// are expected to be passed as empty strings.
+func (aq *AttrQuality) AddValue(value string, count int, placeholders []string) {
+	aq.NumRows += count
+	if value == "" {
+		aq.NumMissing += count
+		return
+	}
+	for _, p := range placeholders {
+		if value == p {
+			aq.NumPlaceholders += count
+			return
+		}
+	}
+	aq.NumFilled += count
+	aq.Cardinality++
+	suspicious := false
+	if HasWhitespaceIssue(value) {
+		aq.NumWhitespaceIssues += count
+		suspicious = true
+	}
+	if HasControlChars(value) {
+		aq.NumControlChars += count
+		suspicious = true
+	}
+	if suspicious && len(aq.SuspiciousSamples) < maxQualitySamples {
+		aq.SuspiciousSamples = append(aq.SuspiciousSamples, value)
+	}
+}
+
+// Finalize calculates ratios once all the values are added
+func (aq *AttrQuality) Finalize() {
+	if aq.SuspiciousSamples == nil {
+		aq.SuspiciousSamples = []string{}
+	}
+	if aq.NumRows == 0 {
+		return
+	}
+	aq.FillRate = float64(aq.NumFilled) / float64(aq.NumRows)
+	aq.PlaceholderRatio = float64(aq.NumPlaceholders) / float64(aq.NumRows)
+}
+
+// HasWhitespaceIssue tests whether a value contains leading,
+// trailing or repeated whitespace
+func HasWhitespaceIssue(value string) bool {
+	if strings.TrimSpace(value) != value {
+		return true
+	}
+	prevSpace := false
+	for _, r := range value {
+		isSpace := unicode.IsSpace(r)
+		if isSpace && prevSpace {
+			return true
+		}
+		prevSpace = isSpace
+	}
+	return false
+}
+
+// HasControlChars tests whether a value contains control characters
+func HasControlChars(value string) bool {
+	return strings.IndexFunc(value, unicode.IsControl) >= 0
+}
+
+// QualityReport contains data quality metrics of all
+// the structural attributes of a corpus
+type QualityReport struct {
+	CorpusID string        `json:"corpusId"`
+	Created  time.Time     `json:"created"`
+	Attrs    []AttrQuality `json:"attrs"`
+}
+
+// SortByIssues sorts attributes so the ones with the most
+// problematic entries come first. This is the order in which
+// metadata cleanup is expected to be most useful.
+func (qr *QualityReport) SortByIssues() {
+	numIssues := func(aq AttrQuality) int {
+		return aq.NumMissing + aq.NumPlaceholders + aq.NumWhitespaceIssues + aq.NumControlChars
+	}
+	sort.SliceStable(qr.Attrs, func(i, j int) bool {
+		ni, nj := numIssues(qr.Attrs[i]), numIssues(qr.Attrs[j])
+		if ni != nj {
+			return ni > nj
+		}
+		return qr.Attrs[i].Attr < qr.Attrs[j].Attr
+	})
+}
+
+type QualityJobInfoArgs struct {
+	Placeholders []string `json:"placeholders"`
+}
+
+// QualityJobInfo collects information about a job calculating
+// data quality metrics of a corpus
+type QualityJobInfo struct {
+	ID          string             `json:"id"`
+	Type        string             `json:"type"`
+	CorpusID    string             `json:"corpusId"`
+	Start       jobs.JSONTime      `json:"start"`
+	Update      jobs.JSONTime      `json:"update"`
+	Finished    bool               `json:"finished"`
+	Error       error              `json:"error,omitempty"`
+	NumRestarts int                `json:"numRestarts"`
+	Args        QualityJobInfoArgs `json:"args"`
+}
+
+func (j QualityJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j QualityJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j QualityJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j QualityJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j QualityJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j QualityJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j QualityJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j QualityJobInfo) FullInfo() any {
+	return struct {
+		ID          string             `json:"id"`
+		Type        string             `json:"type"`
+		CorpusID    string             `json:"corpusId"`
+		Start       jobs.JSONTime      `json:"start"`
+		Update      jobs.JSONTime      `json:"update"`
+		Finished    bool               `json:"finished"`
+		Error       string             `json:"error,omitempty"`
+		OK          bool               `json:"ok"`
+		NumRestarts int                `json:"numRestarts"`
+		Args        QualityJobInfoArgs `json:"args"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+	}
+}
+
+func (j QualityJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j QualityJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j QualityJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return QualityJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+	}
+}
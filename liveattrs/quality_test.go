@@ -0,0 +1,85 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasWhitespaceIssue(t *testing.T) {
+	assert.False(t, HasWhitespaceIssue("Karel Čapek"))
+	assert.True(t, HasWhitespaceIssue(" Karel Čapek"))
+	assert.True(t, HasWhitespaceIssue("Karel Čapek "))
+	assert.True(t, HasWhitespaceIssue("Karel  Čapek"))
+	assert.False(t, HasWhitespaceIssue(""))
+}
+
+func TestHasControlChars(t *testing.T) {
+	assert.False(t, HasControlChars("Karel Čapek"))
+	assert.True(t, HasControlChars("Karel\tČapek"))
+	assert.True(t, HasControlChars("Karel\u0000"))
+}
+
+func TestAttrQualityAddValue(t *testing.T) {
+	aq := AttrQuality{Attr: "doc.author"}
+	placeholders := []string{"?", "N/A"}
+	aq.AddValue("", 10, placeholders)
+	aq.AddValue("?", 5, placeholders)
+	aq.AddValue("N/A", 5, placeholders)
+	aq.AddValue("Karel Čapek", 50, placeholders)
+	aq.AddValue("Karel Čapek ", 20, placeholders)
+	aq.AddValue("Božena\tNěmcová", 10, placeholders)
+	aq.Finalize()
+	assert.Equal(t, 100, aq.NumRows)
+	assert.Equal(t, 80, aq.NumFilled)
+	assert.Equal(t, 10, aq.NumMissing)
+	assert.Equal(t, 10, aq.NumPlaceholders)
+	assert.Equal(t, 3, aq.Cardinality)
+	assert.Equal(t, 20, aq.NumWhitespaceIssues)
+	assert.Equal(t, 10, aq.NumControlChars)
+	assert.InDelta(t, 0.8, aq.FillRate, 0.0001)
+	assert.InDelta(t, 0.1, aq.PlaceholderRatio, 0.0001)
+	assert.Equal(t, []string{"Karel Čapek ", "Božena\tNěmcová"}, aq.SuspiciousSamples)
+}
+
+func TestAttrQualityFinalizeEmpty(t *testing.T) {
+	aq := AttrQuality{Attr: "doc.author"}
+	aq.Finalize()
+	assert.Equal(t, 0.0, aq.FillRate)
+	assert.Equal(t, []string{}, aq.SuspiciousSamples)
+}
+
+func TestQualityReportSortByIssues(t *testing.T) {
+	qr := QualityReport{
+		Attrs: []AttrQuality{
+			{Attr: "doc.title", NumMissing: 1},
+			{Attr: "doc.author", NumPlaceholders: 5, NumControlChars: 1},
+			{Attr: "doc.year"},
+			{Attr: "doc.id"},
+		},
+	}
+	qr.SortByIssues()
+	attrs := make([]string, len(qr.Attrs))
+	for i, aq := range qr.Attrs {
+		attrs[i] = aq.Attr
+	}
+	assert.Equal(t, []string{"doc.author", "doc.title", "doc.id", "doc.year"}, attrs)
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package cooccurrence
+
+import "masm/v3/liveattrs/request/query"
+
+// Payload represents arguments of the attribute co-occurrence HTTP API endpoint
+type Payload struct {
+	Attrs       query.Attrs `json:"attrs"`
+	Aligned     []string    `json:"aligned"`
+	TargetAttrs []string    `json:"targetAttrs"`
+	MaxValues   int         `json:"maxValues"`
+}
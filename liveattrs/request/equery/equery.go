@@ -18,10 +18,51 @@
 
 package equery
 
-import "masm/v3/liveattrs/request/query"
+import (
+	"fmt"
+	"masm/v3/liveattrs/request/query"
+)
+
+// SizeUnit specifies how a subcorpus size is measured
+type SizeUnit string
+
+const (
+	SizeUnitTokens    SizeUnit = "tokens"
+	SizeUnitDocuments SizeUnit = "documents"
+
+	// SizeUnitWords is not supported yet as vert-tagextract
+	// does not fill in the `wordcount` column
+	SizeUnitWords SizeUnit = "words"
+
+	// SizeUnitPositions is an alias for SizeUnitTokens
+	SizeUnitPositions SizeUnit = "positions"
+)
+
+// Validate tests whether the unit is supported. An empty
+// value is considered valid (see Normalized).
+func (u SizeUnit) Validate() error {
+	switch u {
+	case "", SizeUnitTokens, SizeUnitPositions, SizeUnitDocuments:
+		return nil
+	case SizeUnitWords:
+		return fmt.Errorf("size unit %s is not supported yet (word counts are not available)", u)
+	}
+	return fmt.Errorf("unsupported size unit: %s", u)
+}
+
+// Normalized returns the unit with the default
+// value (tokens) applied in case it is empty and
+// with aliases resolved.
+func (u SizeUnit) Normalized() SizeUnit {
+	if u == "" || u == SizeUnitPositions {
+		return SizeUnitTokens
+	}
+	return u
+}
 
 type Payload struct {
 	Corpname string      `json:"corpname"`
 	Aligned  []string    `json:"aligned"`
 	Attrs    query.Attrs `json:"attrs"`
+	Unit     SizeUnit    `json:"unit"`
 }
@@ -0,0 +1,40 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package equery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeUnitValidate(t *testing.T) {
+	assert.NoError(t, SizeUnit("").Validate())
+	assert.NoError(t, SizeUnitTokens.Validate())
+	assert.NoError(t, SizeUnitPositions.Validate())
+	assert.NoError(t, SizeUnitDocuments.Validate())
+	assert.Error(t, SizeUnitWords.Validate())
+	assert.Error(t, SizeUnit("chars").Validate())
+}
+
+func TestSizeUnitNormalized(t *testing.T) {
+	assert.Equal(t, SizeUnitTokens, SizeUnit("").Normalized())
+	assert.Equal(t, SizeUnitTokens, SizeUnitPositions.Normalized())
+	assert.Equal(t, SizeUnitDocuments, SizeUnitDocuments.Normalized())
+}
@@ -18,8 +18,14 @@
 
 package fillattrs
 
+import "masm/v3/liveattrs/request/query"
+
 type Payload struct {
 	Search string   `json:"search"`
 	Values []string `json:"values"`
 	Fill   []string `json:"fill"`
+
+	// Subcorpus (if specified) restricts searched
+	// entries to a subset of the corpus
+	Subcorpus *query.Subcorpus `json:"subcorpus"`
 }
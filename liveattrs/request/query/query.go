@@ -20,33 +20,118 @@ package query
 
 import (
 	"fmt"
+	"strconv"
 )
 
 // Attrs represents a user selection of text types
 // The values can be of different types. To handle them
 // in a more convenient way, the type contains helper methods
-// (GetRegexpAttrVal, GetListingOf).
+// (GetOperatorAttrVal, GetRegexpAttrVal, GetRangeAttrVal, GetNegatedValues,
+// GetListingOf).
 type Attrs map[string]any
 
+const (
+	OperatorRegexp   = "regexp"
+	OperatorWildcard = "wildcard"
+	OperatorNot      = "not"
+)
+
+// valueToString converts a decoded JSON value to a string. Numbers
+// are formatted without an exponent (JSON numbers are decoded as float64
+// so e.g. 1000000 would become "1e+06" otherwise).
+func valueToString(v any) string {
+	if tv, ok := v.(float64); ok {
+		return strconv.FormatFloat(tv, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// GetOperatorAttrVal tries to extract an operator-based value
+// (e.g. `{"regexp": "^Nov.*"}` or `{"wildcard": "Nov*"}`) from Attrs
+// under the 'attr' key. In case the type matches, the operator and
+// its argument are returned along with true. In any other case,
+// false is returned as the third value.
+func (q Attrs) GetOperatorAttrVal(attr string) (string, string, bool) {
+	v, ok := q[attr]
+	if !ok {
+		return "", "", false
+	}
+	tm, ok := v.(map[string]any)
+	if !ok {
+		return "", "", false
+	}
+	for _, op := range []string{OperatorRegexp, OperatorWildcard} {
+		tv, ok := tm[op].(string)
+		if ok && tv != "" {
+			return op, tv, true
+		}
+	}
+	return "", "", false
+}
+
 // GetRegexpAttrVal tries to extract value of a regular
 // expression from Attrs under the 'attr' key. In case
 // the type matches (i.e. there is a regexp value stored
 // in q[attr]), a respective value is returned long with true.
 // In any other case, false is returned as the second value.
 func (q Attrs) GetRegexpAttrVal(attr string) (string, bool) {
+	op, v, ok := q.GetOperatorAttrVal(attr)
+	if ok && op == OperatorRegexp {
+		return v, true
+	}
+	return "", false
+}
+
+// GetRangeAttrVal tries to extract range boundaries from Attrs
+// under the 'attr' key (e.g. `{"from": "2001-01-01", "to": "2005-12-31"}`).
+// Any of the boundaries may be omitted (in such case, an empty string
+// is returned for the boundary) but at least one of them must be present.
+// In case the type matches, the boundaries are returned along with true.
+func (q Attrs) GetRangeAttrVal(attr string) (string, string, bool) {
 	v, ok := q[attr]
 	if !ok {
-		return "", false
+		return "", "", false
 	}
 	tm, ok := v.(map[string]any)
-	if ok && tm["regexp"] != "" {
-		v := tm["regexp"]
-		tv, ok := v.(string)
-		if ok {
-			return tv, true
+	if !ok {
+		return "", "", false
+	}
+	var from, to string
+	if tv, ok := tm["from"]; ok && tv != nil {
+		from = valueToString(tv)
+	}
+	if tv, ok := tm["to"]; ok && tv != nil {
+		to = valueToString(tv)
+	}
+	return from, to, from != "" || to != ""
+}
+
+// GetNegatedValues tries to extract a list of excluded values
+// from Attrs under the 'attr' key (e.g. `{"not": ["fiction", "poetry"]}`
+// or `{"not": "fiction"}`). In case the type matches, the values
+// are returned along with true. In any other case, false is returned
+// as the second value.
+func (q Attrs) GetNegatedValues(attr string) ([]string, bool) {
+	v, ok := q[attr]
+	if !ok {
+		return []string{}, false
+	}
+	tm, ok := v.(map[string]any)
+	if !ok {
+		return []string{}, false
+	}
+	switch tv := tm[OperatorNot].(type) {
+	case string:
+		return []string{tv}, true
+	case []any:
+		ans := make([]string, len(tv))
+		for i, item := range tv {
+			// gracefully ignore typing problems here
+			ans[i] = valueToString(item)
 		}
+		return ans, len(ans) > 0
 	}
-	return "", false
+	return []string{}, false
 }
 
 // GetListingOf returns a list of strings (= selected values) for
@@ -75,16 +160,94 @@ func (q Attrs) GetListingOf(attr string) ([]string, error) {
 
 		} else {
 			// gracefully ignore typing problems here
-			ans[i] = fmt.Sprintf("%v", v)
+			ans[i] = valueToString(v)
 		}
 	}
 	return ans, nil
 }
 
+const (
+	// SortAlpha sorts listed attribute values alphabetically
+	// (with respect to a configured locale). This is the default.
+	SortAlpha = "alpha"
+
+	// SortCount sorts listed attribute values by their counts
+	// in descending order
+	SortCount = "count"
+
+	// SortCustom sorts listed attribute values according
+	// to explicit orders configured for a corpus. Values
+	// (and attributes) without a configured order are sorted
+	// alphabetically.
+	SortCustom = "custom"
+)
+
 // Payload represents a query arguments as required by an HTTP API endpoint
 type Payload struct {
 	Aligned          []string `json:"aligned"`
 	Attrs            Attrs    `json:"attrs"`
 	AutocompleteAttr string   `json:"autocompleteAttr"`
 	MaxAttrListSize  int      `json:"maxAttrListSize"`
+
+	// IncludeDocCounts specifies whether the response should contain
+	// also numbers of atoms (documents) with non-empty attribute values
+	IncludeDocCounts bool `json:"includeDocCounts"`
+
+	// Sort specifies ordering of listed attribute values
+	// (SortAlpha, SortCount, SortCustom). An empty value
+	// means SortAlpha.
+	Sort string `json:"sort"`
+
+	// Transforms is a pipeline of transforms applied to listed
+	// values once they are aggregated and sorted
+	Transforms []Transform `json:"transforms"`
+
+	// Subcorpus (if specified) restricts the query
+	// to a subset of the corpus
+	Subcorpus *Subcorpus `json:"subcorpus"`
+}
+
+// SortOrder returns the requested ordering of listed
+// attribute values with the default applied.
+func (p Payload) SortOrder() string {
+	if p.Sort == "" {
+		return SortAlpha
+	}
+	return p.Sort
+}
+
+// TreeAttrs returns attributes with values transformed into
+// trees (see TransformTree)
+func (p Payload) TreeAttrs() []string {
+	ans := make([]string, 0, len(p.Transforms))
+	for _, t := range p.Transforms {
+		if t.Type == TransformTree {
+			ans = append(ans, t.Attr)
+		}
+	}
+	return ans
+}
+
+// Validate tests whether the payload contains supported values
+// of enumerated arguments and valid transforms.
+func (p Payload) Validate() error {
+	switch p.Sort {
+	case "", SortAlpha, SortCount, SortCustom:
+	default:
+		return fmt.Errorf("unsupported sort order: %s", p.Sort)
+	}
+	if len(p.Transforms) > MaxNumTransforms {
+		return fmt.Errorf("too many transforms (max. %d)", MaxNumTransforms)
+	}
+	for _, t := range p.Transforms {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Subcorpus != nil {
+		if err := p.Subcorpus.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
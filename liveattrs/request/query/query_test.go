@@ -0,0 +1,58 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAttrs(t *testing.T, src string) Attrs {
+	var ans Attrs
+	assert.NoError(t, json.Unmarshal([]byte(src), &ans))
+	return ans
+}
+
+func TestGetRangeAttrValLargeNumbers(t *testing.T) {
+	attrs := decodeAttrs(t, `{"doc.wordcount": {"from": 1000000, "to": 2500000.5}, "doc.id": {"to": 1e21}}`)
+	from, to, ok := attrs.GetRangeAttrVal("doc.wordcount")
+	assert.True(t, ok)
+	assert.Equal(t, "1000000", from)
+	assert.Equal(t, "2500000.5", to)
+	from, to, ok = attrs.GetRangeAttrVal("doc.id")
+	assert.True(t, ok)
+	assert.Equal(t, "", from)
+	assert.Equal(t, "1000000000000000000000", to)
+}
+
+func TestGetNegatedValuesLargeNumbers(t *testing.T) {
+	attrs := decodeAttrs(t, `{"doc.year": {"not": [1000000, "2001", 12.5]}}`)
+	values, ok := attrs.GetNegatedValues("doc.year")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"1000000", "2001", "12.5"}, values)
+}
+
+func TestGetListingOfLargeNumbers(t *testing.T) {
+	attrs := decodeAttrs(t, `{"doc.id": [1000000, "abc"]}`)
+	values, err := attrs.GetListingOf("doc.id")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1000000", "abc"}, values)
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2022 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxSubcorpusStructIDs limits number of structure IDs
+// a subcorpus can be specified by
+const MaxSubcorpusStructIDs = 50000
+
+// Subcorpus restricts a query to a subset of a corpus - typically
+// to a subcorpus stored by KonText. The subset is given by IDs
+// of structures (values of the bibliography ID attribute), by
+// a text types definition or by both (then both conditions apply).
+type Subcorpus struct {
+
+	// ID identifies the subcorpus (e.g. KonText subcorpus ID).
+	// It is used just for logging.
+	ID string `json:"id"`
+
+	StructIDs []string `json:"structIds"`
+
+	// Definition has the same format as Payload.Attrs
+	Definition Attrs `json:"definition"`
+}
+
+// Validate tests whether the subcorpus is specified in a supported way
+func (s *Subcorpus) Validate() error {
+	if len(s.StructIDs) == 0 && len(s.Definition) == 0 {
+		return errors.New("subcorpus must contain structIds or definition")
+	}
+	if len(s.StructIDs) > MaxSubcorpusStructIDs {
+		return fmt.Errorf("too many subcorpus structIds (max. %d)", MaxSubcorpusStructIDs)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// TransformGroupByPrefix merges values sharing the same prefix
+	// (either of a fixed length or up to a separator)
+	TransformGroupByPrefix = "groupByPrefix"
+
+	// TransformMergeByRegexp merges values matching a regular
+	// expression into a single value
+	TransformMergeByRegexp = "mergeByRegexp"
+
+	// TransformTopN keeps only N values with the highest counts
+	TransformTopN = "topN"
+
+	// TransformTree turns values with hierarchical labels (e.g. "fiction/novel")
+	// into a tree of nodes with aggregated counts. Attributes with the transform
+	// are never summarized (see Payload.TreeAttrs).
+	TransformTree = "tree"
+
+	// MaxNumTransforms is the max. number of transforms
+	// in a single query
+	MaxNumTransforms = 10
+)
+
+// Transform specifies a single step of a pipeline shaping listed
+// values of an attribute once the values are aggregated.
+type Transform struct {
+	Type string `json:"type"`
+	Attr string `json:"attr"`
+
+	// PrefixLength is a prefix length (in characters) for TransformGroupByPrefix
+	PrefixLength int `json:"prefixLength"`
+
+	// Separator is an alternative to PrefixLength for TransformGroupByPrefix -
+	// a prefix is a part of a value preceding the first occurrence
+	// of the separator (values without the separator are left untouched).
+	// For TransformTree, it separates levels of the hierarchy.
+	Separator string `json:"separator"`
+
+	// Pattern is a regular expression for TransformMergeByRegexp
+	Pattern string `json:"pattern"`
+
+	// Label is a label of a merged value for TransformMergeByRegexp
+	// and of the rest of values for TransformTopN (in case it is empty,
+	// the rest of values is removed)
+	Label string `json:"label"`
+
+	// N is the number of kept values for TransformTopN
+	N int `json:"n"`
+
+	// Depth is a max. depth of nodes for TransformTree (deeper nodes
+	// are only counted by their parents). Zero means no limit.
+	Depth int `json:"depth"`
+
+	// Root is a node (value) whose subtree is returned by TransformTree
+	// (an empty value means the whole tree)
+	Root string `json:"root"`
+}
+
+// Validate tests whether the transform is of a known type
+// and whether it has all the required arguments
+func (t Transform) Validate() error {
+	if t.Attr == "" {
+		return fmt.Errorf("missing attribute of transform %s", t.Type)
+	}
+	switch t.Type {
+	case TransformGroupByPrefix:
+		if (t.PrefixLength > 0) == (t.Separator != "") {
+			return fmt.Errorf("transform %s requires either prefixLength or separator", t.Type)
+		}
+		if t.PrefixLength < 0 {
+			return fmt.Errorf("invalid prefixLength of transform %s", t.Type)
+		}
+	case TransformMergeByRegexp:
+		if t.Pattern == "" || t.Label == "" {
+			return fmt.Errorf("transform %s requires pattern and label", t.Type)
+		}
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern of transform %s: %w", t.Type, err)
+		}
+	case TransformTopN:
+		if t.N <= 0 {
+			return fmt.Errorf("transform %s requires a positive n", t.Type)
+		}
+	case TransformTree:
+		if t.Separator == "" {
+			return fmt.Errorf("transform %s requires separator", t.Type)
+		}
+		if t.Depth < 0 {
+			return fmt.Errorf("invalid depth of transform %s", t.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported transform: %s", t.Type)
+	}
+	return nil
+}
@@ -21,8 +21,14 @@ package response
 import (
 	"encoding/json"
 	"masm/v3/general/collections"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
 	"sort"
 	"strings"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
 type ListedValue struct {
@@ -41,9 +47,25 @@ type QueryAns struct {
 	Poscount       int
 	AttrValues     map[string]any
 	AlignedCorpora []string
+
+	// DocCounts contains number of atoms (typically documents)
+	// with a non-empty value of a respective attribute
+	DocCounts map[string]int
+
+	// Timeout is true in case the time budget of the request has
+	// been exceeded and the answer contains only data obtained
+	// before the deadline
+	Timeout bool
+
+	// TimeoutStages contains processing stages which have not
+	// been finished in time (see cnf.RequestBudgetsConf)
+	TimeoutStages []string
 }
 
-func (qa *QueryAns) MarshalJSON() ([]byte, error) {
+// ExportedAttrValues returns attribute values in the same form
+// as used in the JSON representation of the answer (i.e. listed
+// values are encoded as tuples).
+func (qa *QueryAns) ExportedAttrValues() map[string]any {
 	expAllAttrValues := make(map[string]any)
 	for k, v := range qa.AttrValues {
 		var attrValues any
@@ -70,14 +92,24 @@ func (qa *QueryAns) MarshalJSON() ([]byte, error) {
 		expAllAttrValues[k] = attrValues
 
 	}
+	return expAllAttrValues
+}
+
+func (qa *QueryAns) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Poscount       int            `json:"poscount"`
 		AttrValues     map[string]any `json:"attr_values"`
 		AlignedCorpora []string       `json:"aligned"`
+		DocCounts      map[string]int `json:"doc_counts,omitempty"`
+		Timeout        bool           `json:"timeout,omitempty"`
+		TimeoutStages  []string       `json:"timeoutStages,omitempty"`
 	}{
 		Poscount:       qa.Poscount,
-		AttrValues:     expAllAttrValues,
+		AttrValues:     qa.ExportedAttrValues(),
 		AlignedCorpora: qa.AlignedCorpora,
+		DocCounts:      qa.DocCounts,
+		Timeout:        qa.Timeout,
+		TimeoutStages:  qa.TimeoutStages,
 	})
 }
 
@@ -89,6 +121,46 @@ func (qa *QueryAns) AddListedValue(attr string, v *ListedValue) {
 	}
 }
 
+// WithTimeoutStages returns a shallow copy of the answer
+// marked as incomplete due to a timeout in `stages`
+func (qa *QueryAns) WithTimeoutStages(stages []string) *QueryAns {
+	ans := *qa
+	ans.Timeout = true
+	ans.TimeoutStages = stages
+	return &ans
+}
+
+// WithoutDocCounts returns a shallow copy of the answer
+// with document counts removed
+func (qa *QueryAns) WithoutDocCounts() *QueryAns {
+	ans := *qa
+	ans.DocCounts = nil
+	return &ans
+}
+
+// WithoutHiddenValues returns a shallow copy of the answer with listed
+// values for which `isHidden` returns true removed. Summarized values
+// are kept as they are.
+func (qa *QueryAns) WithoutHiddenValues(isHidden func(attr, value string) bool) *QueryAns {
+	ans := *qa
+	ans.AttrValues = make(map[string]any)
+	for attr, v := range qa.AttrValues {
+		tv, ok := v.([]*ListedValue)
+		if !ok {
+			ans.AttrValues[attr] = v
+			continue
+		}
+		visible := make([]*ListedValue, 0, len(tv))
+		for _, item := range tv {
+			if !isHidden(attr, item.Label) {
+				visible = append(visible, item)
+			}
+		}
+		ans.AttrValues[attr] = visible
+	}
+	return &ans
+}
+
 func exportKey(k string) string {
 	if k == "corpus_id" {
 		return k
@@ -96,11 +168,146 @@ func exportKey(k string) string {
 	return strings.Replace(k, "_", ".", 1)
 }
 
+// labelsLess creates a "less" function for sorting listed values
+// by their labels according to a locale. For an empty locale and for
+// laconf.LocaleBinary, values are compared byte-wise.
+func labelsLess(values []*ListedValue, locale string) func(i, j int) bool {
+	if locale == "" || locale == "binary" {
+		return func(i, j int) bool {
+			return strings.Compare(values[i].Label, values[j].Label) == -1
+		}
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		log.Warn().Err(err).Str("locale", locale).Msg("invalid locale, using binary sorting")
+		return labelsLess(values, "")
+	}
+	coll := collate.New(tag)
+	return func(i, j int) bool {
+		return coll.CompareString(values[i].Label, values[j].Label) == -1
+	}
+}
+
+// parseNumericLabel parses a label as a number written according to `nf`.
+// As a fallback, plain numbers with a decimal comma are accepted
+// (see laconf.AttrTypeNumber).
+func parseNumericLabel(label string, nf laconf.NumberFormat) (float64, bool) {
+	if v, ok := nf.Parse(label); ok {
+		return v, true
+	}
+	return laconf.DefaultNumberFormat.Parse(strings.Replace(label, ",", ".", 1))
+}
+
+// numericLabelsLess creates a "less" function for sorting listed values
+// by numeric values of their labels. Labels which are not numbers (including
+// empty ones) follow the numbers. Ties are resolved by `fallback`.
+func numericLabelsLess(
+	values []*ListedValue,
+	nf laconf.NumberFormat,
+	fallback func(i, j int) bool,
+) func(i, j int) bool {
+	// values are swapped during sorting so we cannot use indices as keys
+	numbers := make(map[*ListedValue]float64, len(values))
+	for _, v := range values {
+		if n, ok := parseNumericLabel(v.Label, nf); ok {
+			numbers[v] = n
+		}
+	}
+	return func(i, j int) bool {
+		ni, iok := numbers[values[i]]
+		nj, jok := numbers[values[j]]
+		if iok && jok && ni != nj {
+			return ni < nj
+		}
+		if iok != jok {
+			return iok
+		}
+		return fallback(i, j)
+	}
+}
+
+// isNumericAttr tests whether values of an attribute should be sorted
+// as numbers. This applies for attributes with declared numeric type
+// and for attributes with all the (non-empty) labels being numbers
+// according to `nf`.
+func isNumericAttr(values []*ListedValue, attrType laconf.AttrType, nf laconf.NumberFormat) bool {
+	if attrType.IsNumeric() {
+		return true
+	}
+	if attrType != laconf.AttrTypeString {
+		return false
+	}
+	labels := make([]string, len(values))
+	for i, v := range values {
+		labels[i] = v.Label
+	}
+	return nf.IsNumericValues(labels)
+}
+
+// attrValuesLess creates a "less" function for sorting listed values
+// according to `sortOrder` (see query.SortAlpha etc.). Ties (and values
+// without an explicit position in case of query.SortCustom) are resolved
+// by comparing labels according to a locale. In case `numeric` is true,
+// labels are compared as numbers written according to the locale.
+func attrValuesLess(
+	values []*ListedValue,
+	locale string,
+	numeric bool,
+	sortOrder string,
+	valueOrder []string,
+) func(i, j int) bool {
+	byLabel := labelsLess(values, locale)
+	if numeric {
+		byLabel = numericLabelsLess(values, laconf.NumberFormatOf(locale), byLabel)
+	}
+	switch sortOrder {
+	case query.SortCount:
+		return func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return byLabel(i, j)
+		}
+	case query.SortCustom:
+		if len(valueOrder) == 0 {
+			return byLabel
+		}
+		positions := make(map[string]int)
+		for i, v := range valueOrder {
+			positions[v] = i
+		}
+		return func(i, j int) bool {
+			pi, iok := positions[values[i].Label]
+			pj, jok := positions[values[j].Label]
+			if iok && jok {
+				return pi < pj
+			}
+			if iok != jok {
+				return iok
+			}
+			return byLabel(i, j)
+		}
+	}
+	return byLabel
+}
+
+// ExportAttrValues sorts listed values and summarizes attributes
+// with too many values (unless they are in `expandAttrs`). Values
+// are sorted according to `sortOrder` (see query.SortAlpha etc.)
+// with explicit orders of values taken from `valueOrders`. Labels
+// are compared using `collatorLocale` unless a different locale
+// of an attribute is specified in `attrLocales`. Values of numeric
+// attributes (either declared in `attrTypes` or detected by examining
+// their labels) are compared as numbers (e.g. "9" < "10").
 func ExportAttrValues(
 	data *QueryAns,
 	alignedCorpora []string,
 	expandAttrs []string,
 	collatorLocale string,
+	attrLocales map[string]string,
+	attrTypes laconf.AttrTypes,
+	sortOrder string,
+	valueOrders map[string][]string,
 	maxAttrListSize int,
 ) {
 	values := make(map[string]any)
@@ -109,12 +316,12 @@ func ExportAttrValues(
 		case []*ListedValue:
 			if maxAttrListSize == 0 || len(tVal) < maxAttrListSize ||
 				collections.SliceContains(expandAttrs, k) {
-				sort.Slice(
-					tVal,
-					func(i, j int) bool {
-						return strings.Compare(tVal[i].Label, tVal[j].Label) == -1
-					},
-				)
+				locale := collatorLocale
+				if v, ok := attrLocales[k]; ok {
+					locale = v
+				}
+				numeric := isNumericAttr(tVal, attrTypes.Get(k), laconf.NumberFormatOf(locale))
+				sort.SliceStable(tVal, attrValuesLess(tVal, locale, numeric, sortOrder, valueOrders[k]))
 				values[k] = tVal
 
 			} else {
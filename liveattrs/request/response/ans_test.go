@@ -0,0 +1,140 @@
+// Copyright 2022 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2022 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+import (
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestingAns() *QueryAns {
+	return &QueryAns{
+		AttrValues: map[string]any{
+			"doc.author": []*ListedValue{
+				{Label: "Zeman"}, {Label: "Čapek"}, {Label: "Chalupa"}, {Label: "Hora"},
+			},
+			"doc.lang": []*ListedValue{
+				{Label: "cs"}, {Label: "EN"}, {Label: "de"},
+			},
+		},
+	}
+}
+
+func exportedLabels(ans *QueryAns, attr string) []string {
+	values := ans.AttrValues[attr].([]*ListedValue)
+	labels := make([]string, len(values))
+	for i, v := range values {
+		labels[i] = v.Label
+	}
+	return labels
+}
+
+func TestExportAttrValuesCorpusLocale(t *testing.T) {
+	ans := createTestingAns()
+	ExportAttrValues(ans, []string{}, []string{}, "cs_CZ", map[string]string{}, nil, query.SortAlpha, nil, 0)
+	assert.Equal(t, []string{"Čapek", "Hora", "Chalupa", "Zeman"}, exportedLabels(ans, "doc.author"))
+	assert.Equal(t, []string{"cs", "de", "EN"}, exportedLabels(ans, "doc.lang"))
+}
+
+func TestExportAttrValuesAttrLocaleOverride(t *testing.T) {
+	ans := createTestingAns()
+	ExportAttrValues(
+		ans, []string{}, []string{}, "cs_CZ", map[string]string{"doc.lang": "binary"},
+		nil, query.SortAlpha, nil, 0)
+	assert.Equal(t, []string{"Čapek", "Hora", "Chalupa", "Zeman"}, exportedLabels(ans, "doc.author"))
+	assert.Equal(t, []string{"EN", "cs", "de"}, exportedLabels(ans, "doc.lang"))
+}
+
+func TestExportAttrValuesSummarized(t *testing.T) {
+	ans := createTestingAns()
+	ExportAttrValues(ans, []string{}, []string{"doc.lang"}, "", map[string]string{}, nil, "", nil, 3)
+	assert.Equal(t, SummarizedValue{Length: 4}, ans.AttrValues["doc.author"])
+	assert.Equal(t, []string{"EN", "cs", "de"}, exportedLabels(ans, "doc.lang"))
+}
+
+func TestExportAttrValuesSortByCount(t *testing.T) {
+	ans := createTestingAns()
+	for i, v := range ans.AttrValues["doc.author"].([]*ListedValue) {
+		v.Count = []int{10, 20, 10, 5}[i]
+	}
+	ExportAttrValues(ans, []string{}, []string{}, "cs_CZ", map[string]string{}, nil, query.SortCount, nil, 0)
+	assert.Equal(t, []string{"Čapek", "Chalupa", "Zeman", "Hora"}, exportedLabels(ans, "doc.author"))
+}
+
+func TestExportAttrValuesSortCustom(t *testing.T) {
+	ans := createTestingAns()
+	ExportAttrValues(
+		ans, []string{}, []string{}, "cs_CZ", map[string]string{}, nil, query.SortCustom,
+		map[string][]string{"doc.lang": {"EN", "cs"}}, 0)
+	assert.Equal(t, []string{"EN", "cs", "de"}, exportedLabels(ans, "doc.lang"))
+	// attributes without a configured order are sorted alphabetically
+	assert.Equal(t, []string{"Čapek", "Hora", "Chalupa", "Zeman"}, exportedLabels(ans, "doc.author"))
+}
+
+func TestExportAttrValuesNumericDetected(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.year": []*ListedValue{
+				{Label: "1999"}, {Label: "20"}, {Label: ""}, {Label: "1 000,5"}, {Label: "3"},
+			},
+		},
+	}
+	ExportAttrValues(ans, []string{}, []string{}, "cs_CZ", map[string]string{}, nil, query.SortAlpha, nil, 0)
+	assert.Equal(t, []string{"3", "20", "1 000,5", "1999", ""}, exportedLabels(ans, "doc.year"))
+}
+
+func TestExportAttrValuesNumericDeclared(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.rating": []*ListedValue{
+				{Label: "10"}, {Label: "unknown"}, {Label: "2.5"}, {Label: "9"},
+			},
+		},
+	}
+	ExportAttrValues(
+		ans, []string{}, []string{}, "cs_CZ", map[string]string{},
+		laconf.AttrTypes{"doc.rating": laconf.AttrTypeNumber}, query.SortAlpha, nil, 0)
+	assert.Equal(t, []string{"2.5", "9", "10", "unknown"}, exportedLabels(ans, "doc.rating"))
+}
+
+func TestExportAttrValuesNotNumeric(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.id": []*ListedValue{{Label: "20"}, {Label: "1999"}, {Label: "a1"}},
+		},
+	}
+	ExportAttrValues(ans, []string{}, []string{}, "", map[string]string{}, nil, query.SortAlpha, nil, 0)
+	assert.Equal(t, []string{"1999", "20", "a1"}, exportedLabels(ans, "doc.id"))
+}
+
+func TestWithoutHiddenValues(t *testing.T) {
+	ans := createTestingAns()
+	ans.AttrValues["doc.title"] = SummarizedValue{Length: 100}
+	filtered := ans.WithoutHiddenValues(func(attr, value string) bool {
+		return attr == "doc.author" && value == "Hora"
+	})
+	assert.Equal(t, []string{"Zeman", "Čapek", "Chalupa"}, exportedLabels(filtered, "doc.author"))
+	assert.Equal(t, []string{"cs", "EN", "de"}, exportedLabels(filtered, "doc.lang"))
+	assert.Equal(t, SummarizedValue{Length: 100}, filtered.AttrValues["doc.title"])
+	// the original answer must not be modified
+	assert.Len(t, ans.AttrValues["doc.author"], 4)
+}
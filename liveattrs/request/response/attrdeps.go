@@ -0,0 +1,37 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+// AttrDependency describes how strongly values of DependentAttr
+// are determined by values of Attr.
+//
+// Ratio is a share of items whose DependentAttr value equals the most
+// frequent DependentAttr value of their Attr value (1.0 means a functional
+// dependency). Lambda is Goodman and Kruskal's lambda - i.e. Ratio
+// corrected for the share of the most frequent DependentAttr value
+// overall (0.0 means Attr does not help predicting DependentAttr at all).
+type AttrDependency struct {
+	Attr                   string  `json:"attr"`
+	DependentAttr          string  `json:"dependentAttr"`
+	NumItems               int     `json:"numItems"`
+	NumAttrValues          int     `json:"numAttrValues"`
+	NumDependentAttrValues int     `json:"numDependentAttrValues"`
+	Ratio                  float64 `json:"ratio"`
+	Lambda                 float64 `json:"lambda"`
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+type HistogramBin struct {
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+	NumItems int     `json:"numItems"`
+	Poscount int     `json:"poscount"`
+}
+
+// AttrStats contains basic statistics of a numeric
+// attribute within a selection of text types
+type AttrStats struct {
+	Attr      string          `json:"attr"`
+	NumItems  int             `json:"numItems"`
+	Min       float64         `json:"min"`
+	Max       float64         `json:"max"`
+	Mean      float64         `json:"mean"`
+	Histogram []*HistogramBin `json:"histogram"`
+}
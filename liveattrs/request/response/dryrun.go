@@ -0,0 +1,40 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+// DryRunColumn describes a column of a liveattrs table
+// created from a vertical sample
+type DryRunColumn struct {
+	Name        string `json:"name"`
+	Cardinality int    `json:"cardinality"`
+}
+
+// ExtractionDryRun is a result of a data extraction performed
+// on a sample of vertical file(s) along with a projection
+// of the whole table size
+type ExtractionDryRun struct {
+	SampleAtoms        int            `json:"sampleAtoms"`
+	SampleLines        int            `json:"sampleLines"`
+	SampleRows         int            `json:"sampleRows"`
+	SampleSizeBytes    int64          `json:"sampleSizeBytes"`
+	Columns            []DryRunColumn `json:"columns"`
+	EstimatedNumLines  int            `json:"estimatedNumLines"`
+	ProjectedRows      int            `json:"projectedRows"`
+	ProjectedSizeBytes int64          `json:"projectedSizeBytes"`
+}
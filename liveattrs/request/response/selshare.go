@@ -0,0 +1,38 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+// ValueShare describes how much of the corpus part with
+// a specific attribute value is covered by a selection
+type ValueShare struct {
+	Value         string  `json:"value"`
+	CorpusSize    int     `json:"corpusSize"`
+	SelectionSize int     `json:"selectionSize"`
+	Share         float64 `json:"share"`
+}
+
+// SelectionShare contains sizes of a selection of text types
+// relative to the whole corpus, both in total and broken down
+// by values of target attributes (sorted by corpus size, descending).
+type SelectionShare struct {
+	CorpusSize    int                      `json:"corpusSize"`
+	SelectionSize int                      `json:"selectionSize"`
+	Share         float64                  `json:"share"`
+	Attrs         map[string][]*ValueShare `json:"attrs"`
+}
@@ -19,6 +19,16 @@
 package response
 
 type GetSubcSize struct {
-	Total    int         `json:"total"`
-	Messages [][2]string `json:"messages"`
+	Total int    `json:"total"`
+	Unit  string `json:"unit"`
+
+	// Breakdown contains sizes for individual corpora
+	// (the main one and the aligned ones) in case
+	// aligned corpora are involved
+	Breakdown map[string]int `json:"breakdown,omitempty"`
+	Messages  [][2]string    `json:"messages"`
+
+	// Duration is a total duration (in seconds) of selected
+	// segments in case segment timing is configured for the corpus
+	Duration *float64 `json:"duration,omitempty"`
 }
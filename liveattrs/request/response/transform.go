@@ -0,0 +1,250 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+import (
+	"masm/v3/liveattrs/request/query"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// groupKeyFn provides a key of a group a value label belongs to.
+// In case the value should not be grouped, false is returned.
+type groupKeyFn func(label string) (string, bool)
+
+func prefixGroupKey(t query.Transform) groupKeyFn {
+	if t.Separator != "" {
+		return func(label string) (string, bool) {
+			idx := strings.Index(label, t.Separator)
+			if idx < 0 {
+				return "", false
+			}
+			return label[:idx], true
+		}
+	}
+	return func(label string) (string, bool) {
+		runes := []rune(label)
+		if len(runes) <= t.PrefixLength {
+			return label, true
+		}
+		return string(runes[:t.PrefixLength]), true
+	}
+}
+
+func regexpGroupKey(rx *regexp.Regexp, groupLabel string) groupKeyFn {
+	return func(label string) (string, bool) {
+		if rx.MatchString(label) {
+			return groupLabel, true
+		}
+		return "", false
+	}
+}
+
+func newMergedValue(label string) *ListedValue {
+	return &ListedValue{ID: label, Label: label, ShortLabel: label}
+}
+
+// groupValues merges values with the same group key into a single
+// value with summed counts. A merged value takes the position
+// of the first value of its group. Values are never modified
+// in place as they may be shared with cached answers.
+func groupValues(values []*ListedValue, keyFn groupKeyFn) []*ListedValue {
+	ans := make([]*ListedValue, 0, len(values))
+	groups := make(map[string]*ListedValue)
+	for _, v := range values {
+		key, ok := keyFn(v.Label)
+		if !ok {
+			ans = append(ans, v)
+			continue
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = newMergedValue(key)
+			groups[key] = group
+			ans = append(ans, group)
+		}
+		group.Count += v.Count
+		group.Grouping += v.Grouping
+	}
+	return ans
+}
+
+// topValues keeps `n` values with the highest counts (in their original
+// order). In case `restLabel` is not empty, the rest of values is merged
+// into a single value appended to the end.
+func topValues(values []*ListedValue, n int, restLabel string) []*ListedValue {
+	if len(values) <= n {
+		return values
+	}
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return values[indices[i]].Count > values[indices[j]].Count
+	})
+	kept := make(map[int]bool, n)
+	for _, idx := range indices[:n] {
+		kept[idx] = true
+	}
+	ans := make([]*ListedValue, 0, n+1)
+	var rest *ListedValue
+	for i, v := range values {
+		if kept[i] {
+			ans = append(ans, v)
+		} else if restLabel != "" {
+			if rest == nil {
+				rest = newMergedValue(restLabel)
+			}
+			rest.Count += v.Count
+			rest.Grouping += v.Grouping
+		}
+	}
+	if rest != nil {
+		ans = append(ans, rest)
+	}
+	return ans
+}
+
+// ValueTreeNode is a node of a tree of hierarchical attribute values
+type ValueTreeNode struct {
+
+	// Label is the node's part of a value (between separators)
+	Label string `json:"label"`
+
+	// Value is the whole value (path) of the node
+	Value string `json:"value"`
+
+	// ID is an identifier of the value in case the node
+	// represents an actual attribute value
+	ID string `json:"id,omitempty"`
+
+	// Count is a summed count of the node's value and values
+	// of all its descendants
+	Count int `json:"count"`
+
+	// NumChildren is a number of the node's children (including
+	// the ones not listed due to the max. depth of the tree)
+	NumChildren int `json:"numChildren"`
+
+	Children []*ValueTreeNode `json:"children,omitempty"`
+}
+
+// ValueTree is a tree of hierarchical attribute values
+// (see query.TransformTree)
+type ValueTree struct {
+	Separator string           `json:"separator"`
+	Root      string           `json:"root,omitempty"`
+	Nodes     []*ValueTreeNode `json:"nodes"`
+}
+
+// valueTree creates a tree of values with labels split by
+// the transform's separator. Nodes are ordered by the first
+// occurrence of their values.
+func valueTree(values []*ListedValue, t query.Transform) *ValueTree {
+	ans := &ValueTree{
+		Separator: t.Separator,
+		Root:      t.Root,
+		Nodes:     []*ValueTreeNode{},
+	}
+	var rootPrefix string
+	if t.Root != "" {
+		rootPrefix = t.Root + t.Separator
+	}
+	nodes := make(map[string]*ValueTreeNode)
+	for _, v := range values {
+		if !strings.HasPrefix(v.Label, rootPrefix) || v.Label == rootPrefix {
+			continue
+		}
+		path := t.Root
+		var parent *ValueTreeNode
+		for depth, label := range strings.Split(v.Label[len(rootPrefix):], t.Separator) {
+			if path == "" {
+				path = label
+
+			} else {
+				path += t.Separator + label
+			}
+			node, ok := nodes[path]
+			if !ok {
+				node = &ValueTreeNode{Label: label, Value: path}
+				nodes[path] = node
+				if parent != nil {
+					parent.NumChildren++
+				}
+				if t.Depth > 0 && depth >= t.Depth {
+					break
+				}
+				if parent != nil {
+					parent.Children = append(parent.Children, node)
+
+				} else {
+					ans.Nodes = append(ans.Nodes, node)
+				}
+
+			} else if t.Depth > 0 && depth >= t.Depth {
+				break
+			}
+			node.Count += v.Count
+			if path == v.Label {
+				node.ID = v.ID
+			}
+			parent = node
+		}
+	}
+	return ans
+}
+
+// WithTransforms returns a shallow copy of the answer with listed values
+// shaped by `transforms` (applied in the specified order). Transforms
+// of attributes without listed values (e.g. summarized ones or ones
+// already transformed into a tree) are ignored.
+// The original answer is not modified.
+func (qa *QueryAns) WithTransforms(transforms []query.Transform) *QueryAns {
+	if len(transforms) == 0 {
+		return qa
+	}
+	ans := *qa
+	ans.AttrValues = make(map[string]any, len(qa.AttrValues))
+	for attr, v := range qa.AttrValues {
+		ans.AttrValues[attr] = v
+	}
+	for _, t := range transforms {
+		values, ok := ans.AttrValues[t.Attr].([]*ListedValue)
+		if !ok {
+			continue
+		}
+		switch t.Type {
+		case query.TransformGroupByPrefix:
+			ans.AttrValues[t.Attr] = groupValues(values, prefixGroupKey(t))
+		case query.TransformMergeByRegexp:
+			rx, err := regexp.Compile(t.Pattern)
+			if err != nil {
+				continue // Transform.Validate prevents this
+			}
+			ans.AttrValues[t.Attr] = groupValues(values, regexpGroupKey(rx, t.Label))
+		case query.TransformTopN:
+			ans.AttrValues[t.Attr] = topValues(values, t.N, t.Label)
+		case query.TransformTree:
+			ans.AttrValues[t.Attr] = valueTree(values, t)
+		}
+	}
+	return &ans
+}
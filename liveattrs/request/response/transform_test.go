@@ -0,0 +1,175 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package response
+
+import (
+	"masm/v3/liveattrs/request/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTransformTestingAns() *QueryAns {
+	return &QueryAns{
+		AttrValues: map[string]any{
+			"doc.genre": []*ListedValue{
+				{ID: "fic:novel", Label: "fic:novel", Count: 10, Grouping: 1},
+				{ID: "nfic:essay", Label: "nfic:essay", Count: 3, Grouping: 1},
+				{ID: "other", Label: "other", Count: 1, Grouping: 1},
+				{ID: "fic:poetry", Label: "fic:poetry", Count: 5, Grouping: 1},
+			},
+			"doc.title": &SummarizedValue{Length: 1000},
+		},
+	}
+}
+
+func labels(values any) []string {
+	ans := []string{}
+	for _, v := range values.([]*ListedValue) {
+		ans = append(ans, v.Label)
+	}
+	return ans
+}
+
+func TestWithTransformsGroupBySeparator(t *testing.T) {
+	orig := createTransformTestingAns()
+	ans := orig.WithTransforms([]query.Transform{
+		{Type: query.TransformGroupByPrefix, Attr: "doc.genre", Separator: ":"},
+	})
+	values := ans.AttrValues["doc.genre"].([]*ListedValue)
+	assert.Equal(t, []string{"fic", "nfic", "other"}, labels(values))
+	assert.Equal(t, 15, values[0].Count)
+	assert.Equal(t, 2, values[0].Grouping)
+	assert.Equal(t, "fic", values[0].ID)
+	assert.Len(t, orig.AttrValues["doc.genre"], 4)
+	assert.Equal(t, 10, orig.AttrValues["doc.genre"].([]*ListedValue)[0].Count)
+}
+
+func TestWithTransformsGroupByPrefixLength(t *testing.T) {
+	ans := createTransformTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformGroupByPrefix, Attr: "doc.genre", PrefixLength: 2},
+	})
+	values := ans.AttrValues["doc.genre"].([]*ListedValue)
+	assert.Equal(t, []string{"fi", "nf", "ot"}, labels(values))
+	assert.Equal(t, 15, values[0].Count)
+}
+
+func TestWithTransformsMergeByRegexp(t *testing.T) {
+	ans := createTransformTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformMergeByRegexp, Attr: "doc.genre", Pattern: "^(nfic|other)", Label: "misc"},
+	})
+	values := ans.AttrValues["doc.genre"].([]*ListedValue)
+	assert.Equal(t, []string{"fic:novel", "misc", "fic:poetry"}, labels(values))
+	assert.Equal(t, 4, values[1].Count)
+}
+
+func TestWithTransformsTopN(t *testing.T) {
+	ans := createTransformTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTopN, Attr: "doc.genre", N: 2},
+	})
+	assert.Equal(t, []string{"fic:novel", "fic:poetry"}, labels(ans.AttrValues["doc.genre"]))
+
+	ans = createTransformTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTopN, Attr: "doc.genre", N: 2, Label: "rest"},
+	})
+	values := ans.AttrValues["doc.genre"].([]*ListedValue)
+	assert.Equal(t, []string{"fic:novel", "fic:poetry", "rest"}, labels(values))
+	assert.Equal(t, 4, values[2].Count)
+	assert.Equal(t, 2, values[2].Grouping)
+}
+
+func TestWithTransformsPipeline(t *testing.T) {
+	ans := createTransformTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformGroupByPrefix, Attr: "doc.genre", Separator: ":"},
+		{Type: query.TransformTopN, Attr: "doc.genre", N: 1, Label: "rest"},
+		{Type: query.TransformTopN, Attr: "doc.title", N: 1},
+	})
+	assert.Equal(t, []string{"fic", "rest"}, labels(ans.AttrValues["doc.genre"]))
+	assert.Equal(t, &SummarizedValue{Length: 1000}, ans.AttrValues["doc.title"])
+}
+
+func createTreeTestingAns() *QueryAns {
+	return &QueryAns{
+		AttrValues: map[string]any{
+			"doc.class": []*ListedValue{
+				{ID: "1", Label: "fiction/novel/detective", Count: 10},
+				{ID: "2", Label: "fiction/novel", Count: 2},
+				{ID: "3", Label: "nonfiction/essay", Count: 3},
+				{ID: "4", Label: "fiction/poetry", Count: 5},
+				{ID: "5", Label: "fiction/novel/romance", Count: 4},
+			},
+		},
+	}
+}
+
+func TestWithTransformsTree(t *testing.T) {
+	ans := createTreeTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTree, Attr: "doc.class", Separator: "/"},
+	})
+	tree := ans.AttrValues["doc.class"].(*ValueTree)
+	if assert.Len(t, tree.Nodes, 2) {
+		fiction := tree.Nodes[0]
+		assert.Equal(t, "fiction", fiction.Label)
+		assert.Equal(t, 21, fiction.Count)
+		assert.Equal(t, "", fiction.ID)
+		assert.Equal(t, 2, fiction.NumChildren)
+		novel := fiction.Children[0]
+		assert.Equal(t, "fiction/novel", novel.Value)
+		assert.Equal(t, "2", novel.ID)
+		assert.Equal(t, 16, novel.Count)
+		if assert.Len(t, novel.Children, 2) {
+			assert.Equal(t, "detective", novel.Children[0].Label)
+			assert.Equal(t, "romance", novel.Children[1].Label)
+			assert.Equal(t, 4, novel.Children[1].Count)
+		}
+		assert.Equal(t, "poetry", fiction.Children[1].Label)
+		assert.Equal(t, 3, tree.Nodes[1].Count)
+	}
+}
+
+func TestWithTransformsTreeDepthAndRoot(t *testing.T) {
+	ans := createTreeTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTree, Attr: "doc.class", Separator: "/", Depth: 1},
+	})
+	tree := ans.AttrValues["doc.class"].(*ValueTree)
+	if assert.Len(t, tree.Nodes, 2) {
+		assert.Equal(t, 21, tree.Nodes[0].Count)
+		assert.Equal(t, 2, tree.Nodes[0].NumChildren)
+		assert.Nil(t, tree.Nodes[0].Children)
+	}
+
+	ans = createTreeTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTree, Attr: "doc.class", Separator: "/", Root: "fiction/novel"},
+	})
+	tree = ans.AttrValues["doc.class"].(*ValueTree)
+	if assert.Len(t, tree.Nodes, 2) {
+		assert.Equal(t, "detective", tree.Nodes[0].Label)
+		assert.Equal(t, "fiction/novel/detective", tree.Nodes[0].Value)
+		assert.Equal(t, "1", tree.Nodes[0].ID)
+		assert.Equal(t, 10, tree.Nodes[0].Count)
+	}
+}
+
+func TestWithTransformsAfterTree(t *testing.T) {
+	ans := createTreeTestingAns().WithTransforms([]query.Transform{
+		{Type: query.TransformTree, Attr: "doc.class", Separator: "/"},
+		{Type: query.TransformTopN, Attr: "doc.class", N: 1},
+	})
+	assert.IsType(t, &ValueTree{}, ans.AttrValues["doc.class"])
+}
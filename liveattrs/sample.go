@@ -0,0 +1,141 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	structOpenRegexp  = regexp.MustCompile(`^<([a-zA-Z_][a-zA-Z0-9_\-]*)(\s[^>]*)?>$`)
+	structCloseRegexp = regexp.MustCompile(`^</([a-zA-Z_][a-zA-Z0-9_\-]*)>$`)
+)
+
+// VerticalSample describes a sample created from vertical file(s)
+type VerticalSample struct {
+	NumLines int `json:"numLines"`
+	NumAtoms int `json:"numAtoms"`
+}
+
+type sampleWriter struct {
+	wr       *bufio.Writer
+	atom     string
+	maxAtoms int
+	open     []string
+	ans      VerticalSample
+}
+
+// done tests whether the sample already contains enough atoms
+func (sw *sampleWriter) done() bool {
+	return sw.ans.NumAtoms >= sw.maxAtoms
+}
+
+func (sw *sampleWriter) writeLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+	if m := structCloseRegexp.FindStringSubmatch(trimmed); m != nil {
+		for i := len(sw.open) - 1; i >= 0; i-- {
+			if sw.open[i] == m[1] {
+				sw.open = sw.open[:i]
+				break
+			}
+		}
+		if m[1] == sw.atom {
+			sw.ans.NumAtoms++
+		}
+
+	} else if m := structOpenRegexp.FindStringSubmatch(trimmed); m != nil && !strings.HasSuffix(trimmed, "/>") {
+		sw.open = append(sw.open, m[1])
+	}
+	sw.ans.NumLines++
+	_, err := sw.wr.WriteString(line + "\n")
+	return err
+}
+
+// closeOpenStructs writes closing tags of all the structures
+// still open (so the sample is a valid vertical)
+func (sw *sampleWriter) closeOpenStructs() error {
+	for i := len(sw.open) - 1; i >= 0; i-- {
+		if _, err := fmt.Fprintf(sw.wr, "</%s>\n", sw.open[i]); err != nil {
+			return err
+		}
+	}
+	sw.open = sw.open[:0]
+	return nil
+}
+
+func (sw *sampleWriter) copyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rd, _, err := decompressedReader(f, path)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for !sw.done() && scanner.Scan() {
+		if err := sw.writeLine(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sw.closeOpenStructs()
+}
+
+// CreateVerticalSample writes (uncompressed) beginning of provided vertical
+// files containing at most maxAtoms atom structures to dstPath. Structures
+// left open at the end of the sample are closed.
+func CreateVerticalSample(
+	paths []string,
+	atomStruct string,
+	maxAtoms int,
+	dstPath string,
+) (VerticalSample, error) {
+	f, err := os.Create(dstPath)
+	if err != nil {
+		return VerticalSample{}, fmt.Errorf("failed to create vertical sample: %w", err)
+	}
+	defer f.Close()
+	sw := &sampleWriter{
+		wr:       bufio.NewWriter(f),
+		atom:     atomStruct,
+		maxAtoms: maxAtoms,
+	}
+	for _, path := range paths {
+		if sw.done() {
+			break
+		}
+		if err := sw.copyFile(path); err != nil {
+			return VerticalSample{}, fmt.Errorf("failed to create vertical sample: %w", err)
+		}
+	}
+	if err := sw.wr.Flush(); err != nil {
+		return VerticalSample{}, fmt.Errorf("failed to create vertical sample: %w", err)
+	}
+	return sw.ans, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testVertical = `<corpus id="foo">
+<doc id="1">
+<p>
+word1	lemma1
+</p>
+<g/>
+</doc>
+<doc id="2">
+word2	lemma2
+</doc>
+<doc id="3">
+word3	lemma3
+</doc>
+</corpus>
+`
+
+func TestCreateVerticalSample(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.vert")
+	assert.NoError(t, os.WriteFile(src, []byte(testVertical), 0644))
+	dst := filepath.Join(dir, "sample.vert")
+	ans, err := CreateVerticalSample([]string{src}, "doc", 2, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ans.NumAtoms)
+	assert.Equal(t, 10, ans.NumLines)
+	data, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"<corpus id=\"foo\">\n<doc id=\"1\">\n<p>\nword1\tlemma1\n</p>\n<g/>\n</doc>\n"+
+			"<doc id=\"2\">\nword2\tlemma2\n</doc>\n</corpus>\n",
+		string(data),
+	)
+}
+
+func TestCreateVerticalSampleWholeFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.vert")
+	assert.NoError(t, os.WriteFile(src, []byte(testVertical), 0644))
+	dst := filepath.Join(dir, "sample.vert")
+	ans, err := CreateVerticalSample([]string{src, src}, "doc", 5, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, ans.NumAtoms)
+	assert.Equal(t, 14+10, ans.NumLines)
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SpeakerEntry describes a speaker within a document
+// (see laconf.SpeakerConf)
+type SpeakerEntry struct {
+	DocID     string
+	SpeakerID string
+	Attrs     map[string]string
+	Poscount  int
+}
+
+// SpeakerScanArgs specifies structures and attributes
+// needed to extract speakers from a vertical
+type SpeakerScanArgs struct {
+	AtomStruct    string
+	DocIDAttr     string
+	SpeakerStruct string
+	SpeakerIDAttr string
+	SpeakerAttrs  []string
+}
+
+type speakerScanner struct {
+	args    SpeakerScanArgs
+	docID   string
+	inDoc   bool
+	speaker *SpeakerEntry
+	entries map[[2]string]*SpeakerEntry
+	ans     []*SpeakerEntry
+}
+
+func parseStructAttrs(tagContent string) map[string]string {
+	ans := make(map[string]string)
+	for _, am := range structAttrRegexp.FindAllStringSubmatch(tagContent, -1) {
+		ans[am[1]] = am[2]
+	}
+	return ans
+}
+
+func (ss *speakerScanner) openSpeaker(attrs map[string]string) {
+	key := [2]string{ss.docID, attrs[ss.args.SpeakerIDAttr]}
+	if entry, ok := ss.entries[key]; ok {
+		ss.speaker = entry
+		return
+	}
+	entry := &SpeakerEntry{
+		DocID:     key[0],
+		SpeakerID: key[1],
+		Attrs:     make(map[string]string, len(ss.args.SpeakerAttrs)),
+	}
+	for _, attr := range ss.args.SpeakerAttrs {
+		entry.Attrs[attr] = attrs[attr]
+	}
+	ss.entries[key] = entry
+	ss.ans = append(ss.ans, entry)
+	ss.speaker = entry
+}
+
+func (ss *speakerScanner) processLine(line string) {
+	trimmed := strings.TrimSpace(line)
+	if m := structCloseRegexp.FindStringSubmatch(trimmed); m != nil {
+		switch m[1] {
+		case ss.args.AtomStruct:
+			ss.inDoc = false
+			ss.speaker = nil
+		case ss.args.SpeakerStruct:
+			ss.speaker = nil
+		}
+
+	} else if m := structOpenRegexp.FindStringSubmatch(trimmed); m != nil {
+		switch m[1] {
+		case ss.args.AtomStruct:
+			ss.inDoc = true
+			ss.docID = parseStructAttrs(m[2])[ss.args.DocIDAttr]
+		case ss.args.SpeakerStruct:
+			if ss.inDoc {
+				ss.openSpeaker(parseStructAttrs(m[2]))
+			}
+		}
+
+	} else if ss.speaker != nil && trimmed != "" && !strings.HasPrefix(trimmed, "<") {
+		ss.speaker.Poscount++
+	}
+}
+
+func (ss *speakerScanner) scanFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rd, _, err := decompressedReader(f, path)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ss.processLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// ExtractSpeakers reads vertical files and returns speakers of each
+// document (atom) along with numbers of positions they uttered there.
+// Multiple turns of a speaker within a document are merged (attributes
+// of the first turn are used). Positions outside speaker turns
+// are not counted.
+func ExtractSpeakers(paths []string, args SpeakerScanArgs) ([]*SpeakerEntry, error) {
+	ss := &speakerScanner{
+		args:    args,
+		entries: make(map[[2]string]*SpeakerEntry),
+		ans:     make([]*SpeakerEntry, 0, 1000),
+	}
+	for _, path := range paths {
+		if err := ss.scanFile(path); err != nil {
+			return nil, fmt.Errorf("failed to extract speakers: %w", err)
+		}
+	}
+	return ss.ans, nil
+}
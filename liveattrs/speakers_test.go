@@ -0,0 +1,74 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpokenVertical = `<doc id="d1" year="2010">
+<sp id="s1" sex="F" age="30">
+word1	lemma1
+word2	lemma2
+</sp>
+<sp id="s2" sex="M" age="50">
+word3	lemma3
+</sp>
+<sp id="s1" sex="F" age="30">
+word4	lemma4
+<g/>
+word5	lemma5
+</sp>
+</doc>
+<doc id="d2" year="2012">
+word6	lemma6
+<sp id="s1" sex="F" age="32">
+word7	lemma7
+</sp>
+</doc>
+`
+
+func TestExtractSpeakers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vert")
+	assert.NoError(t, os.WriteFile(path, []byte(testSpokenVertical), 0644))
+	ans, err := ExtractSpeakers(
+		[]string{path},
+		SpeakerScanArgs{
+			AtomStruct:    "doc",
+			DocIDAttr:     "id",
+			SpeakerStruct: "sp",
+			SpeakerIDAttr: "id",
+			SpeakerAttrs:  []string{"sex", "age"},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*SpeakerEntry{
+			{DocID: "d1", SpeakerID: "s1", Attrs: map[string]string{"sex": "F", "age": "30"}, Poscount: 4},
+			{DocID: "d1", SpeakerID: "s2", Attrs: map[string]string{"sex": "M", "age": "50"}, Poscount: 1},
+			{DocID: "d2", SpeakerID: "s1", Attrs: map[string]string{"sex": "F", "age": "32"}, Poscount: 1},
+		},
+		ans,
+	)
+}
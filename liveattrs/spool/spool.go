@@ -0,0 +1,286 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package spool provides disk-backed storage of large API results
+// (e.g. document lists matching millions of documents). Instead of
+// building a whole response in memory, items are written to a temporary
+// file and clients fetch them page by page using a result ID which
+// is valid for a configured time.
+package spool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltTTLSecs   = 600
+	dfltMinItems  = 100000
+	checkpointGap = 1000
+	filePrefix    = "masm-spool-"
+)
+
+var (
+	ErrorResultNotFound = errors.New("result not found (or expired)")
+)
+
+// Conf configures result spooling
+type Conf struct {
+
+	// DirPath is a directory for spooled results. If empty,
+	// the spooling is disabled.
+	DirPath string `json:"dirPath"`
+
+	// MinItems is a minimum size of a result to be spooled
+	MinItems int `json:"minItems"`
+
+	// TTLSecs specifies how long a spooled result is available
+	TTLSecs int `json:"ttlSecs"`
+}
+
+func (conf *Conf) TTL() time.Duration {
+	if conf.TTLSecs <= 0 {
+		return time.Duration(dfltTTLSecs) * time.Second
+	}
+	return time.Duration(conf.TTLSecs) * time.Second
+}
+
+func (conf *Conf) MinItemsOrDefault() int {
+	if conf.MinItems <= 0 {
+		return dfltMinItems
+	}
+	return conf.MinItems
+}
+
+// Result is a handle of a spooled result
+type Result struct {
+	ID       string    `json:"resultId"`
+	Owner    string    `json:"-"`
+	NumItems int       `json:"numItems"`
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`
+
+	path string
+
+	// checkpoints contains file offsets of each checkpointGap-th item
+	// so a page can be found without reading the whole file
+	checkpoints []int64
+}
+
+// Page is a page of a spooled result
+type Page struct {
+	ResultID string            `json:"resultId"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"pageSize"`
+	NumItems int               `json:"numItems"`
+	Expires  time.Time         `json:"expires"`
+	Items    []json.RawMessage `json:"items"`
+}
+
+// Spool manages spooled results
+type Spool struct {
+	conf    Conf
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+// Enabled tests whether the spooling is configured
+func (sp *Spool) Enabled() bool {
+	return sp.conf.DirPath != ""
+}
+
+// MinItems returns minimum number of items a result must have
+// to be spooled
+func (sp *Spool) MinItems() int {
+	return sp.conf.MinItemsOrDefault()
+}
+
+func (sp *Spool) removeResult(res *Result) {
+	delete(sp.results, res.ID)
+	if err := os.Remove(res.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("path", res.path).Msg("failed to remove spooled result")
+	}
+}
+
+// Cleanup removes expired results
+func (sp *Spool) Cleanup() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	now := time.Now()
+	for _, res := range sp.results {
+		if now.After(res.Expires) {
+			sp.removeResult(res)
+		}
+	}
+}
+
+// Create spools a new result. The `fill` function is expected to write
+// all the result items using the provided `write` function.
+// The `owner` is an arbitrary identifier (e.g. a corpus ID) the result
+// can be accessed with.
+func (sp *Spool) Create(owner string, fill func(write func(item any) error) error) (*Result, error) {
+	if !sp.Enabled() {
+		return nil, fmt.Errorf("result spooling not configured")
+	}
+	sp.Cleanup()
+	if err := os.MkdirAll(sp.conf.DirPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to spool result: %w", err)
+	}
+	f, err := os.CreateTemp(sp.conf.DirPath, filePrefix+"*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to spool result: %w", err)
+	}
+	res := &Result{
+		ID:          uuid.New().String(),
+		Owner:       owner,
+		path:        f.Name(),
+		checkpoints: make([]int64, 0, 100),
+	}
+	wr := bufio.NewWriter(f)
+	var offset int64
+	err = fill(func(item any) error {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if res.NumItems%checkpointGap == 0 {
+			res.checkpoints = append(res.checkpoints, offset)
+		}
+		data = append(data, '\n')
+		if _, err := wr.Write(data); err != nil {
+			return err
+		}
+		offset += int64(len(data))
+		res.NumItems++
+		return nil
+	})
+	if err == nil {
+		err = wr.Flush()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to spool result: %w", err)
+	}
+	res.Created = time.Now()
+	res.Expires = res.Created.Add(sp.conf.TTL())
+	sp.mu.Lock()
+	sp.results[res.ID] = res
+	sp.mu.Unlock()
+	return res, nil
+}
+
+func (sp *Spool) getResult(owner, resultID string) (*Result, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	res, ok := sp.results[resultID]
+	if !ok || res.Owner != owner {
+		return nil, ErrorResultNotFound
+	}
+	if time.Now().After(res.Expires) {
+		sp.removeResult(res)
+		return nil, ErrorResultNotFound
+	}
+	return res, nil
+}
+
+// GetPage reads a page of a spooled result (pages are numbered from 1).
+// For a page beyond the result, an empty list of items is returned.
+func (sp *Spool) GetPage(owner, resultID string, page, pageSize int) (*Page, error) {
+	if page < 1 || pageSize < 1 {
+		return nil, fmt.Errorf("invalid page %d or page size %d", page, pageSize)
+	}
+	res, err := sp.getResult(owner, resultID)
+	if err != nil {
+		return nil, err
+	}
+	ans := &Page{
+		ResultID: res.ID,
+		Page:     page,
+		PageSize: pageSize,
+		NumItems: res.NumItems,
+		Expires:  res.Expires,
+		Items:    []json.RawMessage{},
+	}
+	first := (page - 1) * pageSize
+	if first >= res.NumItems {
+		return ans, nil
+	}
+	f, err := os.Open(res.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrorResultNotFound
+
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read spooled result: %w", err)
+	}
+	defer f.Close()
+	cpIdx := first / checkpointGap
+	if _, err := f.Seek(res.checkpoints[cpIdx], io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read spooled result: %w", err)
+	}
+	rd := bufio.NewReader(f)
+	for i := cpIdx * checkpointGap; i < res.NumItems && len(ans.Items) < pageSize; i++ {
+		line, err := rd.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spooled result: %w", err)
+		}
+		if i >= first {
+			ans.Items = append(ans.Items, json.RawMessage(line[:len(line)-1]))
+		}
+	}
+	return ans, nil
+}
+
+// RunCleanup periodically removes expired results
+func (sp *Spool) RunCleanup() {
+	ticker := time.NewTicker(sp.conf.TTL() / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		sp.Cleanup()
+	}
+}
+
+// NewSpool creates a new Spool. Files possibly left in the configured
+// directory by a previous run are removed as their handles are lost.
+func NewSpool(conf Conf) *Spool {
+	ans := &Spool{
+		conf:    conf,
+		results: make(map[string]*Result),
+	}
+	if ans.Enabled() {
+		old, _ := filepath.Glob(filepath.Join(conf.DirPath, filePrefix+"*"))
+		for _, path := range old {
+			if err := os.Remove(path); err != nil {
+				log.Warn().Err(err).Str("path", path).Msg("failed to remove old spooled result")
+			}
+		}
+	}
+	return ans
+}
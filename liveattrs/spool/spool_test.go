@@ -0,0 +1,119 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package spool
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	Idx  int    `json:"idx"`
+	Name string `json:"name"`
+}
+
+func createTestResult(t *testing.T, sp *Spool, numItems int) *Result {
+	res, err := sp.Create("corp1", func(write func(item any) error) error {
+		for i := 0; i < numItems; i++ {
+			if err := write(testItem{Idx: i, Name: fmt.Sprintf("item%d", i)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	return res
+}
+
+func decodeItems(t *testing.T, page *Page) []testItem {
+	ans := make([]testItem, len(page.Items))
+	for i, item := range page.Items {
+		assert.NoError(t, json.Unmarshal(item, &ans[i]))
+	}
+	return ans
+}
+
+func TestGetPage(t *testing.T) {
+	sp := NewSpool(Conf{DirPath: t.TempDir()})
+	res := createTestResult(t, sp, 2500)
+	assert.Equal(t, 2500, res.NumItems)
+
+	page, err := sp.GetPage("corp1", res.ID, 3, 1000)
+	assert.NoError(t, err)
+	items := decodeItems(t, page)
+	assert.Len(t, items, 500)
+	assert.Equal(t, testItem{Idx: 2000, Name: "item2000"}, items[0])
+	assert.Equal(t, 2499, items[499].Idx)
+
+	page, err = sp.GetPage("corp1", res.ID, 2, 7)
+	assert.NoError(t, err)
+	items = decodeItems(t, page)
+	assert.Equal(t, []int{7, 8, 9, 10, 11, 12, 13}, func() []int {
+		ans := make([]int, len(items))
+		for i, item := range items {
+			ans[i] = item.Idx
+		}
+		return ans
+	}())
+
+	page, err = sp.GetPage("corp1", res.ID, 4, 1000)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+}
+
+func TestGetPageWrongOwner(t *testing.T) {
+	sp := NewSpool(Conf{DirPath: t.TempDir()})
+	res := createTestResult(t, sp, 10)
+	_, err := sp.GetPage("corp2", res.ID, 1, 10)
+	assert.ErrorIs(t, err, ErrorResultNotFound)
+	_, err = sp.GetPage("corp1", "foo", 1, 10)
+	assert.ErrorIs(t, err, ErrorResultNotFound)
+}
+
+func TestExpiredResult(t *testing.T) {
+	sp := NewSpool(Conf{DirPath: t.TempDir()})
+	res := createTestResult(t, sp, 10)
+	res.Expires = time.Now().Add(-time.Second)
+	sp.Cleanup()
+	_, err := sp.GetPage("corp1", res.ID, 1, 10)
+	assert.ErrorIs(t, err, ErrorResultNotFound)
+	assert.NoFileExists(t, res.path)
+}
+
+func TestCreateFillError(t *testing.T) {
+	dir := t.TempDir()
+	sp := NewSpool(Conf{DirPath: dir})
+	_, err := sp.Create("corp1", func(write func(item any) error) error {
+		write(testItem{Idx: 0})
+		return fmt.Errorf("db failure")
+	})
+	assert.Error(t, err)
+	assert.Empty(t, sp.results)
+}
+
+func TestDisabledSpool(t *testing.T) {
+	sp := NewSpool(Conf{})
+	assert.False(t, sp.Enabled())
+	_, err := sp.Create("corp1", func(write func(item any) error) error { return nil })
+	assert.Error(t, err)
+}
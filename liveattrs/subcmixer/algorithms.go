@@ -0,0 +1,236 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package subcmixer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Algorithm specifies how texts are selected to match
+// required category sizes
+type Algorithm string
+
+const (
+
+	// AlgorithmLP solves a linear programming relaxation of the problem
+	// (using the external Pulp solver) and rounds the results. This is
+	// the default.
+	AlgorithmLP Algorithm = "lp"
+
+	// AlgorithmILP solves the problem as an integer linear program
+	// (using the external Pulp solver). It is the most accurate one
+	// but it may be very slow for large corpora.
+	AlgorithmILP Algorithm = "ilp"
+
+	// AlgorithmGreedy adds texts from the largest ones as long as
+	// they fit into all the categories they belong to
+	AlgorithmGreedy Algorithm = "greedy"
+
+	// AlgorithmProportional repeatedly adds a random text to the category
+	// which is filled the least (relatively to its required size) so
+	// all the categories are filled proportionally
+	AlgorithmProportional Algorithm = "proportional"
+)
+
+// Normalized returns the algorithm with the default applied
+func (alg Algorithm) Normalized() Algorithm {
+	if alg == "" {
+		return AlgorithmLP
+	}
+	return alg
+}
+
+// Validate tests whether the algorithm is supported
+func (alg Algorithm) Validate() error {
+	switch alg.Normalized() {
+	case AlgorithmLP, AlgorithmILP, AlgorithmGreedy, AlgorithmProportional:
+		return nil
+	}
+	return fmt.Errorf("unsupported subcmixer algorithm: %s", alg)
+}
+
+const (
+
+	// progressReportInterval specifies after how many iterations
+	// a progress of an incremental selection is reported
+	progressReportInterval = 1000
+
+	ProgressPhasePreparing = "preparing"
+	ProgressPhaseSolving   = "solving"
+	ProgressPhaseFinished  = "finished"
+)
+
+// Progress describes a state of a running task
+type Progress struct {
+	Phase string `json:"phase"`
+
+	// Iterations is the number of processed candidate texts
+	// (available only for the greedy and proportional algorithms)
+	Iterations int `json:"iterations"`
+
+	// NumTexts is the number of all texts
+	NumTexts int `json:"numTexts"`
+
+	// Error is the largest relative difference between a required
+	// size of a category and its current size
+	Error float64 `json:"error"`
+}
+
+// ProgressFn receives progress updates of a running task
+type ProgressFn func(Progress)
+
+// selectionState keeps track of category sizes during
+// incremental selection of texts (see solveGreedy, solveProportional)
+type selectionState struct {
+	a          [][]float64
+	b          []float64
+	filled     []float64
+	selections []float64
+	iterations int
+	onProgress ProgressFn
+}
+
+func newSelectionState(a [][]float64, b []float64, numTexts int, onProgress ProgressFn) *selectionState {
+	return &selectionState{
+		a:          a,
+		b:          b,
+		filled:     make([]float64, len(b)),
+		selections: make([]float64, numTexts),
+		onProgress: onProgress,
+	}
+}
+
+// relError returns the largest relative difference between
+// a required size of a category and its current size
+func (st *selectionState) relError() float64 {
+	var ans float64
+	for i := range st.b {
+		if st.b[i] <= 0 {
+			continue
+		}
+		if v := math.Abs(st.b[i]-st.filled[i]) / st.b[i]; v > ans {
+			ans = v
+		}
+	}
+	return ans
+}
+
+// nextIteration counts processed candidate texts and reports
+// the progress (if requested) once in a while
+func (st *selectionState) nextIteration() {
+	st.iterations++
+	if st.onProgress != nil && st.iterations%progressReportInterval == 0 {
+		st.reportProgress()
+	}
+}
+
+func (st *selectionState) reportProgress() {
+	if st.onProgress == nil {
+		return
+	}
+	st.onProgress(Progress{
+		Phase:      ProgressPhaseSolving,
+		Iterations: st.iterations,
+		NumTexts:   len(st.selections),
+		Error:      st.relError(),
+	})
+}
+
+// fits tells whether a text can be added without exceeding
+// any of the required category sizes
+func (st *selectionState) fits(text int) bool {
+	for i := range st.b {
+		if st.filled[i]+st.a[i][text] > st.b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (st *selectionState) add(text int) {
+	for i := range st.b {
+		st.filled[i] += st.a[i][text]
+	}
+	st.selections[text] = 1
+}
+
+// solveGreedy selects texts from the largest ones (ties are
+// broken randomly) as long as they fit into their categories
+func solveGreedy(
+	a [][]float64, b []float64, textSizes []int, rng *rand.Rand, onProgress ProgressFn,
+) []float64 {
+	order := rng.Perm(len(textSizes))
+	sort.SliceStable(order, func(i, j int) bool {
+		return textSizes[order[i]] > textSizes[order[j]]
+	})
+	st := newSelectionState(a, b, len(textSizes), onProgress)
+	for _, text := range order {
+		if st.fits(text) {
+			st.add(text)
+		}
+		st.nextIteration()
+	}
+	st.reportProgress()
+	return st.selections
+}
+
+// solveProportional repeatedly picks the category filled the least
+// (relatively to its required size) and adds its next randomly
+// chosen text in case the text fits into all its categories
+func solveProportional(
+	a [][]float64, b []float64, textSizes []int, rng *rand.Rand, onProgress ProgressFn,
+) []float64 {
+	st := newSelectionState(a, b, len(textSizes), onProgress)
+	candidates := make([][]int, len(b))
+	for i := range b {
+		if b[i] <= 0 {
+			continue
+		}
+		for _, text := range rng.Perm(len(textSizes)) {
+			if a[i][text] > 0 {
+				candidates[i] = append(candidates[i], text)
+			}
+		}
+	}
+	for {
+		cat := -1
+		for i := range b {
+			if len(candidates[i]) == 0 {
+				continue
+			}
+			if cat < 0 || st.filled[i]/b[i] < st.filled[cat]/b[cat] {
+				cat = i
+			}
+		}
+		if cat < 0 {
+			break
+		}
+		text := candidates[cat][0]
+		candidates[cat] = candidates[cat][1:]
+		if st.selections[text] == 0 && st.fits(text) {
+			st.add(text)
+		}
+		st.nextIteration()
+	}
+	st.reportProgress()
+	return st.selections
+}
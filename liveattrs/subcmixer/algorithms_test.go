@@ -0,0 +1,96 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package subcmixer
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// two categories (e.g. fiction and non-fiction) and five texts
+func createTestingTask() ([][]float64, []float64, []int) {
+	a := [][]float64{
+		{100, 50, 0, 0, 30},
+		{0, 0, 80, 40, 0},
+	}
+	b := []float64{130, 100}
+	return a, b, []int{100, 50, 80, 40, 30}
+}
+
+func categorySizes(a [][]float64, selections []float64) []float64 {
+	ans := make([]float64, len(a))
+	for i := range a {
+		for j, v := range selections {
+			ans[i] += a[i][j] * v
+		}
+	}
+	return ans
+}
+
+func TestAlgorithmValidate(t *testing.T) {
+	assert.NoError(t, Algorithm("").Validate())
+	assert.NoError(t, AlgorithmProportional.Validate())
+	assert.Error(t, Algorithm("simplex").Validate())
+	assert.Equal(t, AlgorithmLP, Algorithm("").Normalized())
+}
+
+func TestSolveGreedy(t *testing.T) {
+	a, b, sizes := createTestingTask()
+	selections := solveGreedy(a, b, sizes, rand.New(rand.NewSource(1)), nil)
+	assert.Equal(t, []float64{1, 0, 1, 0, 1}, selections)
+	assert.Equal(t, []float64{130, 80}, categorySizes(a, selections))
+}
+
+func TestSolveProportionalRespectsLimits(t *testing.T) {
+	a, b, sizes := createTestingTask()
+	for seed := int64(0); seed < 20; seed++ {
+		selections := solveProportional(a, b, sizes, rand.New(rand.NewSource(seed)), nil)
+		catSizes := categorySizes(a, selections)
+		for i := range b {
+			assert.LessOrEqual(t, catSizes[i], b[i])
+			assert.Greater(t, catSizes[i], 0.0)
+		}
+	}
+}
+
+func TestSolveDeterministicSeed(t *testing.T) {
+	a, b, sizes := createTestingTask()
+	for _, solve := range []func([][]float64, []float64, []int, *rand.Rand, ProgressFn) []float64{
+		solveGreedy, solveProportional,
+	} {
+		s1 := solve(a, b, sizes, rand.New(rand.NewSource(42)), nil)
+		s2 := solve(a, b, sizes, rand.New(rand.NewSource(42)), nil)
+		assert.Equal(t, s1, s2)
+	}
+}
+
+func TestSolveGreedyProgress(t *testing.T) {
+	a, b, sizes := createTestingTask()
+	var updates []Progress
+	solveGreedy(a, b, sizes, rand.New(rand.NewSource(1)), func(p Progress) {
+		updates = append(updates, p)
+	})
+	assert.Len(t, updates, 1)
+	assert.Equal(t, ProgressPhaseSolving, updates[0].Phase)
+	assert.Equal(t, 5, updates[0].Iterations)
+	assert.Equal(t, 5, updates[0].NumTexts)
+	assert.InDelta(t, 0.2, updates[0].Error, 0.0001)
+}
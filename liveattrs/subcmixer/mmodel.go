@@ -29,10 +29,12 @@ import (
 	"masm/v3/general/collections"
 	"masm/v3/liveattrs/utils"
 	"math"
+	"math/rand"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -54,6 +56,10 @@ type CorpusComposition struct {
 	DocIDs        []string       `json:"docIds"`
 	SizeAssembled int            `json:"sizeAssembled"`
 	CategorySizes []CategorySize `json:"categorySizes"`
+
+	// Algorithm and Seed allow for reproducing the result
+	Algorithm Algorithm `json:"algorithm"`
+	Seed      int64     `json:"seed"`
 }
 
 type MetadataModel struct {
@@ -234,31 +240,22 @@ func (mm *MetadataModel) getAssembledSize(results []float64) float64 {
 	return ans
 }
 
-// Solve calculates a task of mixing texts with
-// defined type ratios. The core LP logic is
-// in the scripts/subcmixer_solve.py file
-// (based on the Pulp library). Please note that
-// the current implementation forces a hardcoded
+// solveExternal solves the task using an external Python solver
+// (scripts/subcmixer_solve.py, based on the Pulp library).
+// Please note that the current implementation forces a hardcoded
 // timeout specified with the constant [pulpSolverTimeoutSecs].
-func (mm *MetadataModel) Solve() *CorpusComposition {
+func (mm *MetadataModel) solveExternal(alg Algorithm, seed int64) ([]float64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), pulpSolverTimeoutSecs*time.Second)
 	defer cancel()
 
-	if mm.isZeroVector(mm.b) {
-		return &CorpusComposition{}
-	}
-	c := make([]float64, mm.numTexts)
-	for i := 0; i < mm.numTexts; i++ {
-		c[i] = 1.0
-	}
-
-	// here we use external python solver
 	json_data, err := json.Marshal(map[string]any{
-		"A": mm.a,
-		"b": mm.b,
+		"A":       mm.a,
+		"b":       mm.b,
+		"integer": alg == AlgorithmILP,
+		"seed":    seed,
 	})
 	if err != nil {
-		return &CorpusComposition{Error: err.Error()}
+		return nil, err
 	}
 
 	_, currPath, _, _ := runtime.Caller(0)
@@ -269,25 +266,23 @@ func (mm *MetadataModel) Solve() *CorpusComposition {
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return &CorpusComposition{Error: err.Error()}
+		return nil, err
 	}
 
 	err = cmd.Start()
 	if err != nil {
-		return &CorpusComposition{Error: err.Error()}
+		return nil, err
 	}
 
 	io.WriteString(stdin, string(json_data))
 	stdin.Close()
 
 	err = cmd.Wait()
-	if err == context.DeadlineExceeded {
-		return &CorpusComposition{
-			Error: fmt.Sprintf("Pulp LP solver timeout after %ds", pulpSolverTimeoutSecs),
-		}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("Pulp LP solver timeout after %ds", pulpSolverTimeoutSecs)
 
 	} else if err != nil {
-		return &CorpusComposition{Error: err.Error()}
+		return nil, err
 	}
 
 	variables := []float64{}
@@ -296,12 +291,47 @@ func (mm *MetadataModel) Solve() *CorpusComposition {
 		log.Err(err).Msg("")
 	}
 	log.Debug().Msgf("variables: %v", variables)
-
-	var simplexErr error
-	selections := common.MapSlice(
+	return common.MapSlice(
 		variables,
 		func(v float64, i int) float64 { return math.RoundToEven(v) },
-	)
+	), nil
+}
+
+// Solve calculates a task of mixing texts with defined type
+// ratios using a specified algorithm. The `seed` initializes
+// random choices of the algorithm so the same seed leads
+// to the same result for the same data.
+func (mm *MetadataModel) Solve(alg Algorithm, seed int64) *CorpusComposition {
+	return mm.SolveWithProgress(alg, seed, nil)
+}
+
+// SolveWithProgress works just like Solve but it also reports
+// progress of the calculation via `onProgress` (if not nil).
+// Please note that the external solver (lp, ilp algorithms)
+// does not provide any intermediate progress.
+func (mm *MetadataModel) SolveWithProgress(alg Algorithm, seed int64, onProgress ProgressFn) *CorpusComposition {
+	alg = alg.Normalized()
+	if mm.isZeroVector(mm.b) {
+		return &CorpusComposition{Algorithm: alg, Seed: seed}
+	}
+	var selections []float64
+	switch alg {
+	case AlgorithmGreedy:
+		selections = solveGreedy(mm.a, mm.b, mm.textSizes, rand.New(rand.NewSource(seed)), onProgress)
+	case AlgorithmProportional:
+		selections = solveProportional(mm.a, mm.b, mm.textSizes, rand.New(rand.NewSource(seed)), onProgress)
+	default:
+		if onProgress != nil {
+			onProgress(Progress{Phase: ProgressPhaseSolving, NumTexts: mm.numTexts})
+		}
+		var err error
+		selections, err = mm.solveExternal(alg, seed)
+		if err != nil {
+			return &CorpusComposition{Error: err.Error(), Algorithm: alg, Seed: seed}
+		}
+	}
+
+	var simplexErr error
 	categorySizes := make([]float64, mm.cTree.NumCategories()-1)
 
 	for c := 0; c < mm.cTree.NumCategories()-1; c++ {
@@ -317,6 +347,7 @@ func (mm *MetadataModel) Solve() *CorpusComposition {
 			docIDs = append(docIDs, docID)
 		}
 	}
+	sort.Strings(docIDs)
 	var errDesc string
 	if simplexErr != nil {
 		errDesc = simplexErr.Error()
@@ -327,6 +358,8 @@ func (mm *MetadataModel) Solve() *CorpusComposition {
 		Error:         errDesc,
 		DocIDs:        docIDs,
 		SizeAssembled: int(total),
+		Algorithm:     alg,
+		Seed:          seed,
 		CategorySizes: common.MapSlice(
 			categorySizes,
 			func(v float64, i int) CategorySize {
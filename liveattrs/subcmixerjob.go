@@ -0,0 +1,156 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"masm/v3/jobs"
+	"masm/v3/liveattrs/subcmixer"
+	"time"
+)
+
+const (
+	SubcmixerJobType = "liveattrs-subcmixer"
+)
+
+// SubcmixerRatio is a required ratio (in percents)
+// of texts with a specific attribute value
+type SubcmixerRatio struct {
+	AttrName  string  `json:"attrName"`
+	AttrValue string  `json:"attrValue"`
+	Ratio     float64 `json:"ratio"`
+}
+
+type SubcmixerJobInfoArgs struct {
+	Corpora   []string            `json:"corpora"`
+	TextTypes []SubcmixerRatio    `json:"textTypes"`
+	Algorithm subcmixer.Algorithm `json:"algorithm"`
+
+	// Seed is always set (a random one is generated if not
+	// specified by a user) so a restarted job provides
+	// the same result
+	Seed int64 `json:"seed"`
+}
+
+// SubcmixerJobInfo collects information about a job mixing
+// a subcorpus with specified text type ratios
+type SubcmixerJobInfo struct {
+	ID          string                       `json:"id"`
+	Type        string                       `json:"type"`
+	CorpusID    string                       `json:"corpusId"`
+	Start       jobs.JSONTime                `json:"start"`
+	Update      jobs.JSONTime                `json:"update"`
+	Finished    bool                         `json:"finished"`
+	Error       error                        `json:"error,omitempty"`
+	NumRestarts int                          `json:"numRestarts"`
+	Args        SubcmixerJobInfoArgs         `json:"args"`
+	Progress    subcmixer.Progress           `json:"progress"`
+	Result      *subcmixer.CorpusComposition `json:"result"`
+}
+
+func (j SubcmixerJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j SubcmixerJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j SubcmixerJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j SubcmixerJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j SubcmixerJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j SubcmixerJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j SubcmixerJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j SubcmixerJobInfo) FullInfo() any {
+	return struct {
+		ID          string                       `json:"id"`
+		Type        string                       `json:"type"`
+		CorpusID    string                       `json:"corpusId"`
+		Start       jobs.JSONTime                `json:"start"`
+		Update      jobs.JSONTime                `json:"update"`
+		Finished    bool                         `json:"finished"`
+		Error       string                       `json:"error,omitempty"`
+		OK          bool                         `json:"ok"`
+		NumRestarts int                          `json:"numRestarts"`
+		Args        SubcmixerJobInfoArgs         `json:"args"`
+		Progress    subcmixer.Progress           `json:"progress"`
+		Result      *subcmixer.CorpusComposition `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil && (j.Result == nil || j.Result.Error == ""),
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Progress:    j.Progress,
+		Result:      j.Result,
+	}
+}
+
+func (j SubcmixerJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil && (j.Result == nil || j.Result.Error == ""),
+	}
+}
+
+func (j SubcmixerJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j SubcmixerJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return SubcmixerJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Progress:    j.Progress,
+		Result:      j.Result,
+	}
+}
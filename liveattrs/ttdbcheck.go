@@ -0,0 +1,152 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"masm/v3/jobs"
+	"masm/v3/liveattrs/db/ttdb"
+	"time"
+)
+
+const (
+	TTDbCheckJobType = "liveattrs-ttdb-check"
+)
+
+// TTDbCheckResult is a result of a text types database verification
+type TTDbCheckResult struct {
+
+	// Check is a report of the installed database
+	Check *ttdb.Report `json:"check"`
+
+	// Repair is a report of the rebuilt database (if repaired)
+	Repair *ttdb.Report `json:"repair,omitempty"`
+
+	Repaired bool `json:"repaired"`
+}
+
+type TTDbCheckJobInfoArgs struct {
+
+	// Repair specifies whether an invalid database should
+	// be rebuilt out of the data stored in MySQL
+	Repair bool `json:"repair"`
+}
+
+// TTDbCheckJobInfo collects information about a job verifying
+// (and optionally repairing) an SQLite text types database
+type TTDbCheckJobInfo struct {
+	ID          string               `json:"id"`
+	Type        string               `json:"type"`
+	CorpusID    string               `json:"corpusId"`
+	Start       jobs.JSONTime        `json:"start"`
+	Update      jobs.JSONTime        `json:"update"`
+	Finished    bool                 `json:"finished"`
+	Error       error                `json:"error,omitempty"`
+	NumRestarts int                  `json:"numRestarts"`
+	Args        TTDbCheckJobInfoArgs `json:"args"`
+	Result      *TTDbCheckResult     `json:"result"`
+}
+
+func (j TTDbCheckJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j TTDbCheckJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j TTDbCheckJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j TTDbCheckJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j TTDbCheckJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j TTDbCheckJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j TTDbCheckJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j TTDbCheckJobInfo) FullInfo() any {
+	return struct {
+		ID          string               `json:"id"`
+		Type        string               `json:"type"`
+		CorpusID    string               `json:"corpusId"`
+		Start       jobs.JSONTime        `json:"start"`
+		Update      jobs.JSONTime        `json:"update"`
+		Finished    bool                 `json:"finished"`
+		Error       string               `json:"error,omitempty"`
+		OK          bool                 `json:"ok"`
+		NumRestarts int                  `json:"numRestarts"`
+		Args        TTDbCheckJobInfoArgs `json:"args"`
+		Result      *TTDbCheckResult     `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
+
+func (j TTDbCheckJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j TTDbCheckJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j TTDbCheckJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return TTDbCheckJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"masm/v3/jobs"
+	"sort"
+	"time"
+
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+)
+
+const (
+	UnusedColsJobType = "liveattrs-unused-columns"
+
+	// DfltUnusedColsMonths is a default number of months
+	// a column must not be queried to be reported as unused
+	DfltUnusedColsMonths = 6
+)
+
+// AttrUsage contains aggregated usage statistics of a column
+// (see the `usage` table). LastUsed is nil for columns never
+// queried and for records created before the last use time
+// has been tracked.
+type AttrUsage struct {
+	NumUsed  int
+	LastUsed *time.Time
+}
+
+// IsUnusedSince tests whether a column with the usage `u` has not
+// been queried since `since`. Columns used before the time of use
+// has been tracked are considered used as there is no way to tell.
+func (u AttrUsage) IsUnusedSince(since time.Time) bool {
+	if u.NumUsed == 0 {
+		return true
+	}
+	return u.LastUsed != nil && u.LastUsed.Before(since)
+}
+
+// ProtectedColumns returns columns which must not be reported
+// (and dropped) even if they are never queried directly, i.e.
+// columns of the bibliography view and self-join columns.
+func ProtectedColumns(conf *vteCnf.VTEConf) map[string]bool {
+	ans := make(map[string]bool)
+	if conf.BibView.IDAttr != "" {
+		ans[conf.BibView.IDAttr] = true
+	}
+	for _, col := range conf.BibView.Cols {
+		ans[col] = true
+	}
+	for _, col := range conf.SelfJoin.ArgColumns {
+		ans[col] = true
+	}
+	return ans
+}
+
+// UnusedColumn describes a column not queried for a specified time
+type UnusedColumn struct {
+	Attr     string     `json:"attr"`
+	NumUsed  int        `json:"numUsed"`
+	LastUsed *time.Time `json:"lastUsed"`
+
+	// DataSize is the size of the column's values in bytes
+	// (indexes and storage overhead are not included)
+	DataSize int64 `json:"dataSize"`
+}
+
+// UnusedColumnsReport lists unused columns of a liveattrs table.
+// For a table shared by a parallel corpus group, usage of all
+// the group members is considered.
+type UnusedColumnsReport struct {
+	CorpusID      string         `json:"corpusId"`
+	Table         string         `json:"table"`
+	Corpora       []string       `json:"corpora"`
+	Created       time.Time      `json:"created"`
+	UnusedSince   time.Time      `json:"unusedSince"`
+	Columns       []UnusedColumn `json:"columns"`
+	TotalDataSize int64          `json:"totalDataSize"`
+	Dropped       bool           `json:"dropped"`
+}
+
+// SortByCost sorts columns so the ones with the biggest
+// data come first
+func (r *UnusedColumnsReport) SortByCost() {
+	sort.SliceStable(r.Columns, func(i, j int) bool {
+		if r.Columns[i].DataSize != r.Columns[j].DataSize {
+			return r.Columns[i].DataSize > r.Columns[j].DataSize
+		}
+		return r.Columns[i].Attr < r.Columns[j].Attr
+	})
+}
+
+// Attrs returns names of all the reported columns
+func (r *UnusedColumnsReport) Attrs() []string {
+	ans := make([]string, len(r.Columns))
+	for i, col := range r.Columns {
+		ans[i] = col.Attr
+	}
+	return ans
+}
+
+type UnusedColsJobInfoArgs struct {
+	Months int `json:"months"`
+
+	// Drop specifies whether the unused columns should be removed
+	// from the table (and from the configs of the corpora)
+	Drop bool `json:"drop"`
+}
+
+// UnusedColsJobInfo collects information about a job searching
+// for (and optionally dropping) unused liveattrs columns
+type UnusedColsJobInfo struct {
+	ID          string                `json:"id"`
+	Type        string                `json:"type"`
+	CorpusID    string                `json:"corpusId"`
+	Start       jobs.JSONTime         `json:"start"`
+	Update      jobs.JSONTime         `json:"update"`
+	Finished    bool                  `json:"finished"`
+	Error       error                 `json:"error,omitempty"`
+	NumRestarts int                   `json:"numRestarts"`
+	Args        UnusedColsJobInfoArgs `json:"args"`
+	Result      *UnusedColumnsReport  `json:"result"`
+}
+
+func (j UnusedColsJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j UnusedColsJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j UnusedColsJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j UnusedColsJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j UnusedColsJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j UnusedColsJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j UnusedColsJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j UnusedColsJobInfo) FullInfo() any {
+	return struct {
+		ID          string                `json:"id"`
+		Type        string                `json:"type"`
+		CorpusID    string                `json:"corpusId"`
+		Start       jobs.JSONTime         `json:"start"`
+		Update      jobs.JSONTime         `json:"update"`
+		Finished    bool                  `json:"finished"`
+		Error       string                `json:"error,omitempty"`
+		OK          bool                  `json:"ok"`
+		NumRestarts int                   `json:"numRestarts"`
+		Args        UnusedColsJobInfoArgs `json:"args"`
+		Result      *UnusedColumnsReport  `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
+
+func (j UnusedColsJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j UnusedColsJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j UnusedColsJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return UnusedColsJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    j.Finished,
+		Error:       err,
+		NumRestarts: j.NumRestarts,
+		Args:        j.Args,
+		Result:      j.Result,
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"testing"
+	"time"
+
+	vteCnf "github.com/czcorpus/vert-tagextract/v2/cnf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrUsageIsUnusedSince(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := since.Add(-time.Hour)
+	after := since.Add(time.Hour)
+	assert.True(t, AttrUsage{}.IsUnusedSince(since))
+	assert.True(t, AttrUsage{NumUsed: 3, LastUsed: &before}.IsUnusedSince(since))
+	assert.False(t, AttrUsage{NumUsed: 3, LastUsed: &after}.IsUnusedSince(since))
+	assert.False(t, AttrUsage{NumUsed: 3}.IsUnusedSince(since))
+}
+
+func TestProtectedColumns(t *testing.T) {
+	conf := &vteCnf.VTEConf{}
+	conf.BibView.IDAttr = "doc_id"
+	conf.BibView.Cols = []string{"doc_title", "doc_author"}
+	conf.SelfJoin.ArgColumns = []string{"div_group"}
+	assert.Equal(
+		t,
+		map[string]bool{"doc_id": true, "doc_title": true, "doc_author": true, "div_group": true},
+		ProtectedColumns(conf),
+	)
+}
+
+func TestUnusedColumnsReportSortByCost(t *testing.T) {
+	report := UnusedColumnsReport{
+		Columns: []UnusedColumn{
+			{Attr: "doc.b", DataSize: 10},
+			{Attr: "doc.c", DataSize: 300},
+			{Attr: "doc.a", DataSize: 10},
+		},
+	}
+	report.SortByCost()
+	assert.Equal(t, []string{"doc.c", "doc.a", "doc.b"}, report.Attrs())
+}
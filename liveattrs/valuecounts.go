@@ -0,0 +1,167 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ValueCount is a number of items (structures) with an attribute
+// value and their total size (in positions)
+type ValueCount struct {
+	NumItems int   `json:"numItems"`
+	Poscount int64 `json:"poscount"`
+}
+
+// AttrValueCounts maps attributes (in the `struct.attr` form)
+// to counts of their individual values
+type AttrValueCounts map[string]map[string]ValueCount
+
+// ValueCountsSnapshot describes stored value counts of a corpus
+// created by a liveattrs data extraction job
+type ValueCountsSnapshot struct {
+	ID       int64     `json:"id"`
+	CorpusID string    `json:"corpusId"`
+	JobID    string    `json:"jobId"`
+	Created  time.Time `json:"created"`
+}
+
+// ValueCountChange describes a value present in one of the compared
+// snapshots only or a value with a changed count. The Shift is a relative
+// change of the value's poscount (e.g. -0.5 means half of the original
+// size).
+type ValueCountChange struct {
+	Attr  string      `json:"attr"`
+	Value string      `json:"value"`
+	Old   *ValueCount `json:"old,omitempty"`
+	New   *ValueCount `json:"new,omitempty"`
+	Shift float64     `json:"shift,omitempty"`
+}
+
+// ValueCountsDiff is a comparison of two value counts snapshots
+// of a corpus. Listed changes are limited (see DiffValueCounts),
+// the Num* values contain total numbers of changes.
+type ValueCountsDiff struct {
+	From             *ValueCountsSnapshot `json:"from"`
+	To               *ValueCountsSnapshot `json:"to"`
+	NewAttrs         []string             `json:"newAttrs"`
+	RemovedAttrs     []string             `json:"removedAttrs"`
+	NewValues        []ValueCountChange   `json:"newValues"`
+	NumNewValues     int                  `json:"numNewValues"`
+	RemovedValues    []ValueCountChange   `json:"removedValues"`
+	NumRemovedValues int                  `json:"numRemovedValues"`
+	Shifts           []ValueCountChange   `json:"shifts"`
+	NumShifts        int                  `json:"numShifts"`
+}
+
+func (diff *ValueCountsDiff) HasChanges() bool {
+	return len(diff.NewAttrs) > 0 || len(diff.RemovedAttrs) > 0 ||
+		diff.NumNewValues > 0 || diff.NumRemovedValues > 0 || diff.NumShifts > 0
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	ans := make([]string, 0, len(m))
+	for k := range m {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func limitChanges(changes []ValueCountChange, maxItems int) []ValueCountChange {
+	if maxItems > 0 && len(changes) > maxItems {
+		return changes[:maxItems]
+	}
+	return changes
+}
+
+// DiffValueCounts compares value counts of two snapshots. Only attributes
+// present in both snapshots are compared value by value. A value is reported
+// as shifted in case the relative change of its poscount is at least
+// `minShift` (e.g. 0.5 = 50%). Shifts are sorted from the largest ones,
+// each list of changes contains at most `maxItems` items (0 = no limit).
+func DiffValueCounts(
+	oldCounts, newCounts AttrValueCounts,
+	minShift float64,
+	maxItems int,
+) *ValueCountsDiff {
+	ans := &ValueCountsDiff{
+		NewAttrs:      []string{},
+		RemovedAttrs:  []string{},
+		NewValues:     []ValueCountChange{},
+		RemovedValues: []ValueCountChange{},
+		Shifts:        []ValueCountChange{},
+	}
+	for _, attr := range sortedKeys(oldCounts) {
+		if _, ok := newCounts[attr]; !ok {
+			ans.RemovedAttrs = append(ans.RemovedAttrs, attr)
+		}
+	}
+	for _, attr := range sortedKeys(newCounts) {
+		oldValues, ok := oldCounts[attr]
+		if !ok {
+			ans.NewAttrs = append(ans.NewAttrs, attr)
+			continue
+		}
+		newValues := newCounts[attr]
+		for _, value := range sortedKeys(oldValues) {
+			if _, ok := newValues[value]; !ok {
+				oldCount := oldValues[value]
+				ans.RemovedValues = append(
+					ans.RemovedValues,
+					ValueCountChange{Attr: attr, Value: value, Old: &oldCount},
+				)
+			}
+		}
+		for _, value := range sortedKeys(newValues) {
+			newCount := newValues[value]
+			oldCount, ok := oldValues[value]
+			if !ok {
+				ans.NewValues = append(
+					ans.NewValues,
+					ValueCountChange{Attr: attr, Value: value, New: &newCount},
+				)
+				continue
+			}
+			if oldCount.Poscount == 0 {
+				continue
+			}
+			shift := float64(newCount.Poscount-oldCount.Poscount) / float64(oldCount.Poscount)
+			if math.Abs(shift) >= minShift && shift != 0 {
+				ans.Shifts = append(
+					ans.Shifts,
+					ValueCountChange{
+						Attr: attr, Value: value, Old: &oldCount, New: &newCount, Shift: shift},
+				)
+			}
+		}
+	}
+	sort.SliceStable(ans.Shifts, func(i, j int) bool {
+		return math.Abs(ans.Shifts[i].Shift) > math.Abs(ans.Shifts[j].Shift)
+	})
+	ans.NumNewValues = len(ans.NewValues)
+	ans.NumRemovedValues = len(ans.RemovedValues)
+	ans.NumShifts = len(ans.Shifts)
+	ans.NewValues = limitChanges(ans.NewValues, maxItems)
+	ans.RemovedValues = limitChanges(ans.RemovedValues, maxItems)
+	ans.Shifts = limitChanges(ans.Shifts, maxItems)
+	return ans
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffValueCounts(t *testing.T) {
+	oldCounts := AttrValueCounts{
+		"doc.year": {
+			"2019": {NumItems: 10, Poscount: 1000},
+			"2020": {NumItems: 10, Poscount: 1000},
+			"2021": {NumItems: 10, Poscount: 1000},
+		},
+		"doc.genre": {"news": {NumItems: 30, Poscount: 3000}},
+	}
+	newCounts := AttrValueCounts{
+		"doc.year": {
+			"2019": {NumItems: 10, Poscount: 1100},
+			"2021": {NumItems: 3, Poscount: 300},
+			"2022": {NumItems: 10, Poscount: 1000},
+		},
+		"doc.lang": {"cs": {NumItems: 23, Poscount: 2400}},
+	}
+	diff := DiffValueCounts(oldCounts, newCounts, 0.5, 0)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, []string{"doc.lang"}, diff.NewAttrs)
+	assert.Equal(t, []string{"doc.genre"}, diff.RemovedAttrs)
+	assert.Equal(t, 1, diff.NumNewValues)
+	assert.Equal(t, "2022", diff.NewValues[0].Value)
+	assert.Nil(t, diff.NewValues[0].Old)
+	assert.Equal(t, 1, diff.NumRemovedValues)
+	assert.Equal(t, "2020", diff.RemovedValues[0].Value)
+	assert.Equal(t, 10, diff.RemovedValues[0].Old.NumItems)
+	assert.Equal(t, 1, diff.NumShifts)
+	assert.Equal(t, "2021", diff.Shifts[0].Value)
+	assert.InDelta(t, -0.7, diff.Shifts[0].Shift, 0.0001)
+}
+
+func TestDiffValueCountsShiftOrderAndLimit(t *testing.T) {
+	oldCounts := AttrValueCounts{
+		"doc.year": {
+			"2019": {NumItems: 1, Poscount: 100},
+			"2020": {NumItems: 1, Poscount: 100},
+			"2021": {NumItems: 1, Poscount: 100},
+		},
+	}
+	newCounts := AttrValueCounts{
+		"doc.year": {
+			"2019": {NumItems: 1, Poscount: 40},
+			"2020": {NumItems: 1, Poscount: 400},
+			"2021": {NumItems: 1, Poscount: 100},
+		},
+	}
+	diff := DiffValueCounts(oldCounts, newCounts, 0.5, 1)
+	assert.Equal(t, 2, diff.NumShifts)
+	assert.Len(t, diff.Shifts, 1)
+	assert.Equal(t, "2020", diff.Shifts[0].Value)
+	assert.InDelta(t, 3.0, diff.Shifts[0].Shift, 0.0001)
+}
+
+func TestDiffValueCountsNoChanges(t *testing.T) {
+	counts := AttrValueCounts{"doc.year": {"2019": {NumItems: 1, Poscount: 100}}}
+	diff := DiffValueCounts(counts, counts, 0.5, 0)
+	assert.False(t, diff.HasChanges())
+	assert.Equal(t, []ValueCountChange{}, diff.Shifts)
+}
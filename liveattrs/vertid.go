@@ -0,0 +1,95 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	verticalIDChunkSize = 1024 * 1024
+)
+
+// VerticalIdentity identifies a vertical file processed
+// by a data extraction job
+type VerticalIdentity struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// SameAs tests whether two identities represent the same file
+// (the path is not considered as the same file can be
+// available under different paths)
+func (vi VerticalIdentity) SameAs(other VerticalIdentity) bool {
+	return vi.Size == other.Size && vi.Hash == other.Hash
+}
+
+// IdentifyVertical creates an identity of a vertical file based on its
+// size and a hash of its first and last verticalIDChunkSize bytes (hashing
+// whole verticals would take too long).
+func IdentifyVertical(path string) (VerticalIdentity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+	}
+	defer f.Close()
+	finfo, err := f.Stat()
+	if err != nil {
+		return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+	}
+	hsh := sha1.New()
+	if _, err := io.CopyN(hsh, f, verticalIDChunkSize); err != nil && err != io.EOF {
+		return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+	}
+	if finfo.Size() > 2*verticalIDChunkSize {
+		if _, err := f.Seek(-verticalIDChunkSize, io.SeekEnd); err != nil {
+			return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+		}
+
+	} else if _, err := f.Seek(verticalIDChunkSize, io.SeekStart); err != nil {
+		return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+	}
+	if _, err := io.Copy(hsh, f); err != nil {
+		return VerticalIdentity{}, fmt.Errorf("failed to identify vertical %s: %w", path, err)
+	}
+	return VerticalIdentity{
+		Path: path,
+		Size: finfo.Size(),
+		Hash: hex.EncodeToString(hsh.Sum(nil)),
+	}, nil
+}
+
+// IdentifyVerticals creates identities of multiple vertical files
+// (see IdentifyVertical)
+func IdentifyVerticals(paths []string) ([]VerticalIdentity, error) {
+	ans := make([]VerticalIdentity, len(paths))
+	for i, path := range paths {
+		var err error
+		ans[i], err = IdentifyVertical(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
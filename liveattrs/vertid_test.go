@@ -0,0 +1,67 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package liveattrs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifyVertical(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("word\tlemma\n"), 300000)
+	path1 := filepath.Join(dir, "vert1")
+	path2 := filepath.Join(dir, "vert2")
+	assert.NoError(t, os.WriteFile(path1, data, 0644))
+	assert.NoError(t, os.WriteFile(path2, data, 0644))
+	id1, err := IdentifyVertical(path1)
+	assert.NoError(t, err)
+	id2, err := IdentifyVertical(path2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), id1.Size)
+	assert.True(t, id1.SameAs(id2))
+
+	// change near the end of the file
+	data[len(data)-2] = 'X'
+	assert.NoError(t, os.WriteFile(path2, data, 0644))
+	id2, err = IdentifyVertical(path2)
+	assert.NoError(t, err)
+	assert.False(t, id1.SameAs(id2))
+}
+
+func TestIdentifySmallVertical(t *testing.T) {
+	dir := t.TempDir()
+	path1 := filepath.Join(dir, "vert1")
+	path2 := filepath.Join(dir, "vert2")
+	assert.NoError(t, os.WriteFile(path1, []byte("foo\n"), 0644))
+	assert.NoError(t, os.WriteFile(path2, []byte("bar\n"), 0644))
+	ids, err := IdentifyVerticals([]string{path1, path2})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 2)
+	assert.False(t, ids[0].SameAs(ids[1]))
+}
+
+func TestIdentifyMissingVertical(t *testing.T) {
+	_, err := IdentifyVertical(filepath.Join(t.TempDir(), "nonexistent"))
+	assert.Error(t, err)
+}
@@ -78,6 +78,26 @@ CorpusStringRetval get_corpus_conf(CorpusV corpus, const char* prop) {
     }
 }
 
+CorpusSizeRetrval get_struct_coverage(CorpusV corpus, const char* structName) {
+    CorpusSizeRetrval ans;
+    ans.err = nullptr;
+    ans.value = 0;
+    string tmp(structName);
+    try {
+        Structure* strc = ((Corpus*)corpus)->get_struct(tmp);
+        RangeStream* rs = strc->rng->whole();
+        while (!rs->end()) {
+            ans.value += rs->peek_end() - rs->peek_beg();
+            rs->next();
+        }
+        delete rs;
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
 
 ConcRetval create_concordance(CorpusV corpus, char* query) {
     string q(query);
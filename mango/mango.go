@@ -32,6 +32,7 @@ import (
 // GoCorpus is a Go wrapper for Manatee Corpus instance
 type GoCorpus struct {
 	corp C.CorpusV
+	path string
 }
 
 func (gc *GoCorpus) Close() {
@@ -75,9 +76,16 @@ type GoColls struct {
 }
 
 // OpenCorpus is a factory function creating
-// a Manatee corpus wrapper.
+// a Manatee corpus wrapper. The number of simultaneously
+// open corpora is limited by the corpus pool (see ConfigurePool)
+// so the call may block until some corpus is closed.
+// The returned corpus must be closed via CloseCorpus.
 func OpenCorpus(path string) (*GoCorpus, error) {
-	ret := &GoCorpus{}
+	return pool.acquire(path)
+}
+
+func openCorpus(path string) (*GoCorpus, error) {
+	ret := &GoCorpus{path: path}
 	var err error
 	ans := C.open_corpus(C.CString(path))
 
@@ -93,10 +101,12 @@ func OpenCorpus(path string) (*GoCorpus, error) {
 	return ret, nil
 }
 
-// CloseCorpus closes all the resources accompanying
-// the corpus. The instance should become unusable.
+// CloseCorpus returns the corpus to the corpus pool which
+// either keeps it open for reuse or closes all the resources
+// accompanying the corpus. In any case, the instance should
+// not be used by the caller anymore.
 func CloseCorpus(corpus *GoCorpus) error {
-	C.close_corpus(corpus.corp)
+	pool.release(corpus)
 	return nil
 }
 
@@ -123,6 +133,20 @@ func GetCorpusConf(corpus *GoCorpus, prop string) (string, error) {
 	return C.GoString(ans.value), nil
 }
 
+// GetStructCoverage returns number of corpus positions
+// covered by a structure
+func GetStructCoverage(corpus *GoCorpus, structName string) (int64, error) {
+	cStructName := C.CString(structName)
+	defer C.free(unsafe.Pointer(cStructName))
+	ans := C.get_struct_coverage(corpus.corp, cStructName)
+	if ans.err != nil {
+		err := fmt.Errorf(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return -1, err
+	}
+	return int64(ans.value), nil
+}
+
 func CreateConcordance(corpus *GoCorpus, query string) (*GoConc, error) {
 	var ret GoConc
 	ans := C.create_concordance(corpus.corp, C.CString(query))
@@ -84,6 +84,12 @@ CorpusSizeRetrval get_corpus_size(CorpusV corpus);
 
 CorpusStringRetval get_corpus_conf(CorpusV corpus, const char* prop);
 
+/**
+ * Get number of corpus positions covered
+ * by a structure
+ */
+CorpusSizeRetrval get_struct_coverage(CorpusV corpus, const char* structName);
+
 ConcRetval create_concordance(CorpusV corpus, char* query);
 
 PosInt concordance_size(ConcV conc);
@@ -0,0 +1,257 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package mango
+
+import (
+	"container/list"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// PoolStats contains information about corpora opened
+// via the corpus pool
+type PoolStats struct {
+	MaxOpen   int `json:"maxOpen"`
+	NumOpen   int `json:"numOpen"`
+	NumIdle   int `json:"numIdle"`
+	NumInUse  int `json:"numInUse"`
+	NumOpened int `json:"numOpened"`
+	NumClosed int `json:"numClosed"`
+
+	// NumReused is a number of times an idle corpus handle
+	// has been reused instead of opening the corpus again
+	NumReused int `json:"numReused"`
+
+	// NumWaits is a number of times a caller had to wait
+	// for a free slot
+	NumWaits int `json:"numWaits"`
+
+	// OpenRate is an average number of opened corpora per minute
+	OpenRate float64 `json:"openRate"`
+
+	// CloseRate is an average number of closed corpora per minute
+	CloseRate float64 `json:"closeRate"`
+
+	Since time.Time `json:"since"`
+}
+
+// corpusPool limits the number of simultaneously open Manatee
+// corpora and keeps recently released corpus handles open
+// (in an LRU manner) so they can be reused.
+//
+// Handles are never shared among concurrent users - an idle
+// handle is reused only once it has been released.
+//
+// Please note that a caller holding a handle must not acquire
+// another one as with a limited number of open corpora, concurrent
+// callers may end up waiting for each other forever.
+type corpusPool struct {
+	maxOpen int
+	maxIdle int
+
+	// idle is an LRU list of released corpus handles (*GoCorpus)
+	// with the most recently released at the front
+	idle  *list.List
+	inUse int
+
+	// borrowed contains handles currently in use. The value tells
+	// whether the handle has been evicted (see evict) and thus
+	// should be closed once released.
+	borrowed map[*GoCorpus]bool
+
+	open        func(path string) (*GoCorpus, error)
+	closeHandle func(corp *GoCorpus)
+
+	numOpened int
+	numClosed int
+	numReused int
+	numWaits  int
+	since     time.Time
+
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+func (p *corpusPool) numOpen() int {
+	return p.inUse + p.idle.Len()
+}
+
+func (p *corpusPool) closeElement(elm *list.Element) {
+	corp := p.idle.Remove(elm).(*GoCorpus)
+	p.closeHandle(corp)
+	p.numClosed++
+}
+
+func (p *corpusPool) findIdle(path string) *list.Element {
+	for elm := p.idle.Front(); elm != nil; elm = elm.Next() {
+		if elm.Value.(*GoCorpus).path == path {
+			return elm
+		}
+	}
+	return nil
+}
+
+// acquire returns an idle handle of a corpus or opens a new one.
+// In case the maximum number of open corpora is reached, the least
+// recently used idle handle is closed. If there is no idle handle,
+// the call blocks until some handle is released.
+func (p *corpusPool) acquire(path string) (*GoCorpus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elm := p.findIdle(path); elm != nil {
+		corp := p.idle.Remove(elm).(*GoCorpus)
+		p.inUse++
+		p.borrowed[corp] = false
+		p.numReused++
+		return corp, nil
+	}
+	waited := false
+	for p.maxOpen > 0 && p.numOpen() >= p.maxOpen {
+		if back := p.idle.Back(); back != nil {
+			p.closeElement(back)
+			break
+		}
+		if !waited {
+			p.numWaits++
+			waited = true
+		}
+		p.cond.Wait()
+	}
+	// reserve the slot so we can open the corpus without holding the lock
+	p.inUse++
+	p.mu.Unlock()
+	corp, err := p.open(path)
+	p.mu.Lock()
+	if err != nil {
+		p.inUse--
+		p.cond.Signal()
+		return corp, err
+	}
+	p.borrowed[corp] = false
+	p.numOpened++
+	return corp, nil
+}
+
+// release returns a handle to the pool. In case there are
+// too many idle handles, the least recently used ones are closed.
+// Evicted handles are closed immediately.
+func (p *corpusPool) release(corp *GoCorpus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inUse--
+	evicted := p.borrowed[corp]
+	delete(p.borrowed, corp)
+	if evicted {
+		p.closeHandle(corp)
+		p.numClosed++
+		p.cond.Signal()
+		return
+	}
+	p.idle.PushFront(corp)
+	for p.idle.Len() > p.maxIdle {
+		p.closeElement(p.idle.Back())
+	}
+	p.cond.Signal()
+}
+
+// evict closes idle handles of a corpus specified by its registry path
+// and marks handles in use so they are closed once released. This
+// prevents reusing handles opened on outdated corpus data.
+func (p *corpusPool) evict(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	path = filepath.Clean(path)
+	for elm := p.idle.Front(); elm != nil; {
+		next := elm.Next()
+		if filepath.Clean(elm.Value.(*GoCorpus).path) == path {
+			p.closeElement(elm)
+		}
+		elm = next
+	}
+	for corp := range p.borrowed {
+		if filepath.Clean(corp.path) == path {
+			p.borrowed[corp] = true
+		}
+	}
+	p.cond.Broadcast()
+}
+
+func (p *corpusPool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ans := PoolStats{
+		MaxOpen:   p.maxOpen,
+		NumOpen:   p.numOpen(),
+		NumIdle:   p.idle.Len(),
+		NumInUse:  p.inUse,
+		NumOpened: p.numOpened,
+		NumClosed: p.numClosed,
+		NumReused: p.numReused,
+		NumWaits:  p.numWaits,
+		Since:     p.since,
+	}
+	if mins := time.Since(p.since).Minutes(); mins > 0 {
+		ans.OpenRate = float64(p.numOpened) / mins
+		ans.CloseRate = float64(p.numClosed) / mins
+	}
+	return ans
+}
+
+var pool = newCorpusPool(0, 0)
+
+func newCorpusPool(maxOpen, maxIdle int) *corpusPool {
+	ans := &corpusPool{
+		maxOpen:  maxOpen,
+		maxIdle:  maxIdle,
+		idle:     list.New(),
+		borrowed: make(map[*GoCorpus]bool),
+		open:     openCorpus,
+		closeHandle: func(corp *GoCorpus) {
+			corp.Close()
+		},
+		since: time.Now(),
+	}
+	ans.cond = sync.NewCond(&ans.mu)
+	return ans
+}
+
+// ConfigurePool sets the maximum number of simultaneously open corpora
+// (zero means unlimited) and the maximum number of released corpus handles
+// kept open for reuse. It should be called before any corpus is opened.
+func ConfigurePool(maxOpen, maxIdle int) {
+	if maxOpen > 0 && maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	pool = newCorpusPool(maxOpen, maxIdle)
+}
+
+// EvictCorpus makes sure no handle of a corpus specified by its
+// registry path is reused. Idle handles are closed immediately,
+// handles in use are closed once released. It should be called
+// whenever corpus data or the registry file change.
+func EvictCorpus(path string) {
+	pool.evict(path)
+}
+
+// GetPoolStats returns information about currently open
+// corpora and open/close rates since the pool was configured.
+func GetPoolStats() PoolStats {
+	return pool.stats()
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package mango
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestingPool(maxOpen, maxIdle int) (*corpusPool, *[]string) {
+	closed := make([]string, 0, 10)
+	p := newCorpusPool(maxOpen, maxIdle)
+	p.open = func(path string) (*GoCorpus, error) {
+		return &GoCorpus{path: path}, nil
+	}
+	p.closeHandle = func(corp *GoCorpus) {
+		closed = append(closed, corp.path)
+	}
+	return p, &closed
+}
+
+func TestPoolReusesIdleHandle(t *testing.T) {
+	p, _ := newTestingPool(2, 2)
+	corp1, err := p.acquire("/reg/syn2020")
+	assert.NoError(t, err)
+	p.release(corp1)
+	corp2, err := p.acquire("/reg/syn2020")
+	assert.NoError(t, err)
+	assert.Same(t, corp1, corp2)
+	assert.Equal(t, 1, p.stats().NumReused)
+	assert.Equal(t, 1, p.stats().NumOpened)
+}
+
+func TestPoolClosesIdleHandleWhenFull(t *testing.T) {
+	p, closed := newTestingPool(1, 1)
+	corp1, err := p.acquire("/reg/syn2020")
+	assert.NoError(t, err)
+	p.release(corp1)
+	corp2, err := p.acquire("/reg/omezeni/syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/reg/syn2020"}, *closed)
+	p.release(corp2)
+	assert.Equal(t, 1, p.stats().NumOpen)
+}
+
+func TestPoolNestedAcquireWaitsForRelease(t *testing.T) {
+	p, _ := newTestingPool(1, 1)
+	outer, err := p.acquire("/reg/syn2020")
+	assert.NoError(t, err)
+	acquired := make(chan *GoCorpus)
+	go func() {
+		inner, err := p.acquire("/reg/omezeni/syn2020")
+		assert.NoError(t, err)
+		acquired <- inner
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("nested acquire must wait for a free slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	p.release(outer)
+	select {
+	case inner := <-acquired:
+		assert.Equal(t, "/reg/omezeni/syn2020", inner.path)
+		p.release(inner)
+	case <-time.After(time.Second):
+		t.Fatal("nested acquire has not been unblocked by release")
+	}
+	assert.Equal(t, 1, p.stats().NumWaits)
+}
+
+func TestPoolNestedAcquireWithinLimit(t *testing.T) {
+	p, _ := newTestingPool(2, 2)
+	outer, err := p.acquire("/reg/syn2020")
+	assert.NoError(t, err)
+	inner, err := p.acquire("/reg/omezeni/syn2020")
+	assert.NoError(t, err)
+	p.release(inner)
+	p.release(outer)
+	stats := p.stats()
+	assert.Equal(t, 0, stats.NumInUse)
+	assert.Equal(t, 2, stats.NumIdle)
+	assert.Equal(t, 0, stats.NumWaits)
+}
+
+func TestPoolEvictClosesIdleHandles(t *testing.T) {
+	p, closed := newTestingPool(0, 5)
+	corp1, _ := p.acquire("/reg/syn2020")
+	corp2, _ := p.acquire("/reg/syn2015")
+	p.release(corp1)
+	p.release(corp2)
+	p.evict("/reg/./syn2020")
+	assert.Equal(t, []string{"/reg/syn2020"}, *closed)
+	corp3, _ := p.acquire("/reg/syn2020")
+	assert.NotSame(t, corp1, corp3)
+	assert.Equal(t, 3, p.stats().NumOpened)
+}
+
+func TestPoolEvictClosesHandleInUseOnRelease(t *testing.T) {
+	p, closed := newTestingPool(0, 5)
+	corp, _ := p.acquire("/reg/syn2020")
+	p.evict("/reg/syn2020")
+	assert.Empty(t, *closed)
+	p.release(corp)
+	assert.Equal(t, []string{"/reg/syn2020"}, *closed)
+	stats := p.stats()
+	assert.Equal(t, 0, stats.NumOpen)
+	assert.Equal(t, 1, stats.NumClosed)
+}
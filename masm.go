@@ -42,13 +42,29 @@ import (
 	"masm/v3/corpdata"
 	"masm/v3/corpus"
 	"masm/v3/corpus/query"
+	"masm/v3/db/health"
 	"masm/v3/db/mysql"
 	"masm/v3/debug"
 	"masm/v3/general"
+	"masm/v3/general/loglevel"
+	"masm/v3/general/quota"
 	"masm/v3/jobs"
 	"masm/v3/liveattrs"
 	laActions "masm/v3/liveattrs/actions"
+	"masm/v3/liveattrs/cache"
+	"masm/v3/liveattrs/laconf"
+	"masm/v3/liveattrs/request/attrdeps"
+	"masm/v3/liveattrs/request/attrstats"
+	"masm/v3/liveattrs/request/biblio"
+	"masm/v3/liveattrs/request/cooccurrence"
+	"masm/v3/liveattrs/request/equery"
+	"masm/v3/liveattrs/request/fillattrs"
+	laQuery "masm/v3/liveattrs/request/query"
+	"masm/v3/liveattrs/request/response"
+	"masm/v3/liveattrs/request/selshare"
+	"masm/v3/mango"
 	"masm/v3/registry"
+	"masm/v3/replication"
 	"masm/v3/root"
 
 	_ "masm/v3/translations"
@@ -67,7 +83,118 @@ type ExitHandler interface {
 func init() {
 	gob.Register(&liveattrs.LiveAttrsJobInfo{})
 	gob.Register(&liveattrs.IdxUpdateJobInfo{})
+	gob.Register(&liveattrs.UnusedColsJobInfo{})
+	gob.Register(&liveattrs.QualityJobInfo{})
+	gob.Register(&liveattrs.SubcmixerJobInfo{})
+	gob.Register(&liveattrs.TTDbCheckJobInfo{})
+	gob.Register(&liveattrs.LangDetectJobInfo{})
 	gob.Register(&corpus.JobInfo{})
+	gob.Register(&corpus.RemovalJobInfo{})
+	gob.Register(&corpus.ExportJobInfo{})
+	gob.Register(&corpus.RenameJobInfo{})
+	gob.Register(&corpus.ManifestJobInfo{})
+	gob.Register(&jobs.PipelineJobInfo{})
+}
+
+// restartDetachedJobs restarts unfinished jobs loaded from the status
+// data file (or replicated from a primary instance). Pipelines are
+// restarted last so they can wait for restarted jobs of their steps.
+func restartDetachedJobs(
+	jobActions *jobs.Actions,
+	liveattrsActions *laActions.Actions,
+	corpusActions *corpus.Actions,
+) {
+	pipelines := make([]*jobs.PipelineJobInfo, 0, 5)
+	for _, dj := range jobActions.GetDetachedJobs() {
+		if dj.IsFinished() {
+			continue
+		}
+		switch tdj := dj.(type) {
+		case *liveattrs.LiveAttrsJobInfo:
+			err := liveattrsActions.RestartLiveAttrsJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.IdxUpdateJobInfo:
+			err := liveattrsActions.RestartIdxUpdateJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.UnusedColsJobInfo:
+			err := liveattrsActions.RestartUnusedColsJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.QualityJobInfo:
+			err := liveattrsActions.RestartQualityJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.SubcmixerJobInfo:
+			err := liveattrsActions.RestartSubcmixerJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.TTDbCheckJobInfo:
+			err := liveattrsActions.RestartTTDbCheckJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *liveattrs.LangDetectJobInfo:
+			err := liveattrsActions.RestartLangDetectJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *corpus.JobInfo:
+			err := corpusActions.RestartJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *corpus.RemovalJobInfo:
+			err := corpusActions.RestartRemovalJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *corpus.ExportJobInfo:
+			err := corpusActions.RestartExportJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *corpus.ManifestJobInfo:
+			err := corpusActions.RestartManifestJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *corpus.RenameJobInfo:
+			err := corpusActions.RestartRenameJob(tdj)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
+			}
+			jobActions.ClearDetachedJob(tdj.ID)
+		case *jobs.PipelineJobInfo:
+			pipelines = append(pipelines, tdj)
+		default:
+			log.Error().Msg("unknown detached job type")
+		}
+	}
+	for _, pipeline := range pipelines {
+		err := jobActions.RestartPipeline(pipeline)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to restart pipeline %s. The pipeline will be removed.", pipeline.ID)
+		}
+		jobActions.ClearDetachedJob(pipeline.ID)
+	}
 }
 
 func main() {
@@ -93,6 +220,7 @@ func main() {
 	}
 	conf := cnf.LoadConfig(flag.Arg(1))
 	logging.SetupLogging(conf.LogFile, conf.LogLevel)
+	loglevel.Init()
 	log.Info().Msg("Starting MASM (Manatee Assets, Services and Metadata)")
 	cnf.ApplyDefaults(conf)
 	syscallChan := make(chan os.Signal, 1)
@@ -138,6 +266,13 @@ func main() {
 	}
 	log.Info().Msgf("LiveAttrs SQL database(s): %s", dbInfo)
 
+	dbProbes := []*health.Probe{health.NewProbe("cncDb", cncDB.Conn(), conf.DBHealth)}
+	if conf.LiveAttrs.DB.Type == "mysql" {
+		dbProbes = append(dbProbes, health.NewProbe("liveAttrsDb", laDB, conf.DBHealth))
+	}
+	dbHealth := health.NewMonitor(dbProbes...)
+	dbHealth.Start(exitEvent)
+
 	if !conf.LogLevel.IsDebugMode() {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -149,181 +284,1013 @@ func main() {
 	engine.NoMethod(uniresp.NoMethodHandler)
 	engine.NoRoute(uniresp.NotFoundHandler)
 
+	mango.ConfigurePool(conf.CorporaSetup.MaxOpenCorpora, conf.CorporaSetup.MaxIdleCorpora)
+
 	rootActions := root.Actions{Version: version, Conf: conf}
+	startTime := time.Now()
+	if conf.ShutdownReportPath != "" {
+		rootActions.LastShutdownReport, err = root.LoadShutdownReport(conf.ShutdownReportPath)
+		if err != nil {
+			log.Error().Err(err).Msg("")
+
+		} else if rootActions.LastShutdownReport != nil && !rootActions.LastShutdownReport.Clean {
+			log.Warn().
+				Time("started", rootActions.LastShutdownReport.Started).
+				Msg("previous run of the service has not been shut down properly")
+		}
+		// until the service is shut down properly, the stored report
+		// signals an unexpected termination
+		runMarker := root.ShutdownReport{Started: startTime, Version: version}
+		if err := runMarker.Save(conf.ShutdownReportPath); err != nil {
+			log.Error().Err(err).Msg("")
+		}
+	}
 
 	corpdataActions := corpdata.NewActions(conf, version)
 
+	quotas := quota.NewTracker(
+		conf.Quotas,
+		func(corpusID string) bool {
+			return conf.CorporaSetup.GetFirstValidRegistry(corpusID, corpus.CorpusVariantPrimary.SubDir()) != ""
+		},
+	)
+
 	jobStopChannel := make(chan string)
 	jobActions := jobs.NewActions(conf.Jobs, conf.Language, exitEvent, jobStopChannel)
 
-	corpusActions := corpus.NewActions(conf.CorporaSetup, conf.Jobs, jobActions, cncDB)
-
-	concCache := query.NewCache(conf.CorporaSetup.ConcCacheDirPath, conf.GetLocation())
-	concCache.RestoreUnboundEntries()
-	concActions := query.NewActions(conf.CorporaSetup, conf.GetLocation(), concCache)
-
 	liveattrsActions := laActions.NewActions(
 		laActions.LAConf{
 			LA:      conf.LiveAttrs,
 			Ngram:   conf.NgramDB,
 			KonText: conf.Kontext,
 			Corp:    conf.CorporaSetup,
+			Budgets: conf.RequestBudgets,
 		},
 		exitEvent,
 		jobStopChannel,
 		jobActions,
 		cncDB,
 		laDB,
+		quotas,
 		version,
 	)
-	registryActions := registry.NewActions(conf.CorporaSetup)
+	corpusActions := corpus.NewActions(
+		conf.CorporaSetup, conf.Jobs, jobActions, cncDB, corpus.NewNotesStore(laDB), liveattrsActions)
+	corpusActions.AddDataRemovers(liveattrsActions, cncDB)
+	corpusActions.AddArchiveFilesProviders(liveattrsActions)
+	corpusActions.AddRenamers(liveattrsActions, cncDB)
+	corpusActions.WatchRegistry(exitEvent)
 
-	for _, dj := range jobActions.GetDetachedJobs() {
-		if dj.IsFinished() {
-			continue
-		}
-		switch tdj := dj.(type) {
-		case *liveattrs.LiveAttrsJobInfo:
-			err := liveattrsActions.RestartLiveAttrsJob(tdj)
-			if err != nil {
-				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
-			}
-			jobActions.ClearDetachedJob(tdj.ID)
-		case *liveattrs.IdxUpdateJobInfo:
-			err := liveattrsActions.RestartIdxUpdateJob(tdj)
-			if err != nil {
-				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
-			}
-			jobActions.ClearDetachedJob(tdj.ID)
-		case *corpus.JobInfo:
-			err := corpusActions.RestartJob(tdj)
-			if err != nil {
-				log.Error().Err(err).Msgf("Failed to restart job %s. The job will be removed.", tdj.ID)
-			}
-			jobActions.ClearDetachedJob(tdj.ID)
-		default:
-			log.Error().Msg("unknown detached job type")
-		}
+	concCache := query.NewCache(conf.CorporaSetup.ConcCacheDirPath, conf.GetLocation())
+	if conf.Features.IsEnabled(cnf.FeatureFreqs) {
+		concCache.RestoreUnboundEntries()
 	}
+	concActions := query.NewActions(conf.CorporaSetup, conf.GetLocation(), concCache)
+
+	registryActions := registry.NewActions(conf.CorporaSetup)
+	cncdbActions := cncdb.NewActions(conf.CNCDB, conf.CorporaSetup, cncDB)
 
-	engine.GET(
-		"/", rootActions.RootAction)
-	engine.GET(
-		"/corpora/:corpusId", corpusActions.GetCorpusInfo)
-	engine.POST(
-		"/corpora/:corpusId/_syncData", corpusActions.SynchronizeCorpusData)
-
-	engine.GET(
-		"/freqs/:corpusId", concActions.FreqDistrib)
-
-	engine.GET(
-		"/collocs/:corpusId", concActions.Collocations)
-
-	engine.POST(
-		"/liveAttributes/:corpusId/data", liveattrsActions.Create)
-	engine.DELETE(
-		"/liveAttributes/:corpusId/data", liveattrsActions.Delete)
-	engine.GET(
-		"/liveAttributes/:corpusId/conf", liveattrsActions.ViewConf)
-	engine.PUT(
-		"/liveAttributes/:corpusId/conf", liveattrsActions.CreateConf)
-	engine.PATCH(
-		"/liveAttributes/:corpusId/conf", liveattrsActions.PatchConfig)
-	engine.GET(
-		"/liveAttributes/:corpusId/qsDefaults", liveattrsActions.QSDefaults)
-	engine.DELETE(
-		"/liveAttributes/:corpusId/confCache", liveattrsActions.FlushCache)
-	engine.POST(
-		"/liveAttributes/:corpusId/query", liveattrsActions.Query)
-	engine.POST(
-		"/liveAttributes/:corpusId/fillAttrs", liveattrsActions.FillAttrs)
-	engine.POST(
-		"/liveAttributes/:corpusId/selectionSubcSize",
-		liveattrsActions.GetAdhocSubcSize)
-	engine.POST(
-		"/liveAttributes/:corpusId/attrValAutocomplete",
-		liveattrsActions.AttrValAutocomplete)
-	engine.POST(
-		"/liveAttributes/:corpusId/getBibliography",
-		liveattrsActions.GetBibliography)
-	engine.POST(
-		"/liveAttributes/:corpusId/findBibTitles",
-		liveattrsActions.FindBibTitles)
-	engine.GET(
-		"/liveAttributes/:corpusId/stats", liveattrsActions.Stats)
-	engine.POST(
-		"/liveAttributes/:corpusId/updateIndexes",
-		liveattrsActions.UpdateIndexes)
-	engine.POST(
-		"/liveAttributes/:corpusId/mixSubcorpus",
-		liveattrsActions.MixSubcorpus)
-	engine.GET(
-		"/liveAttributes/:corpusId/inferredAtomStructure",
-		liveattrsActions.InferredAtomStructure)
-	engine.POST(
-		"/liveAttributes/:corpusId/ngrams",
-		liveattrsActions.GenerateNgrams)
-	engine.POST(
-		"/liveAttributes/:corpusId/querySuggestions",
-		liveattrsActions.CreateQuerySuggestions)
-	engine.POST(
-		"/liveAttributes/:corpusId/documentList",
-		liveattrsActions.DocumentList)
-	engine.POST(
-		"/liveAttributes/:corpusId/numMatchingDocuments",
-		liveattrsActions.NumMatchingDocuments)
-
-	engine.GET(
-		"/jobs", jobActions.JobList)
-	engine.GET(
-		"/jobs/utilization", jobActions.Utilization)
-	engine.GET(
-		"/jobs/:jobId", jobActions.JobInfo)
-	engine.DELETE(
-		"/jobs/:jobId", jobActions.Delete)
-	engine.GET(
-		"/jobs/:jobId/clearIfFinished", jobActions.ClearIfFinished)
-	engine.GET(
-		"/jobs/:jobId/emailNotification", jobActions.GetNotifications)
-	engine.GET(
-		"/jobs/:jobId/emailNotification/:address",
-		jobActions.CheckNotification)
-	engine.PUT(
-		"/jobs/:jobId/emailNotification/:address",
-		jobActions.AddNotification)
-	engine.DELETE(
-		"/jobs/:jobId/emailNotification/:address",
-		jobActions.RemoveNotification)
-
-	engine.GET(
-		"/registry/defaults/attribute/dynamic-functions",
-		registryActions.DynamicFunctions)
-	engine.GET(
-		"/registry/defaults/wposlist", registryActions.PosSets)
-	engine.GET(
-		"/registry/defaults/wposlist/:posId", registryActions.GetPosSetInfo)
-	engine.GET(
-		"/registry/defaults/attribute/multivalue",
-		registryActions.GetAttrMultivalueDefaults)
-	engine.GET(
-		"/registry/defaults/attribute/multisep",
-		registryActions.GetAttrMultisepDefaults)
-	engine.GET(
-		"/registry/defaults/attribute/dynlib",
-		registryActions.GetAttrDynlibDefaults)
-	engine.GET(
-		"/registry/defaults/attribute/transquery",
-		registryActions.GetAttrTransqueryDefaults)
-	engine.GET(
-		"/registry/defaults/structure/multivalue",
-		registryActions.GetStructMultivalueDefaults)
-	engine.GET(
-		"/registry/defaults/structure/multisep",
-		registryActions.GetStructMultisepDefaults)
+	restartDetachedJobs(jobActions, liveattrsActions, corpusActions)
+
+	replicationActions := replication.NewActions(
+		conf.Standby,
+		jobActions,
+		liveattrsActions,
+		func() {
+			restartDetachedJobs(jobActions, liveattrsActions, corpusActions)
+		},
+		exitEvent,
+	)
+
+	routes := root.Routes{
+		{
+			Method:      http.MethodGet,
+			Path:        "/",
+			Description: "basic information about the service",
+			Handler:     rootActions.RootAction,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/routes",
+			Description: "list of all the available routes",
+			Handler:     rootActions.RouteList,
+			Response:    root.Routes{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/clients/:language",
+			Description: "source code of a typed client (go, python) of available routes",
+			Handler:     rootActions.Client,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/admin/logLevel",
+			Description: "actual log levels (default and per module)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     rootActions.LogLevels,
+			Response:    loglevel.Levels{},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/admin/logLevel",
+			Description: "change the default log level or a level of a module",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     rootActions.SetLogLevel,
+			Request:     root.LogLevelArgs{},
+			Response:    loglevel.Levels{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/admin/lastShutdown",
+			Description: "report on the previous shutdown of the service",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     rootActions.LastShutdown,
+			Response:    root.ShutdownReport{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/admin/quotas",
+			Description: "daily usage of operations limited by quotas",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     quotas.ReportAction,
+			Response:    quota.Report{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/corpora/_openStats",
+			Description: "statistics of opened Manatee corpora",
+			Handler:     corpusActions.OpenCorporaStats,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/corpora/:corpusId",
+			Description: "information about corpus files",
+			Handler:     corpusActions.GetCorpusInfo,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/corpora/:corpusId",
+			Description: "remove corpus data, registry files and liveattrs data",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.RemoveCorpus),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_refreshInfo",
+			Description: "reload cached information about a corpus",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     corpusActions.RefreshCorpusInfo,
+			Response:    corpus.Info{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_syncData",
+			Description: "synchronize corpus data between configured locations",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.SynchronizeCorpusData),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_manifest",
+			Description: "create a SHA-256 manifest of corpus data files",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.CreateManifest),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_verifyManifest",
+			Description: "verify corpus data files against their SHA-256 manifest",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.VerifyManifest),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_export",
+			Description: "create a tar.gz archive of corpus data for a handover",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.ExportCorpus),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/_rename",
+			Description: "rename corpus data, registry files, liveattrs data and database record",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(corpusActions.RenameCorpus),
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/corpora/:corpusId/onboardingStatus",
+			Description: "checklist of steps required to make a corpus available",
+			Handler:     corpusActions.OnboardingStatus,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/corpora/:corpusId/notes",
+			Description: "operational notes attached to a corpus",
+			Handler:     corpusActions.CorpusNotes,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora/:corpusId/notes",
+			Description: "attach an operational note to a corpus",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     corpusActions.AddCorpusNote,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/corpora/:corpusId/notes/:noteId",
+			Description: "update an operational note of a corpus",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     corpusActions.UpdateCorpusNote,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/corpora/:corpusId/notes/:noteId",
+			Description: "delete an operational note of a corpus",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     corpusActions.DeleteCorpusNote,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/freqs/:corpusId",
+			Description: "frequency distribution of a concordance",
+			Handler:     concActions.FreqDistrib,
+			Feature:     cnf.FeatureFreqs,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/collocs/:corpusId",
+			Description: "collocations of a concordance",
+			Handler:     concActions.Collocations,
+			Feature:     cnf.FeatureFreqs,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/data",
+			Description: "create liveattrs data (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.Create),
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/liveAttributes/:corpusId/data",
+			Description: "remove liveattrs data",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.Delete,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/liveAttributes/:corpusId/data/progress",
+			Description:  "stream progress of a running liveattrs data job (Server-Sent Events)",
+			Handler:      liveattrsActions.DataProgress,
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/pendingJobs",
+			Description: "liveattrs data jobs waiting for a running job to finish",
+			Handler:     liveattrsActions.PendingJobs,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/liveAttributes/:corpusId/pendingJobs/:jobId",
+			Description: "cancel a pending liveattrs data job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.CancelPendingJob,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/ingestedVerticals",
+			Description: "vertical files ingested to liveattrs data of a corpus",
+			Handler:     liveattrsActions.IngestedVerticals,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/valueCounts",
+			Description: "attribute value counts snapshots of corpus builds",
+			Handler:     liveattrsActions.ValueCountsSnapshots,
+			Response:    []liveattrs.ValueCountsSnapshot{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/valueCounts/_diff",
+			Description: "compare attribute value counts of two corpus builds",
+			Handler:     liveattrsActions.ValueCountsDiff,
+			Response:    liveattrs.ValueCountsDiff{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/_duplicateVerticals",
+			Description: "vertical files ingested to multiple corpora or multiple times",
+			Handler:     liveattrsActions.DuplicateVerticals,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/conf",
+			Description: "show liveattrs configuration",
+			Handler:     liveattrsActions.ViewConf,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/liveAttributes/:corpusId/conf",
+			Description: "create liveattrs configuration",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.CreateConf,
+		},
+		{
+			Method:      http.MethodPatch,
+			Path:        "/liveAttributes/:corpusId/conf",
+			Description: "update liveattrs configuration",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.PatchConfig,
+		},
+		{
+			Method:      http.MethodPatch,
+			Path:        "/liveAttributes/_groupConf",
+			Description: "update settings shared by a parallel corpus group in all its liveattrs configurations at once",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.PatchGroupConfig,
+			Request:     laconf.GroupPatchArgs{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/_confs",
+			Description: "list of all the stored liveattrs configurations with their key properties",
+			Handler:     liveattrsActions.ListConfs,
+			Response:    []laconf.ConfSummary{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/conf/history",
+			Description: "list of backed up versions of liveattrs configuration",
+			Handler:     liveattrsActions.ConfHistory,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/conf/history/:version",
+			Description: "backed up version of liveattrs configuration",
+			Handler:     liveattrsActions.ViewConfVersion,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/conf/history/:version/_restore",
+			Description: "restore a backed up version of liveattrs configuration",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.RestoreConfVersion,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/conf/_diff",
+			Description: "differences between stored and freshly generated liveattrs configuration",
+			Handler:     liveattrsActions.DiffConf,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/conf/_validate",
+			Description: "check liveattrs configuration without starting a job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.ValidateConf,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/qsDefaults",
+			Description: "default query suggestions settings",
+			Handler:     liveattrsActions.QSDefaults,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/liveAttributes/:corpusId/confCache",
+			Description: "remove liveattrs configuration from cache",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.FlushCache,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/liveAttributes/:corpusId/textTypesDb",
+			Description:  "download a generated SQLite text types database (token-authorized)",
+			Handler:      liveattrsActions.DownloadTextTypesDb,
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodHead,
+			Path:        "/liveAttributes/:corpusId/textTypesDb",
+			Description: "size and checksum of a generated SQLite text types database",
+			Handler:     liveattrsActions.DownloadTextTypesDb,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/query",
+			Description: "search attribute values based on selected values",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.Query),
+			Request:     laQuery.Payload{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/_valueHarmonization",
+			Description: "compare values of an attribute shared by multiple corpora",
+			Handler:     liveattrsActions.ValueHarmonization,
+			Response:    liveattrs.HarmonizationReport{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/_multiQuery",
+			Description: "search attribute values in multiple corpora",
+			Handler:     liveattrsActions.MultiQuery,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/graphql",
+			Description: "GraphQL access to liveattrs search, fillAttrs, bibliography and documents",
+			Handler:     liveattrsActions.GraphQL,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/fillAttrs",
+			Description: "find values of attributes related to provided values",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.FillAttrs),
+			Request:     fillattrs.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/selectionSubcSize",
+			Description: "size of a subcorpus defined by selected attributes",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.GetAdhocSubcSize),
+			Request:     equery.Payload{},
+			Response:    response.GetSubcSize{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/attrValAutocomplete",
+			Description: "autocomplete attribute values",
+			Handler:     quotas.Guard(quota.OpAutocomplete, liveattrsActions.AttrValAutocomplete),
+			Request:     laQuery.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/attrStats",
+			Description: "summary statistics and histogram of a numeric attribute",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.AttrStats),
+			Request:     attrstats.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/attrDependency",
+			Description: "how strongly an attribute is determined by another one",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.AttrDependency),
+			Request:     attrdeps.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/cooccurrence",
+			Description: "attribute values still combinable with a selection of text types",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.Cooccurrence),
+			Request:     cooccurrence.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/selectionShare",
+			Description: "share of the whole corpus covered by a selection of text types per attribute value",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.SelectionShare),
+			Request:     selshare.Payload{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/facetIndex",
+			Description: "information about a precomputed facet index",
+			Handler:     liveattrsActions.FacetIndexInfo,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/facetIndex",
+			Description: "build a facet index used by cooccurrence",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.BuildFacetIndex,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/liveAttributes/:corpusId/facetIndex",
+			Description: "remove a facet index",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.DeleteFacetIndex,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/mergeReport",
+			Description: "verification report of a self-joined (parallel) liveattrs table",
+			Handler:     liveattrsActions.MergeReport,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/quality",
+			Description: "data quality metrics of structural attributes",
+			Handler:     liveattrsActions.Quality,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/liveAttributes/:corpusId/snapshot",
+			Description:  "export liveattrs data as an SQLite file",
+			Roles:        []string{root.RoleAdmin},
+			Handler:      liveattrsActions.ExportSnapshot,
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/liveAttributes/:corpusId/snapshot",
+			Description: "import liveattrs data from an SQLite file",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.ImportSnapshot,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/getBibliography",
+			Description: "bibliographic information about a document",
+			Handler:     quotas.Guard(quota.OpQuery, liveattrsActions.GetBibliography),
+			Request:     biblio.Payload{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/findBibTitles",
+			Description: "titles of bibliographic items",
+			Handler:     quotas.Guard(quota.OpAutocomplete, liveattrsActions.FindBibTitles),
+			Request:     biblio.PayloadList{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/stats",
+			Description: "usage statistics of liveattrs attributes",
+			Handler:     liveattrsActions.Stats,
+			Response:    map[string]int{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/updateIndexes",
+			Description: "update liveattrs indexes based on usage (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.UpdateIndexes),
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/valueMerges",
+			Description: "list merges of attribute values",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.ValueMerges,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/valueMerges",
+			Description: "merge attribute values into a canonical value (also applied on future rebuilds)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.MergeValues,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/liveAttributes/:corpusId/valueMerges/:mergeId",
+			Description: "undo a merge of attribute values",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     liveattrsActions.UndoValueMerge,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/unusedColumns",
+			Description: "find (and optionally drop) liveattrs columns not queried for a specified time (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.UnusedColumns),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/textTypesDb/_check",
+			Description: "verify (and optionally repair) an SQLite text types database (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.CheckTextTypesDb),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/detectLanguages",
+			Description: "detect languages of documents and store them as a derived attribute (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.DetectLanguages),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/mixSubcorpus",
+			Description: "create a subcorpus with specified text type ratios",
+			Handler:     jobActions.RecordingRequest(liveattrsActions.MixSubcorpus),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/kontextSubcorpus",
+			Description: "create a KonText subcorpus from a selection of text types (on behalf of the user)",
+			Handler:     liveattrsActions.CreateKontextSubcorpus,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/inferredAtomStructure",
+			Description: "atom structure inferred from corpus registry",
+			Handler:     liveattrsActions.InferredAtomStructure,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/liveAttributes/:corpusId/detectedAttrTypes",
+			Description: "declared attribute types and detected numeric types of other attributes",
+			Handler:     liveattrsActions.DetectedAttrTypes,
+			Response:    map[string]laconf.AttrTypes{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/ngrams",
+			Description: "generate n-gram frequency database (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     quotas.Guard(quota.OpNgrams, jobActions.RecordingRequest(liveattrsActions.GenerateNgrams)),
+			Feature:     cnf.FeatureNgrams,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/querySuggestions",
+			Description: "generate query suggestions data (as a job)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RecordingRequest(liveattrsActions.CreateQuerySuggestions),
+			Feature:     cnf.FeatureNgrams,
+		},
+		{
+			Method:       http.MethodPost,
+			Path:         "/liveAttributes/:corpusId/documentList",
+			Description:  "list of documents matching selected attributes",
+			Handler:      quotas.Guard(quota.OpDocumentList, liveattrsActions.DocumentList),
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/liveAttributes/:corpusId/documentList/:resultId",
+			Description:  "a page of a spooled document list",
+			Handler:      quotas.Guard(quota.OpDocumentListPage, liveattrsActions.DocumentListPage),
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/liveAttributes/:corpusId/numMatchingDocuments",
+			Description: "number of documents matching selected attributes",
+			Handler:     quotas.Guard(quota.OpDocumentList, liveattrsActions.NumMatchingDocuments),
+			Request:     laQuery.Payload{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs",
+			Description: "list of jobs",
+			Handler:     jobActions.JobList,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/utilization",
+			Description: "job queue utilization",
+			Handler:     jobActions.Utilization,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/jobs/events",
+			Description:  "stream events of all the jobs (Server-Sent Events)",
+			Handler:      jobActions.JobEvents,
+			Response:     jobs.JobEvent{},
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/archive",
+			Description: "search for jobs archived once their retention expired",
+			Handler:     jobActions.ArchivedJobs,
+			Response:    jobs.ArchivedJobsPage{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/queue",
+			Description: "jobs waiting to be started",
+			Handler:     jobActions.Queue,
+			Response:    []jobs.QueuedJob{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/jobs/pipeline",
+			Description: "create a job running requests one after another",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.CreatePipeline,
+		},
+		{
+			Method:       http.MethodGet,
+			Path:         "/jobs/_export",
+			Description:  "export the job history as JSON",
+			Roles:        []string{root.RoleAdmin},
+			Handler:      jobActions.ExportJobs,
+			Response:     jobs.JobListExport{},
+			TimeoutClass: cnf.TimeoutClassExport,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/jobs/_import",
+			Description: "import a job history exported by another instance",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.ImportJobs,
+			Request:     jobs.JobListExport{},
+			Response:    jobs.JobListImportResult{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId",
+			Description: "information about a job",
+			Handler:     jobActions.JobInfo,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/jobs/:jobId",
+			Description: "stop and remove a job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.Delete,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/jobs/:jobId/pause",
+			Description: "suspend processing of a running job (if supported)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.PauseJob,
+			Response:    jobs.JobInfoCompact{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/jobs/:jobId/resume",
+			Description: "continue processing of a paused job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.ResumeJob,
+			Response:    jobs.JobInfoCompact{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId/log",
+			Description: "log entries captured during a job's execution",
+			Handler:     jobActions.JobLog,
+			Response:    jobs.JobLogPage{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId/request",
+			Description: "originating request of a job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.JobRequest,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/jobs/:jobId/_rerun",
+			Description: "re-submit the originating request of a job",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.Rerun,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId/clearIfFinished",
+			Description: "remove a job in case it is finished",
+			Handler:     jobActions.ClearIfFinished,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId/emailNotification",
+			Description: "list of e-mail addresses notified about a job",
+			Handler:     jobActions.GetNotifications,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/:jobId/emailNotification/:address",
+			Description: "test whether an address is notified about a job",
+			Handler:     jobActions.CheckNotification,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/jobs/:jobId/emailNotification/:address",
+			Description: "add an e-mail notification for a job",
+			Handler:     jobActions.AddNotification,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/jobs/:jobId/emailNotification/:address",
+			Description: "remove an e-mail notification for a job",
+			Handler:     jobActions.RemoveNotification,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/schedules",
+			Description: "list of scheduled recurring requests",
+			Handler:     jobActions.ListSchedules,
+			Response:    []jobs.Schedule{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/schedules",
+			Description: "create a scheduled recurring request",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.CreateSchedule,
+			Response:    jobs.Schedule{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/schedules/:scheduleId",
+			Description: "information about a schedule",
+			Handler:     jobActions.GetSchedule,
+			Response:    jobs.Schedule{},
+		},
+		{
+			Method:      http.MethodPatch,
+			Path:        "/schedules/:scheduleId",
+			Description: "change (e.g. enable/disable) a schedule",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.PatchSchedule,
+			Request:     jobs.SchedulePatch{},
+			Response:    jobs.Schedule{},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/schedules/:scheduleId",
+			Description: "remove a schedule",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.DeleteSchedule,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/schedules/:scheduleId/_run",
+			Description: "submit the request of a schedule immediately",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     jobActions.RunSchedule,
+			Response:    jobs.Schedule{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/replication/status",
+			Description: "replication state of the instance (primary/standby)",
+			Handler:     replicationActions.Status,
+			Response:    replication.Status{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/dbHealth",
+			Description: "state of the databases used by the service",
+			Handler:     dbHealth.StatusAction,
+			Response:    []health.Status{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/replication/jobs",
+			Description: "job history for standby instances (gob-encoded)",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     replicationActions.ExportJobs,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/replication/liveAttributes/confs",
+			Description: "liveattrs config files for standby instances",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     replicationActions.ExportConfs,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/replication/liveAttributes/warmUpHints",
+			Description: "cached liveattrs queries for warming up standby instances",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     replicationActions.WarmUpHints,
+			Response:    []cache.CachedQuery{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/replication/_promote",
+			Description: "turn a standby instance into a primary one",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     replicationActions.Promote,
+			Response:    replication.Status{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/attribute/dynamic-functions",
+			Description: "available dynamic functions",
+			Handler:     registryActions.DynamicFunctions,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/wposlist",
+			Description: "available wposlist sets",
+			Handler:     registryActions.PosSets,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/wposlist/:posId",
+			Description: "information about a wposlist set",
+			Handler:     registryActions.GetPosSetInfo,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/attribute/multivalue",
+			Description: "default multivalue setting of attributes",
+			Handler:     registryActions.GetAttrMultivalueDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/attribute/multisep",
+			Description: "default multisep setting of attributes",
+			Handler:     registryActions.GetAttrMultisepDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/attribute/dynlib",
+			Description: "default dynlib setting of attributes",
+			Handler:     registryActions.GetAttrDynlibDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/attribute/transquery",
+			Description: "default transquery setting of attributes",
+			Handler:     registryActions.GetAttrTransqueryDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/structure/multivalue",
+			Description: "default multivalue setting of structures",
+			Handler:     registryActions.GetStructMultivalueDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/defaults/structure/multisep",
+			Description: "default multisep setting of structures",
+			Handler:     registryActions.GetStructMultisepDefaults,
+			Feature:     cnf.FeatureRegistryDefaults,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/corpora-database/:corpusId/auto-update",
+			Description: "update corpus size and description in the database",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     cncdbActions.UpdateCorpusInfo,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/corpora-database/:corpusId/kontextDefaults",
+			Description: "infer KonText default view options",
+			Roles:       []string{root.RoleAdmin},
+			Handler:     cncdbActions.InferKontextDefaults,
+		},
+	}
+	if conf.LogLevel.IsDebugMode() && conf.Features.IsEnabled(cnf.FeatureDebug) {
+		debugActions := debug.NewActions(jobActions)
+		routes = append(
+			routes,
+			root.Route{
+				Method:      http.MethodPost,
+				Path:        "/debug/createJob",
+				Description: "create a dummy job",
+				Roles:       []string{root.RoleAdmin},
+				Handler:     jobActions.RecordingRequest(debugActions.CreateDummyJob),
+				Feature:     cnf.FeatureDebug,
+			},
+			root.Route{
+				Method:      http.MethodPost,
+				Path:        "/debug/finishJob/:jobId",
+				Description: "finish a dummy job",
+				Roles:       []string{root.RoleAdmin},
+				Handler:     debugActions.FinishDummyJob,
+				Feature:     cnf.FeatureDebug,
+			},
+		)
+	}
+	routes = routes.Enabled(conf.Features.IsEnabled).WithTimeouts(conf.EndpointTimeouts.Timeouts())
+	rootActions.Routes = routes
+	routes.Register(engine)
+	jobActions.SetRequestHandler(engine)
 
 	go func(exitHandlers []ExitHandler) {
 		select {
 		case evt := <-syscallChan:
+			// the report must be created before the exit handlers
+			// start to stop jobs and flush data
+			finished := time.Now()
+			caches := liveattrsActions.CacheStats()
+			caches["concordances"] = concCache.NumEntries()
+			report := root.ShutdownReport{
+				Clean:                    true,
+				Started:                  startTime,
+				Finished:                 &finished,
+				Signal:                   evt.String(),
+				Version:                  version,
+				RunningJobs:              jobActions.Checkpoints(),
+				NumQueuedJobs:            jobActions.NumQueuedJobs(),
+				NumUnflushedUsageRecords: liveattrsActions.NumUnflushedUsageRecords(),
+				Caches:                   caches,
+			}
+			report.Log()
+			if conf.ShutdownReportPath != "" {
+				if err := report.Save(conf.ShutdownReportPath); err != nil {
+					log.Error().Err(err).Msg("")
+				}
+			}
 			for _, h := range exitHandlers {
 				h.OnExit()
 			}
@@ -332,25 +1299,12 @@ func main() {
 		}
 	}([]ExitHandler{corpdataActions, jobActions, corpusActions, liveattrsActions})
 
-	cncdbActions := cncdb.NewActions(conf.CNCDB, conf.CorporaSetup, cncDB)
-	engine.POST(
-		"/corpora-database/:corpusId/auto-update",
-		cncdbActions.UpdateCorpusInfo)
-	engine.PUT(
-		"/corpora-database/:corpusId/kontextDefaults",
-		cncdbActions.InferKontextDefaults)
-
-	if conf.LogLevel.IsDebugMode() {
-		debugActions := debug.NewActions(jobActions)
-		engine.POST("/debug/createJob", debugActions.CreateDummyJob)
-		engine.POST("/debug/finishJob/:jobId", debugActions.FinishDummyJob)
-	}
-
 	log.Info().Msgf("starting to listen at %s:%d", conf.ListenAddress, conf.ListenPort)
 	srv := &http.Server{
-		Handler:      engine,
-		Addr:         fmt.Sprintf("%s:%d", conf.ListenAddress, conf.ListenPort),
-		WriteTimeout: time.Duration(conf.ServerWriteTimeoutSecs) * time.Second,
+		Handler: engine,
+		Addr:    fmt.Sprintf("%s:%d", conf.ListenAddress, conf.ListenPort),
+		// routes set their own write deadlines (see root.WithDeadline)
+		WriteTimeout: time.Duration(conf.EndpointTimeouts.MaxSecs()) * time.Second,
 		ReadTimeout:  time.Duration(conf.ServerReadTimeoutSecs) * time.Second,
 	}
 
@@ -0,0 +1,60 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"masm/v3/mail"
+	"time"
+
+	cncmail "github.com/czcorpus/cnc-gokit/mail"
+)
+
+// EmailNotifier sends notifications via SMTP
+type EmailNotifier struct {
+	conf       mail.EmailNotification
+	recipients []string
+}
+
+func (n *EmailNotifier) Notify(msg Message) error {
+	notificationConf := n.conf.WithRecipients(n.recipients...)
+	if msg.HTML != "" {
+		return cncmail.SendNotification(
+			&notificationConf,
+			time.Now().Location(),
+			cncmail.FormattedNotification{
+				Subject: msg.Subject,
+				Divs:    []string{msg.HTML},
+			},
+		)
+	}
+	return cncmail.SendNotification(
+		&notificationConf,
+		time.Now().Location(),
+		cncmail.Notification{
+			Subject:    msg.Subject,
+			Paragraphs: msg.Paragraphs,
+		},
+	)
+}
+
+// NewEmailNotifier creates a notifier sending a single e-mail
+// to all the recipients
+func NewEmailNotifier(conf mail.EmailNotification, recipients ...string) *EmailNotifier {
+	return &EmailNotifier{conf: conf, recipients: recipients}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"fmt"
+	"masm/v3/mail"
+)
+
+const (
+	ChannelEmail      = "email"
+	ChannelWebhook    = "webhook"
+	ChannelTeams      = "teams"
+	ChannelSlack      = "slack"
+	ChannelMattermost = "mattermost"
+)
+
+// Message is a channel-independent notification
+type Message struct {
+	Subject    string
+	Paragraphs []string
+
+	// HTML is an optional formatted version of the message
+	// used by e-mail notifications instead of Paragraphs
+	HTML string
+}
+
+// Notifier sends notifications via a specific channel
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// ChannelConf configures a notification channel of a recipient
+type ChannelConf struct {
+
+	// Type is one of ChannelEmail, ChannelWebhook, ChannelTeams,
+	// ChannelSlack, ChannelMattermost
+	Type string `json:"type"`
+
+	// URL is a target URL for ChannelWebhook, ChannelTeams
+	// and an incoming webhook URL for ChannelSlack, ChannelMattermost
+	URL string `json:"url"`
+
+	// Channel optionally overrides the default channel of a Slack
+	// or Mattermost incoming webhook
+	Channel string `json:"channel"`
+
+	// Username optionally overrides the default sender name
+	// of a Slack or Mattermost incoming webhook
+	Username string `json:"username"`
+
+	// Address is an e-mail address for ChannelEmail. If empty,
+	// the recipient name is used as the address.
+	Address string `json:"address"`
+}
+
+// Validate tests whether the configuration contains all the
+// values required by its channel type
+func (conf ChannelConf) Validate() error {
+	switch conf.Type {
+	case ChannelEmail:
+		return nil
+	case ChannelWebhook, ChannelTeams, ChannelSlack, ChannelMattermost:
+		if conf.URL == "" {
+			return fmt.Errorf("missing url for %s notification channel", conf.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown notification channel type: %s", conf.Type)
+}
+
+// NewNotifier creates a notifier for a recipient based on its channel
+// configuration
+func NewNotifier(
+	recipient string,
+	conf ChannelConf,
+	emailConf mail.EmailNotification,
+) (Notifier, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	switch conf.Type {
+	case ChannelWebhook:
+		return &WebhookNotifier{URL: conf.URL}, nil
+	case ChannelTeams:
+		return &TeamsNotifier{URL: conf.URL}, nil
+	case ChannelSlack, ChannelMattermost:
+		return &SlackNotifier{
+			URL:        conf.URL,
+			Channel:    conf.Channel,
+			Username:   conf.Username,
+			Mattermost: conf.Type == ChannelMattermost,
+		}, nil
+	}
+	address := conf.Address
+	if address == "" {
+		address = recipient
+	}
+	return NewEmailNotifier(emailConf, address), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, status int, data *map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(data))
+		w.WriteHeader(status)
+	}))
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var data map[string]any
+	srv := newTestServer(t, http.StatusOK, &data)
+	defer srv.Close()
+	n := &WebhookNotifier{URL: srv.URL}
+	err := n.Notify(Message{Subject: "Job finished", Paragraphs: []string{"Job ID: 1", "OK"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Job finished", data["subject"])
+	assert.Equal(t, []any{"Job ID: 1", "OK"}, data["paragraphs"])
+	assert.Equal(t, "Job finished\nJob ID: 1\nOK", data["text"])
+}
+
+func TestWebhookNotifyErrorStatus(t *testing.T) {
+	var data map[string]any
+	srv := newTestServer(t, http.StatusInternalServerError, &data)
+	defer srv.Close()
+	n := &WebhookNotifier{URL: srv.URL}
+	assert.Error(t, n.Notify(Message{Subject: "Job finished"}))
+}
+
+func TestTeamsNotify(t *testing.T) {
+	var data map[string]any
+	srv := newTestServer(t, http.StatusOK, &data)
+	defer srv.Close()
+	n := &TeamsNotifier{URL: srv.URL}
+	err := n.Notify(Message{Subject: "Job finished", Paragraphs: []string{"Job ID: 1", "", "OK"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "MessageCard", data["@type"])
+	assert.Equal(t, "Job finished", data["title"])
+	assert.Equal(t, "Job ID: 1\n\nOK", data["text"])
+}
+
+func TestSlackNotify(t *testing.T) {
+	var data map[string]any
+	srv := newTestServer(t, http.StatusOK, &data)
+	defer srv.Close()
+	n := &SlackNotifier{URL: srv.URL, Channel: "#ops"}
+	err := n.Notify(Message{Subject: "Job failed", Paragraphs: []string{"Job ID: 1", "", "error"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "*Job failed*\nJob ID: 1\nerror", data["text"])
+	assert.Equal(t, "#ops", data["channel"])
+	assert.NotContains(t, data, "username")
+
+	n = &SlackNotifier{URL: srv.URL, Mattermost: true}
+	assert.NoError(t, n.Notify(Message{Subject: "Job failed"}))
+	assert.Equal(t, "**Job failed**", data["text"])
+}
+
+func TestChannelConfValidate(t *testing.T) {
+	assert.NoError(t, ChannelConf{Type: ChannelEmail}.Validate())
+	assert.NoError(t, ChannelConf{Type: ChannelTeams, URL: "http://localhost"}.Validate())
+	assert.Error(t, ChannelConf{Type: ChannelWebhook}.Validate())
+	assert.Error(t, ChannelConf{Type: ChannelMattermost}.Validate())
+	assert.Error(t, ChannelConf{Type: "pigeon"}.Validate())
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// postJSON sends JSON encoded data to a URL and checks
+// the response status
+func postJSON(url string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf(
+			"failed to send notification to %s - unexpected status code %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+type webhookPayload struct {
+	Subject    string   `json:"subject"`
+	Paragraphs []string `json:"paragraphs"`
+	Text       string   `json:"text"`
+}
+
+// WebhookNotifier posts notifications as JSON to a generic webhook
+// (e.g. an SMS gateway or a chat integration). Besides the subject
+// and paragraphs, the payload contains also the whole message as
+// a plain text.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (n *WebhookNotifier) Notify(msg Message) error {
+	return postJSON(
+		n.URL,
+		webhookPayload{
+			Subject:    msg.Subject,
+			Paragraphs: msg.Paragraphs,
+			Text:       strings.Join(append([]string{msg.Subject}, msg.Paragraphs...), "\n"),
+		},
+	)
+}
+
+type teamsCard struct {
+	Type     string `json:"@type"`
+	Context  string `json:"@context"`
+	Summary  string `json:"summary"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	ThemeCol string `json:"themeColor,omitempty"`
+}
+
+// TeamsNotifier posts notifications as message cards
+// to an MS Teams incoming webhook
+type TeamsNotifier struct {
+	URL string
+}
+
+func (n *TeamsNotifier) Notify(msg Message) error {
+	paragraphs := make([]string, 0, len(msg.Paragraphs))
+	for _, p := range msg.Paragraphs {
+		if strings.TrimSpace(p) != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return postJSON(
+		n.URL,
+		teamsCard{
+			Type:    "MessageCard",
+			Context: "http://schema.org/extensions",
+			Summary: msg.Subject,
+			Title:   msg.Subject,
+			// Teams cards use markdown where paragraphs
+			// must be separated by an empty line
+			Text: strings.Join(paragraphs, "\n\n"),
+		},
+	)
+}
+
+type slackPayload struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook.
+// As Mattermost incoming webhooks accept the same payload, it is
+// used for Mattermost too (only the markdown differs).
+type SlackNotifier struct {
+	URL      string
+	Channel  string
+	Username string
+
+	// Mattermost switches to the Mattermost markdown
+	Mattermost bool
+}
+
+func (n *SlackNotifier) Notify(msg Message) error {
+	bold := "*"
+	if n.Mattermost {
+		bold = "**"
+	}
+	lines := make([]string, 0, len(msg.Paragraphs)+1)
+	lines = append(lines, bold+msg.Subject+bold)
+	for _, p := range msg.Paragraphs {
+		if strings.TrimSpace(p) != "" {
+			lines = append(lines, p)
+		}
+	}
+	return postJSON(
+		n.URL,
+		slackPayload{
+			Text:     strings.Join(lines, "\n"),
+			Channel:  n.Channel,
+			Username: n.Username,
+		},
+	)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package replication
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	dfltSyncIntervalSecs = 60
+)
+
+// StandbyConf configures a standby mode in which the instance
+// periodically pulls data from a primary instance so it can
+// quickly take over in case the primary fails (see Actions.Promote)
+type StandbyConf struct {
+
+	// PrimaryURL is a root URL of the primary MASM instance
+	PrimaryURL string `json:"primaryUrl"`
+
+	// SyncIntervalSecs specifies how often data are pulled
+	// from the primary instance
+	SyncIntervalSecs int `json:"syncIntervalSecs"`
+
+	// AuthHeader is a value of the `Authorization` header
+	// sent to the primary instance (if any)
+	AuthHeader string `json:"authHeader"`
+}
+
+// SyncInterval returns the sync interval with the default applied
+func (conf *StandbyConf) SyncInterval() time.Duration {
+	if conf.SyncIntervalSecs <= 0 {
+		return dfltSyncIntervalSecs * time.Second
+	}
+	return time.Duration(conf.SyncIntervalSecs) * time.Second
+}
+
+// Validate tests whether the configuration is usable
+func (conf *StandbyConf) Validate() error {
+	u, err := url.Parse(conf.PrimaryURL)
+	if err != nil {
+		return fmt.Errorf("invalid primaryUrl: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid primaryUrl %s", conf.PrimaryURL)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package replication provides a warm standby mode. A standby instance
+// periodically pulls job history, liveattrs configs and cache warm-up
+// hints from a primary instance so it can take over without a cold start.
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"masm/v3/liveattrs/cache"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RolePrimary = "primary"
+	RoleStandby = "standby"
+
+	jobsPath        = "/replication/jobs"
+	confsPath       = "/replication/liveAttributes/confs"
+	warmUpHintsPath = "/replication/liveAttributes/warmUpHints"
+)
+
+// JobHistory is a source and a target of replicated jobs
+// (see jobs.Actions)
+type JobHistory interface {
+	ExportJobHistory(w io.Writer) error
+	ImportJobHistory(r io.Reader) (int, error)
+}
+
+// LiveAttrsData is a source and a target of replicated liveattrs
+// configs and cache warm-up hints (see liveattrs/actions.Actions)
+type LiveAttrsData interface {
+	ExportConfFiles() (map[string]json.RawMessage, error)
+	ImportConfFiles(files map[string]json.RawMessage) ([]string, error)
+	WarmUpHints() []cache.CachedQuery
+	WarmUp(ctx context.Context, hints []cache.CachedQuery) int
+}
+
+// Status describes the replication state of the instance
+type Status struct {
+	Role       string `json:"role"`
+	PrimaryURL string `json:"primaryUrl,omitempty"`
+
+	// LastSync is the time of the last successful sync
+	LastSync *time.Time `json:"lastSync,omitempty"`
+
+	// LastError is an error of the last sync (if it failed)
+	LastError string `json:"lastError,omitempty"`
+
+	NumJobs      int      `json:"numJobs"`
+	ChangedConfs []string `json:"changedConfs"`
+	NumWarmedUp  int      `json:"numWarmedUp"`
+
+	// Promoted is the time the standby instance became a primary one
+	Promoted *time.Time `json:"promoted,omitempty"`
+}
+
+// Actions provides the replication API of a primary instance
+// and pulls the data in case of a standby instance
+type Actions struct {
+	conf      *StandbyConf
+	jobs      JobHistory
+	liveAttrs LiveAttrsData
+
+	// onPromote is called once a standby instance becomes a primary one
+	// (e.g. to restart unfinished replicated jobs)
+	onPromote func()
+
+	// syncLock prevents promoting an instance while syncing
+	syncLock sync.Mutex
+
+	status     Status
+	statusLock sync.Mutex
+
+	promoted chan struct{}
+}
+
+func (a *Actions) fetch(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(a.conf.PrimaryURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.conf.AuthHeader != "" {
+		req.Header.Set("Authorization", a.conf.AuthHeader)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (a *Actions) fetchJSON(ctx context.Context, path string, target any) error {
+	body, err := a.fetch(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(target)
+}
+
+// sync pulls all the replicated data from the primary instance.
+// In case the instance is not a standby one (anymore), nothing is done.
+func (a *Actions) sync(ctx context.Context) error {
+	a.syncLock.Lock()
+	defer a.syncLock.Unlock()
+	if a.getStatus().Role != RoleStandby {
+		return nil
+	}
+	body, err := a.fetch(ctx, jobsPath)
+	if err != nil {
+		return fmt.Errorf("failed to sync jobs: %w", err)
+	}
+	numJobs, err := a.jobs.ImportJobHistory(body)
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to sync jobs: %w", err)
+	}
+	var files map[string]json.RawMessage
+	if err := a.fetchJSON(ctx, confsPath, &files); err != nil {
+		return fmt.Errorf("failed to sync liveattrs configs: %w", err)
+	}
+	changed, err := a.liveAttrs.ImportConfFiles(files)
+	if err != nil {
+		return fmt.Errorf("failed to sync liveattrs configs: %w", err)
+	}
+	var hints []cache.CachedQuery
+	if err := a.fetchJSON(ctx, warmUpHintsPath, &hints); err != nil {
+		return fmt.Errorf("failed to sync warm-up hints: %w", err)
+	}
+	numWarmedUp := a.liveAttrs.WarmUp(ctx, hints)
+	now := time.Now()
+	a.statusLock.Lock()
+	a.status.LastSync = &now
+	a.status.LastError = ""
+	a.status.NumJobs = numJobs
+	a.status.ChangedConfs = changed
+	a.status.NumWarmedUp = numWarmedUp
+	a.statusLock.Unlock()
+	log.Info().
+		Int("numJobs", numJobs).
+		Strs("changedConfs", changed).
+		Int("numWarmedUp", numWarmedUp).
+		Msg("synced data from primary instance")
+	return nil
+}
+
+func (a *Actions) runSync(exitEvent <-chan os.Signal) {
+	interval := a.conf.SyncInterval()
+	doSync := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		if err := a.sync(ctx); err != nil {
+			log.Error().Err(err).Str("primary", a.conf.PrimaryURL).Msg("failed to sync from primary instance")
+			a.statusLock.Lock()
+			a.status.LastError = err.Error()
+			a.statusLock.Unlock()
+		}
+	}
+	doSync()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			doSync()
+		case <-a.promoted:
+			return
+		case <-exitEvent:
+			return
+		}
+	}
+}
+
+func (a *Actions) getStatus() Status {
+	a.statusLock.Lock()
+	defer a.statusLock.Unlock()
+	return a.status
+}
+
+// promote turns a standby instance into a primary one. It returns
+// false in case the instance is not a standby one.
+func (a *Actions) promote() bool {
+	a.syncLock.Lock()
+	a.statusLock.Lock()
+	if a.status.Role != RoleStandby {
+		a.statusLock.Unlock()
+		a.syncLock.Unlock()
+		return false
+	}
+	now := time.Now()
+	a.status.Role = RolePrimary
+	a.status.Promoted = &now
+	a.statusLock.Unlock()
+	close(a.promoted)
+	a.syncLock.Unlock()
+	log.Warn().Str("formerPrimary", a.conf.PrimaryURL).Msg("standby instance promoted to primary")
+	if a.onPromote != nil {
+		a.onPromote()
+	}
+	return true
+}
+
+// ExportJobs provides gob-encoded job history (see jobs.Actions.ExportJobHistory)
+func (a *Actions) ExportJobs(ctx *gin.Context) {
+	var buff bytes.Buffer
+	if err := a.jobs.ExportJobHistory(&buff); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to export jobs: %w", err), http.StatusInternalServerError)
+		return
+	}
+	ctx.Writer.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Write(buff.Bytes())
+}
+
+// ExportConfs provides all the stored liveattrs config files
+func (a *Actions) ExportConfs(ctx *gin.Context) {
+	ans, err := a.liveAttrs.ExportConfFiles()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("failed to export configs: %w", err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// WarmUpHints provides queries with cached liveattrs results
+func (a *Actions) WarmUpHints(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.liveAttrs.WarmUpHints())
+}
+
+// Status shows the replication state of the instance
+func (a *Actions) Status(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.getStatus())
+}
+
+// Promote turns a standby instance into a primary one
+// (i.e. it stops syncing and restarts replicated unfinished jobs)
+func (a *Actions) Promote(ctx *gin.Context) {
+	if !a.promote() {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("the instance is not a standby one"), http.StatusConflict)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, a.getStatus())
+}
+
+// NewActions creates replication actions. In case conf is not nil,
+// the instance runs in the standby mode and starts syncing
+// from the configured primary instance.
+func NewActions(
+	conf *StandbyConf,
+	jobs JobHistory,
+	liveAttrs LiveAttrsData,
+	onPromote func(),
+	exitEvent <-chan os.Signal,
+) *Actions {
+	ans := &Actions{
+		conf:      conf,
+		jobs:      jobs,
+		liveAttrs: liveAttrs,
+		onPromote: onPromote,
+		status:    Status{Role: RolePrimary, ChangedConfs: []string{}},
+		promoted:  make(chan struct{}),
+	}
+	if conf != nil {
+		ans.status.Role = RoleStandby
+		ans.status.PrimaryURL = conf.PrimaryURL
+		go ans.runSync(exitEvent)
+	}
+	return ans
+}
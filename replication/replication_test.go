@@ -0,0 +1,143 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"masm/v3/liveattrs/cache"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeJobHistory struct {
+	data string
+}
+
+func (jh *fakeJobHistory) ExportJobHistory(w io.Writer) error {
+	_, err := w.Write([]byte(jh.data))
+	return err
+}
+
+func (jh *fakeJobHistory) ImportJobHistory(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	jh.data = string(data)
+	return len(data), err
+}
+
+type fakeLiveAttrsData struct {
+	files  map[string]json.RawMessage
+	hints  []cache.CachedQuery
+	warmed []cache.CachedQuery
+}
+
+func (la *fakeLiveAttrsData) ExportConfFiles() (map[string]json.RawMessage, error) {
+	return la.files, nil
+}
+
+func (la *fakeLiveAttrsData) ImportConfFiles(files map[string]json.RawMessage) ([]string, error) {
+	la.files = files
+	return []string{"syn2020"}, nil
+}
+
+func (la *fakeLiveAttrsData) WarmUpHints() []cache.CachedQuery {
+	return la.hints
+}
+
+func (la *fakeLiveAttrsData) WarmUp(ctx context.Context, hints []cache.CachedQuery) int {
+	la.warmed = hints
+	return len(hints)
+}
+
+func TestStandbyConfValidate(t *testing.T) {
+	assert.NoError(t, (&StandbyConf{PrimaryURL: "http://masm.example.com:8080/"}).Validate())
+	assert.Error(t, (&StandbyConf{PrimaryURL: ""}).Validate())
+	assert.Error(t, (&StandbyConf{PrimaryURL: "masm.example.com"}).Validate())
+}
+
+func TestSyncAndPromote(t *testing.T) {
+	primaryLA := &fakeLiveAttrsData{
+		files: map[string]json.RawMessage{"syn2020.json": json.RawMessage(`{"corpus":"syn2020"}`)},
+		hints: []cache.CachedQuery{{CorpusID: "syn2020", Sort: "alpha"}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer 1234", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case jobsPath:
+			(&fakeJobHistory{data: "jobs"}).ExportJobHistory(w)
+		case confsPath:
+			json.NewEncoder(w).Encode(primaryLA.files)
+		case warmUpHintsPath:
+			json.NewEncoder(w).Encode(primaryLA.hints)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var numPromoted int
+	standbyJobs := &fakeJobHistory{}
+	standbyLA := &fakeLiveAttrsData{}
+	a := &Actions{
+		conf:      &StandbyConf{PrimaryURL: srv.URL + "/", AuthHeader: "Bearer 1234"},
+		jobs:      standbyJobs,
+		liveAttrs: standbyLA,
+		onPromote: func() { numPromoted++ },
+		status:    Status{Role: RoleStandby},
+		promoted:  make(chan struct{}),
+	}
+	assert.NoError(t, a.sync(context.Background()))
+	assert.Equal(t, "jobs", standbyJobs.data)
+	assert.Equal(t, primaryLA.files, standbyLA.files)
+	assert.Equal(t, primaryLA.hints, standbyLA.warmed)
+	status := a.getStatus()
+	assert.NotNil(t, status.LastSync)
+	assert.Equal(t, []string{"syn2020"}, status.ChangedConfs)
+	assert.Equal(t, 1, status.NumWarmedUp)
+
+	assert.True(t, a.promote())
+	assert.False(t, a.promote())
+	assert.Equal(t, 1, numPromoted)
+	assert.Equal(t, RolePrimary, a.getStatus().Role)
+
+	// promoted instance does not sync anymore
+	standbyJobs.data = ""
+	assert.NoError(t, a.sync(context.Background()))
+	assert.Equal(t, "", standbyJobs.data)
+}
+
+func TestSyncFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	a := &Actions{
+		conf:      &StandbyConf{PrimaryURL: srv.URL},
+		jobs:      &fakeJobHistory{},
+		liveAttrs: &fakeLiveAttrsData{},
+		status:    Status{Role: RoleStandby},
+		promoted:  make(chan struct{}),
+	}
+	assert.Error(t, a.sync(context.Background()))
+	assert.Nil(t, a.getStatus().LastSync)
+}
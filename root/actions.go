@@ -33,6 +33,11 @@ import (
 type Actions struct {
 	Version general.VersionInfo
 	Conf    *cnf.Conf
+	Routes  Routes
+
+	// LastShutdownReport describes the previous run of the service
+	// (if a report is available)
+	LastShutdownReport *ShutdownReport
 }
 
 func (a *Actions) OnExit() {}
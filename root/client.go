@@ -0,0 +1,54 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package root
+
+import (
+	"masm/v3/general/clientgen"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// Client provides a source code of a typed client of the routes
+// available in the running instance. Supported languages are `go`
+// and `python`.
+func (a *Actions) Client(ctx *gin.Context) {
+	baseErrTpl := "failed to generate client: %w"
+	endpoints := make([]clientgen.Endpoint, len(a.Routes))
+	for i, route := range a.Routes {
+		endpoints[i] = clientgen.Endpoint{
+			Method:      route.Method,
+			Path:        route.Path,
+			Description: route.Description,
+			Request:     route.Request,
+			Response:    route.Response,
+		}
+	}
+	src, err := clientgen.Generate(
+		clientgen.Language(ctx.Param("language")), a.Version.Version, endpoints)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusNotFound)
+		return
+	}
+	ctx.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Write(src)
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/general/loglevel"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LogLevelArgs are arguments of the SetLogLevel action
+type LogLevelArgs struct {
+	// Level is a zerolog level name. An empty level along
+	// with a module removes the module's own level.
+	Level string `json:"level"`
+
+	// Module is optional, an empty value means the default level
+	Module string `json:"module"`
+}
+
+// LogLevels shows actual log levels
+func (a *Actions) LogLevels(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, loglevel.GetLevels())
+}
+
+// SetLogLevel changes the default log level or a level
+// of a module without restarting the service.
+func (a *Actions) SetLogLevel(ctx *gin.Context) {
+	baseErrTpl := "failed to set log level: %w"
+	var args LogLevelArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	var err error
+	if args.Level == "" && args.Module != "" {
+		err = loglevel.ResetLevel(args.Module)
+
+	} else {
+		var level zerolog.Level
+		level, err = zerolog.ParseLevel(args.Level)
+		if err == nil && level == zerolog.NoLevel {
+			err = fmt.Errorf("missing log level")
+		}
+		if err == nil {
+			err = loglevel.SetLevel(args.Module, level)
+		}
+	}
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, err), http.StatusBadRequest)
+		return
+	}
+	log.Warn().
+		Str("module", args.Module).
+		Str("level", args.Level).
+		Msg("log level changed")
+	uniresp.WriteJSONResponse(ctx.Writer, loglevel.GetLevels())
+}
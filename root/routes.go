@@ -0,0 +1,145 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package root
+
+import (
+	"context"
+	"errors"
+	"masm/v3/cnf"
+	"masm/v3/general/collections"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// RoleAdmin denotes operations which modify data or configuration
+	// and should be available only to administrators. Please note that
+	// MASM itself does not enforce roles - this is up to a proxy server.
+	RoleAdmin = "admin"
+)
+
+// Route is a declarative definition of a MASM HTTP route
+type Route struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Description string          `json:"description"`
+	Roles       []string        `json:"roles"`
+	Handler     gin.HandlerFunc `json:"-"`
+
+	// Feature is an optional module the route belongs to
+	// (see cnf.FeaturesConf)
+	Feature string `json:"-"`
+
+	// TimeoutClass is an optional class of the route's write timeout
+	// (see cnf.EndpointTimeoutsConf). By default, routes with the admin
+	// role are of the admin class and the other ones are interactive.
+	TimeoutClass string `json:"timeoutClass"`
+
+	// Request and Response are optional values of types the route
+	// reads (JSON body) and writes. They are used to generate typed
+	// clients (see Actions.Client).
+	Request  any `json:"-"`
+	Response any `json:"-"`
+}
+
+// Routes is a list of route definitions
+type Routes []Route
+
+// Enabled returns only the routes of enabled features
+func (r Routes) Enabled(isEnabled func(feature string) bool) Routes {
+	ans := make(Routes, 0, len(r))
+	for _, route := range r {
+		if isEnabled(route.Feature) {
+			ans = append(ans, route)
+		}
+	}
+	return ans
+}
+
+// EffectiveTimeoutClass returns the route's timeout class
+// (an explicit one or the one derived from the route's roles)
+func (r Route) EffectiveTimeoutClass() string {
+	if r.TimeoutClass != "" {
+		return r.TimeoutClass
+	}
+	if collections.SliceContains(r.Roles, RoleAdmin) {
+		return cnf.TimeoutClassAdmin
+	}
+	return cnf.TimeoutClassInteractive
+}
+
+// WithTimeouts returns the routes with handlers limited by write
+// timeouts of the routes' classes (see WithDeadline). Routes of classes
+// without a timeout are kept unchanged.
+func (r Routes) WithTimeouts(timeouts map[string]time.Duration) Routes {
+	ans := make(Routes, len(r))
+	for i, route := range r {
+		ans[i] = route
+		ans[i].TimeoutClass = route.EffectiveTimeoutClass()
+		if timeout, ok := timeouts[ans[i].TimeoutClass]; ok && timeout > 0 {
+			ans[i].Handler = WithDeadline(timeout, route.Handler)
+		}
+	}
+	return ans
+}
+
+// WithDeadline wraps a handler so both writing of its response and
+// its request context are limited by a timeout. The write deadline
+// replaces the server's one (see http.Server.WriteTimeout) so it can be
+// both shorter and longer.
+func WithDeadline(timeout time.Duration, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		deadline := time.Now().Add(timeout)
+		rc := http.NewResponseController(ctx.Writer)
+		if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Warn().Err(err).Str("path", ctx.FullPath()).Msg("failed to set write deadline")
+		}
+		reqCtx, cancel := context.WithDeadline(ctx.Request.Context(), deadline)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		handler(ctx)
+	}
+}
+
+// Register adds all the routes to a Gin engine
+func (r Routes) Register(engine *gin.Engine) {
+	for _, route := range r {
+		engine.Handle(route.Method, route.Path, route.Handler)
+	}
+}
+
+// RouteList provides a list of all the registered routes
+func (a *Actions) RouteList(ctx *gin.Context) {
+	ans := make(Routes, len(a.Routes))
+	for i, route := range a.Routes {
+		ans[i] = route
+		if ans[i].Roles == nil {
+			ans[i].Roles = []string{}
+		}
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[i].Path < ans[j].Path
+	})
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package root
+
+import (
+	"masm/v3/cnf"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveTimeoutClass(t *testing.T) {
+	assert.Equal(t, cnf.TimeoutClassInteractive, Route{}.EffectiveTimeoutClass())
+	assert.Equal(t, cnf.TimeoutClassAdmin, Route{Roles: []string{RoleAdmin}}.EffectiveTimeoutClass())
+	assert.Equal(
+		t,
+		cnf.TimeoutClassExport,
+		Route{Roles: []string{RoleAdmin}, TimeoutClass: cnf.TimeoutClassExport}.EffectiveTimeoutClass(),
+	)
+}
+
+func TestWithTimeouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var deadlines []time.Duration
+	handler := func(ctx *gin.Context) {
+		deadline, ok := ctx.Request.Context().Deadline()
+		if ok {
+			deadlines = append(deadlines, time.Until(deadline))
+		}
+		ctx.Status(http.StatusOK)
+	}
+	routes := Routes{
+		{Method: http.MethodGet, Path: "/a", Handler: handler},
+		{Method: http.MethodGet, Path: "/b", Handler: handler, TimeoutClass: cnf.TimeoutClassExport},
+		{Method: http.MethodGet, Path: "/c", Handler: handler, Roles: []string{RoleAdmin}},
+	}.WithTimeouts(map[string]time.Duration{
+		cnf.TimeoutClassInteractive: 10 * time.Second,
+		cnf.TimeoutClassExport:      300 * time.Second,
+	})
+	assert.Equal(t, cnf.TimeoutClassAdmin, routes[2].TimeoutClass)
+	engine := gin.New()
+	routes.Register(engine)
+	for _, path := range []string{"/a", "/b", "/c"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	// the admin class has no timeout configured
+	assert.Len(t, deadlines, 2)
+	assert.InDelta(t, 10*time.Second, deadlines[0], float64(time.Second))
+	assert.InDelta(t, 300*time.Second, deadlines[1], float64(time.Second))
+}
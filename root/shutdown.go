@@ -0,0 +1,121 @@
+// Copyright 2024 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2024 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"masm/v3/general"
+	"masm/v3/jobs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ShutdownReport describes the state of the service at the time
+// it was shut down. Once the service starts, a report with Clean
+// set to false is stored so in case the service crashes, the next
+// run can tell the previous one has not finished properly.
+type ShutdownReport struct {
+
+	// Clean is true in case the service has been shut down properly
+	Clean bool `json:"clean"`
+
+	Started  time.Time           `json:"started"`
+	Finished *time.Time          `json:"finished,omitempty"`
+	Signal   string              `json:"signal,omitempty"`
+	Version  general.VersionInfo `json:"version"`
+
+	// RunningJobs contains last known states of jobs running
+	// at the time of the shutdown
+	RunningJobs   []jobs.JobCheckpoint `json:"runningJobs"`
+	NumQueuedJobs int                  `json:"numQueuedJobs"`
+
+	// NumUnflushedUsageRecords is the number of liveattrs usage
+	// records which have not been stored to the database
+	NumUnflushedUsageRecords int `json:"numUnflushedUsageRecords"`
+
+	// Caches contains numbers of entries of individual caches
+	Caches map[string]int `json:"caches"`
+}
+
+// Log writes a summary of the report to the log
+func (r *ShutdownReport) Log() {
+	jobIDs := make([]string, len(r.RunningJobs))
+	for i, job := range r.RunningJobs {
+		jobIDs[i] = job.ID
+	}
+	evt := log.Info().
+		Str("signal", r.Signal).
+		Strs("runningJobs", jobIDs).
+		Int("numQueuedJobs", r.NumQueuedJobs).
+		Int("numUnflushedUsageRecords", r.NumUnflushedUsageRecords)
+	for name, size := range r.Caches {
+		evt = evt.Int("cache_"+name, size)
+	}
+	evt.Msg("shutdown report")
+}
+
+// Save stores the report to a file (via a temporary file
+// so an interrupted writing does not corrupt the previous report)
+func (r *ShutdownReport) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save shutdown report: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save shutdown report: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save shutdown report: %w", err)
+	}
+	return nil
+}
+
+// LoadShutdownReport loads a stored report. In case there is
+// no report, nil is returned.
+func LoadShutdownReport(path string) (*ShutdownReport, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load shutdown report: %w", err)
+	}
+	var ans ShutdownReport
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load shutdown report: %w", err)
+	}
+	return &ans, nil
+}
+
+// LastShutdown shows the report of the previous run of the service
+func (a *Actions) LastShutdown(ctx *gin.Context) {
+	if a.LastShutdownReport == nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError("no shutdown report available"), http.StatusNotFound)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, a.LastShutdownReport)
+}
@@ -11,8 +11,52 @@ CREATE TABLE `usage` (
     corpus_id varchar(127) NOT NULL,
 	structattr_name varchar(127) NOT NULL,
 	num_used int NOT NULL DEFAULT 1,
+	last_used datetime,
 	PRIMARY KEY (corpus_id, structattr_name)
 );
 
+CREATE TABLE ingested_verticals (
+    corpus_id varchar(127) NOT NULL,
+    grouped_name varchar(127) NOT NULL,
+    hash char(40) NOT NULL,
+    size bigint NOT NULL,
+    path varchar(255) NOT NULL,
+    ingested datetime NOT NULL,
+    num_ingestions int NOT NULL DEFAULT 1,
+    PRIMARY KEY (corpus_id, hash, size),
+    KEY (grouped_name),
+    KEY (hash, size)
+);
+
+CREATE TABLE corpus_notes (
+    id int NOT NULL AUTO_INCREMENT,
+    corpus_id varchar(127) NOT NULL,
+    text text NOT NULL,
+    author varchar(127),
+    created datetime NOT NULL,
+    updated datetime NOT NULL,
+    PRIMARY KEY (id),
+    KEY (corpus_id)
+);
+
+CREATE TABLE value_counts_snapshots (
+    id int NOT NULL AUTO_INCREMENT,
+    corpus_id varchar(127) NOT NULL,
+    job_id varchar(127) NOT NULL,
+    created datetime NOT NULL,
+    PRIMARY KEY (id),
+    KEY (corpus_id)
+);
+
+CREATE TABLE value_counts (
+    snapshot_id int NOT NULL,
+    attr varchar(127) NOT NULL,
+    value text NOT NULL,
+    num_items int NOT NULL,
+    poscount bigint NOT NULL,
+    KEY (snapshot_id),
+    FOREIGN KEY (snapshot_id) REFERENCES value_counts_snapshots(id)
+);
+
 -- individual data tables for live attributes and n-grams
 -- are created/dropped by MASM dynamically
@@ -10,9 +10,11 @@ try:
 except:
     sys.exit(3)
 
-class SolveData(TypedDict):
+class SolveData(TypedDict, total=False):
     A: List[List[float]]
     b: List[float]
+    integer: bool  # solve as an integer (binary) program
+    seed: int
 
 buff = ''
 for line in sys.stdin:
@@ -27,7 +29,8 @@ num_conditions = len(b)
 x_min = 0
 x_max = 1
 
-x = pulp.LpVariable.dicts('x', list(range(num_texts)), x_min, x_max)
+x = pulp.LpVariable.dicts(
+    'x', list(range(num_texts)), x_min, x_max, cat=pulp.LpBinary if data.get('integer') else pulp.LpContinuous)
 lp_prob = pulp.LpProblem('Minmax_Problem', pulp.LpMaximize)
 lp_prob += pulp.lpSum(x), 'Minimize_the_maximum'
 for i in range(num_conditions):
@@ -35,7 +38,8 @@ for i in range(num_conditions):
     condition = pulp.lpSum([A[i][j]*x[j] for j in range(num_texts)]) <= b[i]
     lp_prob += condition, label
 
-stat = lp_prob.solve(pulp.PULP_CBC_CMD(msg=0))
+seed = abs(data.get('seed', 0)) % 2147483647
+stat = lp_prob.solve(pulp.PULP_CBC_CMD(msg=0, options=[f'randomSeed {seed}', f'randomCbcSeed {seed}']))
 
 variables = [0] * len(x)
 for idx, lpvar in x.items():
@@ -39,38 +39,49 @@ func init() {
 }
 
 var messageKeyToIndex = map[string]int{
+	"Corpus: %s":                                     8,
+	"Failed jobs:":                                   11,
 	"Job ID: %s":                                     1,
 	"Job finished with error: %s":                    7,
 	"Job finished without errors":                    6,
 	"Job of type \"%s\" finished":                    0,
+	"Jobs finished between %s and %s":                10,
 	"Live attributes data extraction and generation": 3,
+	"MASM jobs digest: %d succeeded, %d failed":      9,
 	"N-grams and query suggestion data generation":   2,
+	"Successful jobs:":                               12,
 	"Testing and debugging empty job":                4,
 	"Unknown job":                                    5,
 }
 
-var csIndex = []uint32{ // 9 elements
+var csIndex = []uint32{ // 14 elements
 	0x00000000, 0x00000024, 0x00000035, 0x00000063,
 	0x0000008a, 0x000000b1, 0x000000c2, 0x000000dc,
-	0x000000fd,
-} // Size: 60 bytes
+	0x000000fd, 0x0000010b, 0x00000149, 0x0000016f,
+	0x00000185, 0x00000199,
+} // Size: 80 bytes
 
-const csData string = "" + // Size: 253 bytes
+const csData string = "" + // Size: 409 bytes
 	"\x02Úloha typu \x22%[1]s\x22 byla dokončena\x02ID úlohy: %[1]s\x02Genero" +
 	"vání n-gramů a dat pro našeptávač\x02vygenerování dat pro Live attribute" +
 	"s\x02Prázdný testovací a debugovací job\x02Neznámá úloha\x02Úloha skonči" +
-	"la bez chyb\x02Úloha skončila s chybou: %[1]s"
+	"la bez chyb\x02Úloha skončila s chybou: %[1]s\x02Korpus: %[1]s\x02Souhrn" +
+	" úloh MASM: %[1]d úspěšných, %[2]d neúspěšných\x02Úlohy dokončené mezi %" +
+	"[1]s a %[2]s\x02Neúspěšné úlohy:\x02Úspěšné úlohy:"
 
-var enIndex = []uint32{ // 9 elements
+var enIndex = []uint32{ // 14 elements
 	0x00000000, 0x0000001d, 0x0000002b, 0x00000058,
 	0x00000087, 0x000000a7, 0x000000b3, 0x000000cf,
-	0x000000ee,
-} // Size: 60 bytes
+	0x000000ee, 0x000000fc, 0x0000012c, 0x00000152,
+	0x0000015f, 0x00000170,
+} // Size: 80 bytes
 
-const enData string = "" + // Size: 238 bytes
+const enData string = "" + // Size: 368 bytes
 	"\x02Job of type \x22%[1]s\x22 finished\x02Job ID: %[1]s\x02N-grams and q" +
 	"uery suggestion data generation\x02Live attributes data extraction and g" +
 	"eneration\x02Testing and debugging empty job\x02Unknown job\x02Job finis" +
-	"hed without errors\x02Job finished with error: %[1]s"
+	"hed without errors\x02Job finished with error: %[1]s\x02Corpus: %[1]s" +
+	"\x02MASM jobs digest: %[1]d succeeded, %[2]d failed\x02Jobs finished bet" +
+	"ween %[1]s and %[2]s\x02Failed jobs:\x02Successful jobs:"
 
-	// Total table size 611 bytes (0KiB); checksum: 1EE06547
+	// Total table size 937 bytes (0KiB); checksum: 4E6B4D59